@@ -64,7 +64,10 @@ require (
 	stathat.com/c/consistent v1.0.0
 )
 
-require github.com/mozillazg/go-pinyin v0.20.0
+require (
+	github.com/mozillazg/go-pinyin v0.20.0
+	golang.org/x/net v0.19.0
+)
 
 require (
 	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
@@ -144,7 +147,6 @@ require (
 	go.opentelemetry.io/proto/otlp v1.0.0 // indirect
 	golang.org/x/arch v0.3.0 // indirect
 	golang.org/x/crypto v0.16.0 // indirect
-	golang.org/x/net v0.19.0 // indirect
 	golang.org/x/sync v0.5.0 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240102182953-50ed04b92917 // indirect
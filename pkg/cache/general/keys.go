@@ -43,6 +43,24 @@ var (
 	PlatKey = newGeneralKey(Plat, 6*time.Hour, [2]int{0, 30 * 60})
 	// ProjectKey is the  detail cache key
 	ProjectKey = newGeneralKey(Project, 6*time.Hour, [2]int{0, 30 * 60})
+	// ModelKey is the model detail cache key
+	ModelKey = newGeneralKey(Model, 6*time.Hour, [2]int{0, 30 * 60})
+	// ModelAttributeKey is the model attribute detail cache key
+	ModelAttributeKey = newGeneralKey(ModelAttribute, 6*time.Hour, [2]int{0, 30 * 60})
+	// ModelAttrGroupKey is the model attribute group detail cache key
+	ModelAttrGroupKey = newGeneralKey(ModelAttrGroup, 6*time.Hour, [2]int{0, 30 * 60})
+	// ModelUniqueKey is the model unique rule detail cache key
+	ModelUniqueKey = newGeneralKey(ModelUnique, 6*time.Hour, [2]int{0, 30 * 60})
+	// ModelAssociationKey is the model association detail cache key
+	ModelAssociationKey = newGeneralKey(ModelAssociation, 6*time.Hour, [2]int{0, 30 * 60})
+	// ServiceTemplateKey is the service template detail cache key
+	ServiceTemplateKey = newGeneralKey(ServiceTemplate, 6*time.Hour, [2]int{0, 30 * 60})
+	// SetTemplateKey is the set template detail cache key
+	SetTemplateKey = newGeneralKey(SetTemplate, 6*time.Hour, [2]int{0, 30 * 60})
+	// FieldTemplateKey is the field template detail cache key
+	FieldTemplateKey = newGeneralKey(FieldTemplate, 6*time.Hour, [2]int{0, 30 * 60})
+	// HostApplyRuleKey is the host apply rule detail cache key
+	HostApplyRuleKey = newGeneralKey(HostApplyRule, 6*time.Hour, [2]int{0, 30 * 60})
 	// ObjInstKey is the object instance detail cache key
 	ObjInstKey = NewKey(ObjectInstance, 6*time.Hour, [2]int{0, 30 * 60}, genIDKeyByID, genDetailKeyWithoutSubRes)
 	// MainlineInstKey is the mainline instance detail cache key
@@ -90,6 +108,15 @@ var cacheKeyMap = map[ResType]*Key{
 	BizSet:           BizSetKey,
 	Plat:             PlatKey,
 	Project:          ProjectKey,
+	Model:            ModelKey,
+	ModelAttribute:   ModelAttributeKey,
+	ModelAttrGroup:   ModelAttrGroupKey,
+	ModelUnique:      ModelUniqueKey,
+	ModelAssociation: ModelAssociationKey,
+	ServiceTemplate:  ServiceTemplateKey,
+	SetTemplate:      SetTemplateKey,
+	FieldTemplate:    FieldTemplateKey,
+	HostApplyRule:    HostApplyRuleKey,
 	ObjectInstance:   ObjInstKey,
 	MainlineInstance: MainlineInstKey,
 	InstAsst:         InstAsstKey,
@@ -35,6 +35,15 @@ var cursorTypeMap = map[general.ResType]watch.CursorType{
 	general.BizSet:           watch.BizSet,
 	general.Plat:             watch.Plat,
 	general.Project:          watch.Project,
+	general.Model:            watch.Model,
+	general.ModelAttribute:   watch.ModelAttribute,
+	general.ModelAttrGroup:   watch.ModelAttrGroup,
+	general.ModelUnique:      watch.ModelUnique,
+	general.ModelAssociation: watch.ModelAssociation,
+	general.ServiceTemplate:  watch.ServiceTemplate,
+	general.SetTemplate:      watch.SetTemplate,
+	general.FieldTemplate:    watch.FieldTemplate,
+	general.HostApplyRule:    watch.HostApplyRule,
 	general.ObjectInstance:   watch.ObjectBase,
 	general.MainlineInstance: watch.MainlineInstance,
 	general.InstAsst:         watch.InstAsst,
@@ -46,6 +46,24 @@ const (
 	Plat ResType = "plat"
 	// Project is the resource type for project cache
 	Project ResType = "project"
+	// Model is the resource type for model cache
+	Model ResType = "model"
+	// ModelAttribute is the resource type for model attribute cache
+	ModelAttribute ResType = "model_attribute"
+	// ModelAttrGroup is the resource type for model attribute group cache
+	ModelAttrGroup ResType = "model_attribute_group"
+	// ModelUnique is the resource type for model unique rule cache
+	ModelUnique ResType = "model_unique"
+	// ModelAssociation is the resource type for model association cache
+	ModelAssociation ResType = "model_association"
+	// ServiceTemplate is the resource type for service template cache
+	ServiceTemplate ResType = "service_template"
+	// SetTemplate is the resource type for set template cache
+	SetTemplate ResType = "set_template"
+	// FieldTemplate is the resource type for field template cache
+	FieldTemplate ResType = "field_template"
+	// HostApplyRule is the resource type for host apply rule cache
+	HostApplyRule ResType = "host_apply_rule"
 	// ObjectInstance is the resource type for common object instance cache, its sub resource specifies the object id
 	ObjectInstance ResType = "object_instance"
 	// MainlineInstance is the resource type for mainline instance cache, its sub resource specifies the object id
@@ -75,6 +93,15 @@ var SupportedResTypeMap = map[ResType]struct{}{
 	Plat:             {},
 	ObjectInstance:   {},
 	MainlineInstance: {},
+	Model:            {},
+	ModelAttribute:   {},
+	ModelAttrGroup:   {},
+	ModelUnique:      {},
+	ModelAssociation: {},
+	ServiceTemplate:  {},
+	SetTemplate:      {},
+	FieldTemplate:    {},
+	HostApplyRule:    {},
 }
 
 // ResTypeHasSubResMap is a map of supported resource type -> whether it has sub resource
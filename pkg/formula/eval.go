@@ -0,0 +1,347 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package formula
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayout is the layout that a time value is converted to, so that it can be compared with the time string
+const timeLayout = "2006-01-02 15:04:05"
+
+type evalContext struct {
+	formula  *Formula
+	resolver Resolver
+	// related caches the related instances of each object, so that they are only fetched once
+	related map[string][]map[string]interface{}
+}
+
+func (c *evalContext) getRelated(objID string) ([]map[string]interface{}, error) {
+	if insts, exists := c.related[objID]; exists {
+		return insts, nil
+	}
+
+	insts, err := c.resolver.Related(objID, c.formula.RelatedFields(objID))
+	if err != nil {
+		return nil, fmt.Errorf("get related %s instances failed, err: %v", objID, err)
+	}
+	c.related[objID] = insts
+	return insts, nil
+}
+
+type node interface {
+	eval(ctx *evalContext) (interface{}, error)
+}
+
+type literalNode struct {
+	val interface{}
+}
+
+func (n *literalNode) eval(*evalContext) (interface{}, error) {
+	return n.val, nil
+}
+
+type fieldNode struct {
+	field string
+}
+
+func (n *fieldNode) eval(ctx *evalContext) (interface{}, error) {
+	return normalize(ctx.resolver.Field(n.field)), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(ctx *evalContext) (interface{}, error) {
+	val, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "!":
+		return !truthy(val), nil
+	case "-":
+		if val == nil {
+			return nil, nil
+		}
+		num, ok := val.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator - can not be applied to %v", val)
+		}
+		return -num, nil
+	default:
+		return nil, fmt.Errorf("unknown unary operator %s", n.op)
+	}
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(ctx *evalContext) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// logical operators are short-circuited
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "+":
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		if leftIsStr || rightIsStr {
+			return toString(left) + toString(right), nil
+		}
+		return arithmetic(n.op, left, right)
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	// null is propagated by arithmetic operators
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s can not be applied to %v and %v", op, left, right)
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	default:
+		return nil, fmt.Errorf("unknown binary operator %s", op)
+	}
+}
+
+func compare(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("can not compare %v with %v", left, right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("can not compare %v with %v", left, right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, fmt.Errorf("can not compare %v with %v", left, right)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	switch l := left.(type) {
+	case float64, string, bool:
+		return l == right
+	default:
+		return toString(left) == toString(right)
+	}
+}
+
+// normalize converts the value to the types that the formula uses: nil, float64, string, bool and []interface{}
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil, float64, string, bool:
+		return v
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		num, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return num
+	case time.Time:
+		return v.Local().Format(timeLayout)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = normalize(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func truthy(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for idx, item := range v {
+			items[idx] = toString(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func toNumber(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case string:
+		num, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		return num, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
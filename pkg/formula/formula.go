@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package formula is a safe expression language used to define the computed attributes of a model.
+// It only supports literals, the instance's own fields, arithmetic/logical operators, a fixed set of
+// scalar functions and aggregate functions over the instance's related instances, there is no
+// assignment, loop or any other side effect.
+package formula
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength defines the maximum length of a formula expression
+	MaxLength = 1024
+	// MaxDepth defines the maximum nesting depth of a formula expression
+	MaxDepth = 20
+	// MaxNodes defines the maximum node count of a formula expression
+	MaxNodes = 200
+)
+
+// Resolver provides the data that a formula is evaluated against.
+type Resolver interface {
+	// Field returns the value of the instance's own field, returns nil if the field is not set.
+	Field(field string) interface{}
+	// Related returns the instances of object objID that are related to the instance,
+	// fields are the fields of the related instances that the formula needs.
+	Related(objID string, fields []string) ([]map[string]interface{}, error)
+}
+
+// Formula is a parsed formula expression
+type Formula struct {
+	expr string
+	root node
+	// fields is the instance's own fields that are referenced by the formula
+	fields map[string]struct{}
+	// related is the related object id to its referenced fields map
+	related map[string]map[string]struct{}
+}
+
+// Parse parse and validate the formula expression
+func Parse(expr string) (*Formula, error) {
+	if len(expr) == 0 {
+		return nil, fmt.Errorf("formula is empty")
+	}
+
+	if utf8.RuneCountInString(expr) > MaxLength {
+		return nil, fmt.Errorf("formula exceeds the maximum length %d", MaxLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Formula{
+		expr:    expr,
+		fields:  make(map[string]struct{}),
+		related: make(map[string]map[string]struct{}),
+	}
+
+	p := &parser{tokens: tokens, formula: f}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected token %s at position %d", t.val, t.pos)
+	}
+
+	f.root = root
+	return f, nil
+}
+
+// String returns the raw formula expression
+func (f *Formula) String() string {
+	return f.expr
+}
+
+// Fields returns the instance's own fields that are referenced by the formula
+func (f *Formula) Fields() []string {
+	return sortedKeys(f.fields)
+}
+
+// RelatedObjects returns the object ids whose instances are aggregated by the formula
+func (f *Formula) RelatedObjects() []string {
+	objIDs := make([]string, 0, len(f.related))
+	for objID := range f.related {
+		objIDs = append(objIDs, objID)
+	}
+	sort.Strings(objIDs)
+	return objIDs
+}
+
+// RelatedFields returns the fields of the related object that are referenced by the formula
+func (f *Formula) RelatedFields(objID string) []string {
+	return sortedKeys(f.related[objID])
+}
+
+// Eval evaluate the formula with the data provided by the resolver
+func (f *Formula) Eval(r Resolver) (interface{}, error) {
+	ctx := &evalContext{
+		formula:  f,
+		resolver: r,
+		related:  make(map[string][]map[string]interface{}),
+	}
+	return f.root.eval(ctx)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MapResolver is a resolver that uses a map as the instance's data and has no related instances.
+type MapResolver map[string]interface{}
+
+// Field returns the value of the field in the map
+func (m MapResolver) Field(field string) interface{} {
+	return m[field]
+}
+
+// Related always returns no instances
+func (m MapResolver) Related(string, []string) ([]map[string]interface{}, error) {
+	return make([]map[string]interface{}, 0), nil
+}
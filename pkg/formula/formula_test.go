@@ -0,0 +1,121 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package formula
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResolver struct {
+	data    map[string]interface{}
+	related map[string][]map[string]interface{}
+}
+
+func (r *testResolver) Field(field string) interface{} {
+	return r.data[field]
+}
+
+func (r *testResolver) Related(objID string, _ []string) ([]map[string]interface{}, error) {
+	return r.related[objID], nil
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse(`concat(bk_set_name, "-", first(biz.bk_biz_name)) + sum(host.bk_mem) * 2`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bk_set_name"}, f.Fields())
+	assert.Equal(t, []string{"biz", "host"}, f.RelatedObjects())
+	assert.Equal(t, []string{"bk_mem"}, f.RelatedFields("host"))
+
+	invalid := []string{
+		"",
+		"bk_cpu +",
+		"(bk_cpu",
+		"unknown(bk_cpu)",
+		"host.bk_mem",
+		"sum(host)",
+		"sum(1)",
+		"if(bk_cpu, 1)",
+		"bk_cpu == 1 == 2",
+		"'abc",
+		"bk_cpu # 1",
+		strings.Repeat("(", MaxDepth+1) + "1" + strings.Repeat(")", MaxDepth+1),
+	}
+	for _, expr := range invalid {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestEval(t *testing.T) {
+	r := &testResolver{
+		data: map[string]interface{}{
+			"bk_host_name": "host1",
+			"bk_cpu":       int64(8),
+			"bk_mem":       1024.5,
+			"bk_os_type":   "1",
+		},
+		related: map[string][]map[string]interface{}{
+			"host": {
+				{"bk_mem": int64(1024), "bk_host_innerip": "127.0.0.1"},
+				{"bk_mem": 2048, "bk_host_innerip": "127.0.0.2"},
+				{"bk_host_innerip": "127.0.0.3"},
+			},
+			"set": {{"bk_set_env": "3"}},
+		},
+	}
+
+	cases := []struct {
+		expr   string
+		expect interface{}
+	}{
+		{expr: `bk_cpu * 2 + 1`, expect: float64(17)},
+		{expr: `bk_host_name + "-" + bk_cpu`, expect: "host1-8"},
+		{expr: `concat(upper(bk_host_name), ":", bk_os_type)`, expect: "HOST1:1"},
+		{expr: `if(bk_os_type == "1", "linux", "windows")`, expect: "linux"},
+		{expr: `bk_cpu >= 8 && !(bk_mem < 1024)`, expect: true},
+		{expr: `not_exist_field + 1`, expect: nil},
+		{expr: `coalesce(not_exist_field, bk_host_name)`, expect: "host1"},
+		{expr: `round(bk_mem / 3, 2)`, expect: 341.5},
+		{expr: `sum(host.bk_mem)`, expect: float64(3072)},
+		{expr: `avg(host.bk_mem)`, expect: float64(1536)},
+		{expr: `max(host.bk_mem) - min(host.bk_mem)`, expect: float64(1024)},
+		{expr: `count(host)`, expect: float64(3)},
+		{expr: `count(host.bk_mem)`, expect: float64(2)},
+		{expr: `join(host.bk_host_innerip, ";")`, expect: "127.0.0.1;127.0.0.2;127.0.0.3"},
+		{expr: `first(set.bk_set_env)`, expect: "3"},
+		{expr: `first(module.bk_module_name)`, expect: nil},
+	}
+
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		val, err := f.Eval(r)
+		assert.NoError(t, err, c.expr)
+		assert.Equal(t, c.expect, val, c.expr)
+	}
+
+	f, err := Parse(`bk_cpu / 0`)
+	assert.NoError(t, err)
+	_, err = f.Eval(r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package formula
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	aggSum   = "sum"
+	aggAvg   = "avg"
+	aggMin   = "min"
+	aggMax   = "max"
+	aggCount = "count"
+	aggFirst = "first"
+	aggJoin  = "join"
+)
+
+// aggregateFuncs are the functions that aggregate the field values of the related instances
+var aggregateFuncs = map[string]struct{}{
+	aggSum:   {},
+	aggAvg:   {},
+	aggMin:   {},
+	aggMax:   {},
+	aggCount: {},
+	aggFirst: {},
+	aggJoin:  {},
+}
+
+// scalarFunc defines a function that is evaluated with its arguments, maxArgs < 0 means no limit.
+type scalarFunc struct {
+	minArgs int
+	maxArgs int
+	// lazy function evaluates the argument nodes by itself
+	lazy func(ctx *evalContext, args []node) (interface{}, error)
+	call func(args []interface{}) (interface{}, error)
+}
+
+var scalarFuncs = map[string]*scalarFunc{
+	"concat": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
+		}
+		return sb.String(), nil
+	}},
+	"if": {minArgs: 3, maxArgs: 3, lazy: func(ctx *evalContext, args []node) (interface{}, error) {
+		cond, err := args[0].eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return args[1].eval(ctx)
+		}
+		return args[2].eval(ctx)
+	}},
+	"coalesce": {minArgs: 1, maxArgs: -1, lazy: func(ctx *evalContext, args []node) (interface{}, error) {
+		for _, arg := range args {
+			val, err := arg.eval(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if val != nil && val != "" {
+				return val, nil
+			}
+		}
+		return nil, nil
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(args[0])), nil
+	}},
+	"lower": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(toString(args[0])), nil
+	}},
+	"len": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case nil:
+			return float64(0), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		default:
+			return float64(utf8.RuneCountInString(toString(v))), nil
+		}
+	}},
+	"number": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		num, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("can not convert %v to number", args[0])
+		}
+		return num, nil
+	}},
+	"string": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return toString(args[0]), nil
+	}},
+	"abs": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return numericFunc(args[0], math.Abs)
+	}},
+	"round": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		precision := float64(0)
+		if len(args) == 2 {
+			p, ok := args[1].(float64)
+			if !ok || p < 0 || p > 10 {
+				return nil, fmt.Errorf("round precision %v is invalid", args[1])
+			}
+			precision = math.Floor(p)
+		}
+		scale := math.Pow(10, precision)
+		return numericFunc(args[0], func(v float64) float64 { return math.Round(v*scale) / scale })
+	}},
+}
+
+func numericFunc(val interface{}, fn func(float64) float64) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+	num, ok := val.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", val)
+	}
+	return fn(num), nil
+}
+
+type callNode struct {
+	name string
+	fn   *scalarFunc
+	args []node
+}
+
+func (n *callNode) eval(ctx *evalContext) (interface{}, error) {
+	if n.fn.lazy != nil {
+		return n.fn.lazy(ctx, n.args)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for idx, arg := range n.args {
+		val, err := arg.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[idx] = val
+	}
+
+	val, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("function %s failed, err: %v", n.name, err)
+	}
+	return val, nil
+}
+
+type aggregateNode struct {
+	name  string
+	objID string
+	field string
+	sep   string
+}
+
+func (n *aggregateNode) eval(ctx *evalContext) (interface{}, error) {
+	insts, err := ctx.getRelated(n.objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.name == aggCount && n.field == "" {
+		return float64(len(insts)), nil
+	}
+
+	values := make([]interface{}, 0, len(insts))
+	for _, inst := range insts {
+		val := normalize(inst[n.field])
+		if val == nil {
+			continue
+		}
+		values = append(values, val)
+	}
+
+	switch n.name {
+	case aggCount:
+		return float64(len(values)), nil
+	case aggFirst:
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values[0], nil
+	case aggJoin:
+		items := make([]string, len(values))
+		for idx, val := range values {
+			items[idx] = toString(val)
+		}
+		return strings.Join(items, n.sep), nil
+	}
+
+	nums := make([]float64, len(values))
+	for idx, val := range values {
+		num, ok := toNumber(val)
+		if !ok {
+			return nil, fmt.Errorf("function %s got non-numeric value %v of %s.%s", n.name, val, n.objID, n.field)
+		}
+		nums[idx] = num
+	}
+
+	switch n.name {
+	case aggSum:
+		sum := float64(0)
+		for _, num := range nums {
+			sum += num
+		}
+		return sum, nil
+	case aggAvg:
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		sum := float64(0)
+		for _, num := range nums {
+			sum += num
+		}
+		return sum / float64(len(nums)), nil
+	case aggMin, aggMax:
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		result := nums[0]
+		for _, num := range nums[1:] {
+			if (n.name == aggMin && num < result) || (n.name == aggMax && num > result) {
+				result = num
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unknown aggregate function %s", n.name)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+// operators supported by the formula, longer operators must be placed before the shorter ones with the same prefix.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!"}
+
+// tokenize split the formula expression into tokens
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expr)
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, val: "(", pos: pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, val: ")", pos: pos})
+			pos++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, val: ",", pos: pos})
+			pos++
+		case r == '"' || r == '\'':
+			str, next, err := readString(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, val: str, pos: pos})
+			pos = next
+		case unicode.IsDigit(r):
+			start := pos
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, val: string(runes[start:pos]), pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := pos
+			for pos < len(runes) && isIdentRune(runes[pos]) {
+				pos++
+			}
+			ident := string(runes[start:pos])
+			if strings.HasPrefix(ident, ".") || strings.HasSuffix(ident, ".") || strings.Contains(ident, "..") {
+				return nil, fmt.Errorf("invalid identifier %s at position %d", ident, start)
+			}
+			tokens = append(tokens, token{kind: tokenIdent, val: ident, pos: start})
+		default:
+			op := matchOperator(runes[pos:])
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, val: op, pos: pos})
+			pos += len([]rune(op))
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func matchOperator(runes []rune) string {
+	for _, op := range operators {
+		if strings.HasPrefix(string(runes), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// readString read a quoted string starting at pos, returns the unquoted string and the position after it.
+func readString(runes []rune, pos int) (string, int, error) {
+	quote := runes[pos]
+	var sb strings.Builder
+	for i := pos + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 >= len(runes) {
+				return "", 0, fmt.Errorf("unterminated string at position %d", pos)
+			}
+			i++
+			sb.WriteRune(runes[i])
+		case quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", pos)
+}
@@ -0,0 +1,284 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parser is a recursive descent parser for the formula expression, the grammar is:
+//
+//	expr    := or
+//	or      := and ("||" and)*
+//	and     := compare ("&&" compare)*
+//	compare := add (("==" | "!=" | "<" | "<=" | ">" | ">=") add)?
+//	add     := mul (("+" | "-") mul)*
+//	mul     := unary (("*" | "/" | "%") unary)*
+//	unary   := ("!" | "-") unary | primary
+//	primary := number | string | "true" | "false" | "null" | field | call | "(" expr ")"
+//	call    := ident "(" (expr ("," expr)*)? ")"
+type parser struct {
+	tokens  []token
+	pos     int
+	depth   int
+	nodeCnt int
+	formula *Formula
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.val == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) newNode() error {
+	p.nodeCnt++
+	if p.nodeCnt > MaxNodes {
+		return fmt.Errorf("formula exceeds the maximum node count %d", MaxNodes)
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, fmt.Errorf("formula exceeds the maximum depth %d", MaxDepth)
+	}
+	return p.parseBinary(0)
+}
+
+// binaryLevels defines the binary operators from the lowest precedence to the highest one.
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level >= len(binaryLevels) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(binaryLevels[level]...) {
+		op := p.next().val
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.newNode(); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+
+		// comparison operators are not associative
+		if level == 2 {
+			break
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!", "-") {
+		op := p.next().val
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.newNode(); err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if err := p.newNode(); err != nil {
+		return nil, err
+	}
+
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		num, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t.val, t.pos)
+		}
+		return &literalNode{val: num}, nil
+
+	case tokenString:
+		return &literalNode{val: t.val}, nil
+
+	case tokenLParen:
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenRParen {
+			return nil, fmt.Errorf("missing ')' for '(' at position %d", t.pos)
+		}
+		return expr, nil
+
+	case tokenIdent:
+		switch t.val {
+		case "true":
+			return &literalNode{val: true}, nil
+		case "false":
+			return &literalNode{val: false}, nil
+		case "null":
+			return &literalNode{val: nil}, nil
+		}
+
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+
+		if strings.Contains(t.val, ".") {
+			return nil, fmt.Errorf("related field %s at position %d can only be used in aggregate functions",
+				t.val, t.pos)
+		}
+		p.formula.fields[t.val] = struct{}{}
+		return &fieldNode{field: t.val}, nil
+
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of formula")
+
+	default:
+		return nil, fmt.Errorf("unexpected token %s at position %d", t.val, t.pos)
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	// skip the left paren
+	p.next()
+
+	if _, isAggregate := aggregateFuncs[name.val]; isAggregate {
+		return p.parseAggregate(name)
+	}
+
+	fn, exists := scalarFuncs[name.val]
+	if !exists {
+		return nil, fmt.Errorf("unknown function %s at position %d", name.val, name.pos)
+	}
+
+	args := make([]node, 0)
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.next().kind != tokenRParen {
+		return nil, fmt.Errorf("missing ')' for function %s at position %d", name.val, name.pos)
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("invalid argument count %d for function %s", len(args), name.val)
+	}
+
+	return &callNode{name: name.val, fn: fn, args: args}, nil
+}
+
+// parseAggregate parse aggregate function call like sum(host.bk_mem), count(host) and join(host.bk_host_name, ",")
+func (p *parser) parseAggregate(name token) (node, error) {
+	ref := p.next()
+	if ref.kind != tokenIdent {
+		return nil, fmt.Errorf("function %s at position %d needs a related object field reference", name.val,
+			name.pos)
+	}
+
+	agg := &aggregateNode{name: name.val}
+	idx := strings.Index(ref.val, ".")
+	if idx < 0 {
+		if name.val != aggCount {
+			return nil, fmt.Errorf("function %s at position %d needs a field reference like obj.field", name.val,
+				name.pos)
+		}
+		agg.objID = ref.val
+	} else {
+		agg.objID, agg.field = ref.val[:idx], ref.val[idx+1:]
+		if strings.Contains(agg.field, ".") {
+			return nil, fmt.Errorf("invalid related field reference %s at position %d", ref.val, ref.pos)
+		}
+	}
+
+	if name.val == aggJoin && p.peek().kind == tokenComma {
+		p.next()
+		sep := p.next()
+		if sep.kind != tokenString {
+			return nil, fmt.Errorf("separator of function join at position %d must be a string", sep.pos)
+		}
+		agg.sep = sep.val
+	} else if name.val == aggJoin {
+		agg.sep = ","
+	}
+
+	if p.next().kind != tokenRParen {
+		return nil, fmt.Errorf("missing ')' for function %s at position %d", name.val, name.pos)
+	}
+
+	fields, exists := p.formula.related[agg.objID]
+	if !exists {
+		fields = make(map[string]struct{})
+		p.formula.related[agg.objID] = fields
+	}
+	if agg.field != "" {
+		fields[agg.field] = struct{}{}
+	}
+	return agg, nil
+}
@@ -355,6 +355,12 @@ operationServer:
   # 禁用运营统计数据统计功能，默认false
   disableOperationStatistic: false
 
+# proc_server专属配置
+procServer:
+  processPortConflict:
+    # 通过服务模板创建或同步服务实例时，如果进程绑定信息存在端口冲突是否阻止操作，默认false，仅记录日志
+    blockTemplateSync: false
+
 #auth_server专属配置
 authServer:
   #蓝鲸权限中心地址,可配置多个,用,(逗号)分割
@@ -409,6 +415,13 @@ datacollection:
     rateLimiter:
       qps: 40
       burst: 100
+    # 主机快照配置漂移告警，driftAlert.rules 为漂移规则，多个规则以逗号分隔，格式为 "快照字段:声明字段[:容忍百分比]"，如 "bk_mem:mem_quota:5"
+    # 表示快照上报的 bk_mem 与 cmdb 中声明的 mem_quota 相差超过5%时记录漂移历史，快照字段必须是快照管理的字段，声明字段不能是快照管理的字段
+    # driftAlert.notice.enabled 为是否通过bk-notice将漂移告警发送给主机的主备负责人，开启时需要配置apiGW
+    driftAlert:
+      rules:
+      notice:
+        enabled: false
 
 # 监控配置， monitor配置项必须存在
 monitor:
@@ -430,6 +430,15 @@ var resourceActionMap = map[meta.ResourceType]map[meta.Action]ActionID{
 		meta.WatchKubeWorkload:     WatchKubeWorkloadEvent,
 		meta.WatchKubePod:          WatchKubePodEvent,
 		meta.WatchProject:          WatchProjectEvent,
+		meta.WatchModel:            WatchModelEvent,
+		meta.WatchModelAttribute:   WatchModelEvent,
+		meta.WatchModelAttrGroup:   WatchModelEvent,
+		meta.WatchModelUnique:      WatchModelEvent,
+		meta.WatchModelAssociation: WatchModelEvent,
+		meta.WatchServiceTemplate:  WatchTemplateEvent,
+		meta.WatchSetTemplate:      WatchTemplateEvent,
+		meta.WatchFieldTemplate:    WatchTemplateEvent,
+		meta.WatchHostApplyRule:    WatchHostApplyRuleEvent,
 	},
 	meta.UserCustom: {
 		meta.Find:   Skip,
@@ -427,6 +427,15 @@ func genResourceManageActionGroups() []ActionGroup {
 						{
 							ID: WatchProjectEvent,
 						},
+						{
+							ID: WatchModelEvent,
+						},
+						{
+							ID: WatchTemplateEvent,
+						},
+						{
+							ID: WatchHostApplyRuleEvent,
+						},
 					},
 				},
 				{
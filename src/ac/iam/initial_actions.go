@@ -133,6 +133,9 @@ var ActionIDNameMap = map[ActionID]string{
 	WatchKubeWorkloadEvent:              "容器工作负载事件监听",
 	WatchKubePodEvent:                   "容器Pod事件监听",
 	WatchProjectEvent:                   "项目事件监听",
+	WatchModelEvent:                     "模型事件监听",
+	WatchTemplateEvent:                  "模板事件监听",
+	WatchHostApplyRuleEvent:             "主机属性自动应用事件监听",
 	GlobalSettings:                      "全局设置",
 	ManageHostAgentID:                   "主机AgentID管理",
 	CreateContainerCluster:              "容器集群新建",
@@ -1406,6 +1409,30 @@ func genEventWatchActions() []ResourceAction {
 		Version: 1,
 	})
 
+	actions = append(actions, ResourceAction{
+		ID:      WatchModelEvent,
+		Name:    ActionIDNameMap[WatchModelEvent],
+		NameEn:  "Model Event Listen",
+		Type:    View,
+		Version: 1,
+	})
+
+	actions = append(actions, ResourceAction{
+		ID:      WatchTemplateEvent,
+		Name:    ActionIDNameMap[WatchTemplateEvent],
+		NameEn:  "Template Event Listen",
+		Type:    View,
+		Version: 1,
+	})
+
+	actions = append(actions, ResourceAction{
+		ID:      WatchHostApplyRuleEvent,
+		Name:    ActionIDNameMap[WatchHostApplyRuleEvent],
+		NameEn:  "Host Apply Rule Event Listen",
+		Type:    View,
+		Version: 1,
+	})
+
 	modelSelection := []RelatedInstanceSelection{{
 		SystemID: SystemIDCMDB,
 		ID:       SysModelEventSelection,
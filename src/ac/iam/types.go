@@ -519,6 +519,13 @@ const (
 	WatchPlatEvent ActionID = "watch_plat_event"
 	// WatchProjectEvent watch project event action id
 	WatchProjectEvent ActionID = "watch_project_event"
+	// WatchModelEvent watch model metadata event action id, including model, attribute, attribute group, unique rule
+	// and model association events
+	WatchModelEvent ActionID = "watch_model_event"
+	// WatchTemplateEvent watch template event action id, including service, set and field template events
+	WatchTemplateEvent ActionID = "watch_template_event"
+	// WatchHostApplyRuleEvent watch host apply rule event action id
+	WatchHostApplyRuleEvent ActionID = "watch_host_apply_rule_event"
 
 	// watch kube related event actions
 
@@ -195,6 +195,27 @@ const (
 	// WatchProject watch project event cc action
 	WatchProject Action = "project"
 
+	// model metadata, template and host apply rule event watch cc actions
+
+	// WatchModel watch model event cc action
+	WatchModel Action = "model"
+	// WatchModelAttribute watch model attribute event cc action
+	WatchModelAttribute Action = "model_attribute"
+	// WatchModelAttrGroup watch model attribute group event cc action
+	WatchModelAttrGroup Action = "model_attribute_group"
+	// WatchModelUnique watch model unique rule event cc action
+	WatchModelUnique Action = "model_unique"
+	// WatchModelAssociation watch model association event cc action
+	WatchModelAssociation Action = "model_association"
+	// WatchServiceTemplate watch service template event cc action
+	WatchServiceTemplate Action = "service_template"
+	// WatchSetTemplate watch set template event cc action
+	WatchSetTemplate Action = "set_template"
+	// WatchFieldTemplate watch field template event cc action
+	WatchFieldTemplate Action = "field_template"
+	// WatchHostApplyRule watch host apply rule event cc action
+	WatchHostApplyRule Action = "host_apply_rule"
+
 	// kube related event watch cc actions
 
 	// WatchKubeCluster watch kube cluster event cc action
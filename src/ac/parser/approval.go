@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/ac/meta"
+)
+
+func (ps *parseStream) approvalRelated() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	ps.approvalPolicy().changeRequest()
+
+	return ps
+}
+
+var (
+	updateApprovalPolicyRegexp = regexp.MustCompile(`^/api/v3/update/approval_policy/[0-9]+/?$`)
+	deleteApprovalPolicyRegexp = regexp.MustCompile(`^/api/v3/delete/approval_policy/[0-9]+/?$`)
+	findChangeRequestRegexp    = regexp.MustCompile(`^/api/v3/find/change_request/[0-9]+/?$`)
+	decideChangeRequestRegexp  = regexp.MustCompile(`^/api/v3/update/change_request/[0-9]+/(approve|reject|cancel)/?$`)
+)
+
+// approvalPolicyConfigs approval policies decide which operations need to be approved, so they are managed as the
+// global config of the platform
+var approvalPolicyConfigs = []AuthConfig{
+	{
+		Name:           "createApprovalPolicy",
+		Description:    "创建变更审批策略",
+		Pattern:        "/api/v3/create/approval_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateApprovalPolicy",
+		Description:    "更新变更审批策略",
+		Regex:          updateApprovalPolicyRegexp,
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteApprovalPolicy",
+		Description:    "删除变更审批策略",
+		Regex:          deleteApprovalPolicyRegexp,
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findApprovalPolicy",
+		Description:    "查询变更审批策略",
+		Pattern:        "/api/v3/findmany/approval_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	},
+}
+
+func (ps *parseStream) approvalPolicy() *parseStream {
+	return ParseStreamWithFramework(ps, approvalPolicyConfigs)
+}
+
+// changeRequestConfigs the approvers of change requests are checked by task server, and the change request is
+// executed with the permission of the requester, so these apis skip the authorization.
+var changeRequestConfigs = []AuthConfig{
+	{
+		Name:           "findManyChangeRequest",
+		Description:    "查询变更请求列表",
+		Pattern:        "/api/v3/findmany/change_request",
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "findChangeRequest",
+		Description:    "查询变更请求详情",
+		Regex:          findChangeRequestRegexp,
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "decideChangeRequest",
+		Description:    "审批或撤销变更请求",
+		Regex:          decideChangeRequestRegexp,
+		HTTPMethod:     http.MethodPut,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
+func (ps *parseStream) changeRequest() *parseStream {
+	return ParseStreamWithFramework(ps, changeRequestConfigs)
+}
@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/ac/meta"
+)
+
+var (
+	updateAttributePolicyRegexp = regexp.MustCompile(`^/api/v3/update/attribute_policy/[0-9]+/?$`)
+	deleteAttributePolicyRegexp = regexp.MustCompile(`^/api/v3/delete/attribute_policy/[0-9]+/?$`)
+)
+
+// attributePolicyConfigs attribute policies change the attribute values of all the matched instances of a model
+// across businesses, so they are managed as the global config of the platform like the approval policies
+var attributePolicyConfigs = []AuthConfig{
+	{
+		Name:           "createAttributePolicy",
+		Description:    "创建属性策略",
+		Pattern:        "/api/v3/create/attribute_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateAttributePolicy",
+		Description:    "更新属性策略",
+		Regex:          updateAttributePolicyRegexp,
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteAttributePolicy",
+		Description:    "删除属性策略",
+		Regex:          deleteAttributePolicyRegexp,
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findAttributePolicy",
+		Description:    "查询属性策略",
+		Pattern:        "/api/v3/findmany/attribute_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "previewAttributePolicy",
+		Description:    "预览属性策略",
+		Pattern:        "/api/v3/preview/attribute_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "applyAttributePolicy",
+		Description:    "应用属性策略",
+		Pattern:        "/api/v3/apply/attribute_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) attributePolicy() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	return ParseStreamWithFramework(ps, attributePolicyConfigs)
+}
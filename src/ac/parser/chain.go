@@ -104,6 +104,13 @@ func (ps *parseStream) Parse() (*meta.AuthAttribute, error) {
 		eventRelated().
 		cloudRelated().
 		kubeRelated().
+		approvalRelated().
+		attributePolicy().
+		hostLifecycle().
+		hostSnapHistory().
+		hostLease().
+		syncRollout().
+		setTemplateSyncPolicy().
 		// finalizer must be at the end of the check chains.
 		finalizer()
 
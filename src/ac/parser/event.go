@@ -33,12 +33,15 @@ func (ps *parseStream) eventRelated() *parseStream {
 	ps.watch().
 		syncHostIdentifier().
 		pushHostIdentifier().
-		findHostIdentifierPushResult()
+		findHostIdentifierPushResult().
+		subscription().
+		notificationRule()
 	return ps
 }
 
 var (
 	watchResourceRegexp = regexp.MustCompile(`^/api/v3/event/watch/resource/\S+/?$`)
+	watchStreamRegexp   = regexp.MustCompile(`^/api/v3/event/watch/stream/resource/\S+/?$`)
 )
 
 func (ps *parseStream) watch() *parseStream {
@@ -109,6 +112,18 @@ func (ps *parseStream) watch() *parseStream {
 		return ps
 	}
 
+	// watch resource stream, the options may be in the query parameters, so it is authorized by event server.
+	if ps.hitRegexp(watchStreamRegexp, http.MethodGet) || ps.hitRegexp(watchStreamRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
 
@@ -174,3 +189,89 @@ func (ps *parseStream) findHostIdentifierPushResult() *parseStream {
 
 	return ps
 }
+
+const (
+	createSubscriptionPattern = "/api/v3/event/create/subscription"
+	findSubscriptionPattern   = "/api/v3/event/findmany/subscription"
+)
+
+var (
+	updateSubscriptionRegexp  = regexp.MustCompile(`^/api/v3/event/update/subscription/[0-9]+/?$`)
+	deleteSubscriptionRegexp  = regexp.MustCompile(`^/api/v3/event/delete/subscription/[0-9]+/?$`)
+	pauseSubscriptionRegexp   = regexp.MustCompile(`^/api/v3/event/pause/subscription/[0-9]+/?$`)
+	resumeSubscriptionRegexp  = regexp.MustCompile(`^/api/v3/event/resume/subscription/[0-9]+/?$`)
+	replaySubscriptionRegexp  = regexp.MustCompile(`^/api/v3/event/replay/subscription/[0-9]+/?$`)
+	findDeliveryHistoryRegexp = regexp.MustCompile(`^/api/v3/event/findmany/subscription/[0-9]+/delivery_history/?$`)
+	findDeadLetterRegexp      = regexp.MustCompile(`^/api/v3/event/findmany/subscription/[0-9]+/dead_letter/?$`)
+)
+
+// subscription webhook subscription apis are authorized by event server with the watch permission of the
+// subscription resource, because the resource is stored in the subscription.
+func (ps *parseStream) subscription() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createSubscriptionPattern, http.MethodPost) ||
+		ps.hitPattern(findSubscriptionPattern, http.MethodPost) ||
+		ps.hitRegexp(updateSubscriptionRegexp, http.MethodPut) ||
+		ps.hitRegexp(deleteSubscriptionRegexp, http.MethodDelete) ||
+		ps.hitRegexp(pauseSubscriptionRegexp, http.MethodPut) ||
+		ps.hitRegexp(resumeSubscriptionRegexp, http.MethodPut) ||
+		ps.hitRegexp(replaySubscriptionRegexp, http.MethodPut) ||
+		ps.hitRegexp(findDeliveryHistoryRegexp, http.MethodPost) ||
+		ps.hitRegexp(findDeadLetterRegexp, http.MethodPost) {
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	createNotificationRulePattern = "/api/v3/event/create/notification_rule"
+	findNotificationRulePattern   = "/api/v3/event/findmany/notification_rule"
+)
+
+var (
+	updateNotificationRuleRegexp  = regexp.MustCompile(`^/api/v3/event/update/notification_rule/[0-9]+/?$`)
+	deleteNotificationRuleRegexp  = regexp.MustCompile(`^/api/v3/event/delete/notification_rule/[0-9]+/?$`)
+	enableNotificationRuleRegexp  = regexp.MustCompile(`^/api/v3/event/enable/notification_rule/[0-9]+/?$`)
+	disableNotificationRuleRegexp = regexp.MustCompile(`^/api/v3/event/disable/notification_rule/[0-9]+/?$`)
+	findNotificationHistoryRegexp = regexp.MustCompile(`^/api/v3/event/findmany/notification_rule/[0-9]+/history/?$`)
+)
+
+// notificationRule notification rule apis are authorized by event server with the watch permission of the rule
+// resource, same as the webhook subscription apis.
+func (ps *parseStream) notificationRule() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createNotificationRulePattern, http.MethodPost) ||
+		ps.hitPattern(findNotificationRulePattern, http.MethodPost) ||
+		ps.hitRegexp(updateNotificationRuleRegexp, http.MethodPut) ||
+		ps.hitRegexp(deleteNotificationRuleRegexp, http.MethodDelete) ||
+		ps.hitRegexp(enableNotificationRuleRegexp, http.MethodPut) ||
+		ps.hitRegexp(disableNotificationRuleRegexp, http.MethodPut) ||
+		ps.hitRegexp(findNotificationHistoryRegexp, http.MethodPost) {
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
@@ -349,6 +349,8 @@ const (
 	lockHostPattern                       = "/api/v3/host/lock"
 	unLockHostPattern                     = "/api/v3/host/lock"
 	queryHostLockPattern                  = "/api/v3/host/lock/search"
+	listHostLockPattern                   = "/api/v3/host/lock/list"
+	forceUnlockHostPattern                = "/api/v3/host/lock/force"
 
 	// findHostsWithBizPattern only for ui.
 	findHostsWithBizPattern = "/api/v3/findmany/hosts/search/with_biz"
@@ -531,6 +533,31 @@ func (ps *parseStream) host() *parseStream {
 		return ps
 	}
 
+	// the host locks of all the users can only be listed and force released by the administrators
+	if ps.hitPattern(listHostLockPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ConfigAdmin,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitPattern(forceUnlockHostPattern, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ConfigAdmin,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
 	// delete hosts batch operation.
 	if ps.hitPattern(deleteHostBatchPattern, http.MethodDelete) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
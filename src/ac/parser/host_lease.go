@@ -0,0 +1,144 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"configcenter/src/ac/meta"
+)
+
+var createHostLeaseRegexp = regexp.MustCompile(`^/api/v3/create/host_lease/bk_biz_id/([0-9]+)/?$`)
+
+// hostLeaseConfigs extending and returning the leased hosts transfer hosts in the business, so they are authorized
+// as the host transfer operations of the business
+var hostLeaseConfigs = []AuthConfig{
+	{
+		Name:           "extendHostLease",
+		Description:    "续期主机租约",
+		Regex:          regexp.MustCompile(`^/api/v3/update/host_lease/[0-9]+/extend/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       7,
+		ResourceType:   meta.ProcessServiceInstance,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "returnHostLease",
+		Description:    "归还主机租约",
+		Regex:          regexp.MustCompile(`^/api/v3/update/host_lease/[0-9]+/return/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       7,
+		ResourceType:   meta.ProcessServiceInstance,
+		ResourceAction: meta.Update,
+	}, {
+		Name:             "findHostLease",
+		Description:      "查询主机租约",
+		Regex:            regexp.MustCompile(`^/api/v3/findmany/host_lease/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      BizIDFromURLGetter,
+		BizIndex:         5,
+		ResourceType:     meta.Business,
+		ResourceAction:   meta.ViewBusinessResource,
+		InstanceIDGetter: hostLeaseBizIDGetter,
+	}, {
+		Name:             "findHostLeaseHistory",
+		Description:      "查询主机租约历史",
+		Regex:            regexp.MustCompile(`^/api/v3/findmany/host_lease/history/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      BizIDFromURLGetter,
+		BizIndex:         6,
+		ResourceType:     meta.Business,
+		ResourceAction:   meta.ViewBusinessResource,
+		InstanceIDGetter: hostLeaseBizIDGetter,
+	},
+}
+
+func (ps *parseStream) hostLease() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// allocating the leased hosts moves the hosts in the resource directory to the business, so it is authorized
+	// as moving resource pool hosts to the business
+	if ps.hitRegexp(createHostLeaseRegexp, http.MethodPost) {
+		bizIDs, err := hostLeaseBizIDGetter(ps.RequestCtx, createHostLeaseRegexp)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		bizID := bizIDs[0]
+
+		opt := new(struct {
+			HostIDs []int64 `json:"bk_host_ids"`
+		})
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		if err := json.Unmarshal(body, opt); err != nil {
+			ps.err = err
+			return ps
+		}
+
+		relation, err := ps.getRscPoolHostModuleRelation(opt.HostIDs)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = make([]meta.ResourceAttribute, 0, len(opt.HostIDs))
+		for _, id := range opt.HostIDs {
+			srcModuleID, exist := relation[id]
+			if !exist {
+				ps.err = errors.New("host not exist in resource pool")
+				return ps
+			}
+
+			ps.Attribute.Resources = append(ps.Attribute.Resources, meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.HostInstance,
+					Action:     meta.MoveResPoolHostToBizIdleModule,
+					InstanceID: id,
+				},
+				Layers: []meta.Item{{Type: meta.ModelModule, InstanceID: srcModuleID},
+					{Type: meta.Business, InstanceID: bizID}},
+			})
+		}
+
+		return ps
+	}
+
+	return ParseStreamWithFramework(ps, hostLeaseConfigs)
+}
+
+// hostLeaseBizIDGetter get the business id from the last sub match of the host lease url
+func hostLeaseBizIDGetter(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+	match := re.FindStringSubmatch(request.URI)
+	if len(match) < 2 {
+		return nil, fmt.Errorf("url %s does not match %s", request.URI, re.String())
+	}
+
+	bizID, err := strconv.ParseInt(match[len(match)-1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse business id from url %s failed, err: %v", request.URI, err)
+	}
+
+	return []int64{bizID}, nil
+}
@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package parser
+
+import (
+	"net/http"
+
+	"configcenter/src/ac/meta"
+)
+
+// hostLifecycleConfigs the host lifecycle constrains the host state changes of all the businesses, so it is managed
+// as the global config of the platform
+var hostLifecycleConfigs = []AuthConfig{
+	{
+		Name:           "updateHostLifecycle",
+		Description:    "更新主机生命周期定义",
+		Pattern:        "/api/v3/update/host_lifecycle/definition",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findHostLifecycle",
+		Description:    "查询主机生命周期定义",
+		Pattern:        "/api/v3/find/host_lifecycle/definition",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "findHostLifecycleHistory",
+		Description:    "查询主机生命周期流转历史",
+		Pattern:        "/api/v3/findmany/host_lifecycle/history",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "findHostLifecycleStuckHost",
+		Description:    "查询在生命周期状态停留超时的主机",
+		Pattern:        "/api/v3/findmany/host_lifecycle/stuck_host",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	},
+}
+
+func (ps *parseStream) hostLifecycle() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	return ParseStreamWithFramework(ps, hostLifecycleConfigs)
+}
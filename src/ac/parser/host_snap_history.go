@@ -0,0 +1,47 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"net/http"
+
+	"configcenter/src/ac/meta"
+)
+
+// hostSnapHistoryConfigs the host snapshot histories are the reported hardware and system data of the hosts, so they
+// are authorized as finding the hosts
+var hostSnapHistoryConfigs = []AuthConfig{
+	{
+		Name:           "findHostSnapHistory",
+		Description:    "查询主机快照字段变更历史",
+		Pattern:        "/api/v3/findmany/host_snap/history",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.HostInstance,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "findHostSnapChangedHost",
+		Description:    "查询主机快照字段发生变更的主机",
+		Pattern:        "/api/v3/findmany/host_snap/changed_host",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.HostInstance,
+		ResourceAction: meta.FindMany,
+	},
+}
+
+func (ps *parseStream) hostSnapHistory() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	return ParseStreamWithFramework(ps, hostSnapHistoryConfigs)
+}
@@ -66,6 +66,42 @@ var HostApplyAuthConfigs = []AuthConfig{
 		BizIndex:       5,
 		ResourceType:   meta.HostApply,
 		ResourceAction: meta.DefaultHostApply,
+	}, {
+		Name:           "CreateHostApplyScopeRuleRegex",
+		Description:    "添加业务、集群、集群模板或动态分组维度的主机属性自动应用规则",
+		Regex:          regexp.MustCompile(`^/api/v3/create/host_apply_scope_rule/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.HostApply,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "UpdateHostApplyScopeRuleRegex",
+		Description:    "更新业务、集群、集群模板或动态分组维度的主机属性自动应用规则",
+		Regex:          regexp.MustCompile(`^/api/v3/update/host_apply_scope_rule/([0-9]+)/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       6,
+		ResourceType:   meta.HostApply,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "DeleteHostApplyScopeRuleRegex",
+		Description:    "删除业务、集群、集群模板或动态分组维度的主机属性自动应用规则",
+		Regex:          regexp.MustCompile(`^/api/v3/deletemany/host_apply_scope_rule/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.HostApply,
+		ResourceAction: meta.Delete,
+	}, {
+		Name:           "ListHostApplyScopeRuleRegex",
+		Description:    "列表查询业务、集群、集群模板或动态分组维度的主机属性自动应用规则",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/host_apply_scope_rule/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.HostApply,
+		ResourceAction: meta.DefaultHostApply,
 	}, {
 		Name:           "FindmanyModuleHostApplyTaskStatus",
 		Description:    "查询模块场景下主机自动应用任务状态",
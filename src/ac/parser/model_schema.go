@@ -0,0 +1,110 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/ac/meta"
+)
+
+// ModelSchemaAuthConfigs model schema draft, version and model definition related auth configs, skip all, authorize in topo-server.
+var ModelSchemaAuthConfigs = []AuthConfig{
+	{
+		Name:           "FindModelSchemaDraft",
+		Description:    "查询模型草稿",
+		Regex:          regexp.MustCompile(`^/api/v3/find/object/[^\s/]+/schema/draft/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "UpdateModelSchemaDraft",
+		Description:    "更新模型草稿",
+		Regex:          regexp.MustCompile(`^/api/v3/update/object/[^\s/]+/schema/draft/?$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "DeleteModelSchemaDraft",
+		Description:    "删除模型草稿",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/object/[^\s/]+/schema/draft/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "CheckModelSchemaDraft",
+		Description:    "校验模型草稿",
+		Regex:          regexp.MustCompile(`^/api/v3/check/object/[^\s/]+/schema/draft/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "PublishModelSchemaDraft",
+		Description:    "发布模型草稿",
+		Regex:          regexp.MustCompile(`^/api/v3/publish/object/[^\s/]+/schema/draft/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "ListModelSchemaVersion",
+		Description:    "查询模型版本列表",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/object/[^\s/]+/schema/version/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "FindModelSchemaVersion",
+		Description:    "查询模型版本",
+		Regex:          regexp.MustCompile(`^/api/v3/find/object/[^\s/]+/schema/version/[0-9]+/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "DiffModelSchemaVersion",
+		Description:    "对比模型版本",
+		Regex:          regexp.MustCompile(`^/api/v3/diff/object/[^\s/]+/schema/version/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "RevertModelSchemaVersion",
+		Description:    "回滚模型版本",
+		Regex:          regexp.MustCompile(`^/api/v3/revert/object/[^\s/]+/schema/version/[0-9]+/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "PlanModelDefinition",
+		Description:    "预览模型定义变更",
+		Regex:          regexp.MustCompile(`^/api/v3/plan/model/definition/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "ApplyModelDefinition",
+		Description:    "应用模型定义",
+		Regex:          regexp.MustCompile(`^/api/v3/apply/model/definition/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
+func (ps *parseStream) modelSchema() *parseStream {
+	return ParseStreamWithFramework(ps, ModelSchemaAuthConfigs)
+}
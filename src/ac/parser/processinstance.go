@@ -138,6 +138,14 @@ var ProcessInstanceAuthConfigs = []AuthConfig{
 		BizIDGetter:    DefaultBizIDGetter,
 		ResourceType:   ProcessInstanceIAMResourceType,
 		ResourceAction: meta.Find,
+	}, {
+		Name:           "listProcessPortConflicts",
+		Description:    "查询业务下主机上进程绑定信息的端口冲突",
+		Pattern:        "/api/v3/findmany/proc/process_port_conflict",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   ProcessInstanceIAMResourceType,
+		ResourceAction: meta.Find,
 	}, {
 		// list process instances by ids and biz set regex, authorize by biz set access permission, **only for ui**
 		Name:           "listProcessInstancesDetailsByIDsAndBizSetRegexp",
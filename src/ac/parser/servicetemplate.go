@@ -301,9 +301,68 @@ var ServiceTemplateAuthConfigs = []AuthConfig{
 			}
 			return []int64{templateID}, nil
 		},
+	}, {
+		Name:             "createServiceTemplateVersion",
+		Description:      "创建服务模板版本",
+		Pattern:          "/api/v3/create/proc/service_template/version",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateVersionIDGetter,
+	}, {
+		Name:             "listServiceTemplateVersion",
+		Description:      "查询服务模板版本",
+		Pattern:          "/api/v3/findmany/proc/service_template/version",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.FindMany,
+		InstanceIDGetter: serviceTemplateVersionIDGetter,
+	}, {
+		Name:             "diffServiceTemplateVersion",
+		Description:      "对比服务模板版本差异",
+		Pattern:          "/api/v3/find/proc/service_template/version/difference",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Find,
+		InstanceIDGetter: serviceTemplateVersionIDGetter,
+	}, {
+		Name:             "rollbackServiceTemplateVersion",
+		Description:      "回滚服务模板到指定版本",
+		Pattern:          "/api/v3/update/proc/service_template/version/rollback",
+		HTTPMethod:       http.MethodPut,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateVersionIDGetter,
+	}, {
+		Name:             "listServiceTemplateVersionPin",
+		Description:      "查询模块同步的服务模板版本",
+		Pattern:          "/api/v3/findmany/proc/service_template/version/pin",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.FindMany,
+		InstanceIDGetter: serviceTemplateVersionIDGetter,
 	},
 }
 
+// serviceTemplateVersionIDGetter get the service template id of the service template version request
+func serviceTemplateVersionIDGetter(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+	val, err := request.getValueFromBody("template_id")
+	if err != nil {
+		return nil, err
+	}
+
+	templateID := val.Int()
+	if templateID <= 0 {
+		return nil, errors.New("invalid service template id")
+	}
+	return []int64{templateID}, nil
+}
+
 // ServiceTemplate TODO
 func (ps *parseStream) ServiceTemplate() *parseStream {
 	return ParseStreamWithFramework(ps, ServiceTemplateAuthConfigs)
@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/ac/meta"
+)
+
+// setTemplateSyncPolicyConfigs the sync policy synchronizes the sets by the set template automatically, so the
+// operations are authorized as synchronizing the sets of the business
+var setTemplateSyncPolicyConfigs = []AuthConfig{
+	{
+		Name:           "createSetTemplateSyncPolicy",
+		Description:    "创建集群模板同步策略",
+		Regex:          regexp.MustCompile(`^/api/v3/create/set_template_sync_policy/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.ModelSet,
+		ResourceAction: meta.UpdateMany,
+	}, {
+		Name:           "updateSetTemplateSyncPolicy",
+		Description:    "更新集群模板同步策略",
+		Regex:          regexp.MustCompile(`^/api/v3/update/set_template_sync_policy/[0-9]+/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       6,
+		ResourceType:   meta.ModelSet,
+		ResourceAction: meta.UpdateMany,
+	}, {
+		Name:           "deleteSetTemplateSyncPolicy",
+		Description:    "删除集群模板同步策略",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/set_template_sync_policy/[0-9]+/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       6,
+		ResourceType:   meta.ModelSet,
+		ResourceAction: meta.UpdateMany,
+	}, {
+		Name:           "findSetTemplateSyncPolicy",
+		Description:    "查询集群模板同步策略",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/set_template_sync_policy/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.ModelSet,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "findSetTemplateSyncSummary",
+		Description:    "查询业务下集群与集群模板不同步的汇总报告",
+		Regex:          regexp.MustCompile(`^/api/v3/find/set_template_sync_summary/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.ModelSet,
+		ResourceAction: meta.FindMany,
+	},
+}
+
+func (ps *parseStream) setTemplateSyncPolicy() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	return ParseStreamWithFramework(ps, setTemplateSyncPolicyConfigs)
+}
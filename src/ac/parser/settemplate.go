@@ -330,9 +330,68 @@ var SetTemplateAuthConfigs = []AuthConfig{
 			}
 			return []int64{templateID}, nil
 		},
+	}, {
+		Name:             "createSetTemplateVersion",
+		Description:      "创建集群模板版本",
+		Pattern:          "/api/v3/create/topo/set_template/version",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.SetTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: setTemplateVersionIDGetter,
+	}, {
+		Name:             "listSetTemplateVersion",
+		Description:      "查询集群模板版本",
+		Pattern:          "/api/v3/findmany/topo/set_template/version",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.SetTemplate,
+		ResourceAction:   meta.FindMany,
+		InstanceIDGetter: setTemplateVersionIDGetter,
+	}, {
+		Name:             "diffSetTemplateVersion",
+		Description:      "对比集群模板版本差异",
+		Pattern:          "/api/v3/find/topo/set_template/version/difference",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.SetTemplate,
+		ResourceAction:   meta.Find,
+		InstanceIDGetter: setTemplateVersionIDGetter,
+	}, {
+		Name:             "rollbackSetTemplateVersion",
+		Description:      "回滚集群模板到指定版本",
+		Pattern:          "/api/v3/update/topo/set_template/version/rollback",
+		HTTPMethod:       http.MethodPut,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.SetTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: setTemplateVersionIDGetter,
+	}, {
+		Name:             "listSetTemplateVersionPin",
+		Description:      "查询集群同步的集群模板版本",
+		Pattern:          "/api/v3/findmany/topo/set_template/version/pin",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.SetTemplate,
+		ResourceAction:   meta.FindMany,
+		InstanceIDGetter: setTemplateVersionIDGetter,
 	},
 }
 
+// setTemplateVersionIDGetter get the set template id of the set template version request
+func setTemplateVersionIDGetter(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+	val, err := request.getValueFromBody("template_id")
+	if err != nil {
+		return nil, err
+	}
+
+	templateID := val.Int()
+	if templateID <= 0 {
+		return nil, errors.New("invalid set template id")
+	}
+	return []int64{templateID}, nil
+}
+
 func (ps *parseStream) setTemplate() *parseStream {
 	return ParseStreamWithFramework(ps, SetTemplateAuthConfigs)
 }
@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/ac/meta"
+)
+
+// syncRolloutConfigs the sync rollout synchronizes the service instances of the modules by the service template, so
+// the operations are authorized as syncing the service instances of the business
+var syncRolloutConfigs = []AuthConfig{
+	{
+		Name:           "createServiceTemplateSyncRollout",
+		Description:    "创建服务模板分批同步",
+		Regex:          regexp.MustCompile(`^/api/v3/create/service_template_sync_rollout/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.ProcessServiceInstance,
+		ResourceAction: meta.Update,
+	}, {
+		Name:        "resumeServiceTemplateSyncRollout",
+		Description: "继续服务模板分批同步",
+		Regex: regexp.MustCompile(
+			`^/api/v3/update/service_template_sync_rollout/[0-9]+/resume/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       7,
+		ResourceType:   meta.ProcessServiceInstance,
+		ResourceAction: meta.Update,
+	}, {
+		Name:        "abortServiceTemplateSyncRollout",
+		Description: "终止服务模板分批同步",
+		Regex: regexp.MustCompile(
+			`^/api/v3/update/service_template_sync_rollout/[0-9]+/abort/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       7,
+		ResourceType:   meta.ProcessServiceInstance,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findServiceTemplateSyncRollout",
+		Description:    "查询服务模板分批同步",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/service_template_sync_rollout/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.FindMany,
+	},
+}
+
+func (ps *parseStream) syncRollout() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	return ParseStreamWithFramework(ps, syncRolloutConfigs)
+}
@@ -49,7 +49,9 @@ func (ps *parseStream) topologyLatest() *parseStream {
 		mainlineLatest().
 		setTemplate().
 		modelQuote().
-		fieldTemplate()
+		fieldTemplate().
+		validationRule().
+		modelSchema()
 
 	return ps
 }
@@ -477,6 +479,7 @@ const (
 var (
 	deleteObjectInstanceAssociationLatestRegexp      = regexp.MustCompile(`^/api/v3/delete/instassociation/[^\s/]+/[0-9]+/?$`)
 	deleteObjectInstanceAssociationBatchLatestRegexp = regexp.MustCompile("^/api/v3/delete/instassociation/batch")
+	updateObjectInstanceAssociationLatestRegexp      = regexp.MustCompile(`^/api/v3/update/instassociation/[^\s/]+/[0-9]+/?$`)
 	findObjectInstanceTopologyUILatestRegexp         = regexp.MustCompile(`^/api/v3/findmany/inst/association/object/[^\s/]+/inst_id/[0-9]+/offset/[0-9]+/limit/[0-9]+/web$`)
 	findInstAssociationObjInstInfoLatestRegexp       = regexp.MustCompile(`^/api/v3/findmany/inst/association/association_object/inst_base_info$`)
 	searchInstAssociationAndInstDetailLatestRegexp   = regexp.MustCompile(
@@ -727,22 +730,24 @@ func (ps *parseStream) objectInstanceAssociationLatest() *parseStream {
 		return ps
 	}
 
-	// delete object's instance association operation. for web
-	if ps.hitRegexp(deleteObjectInstanceAssociationLatestRegexp, http.MethodDelete) {
+	// delete or update attributes of object's instance association operation, both need the update permission
+	// of the instances on both sides. for web
+	if ps.hitRegexp(deleteObjectInstanceAssociationLatestRegexp, http.MethodDelete) ||
+		ps.hitRegexp(updateObjectInstanceAssociationLatestRegexp, http.MethodPut) {
 		if len(ps.RequestCtx.Elements) != 6 {
-			ps.err = errors.New("delete object's instance association, but got invalid url")
+			ps.err = errors.New("delete or update object's instance association, but got invalid url")
 			return ps
 		}
 
 		objID := ps.RequestCtx.Elements[4]
 		if len(objID) == 0 {
-			ps.err = fmt.Errorf("delete object instance association, but got empty object id")
+			ps.err = fmt.Errorf("delete or update object instance association, but got empty object id")
 			return ps
 		}
 
 		assoID, err := strconv.ParseInt(ps.RequestCtx.Elements[5], 10, 64)
 		if err != nil {
-			ps.err = fmt.Errorf("delete object instance association, but got invalid association id %s",
+			ps.err = fmt.Errorf("delete or update object instance association, but got invalid association id %s",
 				ps.RequestCtx.Elements[5])
 			return ps
 		}
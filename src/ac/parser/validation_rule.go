@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/ac/meta"
+)
+
+// ValidationRuleAuthConfigs model validation rule related auth configs, skip all, authorize in topo-server.
+var ValidationRuleAuthConfigs = []AuthConfig{
+	{
+		Name:           "ListValidationRule",
+		Description:    "查询模型校验规则列表",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/object/[^\s/]+/validation_rule/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "CreateValidationRule",
+		Description:    "创建模型校验规则",
+		Regex:          regexp.MustCompile(`^/api/v3/create/object/[^\s/]+/validation_rule/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "UpdateValidationRule",
+		Description:    "更新模型校验规则",
+		Regex:          regexp.MustCompile(`^/api/v3/update/object/[^\s/]+/validation_rule/[0-9]+/?$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceAction: meta.SkipAction,
+	},
+	{
+		Name:           "DeleteValidationRule",
+		Description:    "删除模型校验规则",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/object/[^\s/]+/validation_rule/[0-9]+/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
+func (ps *parseStream) validationRule() *parseStream {
+	return ParseStreamWithFramework(ps, ValidationRuleAuthConfigs)
+}
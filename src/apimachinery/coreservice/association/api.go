@@ -521,3 +521,29 @@ func (asst *association) DeleteInstAssociation(ctx context.Context, h http.Heade
 
 	return &resp.Data, nil
 }
+
+// UpdateInstAssociationAttrs set the attribute values of the instance association
+func (asst *association) UpdateInstAssociationAttrs(ctx context.Context, h http.Header,
+	input *metadata.UpdateInstAsstAttrsOption) error {
+
+	resp := new(metadata.BaseResp)
+	subPath := "/update/instanceassociation/attrs"
+
+	err := asst.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -66,6 +66,8 @@ type AssociationClientInterface interface {
 		resp *metadata.QueryInstAssociationResult, err error)
 	DeleteInstAssociation(ctx context.Context, h http.Header, input *metadata.InstAsstDeleteOption) (
 		*metadata.DeletedCount, error)
+	// UpdateInstAssociationAttrs set the attribute values of the instance association
+	UpdateInstAssociationAttrs(ctx context.Context, h http.Header, input *metadata.UpdateInstAsstAttrsOption) error
 
 	// CountInstanceAssociations counts model instance associations num.
 	CountInstanceAssociations(ctx context.Context, header http.Header, objID string, input *metadata.Condition) (
@@ -32,6 +32,7 @@ import (
 	"configcenter/src/apimachinery/coreservice/mainline"
 	"configcenter/src/apimachinery/coreservice/model"
 	modelquote "configcenter/src/apimachinery/coreservice/model_quote"
+	modelschema "configcenter/src/apimachinery/coreservice/model_schema"
 	"configcenter/src/apimachinery/coreservice/operation"
 	"configcenter/src/apimachinery/coreservice/process"
 	"configcenter/src/apimachinery/coreservice/project"
@@ -40,6 +41,7 @@ import (
 	ccSystem "configcenter/src/apimachinery/coreservice/system"
 	"configcenter/src/apimachinery/coreservice/topographics"
 	"configcenter/src/apimachinery/coreservice/usermanagement"
+	validationrule "configcenter/src/apimachinery/coreservice/validation_rule"
 	"configcenter/src/apimachinery/rest"
 	"configcenter/src/apimachinery/transaction"
 	"configcenter/src/apimachinery/util"
@@ -72,6 +74,8 @@ type CoreServiceClientInterface interface {
 	FieldTemplate() fieldtmpl.Interface
 	IDRule() idrule.Interface
 	UserManagement() usermanagement.UserManagementInterface
+	ValidationRule() validationrule.Interface
+	ModelSchema() modelschema.Interface
 }
 
 // NewCoreServiceClient TODO
@@ -211,3 +215,13 @@ func (c *coreService) IDRule() idrule.Interface {
 func (c *coreService) UserManagement() usermanagement.UserManagementInterface {
 	return usermanagement.NewUserManagementInterface(c.restCli)
 }
+
+// ValidationRule return the model validation rule client
+func (c *coreService) ValidationRule() validationrule.Interface {
+	return validationrule.New(c.restCli)
+}
+
+// ModelSchema return the model schema draft and version client
+func (c *coreService) ModelSchema() modelschema.Interface {
+	return modelschema.New(c.restCli)
+}
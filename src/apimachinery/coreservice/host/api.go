@@ -267,6 +267,34 @@ func (h *host) QueryHostLock(ctx context.Context, header http.Header,
 	return resp, err
 }
 
+// ListHostLock list the active host locks
+func (h *host) ListHostLock(ctx context.Context, header http.Header, opt *metadata.ListHostLockOption) (
+	*metadata.ListHostLockResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.ListHostLockResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/list/host/lock").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
 // CreateDynamicGroup is dynamic group query datas base on conditions action api machinery.
 func (h *host) CreateDynamicGroup(ctx context.Context, header http.Header,
 	data *metadata.DynamicGroup) (resp *metadata.IDResult, err error) {
@@ -48,6 +48,34 @@ type HostClientInterface interface {
 		resp *metadata.HostLockResponse, err error)
 	QueryHostLock(ctx context.Context, header http.Header, input *metadata.QueryHostLockRequest) (
 		resp *metadata.HostLockQueryResponse, err error)
+	ListHostLock(ctx context.Context, header http.Header, opt *metadata.ListHostLockOption) (
+		*metadata.ListHostLockResult, errors.CCErrorCoder)
+
+	UpdateHostLifecycle(ctx context.Context, header http.Header, lifecycle *metadata.HostLifecycle) errors.CCErrorCoder
+	GetHostLifecycle(ctx context.Context, header http.Header) (*metadata.HostLifecycle, errors.CCErrorCoder)
+	CreateHostLifecycleHistory(ctx context.Context, header http.Header,
+		opt *metadata.CreateHostLifecycleHistoryOption) errors.CCErrorCoder
+	SearchHostLifecycleHistory(ctx context.Context, header http.Header,
+		opt *metadata.SearchHostLifecycleHistoryOption) (*metadata.SearchHostLifecycleHistoryResult, errors.CCErrorCoder)
+	ListStuckHosts(ctx context.Context, header http.Header, opt *metadata.ListStuckHostOption) (
+		*metadata.ListStuckHostResult, errors.CCErrorCoder)
+	CreateHostSnapHistory(ctx context.Context, header http.Header,
+		opt *metadata.CreateHostSnapHistoryOption) errors.CCErrorCoder
+	SearchHostSnapHistory(ctx context.Context, header http.Header, opt *metadata.SearchHostSnapHistoryOption) (
+		*metadata.SearchHostSnapHistoryResult, errors.CCErrorCoder)
+	ListHostSnapChangedHosts(ctx context.Context, header http.Header, opt *metadata.ListHostSnapChangedHostOption) (
+		*metadata.ListHostSnapChangedHostResult, errors.CCErrorCoder)
+
+	CreateHostLease(ctx context.Context, header http.Header, lease *metadata.HostLease) (*metadata.HostLease,
+		errors.CCErrorCoder)
+	UpdateHostLease(ctx context.Context, header http.Header, id int64, opt *metadata.UpdateHostLeaseOption) (
+		*metadata.HostLease, errors.CCErrorCoder)
+	SearchHostLease(ctx context.Context, header http.Header, opt *metadata.SearchHostLeaseOption) (
+		*metadata.SearchHostLeaseResult, errors.CCErrorCoder)
+	CreateHostLeaseHistory(ctx context.Context, header http.Header,
+		histories []metadata.HostLeaseHistory) errors.CCErrorCoder
+	SearchHostLeaseHistory(ctx context.Context, header http.Header, opt *metadata.SearchHostLeaseHistoryOption) (
+		*metadata.SearchHostLeaseHistoryResult, errors.CCErrorCoder)
 
 	// CreateDynamicGroup TODO
 	// dynamic grouping interfaces.
@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateHostLease create an active host lease
+func (h *host) CreateHostLease(ctx context.Context, header http.Header, lease *metadata.HostLease) (
+	*metadata.HostLease, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.HostLease `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(lease).
+		SubResourcef("/create/host_lease").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// UpdateHostLease update the active host lease
+func (h *host) UpdateHostLease(ctx context.Context, header http.Header, id int64,
+	opt *metadata.UpdateHostLeaseOption) (*metadata.HostLease, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.HostLease `json:"data"`
+	}{}
+
+	err := h.client.Put().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/update/host_lease/%d", id).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// SearchHostLease search host leases
+func (h *host) SearchHostLease(ctx context.Context, header http.Header,
+	opt *metadata.SearchHostLeaseOption) (*metadata.SearchHostLeaseResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.SearchHostLeaseResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/host_lease").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// CreateHostLeaseHistory create host lease histories
+func (h *host) CreateHostLeaseHistory(ctx context.Context, header http.Header,
+	histories []metadata.HostLeaseHistory) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(histories).
+		SubResourcef("/createmany/host_lease/history").
+		WithHeaders(header).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return ret.CCError()
+}
+
+// SearchHostLeaseHistory search host lease histories
+func (h *host) SearchHostLeaseHistory(ctx context.Context, header http.Header,
+	opt *metadata.SearchHostLeaseHistoryOption) (*metadata.SearchHostLeaseHistoryResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.SearchHostLeaseHistoryResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/host_lease/history").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
@@ -0,0 +1,145 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// UpdateHostLifecycle create or update the host lifecycle definition
+func (h *host) UpdateHostLifecycle(ctx context.Context, header http.Header,
+	lifecycle *metadata.HostLifecycle) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	err := h.client.Put().
+		WithContext(ctx).
+		Body(lifecycle).
+		SubResourcef("/update/host_lifecycle/definition").
+		WithHeaders(header).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return ret.CCError()
+}
+
+// GetHostLifecycle get the host lifecycle definition
+func (h *host) GetHostLifecycle(ctx context.Context, header http.Header) (*metadata.HostLifecycle,
+	errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.HostLifecycle `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		SubResourcef("/find/host_lifecycle/definition").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// CreateHostLifecycleHistory create host lifecycle transition histories
+func (h *host) CreateHostLifecycleHistory(ctx context.Context, header http.Header,
+	opt *metadata.CreateHostLifecycleHistoryOption) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/createmany/host_lifecycle/history").
+		WithHeaders(header).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return ret.CCError()
+}
+
+// SearchHostLifecycleHistory search host lifecycle transition histories
+func (h *host) SearchHostLifecycleHistory(ctx context.Context, header http.Header,
+	opt *metadata.SearchHostLifecycleHistoryOption) (*metadata.SearchHostLifecycleHistoryResult,
+	errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.SearchHostLifecycleHistoryResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/host_lifecycle/history").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// ListStuckHosts list the hosts that stay in a lifecycle state longer than the specified duration
+func (h *host) ListStuckHosts(ctx context.Context, header http.Header, opt *metadata.ListStuckHostOption) (
+	*metadata.ListStuckHostResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.ListStuckHostResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/host_lifecycle/stuck_host").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateHostSnapHistory create host snapshot change or drift histories
+func (h *host) CreateHostSnapHistory(ctx context.Context, header http.Header,
+	opt *metadata.CreateHostSnapHistoryOption) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/createmany/host_snap/history").
+		WithHeaders(header).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return ret.CCError()
+}
+
+// SearchHostSnapHistory search host snapshot histories
+func (h *host) SearchHostSnapHistory(ctx context.Context, header http.Header,
+	opt *metadata.SearchHostSnapHistoryOption) (*metadata.SearchHostSnapHistoryResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.SearchHostSnapHistoryResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/host_snap/history").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// ListHostSnapChangedHosts list the hosts that have snapshot histories matching the filter
+func (h *host) ListHostSnapChangedHosts(ctx context.Context, header http.Header,
+	opt *metadata.ListHostSnapChangedHostOption) (*metadata.ListHostSnapChangedHostResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.ListHostSnapChangedHostResult `json:"data"`
+	}{}
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/host_snap/changed_host").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
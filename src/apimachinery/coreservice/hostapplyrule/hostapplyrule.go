@@ -46,6 +46,15 @@ type HostApplyRuleInterface interface {
 		option metadata.UpdateHostByHostApplyRuleOption) (metadata.MultipleHostApplyResult, errors.CCErrorCoder)
 	SearchRuleRelatedServiceTemplates(ctx context.Context, header http.Header,
 		option *metadata.RuleRelatedServiceTemplateOption) ([]metadata.SrvTemplate, errors.CCErrorCoder)
+
+	CreateHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64,
+		option metadata.CreateHostApplyScopeRuleOption) (metadata.HostApplyScopeRule, errors.CCErrorCoder)
+	UpdateHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64, ruleID int64,
+		option metadata.UpdateHostApplyScopeRuleOption) (metadata.HostApplyScopeRule, errors.CCErrorCoder)
+	DeleteHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64,
+		option metadata.DeleteHostApplyScopeRuleOption) errors.CCErrorCoder
+	ListHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64,
+		option metadata.ListHostApplyScopeRuleOption) (metadata.MultipleHostApplyScopeRuleResult, errors.CCErrorCoder)
 }
 
 // NewHostApplyRuleClient TODO
@@ -0,0 +1,126 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateHostApplyScopeRule create host apply rule of business, set, set template or host dynamic group scope
+func (p *hostApplyRule) CreateHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64,
+	option metadata.CreateHostApplyScopeRuleOption) (metadata.HostApplyScopeRule, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data metadata.HostApplyScopeRule `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/create/host_apply_scope_rule/bk_biz_id/%d", bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return ret.Data, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return ret.Data, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// UpdateHostApplyScopeRule update the property value of the host apply scope rule
+func (p *hostApplyRule) UpdateHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64, ruleID int64,
+	option metadata.UpdateHostApplyScopeRuleOption) (metadata.HostApplyScopeRule, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data metadata.HostApplyScopeRule `json:"data"`
+	}{}
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/update/host_apply_scope_rule/%d/bk_biz_id/%d", ruleID, bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return ret.Data, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return ret.Data, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// DeleteHostApplyScopeRule delete host apply scope rules
+func (p *hostApplyRule) DeleteHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64,
+	option metadata.DeleteHostApplyScopeRuleOption) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+
+	err := p.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/deletemany/host_apply_scope_rule/bk_biz_id/%d", bizID).
+		WithHeaders(header).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return ret.CCError()
+}
+
+// ListHostApplyScopeRule list host apply scope rules
+func (p *hostApplyRule) ListHostApplyScopeRule(ctx context.Context, header http.Header, bizID int64,
+	option metadata.ListHostApplyScopeRuleOption) (metadata.MultipleHostApplyScopeRuleResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data metadata.MultipleHostApplyScopeRuleResult `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/host_apply_scope_rule/bk_biz_id/%d", bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return ret.Data, errors.CCHttpError
+	}
+
+	if ccErr := ret.CCError(); ccErr != nil {
+		return ret.Data, ccErr
+	}
+
+	return ret.Data, nil
+}
@@ -286,3 +286,29 @@ func (inst *instance) GetInstanceObjectMapping(ctx context.Context, header http.
 
 	return resp.Data, nil
 }
+
+// UpdateComputedFields update the computed fields of the instances with the evaluated values
+func (inst *instance) UpdateComputedFields(ctx context.Context, h http.Header, objID string,
+	input *metadata.UpdateComputedFieldsOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+	subPath := "/update/model/%s/instance/computed_fields"
+
+	err := inst.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -50,6 +50,9 @@ type InstanceClientInterface interface {
 		*metadata.CountResponseContent, error)
 	GetInstanceObjectMapping(ctx context.Context, h http.Header, ids []int64) ([]metadata.ObjectMapping,
 		errors.CCErrorCoder)
+	// UpdateComputedFields update the computed fields of the instances with the evaluated values
+	UpdateComputedFields(ctx context.Context, h http.Header, objID string,
+		input *metadata.UpdateComputedFieldsOption) errors.CCErrorCoder
 }
 
 // NewInstanceClientInterface TODO
@@ -0,0 +1,51 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package modelschema defines model schema draft and version api machinery.
+package modelschema
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// Interface defines model schema draft and version apis.
+type Interface interface {
+	ListModelSchemaDraft(ctx context.Context, h http.Header, opt *metadata.CommonQueryOption) (
+		*metadata.ModelSchemaDraftInfo, errors.CCErrorCoder)
+	SaveModelSchemaDraft(ctx context.Context, h http.Header, opt *metadata.ModelSchemaDraft) errors.CCErrorCoder
+	DeleteModelSchemaDraft(ctx context.Context, h http.Header, opt *metadata.DeleteOption) errors.CCErrorCoder
+	CheckModelSchemaDraft(ctx context.Context, h http.Header, opt *metadata.ModelSchemaDraft) (
+		*metadata.ModelSchemaCheckResult, errors.CCErrorCoder)
+	ListModelSchemaVersion(ctx context.Context, h http.Header, opt *metadata.CommonQueryOption) (
+		*metadata.ModelSchemaVersionInfo, errors.CCErrorCoder)
+	CreateModelSchemaVersion(ctx context.Context, h http.Header, opt *metadata.ModelSchemaVersion) (
+		*metadata.ModelSchemaVersion, errors.CCErrorCoder)
+}
+
+// New model schema api client.
+func New(client rest.ClientInterface) Interface {
+	return &modelSchema{client: client}
+}
+
+type modelSchema struct {
+	client rest.ClientInterface
+}
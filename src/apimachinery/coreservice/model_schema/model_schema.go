@@ -0,0 +1,176 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// ListModelSchemaDraft list model schema drafts
+func (m *modelSchema) ListModelSchemaDraft(ctx context.Context, h http.Header, opt *metadata.CommonQueryOption) (
+	*metadata.ModelSchemaDraftInfo, errors.CCErrorCoder) {
+
+	resp := new(metadata.ListModelSchemaDraftResp)
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/model/schema/draft").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// SaveModelSchemaDraft save model schema draft
+func (m *modelSchema) SaveModelSchemaDraft(ctx context.Context, h http.Header,
+	opt *metadata.ModelSchemaDraft) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := m.client.Put().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/save/model/schema/draft").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteModelSchemaDraft delete model schema drafts
+func (m *modelSchema) DeleteModelSchemaDraft(ctx context.Context, h http.Header,
+	opt *metadata.DeleteOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := m.client.Delete().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/delete/model/schema/draft").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckModelSchemaDraft check model schema draft against the existing instances
+func (m *modelSchema) CheckModelSchemaDraft(ctx context.Context, h http.Header, opt *metadata.ModelSchemaDraft) (
+	*metadata.ModelSchemaCheckResult, errors.CCErrorCoder) {
+
+	resp := new(metadata.CheckModelSchemaDraftResp)
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/check/model/schema/draft").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// ListModelSchemaVersion list model schema versions
+func (m *modelSchema) ListModelSchemaVersion(ctx context.Context, h http.Header, opt *metadata.CommonQueryOption) (
+	*metadata.ModelSchemaVersionInfo, errors.CCErrorCoder) {
+
+	resp := new(metadata.ListModelSchemaVersionResp)
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/model/schema/version").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// CreateModelSchemaVersion create model schema version
+func (m *modelSchema) CreateModelSchemaVersion(ctx context.Context, h http.Header, opt *metadata.ModelSchemaVersion) (
+	*metadata.ModelSchemaVersion, errors.CCErrorCoder) {
+
+	resp := new(metadata.CreateModelSchemaVersionResp)
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/create/model/schema/version").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
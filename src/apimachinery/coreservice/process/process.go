@@ -119,6 +119,15 @@ type ProcessInterface interface {
 		*metadata.ServTempAttrData, errors.CCErrorCoder)
 	CreateServiceTemplateAttrs(ctx context.Context, h http.Header, option *metadata.CreateSvcTempAttrsOption) (
 		[]int64, errors.CCErrorCoder)
+
+	// service template and set template version
+	CreateTemplateVersion(ctx context.Context, h http.Header, version *metadata.TemplateVersionInfo) (
+		*metadata.TemplateVersionInfo, errors.CCErrorCoder)
+	ListTemplateVersion(ctx context.Context, h http.Header, opt *metadata.ListTemplateVersionOption) (
+		*metadata.ListTemplateVersionResult, errors.CCErrorCoder)
+	PinTemplateVersion(ctx context.Context, h http.Header, opt *metadata.PinTemplateVersionOption) errors.CCErrorCoder
+	ListTemplateVersionPin(ctx context.Context, h http.Header, opt *metadata.ListTemplateVersionPinOption) (
+		[]metadata.TemplateVersionPin, errors.CCErrorCoder)
 }
 
 // NewProcessInterfaceClient TODO
@@ -0,0 +1,122 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateTemplateVersion create the next version of the service template or the set template
+func (p *process) CreateTemplateVersion(ctx context.Context, h http.Header, version *metadata.TemplateVersionInfo) (
+	*metadata.TemplateVersionInfo, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.TemplateVersionInfo `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(version).
+		SubResourcef("/create/template_version").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// ListTemplateVersion list the versions of the service template or the set template
+func (p *process) ListTemplateVersion(ctx context.Context, h http.Header, opt *metadata.ListTemplateVersionOption) (
+	*metadata.ListTemplateVersionResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data *metadata.ListTemplateVersionResult `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/template_version").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
+
+// PinTemplateVersion record the template version that the modules or the sets are synchronized to
+func (p *process) PinTemplateVersion(ctx context.Context, h http.Header,
+	opt *metadata.PinTemplateVersionOption) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/update/template_version/pin").
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return ret.CCError()
+}
+
+// ListTemplateVersionPin list the template versions that the modules or the sets are synchronized to
+func (p *process) ListTemplateVersionPin(ctx context.Context, h http.Header,
+	opt *metadata.ListTemplateVersionPinOption) ([]metadata.TemplateVersionPin, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data []metadata.TemplateVersionPin `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/template_version/pin").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ccErr := ret.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return ret.Data, nil
+}
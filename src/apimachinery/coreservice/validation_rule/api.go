@@ -0,0 +1,47 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package validationrule defines model validation rule api machinery.
+package validationrule
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// Interface defines model validation rule apis.
+type Interface interface {
+	ListValidationRule(ctx context.Context, h http.Header, opt *metadata.CommonQueryOption) (
+		*metadata.ValidationRuleInfo, errors.CCErrorCoder)
+	CreateValidationRule(ctx context.Context, h http.Header, opt *metadata.ValidationRule) (*metadata.RspID,
+		errors.CCErrorCoder)
+	UpdateValidationRule(ctx context.Context, h http.Header, opt *metadata.ValidationRule) errors.CCErrorCoder
+	DeleteValidationRule(ctx context.Context, h http.Header, opt *metadata.DeleteOption) errors.CCErrorCoder
+}
+
+// New model validation rule api client.
+func New(client rest.ClientInterface) Interface {
+	return &validationRule{client: client}
+}
+
+type validationRule struct {
+	client rest.ClientInterface
+}
@@ -66,7 +66,8 @@ type ServiceClientInterface interface {
 	DiffServiceTemplateGeneral(ctx context.Context, h http.Header, opt *metadata.ServiceTemplateDiffOption) (
 		*metadata.ServiceTemplateGeneralDiff, errors.CCErrorCoder)
 	SyncServiceInstanceByTemplate(ctx context.Context, h http.Header,
-		opt *metadata.SyncServiceInstanceByTemplateOption) errors.CCErrorCoder
+		opt *metadata.SyncServiceInstanceByTemplateOption) (*metadata.SyncServiceInstanceByTemplateResult,
+		errors.CCErrorCoder)
 }
 
 // NewServiceClientInterface TODO
@@ -132,11 +132,15 @@ func (s *service) DiffServiceTemplateGeneral(ctx context.Context, h http.Header,
 	return resp.Data, nil
 }
 
-// SyncServiceInstanceByTemplate sync service instance by template
+// SyncServiceInstanceByTemplate sync service instance by template, returns the created sync task of each module
 func (s *service) SyncServiceInstanceByTemplate(ctx context.Context, h http.Header,
-	opt *metadata.SyncServiceInstanceByTemplateOption) errors.CCErrorCoder {
+	opt *metadata.SyncServiceInstanceByTemplateOption) (*metadata.SyncServiceInstanceByTemplateResult,
+	errors.CCErrorCoder) {
 
-	resp := new(metadata.BaseResp)
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.SyncServiceInstanceByTemplateResult `json:"data"`
+	})
 	subPath := "/update/proc/service_instance/sync"
 
 	err := s.client.Put().
@@ -148,13 +152,13 @@ func (s *service) SyncServiceInstanceByTemplate(ctx context.Context, h http.Head
 		Into(resp)
 
 	if err != nil {
-		return errors.CCHttpError
+		return nil, errors.CCHttpError
 	}
 	if err := resp.CCError(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return resp.Data, nil
 }
 
 // ServiceInstanceAddLabels TODO
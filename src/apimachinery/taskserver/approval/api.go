@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package approval defines the change approval client of task server
+package approval
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// ApprovalClientInterface the change approval client interface
+type ApprovalClientInterface interface {
+	// CaptureChangeRequest captures the api server request as a change request if it matches an approval policy
+	CaptureChangeRequest(ctx context.Context, header http.Header, opt *metadata.CaptureChangeRequestOption) (
+		*metadata.CaptureChangeRequestResult, errors.CCErrorCoder)
+
+	// VerifyChangeRequest verifies that the api server request is executing the approved change request
+	VerifyChangeRequest(ctx context.Context, header http.Header,
+		opt *metadata.VerifyChangeRequestOption) errors.CCErrorCoder
+
+	// CheckApproval checks if the sensitive operation can be executed, it fails if the operation needs approval
+	CheckApproval(ctx context.Context, header http.Header, opt *metadata.ApprovalCheckOption) errors.CCErrorCoder
+}
+
+// NewApprovalClientInterface new change approval client
+func NewApprovalClientInterface(client rest.ClientInterface) ApprovalClientInterface {
+	return &approval{client: client}
+}
+
+type approval struct {
+	client rest.ClientInterface
+}
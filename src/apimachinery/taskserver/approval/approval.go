@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package approval
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CaptureChangeRequest captures the api server request as a change request if it matches an approval policy
+func (a *approval) CaptureChangeRequest(ctx context.Context, header http.Header,
+	opt *metadata.CaptureChangeRequestOption) (*metadata.CaptureChangeRequestResult, errors.CCErrorCoder) {
+
+	resp := new(metadata.CaptureChangeRequestResponse)
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/capture/change_request").
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// VerifyChangeRequest verifies that the api server request is executing the approved change request
+func (a *approval) VerifyChangeRequest(ctx context.Context, header http.Header,
+	opt *metadata.VerifyChangeRequestOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/verify/change_request").
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return errors.CCHttpError
+	}
+	return resp.CCError()
+}
+
+// CheckApproval checks if the sensitive operation can be executed, it fails if the operation needs approval
+func (a *approval) CheckApproval(ctx context.Context, header http.Header,
+	opt *metadata.ApprovalCheckOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/check/approval").
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return errors.CCHttpError
+	}
+	return resp.CCError()
+}
@@ -18,6 +18,7 @@ import (
 	"sync"
 
 	"configcenter/src/apimachinery/rest"
+	"configcenter/src/apimachinery/taskserver/approval"
 	"configcenter/src/apimachinery/taskserver/queue"
 	"configcenter/src/apimachinery/taskserver/task"
 	"configcenter/src/apimachinery/util"
@@ -30,6 +31,7 @@ import (
 type TaskServerClientInterface interface {
 	Task() task.TaskClientInterface
 	Queue(flag string) queue.TaskQueueClientInterface
+	Approval() approval.ApprovalClientInterface
 }
 
 // NewProcServerClientInterface TODO
@@ -54,6 +56,11 @@ func (ts *taskServer) Task() task.TaskClientInterface {
 	return task.NewTaskClientInterface(ts.client)
 }
 
+// Approval returns the change approval client
+func (ts *taskServer) Approval() approval.ApprovalClientInterface {
+	return approval.NewApprovalClientInterface(ts.client)
+}
+
 // Queue TODO
 func (ts *taskServer) Queue(flag string) queue.TaskQueueClientInterface {
 	ts.RLock()
@@ -49,6 +49,8 @@ type SetTemplateInterface interface {
 		option metadata.DiffSetTplWithInstOption) (*metadata.SetTplDiffResult, errors.CCErrorCoder)
 	SyncSetTplToInst(ctx context.Context, header http.Header, bizID int64, setTemplateID int64,
 		option *metadata.SyncSetTplToInstOption) errors.CCErrorCoder
+	CheckSetInstUpdateToDateStatus(ctx context.Context, header http.Header, bizID int64, setTemplateID int64) (
+		*metadata.SetTemplateUpdateToDateStatus, errors.CCErrorCoder)
 	UpdateSetTemplateAttr(ctx context.Context, header http.Header,
 		option *metadata.UpdateSetTempAttrOption) errors.CCErrorCoder
 	DeleteSetTemplateAttr(ctx context.Context, header http.Header,
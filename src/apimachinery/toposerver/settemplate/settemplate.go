@@ -360,6 +360,34 @@ func (st *SetTemplate) SyncSetTplToInst(ctx context.Context, header http.Header,
 	return nil
 }
 
+// CheckSetInstUpdateToDateStatus check if the sets of the set template are synchronized with the set template
+func (st *SetTemplate) CheckSetInstUpdateToDateStatus(ctx context.Context, header http.Header, bizID int64,
+	setTemplateID int64) (*metadata.SetTemplateUpdateToDateStatus, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp
+		Data metadata.SetTemplateUpdateToDateStatus `json:"data"`
+	}{}
+	subPath := "/findmany/topo/set_template/%d/bk_biz_id/%d/set_template_status"
+
+	err := st.client.Get().
+		WithContext(ctx).
+		SubResourcef(subPath, setTemplateID, bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("check set template %d sets status failed, http request failed, err: %v", setTemplateID, err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
 // UpdateSetTemplateAttr update set template attribute
 func (st *SetTemplate) UpdateSetTemplateAttr(ctx context.Context, header http.Header,
 	option *metadata.UpdateSetTempAttrOption) errors.CCErrorCoder {
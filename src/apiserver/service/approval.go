@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// approvalFilter captures the sensitive operations that need to be approved as change requests, these requests are
+// not forwarded to the scene servers until they are approved and replayed by the task server with the change request
+// id header, the replayed requests are verified against the captured change requests to prevent forgery.
+func (s *service) approvalFilter(errFunc func() errors.CCErrorIf) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+		rid := httpheader.GetRid(req.Request.Header)
+		defErr := errFunc().CreateDefaultCCErrorIf(httpheader.GetLanguage(req.Request.Header))
+		method, path := req.Request.Method, req.Request.URL.Path
+
+		changeReqID := req.Request.Header.Get(httpheader.ChangeRequestIDHeader)
+		_, _, matched := metadata.MatchApprovalOperation(method, path)
+		if !matched && len(changeReqID) == 0 {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		body, err := util.PeekRequest(req.Request)
+		if err != nil {
+			blog.Errorf("peek request body failed, err: %v, rid: %s", err, rid)
+			s.RespError(req, resp, http.StatusBadRequest, defErr.CCError(common.CCErrCommHTTPReadBodyFailed))
+			return
+		}
+
+		// the request is the execution of an approved change request, verify it before forwarding
+		if len(changeReqID) != 0 {
+			id, err := strconv.ParseInt(changeReqID, 10, 64)
+			if err != nil {
+				blog.Errorf("change request id %s is invalid, err: %v, rid: %s", changeReqID, err, rid)
+				s.writeApprovalResp(req, resp, defErr.CCErrorf(common.CCErrTaskChangeRequestVerifyFailed,
+					changeReqID), nil)
+				return
+			}
+
+			opt := &metadata.VerifyChangeRequestOption{ID: id, Method: method, Path: path, Body: string(body)}
+			if err := s.clientSet.TaskServer().Approval().VerifyChangeRequest(req.Request.Context(),
+				req.Request.Header, opt); err != nil {
+				blog.Errorf("verify change request %d failed, err: %v, rid: %s", id, err, rid)
+				s.writeApprovalResp(req, resp, err, nil)
+				return
+			}
+
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		opt := &metadata.CaptureChangeRequestOption{Method: method, Path: path, Body: string(body)}
+		result, ccErr := s.clientSet.TaskServer().Approval().CaptureChangeRequest(req.Request.Context(),
+			req.Request.Header, opt)
+		if ccErr != nil {
+			// the sensitive operation must not be executed without approval if the capture is failed
+			blog.Errorf("capture change request failed, err: %v, method: %s, path: %s, rid: %s", ccErr, method,
+				path, rid)
+			s.writeApprovalResp(req, resp, ccErr, nil)
+			return
+		}
+
+		if !result.Captured {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		blog.Infof("%s %s is captured as change request %d, rid: %s", method, path, result.ID, rid)
+		s.writeApprovalResp(req, resp, defErr.CCErrorf(common.CCErrTaskChangeRequestPendingApproval, result.ID),
+			metadata.CaptureChangeRequestResult{Captured: true, ID: result.ID})
+	}
+}
+
+func (s *service) writeApprovalResp(req *restful.Request, resp *restful.Response, err errors.CCErrorCoder,
+	data interface{}) {
+
+	s.collectErrorMetric(req)
+	rsp := metadata.Response{
+		BaseResp: metadata.BaseResp{Result: false, Code: err.GetCode(), ErrMsg: err.Error()},
+		Data:     data,
+	}
+	if writeErr := resp.WriteAsJson(rsp); writeErr != nil {
+		blog.Errorf("response request[url: %s] failed, err: %v, rid: %s", req.Request.RequestURI, writeErr,
+			httpheader.GetRid(req.Request.Header))
+	}
+}
@@ -158,9 +158,17 @@ func (s *service) routeNeedAuthAPI(ws *restful.WebService, errFunc func() errors
 
 	}
 
+	// watch stream apis are proxied as streams, so that the events are sent to the client as soon as possible
+	ws.Route(ws.GET("/event/watch/stream/resource/{resource}").Filter(s.authFilter(errFunc)).
+		Filter(s.URLFilterChan).To(s.Stream))
+	ws.Route(ws.POST("/event/watch/stream/resource/{resource}").Filter(s.authFilter(errFunc)).
+		Filter(s.URLFilterChan).To(s.Stream))
+
 	ws.Route(ws.GET("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Get))
-	ws.Route(ws.POST("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Post))
-	ws.Route(ws.PUT("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Put))
+	ws.Route(ws.POST("{.*}").Filter(s.authFilter(errFunc)).Filter(s.approvalFilter(errFunc)).
+		Filter(s.URLFilterChan).To(s.Post))
+	ws.Route(ws.PUT("{.*}").Filter(s.authFilter(errFunc)).Filter(s.approvalFilter(errFunc)).
+		Filter(s.URLFilterChan).To(s.Put))
 	ws.Route(ws.DELETE("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Delete))
 }
 
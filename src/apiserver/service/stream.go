@@ -0,0 +1,63 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// Stream proxies the streaming requests like server-sent events and websocket, the response is flushed to the client
+// as soon as it is received, and the upgraded websocket connection is proxied in both directions.
+func (s *service) Stream(req *restful.Request, resp *restful.Response) {
+	rid := httpheader.GetRid(req.Request.Header)
+	target := &url.URL{Scheme: req.Request.URL.Scheme, Host: req.Request.URL.Host}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			// the request is sent by http client, which does not allow request uri to be set
+			r.Out.RequestURI = ""
+		},
+		Transport:     clientTransport{client: s.client},
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			blog.Errorf("proxy stream request[%s] failed, err: %v, rid: %s", r.URL.String(), err, rid)
+			s.RespError(req, resp, http.StatusInternalServerError, &metadata.RespError{
+				Msg:     fmt.Errorf("proxy request failed, %s", err.Error()),
+				ErrCode: common.CCErrProxyRequestFailed,
+			})
+		},
+	}
+
+	proxy.ServeHTTP(resp, req.Request)
+}
+
+// clientTransport sends the proxy requests with the http client
+type clientTransport struct {
+	client HTTPClient
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (c clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req)
+}
@@ -126,7 +126,8 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 // hostCloudAreaURLRegexp host server operator cloud area api regex
 var hostCloudAreaURLRegexp = regexp.MustCompile(fmt.Sprintf("^/api/v3/(%s)/(cloudarea|cloudarea/.*)$", verbs))
 var hostURLRegexp = regexp.MustCompile(fmt.Sprintf(
-	"^/api/v3/(%s)/(host|hosts|host_apply_rule|host_apply_plan)/.*$", verbs))
+	"^/api/v3/(%s)/(host|hosts|host_apply_rule|host_apply_scope_rule|host_apply_plan|host_lifecycle|host_lease|"+
+		"host_snap)/.*$", verbs))
 
 // WithHost transform the host's url
 func (u *URLPath) WithHost(req *restful.Request) (isHit bool) {
@@ -266,6 +267,19 @@ func (u *URLPath) WithOperation(req *restful.Request) (isHit bool) {
 	return false
 }
 
+var approvalURLRegexp = regexp.MustCompile(fmt.Sprintf("^/api/v3/(%s)/(approval_policy|change_request)(/.*)?$",
+	verbs))
+
+// attributePolicyURLRegexp the task callback api of the attribute policy is not exposed
+var attributePolicyURLRegexp = regexp.MustCompile(fmt.Sprintf(
+	"^/api/v3/((%s)/attribute_policy(/[0-9]+)?|(preview|apply)/attribute_policy)/?$", verbs))
+
+var syncRolloutURLRegexp = regexp.MustCompile(fmt.Sprintf("^/api/v3/(%s)/service_template_sync_rollout(/.*)?$",
+	verbs))
+
+var setTemplateSyncURLRegexp = regexp.MustCompile(fmt.Sprintf(
+	"^/api/v3/(%s)/set_template_sync_(policy|summary)(/.*)?$", verbs))
+
 // WithTask transform task server  url
 func (u *URLPath) WithTask(req *restful.Request) (isHit bool) {
 	statisticsRoot := "/task/v3"
@@ -275,6 +289,18 @@ func (u *URLPath) WithTask(req *restful.Request) (isHit bool) {
 	case strings.HasPrefix(string(*u), rootPath+"/task/"):
 		from, to, isHit = rootPath, statisticsRoot, true
 
+	case approvalURLRegexp.MatchString(string(*u)):
+		from, to, isHit = rootPath, statisticsRoot, true
+
+	case attributePolicyURLRegexp.MatchString(string(*u)):
+		from, to, isHit = rootPath, statisticsRoot, true
+
+	case syncRolloutURLRegexp.MatchString(string(*u)):
+		from, to, isHit = rootPath, statisticsRoot, true
+
+	case setTemplateSyncURLRegexp.MatchString(string(*u)):
+		from, to, isHit = rootPath, statisticsRoot, true
+
 	default:
 		isHit = false
 	}
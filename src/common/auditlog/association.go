@@ -16,6 +16,7 @@ import (
 	"configcenter/src/apimachinery/coreservice"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 )
 
@@ -64,6 +65,11 @@ func (a *instanceAssociationAuditLog) GenerateAuditLog(parameter *generateAuditC
 		return nil, kit.CCError.CCError(common.CCErrAuditTakeSnapshotFailed)
 	}
 
+	var updateAttrs mapstr.MapStr
+	if parameter.action == metadata.AuditUpdate {
+		updateAttrs = parameter.updateFields
+	}
+
 	return &metadata.AuditLog{
 		AuditType:    metadata.ModelInstanceType,
 		ResourceType: metadata.InstanceAssociationRes,
@@ -80,6 +86,8 @@ func (a *instanceAssociationAuditLog) GenerateAuditLog(parameter *generateAuditC
 			TargetModelID:      data.AsstObjectID,
 			TargetInstanceID:   data.AsstInstID,
 			TargetInstanceName: targetInstName,
+			Attributes:         data.Attributes,
+			UpdateAttributes:   updateAttrs,
 		},
 	}, nil
 }
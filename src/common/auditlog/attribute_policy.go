@@ -0,0 +1,52 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common/metadata"
+)
+
+// AttributePolicyAuditLog is audit log handler for attribute policy.
+type AttributePolicyAuditLog struct {
+	audit
+}
+
+// NewAttributePolicyAuditLog new attribute policy audit log handler
+func NewAttributePolicyAuditLog(clientSet coreservice.CoreServiceClientInterface) *AttributePolicyAuditLog {
+	return &AttributePolicyAuditLog{
+		audit: audit{
+			clientSet: clientSet,
+		},
+	}
+}
+
+// GenerateAuditLog generate audit log of attribute policy.
+func (h *AttributePolicyAuditLog) GenerateAuditLog(parameter *generateAuditCommonParameter,
+	policy *metadata.AttributePolicy) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		AuditType:       metadata.AttributePolicyType,
+		ResourceType:    metadata.AttributePolicyRes,
+		Action:          parameter.action,
+		ResourceID:      policy.ID,
+		ResourceName:    policy.Name,
+		OperateFrom:     parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{Data: policy, UpdateFields: parameter.updateFields},
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common/metadata"
+)
+
+// ChangeApprovalAuditLog is audit log handler for approval policy and change request.
+type ChangeApprovalAuditLog struct {
+	audit
+}
+
+// NewChangeApprovalAuditLog new change approval audit log handler
+func NewChangeApprovalAuditLog(clientSet coreservice.CoreServiceClientInterface) *ChangeApprovalAuditLog {
+	return &ChangeApprovalAuditLog{
+		audit: audit{
+			clientSet: clientSet,
+		},
+	}
+}
+
+// GenerateApprovalPolicyAuditLog generate audit log of approval policy.
+func (h *ChangeApprovalAuditLog) GenerateApprovalPolicyAuditLog(parameter *generateAuditCommonParameter,
+	policy *metadata.ApprovalPolicy) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		AuditType:       metadata.ChangeApprovalType,
+		ResourceType:    metadata.ApprovalPolicyRes,
+		Action:          parameter.action,
+		ResourceID:      policy.ID,
+		ResourceName:    policy.Name,
+		OperateFrom:     parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{Data: policy, UpdateFields: parameter.updateFields},
+	}
+}
+
+// GenerateChangeRequestAuditLog generate audit log of change request, the change request is recorded as a whole
+// so that the captured request, the diff and the decision can be traced from the audit log.
+func (h *ChangeApprovalAuditLog) GenerateChangeRequestAuditLog(parameter *generateAuditCommonParameter,
+	request *metadata.ChangeRequest) *metadata.AuditLog {
+
+	var bizID int64
+	if len(request.BizIDs) == 1 {
+		bizID = request.BizIDs[0]
+	}
+
+	return &metadata.AuditLog{
+		AuditType:       metadata.ChangeApprovalType,
+		ResourceType:    metadata.ChangeRequestRes,
+		Action:          parameter.action,
+		BusinessID:      bizID,
+		ResourceID:      request.ID,
+		ResourceName:    string(request.Operation),
+		OperateFrom:     parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{Data: request},
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"strconv"
+
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common/metadata"
+)
+
+// HostLeaseAuditLog is audit log handler for host leases.
+type HostLeaseAuditLog struct {
+	audit
+}
+
+// NewHostLeaseAuditLog new host lease audit log handler
+func NewHostLeaseAuditLog(clientSet coreservice.CoreServiceClientInterface) *HostLeaseAuditLog {
+	return &HostLeaseAuditLog{
+		audit: audit{
+			clientSet: clientSet,
+		},
+	}
+}
+
+// GenerateAuditLog generate audit log of host lease.
+func (h *HostLeaseAuditLog) GenerateAuditLog(parameter *generateAuditCommonParameter,
+	lease *metadata.HostLease) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		AuditType:    metadata.HostLeaseType,
+		ResourceType: metadata.HostLeaseRes,
+		Action:       parameter.action,
+		BusinessID:   lease.BizID,
+		ResourceID:   lease.ID,
+		ResourceName: strconv.FormatInt(lease.ID, 10),
+		OperateFrom:  parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{
+			Data:         lease,
+			UpdateFields: parameter.updateFields,
+		},
+	}
+}
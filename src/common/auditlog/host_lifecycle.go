@@ -0,0 +1,67 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common/metadata"
+)
+
+// HostLifecycleAuditLog is audit log handler for host lifecycle definition and transitions.
+type HostLifecycleAuditLog struct {
+	audit
+}
+
+// NewHostLifecycleAuditLog new host lifecycle audit log handler
+func NewHostLifecycleAuditLog(clientSet coreservice.CoreServiceClientInterface) *HostLifecycleAuditLog {
+	return &HostLifecycleAuditLog{
+		audit: audit{
+			clientSet: clientSet,
+		},
+	}
+}
+
+// GenerateLifecycleAuditLog generate audit log of host lifecycle definition.
+func (h *HostLifecycleAuditLog) GenerateLifecycleAuditLog(parameter *generateAuditCommonParameter,
+	lifecycle *metadata.HostLifecycle) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		AuditType:       metadata.HostLifecycleType,
+		ResourceType:    metadata.HostLifecycleRes,
+		Action:          parameter.action,
+		ResourceName:    string(metadata.HostLifecycleRes),
+		OperateFrom:     parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{Data: lifecycle, UpdateFields: parameter.updateFields},
+	}
+}
+
+// GenerateTransitionAuditLog generate audit log of host lifecycle transition.
+func (h *HostLifecycleAuditLog) GenerateTransitionAuditLog(parameter *generateAuditCommonParameter,
+	history *metadata.HostLifecycleHistory) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		AuditType:       metadata.HostLifecycleType,
+		ResourceType:    metadata.HostLifecycleTransitionRes,
+		Action:          parameter.action,
+		BusinessID:      history.BizID,
+		ResourceID:      history.HostID,
+		ResourceName:    history.InnerIP,
+		OperateFrom:     parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{Data: history},
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"strconv"
+
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common/metadata"
+)
+
+// HostLockAuditLog is audit log handler for host locks.
+type HostLockAuditLog struct {
+	audit
+}
+
+// NewHostLockAuditLog new host lock audit log handler
+func NewHostLockAuditLog(clientSet coreservice.CoreServiceClientInterface) *HostLockAuditLog {
+	return &HostLockAuditLog{
+		audit: audit{
+			clientSet: clientSet,
+		},
+	}
+}
+
+// GenerateForceUnlockAuditLog generate audit log of the host lock that is force released.
+func (h *HostLockAuditLog) GenerateForceUnlockAuditLog(parameter *generateAuditCommonParameter,
+	lock metadata.HostLockData, reason string) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		AuditType:    metadata.HostLockType,
+		ResourceType: metadata.HostLockRes,
+		Action:       parameter.action,
+		ResourceID:   lock.ID,
+		ResourceName: strconv.FormatInt(lock.ID, 10),
+		OperateFrom:  parameter.operateFrom,
+		OperationDetail: &metadata.GenericOpDetail{
+			Data: metadata.HostLockForceUnlockDetail{
+				Lock:   lock,
+				Reason: reason,
+			},
+		},
+	}
+}
@@ -1050,6 +1050,9 @@ const (
 	// FieldTypeIDRule the id rule field type
 	FieldTypeIDRule string = "id_rule"
 
+	// FieldTypeComputed the computed field type, its value is evaluated from a formula expression
+	FieldTypeComputed string = "computed"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -1639,6 +1642,12 @@ const (
 	SyncServiceTemplateHostApplyTaskFlag = "service_template_host_apply_sync"
 	// SyncInstIDRuleTaskFlag  instance id rule async task flag.
 	SyncInstIDRuleTaskFlag = "inst_id_rule_sync"
+	// ExecuteChangeRequestTaskFlag approved change request execution async task flag.
+	ExecuteChangeRequestTaskFlag = "change_request_execute"
+	// ApplyAttributePolicyTaskFlag attribute policy apply async task flag.
+	ApplyAttributePolicyTaskFlag = "attribute_policy_apply"
+	// ReturnHostLeaseTaskFlag expired host lease return async task flag.
+	ReturnHostLeaseTaskFlag = "host_lease_return"
 
 	// BKHostState TODO
 	BKHostState = "bk_state"
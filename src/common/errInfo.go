@@ -516,6 +516,10 @@ const (
 	CCErrTopoFieldTemplateUniqueConflict               = 1101126
 	CCErrTopoBizFieldConflict                          = 1101127
 	CCErrTopoArchiveBusinessHasKube                    = 1101128
+	// CCErrTopoModelSchemaCheckFailed 模型草稿校验不通过[%s]: %s
+	CCErrTopoModelSchemaCheckFailed = 1101129
+	// CCErrTopoModelSchemaVersionNotExist 模型版本[%d]不存在
+	CCErrTopoModelSchemaVersionNotExist = 1101130
 
 	// object controller 1102XXX
 
@@ -564,6 +568,15 @@ const (
 	CCErrEventPushHostIdentifierFailed   = 1103010
 	CCErrEventSyncHostIdentifierDisabled = 1103011
 
+	// CCErrEventSubscriptionNotExist the event webhook subscription is not exist
+	CCErrEventSubscriptionNotExist = 1103012
+	// CCErrEventSubscriptionNameDuplicated the event webhook subscription name is duplicated
+	CCErrEventSubscriptionNameDuplicated = 1103013
+	// CCErrEventNotificationRuleNotExist the event notification rule is not exist
+	CCErrEventNotificationRuleNotExist = 1103014
+	// CCErrEventNotificationRuleNameDuplicated the event notification rule name is duplicated
+	CCErrEventNotificationRuleNameDuplicated = 1103015
+
 	// host 1104XXX
 	CCErrHostModuleRelationAddFailed = 1104000
 
@@ -668,6 +681,12 @@ const (
 
 	CCErrFindServiceTemplateByModuleFailed = 1108046
 
+	// CCErrProcTemplateVersionNotExist template [%v] version [%v] does not exist
+	CCErrProcTemplateVersionNotExist = 1108047
+
+	// CCErrProcBindInfoPortConflict process bind info port conflicts: %v
+	CCErrProcBindInfoPortConflict = 1108048
+
 	// audit log 1109XXX
 	CCErrAuditSaveLogFailed      = 1109001
 	CCErrAuditTakeSnapshotFailed = 1109002
@@ -745,6 +764,28 @@ const (
 	CCErrHostFindManyCloudAreaAddHostCountFieldFail           = 1110065
 	CCErrDeleteDefaultCloudAreaFail                           = 1110066
 	CCErrHostFindManyCloudAreaAddSyncTaskIDsFieldFail         = 1110067
+	// CCErrHostLifecycleStateInvalid the state is not defined in the host lifecycle
+	CCErrHostLifecycleStateInvalid = 1110068
+	// CCErrHostLifecycleTransitionNotAllowed the host lifecycle transition is not allowed
+	CCErrHostLifecycleTransitionNotAllowed = 1110069
+	// CCErrHostLifecycleRequiredFieldEmpty the required field of the host lifecycle state is empty
+	CCErrHostLifecycleRequiredFieldEmpty = 1110070
+	// CCErrHostLifecycleOperatorNotAllowed the user is not allowed to make the host lifecycle transition
+	CCErrHostLifecycleOperatorNotAllowed = 1110071
+	// CCErrHostLeaseNotExist the host lease does not exist
+	CCErrHostLeaseNotExist = 1110072
+	// CCErrHostLeaseHostNotInResDir the host to lease is not in the resource directory
+	CCErrHostLeaseHostNotInResDir = 1110073
+	// CCErrHostLeaseHostAlreadyLeased the host to lease is already in another active lease
+	CCErrHostLeaseHostAlreadyLeased = 1110074
+	// CCErrHostLeaseNotActive the host lease is already returned or expired
+	CCErrHostLeaseNotActive = 1110075
+	// CCErrHostLeaseExpireTimeInvalid the expire time of the host lease is invalid
+	CCErrHostLeaseExpireTimeInvalid = 1110076
+	// CCErrHostLocked the host is locked and the operation is not allowed by the lock scope
+	CCErrHostLocked = 1110077
+	// CCErrHostLockedByOthers the hosts are locked by other users and can only be locked or released by the lock owners
+	CCErrHostLockedByOthers = 1110078
 
 	// web 1111XXX
 	CCErrWebFileNoFound                 = 1111001
@@ -883,6 +924,12 @@ const (
 	CCErrCoreServiceFieldTemplateHasRelation = 1113042
 	// CCErrCoreServiceHostRelateToKube some hosts has related container resources
 	CCErrCoreServiceHostRelateToKube = 1113043
+	// CCErrCoreServiceValidationRuleFailed 实例数据不满足模型校验规则[%s]: %s
+	CCErrCoreServiceValidationRuleFailed = 1113044
+	// CCErrCoreServiceAdmissionDenied resource [%s] operation [%s] is denied by admission webhook [%s]: %s
+	CCErrCoreServiceAdmissionDenied = 1113045
+	// CCErrCoreServiceAdmissionWebhookFailed call admission webhook [%s] failed: %s
+	CCErrCoreServiceAdmissionWebhookFailed = 1113046
 
 	// synchronize data core service  11139xx
 	CCErrCoreServiceSyncError = 1113900
@@ -918,6 +965,41 @@ const (
 	CCErrTaskListTaskFail         = 1117007
 	CCErrTaskCreateConflict       = 1117008
 	CCErrTaskDeleteConflict       = 1117009
+	// CCErrTaskChangeRequestPendingApproval the operation is captured as a change request waiting for approval
+	CCErrTaskChangeRequestPendingApproval = 1117010
+	// CCErrTaskChangeRequestNotExist change request not exist
+	CCErrTaskChangeRequestNotExist = 1117011
+	// CCErrTaskChangeRequestStatusInvalid the status of the change request does not allow the operation
+	CCErrTaskChangeRequestStatusInvalid = 1117012
+	// CCErrTaskChangeRequestNotApprover the user is not the approver of the change request
+	CCErrTaskChangeRequestNotApprover = 1117013
+	// CCErrTaskChangeRequestVerifyFailed the executed request does not match the approved change request
+	CCErrTaskChangeRequestVerifyFailed = 1117014
+	// CCErrTaskApprovalPolicyNotExist approval policy not exist
+	CCErrTaskApprovalPolicyNotExist = 1117015
+	// CCErrTaskApprovalPolicyDuplicated the operation already has an approval policy
+	CCErrTaskApprovalPolicyDuplicated = 1117016
+	// CCErrTaskAttributePolicyNotExist attribute policy not exist
+	CCErrTaskAttributePolicyNotExist = 1117017
+	// CCErrTaskAttributePolicyObjNotSupported the model does not support attribute policy
+	CCErrTaskAttributePolicyObjNotSupported = 1117018
+	// CCErrTaskSyncRolloutNotExist service template sync rollout not exist
+	CCErrTaskSyncRolloutNotExist = 1117019
+	// CCErrTaskSyncRolloutStatusInvalid the operation is not allowed in the current status of the sync rollout
+	CCErrTaskSyncRolloutStatusInvalid = 1117020
+	// CCErrTaskSyncRolloutDuplicated the service template already has an unfinished sync rollout
+	CCErrTaskSyncRolloutDuplicated = 1117021
+	// CCErrTaskSyncRolloutModuleInvalid the module is not created by the service template of the business
+	CCErrTaskSyncRolloutModuleInvalid = 1117022
+	// CCErrTaskSetTemplateSyncPolicyNotExist set template sync policy not exist
+	CCErrTaskSetTemplateSyncPolicyNotExist = 1117023
+	// CCErrTaskSetTemplateSyncPolicyDuplicated the set template already has a sync policy
+	CCErrTaskSetTemplateSyncPolicyDuplicated = 1117024
+	// CCErrTaskSetTemplateSyncPolicySetInvalid the set is not created by the set template of the business
+	CCErrTaskSetTemplateSyncPolicySetInvalid = 1117025
+	// CCErrTaskChangeRequestApprovalRequired the operation needs approval, it should be requested through the api
+	// that captures it as a change request
+	CCErrTaskChangeRequestApprovalRequired = 1117026
 
 	// cloud_server 1118xxx
 	// CCErrCloudVendorNotSupport cloud vendor not support
@@ -76,4 +76,7 @@ const (
 
 	// IsInnerReqHeader is the http header key that represents if request is an inner request
 	IsInnerReqHeader = "X-Bkcmdb-Is-Inner-Request"
+
+	// ChangeRequestIDHeader is the http header key of the approved change request id that the request executes
+	ChangeRequestIDHeader = "X-Bkcmdb-Change-Request-Id"
 )
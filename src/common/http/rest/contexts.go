@@ -464,6 +464,12 @@ func (c *Contexts) RespNoAuth(resp *metadata.BaseResp) {
 	}
 }
 
+// RawResponse returns the raw response writer, it is only used by the apis that write the response by themselves,
+// like the streaming apis.
+func (c *Contexts) RawResponse() *restful.Response {
+	return c.resp
+}
+
 // NewContexts 产生一个新的contexts， 一般用于在创建新的协程的时候，这个时候会对header 做处理，删除不必要的http header。
 func (c *Contexts) NewContexts() *Contexts {
 	newHeader := headerutil.CCHeader(c.Kit.Header)
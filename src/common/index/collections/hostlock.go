@@ -29,7 +29,16 @@ func init() {
 
 //  新加和修改后的索引,索引名字一定要用对应的前缀，CCLogicUniqueIdxNamePrefix|common.CCLogicIndexNamePrefix
 
-var commHostLockIndexes = []types.Index{}
+var commHostLockIndexes = []types.Index{
+	{
+		// the expired host locks are treated as unlocked by the lock checks at once, this index only cleans them up
+		// periodically, the locks that never expire have no expire_time
+		Name:               common.CCLogicIndexNamePrefix + "expireTime",
+		Keys:               bson.D{{"expire_time", 1}},
+		Background:         true,
+		ExpireAfterSeconds: 1,
+	},
+}
 
 // deprecated 未规范化前的索引，只允许删除不允许新加和修改，
 var deprecatedHostLockIndexes = []types.Index{
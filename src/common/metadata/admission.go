@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common/mapstr"
+)
+
+// AdmissionAction is the action of the resource that is admitted by the admission webhooks
+type AdmissionAction string
+
+const (
+	// AdmissionCreate create resource instance action
+	AdmissionCreate AdmissionAction = "create"
+	// AdmissionUpdate update resource instance action
+	AdmissionUpdate AdmissionAction = "update"
+	// AdmissionDelete delete resource instance action
+	AdmissionDelete AdmissionAction = "delete"
+	// AdmissionTransfer transfer host to other modules action, only host resource has this action
+	AdmissionTransfer AdmissionAction = "transfer"
+)
+
+// Validate admission action
+func (a AdmissionAction) Validate() bool {
+	switch a {
+	case AdmissionCreate, AdmissionUpdate, AdmissionDelete, AdmissionTransfer:
+		return true
+	default:
+		return false
+	}
+}
+
+// Mutable returns if the data of the action can be mutated by the mutating webhooks
+func (a AdmissionAction) Mutable() bool {
+	return a == AdmissionCreate || a == AdmissionUpdate
+}
+
+// AdmissionRequest is the request that is posted to the admission webhooks
+type AdmissionRequest struct {
+	// UID identifies the admission request, the admission response must contain the same uid
+	UID string `json:"uid"`
+	// Resource is the object id of the instance, e.g. host, biz, set or the custom object id
+	Resource string          `json:"resource"`
+	Action   AdmissionAction `json:"action"`
+	User     string          `json:"user"`
+	OwnerID  string          `json:"bk_supplier_account"`
+	AppCode  string          `json:"app_code,omitempty"`
+	Rid      string          `json:"rid"`
+	// OldObject is the instance data before the change, it is empty for create action.
+	// for host transfer action, it contains bk_host_id, bk_biz_id and bk_module_id of the current relations.
+	OldObject mapstr.MapStr `json:"old_object,omitempty"`
+	// Object is the new data of the change, it is the whole instance for create action and the update data for
+	// update action, it is empty for delete action.
+	// for host transfer action, it contains bk_host_id, bk_biz_id and bk_module_id of the target relations.
+	Object mapstr.MapStr `json:"object,omitempty"`
+}
+
+// AdmissionResponse is the response of the admission webhooks
+type AdmissionResponse struct {
+	UID     string `json:"uid"`
+	Allowed bool   `json:"allowed"`
+	// Message is the reason why the request is not allowed
+	Message string `json:"message,omitempty"`
+	// Patch is merged into the object of the request, a null value removes the field from the object,
+	// it is only used for mutating webhooks and create or update actions.
+	Patch mapstr.MapStr `json:"patch,omitempty"`
+}
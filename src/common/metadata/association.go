@@ -237,6 +237,8 @@ type CreateAssociationInstRequest struct {
 	ObjectAsstID string `field:"bk_obj_asst_id" json:"bk_obj_asst_id,omitempty" bson:"bk_obj_asst_id,omitempty"`
 	InstID       int64  `field:"bk_inst_id" json:"bk_inst_id,omitempty" bson:"bk_inst_id,omitempty"`
 	AsstInstID   int64  `field:"bk_asst_inst_id" json:"bk_asst_inst_id,omitempty" bson:"bk_asst_inst_id,omitempty"`
+	// Attributes the attribute values of the instance association
+	Attributes mapstr.MapStr `field:"bk_asst_attrs" json:"bk_asst_attrs,omitempty" bson:"bk_asst_attrs,omitempty"`
 }
 
 // CreateAssociationInstResult TODO
@@ -485,6 +487,8 @@ type Association struct {
 	// describe whether this association is a pre-defined association or not,
 	// if true, it means this association is used by cmdb itself.
 	IsPre *bool `field:"ispre" json:"ispre" bson:"ispre"`
+	// the attributes of the instance associations that belongs to this association.
+	Attributes []AssociationAttribute `field:"bk_asst_attributes" json:"bk_asst_attributes,omitempty" bson:"bk_asst_attributes,omitempty"`
 }
 
 // CanUpdate TODO
@@ -518,7 +522,7 @@ func (a *Association) CanUpdate() (field string, can bool) {
 		return "ispre", false
 	}
 
-	// only on delete, association kind id, alias name can be update.
+	// only on delete, association kind id, alias name and attributes can be update.
 	return "", true
 }
 
@@ -568,6 +572,8 @@ type InstAsst struct {
 
 	// BizID the business ID
 	BizID int64 `field:"bk_biz_id" json:"bk_biz_id,omitempty" bson:"bk_biz_id"`
+	// Attributes the attribute values of this association, defined by the object association's attributes
+	Attributes mapstr.MapStr `field:"bk_asst_attrs" json:"bk_asst_attrs,omitempty" bson:"bk_asst_attrs,omitempty"`
 }
 
 // GetInstID TODO
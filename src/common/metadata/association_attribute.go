@@ -0,0 +1,201 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// AssociationFieldAttributes the attribute definitions field of the object association
+	AssociationFieldAttributes = "bk_asst_attributes"
+	// InstAsstFieldAttributes the attribute values field of the instance association
+	InstAsstFieldAttributes = "bk_asst_attrs"
+	// AssociationAttributeMaxNum the max number of attributes that an object association can define
+	AssociationAttributeMaxNum = 20
+)
+
+// AssociationAttribute defines an attribute of the instance association, the attributes are defined on the object
+// association, and the values are stored in the instance associations that belongs to this object association.
+type AssociationAttribute struct {
+	PropertyID   string      `json:"bk_property_id" bson:"bk_property_id"`
+	PropertyName string      `json:"bk_property_name" bson:"bk_property_name"`
+	PropertyType string      `json:"bk_property_type" bson:"bk_property_type"`
+	IsRequired   bool        `json:"isrequired" bson:"isrequired"`
+	IsMultiple   *bool       `json:"ismultiple,omitempty" bson:"ismultiple,omitempty"`
+	Option       interface{} `json:"option,omitempty" bson:"option,omitempty"`
+	Unit         string      `json:"unit,omitempty" bson:"unit,omitempty"`
+	Placeholder  string      `json:"placeholder,omitempty" bson:"placeholder,omitempty"`
+}
+
+// ToAttribute convert the association attribute to object attribute, so that the values can be validated by the
+// same rules as the object attributes.
+func (a *AssociationAttribute) ToAttribute() *Attribute {
+	return &Attribute{
+		PropertyID:   a.PropertyID,
+		PropertyName: a.PropertyName,
+		PropertyType: a.PropertyType,
+		IsRequired:   a.IsRequired,
+		IsMultiple:   a.IsMultiple,
+		Option:       a.Option,
+		Unit:         a.Unit,
+		Placeholder:  a.Placeholder,
+		IsEditable:   true,
+	}
+}
+
+// Validate the association attribute definition, the option is validated by the caller with the property type
+func (a *AssociationAttribute) Validate() errors.RawErrorInfo {
+	a.PropertyID = strings.TrimSpace(a.PropertyID)
+	if a.PropertyID == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKPropertyIDField}}
+	}
+
+	if utf8.RuneCountInString(a.PropertyID) > common.AttributeIDMaxLength {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKPropertyIDField, common.AttributeIDMaxLength}}
+	}
+
+	match, err := regexp.MatchString(common.FieldTypeStrictCharRegexp, a.PropertyID)
+	if err != nil || !match {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{a.PropertyID}}
+	}
+
+	a.PropertyName = strings.TrimSpace(a.PropertyName)
+	if a.PropertyName == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKPropertyNameField}}
+	}
+
+	if utf8.RuneCountInString(a.PropertyName) > common.AttributeNameMaxLength {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKPropertyNameField, common.AttributeNameMaxLength}}
+	}
+
+	if utf8.RuneCountInString(a.Unit) > common.AttributeUnitMaxLength {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{AttributeFieldUnit, common.AttributeUnitMaxLength}}
+	}
+
+	// only simple field types are supported, the association attributes are not used as model relation or table
+	switch a.PropertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
+		common.FieldTypeEnum, common.FieldTypeEnumMulti, common.FieldTypeDate, common.FieldTypeTime,
+		common.FieldTypeUser, common.FieldTypeBool, common.FieldTypeList:
+	default:
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid,
+			Args: []interface{}{AttributeFieldPropertyType}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ValidateAssociationAttributes validate the attribute definitions of an object association
+func ValidateAssociationAttributes(attrs []AssociationAttribute) errors.RawErrorInfo {
+	if len(attrs) > AssociationAttributeMaxNum {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{AssociationFieldAttributes, AssociationAttributeMaxNum}}
+	}
+
+	propertyIDs := make(map[string]struct{})
+	for idx := range attrs {
+		if rawErr := attrs[idx].Validate(); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+
+		if _, exists := propertyIDs[attrs[idx].PropertyID]; exists {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommDuplicateItem,
+				Args: []interface{}{attrs[idx].PropertyID}}
+		}
+		propertyIDs[attrs[idx].PropertyID] = struct{}{}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// UpdateInstAsstAttrsRequest is the request to update the attribute values of an instance association
+type UpdateInstAsstAttrsRequest struct {
+	// Attributes the attribute values to update, they are merged into the existing values, and the value that is
+	// set to null removes the attribute value.
+	Attributes mapstr.MapStr `json:"bk_asst_attrs"`
+}
+
+// Validate update instance association attributes request
+func (r *UpdateInstAsstAttrsRequest) Validate() errors.RawErrorInfo {
+	if len(r.Attributes) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{InstAsstFieldAttributes}}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// UpdateInstAsstAttrsOption is the core service option to set the attribute values of an instance association
+type UpdateInstAsstAttrsOption struct {
+	ObjectID   string        `json:"bk_obj_id"`
+	ID         int64         `json:"id"`
+	Attributes mapstr.MapStr `json:"bk_asst_attrs"`
+}
+
+// MergeInstAsstAttrs merges the updated attribute values into the existing values of the instance association, the
+// attribute whose updated value is null is removed.
+func MergeInstAsstAttrs(prev, update mapstr.MapStr) mapstr.MapStr {
+	merged := make(mapstr.MapStr)
+	for key, val := range prev {
+		merged[key] = val
+	}
+
+	for key, val := range update {
+		if val == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = val
+	}
+
+	return merged
+}
+
+// ValidateAttrValues validate the instance association attribute values by the attributes defined in the object
+// association, the values that are not defined in the object association are not allowed.
+func (a *Association) ValidateAttrValues(ctx context.Context, values mapstr.MapStr) errors.RawErrorInfo {
+	attrMap := make(map[string]*Attribute)
+	for idx := range a.Attributes {
+		attrMap[a.Attributes[idx].PropertyID] = a.Attributes[idx].ToAttribute()
+	}
+
+	for key := range values {
+		if _, exists := attrMap[key]; !exists {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid,
+				Args: []interface{}{InstAsstFieldAttributes + "." + key}}
+		}
+	}
+
+	for key, attr := range attrMap {
+		if rawErr := attr.Validate(ctx, values[key], key); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func TestMergeInstAsstAttrs(t *testing.T) {
+	tests := []struct {
+		name   string
+		prev   mapstr.MapStr
+		update mapstr.MapStr
+		want   mapstr.MapStr
+	}{
+		{"no previous values", nil, mapstr.MapStr{"port": 80}, mapstr.MapStr{"port": 80}},
+		{"update and keep values", mapstr.MapStr{"port": 80, "weight": 1}, mapstr.MapStr{"port": 8080},
+			mapstr.MapStr{"port": 8080, "weight": 1}},
+		{"null value removes attribute", mapstr.MapStr{"port": 80, "weight": 1}, mapstr.MapStr{"weight": nil},
+			mapstr.MapStr{"port": 80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeInstAsstAttrs(tt.prev, tt.update); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeInstAsstAttrs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssociationValidateAttrValues(t *testing.T) {
+	asst := &Association{
+		Attributes: []AssociationAttribute{
+			{PropertyID: "port", PropertyName: "port", PropertyType: common.FieldTypeInt, IsRequired: true},
+			{PropertyID: "remark", PropertyName: "remark", PropertyType: common.FieldTypeSingleChar},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		values  mapstr.MapStr
+		wantErr bool
+	}{
+		{"valid values", mapstr.MapStr{"port": 80, "remark": "uplink"}, false},
+		{"optional value is not set", mapstr.MapStr{"port": 80}, false},
+		{"required value is not set", mapstr.MapStr{"remark": "uplink"}, true},
+		{"value type is invalid", mapstr.MapStr{"port": "eighty"}, true},
+		{"attribute is not defined", mapstr.MapStr{"port": 80, "weight": 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawErr := asst.ValidateAttrValues(context.Background(), tt.values)
+			if (rawErr.ErrCode != 0) != tt.wantErr {
+				t.Errorf("ValidateAttrValues() error = %v, wantErr %v", rawErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAssociationAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   []AssociationAttribute
+		wantErr bool
+	}{
+		{"valid attributes", []AssociationAttribute{{PropertyID: "port", PropertyName: "port",
+			PropertyType: common.FieldTypeInt}}, false},
+		{"duplicate property id", []AssociationAttribute{
+			{PropertyID: "port", PropertyName: "port", PropertyType: common.FieldTypeInt},
+			{PropertyID: "port", PropertyName: "port2", PropertyType: common.FieldTypeInt}}, true},
+		{"unsupported property type", []AssociationAttribute{{PropertyID: "rel", PropertyName: "rel",
+			PropertyType: common.FieldTypeOrganization}}, true},
+		{"empty property name", []AssociationAttribute{{PropertyID: "port",
+			PropertyType: common.FieldTypeInt}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawErr := ValidateAssociationAttributes(tt.attrs)
+			if (rawErr.ErrCode != 0) != tt.wantErr {
+				t.Errorf("ValidateAssociationAttributes() error = %v, wantErr %v", rawErr, tt.wantErr)
+			}
+		})
+	}
+}
@@ -188,6 +188,7 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		common.FieldTypeOrganization: attribute.validOrganization,
 		common.FieldTypeInnerTable:   attribute.validInnerTable,
 		common.FieldTypeIDRule:       attribute.validIDRule,
+		common.FieldTypeComputed:     attribute.validComputed,
 	}
 
 	rawError := errors.RawErrorInfo{}
@@ -0,0 +1,314 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// AttributePolicyNameMaxLength the max length of the attribute policy name
+	AttributePolicyNameMaxLength = 128
+	// AttributePolicyMaxBizIDs the max number of the businesses in an attribute policy condition
+	AttributePolicyMaxBizIDs = 100
+	// AttributePolicyMaxAssociations the max number of the association conditions of an attribute policy
+	AttributePolicyMaxAssociations = 10
+	// AttributePolicyMaxAsstInstIDs the max number of the associated instances in an association condition
+	AttributePolicyMaxAsstInstIDs = 500
+)
+
+// AttributePolicyMode is the way that an attribute policy sets the attribute value of the instances
+type AttributePolicyMode string
+
+const (
+	// AttributePolicyDefault the policy value is only set when the attribute of the instance is empty
+	AttributePolicyDefault AttributePolicyMode = "default"
+	// AttributePolicyEnforce the policy value always overwrites the attribute value of the instance
+	AttributePolicyEnforce AttributePolicyMode = "enforce"
+)
+
+// Validate attribute policy mode
+func (m AttributePolicyMode) Validate() bool {
+	return m == AttributePolicyDefault || m == AttributePolicyEnforce
+}
+
+// AttributePolicyAsstCond matches the instances that are associated with the specified instances of a model
+type AttributePolicyAsstCond struct {
+	AsstObjID string `json:"bk_asst_obj_id" bson:"bk_asst_obj_id"`
+	// AsstInstIDs are the associated instance ids, empty means associated with any instance of the model
+	AsstInstIDs []int64 `json:"bk_asst_inst_ids" bson:"bk_asst_inst_ids"`
+}
+
+// AttributePolicyCondition is the condition of the instances that an attribute policy applies to,
+// all conditions must be matched, empty condition matches all instances of the model
+type AttributePolicyCondition struct {
+	// BizIDs matches the instances whose bk_biz_id is in it or that are associated with one of the businesses
+	BizIDs []int64 `json:"bk_biz_ids" bson:"bk_biz_ids"`
+	// Associations matches the instances that are associated with the instances of all the association conditions
+	Associations []AttributePolicyAsstCond `json:"associations" bson:"associations"`
+}
+
+// Validate attribute policy condition
+func (c *AttributePolicyCondition) Validate(objID string) ccErr.RawErrorInfo {
+	if len(c.BizIDs) > AttributePolicyMaxBizIDs {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{AttributePolicyMaxBizIDs}}
+	}
+
+	if len(c.Associations) > AttributePolicyMaxAssociations {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{AttributePolicyMaxAssociations}}
+	}
+
+	for _, asst := range c.Associations {
+		if len(asst.AsstObjID) == 0 || asst.AsstObjID == objID {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+				Args: []interface{}{"associations.bk_asst_obj_id"}}
+		}
+
+		if len(asst.AsstInstIDs) > AttributePolicyMaxAsstInstIDs {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+				Args: []interface{}{AttributePolicyMaxAsstInstIDs}}
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// AttributePolicy sets the default or enforced value of a model attribute for the instances that match the condition
+type AttributePolicy struct {
+	ID            int64                    `json:"id" bson:"id"`
+	Name          string                   `json:"name" bson:"name"`
+	ObjID         string                   `json:"bk_obj_id" bson:"bk_obj_id"`
+	PropertyID    string                   `json:"bk_property_id" bson:"bk_property_id"`
+	PropertyValue interface{}              `json:"bk_property_value" bson:"bk_property_value"`
+	Mode          AttributePolicyMode      `json:"mode" bson:"mode"`
+	Condition     AttributePolicyCondition `json:"condition" bson:"condition"`
+	// AutoApply defines that the policy is applied automatically when an instance is created or re-associated
+	AutoApply  bool   `json:"auto_apply" bson:"auto_apply"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string `json:"creator" bson:"creator"`
+	Modifier   string `json:"modifier" bson:"modifier"`
+	CreateTime Time   `json:"create_time" bson:"create_time"`
+	LastTime   Time   `json:"last_time" bson:"last_time"`
+}
+
+// Validate attribute policy, the model and the attribute of the policy can not be changed, and the property value is
+// validated by the attribute, so they are validated separately
+func (p *AttributePolicy) Validate() ccErr.RawErrorInfo {
+	if len(p.Name) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	if len(p.Name) > AttributePolicyNameMaxLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKFieldName, AttributePolicyNameMaxLength}}
+	}
+
+	if len(p.Mode) == 0 {
+		p.Mode = AttributePolicyDefault
+	}
+
+	if !p.Mode.Validate() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"mode"}}
+	}
+
+	return p.Condition.Validate(p.ObjID)
+}
+
+// IsEmptyValue returns if the attribute value is regarded as not set by the default mode policy
+func IsEmptyValue(value interface{}) bool {
+	switch val := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(val) == 0
+	case []interface{}:
+		return len(val) == 0
+	default:
+		return false
+	}
+}
+
+// AttributePolicyInstRelation is the business and associations of an instance used to match the policy condition
+type AttributePolicyInstRelation struct {
+	BizID int64
+	// Associations is the associated object id to instance ids map
+	Associations map[string]map[int64]struct{}
+}
+
+// Match returns if the instance relation matches the attribute policy condition
+func (c *AttributePolicyCondition) Match(relation *AttributePolicyInstRelation) bool {
+	if len(c.BizIDs) > 0 {
+		matched := false
+		for _, bizID := range c.BizIDs {
+			if bizID == relation.BizID {
+				matched = true
+				break
+			}
+			if _, exists := relation.Associations[common.BKInnerObjIDApp][bizID]; exists {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, asst := range c.Associations {
+		asstInstMap := relation.Associations[asst.AsstObjID]
+		if len(asstInstMap) == 0 {
+			return false
+		}
+
+		if len(asst.AsstInstIDs) == 0 {
+			continue
+		}
+
+		matched := false
+		for _, instID := range asst.AsstInstIDs {
+			if _, exists := asstInstMap[instID]; exists {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AttributePolicyChange is an attribute change of an instance that is caused by an attribute policy
+type AttributePolicyChange struct {
+	PolicyID   int64       `json:"policy_id"`
+	PropertyID string      `json:"bk_property_id"`
+	Before     interface{} `json:"before"`
+	After      interface{} `json:"after"`
+}
+
+// AttributePolicyInstChange is the attribute changes of an instance that are caused by the attribute policies
+type AttributePolicyInstChange struct {
+	InstID   int64                   `json:"bk_inst_id"`
+	InstName string                  `json:"bk_inst_name"`
+	Changes  []AttributePolicyChange `json:"changes"`
+}
+
+// UpdateData returns the instance update data of the changes
+func (c *AttributePolicyInstChange) UpdateData() mapstr.MapStr {
+	data := make(mapstr.MapStr)
+	for _, change := range c.Changes {
+		data[change.PropertyID] = change.After
+	}
+	return data
+}
+
+// ComputeAttributePolicyChange compute the attribute changes of the instance by the policies of the same model, the
+// policies must be sorted by id, for one attribute the first policy that matches the instance takes effect
+func ComputeAttributePolicyChange(policies []AttributePolicy, inst mapstr.MapStr,
+	relation *AttributePolicyInstRelation, isEqual func(a, b interface{}) bool) []AttributePolicyChange {
+
+	changes := make([]AttributePolicyChange, 0)
+	handled := make(map[string]struct{})
+	for idx := range policies {
+		policy := &policies[idx]
+		if _, exists := handled[policy.PropertyID]; exists {
+			continue
+		}
+
+		if !policy.Condition.Match(relation) {
+			continue
+		}
+		handled[policy.PropertyID] = struct{}{}
+
+		value := inst[policy.PropertyID]
+		if policy.Mode == AttributePolicyDefault && !IsEmptyValue(value) {
+			continue
+		}
+
+		if isEqual(value, policy.PropertyValue) {
+			continue
+		}
+
+		changes = append(changes, AttributePolicyChange{
+			PolicyID:   policy.ID,
+			PropertyID: policy.PropertyID,
+			Before:     value,
+			After:      policy.PropertyValue,
+		})
+	}
+
+	return changes
+}
+
+// SearchAttributePolicyOption search attribute policy option
+type SearchAttributePolicyOption struct {
+	ObjID      string   `json:"bk_obj_id"`
+	PropertyID string   `json:"bk_property_id"`
+	Page       BasePage `json:"page"`
+}
+
+// Validate search attribute policy option
+func (o *SearchAttributePolicyOption) Validate() ccErr.RawErrorInfo {
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchAttributePolicyResult search attribute policy result
+type SearchAttributePolicyResult struct {
+	Count uint64            `json:"count"`
+	Info  []AttributePolicy `json:"info"`
+}
+
+// AttributePolicyApplyOption preview or apply the attribute policies of a model option
+type AttributePolicyApplyOption struct {
+	ObjID string `json:"bk_obj_id"`
+	// PolicyIDs are the policies to apply, empty means all policies of the model
+	PolicyIDs []int64 `json:"policy_ids"`
+	// InstIDs are the instances to apply the policies to, empty means all instances of the model
+	InstIDs []int64 `json:"bk_inst_ids"`
+}
+
+// Validate attribute policy preview or apply option
+func (o *AttributePolicyApplyOption) Validate() ccErr.RawErrorInfo {
+	if len(o.ObjID) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if len(o.PolicyIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxLimitSize}}
+	}
+
+	if len(o.InstIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxLimitSize}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// AttributePolicyPreviewResult preview attribute policies result, only the instances that will be changed are
+// returned, at most BKMaxLimitSize instances are returned and Count is the total number of them
+type AttributePolicyPreviewResult struct {
+	Count int                         `json:"count"`
+	Info  []AttributePolicyInstChange `json:"info"`
+}
+
+// AttributePolicyApplyResult apply attribute policies result
+type AttributePolicyApplyResult struct {
+	TaskID string `json:"task_id"`
+}
@@ -18,6 +18,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/querybuilder"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -266,6 +267,7 @@ var resTypeOpDetailTypeMap = map[ResourceType]DetailFactory{
 	ServiceInstanceRes:     new(ServiceInstanceOpDetail),
 	QuotedInst:             new(QuotedInstOpDetail),
 	ModelUniqueRes:         new(GenericOpDetail),
+	ModelValidationRuleRes: new(GenericOpDetail),
 }
 
 // UnmarshalJSON unmarshal AuditLog
@@ -304,7 +306,8 @@ func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 	}
 
 	switch audit.AuditType {
-	case KubeType, FieldTemplateType:
+	case KubeType, FieldTemplateType, ChangeApprovalType, AttributePolicyType, HostLifecycleType, HostLeaseType,
+		HostLockType:
 		operationDetail := new(GenericOpDetail)
 		if err := json.Unmarshal(audit.OperationDetail, &operationDetail); err != nil {
 			return err
@@ -368,7 +371,8 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 	}
 
 	switch audit.AuditType {
-	case KubeType, FieldTemplateType:
+	case KubeType, FieldTemplateType, ChangeApprovalType, AttributePolicyType, HostLifecycleType, HostLeaseType,
+		HostLockType:
 		operationDetail := new(GenericOpDetail)
 		if err := bson.Unmarshal(audit.OperationDetail, &operationDetail); err != nil {
 			return err
@@ -499,6 +503,10 @@ type InstanceAssociationOpDetail struct {
 	TargetInstanceID int64 `json:"dest_inst_id" bson:"dest_inst_id"`
 	// TargetInstanceID the target instance name
 	TargetInstanceName string `json:"dest_inst_name" bson:"dest_inst_name"`
+	// Attributes the attribute values of the instance association
+	Attributes mapstr.MapStr `json:"bk_asst_attrs,omitempty" bson:"bk_asst_attrs,omitempty"`
+	// UpdateAttributes the updated attribute values of the instance association, only set for update action
+	UpdateAttributes mapstr.MapStr `json:"update_bk_asst_attrs,omitempty" bson:"update_bk_asst_attrs,omitempty"`
 }
 
 // WithName TODO
@@ -661,6 +669,21 @@ const (
 
 	// PlatformSetting is platform setting audit type
 	PlatformSetting AuditType = "platform_setting"
+
+	// ChangeApprovalType is change approval audit type, including approval policy and change request
+	ChangeApprovalType AuditType = "change_approval"
+
+	// AttributePolicyType is attribute policy audit type
+	AttributePolicyType AuditType = "attribute_policy"
+
+	// HostLifecycleType is host lifecycle audit type, including the lifecycle definition and the host transitions
+	HostLifecycleType AuditType = "host_lifecycle"
+
+	// HostLeaseType is host lease audit type
+	HostLeaseType AuditType = "host_lease"
+
+	// HostLockType is host lock audit type
+	HostLockType AuditType = "host_lock"
 )
 
 // ResourceType TODO
@@ -717,6 +740,8 @@ const (
 	ModelGroupRes ResourceType = "model_group"
 	// ModelUniqueRes TODO
 	ModelUniqueRes ResourceType = "model_unique"
+	// ModelValidationRuleRes is model validation rule related audit resource type
+	ModelValidationRuleRes ResourceType = "model_validation_rule"
 	// ResourceDirectoryRes TODO
 	ResourceDirectoryRes ResourceType = "resource_directory"
 
@@ -767,6 +792,27 @@ const (
 
 	// PlatformSettingRes is platform setting audit resource type
 	PlatformSettingRes ResourceType = "platform_setting"
+
+	// ApprovalPolicyRes is approval policy related audit resource type
+	ApprovalPolicyRes ResourceType = "approval_policy"
+
+	// ChangeRequestRes is change request related audit resource type
+	ChangeRequestRes ResourceType = "change_request"
+
+	// AttributePolicyRes is attribute policy related audit resource type
+	AttributePolicyRes ResourceType = "attribute_policy"
+
+	// HostLifecycleRes is host lifecycle definition related audit resource type
+	HostLifecycleRes ResourceType = "host_lifecycle"
+
+	// HostLifecycleTransitionRes is host lifecycle transition related audit resource type
+	HostLifecycleTransitionRes ResourceType = "host_lifecycle_transition"
+
+	// HostLeaseRes is host lease related audit resource type
+	HostLeaseRes ResourceType = "host_lease"
+
+	// HostLockRes is host lock related audit resource type
+	HostLockRes ResourceType = "host_lock"
 )
 
 // OperateFromType TODO
@@ -819,6 +865,16 @@ const (
 	// AuditResume TODO
 	// resume using an object
 	AuditResume ActionType = "resume"
+	// AuditApprove approve a change request
+	AuditApprove ActionType = "approve"
+	// AuditReject reject a change request
+	AuditReject ActionType = "reject"
+	// AuditCancel cancel a change request
+	AuditCancel ActionType = "cancel"
+	// AuditExecute execute an approved change request
+	AuditExecute ActionType = "execute"
+	// AuditExpire expire a change request that is not approved in time
+	AuditExpire ActionType = "expire"
 )
 
 // GetAuditTypeByObjID TODO
@@ -887,10 +943,10 @@ func GetAuditTypesByCategory(category string) []AuditType {
 	case "resource":
 		return []AuditType{BusinessType, BizSetType, ProjectType, ModelInstanceType, CloudResourceType, KubeType}
 	case "host":
-		return []AuditType{HostType}
+		return []AuditType{HostType, HostLifecycleType, HostLeaseType, HostLockType}
 	case "other":
 		return []AuditType{ModelType, AssociationKindType, EventPushType, DynamicGroupType, PlatFormSettingType,
-			FieldTemplateType}
+			FieldTemplateType, ChangeApprovalType, AttributePolicyType}
 	}
 	return []AuditType{}
 }
@@ -1057,6 +1113,15 @@ var auditDict = []resourceTypeInfo{
 			actionInfoMap[AuditDelete],
 		},
 	},
+	{
+		ID:   ModelValidationRuleRes,
+		Name: "模型校验规则",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditUpdate],
+			actionInfoMap[AuditDelete],
+		},
+	},
 	{
 		ID:   CloudAccountRes,
 		Name: "云账户",
@@ -1120,6 +1185,65 @@ var auditDict = []resourceTypeInfo{
 			actionInfoMap[AuditDelete],
 		},
 	},
+	{
+		ID:   ApprovalPolicyRes,
+		Name: "审批策略",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditUpdate],
+			actionInfoMap[AuditDelete],
+		},
+	},
+	{
+		ID:   ChangeRequestRes,
+		Name: "变更申请",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditApprove],
+			actionInfoMap[AuditReject],
+			actionInfoMap[AuditCancel],
+			actionInfoMap[AuditExecute],
+			actionInfoMap[AuditExpire],
+		},
+	},
+	{
+		ID:   AttributePolicyRes,
+		Name: "属性策略",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditUpdate],
+			actionInfoMap[AuditDelete],
+		},
+	},
+	{
+		ID:   HostLifecycleRes,
+		Name: "主机生命周期",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditUpdate],
+		},
+	},
+	{
+		ID:   HostLifecycleTransitionRes,
+		Name: "主机生命周期流转",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditUpdate],
+		},
+	},
+	{
+		ID:   HostLeaseRes,
+		Name: "主机租约",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditUpdate],
+		},
+	},
+	{
+		ID:   HostLockRes,
+		Name: "主机锁",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditDelete],
+		},
+	},
 }
 
 // 注意：记得在actionInfoEnMap中添加对应的英文
@@ -1134,6 +1258,11 @@ var actionInfoMap = map[ActionType]actionTypeInfo{
 	AuditRecover:            {ID: AuditRecover, Name: "恢复"},
 	AuditPause:              {ID: AuditPause, Name: "停用"},
 	AuditResume:             {ID: AuditResume, Name: "启用"},
+	AuditApprove:            {ID: AuditApprove, Name: "审批通过"},
+	AuditReject:             {ID: AuditReject, Name: "审批拒绝"},
+	AuditCancel:             {ID: AuditCancel, Name: "撤销"},
+	AuditExecute:            {ID: AuditExecute, Name: "执行"},
+	AuditExpire:             {ID: AuditExpire, Name: "过期"},
 }
 
 type resourceTypeInfo struct {
@@ -1320,6 +1449,15 @@ var auditEnDict = []resourceTypeInfo{
 			actionInfoEnMap[AuditDelete],
 		},
 	},
+	{
+		ID:   ModelValidationRuleRes,
+		Name: "Model Validation Rule",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditCreate],
+			actionInfoEnMap[AuditUpdate],
+			actionInfoEnMap[AuditDelete],
+		},
+	},
 	{
 		ID:   CloudAccountRes,
 		Name: "Cloud Account",
@@ -1383,6 +1521,65 @@ var auditEnDict = []resourceTypeInfo{
 			actionInfoEnMap[AuditDelete],
 		},
 	},
+	{
+		ID:   ApprovalPolicyRes,
+		Name: "Approval Policy",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditCreate],
+			actionInfoEnMap[AuditUpdate],
+			actionInfoEnMap[AuditDelete],
+		},
+	},
+	{
+		ID:   ChangeRequestRes,
+		Name: "Change Request",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditCreate],
+			actionInfoEnMap[AuditApprove],
+			actionInfoEnMap[AuditReject],
+			actionInfoEnMap[AuditCancel],
+			actionInfoEnMap[AuditExecute],
+			actionInfoEnMap[AuditExpire],
+		},
+	},
+	{
+		ID:   AttributePolicyRes,
+		Name: "Attribute Policy",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditCreate],
+			actionInfoEnMap[AuditUpdate],
+			actionInfoEnMap[AuditDelete],
+		},
+	},
+	{
+		ID:   HostLifecycleRes,
+		Name: "Host Lifecycle",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditUpdate],
+		},
+	},
+	{
+		ID:   HostLifecycleTransitionRes,
+		Name: "Host Lifecycle Transition",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditUpdate],
+		},
+	},
+	{
+		ID:   HostLeaseRes,
+		Name: "Host Lease",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditCreate],
+			actionInfoEnMap[AuditUpdate],
+		},
+	},
+	{
+		ID:   HostLockRes,
+		Name: "Host Lock",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditDelete],
+		},
+	},
 }
 
 var actionInfoEnMap = map[ActionType]actionTypeInfo{
@@ -1396,4 +1593,9 @@ var actionInfoEnMap = map[ActionType]actionTypeInfo{
 	AuditRecover:            {ID: AuditRecover, Name: "Recover"},
 	AuditPause:              {ID: AuditPause, Name: "Pause"},
 	AuditResume:             {ID: AuditResume, Name: "Resume"},
+	AuditApprove:            {ID: AuditApprove, Name: "Approve"},
+	AuditReject:             {ID: AuditReject, Name: "Reject"},
+	AuditCancel:             {ID: AuditCancel, Name: "Cancel"},
+	AuditExecute:            {ID: AuditExecute, Name: "Execute"},
+	AuditExpire:             {ID: AuditExpire, Name: "Expire"},
 }
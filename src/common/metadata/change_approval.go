@@ -0,0 +1,459 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/util"
+)
+
+const (
+	// ApprovalPolicyNameMaxLength the max length of the approval policy name
+	ApprovalPolicyNameMaxLength = 128
+	// ApprovalMaxUsers the max number of the approver users of an approval policy
+	ApprovalMaxUsers = 100
+	// DefaultApprovalTimeout the default seconds that a change request waits for approval before it expires
+	DefaultApprovalTimeout int64 = 24 * 60 * 60
+	// MinApprovalTimeout the min seconds that a change request waits for approval before it expires
+	MinApprovalTimeout int64 = 5 * 60
+	// MaxApprovalTimeout the max seconds that a change request waits for approval before it expires
+	MaxApprovalTimeout int64 = 30 * 24 * 60 * 60
+	// SetEnvProduction the bk_set_env value of the production set
+	SetEnvProduction = "3"
+)
+
+// ApprovalOperation is the sensitive operation that can be configured to execute only after it is approved
+type ApprovalOperation string
+
+const (
+	// ApprovalOperationDeleteBiz delete business operation
+	ApprovalOperationDeleteBiz ApprovalOperation = "delete_biz"
+	// ApprovalOperationTransferHostAcrossBiz transfer hosts across business operation
+	ApprovalOperationTransferHostAcrossBiz ApprovalOperation = "transfer_host_across_biz"
+	// ApprovalOperationUpdateSet update set attributes operation
+	ApprovalOperationUpdateSet ApprovalOperation = "update_set"
+)
+
+// Validate approval operation
+func (o ApprovalOperation) Validate() bool {
+	switch o {
+	case ApprovalOperationDeleteBiz, ApprovalOperationTransferHostAcrossBiz, ApprovalOperationUpdateSet:
+		return true
+	default:
+		return false
+	}
+}
+
+type approvalOperationMatcher struct {
+	operation ApprovalOperation
+	method    string
+	regexp    *regexp.Regexp
+}
+
+// approvalOperationMatchers matches the api server request of the approval operations
+var approvalOperationMatchers = []approvalOperationMatcher{
+	{
+		operation: ApprovalOperationDeleteBiz,
+		method:    http.MethodPost,
+		regexp:    regexp.MustCompile(`^/api/v3/deletemany/biz/?$`),
+	},
+	{
+		operation: ApprovalOperationTransferHostAcrossBiz,
+		method:    http.MethodPost,
+		regexp:    regexp.MustCompile(`^/api/v3/hosts/modules/across/biz/?$`),
+	},
+	{
+		operation: ApprovalOperationUpdateSet,
+		method:    http.MethodPut,
+		regexp:    regexp.MustCompile(`^/api/v3/set/([0-9]+)/([0-9]+)/?$`),
+	},
+}
+
+// MatchApprovalOperation returns the approval operation that the api server request matches,
+// and the parameters parsed from the request path.
+func MatchApprovalOperation(method, path string) (ApprovalOperation, []string, bool) {
+	for _, matcher := range approvalOperationMatchers {
+		if matcher.method != method {
+			continue
+		}
+
+		params := matcher.regexp.FindStringSubmatch(path)
+		if len(params) == 0 {
+			continue
+		}
+		return matcher.operation, params[1:], true
+	}
+
+	return "", nil, false
+}
+
+// ApprovalCheckOption is the sensitive operation that the scene server is going to execute, the scene server checks
+// it by the approval policies in its logics, so that the approval can not be bypassed by any api that does it.
+type ApprovalCheckOption struct {
+	Operation ApprovalOperation `json:"operation"`
+	// DeleteBiz is the businesses to be deleted of the delete_biz operation
+	DeleteBiz *DeleteBizParam `json:"delete_biz,omitempty"`
+	// TransferHost is the hosts to be transferred of the transfer_host_across_biz operation
+	TransferHost *TransferHostAcrossBusinessParameter `json:"transfer_host,omitempty"`
+	// UpdateSet is the set and its update data of the update_set operation
+	UpdateSet *ApprovalUpdateSetOption `json:"update_set,omitempty"`
+}
+
+// ApprovalUpdateSetOption is the set update of the update_set operation
+type ApprovalUpdateSetOption struct {
+	BizID int64                  `json:"bk_biz_id"`
+	SetID int64                  `json:"bk_set_id"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// Validate approval check option, returns false if the option of the operation is not set or invalid
+func (o *ApprovalCheckOption) Validate() bool {
+	switch o.Operation {
+	case ApprovalOperationDeleteBiz:
+		return o.DeleteBiz != nil && len(o.DeleteBiz.BizID) > 0
+	case ApprovalOperationTransferHostAcrossBiz:
+		return o.TransferHost != nil && o.TransferHost.SrcAppID != 0 && o.TransferHost.DstAppID != 0 &&
+			o.TransferHost.DstModuleID != 0 && len(o.TransferHost.HostID) > 0
+	case ApprovalOperationUpdateSet:
+		return o.UpdateSet != nil && o.UpdateSet.BizID != 0 && o.UpdateSet.SetID != 0
+	default:
+		return false
+	}
+}
+
+// ParseApprovalCheckOption parses the approval check option from the api server request of the operation, the path
+// parameters are returned by MatchApprovalOperation. returns false if the request is invalid.
+func ParseApprovalCheckOption(operation ApprovalOperation, params []string, body string) (*ApprovalCheckOption,
+	bool) {
+
+	opt := &ApprovalCheckOption{Operation: operation}
+	switch operation {
+	case ApprovalOperationDeleteBiz:
+		opt.DeleteBiz = new(DeleteBizParam)
+		if err := json.Unmarshal([]byte(body), opt.DeleteBiz); err != nil {
+			return nil, false
+		}
+	case ApprovalOperationTransferHostAcrossBiz:
+		opt.TransferHost = new(TransferHostAcrossBusinessParameter)
+		if err := json.Unmarshal([]byte(body), opt.TransferHost); err != nil {
+			return nil, false
+		}
+	case ApprovalOperationUpdateSet:
+		if len(params) != 2 {
+			return nil, false
+		}
+
+		bizID, err := strconv.ParseInt(params[0], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+
+		setID, err := strconv.ParseInt(params[1], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+
+		opt.UpdateSet = &ApprovalUpdateSetOption{BizID: bizID, SetID: setID, Data: make(map[string]interface{})}
+		if err := json.Unmarshal([]byte(body), &opt.UpdateSet.Data); err != nil {
+			return nil, false
+		}
+	}
+
+	if !opt.Validate() {
+		return nil, false
+	}
+	return opt, true
+}
+
+// ApprovalRoleFields are the role fields of the business whose members can be the approvers
+var ApprovalRoleFields = []string{common.BKMaintainersField, common.BKProductPMField, common.BKDeveloperField,
+	common.BKTesterField, common.BKOperatorField}
+
+// ApprovalApprovers defines who can approve the change requests of an approval policy
+type ApprovalApprovers struct {
+	// Users are the specified approver users
+	Users []string `json:"users" bson:"users"`
+	// Roles are the role fields of the businesses that the change request is related to, like bk_biz_maintainer
+	Roles []string `json:"roles" bson:"roles"`
+}
+
+// Validate approval approvers
+func (a ApprovalApprovers) Validate() ccErr.RawErrorInfo {
+	if len(a.Users) == 0 && len(a.Roles) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"approvers"}}
+	}
+
+	if len(a.Users) > ApprovalMaxUsers {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"approvers.users", ApprovalMaxUsers}}
+	}
+
+	for _, role := range a.Roles {
+		if !util.InStrArr(ApprovalRoleFields, role) {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"approvers.roles"}}
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ApprovalPolicy defines that an operation is captured as a change request and executed after it is approved
+type ApprovalPolicy struct {
+	ID        int64             `json:"id" bson:"id"`
+	Name      string            `json:"name" bson:"name"`
+	Operation ApprovalOperation `json:"operation" bson:"operation"`
+	// ProductionOnly defines that only the operations on the production sets need approval,
+	// it only works for the update_set operation.
+	ProductionOnly bool              `json:"production_only" bson:"production_only"`
+	Approvers      ApprovalApprovers `json:"approvers" bson:"approvers"`
+	// Timeout is the seconds that a change request waits for approval before it expires
+	Timeout    int64  `json:"timeout" bson:"timeout"`
+	Enabled    bool   `json:"enabled" bson:"enabled"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string `json:"creator" bson:"creator"`
+	Modifier   string `json:"modifier" bson:"modifier"`
+	CreateTime Time   `json:"create_time" bson:"create_time"`
+	LastTime   Time   `json:"last_time" bson:"last_time"`
+}
+
+// Validate approval policy, the operation of the policy can not be changed, so it is validated separately
+func (p *ApprovalPolicy) Validate() ccErr.RawErrorInfo {
+	if len(p.Name) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	if len(p.Name) > ApprovalPolicyNameMaxLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKFieldName, ApprovalPolicyNameMaxLength}}
+	}
+
+	if p.ProductionOnly && p.Operation != ApprovalOperationUpdateSet {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"production_only"}}
+	}
+
+	if rawErr := p.Approvers.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if p.Timeout == 0 {
+		p.Timeout = DefaultApprovalTimeout
+	}
+
+	if p.Timeout < MinApprovalTimeout || p.Timeout > MaxApprovalTimeout {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"timeout"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// SearchApprovalPolicyOption search approval policy option
+type SearchApprovalPolicyOption struct {
+	Operation ApprovalOperation `json:"operation"`
+	Page      BasePage          `json:"page"`
+}
+
+// Validate search approval policy option
+func (o *SearchApprovalPolicyOption) Validate() ccErr.RawErrorInfo {
+	if len(o.Operation) > 0 && !o.Operation.Validate() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"operation"}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchApprovalPolicyResult search approval policy result
+type SearchApprovalPolicyResult struct {
+	Count uint64           `json:"count"`
+	Info  []ApprovalPolicy `json:"info"`
+}
+
+// ChangeRequestStatus is the status of the change request
+type ChangeRequestStatus string
+
+const (
+	// ChangeRequestPending the change request is waiting for approval
+	ChangeRequestPending ChangeRequestStatus = "pending"
+	// ChangeRequestApproved the change request is approved, and waiting to be executed by task server
+	ChangeRequestApproved ChangeRequestStatus = "approved"
+	// ChangeRequestRejected the change request is rejected by the approver
+	ChangeRequestRejected ChangeRequestStatus = "rejected"
+	// ChangeRequestCanceled the change request is canceled by the requester
+	ChangeRequestCanceled ChangeRequestStatus = "canceled"
+	// ChangeRequestExpired the change request is not approved before it expires
+	ChangeRequestExpired ChangeRequestStatus = "expired"
+	// ChangeRequestExecuting the approved change request is being executed
+	ChangeRequestExecuting ChangeRequestStatus = "executing"
+	// ChangeRequestExecuted the approved change request is executed successfully
+	ChangeRequestExecuted ChangeRequestStatus = "executed"
+	// ChangeRequestFailed the approved change request is executed but failed
+	ChangeRequestFailed ChangeRequestStatus = "failed"
+)
+
+// changeRequestTransitions are the statuses that a change request can be changed to from its current status
+var changeRequestTransitions = map[ChangeRequestStatus][]ChangeRequestStatus{
+	ChangeRequestPending: {ChangeRequestApproved, ChangeRequestRejected, ChangeRequestCanceled,
+		ChangeRequestExpired},
+	// approved change request is set back to pending if its execute task can not be created
+	ChangeRequestApproved:  {ChangeRequestExecuting, ChangeRequestPending},
+	ChangeRequestExecuting: {ChangeRequestExecuted, ChangeRequestFailed},
+}
+
+// CanTransitTo returns if the change request can be changed from this status to the target status,
+// the rejected, canceled, expired, executed and failed statuses are final.
+func (s ChangeRequestStatus) CanTransitTo(target ChangeRequestStatus) bool {
+	for _, status := range changeRequestTransitions[s] {
+		if status == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeRequestContent is the captured api server request of the change request
+type ChangeRequestContent struct {
+	Method   string `json:"method" bson:"method"`
+	Path     string `json:"path" bson:"path"`
+	Body     string `json:"body" bson:"body"`
+	Language string `json:"language" bson:"language"`
+	AppCode  string `json:"app_code" bson:"app_code"`
+}
+
+// ChangeRequestDiff is the change of an instance that the change request makes
+type ChangeRequestDiff struct {
+	ObjectID string                 `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64                  `json:"bk_inst_id" bson:"bk_inst_id"`
+	InstName string                 `json:"bk_inst_name" bson:"bk_inst_name"`
+	Before   map[string]interface{} `json:"before" bson:"before"`
+	After    map[string]interface{} `json:"after" bson:"after"`
+}
+
+// ChangeRequest is an operation that is captured by an approval policy, it is executed by task server after approved
+type ChangeRequest struct {
+	ID        int64                `json:"id" bson:"id"`
+	PolicyID  int64                `json:"policy_id" bson:"policy_id"`
+	Operation ApprovalOperation    `json:"operation" bson:"operation"`
+	BizIDs    []int64              `json:"bk_biz_ids" bson:"bk_biz_ids"`
+	Request   ChangeRequestContent `json:"request" bson:"request"`
+	Diff      []ChangeRequestDiff  `json:"diff" bson:"diff"`
+	// Approvers are the users that can approve the change request, resolved when it is captured
+	Approvers []string            `json:"approvers" bson:"approvers"`
+	Status    ChangeRequestStatus `json:"status" bson:"status"`
+	Requester string              `json:"requester" bson:"requester"`
+	// Approver is the user that approved or rejected the change request
+	Approver string `json:"approver" bson:"approver"`
+	Comment  string `json:"comment" bson:"comment"`
+	TaskID   string `json:"task_id" bson:"task_id"`
+	// Result is the response of the executed request
+	Result     *BaseResp `json:"result,omitempty" bson:"result,omitempty"`
+	ExpireTime Time      `json:"expire_time" bson:"expire_time"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime Time      `json:"create_time" bson:"create_time"`
+	LastTime   Time      `json:"last_time" bson:"last_time"`
+}
+
+// Covers returns if all the instance changes of the diff are the approved changes of the change request, so that an
+// executing change request can not be used to make the changes that are not approved.
+func (c *ChangeRequest) Covers(diff []ChangeRequestDiff) bool {
+	approved := make(map[string]map[string]interface{})
+	for _, change := range c.Diff {
+		approved[fmt.Sprintf("%s:%d", change.ObjectID, change.InstID)] = change.After
+	}
+
+	for _, change := range diff {
+		after, exists := approved[fmt.Sprintf("%s:%d", change.ObjectID, change.InstID)]
+		if !exists || len(after) != len(change.After) {
+			return false
+		}
+
+		// the approved diff is read from db, so the values are compared by their string forms
+		for field, value := range change.After {
+			if approvedValue, exists := after[field]; !exists || fmt.Sprint(approvedValue) != fmt.Sprint(value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// CaptureChangeRequestOption is the api server request that may be captured as a change request
+type CaptureChangeRequestOption struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body"`
+}
+
+// CaptureChangeRequestResult capture change request result
+type CaptureChangeRequestResult struct {
+	// Captured defines whether the request is captured, the request should not be executed if it is captured
+	Captured bool  `json:"captured"`
+	ID       int64 `json:"id"`
+}
+
+// CaptureChangeRequestResponse capture change request response
+type CaptureChangeRequestResponse struct {
+	BaseResp `json:",inline"`
+	Data     *CaptureChangeRequestResult `json:"data"`
+}
+
+// VerifyChangeRequestOption is the api server request that executes a change request
+type VerifyChangeRequestOption struct {
+	ID     int64  `json:"id"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body"`
+}
+
+// ExecuteChangeRequestOption is the task data that executes an approved change request
+type ExecuteChangeRequestOption struct {
+	ID int64 `json:"id"`
+}
+
+// ChangeRequestDecisionOption approve, reject or cancel change request option
+type ChangeRequestDecisionOption struct {
+	Comment string `json:"comment"`
+}
+
+// SearchChangeRequestOption search change request option
+type SearchChangeRequestOption struct {
+	ID        int64                 `json:"id"`
+	Operation ApprovalOperation     `json:"operation"`
+	Status    []ChangeRequestStatus `json:"status"`
+	BizID     int64                 `json:"bk_biz_id"`
+	Requester string                `json:"requester"`
+	// Approver searches the change requests that the user can approve
+	Approver string   `json:"approver"`
+	Page     BasePage `json:"page"`
+}
+
+// Validate search change request option
+func (o *SearchChangeRequestOption) Validate() ccErr.RawErrorInfo {
+	if len(o.Operation) > 0 && !o.Operation.Validate() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"operation"}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchChangeRequestResult search change request result
+type SearchChangeRequestResult struct {
+	Count uint64          `json:"count"`
+	Info  []ChangeRequest `json:"info"`
+}
@@ -0,0 +1,218 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMatchApprovalOperation(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		operation ApprovalOperation
+		params    []string
+		matched   bool
+	}{
+		{"delete biz", http.MethodPost, "/api/v3/deletemany/biz", ApprovalOperationDeleteBiz, []string{}, true},
+		{"transfer host across biz", http.MethodPost, "/api/v3/hosts/modules/across/biz/",
+			ApprovalOperationTransferHostAcrossBiz, []string{}, true},
+		{"update set", http.MethodPut, "/api/v3/set/2/10", ApprovalOperationUpdateSet, []string{"2", "10"}, true},
+		{"method not matched", http.MethodGet, "/api/v3/set/2/10", "", nil, false},
+		{"path not matched", http.MethodPut, "/api/v3/set/2/abc", "", nil, false},
+		{"path with prefix", http.MethodPost, "/proxy/api/v3/deletemany/biz", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, params, matched := MatchApprovalOperation(tt.method, tt.path)
+			if operation != tt.operation || !reflect.DeepEqual(params, tt.params) || matched != tt.matched {
+				t.Errorf("MatchApprovalOperation() = %s, %v, %v, want %s, %v, %v", operation, params, matched,
+					tt.operation, tt.params, tt.matched)
+			}
+		})
+	}
+}
+
+func TestParseApprovalCheckOption(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation ApprovalOperation
+		params    []string
+		body      string
+		want      *ApprovalCheckOption
+		valid     bool
+	}{
+		{
+			name:      "delete biz",
+			operation: ApprovalOperationDeleteBiz,
+			body:      `{"bk_biz_id":[2,3]}`,
+			want: &ApprovalCheckOption{Operation: ApprovalOperationDeleteBiz,
+				DeleteBiz: &DeleteBizParam{BizID: []int64{2, 3}}},
+			valid: true,
+		},
+		{
+			name:      "delete no biz",
+			operation: ApprovalOperationDeleteBiz,
+			body:      `{"bk_biz_id":[]}`,
+		},
+		{
+			name:      "transfer host across biz",
+			operation: ApprovalOperationTransferHostAcrossBiz,
+			body:      `{"src_bk_biz_id":2,"dst_bk_biz_id":3,"bk_host_id":[1],"bk_module_id":5}`,
+			want: &ApprovalCheckOption{Operation: ApprovalOperationTransferHostAcrossBiz,
+				TransferHost: &TransferHostAcrossBusinessParameter{SrcAppID: 2, DstAppID: 3, HostID: []int64{1},
+					DstModuleID: 5}},
+			valid: true,
+		},
+		{
+			name:      "transfer host without dest module",
+			operation: ApprovalOperationTransferHostAcrossBiz,
+			body:      `{"src_bk_biz_id":2,"dst_bk_biz_id":3,"bk_host_id":[1]}`,
+		},
+		{
+			name:      "update set",
+			operation: ApprovalOperationUpdateSet,
+			params:    []string{"2", "10"},
+			body:      `{"bk_set_env":"3"}`,
+			want: &ApprovalCheckOption{Operation: ApprovalOperationUpdateSet,
+				UpdateSet: &ApprovalUpdateSetOption{BizID: 2, SetID: 10,
+					Data: map[string]interface{}{"bk_set_env": "3"}}},
+			valid: true,
+		},
+		{
+			name:      "update set without set id",
+			operation: ApprovalOperationUpdateSet,
+			params:    []string{"2"},
+			body:      `{"bk_set_env":"3"}`,
+		},
+		{
+			name:      "invalid body",
+			operation: ApprovalOperationUpdateSet,
+			params:    []string{"2", "10"},
+			body:      `invalid`,
+		},
+		{
+			name:      "unknown operation",
+			operation: "delete_set",
+			body:      `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, valid := ParseApprovalCheckOption(tt.operation, tt.params, tt.body)
+			if !reflect.DeepEqual(got, tt.want) || valid != tt.valid {
+				t.Errorf("ParseApprovalCheckOption() = %+v, %v, want %+v, %v", got, valid, tt.want, tt.valid)
+			}
+		})
+	}
+}
+
+func TestChangeRequestCovers(t *testing.T) {
+	// the approved diff is read from db, so its values are not of the same types as the analyzed diff
+	request := &ChangeRequest{Diff: []ChangeRequestDiff{
+		{ObjectID: "host", InstID: 1, After: map[string]interface{}{"bk_biz_id": int32(3),
+			"bk_module_id": []interface{}{int32(5)}}},
+		{ObjectID: "host", InstID: 2, After: map[string]interface{}{"bk_biz_id": int32(3),
+			"bk_module_id": []interface{}{int32(5)}}},
+		{ObjectID: "biz", InstID: 3},
+	}}
+
+	after := map[string]interface{}{"bk_biz_id": int64(3), "bk_module_id": []int64{5}}
+	tests := []struct {
+		name string
+		diff []ChangeRequestDiff
+		want bool
+	}{
+		{"same changes", []ChangeRequestDiff{{ObjectID: "host", InstID: 1, After: after},
+			{ObjectID: "host", InstID: 2, After: after}}, true},
+		{"part of the changes", []ChangeRequestDiff{{ObjectID: "host", InstID: 2, After: after}}, true},
+		{"deletion", []ChangeRequestDiff{{ObjectID: "biz", InstID: 3}}, true},
+		{"instance not approved", []ChangeRequestDiff{{ObjectID: "host", InstID: 4, After: after}}, false},
+		{"object not approved", []ChangeRequestDiff{{ObjectID: "set", InstID: 1, After: after}}, false},
+		{"value not approved", []ChangeRequestDiff{{ObjectID: "host", InstID: 1,
+			After: map[string]interface{}{"bk_biz_id": int64(3), "bk_module_id": []int64{6}}}}, false},
+		{"field not approved", []ChangeRequestDiff{{ObjectID: "host", InstID: 1,
+			After: map[string]interface{}{"bk_biz_id": int64(3)}}}, false},
+		{"update of the deleted instance", []ChangeRequestDiff{{ObjectID: "biz", InstID: 3,
+			After: map[string]interface{}{"bk_biz_name": "a"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := request.Covers(tt.diff); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeRequestStatusCanTransitTo(t *testing.T) {
+	flows := []struct {
+		name     string
+		statuses []ChangeRequestStatus
+	}{
+		{"executed", []ChangeRequestStatus{ChangeRequestPending, ChangeRequestApproved, ChangeRequestExecuting,
+			ChangeRequestExecuted}},
+		{"failed", []ChangeRequestStatus{ChangeRequestPending, ChangeRequestApproved, ChangeRequestExecuting,
+			ChangeRequestFailed}},
+		{"execute task not created", []ChangeRequestStatus{ChangeRequestPending, ChangeRequestApproved,
+			ChangeRequestPending, ChangeRequestApproved}},
+		{"rejected", []ChangeRequestStatus{ChangeRequestPending, ChangeRequestRejected}},
+		{"canceled", []ChangeRequestStatus{ChangeRequestPending, ChangeRequestCanceled}},
+		{"expired", []ChangeRequestStatus{ChangeRequestPending, ChangeRequestExpired}},
+	}
+
+	for _, tt := range flows {
+		t.Run(tt.name, func(t *testing.T) {
+			for idx := 1; idx < len(tt.statuses); idx++ {
+				if !tt.statuses[idx-1].CanTransitTo(tt.statuses[idx]) {
+					t.Errorf("%s can not transit to %s", tt.statuses[idx-1], tt.statuses[idx])
+				}
+			}
+		})
+	}
+
+	all := []ChangeRequestStatus{ChangeRequestPending, ChangeRequestApproved, ChangeRequestRejected,
+		ChangeRequestCanceled, ChangeRequestExpired, ChangeRequestExecuting, ChangeRequestExecuted,
+		ChangeRequestFailed}
+
+	// final statuses can not be changed, so a change request can not be approved or executed again
+	for _, from := range []ChangeRequestStatus{ChangeRequestRejected, ChangeRequestCanceled, ChangeRequestExpired,
+		ChangeRequestExecuted, ChangeRequestFailed} {
+		for _, to := range all {
+			if from.CanTransitTo(to) {
+				t.Errorf("final status %s can transit to %s", from, to)
+			}
+		}
+	}
+
+	// the approval and the execution can not be skipped or reverted
+	skipped := [][2]ChangeRequestStatus{
+		{ChangeRequestPending, ChangeRequestExecuting},
+		{ChangeRequestPending, ChangeRequestExecuted},
+		{ChangeRequestApproved, ChangeRequestExecuted},
+		{ChangeRequestApproved, ChangeRequestRejected},
+		{ChangeRequestExecuting, ChangeRequestPending},
+		{ChangeRequestExecuting, ChangeRequestExecuting},
+	}
+	for _, transition := range skipped {
+		if transition[0].CanTransitTo(transition[1]) {
+			t.Errorf("%s can transit to %s", transition[0], transition[1])
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"configcenter/pkg/formula"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// ComputedOption is the option of computed attribute
+type ComputedOption struct {
+	// Formula is the expression that the attribute value is evaluated from, refer to pkg/formula for the syntax
+	Formula string `json:"formula" bson:"formula" mapstructure:"formula"`
+	// ValueType is the type of the evaluated value, the formula result is converted to this type
+	ValueType string `json:"value_type" bson:"value_type" mapstructure:"value_type"`
+}
+
+// computedValueTypes is the value types that a computed attribute supports
+var computedValueTypes = map[string]struct{}{
+	common.FieldTypeSingleChar: {},
+	common.FieldTypeLongChar:   {},
+	common.FieldTypeInt:        {},
+	common.FieldTypeFloat:      {},
+	common.FieldTypeBool:       {},
+}
+
+// ParseComputedOption parse computed attribute option
+func ParseComputedOption(val interface{}) (*ComputedOption, error) {
+	if val == nil || val == "" {
+		return nil, fmt.Errorf("computed option is not set")
+	}
+
+	option := new(ComputedOption)
+	switch t := val.(type) {
+	case ComputedOption:
+		option = &t
+	case *ComputedOption:
+		option = t
+	case string:
+		if err := json.Unmarshal([]byte(t), option); err != nil {
+			return nil, err
+		}
+	default:
+		byt, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(byt, option); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, exists := computedValueTypes[option.ValueType]; !exists {
+		return nil, fmt.Errorf("computed value type %s is invalid", option.ValueType)
+	}
+
+	return option, nil
+}
+
+// ParseComputedFormula parse computed attribute option and its formula
+func ParseComputedFormula(val interface{}) (*ComputedOption, *formula.Formula, error) {
+	option, err := ParseComputedOption(val)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := formula.Parse(option.Formula)
+	if err != nil {
+		return nil, nil, fmt.Errorf("computed formula %s is invalid, err: %v", option.Formula, err)
+	}
+
+	return option, f, nil
+}
+
+// ConvertComputedValue convert the evaluated formula result to the value type of the computed attribute
+func ConvertComputedValue(valueType string, val interface{}) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	switch valueType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		str := util.GetStrByInterface(val)
+		if f, ok := val.(float64); ok {
+			str = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		limit := common.FieldTypeSingleLenChar
+		if valueType == common.FieldTypeLongChar {
+			limit = common.FieldTypeLongLenChar
+		}
+		if len(str) > limit {
+			return nil, fmt.Errorf("computed value exceeds the length limit %d", limit)
+		}
+		return str, nil
+	case common.FieldTypeInt:
+		num, err := util.GetFloat64ByInterface(val)
+		if err != nil {
+			return nil, fmt.Errorf("computed value %v is not numeric", val)
+		}
+		return int64(math.Round(num)), nil
+	case common.FieldTypeFloat:
+		num, err := util.GetFloat64ByInterface(val)
+		if err != nil {
+			return nil, fmt.Errorf("computed value %v is not numeric", val)
+		}
+		return num, nil
+	case common.FieldTypeBool:
+		b, ok := val.(bool)
+		if !ok {
+			return nil, fmt.Errorf("computed value %v is not bool", val)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("computed value type %s is invalid", valueType)
+	}
+}
+
+// validComputed valid object attribute that is computed type, the value is evaluated by cmdb, so it only
+// needs to be the value type of the computed attribute.
+func (attribute *Attribute) validComputed(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	if val == nil {
+		return errors.RawErrorInfo{}
+	}
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	option, err := ParseComputedOption(attribute.Option)
+	if err != nil {
+		blog.Errorf("parse computed option failed, option: %+v, err: %v, rid: %s", attribute.Option, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	if _, err = ConvertComputedValue(option.ValueType, val); err != nil {
+		blog.Errorf("computed value %v is invalid, err: %v, rid: %s", val, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// IsComputedAttr check if the attribute is a computed attribute
+func IsComputedAttr(propertyType string) bool {
+	return propertyType == common.FieldTypeComputed
+}
+
+// UpdateComputedFieldsOption is the option to update the computed fields of the instances with the values that are
+// evaluated by the event flow, computed fields are read only to users and can only be updated in this way.
+type UpdateComputedFieldsOption struct {
+	Data []ComputedFieldsData `json:"data"`
+}
+
+// ComputedFieldsData is the evaluated computed field values of one instance
+type ComputedFieldsData struct {
+	InstID int64         `json:"bk_inst_id"`
+	Data   mapstr.MapStr `json:"data"`
+}
+
+// Validate update computed fields option
+func (o *UpdateComputedFieldsOption) Validate() errors.RawErrorInfo {
+	if len(o.Data) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"data"}}
+	}
+
+	if len(o.Data) > common.BKMaxUpdateOrCreatePageSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"data", common.BKMaxUpdateOrCreatePageSize}}
+	}
+
+	for _, data := range o.Data {
+		if data.InstID <= 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{common.BKInstIDField}}
+		}
+		if len(data.Data) == 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"data.data"}}
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
@@ -42,6 +42,9 @@ type HostApplyRule struct {
 	AttributeID   int64       `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
 	PropertyValue interface{} `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
 
+	// Scope is the scope that the rule comes from, it is not stored, empty means module or service template rule
+	Scope HostApplyScope `field:"scope" json:"scope,omitempty" bson:"-" mapstructure:"scope"`
+
 	// 通用字段
 	Creator         string    `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
 	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier" mapstructure:"modifier"`
@@ -232,6 +235,8 @@ type HostApplyPlanOption struct {
 	Rules             []HostApplyRule             `field:"host_apply_rules" json:"host_apply_rules" bson:"host_apply_rules" mapstructure:"host_apply_rules"`
 	HostModules       []Host2Modules              `field:"host_modules" json:"host_modules" bson:"host_modules" mapstructure:"host_modules"`
 	ConflictResolvers []HostApplyConflictResolver `field:"conflict_resolvers" json:"conflict_resolvers" bson:"conflict_resolvers" mapstructure:"conflict_resolvers"`
+	// HostRules are the rules matched by hosts (dynamic group rules), they have the lowest precedence
+	HostRules []HostApplyHostRules `field:"host_rules" json:"host_rules" bson:"host_rules" mapstructure:"host_rules"`
 }
 
 // HostApplyConflictField TODO
@@ -297,6 +302,8 @@ type HostApplyPlanResult struct {
 	HostAttributes          []Attribute     `field:"host_attributes" json:"host_attributes" bson:"host_attributes" mapstructure:"host_attributes"`
 	Count                   int             `field:"count" json:"count" bson:"count" mapstructure:"count"`
 	Rules                   []HostApplyRule `field:"final_rules" json:"final_rules" mapstructure:"final_rules"`
+	// ScopeConflicts are the rules of different scopes that are overridden by the higher precedence scope rules
+	ScopeConflicts []HostApplyScopeConflict `field:"scope_conflicts" json:"scope_conflicts" mapstructure:"scope_conflicts"`
 }
 
 // HostApplyPlanBase  host auto-apply Infrastructure
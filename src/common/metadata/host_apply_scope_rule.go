@@ -0,0 +1,299 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// HostApplyScope is the scope that a host apply rule is attached to
+type HostApplyScope string
+
+const (
+	// HostApplyScopeModule the rule is attached to a module
+	HostApplyScopeModule HostApplyScope = "module"
+	// HostApplyScopeServiceTemplate the rule is attached to a service template, it is used by the modules of the
+	// service template instead of the module rules if the service template enables host apply
+	HostApplyScopeServiceTemplate HostApplyScope = "service_template"
+	// HostApplyScopeSet the rule is attached to a set, it is used by all modules of the set
+	HostApplyScopeSet HostApplyScope = "set"
+	// HostApplyScopeSetTemplate the rule is attached to a set template, it is used by all modules of the sets that
+	// are created by the set template
+	HostApplyScopeSetTemplate HostApplyScope = "set_template"
+	// HostApplyScopeBiz the rule is attached to a business, it is used by all modules of the business
+	HostApplyScopeBiz HostApplyScope = "biz"
+	// HostApplyScopeDynamicGroup the rule is attached to a host dynamic group, it is used by the hosts that matches
+	// the dynamic group conditions
+	HostApplyScopeDynamicGroup HostApplyScope = "dynamic_group"
+)
+
+// hostApplyScopePriority is the precedence of the host apply rule scopes, the smaller value takes precedence.
+// for one attribute of a host, only the rule of the highest precedence scope is used, the precedence is:
+// module > service template > set > set template > business > dynamic group
+var hostApplyScopePriority = map[HostApplyScope]int{
+	HostApplyScopeModule:          1,
+	HostApplyScopeServiceTemplate: 2,
+	HostApplyScopeSet:             3,
+	HostApplyScopeSetTemplate:     4,
+	HostApplyScopeBiz:             5,
+	HostApplyScopeDynamicGroup:    6,
+}
+
+// Priority returns the precedence of the host apply rule scope, the smaller value takes precedence
+func (s HostApplyScope) Priority() int {
+	priority, exists := hostApplyScopePriority[s]
+	if !exists {
+		return len(hostApplyScopePriority) + 1
+	}
+	return priority
+}
+
+// IsScopeRule returns if the scope is one of the scopes that are stored as host apply scope rules
+func (s HostApplyScope) IsScopeRule() bool {
+	switch s {
+	case HostApplyScopeSet, HostApplyScopeSetTemplate, HostApplyScopeBiz, HostApplyScopeDynamicGroup:
+		return true
+	default:
+		return false
+	}
+}
+
+// HostApplyScopeRule is a host apply rule attached to a business, set, set template or host dynamic group
+type HostApplyScopeRule struct {
+	ID             int64          `json:"id" bson:"id"`
+	BizID          int64          `json:"bk_biz_id" bson:"bk_biz_id"`
+	Scope          HostApplyScope `json:"scope" bson:"scope"`
+	SetID          int64          `json:"bk_set_id" bson:"bk_set_id"`
+	SetTemplateID  int64          `json:"set_template_id" bson:"set_template_id"`
+	DynamicGroupID string         `json:"dynamic_group_id" bson:"dynamic_group_id"`
+
+	// AttributeID is the `id` field of the host attribute, not the same with bk_property_id
+	AttributeID   int64       `json:"bk_attribute_id" bson:"bk_attribute_id"`
+	PropertyValue interface{} `json:"bk_property_value" bson:"bk_property_value"`
+
+	Creator         string    `json:"creator" bson:"creator"`
+	Modifier        string    `json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	LastTime        time.Time `json:"last_time" bson:"last_time"`
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// ToHostApplyRule converts the scope rule to the host apply rule of the module, so that it can be used to generate
+// host apply plan like the module rules
+func (r *HostApplyScopeRule) ToHostApplyRule(moduleID int64) HostApplyRule {
+	return HostApplyRule{
+		ID:              r.ID,
+		BizID:           r.BizID,
+		ModuleID:        moduleID,
+		Scope:           r.Scope,
+		AttributeID:     r.AttributeID,
+		PropertyValue:   r.PropertyValue,
+		Creator:         r.Creator,
+		Modifier:        r.Modifier,
+		CreateTime:      r.CreateTime,
+		LastTime:        r.LastTime,
+		SupplierAccount: r.SupplierAccount,
+	}
+}
+
+// matchModule returns if the scope rule is used by the module, dynamic group rules are matched by hosts
+func (r *HostApplyScopeRule) matchModule(module *ModuleInst) bool {
+	if r.BizID != module.BizID {
+		return false
+	}
+
+	switch r.Scope {
+	case HostApplyScopeBiz:
+		return true
+	case HostApplyScopeSet:
+		return r.SetID == module.SetID
+	case HostApplyScopeSetTemplate:
+		return module.SetTemplateID != 0 && r.SetTemplateID == module.SetTemplateID
+	default:
+		return false
+	}
+}
+
+// CreateHostApplyScopeRuleOption create host apply scope rule option
+type CreateHostApplyScopeRuleOption struct {
+	Scope          HostApplyScope `json:"scope"`
+	SetID          int64          `json:"bk_set_id"`
+	SetTemplateID  int64          `json:"set_template_id"`
+	DynamicGroupID string         `json:"dynamic_group_id"`
+	AttributeID    int64          `json:"bk_attribute_id"`
+	PropertyValue  interface{}    `json:"bk_property_value"`
+}
+
+// Validate CreateHostApplyScopeRuleOption, only the id field of the scope can be set
+func (op *CreateHostApplyScopeRuleOption) Validate() errors.RawErrorInfo {
+	if op.AttributeID == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_attribute_id"}}
+	}
+
+	setIDSet, setTemplateIDSet, groupIDSet := op.SetID != 0, op.SetTemplateID != 0, op.DynamicGroupID != ""
+
+	var valid bool
+	switch op.Scope {
+	case HostApplyScopeBiz:
+		valid = !setIDSet && !setTemplateIDSet && !groupIDSet
+	case HostApplyScopeSet:
+		valid = setIDSet && !setTemplateIDSet && !groupIDSet
+	case HostApplyScopeSetTemplate:
+		valid = !setIDSet && setTemplateIDSet && !groupIDSet
+	case HostApplyScopeDynamicGroup:
+		valid = !setIDSet && !setTemplateIDSet && groupIDSet
+	default:
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope"}}
+	}
+
+	if !valid {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{"bk_set_id, set_template_id or dynamic_group_id"},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// UpdateHostApplyScopeRuleOption update host apply scope rule option
+type UpdateHostApplyScopeRuleOption struct {
+	PropertyValue interface{} `json:"bk_property_value"`
+}
+
+// ListHostApplyScopeRuleOption list host apply scope rule option
+type ListHostApplyScopeRuleOption struct {
+	Scopes          []HostApplyScope `json:"scopes"`
+	SetIDs          []int64          `json:"bk_set_ids"`
+	SetTemplateIDs  []int64          `json:"set_template_ids"`
+	DynamicGroupIDs []string         `json:"dynamic_group_ids"`
+	AttributeIDs    []int64          `json:"bk_attribute_ids"`
+	Page            BasePage         `json:"page"`
+}
+
+// MultipleHostApplyScopeRuleResult host apply scope rules result
+type MultipleHostApplyScopeRuleResult struct {
+	Count int64                `json:"count"`
+	Info  []HostApplyScopeRule `json:"info"`
+}
+
+// DeleteHostApplyScopeRuleOption delete host apply scope rule option
+type DeleteHostApplyScopeRuleOption struct {
+	RuleIDs []int64 `json:"host_apply_rule_ids"`
+}
+
+// Validate DeleteHostApplyScopeRuleOption
+func (op *DeleteHostApplyScopeRuleOption) Validate() errors.RawErrorInfo {
+	if len(op.RuleIDs) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"host_apply_rule_ids"}}
+	}
+
+	if len(op.RuleIDs) > common.BKMaxDeletePageSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxDeletePageSize}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// HostApplyHostRules are the host apply rules that are used by the host regardless of its modules, the dynamic group
+// rules are converted to the host rules since they are matched by hosts
+type HostApplyHostRules struct {
+	HostID int64           `json:"bk_host_id"`
+	Rules  []HostApplyRule `json:"rules"`
+}
+
+// HostApplyScopeConflict is a conflict between the rules of different scopes on the same attribute of a module, only
+// the final rule of the highest precedence scope is used, the overridden rules with different values are reported
+type HostApplyScopeConflict struct {
+	ModuleID        int64           `json:"bk_module_id"`
+	AttributeID     int64           `json:"bk_attribute_id"`
+	FinalRule       HostApplyRule   `json:"final_rule"`
+	OverriddenRules []HostApplyRule `json:"overridden_rules"`
+}
+
+// MergeHostApplyScopeRules merge the business, set and set template scope rules into the final rules of the modules
+// by the scope precedence. moduleRules are the module or service template rules of the modules, they take precedence
+// over the scope rules. dynamic group rules are matched by hosts, so they are not merged here.
+func MergeHostApplyScopeRules(modules []ModuleInst, moduleRules []HostApplyRule, scopeRules []HostApplyScopeRule) (
+	[]HostApplyRule, []HostApplyScopeConflict) {
+
+	// moduleID -> attributeID -> candidate rules of the module attribute
+	candidates := make(map[int64]map[int64][]HostApplyRule)
+	addCandidate := func(rule HostApplyRule) {
+		if _, exists := candidates[rule.ModuleID]; !exists {
+			candidates[rule.ModuleID] = make(map[int64][]HostApplyRule)
+		}
+		candidates[rule.ModuleID][rule.AttributeID] = append(candidates[rule.ModuleID][rule.AttributeID], rule)
+	}
+
+	for _, rule := range moduleRules {
+		if rule.Scope == "" {
+			rule.Scope = HostApplyScopeModule
+			if rule.ServiceTemplateID != 0 {
+				rule.Scope = HostApplyScopeServiceTemplate
+			}
+		}
+		addCandidate(rule)
+	}
+
+	for idx := range modules {
+		for _, scopeRule := range scopeRules {
+			if scopeRule.matchModule(&modules[idx]) {
+				addCandidate(scopeRule.ToHostApplyRule(modules[idx].ModuleID))
+			}
+		}
+	}
+
+	finalRules := make([]HostApplyRule, 0)
+	conflicts := make([]HostApplyScopeConflict, 0)
+	for moduleID, attrRules := range candidates {
+		for attrID, rules := range attrRules {
+			final := rules[0]
+			for _, rule := range rules[1:] {
+				if rule.Scope.Priority() < final.Scope.Priority() {
+					final = rule
+				}
+			}
+			finalRules = append(finalRules, final)
+
+			overridden := make([]HostApplyRule, 0)
+			for _, rule := range rules {
+				if rule.Scope.Priority() > final.Scope.Priority() && !isSameRuleValue(rule, final) {
+					overridden = append(overridden, rule)
+				}
+			}
+
+			if len(overridden) > 0 {
+				conflicts = append(conflicts, HostApplyScopeConflict{
+					ModuleID:        moduleID,
+					AttributeID:     attrID,
+					FinalRule:       final,
+					OverriddenRules: overridden,
+				})
+			}
+		}
+	}
+
+	return finalRules, conflicts
+}
+
+func isSameRuleValue(rule1, rule2 HostApplyRule) bool {
+	value1, err1 := json.Marshal(rule1.PropertyValue)
+	value2, err2 := json.Marshal(rule2.PropertyValue)
+	return err1 == nil && err2 == nil && string(value1) == string(value2)
+}
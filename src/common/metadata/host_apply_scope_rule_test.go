@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMergeHostApplyScopeRules(t *testing.T) {
+	// module 1 and 2 are in set 10 created by set template 20, module 3 is in set 11 without set template
+	modules := []ModuleInst{
+		{BizID: 2, SetID: 10, ModuleID: 1, SetTemplateID: 20},
+		{BizID: 2, SetID: 10, ModuleID: 2, SetTemplateID: 20},
+		{BizID: 2, SetID: 11, ModuleID: 3},
+	}
+
+	tests := []struct {
+		name        string
+		modules     []ModuleInst
+		moduleRules []HostApplyRule
+		scopeRules  []HostApplyScopeRule
+		// wantRules are the final rule ids of module:attribute
+		wantRules map[string]int64
+		// wantConflicts are the overridden rule ids of module:attribute
+		wantConflicts map[string][]int64
+	}{
+		{
+			name:          "empty inputs",
+			wantRules:     map[string]int64{},
+			wantConflicts: map[string][]int64{},
+		},
+		{
+			name:    "no module",
+			modules: []ModuleInst{},
+			scopeRules: []HostApplyScopeRule{
+				{ID: 1, BizID: 2, Scope: HostApplyScopeBiz, AttributeID: 100, PropertyValue: "a"},
+			},
+			wantRules:     map[string]int64{},
+			wantConflicts: map[string][]int64{},
+		},
+		{
+			name:          "no rule",
+			modules:       modules,
+			wantRules:     map[string]int64{},
+			wantConflicts: map[string][]int64{},
+		},
+		{
+			name:    "overlapping scopes",
+			modules: modules,
+			scopeRules: []HostApplyScopeRule{
+				{ID: 1, BizID: 2, Scope: HostApplyScopeBiz, AttributeID: 100, PropertyValue: "biz"},
+				{ID: 2, BizID: 2, Scope: HostApplyScopeSetTemplate, SetTemplateID: 20, AttributeID: 100,
+					PropertyValue: "set template"},
+				{ID: 3, BizID: 2, Scope: HostApplyScopeSet, SetID: 10, AttributeID: 100, PropertyValue: "set"},
+				// the same value as the final rule is not a conflict
+				{ID: 4, BizID: 2, Scope: HostApplyScopeBiz, AttributeID: 101, PropertyValue: "same"},
+				{ID: 5, BizID: 2, Scope: HostApplyScopeSet, SetID: 11, AttributeID: 101, PropertyValue: "same"},
+				// rules of other business, set or set template are not used
+				{ID: 6, BizID: 3, Scope: HostApplyScopeBiz, AttributeID: 102, PropertyValue: "other"},
+				{ID: 7, BizID: 2, Scope: HostApplyScopeSet, SetID: 12, AttributeID: 102, PropertyValue: "other"},
+				{ID: 8, BizID: 2, Scope: HostApplyScopeSetTemplate, SetTemplateID: 21, AttributeID: 102,
+					PropertyValue: "other"},
+				// dynamic group rules are matched by hosts
+				{ID: 9, BizID: 2, Scope: HostApplyScopeDynamicGroup, DynamicGroupID: "g", AttributeID: 102,
+					PropertyValue: "group"},
+			},
+			wantRules: map[string]int64{
+				"1:100": 3, "2:100": 3, "3:100": 1,
+				"1:101": 4, "2:101": 4, "3:101": 5,
+			},
+			wantConflicts: map[string][]int64{
+				"1:100": {1, 2},
+				"2:100": {1, 2},
+			},
+		},
+		{
+			name:    "module rule against scope rule on the same attribute",
+			modules: modules,
+			moduleRules: []HostApplyRule{
+				{ID: 11, BizID: 2, ModuleID: 1, AttributeID: 100, PropertyValue: "module"},
+				{ID: 12, BizID: 2, ModuleID: 2, ServiceTemplateID: 30, AttributeID: 100,
+					PropertyValue: "service template"},
+				{ID: 13, BizID: 2, ModuleID: 3, AttributeID: 100, PropertyValue: []string{"set", "value"}},
+			},
+			scopeRules: []HostApplyScopeRule{
+				{ID: 1, BizID: 2, Scope: HostApplyScopeSet, SetID: 10, AttributeID: 100, PropertyValue: "set"},
+				{ID: 2, BizID: 2, Scope: HostApplyScopeSet, SetID: 11, AttributeID: 100,
+					PropertyValue: []interface{}{"set", "value"}},
+				{ID: 3, BizID: 2, Scope: HostApplyScopeBiz, AttributeID: 101, PropertyValue: "biz"},
+			},
+			wantRules: map[string]int64{
+				"1:100": 11, "2:100": 12, "3:100": 13,
+				"1:101": 3, "2:101": 3, "3:101": 3,
+			},
+			wantConflicts: map[string][]int64{
+				"1:100": {1},
+				"2:100": {1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finalRules, conflicts := MergeHostApplyScopeRules(tt.modules, tt.moduleRules, tt.scopeRules)
+
+			gotRules := make(map[string]int64)
+			for _, rule := range finalRules {
+				gotRules[fmt.Sprintf("%d:%d", rule.ModuleID, rule.AttributeID)] = rule.ID
+			}
+			if !reflect.DeepEqual(gotRules, tt.wantRules) {
+				t.Errorf("MergeHostApplyScopeRules() rules = %v, want %v", gotRules, tt.wantRules)
+			}
+
+			gotConflicts := make(map[string][]int64)
+			for _, conflict := range conflicts {
+				key := fmt.Sprintf("%d:%d", conflict.ModuleID, conflict.AttributeID)
+				if conflict.FinalRule.ID != tt.wantRules[key] {
+					t.Errorf("conflict %s final rule = %d, want %d", key, conflict.FinalRule.ID, tt.wantRules[key])
+				}
+				for _, rule := range conflict.OverriddenRules {
+					gotConflicts[key] = append(gotConflicts[key], rule.ID)
+				}
+			}
+			if !reflect.DeepEqual(gotConflicts, tt.wantConflicts) {
+				t.Errorf("MergeHostApplyScopeRules() conflicts = %v, want %v", gotConflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestMergeHostApplyScopeRulesScope(t *testing.T) {
+	modules := []ModuleInst{{BizID: 2, SetID: 10, ModuleID: 1}}
+	moduleRules := []HostApplyRule{
+		{ID: 1, BizID: 2, ModuleID: 1, AttributeID: 100},
+		{ID: 2, BizID: 2, ModuleID: 1, ServiceTemplateID: 30, AttributeID: 101},
+	}
+	scopeRules := []HostApplyScopeRule{{ID: 3, BizID: 2, Scope: HostApplyScopeSet, SetID: 10, AttributeID: 102}}
+
+	finalRules, _ := MergeHostApplyScopeRules(modules, moduleRules, scopeRules)
+
+	// the scope of the final rules are filled so that the rules can be told apart
+	gotScopes := make(map[int64]HostApplyScope)
+	for _, rule := range finalRules {
+		gotScopes[rule.ID] = rule.Scope
+	}
+	wantScopes := map[int64]HostApplyScope{1: HostApplyScopeModule, 2: HostApplyScopeServiceTemplate,
+		3: HostApplyScopeSet}
+	if !reflect.DeepEqual(gotScopes, wantScopes) {
+		t.Errorf("MergeHostApplyScopeRules() scopes = %v, want %v", gotScopes, wantScopes)
+	}
+
+	// the module rules are not changed
+	if moduleRules[0].Scope != "" || moduleRules[1].Scope != "" {
+		t.Errorf("module rules are changed, %+v", moduleRules)
+	}
+}
@@ -0,0 +1,315 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+const (
+	// HostLeaseMaxDuration the max seconds that the hosts can be leased, including the extensions
+	HostLeaseMaxDuration int64 = 180 * 24 * 60 * 60
+	// HostLeaseMaxReasonLength the max length of the lease reason
+	HostLeaseMaxReasonLength = 512
+	// HostLeaseMaxNotifiers the max number of the users that are reminded before the lease expires
+	HostLeaseMaxNotifiers = 20
+	// HostLeaseMaxReturnFailures the max times that the hosts of an expired lease fail to be returned automatically,
+	// the lease is set to return failed status after that, so that it is not returned again and again
+	HostLeaseMaxReturnFailures = 3
+)
+
+// HostLeaseStatus is the status of the host lease
+type HostLeaseStatus string
+
+const (
+	// HostLeaseActive the hosts are leased to the business
+	HostLeaseActive HostLeaseStatus = "active"
+	// HostLeaseReturned the hosts are returned by the user before the lease expires
+	HostLeaseReturned HostLeaseStatus = "returned"
+	// HostLeaseExpired the lease is expired and the hosts are returned automatically
+	HostLeaseExpired HostLeaseStatus = "expired"
+	// HostLeaseReturnFailed the lease is expired but the hosts can not be returned automatically, e.g. the host
+	// lifecycle does not allow it, the hosts need to be returned by the user after the problem is fixed
+	HostLeaseReturnFailed HostLeaseStatus = "return_failed"
+)
+
+// CanReturn returns if the hosts of the lease in this status can be returned by the user
+func (s HostLeaseStatus) CanReturn() bool {
+	return s == HostLeaseActive || s == HostLeaseReturnFailed
+}
+
+// HostLeaseReturnMode defines where the hosts are transferred to when they are returned
+type HostLeaseReturnMode string
+
+const (
+	// HostLeaseReturnToRecycle the hosts are transferred to the recycle module of the business
+	HostLeaseReturnToRecycle HostLeaseReturnMode = "recycle_module"
+	// HostLeaseReturnToResourceDir the hosts are transferred back to the resource directory they are leased from
+	HostLeaseReturnToResourceDir HostLeaseReturnMode = "resource_directory"
+)
+
+// Validate host lease return mode
+func (m HostLeaseReturnMode) Validate() bool {
+	switch m {
+	case HostLeaseReturnToRecycle, HostLeaseReturnToResourceDir:
+		return true
+	default:
+		return false
+	}
+}
+
+// HostLeaseAction is the action recorded in the host lease history
+type HostLeaseAction string
+
+const (
+	// HostLeaseActionAllocate the hosts are allocated to the business
+	HostLeaseActionAllocate HostLeaseAction = "allocate"
+	// HostLeaseActionRemind the users are reminded that the lease is going to expire
+	HostLeaseActionRemind HostLeaseAction = "remind"
+	// HostLeaseActionExtend the lease is extended
+	HostLeaseActionExtend HostLeaseAction = "extend"
+	// HostLeaseActionReturn the hosts are returned by the user
+	HostLeaseActionReturn HostLeaseAction = "return"
+	// HostLeaseActionExpire the lease is expired and the hosts are returned automatically
+	HostLeaseActionExpire HostLeaseAction = "expire"
+	// HostLeaseActionReturnFailed the hosts of the expired lease can not be returned automatically
+	HostLeaseActionReturnFailed HostLeaseAction = "return_failed"
+)
+
+// HostLease is a time-bound allocation of the resource pool hosts to a business module
+type HostLease struct {
+	ID       int64   `json:"id" bson:"id"`
+	BizID    int64   `json:"bk_biz_id" bson:"bk_biz_id"`
+	ModuleID int64   `json:"bk_module_id" bson:"bk_module_id"`
+	HostIDs  []int64 `json:"bk_host_ids" bson:"bk_host_ids"`
+	// ResDirID is the resource directory that the hosts are leased from
+	ResDirID   int64               `json:"resource_directory" bson:"resource_directory"`
+	ReturnMode HostLeaseReturnMode `json:"return_mode" bson:"return_mode"`
+	ExpireTime Time                `json:"expire_time" bson:"expire_time"`
+	// RemindBefore is the seconds before the expire time to remind the notifiers, 0 means not to remind
+	RemindBefore int64 `json:"remind_before" bson:"remind_before"`
+	// RemindTime is the time to remind the notifiers, it is calculated by the expire time and the remind before
+	RemindTime Time     `json:"remind_time" bson:"remind_time"`
+	Reminded   bool     `json:"reminded" bson:"reminded"`
+	Notifiers  []string `json:"notifiers" bson:"notifiers"`
+	Reason     string   `json:"reason" bson:"reason"`
+	// Extensions is the number of times that the lease is extended
+	Extensions int `json:"extensions" bson:"extensions"`
+	// ReturnFailures is the number of times that the hosts of the expired lease fail to be returned automatically
+	ReturnFailures int `json:"return_failures" bson:"return_failures"`
+	// ReturnError is the error of the last failed automatic return
+	ReturnError string          `json:"return_error" bson:"return_error"`
+	Status      HostLeaseStatus `json:"status" bson:"status"`
+	OwnerID     string          `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string          `json:"creator" bson:"creator"`
+	Modifier    string          `json:"modifier" bson:"modifier"`
+	CreateTime  Time            `json:"create_time" bson:"create_time"`
+	LastTime    Time            `json:"last_time" bson:"last_time"`
+}
+
+// CalcRemindTime calculates the remind time of the lease by its expire time
+func (l *HostLease) CalcRemindTime() {
+	l.Reminded = l.RemindBefore == 0
+	l.RemindTime = Time{Time: l.ExpireTime.Add(-time.Duration(l.RemindBefore) * time.Second)}
+}
+
+// RecordReturnFailure records a failed automatic return of the expired lease, the lease is set to return failed
+// status if it fails too many times, returns if the lease is set to return failed status.
+func (l *HostLease) RecordReturnFailure(errMsg string) bool {
+	l.ReturnFailures++
+	l.ReturnError = errMsg
+	if l.ReturnFailures < HostLeaseMaxReturnFailures {
+		return false
+	}
+
+	l.Status = HostLeaseReturnFailed
+	return true
+}
+
+// validateHostLeaseExpireTime validate that the expire time is after now and the lease is not longer than max duration
+func validateHostLeaseExpireTime(expireTime, createTime time.Time) ccErr.RawErrorInfo {
+	if !expireTime.After(time.Now()) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrHostLeaseExpireTimeInvalid,
+			Args: []interface{}{HostLeaseMaxDuration / (24 * 60 * 60)}}
+	}
+
+	if expireTime.Sub(createTime) > time.Duration(HostLeaseMaxDuration)*time.Second {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrHostLeaseExpireTimeInvalid,
+			Args: []interface{}{HostLeaseMaxDuration / (24 * 60 * 60)}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// CreateHostLeaseOption create host lease option
+type CreateHostLeaseOption struct {
+	// ModuleID is the module that the hosts are allocated to, the idle module of the business is used if not set
+	ModuleID     int64               `json:"bk_module_id"`
+	ResDirID     int64               `json:"resource_directory"`
+	HostIDs      []int64             `json:"bk_host_ids"`
+	ReturnMode   HostLeaseReturnMode `json:"return_mode"`
+	ExpireTime   Time                `json:"expire_time"`
+	RemindBefore int64               `json:"remind_before"`
+	Notifiers    []string            `json:"notifiers"`
+	Reason       string              `json:"reason"`
+}
+
+// Validate create host lease option
+func (o *CreateHostLeaseOption) Validate() ccErr.RawErrorInfo {
+	if o.ResDirID <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"resource_directory"}}
+	}
+
+	if len(o.HostIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_host_ids"}}
+	}
+
+	if len(o.HostIDs) > common.BKMaxPageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxPageSize}}
+	}
+
+	if len(o.ReturnMode) == 0 {
+		o.ReturnMode = HostLeaseReturnToResourceDir
+	}
+
+	if !o.ReturnMode.Validate() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"return_mode"}}
+	}
+
+	if rawErr := validateHostLeaseExpireTime(o.ExpireTime.Time, time.Now()); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if o.RemindBefore < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"remind_before"}}
+	}
+
+	if len(o.Notifiers) > HostLeaseMaxNotifiers {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{HostLeaseMaxNotifiers}}
+	}
+
+	if len(o.Reason) > HostLeaseMaxReasonLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{"reason", HostLeaseMaxReasonLength}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ExtendHostLeaseOption extend host lease option
+type ExtendHostLeaseOption struct {
+	ExpireTime Time   `json:"expire_time"`
+	Reason     string `json:"reason"`
+}
+
+// Validate extend host lease option, the expire time must be later than the current expire time of the lease
+func (o *ExtendHostLeaseOption) Validate(lease *HostLease) ccErr.RawErrorInfo {
+	if !o.ExpireTime.After(lease.ExpireTime.Time) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"expire_time"}}
+	}
+
+	if rawErr := validateHostLeaseExpireTime(o.ExpireTime.Time, lease.CreateTime.Time); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if len(o.Reason) > HostLeaseMaxReasonLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{"reason", HostLeaseMaxReasonLength}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ReturnHostLeaseTaskOption return the hosts of the expired lease task option
+type ReturnHostLeaseTaskOption struct {
+	ID int64 `json:"id"`
+}
+
+// SearchHostLeaseOption search host leases option
+type SearchHostLeaseOption struct {
+	BizID   int64             `json:"bk_biz_id"`
+	IDs     []int64           `json:"ids"`
+	HostIDs []int64           `json:"bk_host_ids"`
+	Status  []HostLeaseStatus `json:"status"`
+	Page    BasePage          `json:"page"`
+}
+
+// Validate search host leases option
+func (o *SearchHostLeaseOption) Validate() ccErr.RawErrorInfo {
+	if len(o.IDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxLimitSize}}
+	}
+
+	if len(o.HostIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxLimitSize}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchHostLeaseResult search host leases result
+type SearchHostLeaseResult struct {
+	Count uint64      `json:"count"`
+	Info  []HostLease `json:"info"`
+}
+
+// UpdateHostLeaseOption update host lease option, only the lease status, expire time and remind info can be updated
+type UpdateHostLeaseOption struct {
+	Status     HostLeaseStatus `json:"status,omitempty"`
+	ExpireTime *Time           `json:"expire_time,omitempty"`
+	Reminded   *bool           `json:"reminded,omitempty"`
+	// Extend increases the extension count of the lease
+	Extend bool `json:"extend,omitempty"`
+	// ReturnError records a failed automatic return of the expired lease with the error
+	ReturnError string `json:"return_error,omitempty"`
+}
+
+// HostLeaseHistory is an action record of the host lease
+type HostLeaseHistory struct {
+	ID      int64           `json:"id" bson:"id"`
+	LeaseID int64           `json:"lease_id" bson:"lease_id"`
+	BizID   int64           `json:"bk_biz_id" bson:"bk_biz_id"`
+	HostIDs []int64         `json:"bk_host_ids" bson:"bk_host_ids"`
+	Action  HostLeaseAction `json:"action" bson:"action"`
+	// ExpireTime is the expire time of the lease after the action
+	ExpireTime Time   `json:"expire_time" bson:"expire_time"`
+	Reason     string `json:"reason" bson:"reason"`
+	Operator   string `json:"operator" bson:"operator"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime Time   `json:"create_time" bson:"create_time"`
+}
+
+// SearchHostLeaseHistoryOption search host lease histories option
+type SearchHostLeaseHistoryOption struct {
+	BizID   int64    `json:"bk_biz_id"`
+	LeaseID int64    `json:"lease_id"`
+	Page    BasePage `json:"page"`
+}
+
+// Validate search host lease histories option
+func (o *SearchHostLeaseHistoryOption) Validate() ccErr.RawErrorInfo {
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchHostLeaseHistoryResult search host lease histories result
+type SearchHostLeaseHistoryResult struct {
+	Count uint64             `json:"count"`
+	Info  []HostLeaseHistory `json:"info"`
+}
@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+)
+
+func TestHostLeaseCalcRemindTime(t *testing.T) {
+	expireTime := time.Now().Add(24 * time.Hour)
+	tests := []struct {
+		name         string
+		remindBefore int64
+		wantRemind   time.Time
+		wantReminded bool
+	}{
+		{"remind an hour before expiry", 3600, expireTime.Add(-time.Hour), false},
+		{"no reminder", 0, expireTime, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &HostLease{ExpireTime: Time{Time: expireTime}, RemindBefore: tt.remindBefore}
+			lease.CalcRemindTime()
+			if !lease.RemindTime.Equal(tt.wantRemind) || lease.Reminded != tt.wantReminded {
+				t.Errorf("CalcRemindTime() = %v, %v, want %v, %v", lease.RemindTime, lease.Reminded, tt.wantRemind,
+					tt.wantReminded)
+			}
+		})
+	}
+}
+
+func TestExtendHostLeaseOptionValidate(t *testing.T) {
+	now := time.Now()
+	lease := &HostLease{CreateTime: Time{Time: now.Add(-time.Hour)}, ExpireTime: Time{Time: now.Add(time.Hour)}}
+	maxExpireTime := lease.CreateTime.Add(time.Duration(HostLeaseMaxDuration) * time.Second)
+
+	tests := []struct {
+		name       string
+		expireTime time.Time
+		errCode    int
+	}{
+		{"extend a day", now.Add(25 * time.Hour), 0},
+		{"extend to the max duration", maxExpireTime, 0},
+		{"not later than the current expire time", now.Add(time.Hour), common.CCErrCommParamsInvalid},
+		{"already expired", now.Add(-time.Minute), common.CCErrCommParamsInvalid},
+		{"exceed the max duration", maxExpireTime.Add(time.Second), common.CCErrHostLeaseExpireTimeInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := &ExtendHostLeaseOption{ExpireTime: Time{Time: tt.expireTime}}
+			if rawErr := opt.Validate(lease); rawErr.ErrCode != tt.errCode {
+				t.Errorf("Validate() error code = %d, want %d", rawErr.ErrCode, tt.errCode)
+			}
+		})
+	}
+}
+
+func TestHostLeaseRecordReturnFailure(t *testing.T) {
+	lease := &HostLease{Status: HostLeaseActive}
+
+	// the expired lease is returned again by the timer until it fails too many times
+	for idx := 1; idx < HostLeaseMaxReturnFailures; idx++ {
+		if failed := lease.RecordReturnFailure("lifecycle denied"); failed {
+			t.Fatalf("lease is set to return failed after %d failures", idx)
+		}
+		if lease.Status != HostLeaseActive || lease.ReturnFailures != idx {
+			t.Fatalf("lease = %s with %d failures, want active with %d failures", lease.Status,
+				lease.ReturnFailures, idx)
+		}
+	}
+
+	if failed := lease.RecordReturnFailure("lifecycle denied again"); !failed {
+		t.Fatalf("lease is not set to return failed after %d failures", HostLeaseMaxReturnFailures)
+	}
+	if lease.Status != HostLeaseReturnFailed || lease.ReturnError != "lifecycle denied again" {
+		t.Errorf("lease = %s with error %s, want return failed with the last error", lease.Status,
+			lease.ReturnError)
+	}
+}
+
+func TestHostLeaseStatusCanReturn(t *testing.T) {
+	tests := []struct {
+		status HostLeaseStatus
+		want   bool
+	}{
+		{HostLeaseActive, true},
+		// the lease that fails to be returned automatically is returned by the user
+		{HostLeaseReturnFailed, true},
+		{HostLeaseReturned, false},
+		{HostLeaseExpired, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.CanReturn(); got != tt.want {
+				t.Errorf("CanReturn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+const (
+	// HostLifecycleMaxStates the max number of the states of the host lifecycle
+	HostLifecycleMaxStates = 50
+	// HostLifecycleMaxTransitions the max number of the transitions of the host lifecycle
+	HostLifecycleMaxTransitions = 200
+)
+
+// HostLifecycleTrigger is the operation that triggers the host lifecycle transition
+type HostLifecycleTrigger string
+
+const (
+	// HostLifecycleUpdate the host bk_state is changed by updating the host properties
+	HostLifecycleUpdate HostLifecycleTrigger = "update"
+	// HostLifecycleTransfer the host bk_state is changed by transferring the host to another module
+	HostLifecycleTransfer HostLifecycleTrigger = "transfer"
+)
+
+// HostLifecycleState is a state of the host lifecycle, the id is the enum option id of the host bk_state attribute
+type HostLifecycleState struct {
+	ID   string `json:"id" bson:"id"`
+	Name string `json:"name" bson:"name"`
+	// RequiredFields are the host fields that must not be empty when the host enters this state
+	RequiredFields []string `json:"required_fields" bson:"required_fields"`
+	// ModuleDefaults are the default flags of the modules(0 for normal, 1 for idle, 2 for fault, 3 for recycle
+	// module), the host enters this state when it is transferred to these modules
+	ModuleDefaults []int `json:"module_defaults" bson:"module_defaults"`
+}
+
+// HostLifecycleTransition is an allowed transition of the host lifecycle
+type HostLifecycleTransition struct {
+	From string `json:"from" bson:"from"`
+	To   string `json:"to" bson:"to"`
+	// Operators are the users that can make this transition, empty means all users
+	Operators []string `json:"operators" bson:"operators"`
+}
+
+// HostLifecycle is the lifecycle definition of the hosts of a supplier account, the host bk_state can only be changed
+// through the allowed transitions when it is enabled
+type HostLifecycle struct {
+	Enabled     bool                      `json:"enabled" bson:"enabled"`
+	States      []HostLifecycleState      `json:"states" bson:"states"`
+	Transitions []HostLifecycleTransition `json:"transitions" bson:"transitions"`
+	OwnerID     string                    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Modifier    string                    `json:"modifier" bson:"modifier"`
+	LastTime    Time                      `json:"last_time" bson:"last_time"`
+}
+
+// Validate host lifecycle, the state ids are validated by the host bk_state attribute separately
+func (l *HostLifecycle) Validate() ccErr.RawErrorInfo {
+	if len(l.States) > HostLifecycleMaxStates {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{HostLifecycleMaxStates}}
+	}
+
+	if len(l.Transitions) > HostLifecycleMaxTransitions {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{HostLifecycleMaxTransitions}}
+	}
+
+	if l.Enabled && len(l.States) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"states"}}
+	}
+
+	stateMap := make(map[string]struct{})
+	moduleDefaultMap := make(map[int]struct{})
+	for _, state := range l.States {
+		if len(state.ID) == 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"states.id"}}
+		}
+
+		if _, exists := stateMap[state.ID]; exists {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"states.id"}}
+		}
+		stateMap[state.ID] = struct{}{}
+
+		// each module type can only lead to one state, otherwise the transferred host state is ambiguous
+		for _, flag := range state.ModuleDefaults {
+			if flag < common.DefaultFlagDefaultValue || flag > common.DefaultRecycleModuleFlag {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+					Args: []interface{}{"states.module_defaults"}}
+			}
+
+			if _, exists := moduleDefaultMap[flag]; exists {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+					Args: []interface{}{"states.module_defaults"}}
+			}
+			moduleDefaultMap[flag] = struct{}{}
+		}
+	}
+
+	for _, transition := range l.Transitions {
+		if _, exists := stateMap[transition.From]; !exists {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"transitions.from"}}
+		}
+
+		if _, exists := stateMap[transition.To]; !exists || transition.From == transition.To {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"transitions.to"}}
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// GetState get the lifecycle state by id
+func (l *HostLifecycle) GetState(id string) (*HostLifecycleState, bool) {
+	for idx := range l.States {
+		if l.States[idx].ID == id {
+			return &l.States[idx], true
+		}
+	}
+	return nil, false
+}
+
+// GetStateByModuleDefault get the lifecycle state that the host enters when it is transferred to the module with
+// the default flag
+func (l *HostLifecycle) GetStateByModuleDefault(flag int) (*HostLifecycleState, bool) {
+	for idx := range l.States {
+		if util.InArray(flag, l.States[idx].ModuleDefaults) {
+			return &l.States[idx], true
+		}
+	}
+	return nil, false
+}
+
+// CheckTransition check if the host can transit from one state to another by the operator, the host is the host
+// data after the transition. the host whose state is empty or not defined in the lifecycle is not managed by the
+// lifecycle yet, so it can enter any state.
+func (l *HostLifecycle) CheckTransition(hostID int64, from, to, operator string,
+	host mapstr.MapStr) ccErr.RawErrorInfo {
+
+	toState, exists := l.GetState(to)
+	if !exists {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrHostLifecycleStateInvalid, Args: []interface{}{to}}
+	}
+
+	if _, exists := l.GetState(from); exists && from != to {
+		allowed := false
+		for _, transition := range l.Transitions {
+			if transition.From != from || transition.To != to {
+				continue
+			}
+
+			if len(transition.Operators) > 0 && !util.InStrArr(transition.Operators, operator) {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrHostLifecycleOperatorNotAllowed,
+					Args: []interface{}{operator, from, to}}
+			}
+			allowed = true
+			break
+		}
+
+		if !allowed {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrHostLifecycleTransitionNotAllowed,
+				Args: []interface{}{hostID, from, to}}
+		}
+	}
+
+	for _, field := range toState.RequiredFields {
+		if IsEmptyValue(host[field]) {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrHostLifecycleRequiredFieldEmpty,
+				Args: []interface{}{hostID, field, to}}
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// HostLifecycleHistory is a transition record of the host lifecycle
+type HostLifecycleHistory struct {
+	ID         int64                `json:"id" bson:"id"`
+	HostID     int64                `json:"bk_host_id" bson:"bk_host_id"`
+	InnerIP    string               `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	BizID      int64                `json:"bk_biz_id" bson:"bk_biz_id"`
+	From       string               `json:"from" bson:"from"`
+	To         string               `json:"to" bson:"to"`
+	Trigger    HostLifecycleTrigger `json:"trigger" bson:"trigger"`
+	Operator   string               `json:"operator" bson:"operator"`
+	OwnerID    string               `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time            `json:"create_time" bson:"create_time"`
+}
+
+// CreateHostLifecycleHistoryOption create host lifecycle transition histories option
+type CreateHostLifecycleHistoryOption struct {
+	Histories []HostLifecycleHistory `json:"histories"`
+}
+
+// SearchHostLifecycleHistoryOption search host lifecycle transition histories option
+type SearchHostLifecycleHistoryOption struct {
+	HostIDs []int64  `json:"bk_host_ids"`
+	Page    BasePage `json:"page"`
+}
+
+// Validate search host lifecycle transition histories option
+func (o *SearchHostLifecycleHistoryOption) Validate() ccErr.RawErrorInfo {
+	if len(o.HostIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxLimitSize}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchHostLifecycleHistoryResult search host lifecycle transition histories result
+type SearchHostLifecycleHistoryResult struct {
+	Count uint64                 `json:"count"`
+	Info  []HostLifecycleHistory `json:"info"`
+}
+
+// ListStuckHostOption list the hosts that stay in the state longer than the duration option
+type ListStuckHostOption struct {
+	State string `json:"bk_state"`
+	// Duration is the seconds that the host stays in the state
+	Duration int64    `json:"duration"`
+	Page     BasePage `json:"page"`
+}
+
+// Validate list stuck hosts option
+func (o *ListStuckHostOption) Validate() ccErr.RawErrorInfo {
+	if len(o.State) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKHostState}}
+	}
+
+	if o.Duration <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"duration"}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// StuckHost is a host that stays in the state longer than the duration, the enter time is the time of the last
+// transition to the state, or the create time of the host if it never transits to the state
+type StuckHost struct {
+	HostID    int64     `json:"bk_host_id"`
+	InnerIP   string    `json:"bk_host_innerip"`
+	State     string    `json:"bk_state"`
+	EnterTime time.Time `json:"enter_time"`
+}
+
+// ListStuckHostResult list stuck hosts result, the hosts are sorted by the enter time
+type ListStuckHostResult struct {
+	Count uint64      `json:"count"`
+	Info  []StuckHost `json:"info"`
+}
@@ -0,0 +1,201 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func TestHostLifecycleCheckTransition(t *testing.T) {
+	lifecycle := &HostLifecycle{
+		Enabled: true,
+		States: []HostLifecycleState{
+			{ID: "purchased"},
+			{ID: "running", RequiredFields: []string{common.BKOperatorField}},
+			{ID: "frozen"},
+			{ID: "decommissioning"},
+		},
+		Transitions: []HostLifecycleTransition{
+			{From: "purchased", To: "running"},
+			{From: "running", To: "frozen"},
+			{From: "frozen", To: "running", Operators: []string{"admin"}},
+			{From: "running", To: "decommissioning"},
+			{From: "frozen", To: "decommissioning", Operators: []string{"admin"}},
+		},
+	}
+	withOperator := mapstr.MapStr{common.BKOperatorField: "user"}
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		operator string
+		host     mapstr.MapStr
+		errCode  int
+	}{
+		{"purchased to running", "purchased", "running", "user", withOperator, 0},
+		{"running to frozen", "running", "frozen", "user", mapstr.MapStr{}, 0},
+		{"frozen to running by operator", "frozen", "running", "admin", withOperator, 0},
+		{"running to decommissioning", "running", "decommissioning", "user", mapstr.MapStr{}, 0},
+		{"frozen to decommissioning by operator", "frozen", "decommissioning", "admin", mapstr.MapStr{}, 0},
+		// the state that is not managed by the lifecycle yet can enter any state
+		{"empty state to any state", "", "frozen", "user", mapstr.MapStr{}, 0},
+		{"unknown state to any state", "unknown", "decommissioning", "user", mapstr.MapStr{}, 0},
+		{"undefined transition", "purchased", "frozen", "user", mapstr.MapStr{},
+			common.CCErrHostLifecycleTransitionNotAllowed},
+		{"transition from final state", "decommissioning", "running", "admin", withOperator,
+			common.CCErrHostLifecycleTransitionNotAllowed},
+		{"reverted transition", "frozen", "purchased", "admin", mapstr.MapStr{},
+			common.CCErrHostLifecycleTransitionNotAllowed},
+		{"frozen to running by others", "frozen", "running", "user", withOperator,
+			common.CCErrHostLifecycleOperatorNotAllowed},
+		{"frozen to decommissioning by others", "frozen", "decommissioning", "user", mapstr.MapStr{},
+			common.CCErrHostLifecycleOperatorNotAllowed},
+		{"required field not set", "purchased", "running", "user", mapstr.MapStr{},
+			common.CCErrHostLifecycleRequiredFieldEmpty},
+		{"required field empty", "", "running", "user", mapstr.MapStr{common.BKOperatorField: ""},
+			common.CCErrHostLifecycleRequiredFieldEmpty},
+		{"undefined target state", "running", "unknown", "user", mapstr.MapStr{},
+			common.CCErrHostLifecycleStateInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawErr := lifecycle.CheckTransition(1, tt.from, tt.to, tt.operator, tt.host)
+			if rawErr.ErrCode != tt.errCode {
+				t.Errorf("CheckTransition() error code = %d, want %d", rawErr.ErrCode, tt.errCode)
+			}
+		})
+	}
+}
+
+func TestHostLifecycleGetStateByModuleDefault(t *testing.T) {
+	lifecycle := &HostLifecycle{
+		Enabled: true,
+		States: []HostLifecycleState{
+			{ID: "purchased", ModuleDefaults: []int{common.DefaultResModuleFlag}},
+			{ID: "running", ModuleDefaults: []int{common.DefaultFlagDefaultValue}},
+			{ID: "frozen"},
+			{ID: "decommissioning", ModuleDefaults: []int{common.DefaultRecycleModuleFlag}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		flag   int
+		want   string
+		exists bool
+	}{
+		{"normal module", common.DefaultFlagDefaultValue, "running", true},
+		{"idle module", common.DefaultResModuleFlag, "purchased", true},
+		{"recycle module", common.DefaultRecycleModuleFlag, "decommissioning", true},
+		{"fault module", common.DefaultFaultModuleFlag, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, exists := lifecycle.GetStateByModuleDefault(tt.flag)
+			if exists != tt.exists || (exists && state.ID != tt.want) {
+				t.Errorf("GetStateByModuleDefault() = %v, %v, want %s, %v", state, exists, tt.want, tt.exists)
+			}
+		})
+	}
+}
+
+func TestHostLifecycleValidate(t *testing.T) {
+	transitions := []HostLifecycleTransition{{From: "running", To: "frozen"}}
+
+	tests := []struct {
+		name      string
+		lifecycle HostLifecycle
+		wantErr   bool
+	}{
+		{
+			name: "valid lifecycle",
+			lifecycle: HostLifecycle{Enabled: true,
+				States: []HostLifecycleState{
+					{ID: "running", ModuleDefaults: []int{common.DefaultFlagDefaultValue}},
+					{ID: "frozen", ModuleDefaults: []int{common.DefaultResModuleFlag}},
+				},
+				Transitions: transitions},
+		},
+		{
+			name:      "disabled without states",
+			lifecycle: HostLifecycle{},
+		},
+		{
+			name:      "enabled without states",
+			lifecycle: HostLifecycle{Enabled: true},
+			wantErr:   true,
+		},
+		{
+			name: "empty state id",
+			lifecycle: HostLifecycle{Enabled: true,
+				States: []HostLifecycleState{{ID: ""}, {ID: "frozen"}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate state id",
+			lifecycle: HostLifecycle{Enabled: true,
+				States: []HostLifecycleState{{ID: "running"}, {ID: "running"}}},
+			wantErr: true,
+		},
+		{
+			name: "invalid module default",
+			lifecycle: HostLifecycle{Enabled: true,
+				States: []HostLifecycleState{{ID: "running", ModuleDefaults: []int{9}}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate module default",
+			lifecycle: HostLifecycle{Enabled: true,
+				States: []HostLifecycleState{
+					{ID: "running", ModuleDefaults: []int{common.DefaultResModuleFlag}},
+					{ID: "frozen", ModuleDefaults: []int{common.DefaultResModuleFlag}},
+				}},
+			wantErr: true,
+		},
+		{
+			name: "unknown from state",
+			lifecycle: HostLifecycle{Enabled: true,
+				States:      []HostLifecycleState{{ID: "frozen"}},
+				Transitions: transitions},
+			wantErr: true,
+		},
+		{
+			name: "unknown to state",
+			lifecycle: HostLifecycle{Enabled: true,
+				States:      []HostLifecycleState{{ID: "running"}},
+				Transitions: transitions},
+			wantErr: true,
+		},
+		{
+			name: "self transition",
+			lifecycle: HostLifecycle{Enabled: true,
+				States:      []HostLifecycleState{{ID: "running"}},
+				Transitions: []HostLifecycleTransition{{From: "running", To: "running"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rawErr := tt.lifecycle.Validate(); (rawErr.ErrCode != 0) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", rawErr, tt.wantErr)
+			}
+		})
+	}
+}
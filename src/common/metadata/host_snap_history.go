@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// HostSnapHistoryType is the type of the host snapshot history
+type HostSnapHistoryType string
+
+const (
+	// HostSnapHistoryChange the host field is changed by the reported snapshot
+	HostSnapHistoryChange HostSnapHistoryType = "change"
+	// HostSnapHistoryDrift the reported snapshot value differs from the value declared in the cmdb host field
+	HostSnapHistoryDrift HostSnapHistoryType = "drift"
+)
+
+// Validate host snapshot history type
+func (t HostSnapHistoryType) Validate() bool {
+	switch t {
+	case HostSnapHistoryChange, HostSnapHistoryDrift:
+		return true
+	}
+	return false
+}
+
+// HostSnapHistory is a change or drift record of a snapshot managed host field, the change history records the
+// value before and after the snapshot update, the drift history records the declared value and the reported value
+type HostSnapHistory struct {
+	ID     int64               `json:"id" bson:"id"`
+	HostID int64               `json:"bk_host_id" bson:"bk_host_id"`
+	Type   HostSnapHistoryType `json:"type" bson:"type"`
+	// Field is the snapshot managed host field
+	Field string `json:"bk_field" bson:"bk_field"`
+	// DeclaredField is the cmdb host field that declares the expected value of the field, only for drift history
+	DeclaredField string      `json:"declared_field,omitempty" bson:"declared_field,omitempty"`
+	PreData       interface{} `json:"pre_data" bson:"pre_data"`
+	CurData       interface{} `json:"cur_data" bson:"cur_data"`
+	OwnerID       string      `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime    time.Time   `json:"create_time" bson:"create_time"`
+}
+
+// CreateHostSnapHistoryOption create host snapshot histories option
+type CreateHostSnapHistoryOption struct {
+	Histories []HostSnapHistory `json:"histories"`
+}
+
+// HostSnapHistoryFilter is the common filter of the host snapshot histories
+type HostSnapHistoryFilter struct {
+	// Type is the history type, change history is searched if it is not set
+	Type   HostSnapHistoryType `json:"type"`
+	Fields []string            `json:"bk_fields"`
+	// StartTime and EndTime is the create time range of the histories
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+}
+
+// Validate host snapshot history filter
+func (f *HostSnapHistoryFilter) Validate() ccErr.RawErrorInfo {
+	if f.Type == "" {
+		f.Type = HostSnapHistoryChange
+	}
+
+	if !f.Type.Validate() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"type"}}
+	}
+
+	if f.StartTime != nil && f.EndTime != nil && f.StartTime.After(*f.EndTime) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"start_time"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// SearchHostSnapHistoryOption search the snapshot histories of the hosts option
+type SearchHostSnapHistoryOption struct {
+	HostIDs               []int64 `json:"bk_host_ids"`
+	HostSnapHistoryFilter `json:",inline"`
+	Page                  BasePage `json:"page"`
+}
+
+// Validate search host snapshot histories option
+func (o *SearchHostSnapHistoryOption) Validate() ccErr.RawErrorInfo {
+	if len(o.HostIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKHostIDField}}
+	}
+
+	if len(o.HostIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrExceedMaxOperationRecordsAtOnce,
+			Args: []interface{}{common.BKMaxLimitSize}}
+	}
+
+	if rawErr := o.HostSnapHistoryFilter.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchHostSnapHistoryResult search host snapshot histories result
+type SearchHostSnapHistoryResult struct {
+	Count uint64            `json:"count"`
+	Info  []HostSnapHistory `json:"info"`
+}
+
+// ListHostSnapChangedHostOption list the hosts that have snapshot histories matching the filter option, such as the
+// hosts whose memory is changed in the week
+type ListHostSnapChangedHostOption struct {
+	HostSnapHistoryFilter `json:",inline"`
+	Page                  BasePage `json:"page"`
+}
+
+// Validate list host snapshot changed hosts option
+func (o *ListHostSnapChangedHostOption) Validate() ccErr.RawErrorInfo {
+	if len(o.Fields) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_fields"}}
+	}
+
+	if rawErr := o.HostSnapHistoryFilter.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// HostSnapChangedHost is the brief of the snapshot histories of a host
+type HostSnapChangedHost struct {
+	HostID         int64     `json:"bk_host_id" bson:"_id"`
+	Fields         []string  `json:"bk_fields" bson:"bk_fields"`
+	Count          int64     `json:"count" bson:"count"`
+	LastChangeTime time.Time `json:"last_change_time" bson:"last_change_time"`
+}
+
+// ListHostSnapChangedHostResult list host snapshot changed hosts result, sorted by the last change time desc
+type ListHostSnapChangedHostResult struct {
+	Count uint64                `json:"count"`
+	Info  []HostSnapChangedHost `json:"info"`
+}
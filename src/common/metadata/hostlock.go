@@ -15,12 +15,104 @@ package metadata
 import (
 	"time"
 
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/mapstr"
 )
 
+// HostLockMaxReasonLength is the max length of the host lock reason
+const HostLockMaxReasonLength = 256
+
+// HostLockScope is the scope of the operations that the host lock forbids
+type HostLockScope string
+
+const (
+	// HostLockScopeFull forbids all the operations that change the host, including updating, transferring,
+	// deleting the host and binding agent to the host
+	HostLockScopeFull HostLockScope = "full"
+	// HostLockScopeTransfer only forbids the operations that change the topology of the host, including
+	// transferring and deleting the host
+	HostLockScopeTransfer HostLockScope = "transfer"
+)
+
+// HostLockOperation is the host operation that is checked by the host lock
+type HostLockOperation string
+
+const (
+	// HostLockOpUpdate updates the host attributes
+	HostLockOpUpdate HostLockOperation = "update"
+	// HostLockOpBindAgent binds or unbinds agent to the host
+	HostLockOpBindAgent HostLockOperation = "bind_agent"
+	// HostLockOpTransfer transfers the host to other modules or businesses
+	HostLockOpTransfer HostLockOperation = "transfer"
+	// HostLockOpDelete deletes the host from cmdb
+	HostLockOpDelete HostLockOperation = "delete"
+)
+
+// Forbids returns if the host lock of the scope forbids the operation
+func (s HostLockScope) Forbids(op HostLockOperation) bool {
+	switch s {
+	case HostLockScopeTransfer:
+		return op == HostLockOpTransfer || op == HostLockOpDelete
+	default:
+		return true
+	}
+}
+
 // HostLockRequest TODO
 type HostLockRequest struct {
-	IDS []int64 `json:"id_list"`
+	IDS    []int64       `json:"id_list"`
+	Reason string        `json:"reason"`
+	Scope  HostLockScope `json:"scope"`
+	// TTL is the seconds that the lock lasts, the lock never expires if it is not set
+	TTL int64 `json:"ttl"`
+	// Force releases the locks of other users when unlocking the hosts, it is only set by the force unlock api that
+	// is limited to the administrators, the lock owner is checked for all the other requests
+	Force bool `json:"force"`
+}
+
+// ValidateLock validate the host lock request and set the default scope
+func (r *HostLockRequest) ValidateLock() errors.RawErrorInfo {
+	if len(r.IDS) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"id_list"},
+		}
+	}
+
+	if len(r.IDS) > common.BKMaxPageSize {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"id_list", common.BKMaxPageSize},
+		}
+	}
+
+	switch r.Scope {
+	case "":
+		r.Scope = HostLockScopeFull
+	case HostLockScopeFull, HostLockScopeTransfer:
+	default:
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"scope"},
+		}
+	}
+
+	if r.TTL < 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"ttl"},
+		}
+	}
+
+	if len(r.Reason) > HostLockMaxReasonLength {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args:    []interface{}{"reason", HostLockMaxReasonLength},
+		}
+	}
+
+	return errors.RawErrorInfo{}
 }
 
 // QueryHostLockRequest TODO
@@ -36,10 +128,80 @@ type HostLockResultResponse struct {
 
 // HostLockData TODO
 type HostLockData struct {
-	User       string    `json:"bk_user" bson:"bk_user"`
-	ID         int64     `json:"bk_host_id" bson:"bk_host_id"`
-	CreateTime time.Time `json:"create_time" bson:"create_time"`
-	OwnerID    string    `json:"-" bson:"bk_supplier_account"`
+	User       string        `json:"bk_user" bson:"bk_user"`
+	ID         int64         `json:"bk_host_id" bson:"bk_host_id"`
+	CreateTime time.Time     `json:"create_time" bson:"create_time"`
+	OwnerID    string        `json:"-" bson:"bk_supplier_account"`
+	Reason     string        `json:"reason" bson:"reason"`
+	Scope      HostLockScope `json:"scope" bson:"scope"`
+	// ExpireTime is the time that the lock expires, the lock never expires if it is not set
+	ExpireTime *time.Time `json:"expire_time,omitempty" bson:"expire_time,omitempty"`
+}
+
+// IsExpired returns if the host lock is expired at the time, the expired lock is treated as unlocked even if it is
+// not removed from db yet
+func (d *HostLockData) IsExpired(now time.Time) bool {
+	return d.ExpireTime != nil && !d.ExpireTime.After(now)
+}
+
+// GetLockedByOthers returns the hosts that are locked by other users with the lock owners, the expired locks are
+// treated as unlocked
+func GetLockedByOthers(locks []HostLockData, user string, now time.Time) ([]int64, []string) {
+	hostIDs := make([]int64, 0)
+	users := make([]string, 0)
+	userMap := make(map[string]struct{})
+	for _, lock := range locks {
+		if lock.User == user || lock.IsExpired(now) {
+			continue
+		}
+
+		hostIDs = append(hostIDs, lock.ID)
+		if _, exists := userMap[lock.User]; !exists {
+			userMap[lock.User] = struct{}{}
+			users = append(users, lock.User)
+		}
+	}
+	return hostIDs, users
+}
+
+// HostLockForceUnlockDetail is the audit detail of the host lock that is force released by the administrator
+type HostLockForceUnlockDetail struct {
+	Lock   HostLockData `json:"lock"`
+	Reason string       `json:"reason"`
+}
+
+// GetScope returns the scope of the host lock, the locks created before the scope is supported are full locks
+func (d *HostLockData) GetScope() HostLockScope {
+	if d.Scope == "" {
+		return HostLockScopeFull
+	}
+	return d.Scope
+}
+
+// ListHostLockOption list host locks option
+type ListHostLockOption struct {
+	HostIDs []int64       `json:"bk_host_ids"`
+	User    string        `json:"bk_user"`
+	Scope   HostLockScope `json:"scope"`
+	Page    BasePage      `json:"page"`
+}
+
+// Validate list host locks option
+func (o *ListHostLockOption) Validate() errors.RawErrorInfo {
+	if len(o.HostIDs) > common.BKMaxPageSize {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"bk_host_ids", common.BKMaxPageSize},
+		}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// ListHostLockResult list host locks result
+type ListHostLockResult struct {
+	Count int64          `json:"count"`
+	Info  []HostLockData `json:"info"`
 }
 
 // HostLockQueryResponse TODO
@@ -0,0 +1,62 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetLockedByOthers(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	active := now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		locks     []HostLockData
+		wantHosts []int64
+		wantUsers []string
+	}{
+		{
+			name:      "no lock",
+			wantHosts: []int64{},
+			wantUsers: []string{},
+		},
+		{
+			name: "locked by the user",
+			locks: []HostLockData{{ID: 1, User: "admin"}, {ID: 2, User: "admin", ExpireTime: &active},
+				{ID: 3, User: "other", ExpireTime: &expired}},
+			wantHosts: []int64{},
+			wantUsers: []string{},
+		},
+		{
+			name: "locked by others",
+			locks: []HostLockData{{ID: 1, User: "admin"}, {ID: 2, User: "alice"},
+				{ID: 3, User: "bob", ExpireTime: &active}, {ID: 4, User: "alice"},
+				{ID: 5, User: "carol", ExpireTime: &expired}},
+			wantHosts: []int64{2, 3, 4},
+			wantUsers: []string{"alice", "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostIDs, users := GetLockedByOthers(tt.locks, "admin", now)
+			if !reflect.DeepEqual(hostIDs, tt.wantHosts) || !reflect.DeepEqual(users, tt.wantUsers) {
+				t.Errorf("GetLockedByOthers() = %v, %v, want %v, %v", hostIDs, users, tt.wantHosts, tt.wantUsers)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// ModelDefinitionObjectMaxNum is the maximum number of objects in one model definition
+const ModelDefinitionObjectMaxNum = 100
+
+const (
+	// DefinitionClassification model classification, identified by bk_classification_id
+	DefinitionClassification SchemaResourceKind = "classification"
+	// DefinitionAssociationKind association kind, identified by bk_asst_id
+	DefinitionAssociationKind SchemaResourceKind = "association_kind"
+	// DefinitionObject model, identified by bk_obj_id
+	DefinitionObject SchemaResourceKind = "object"
+)
+
+// ModelDefinition is the declarative definition of models that the cmdb is converged to, it is usually written
+// in yaml and kept in a code repository. the fields that are not set in the definition keep their current values.
+type ModelDefinition struct {
+	Classifications  []ClassificationDefinition  `json:"classifications"`
+	AssociationKinds []AssociationKindDefinition `json:"association_kinds"`
+	Objects          []ObjectDefinition          `json:"objects"`
+	// Prune deletes the attributes, groups, unique rules and associations of the defined objects that are not in
+	// the definition, the pre-defined ones are never deleted. classifications, association kinds and objects that
+	// are not in the definition are not deleted either, since they are usually shared with other definitions.
+	Prune bool `json:"prune"`
+}
+
+// ClassificationDefinition is the definition of model classification
+type ClassificationDefinition struct {
+	ClassificationID   string `json:"bk_classification_id"`
+	ClassificationName string `json:"bk_classification_name"`
+	ClassificationIcon string `json:"bk_classification_icon"`
+}
+
+// AssociationKindDefinition is the definition of association kind
+type AssociationKindDefinition struct {
+	AssociationKindID       string               `json:"bk_asst_id"`
+	AssociationKindName     string               `json:"bk_asst_name"`
+	SourceToDestinationNote string               `json:"src_des"`
+	DestinationToSourceNote string               `json:"dest_des"`
+	Direction               AssociationDirection `json:"direction"`
+}
+
+// ObjectDefinition is the definition of model and its schema resources, the schema resources use the same fields
+// as their apis, and unique rules are defined by their property ids.
+type ObjectDefinition struct {
+	ObjectID     string          `json:"bk_obj_id"`
+	ObjectName   string          `json:"bk_obj_name"`
+	ObjIcon      string          `json:"bk_obj_icon"`
+	ObjCls       string          `json:"bk_classification_id"`
+	Attributes   []mapstr.MapStr `json:"attributes"`
+	Groups       []mapstr.MapStr `json:"groups"`
+	Uniques      [][]string      `json:"uniques"`
+	Associations []mapstr.MapStr `json:"associations"`
+}
+
+// Validate model definition
+func (d *ModelDefinition) Validate() ccErr.RawErrorInfo {
+	if len(d.Objects) > ModelDefinitionObjectMaxNum {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"objects", ModelDefinitionObjectMaxNum}}
+	}
+
+	clsIDs := make([]string, len(d.Classifications))
+	for idx, cls := range d.Classifications {
+		clsIDs[idx] = cls.ClassificationID
+	}
+	if rawErr := validateDefinitionKeys(common.BKClassificationIDField, clsIDs); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	asstKindIDs := make([]string, len(d.AssociationKinds))
+	for idx, kind := range d.AssociationKinds {
+		asstKindIDs[idx] = kind.AssociationKindID
+	}
+	if rawErr := validateDefinitionKeys(common.AssociationKindIDField, asstKindIDs); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	objIDs := make([]string, len(d.Objects))
+	for idx := range d.Objects {
+		if rawErr := d.Objects[idx].Validate(); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+		objIDs[idx] = d.Objects[idx].ObjectID
+	}
+	return validateDefinitionKeys(common.BKObjIDField, objIDs)
+}
+
+// Validate object definition
+func (d *ObjectDefinition) Validate() ccErr.RawErrorInfo {
+	if len(d.ObjectID) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if rawErr := validateDefinitionKeys(common.BKPropertyIDField,
+		d.ResourceKeys(SchemaAttribute)); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if rawErr := validateDefinitionKeys(common.BKPropertyGroupIDField,
+		d.ResourceKeys(SchemaGroup)); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if rawErr := validateDefinitionKeys("uniques", d.ResourceKeys(SchemaUnique)); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	for _, asst := range d.Associations {
+		for _, field := range []string{AssociationFieldAssociationKind, AssociationFieldAssociationObjectID} {
+			if len(util.GetStrByInterface(asst[field])) == 0 {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{field}}
+			}
+		}
+	}
+	return validateDefinitionKeys(AssociationFieldAsstID, d.ResourceKeys(SchemaAssociation))
+}
+
+// ResourceKeys returns the keys of the defined schema resources of the kind in the definition order,
+// refer to SchemaResourceKind for the key of each kind.
+func (d *ObjectDefinition) ResourceKeys(kind SchemaResourceKind) []string {
+	keys := make([]string, 0)
+	switch kind {
+	case SchemaAttribute:
+		for _, attr := range d.Attributes {
+			keys = append(keys, util.GetStrByInterface(attr[common.BKPropertyIDField]))
+		}
+	case SchemaGroup:
+		for _, group := range d.Groups {
+			keys = append(keys, util.GetStrByInterface(group[common.BKPropertyGroupIDField]))
+		}
+	case SchemaUnique:
+		for _, unique := range d.Uniques {
+			keys = append(keys, SchemaUniqueKey(unique))
+		}
+	case SchemaAssociation:
+		for _, asst := range d.Associations {
+			keys = append(keys, d.AssociationKey(asst))
+		}
+	}
+	return keys
+}
+
+// AssociationKey returns the bk_obj_asst_id of the defined association, it is generated by the object id,
+// association kind id and the associated object id if not set.
+func (d *ObjectDefinition) AssociationKey(asst mapstr.MapStr) string {
+	if key := util.GetStrByInterface(asst[AssociationFieldAsstID]); len(key) > 0 {
+		return key
+	}
+	return fmt.Sprintf("%s_%s_%s", d.ObjectID, util.GetStrByInterface(asst[AssociationFieldAssociationKind]),
+		util.GetStrByInterface(asst[AssociationFieldAssociationObjectID]))
+}
+
+// validateDefinitionKeys validate that the keys of the defined resources are set and not duplicated
+func validateDefinitionKeys(field string, keys []string) ccErr.RawErrorInfo {
+	exists := make(map[string]struct{})
+	for _, key := range keys {
+		if len(key) == 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{field}}
+		}
+
+		if _, ok := exists[key]; ok {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommDuplicateItem, Args: []interface{}{field + " " + key}}
+		}
+		exists[key] = struct{}{}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// ModelDefinitionChange is one change that converges the cmdb to the model definition
+type ModelDefinitionChange struct {
+	// ObjID is the model that the schema resource belongs to, it is empty for classification and association kind
+	ObjID             string `json:"bk_obj_id,omitempty"`
+	ModelSchemaChange `json:",inline"`
+}
+
+// ModelDefinitionPlan is the changes that converge the cmdb to the model definition in the order of execution
+type ModelDefinitionPlan struct {
+	Changes []ModelDefinitionChange `json:"changes"`
+}
+
+// ModelDefinitionPlanResp plan or apply model definition response
+type ModelDefinitionPlanResp struct {
+	BaseResp `json:",inline"`
+	Data     ModelDefinitionPlan `json:"data"`
+}
@@ -0,0 +1,260 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+// ModelSchemaChangeMaxNum is the maximum number of changes in one model schema draft
+const ModelSchemaChangeMaxNum = 200
+
+// SchemaResourceKind is the kind of model schema resource that a draft change applies to
+type SchemaResourceKind string
+
+const (
+	// SchemaAttribute model attribute, identified by bk_property_id
+	SchemaAttribute SchemaResourceKind = "attribute"
+	// SchemaGroup model attribute group, identified by bk_group_id
+	SchemaGroup SchemaResourceKind = "group"
+	// SchemaUnique model unique rule, identified by its comma separated sorted property ids
+	SchemaUnique SchemaResourceKind = "unique"
+	// SchemaAssociation model association, identified by bk_obj_asst_id
+	SchemaAssociation SchemaResourceKind = "association"
+)
+
+// SchemaChangeOp is the operation of model schema draft change
+type SchemaChangeOp string
+
+const (
+	// SchemaChangeCreate create the schema resource
+	SchemaChangeCreate SchemaChangeOp = "create"
+	// SchemaChangeUpdate update the schema resource
+	SchemaChangeUpdate SchemaChangeOp = "update"
+	// SchemaChangeDelete delete the schema resource
+	SchemaChangeDelete SchemaChangeOp = "delete"
+)
+
+// ModelSchemaChange is one staged change of model schema draft
+type ModelSchemaChange struct {
+	Kind SchemaResourceKind `json:"kind" bson:"kind"`
+	Op   SchemaChangeOp     `json:"op" bson:"op"`
+	// Key identifies the changed resource, refer to SchemaResourceKind for the key of each kind.
+	Key string `json:"key" bson:"key"`
+	// Data is the resource data to create or the fields to update, it is not used when deleting the resource
+	// or changing the unique rule.
+	Data mapstr.MapStr `json:"data,omitempty" bson:"data,omitempty"`
+}
+
+// Validate model schema change
+func (c *ModelSchemaChange) Validate() ccErr.RawErrorInfo {
+	switch c.Kind {
+	case SchemaAttribute, SchemaGroup, SchemaAssociation:
+		switch c.Op {
+		case SchemaChangeCreate, SchemaChangeUpdate:
+			if len(c.Data) == 0 {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"data"}}
+			}
+		case SchemaChangeDelete:
+		default:
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"op"}}
+		}
+	case SchemaUnique:
+		if c.Op != SchemaChangeCreate && c.Op != SchemaChangeDelete {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"op"}}
+		}
+		c.Key = SchemaUniqueKey(strings.Split(c.Key, ","))
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"kind"}}
+	}
+
+	if len(c.Key) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"key"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// SchemaUniqueKey returns the key of the unique rule that consists of the property ids
+func SchemaUniqueKey(propertyIDs []string) string {
+	keys := make([]string, 0, len(propertyIDs))
+	for _, propertyID := range propertyIDs {
+		propertyID = strings.TrimSpace(propertyID)
+		if len(propertyID) == 0 {
+			continue
+		}
+		keys = append(keys, propertyID)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// ModelSchemaDraft is the workspace of model schema changes that are staged but not published yet,
+// each model has at most one draft.
+type ModelSchemaDraft struct {
+	ID         int64               `json:"id" bson:"id"`
+	ObjID      string              `json:"bk_obj_id" bson:"bk_obj_id"`
+	Changes    []ModelSchemaChange `json:"changes" bson:"changes"`
+	OwnerID    string              `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string              `json:"creator" bson:"creator"`
+	Modifier   string              `json:"modifier" bson:"modifier"`
+	CreateTime *Time               `json:"create_time" bson:"create_time"`
+	LastTime   *Time               `json:"last_time" bson:"last_time"`
+}
+
+// Validate model schema draft
+func (d *ModelSchemaDraft) Validate() ccErr.RawErrorInfo {
+	if len(d.ObjID) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if len(d.Changes) > ModelSchemaChangeMaxNum {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"changes", ModelSchemaChangeMaxNum}}
+	}
+
+	exists := make(map[string]struct{})
+	for idx := range d.Changes {
+		if rawErr := d.Changes[idx].Validate(); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+
+		key := string(d.Changes[idx].Kind) + ":" + d.Changes[idx].Key
+		if _, ok := exists[key]; ok {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommDuplicateItem, Args: []interface{}{key}}
+		}
+		exists[key] = struct{}{}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ModelSchema is the definition of model that is versioned
+type ModelSchema struct {
+	Attributes []Attribute `json:"attributes" bson:"attributes"`
+	Groups     []Group     `json:"groups" bson:"groups"`
+	// Uniques are the keys of the model unique rules, refer to SchemaUniqueKey.
+	Uniques      []string      `json:"uniques" bson:"uniques"`
+	Associations []Association `json:"associations" bson:"associations"`
+}
+
+// ModelSchemaVersion is the published model schema, version starts from 1 and increases on each publish.
+type ModelSchemaVersion struct {
+	ID          int64       `json:"id" bson:"id"`
+	ObjID       string      `json:"bk_obj_id" bson:"bk_obj_id"`
+	Version     int64       `json:"version" bson:"version"`
+	Description string      `json:"description" bson:"description"`
+	Schema      ModelSchema `json:"schema" bson:"schema"`
+	OwnerID     string      `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string      `json:"creator" bson:"creator"`
+	CreateTime  *Time       `json:"create_time" bson:"create_time"`
+}
+
+// ModelSchemaDraftInfo model schema draft info for list apis
+type ModelSchemaDraftInfo struct {
+	Count uint64             `json:"count"`
+	Info  []ModelSchemaDraft `json:"info"`
+}
+
+// ModelSchemaVersionInfo model schema version info for list apis
+type ModelSchemaVersionInfo struct {
+	Count uint64               `json:"count"`
+	Info  []ModelSchemaVersion `json:"info"`
+}
+
+// ListModelSchemaDraftResp list model schema draft response
+type ListModelSchemaDraftResp struct {
+	BaseResp `json:",inline"`
+	Data     ModelSchemaDraftInfo `json:"data"`
+}
+
+// ListModelSchemaVersionResp list model schema version response
+type ListModelSchemaVersionResp struct {
+	BaseResp `json:",inline"`
+	Data     ModelSchemaVersionInfo `json:"data"`
+}
+
+// CreateModelSchemaVersionResp create model schema version response
+type CreateModelSchemaVersionResp struct {
+	BaseResp `json:",inline"`
+	Data     ModelSchemaVersion `json:"data"`
+}
+
+// SchemaIssueReason is the reason why the model schema draft conflicts with the existing instances
+type SchemaIssueReason string
+
+const (
+	// SchemaIssueRequiredValueMissing the instances have no value of the attribute that becomes required
+	SchemaIssueRequiredValueMissing SchemaIssueReason = "required_value_missing"
+	// SchemaIssueUniqueValueDuplicated the instances have duplicated values of the new unique rule
+	SchemaIssueUniqueValueDuplicated SchemaIssueReason = "unique_value_duplicated"
+)
+
+// ModelSchemaIssue is the problem found when checking model schema draft against the existing instances
+type ModelSchemaIssue struct {
+	Kind   SchemaResourceKind `json:"kind"`
+	Key    string             `json:"key"`
+	Reason SchemaIssueReason  `json:"reason"`
+	// Count is the number of the conflicting instances, or the number of duplicated value groups for unique rule.
+	Count uint64 `json:"count"`
+}
+
+// ModelSchemaCheckResult model schema draft check result
+type ModelSchemaCheckResult struct {
+	Issues []ModelSchemaIssue `json:"issues"`
+}
+
+// CheckModelSchemaDraftResp check model schema draft response
+type CheckModelSchemaDraftResp struct {
+	BaseResp `json:",inline"`
+	Data     ModelSchemaCheckResult `json:"data"`
+}
+
+// PublishModelSchemaOption publish model schema draft option
+type PublishModelSchemaOption struct {
+	Description string `json:"description"`
+}
+
+// DiffModelSchemaOption diff model schema option, version 0 means the current model schema
+type DiffModelSchemaOption struct {
+	BaseVersion   int64 `json:"base_version"`
+	TargetVersion int64 `json:"target_version"`
+}
+
+// Validate diff model schema option
+func (o *DiffModelSchemaOption) Validate() ccErr.RawErrorInfo {
+	if o.BaseVersion < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"base_version"}}
+	}
+
+	if o.TargetVersion < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"target_version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ModelSchemaDiffResult the changes that changes the base model schema to the target model schema
+type ModelSchemaDiffResult struct {
+	Changes []ModelSchemaChange `json:"changes"`
+}
@@ -173,6 +173,9 @@ type ServiceTemplateDiffOption struct {
 	BizID             int64 `json:"bk_biz_id"`
 	ServiceTemplateID int64 `json:"service_template_id"`
 	ModuleID          int64 `json:"bk_module_id"`
+	// Version is the service template version to compare the module with, the current service template is used if
+	// it is not set
+	Version int64 `json:"version"`
 }
 
 // ServiceTemplateOptionValidate judge the validity of parameters.
@@ -583,6 +586,18 @@ func (o *UpdateProcessByIDsInput) Validate() (rawError cErr.RawErrorInfo) {
 	return cErr.RawErrorInfo{}
 }
 
+// SyncServiceInstanceByTemplateResult sync service instance by service template result, it contains the sync task
+// created for each module
+type SyncServiceInstanceByTemplateResult struct {
+	Tasks []ServiceTemplateSyncTask `json:"tasks"`
+}
+
+// ServiceTemplateSyncTask is the service template sync task created for a module
+type ServiceTemplateSyncTask struct {
+	ModuleID int64  `json:"bk_module_id"`
+	TaskID   string `json:"task_id"`
+}
+
 // SyncServiceInstanceByTemplateOption sync service instance by service template option
 type SyncServiceInstanceByTemplateOption struct {
 	BizID             int64   `json:"bk_biz_id"`
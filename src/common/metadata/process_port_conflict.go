@@ -0,0 +1,272 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// ProcessPortConflictMaxHosts the max number of hosts that can be checked for port conflicts at a time
+const ProcessPortConflictMaxHosts = 500
+
+// ListProcessPortConflictOption list the process port conflicts of the hosts in the business
+type ListProcessPortConflictOption struct {
+	BizID int64 `json:"bk_biz_id"`
+	// HostIDs are the hosts to check, all hosts that have processes in the business are checked if it is not set
+	HostIDs []int64 `json:"bk_host_ids"`
+}
+
+// Validate list process port conflict option
+func (o *ListProcessPortConflictOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID <= 0 {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	if len(o.HostIDs) > ProcessPortConflictMaxHosts {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{common.BKHostIDField, ProcessPortConflictMaxHosts},
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ProcessPortConflictItem is one process bind info that conflicts with the others
+type ProcessPortConflictItem struct {
+	ProcessID         int64  `json:"bk_process_id"`
+	ProcessName       string `json:"bk_process_name"`
+	ServiceInstanceID int64  `json:"service_instance_id"`
+	IP                string `json:"ip"`
+	Port              string `json:"port"`
+	Protocol          string `json:"protocol"`
+}
+
+// ProcessPortConflict is a group of process bind infos on the same host that listen on the same port, the port is
+// the overlapped part of their port ranges
+type ProcessPortConflict struct {
+	HostID    int64                     `json:"bk_host_id"`
+	Transport string                    `json:"transport"`
+	Port      string                    `json:"port"`
+	Processes []ProcessPortConflictItem `json:"processes"`
+}
+
+// ListProcessPortConflictResult list process port conflicts result
+type ListProcessPortConflictResult struct {
+	Count int64                 `json:"count"`
+	Info  []ProcessPortConflict `json:"info"`
+}
+
+// processBindEndpoint is an enabled bind info of a process parsed for conflict detection
+type processBindEndpoint struct {
+	item      ProcessPortConflictItem
+	transport string
+	ip        net.IP
+	ports     []propertyPortItem
+}
+
+// newProcessBindEndpoints parse the enabled bind infos of the process, invalid bind infos are skipped since they can
+// not be listened on
+func newProcessBindEndpoints(process *Process) []processBindEndpoint {
+	endpoints := make([]processBindEndpoint, 0)
+	for _, bindInfo := range process.BindInfo {
+		std := bindInfo.Std
+		if std == nil || std.IP == nil || std.Port == nil || std.Protocol == nil {
+			continue
+		}
+
+		if std.Enable != nil && !*std.Enable {
+			continue
+		}
+
+		transport := bindTransport(ProtocolType(*std.Protocol))
+		ip := net.ParseIP(strings.TrimSpace(*std.IP))
+		ports, err := parseBindPorts(*std.Port)
+		if transport == "" || ip == nil || err != nil {
+			continue
+		}
+
+		item := ProcessPortConflictItem{
+			ProcessID:         process.ProcessID,
+			ServiceInstanceID: process.ServiceInstanceID,
+			IP:                *std.IP,
+			Port:              *std.Port,
+			Protocol:          ProtocolType(*std.Protocol).String(),
+		}
+		if process.ProcessName != nil {
+			item.ProcessName = *process.ProcessName
+		}
+
+		endpoints = append(endpoints, processBindEndpoint{item: item, transport: transport, ip: ip, ports: ports})
+	}
+	return endpoints
+}
+
+// bindTransport returns the transport layer protocol of the bind info, tcp and tcp6 share the same port space
+func bindTransport(protocol ProtocolType) string {
+	switch protocol {
+	case ProtocolTypeTCP, ProtocolTypeTCP6:
+		return "tcp"
+	case ProtocolTypeUDP, ProtocolTypeUDP6:
+		return "udp"
+	}
+	return ""
+}
+
+// parseBindPorts parse the port value like "80,8000-8010" into port ranges
+func parseBindPorts(port string) ([]propertyPortItem, error) {
+	portValue := PropertyPortValue(port)
+	if err := portValue.Validate(); err != nil {
+		return nil, err
+	}
+
+	items := make([]propertyPortItem, 0)
+	for _, portItem := range strings.Split(port, ",") {
+		portArr := strings.Split(portItem, "-")
+		start, _ := strconv.ParseInt(portArr[0], 10, 64)
+		end := start
+		if len(portArr) > 1 {
+			end, _ = strconv.ParseInt(portArr[1], 10, 64)
+		}
+		items = append(items, propertyPortItem{start: start, end: end})
+	}
+	return items, nil
+}
+
+// bindIPOverlap check if the two bind ips can not be listened on at the same time, the ipv4 wildcard address
+// 0.0.0.0 overlaps with all the ipv4 addresses, the ipv6 wildcard address :: is dual stack and overlaps with all
+func bindIPOverlap(a, b net.IP) bool {
+	if a.Equal(b) {
+		return true
+	}
+
+	if a.Equal(net.IPv6unspecified) || b.Equal(net.IPv6unspecified) {
+		return true
+	}
+
+	isIPv4 := func(ip net.IP) bool { return ip.To4() != nil }
+	if a.Equal(net.IPv4zero) {
+		return isIPv4(b)
+	}
+	if b.Equal(net.IPv4zero) {
+		return isIPv4(a)
+	}
+	return false
+}
+
+// overlapPorts returns the overlapped port ranges of the two port range lists
+func overlapPorts(a, b []propertyPortItem) []propertyPortItem {
+	overlap := make([]propertyPortItem, 0)
+	for _, x := range a {
+		for _, y := range b {
+			start, end := x.start, x.end
+			if y.start > start {
+				start = y.start
+			}
+			if y.end < end {
+				end = y.end
+			}
+			if start <= end {
+				overlap = append(overlap, propertyPortItem{start: start, end: end})
+			}
+		}
+	}
+	return overlap
+}
+
+// formatPorts format the port ranges into the port value like "80,8000-8010"
+func formatPorts(ports []propertyPortItem) string {
+	sort.Slice(ports, func(i, j int) bool { return ports[i].start < ports[j].start })
+	items := make([]string, 0)
+	for _, port := range ports {
+		if port.start == port.end {
+			items = append(items, strconv.FormatInt(port.start, 10))
+			continue
+		}
+		items = append(items, fmt.Sprintf("%d-%d", port.start, port.end))
+	}
+	return strings.Join(items, ",")
+}
+
+// FindProcessPortConflicts find the bind info conflicts between the processes on the host, a conflict happens when
+// two enabled bind infos of different processes use the same transport protocol, overlapped ips and ports
+func FindProcessPortConflicts(hostID int64, processes []Process) []ProcessPortConflict {
+	endpoints := make([]processBindEndpoint, 0)
+	for idx := range processes {
+		endpoints = append(endpoints, newProcessBindEndpoints(&processes[idx])...)
+	}
+
+	conflicts := make([]ProcessPortConflict, 0)
+	for i := 0; i < len(endpoints); i++ {
+		for j := i + 1; j < len(endpoints); j++ {
+			a, b := endpoints[i], endpoints[j]
+			if a.item.ProcessID == b.item.ProcessID || a.transport != b.transport || !bindIPOverlap(a.ip, b.ip) {
+				continue
+			}
+
+			ports := overlapPorts(a.ports, b.ports)
+			if len(ports) == 0 {
+				continue
+			}
+
+			conflicts = append(conflicts, ProcessPortConflict{
+				HostID:    hostID,
+				Transport: a.transport,
+				Port:      formatPorts(ports),
+				Processes: []ProcessPortConflictItem{a.item, b.item},
+			})
+		}
+	}
+	return conflicts
+}
+
+// Involves check if the conflict involves any of the processes
+func (c *ProcessPortConflict) Involves(processIDs map[int64]struct{}) bool {
+	for _, process := range c.Processes {
+		if _, exists := processIDs[process.ProcessID]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the unique key of the conflict, it is used to compare the conflicts before and after the processes are
+// changed, a conflict between the same processes on the same port is regarded as the same one
+func (c *ProcessPortConflict) Key() string {
+	processIDs := make([]string, len(c.Processes))
+	for idx, process := range c.Processes {
+		processIDs[idx] = strconv.FormatInt(process.ProcessID, 10)
+	}
+	sort.Strings(processIDs)
+	return fmt.Sprintf("%d:%s:%s:%s", c.HostID, c.Transport, c.Port, strings.Join(processIDs, ","))
+}
+
+// String returns the brief description of the conflict
+func (c *ProcessPortConflict) String() string {
+	items := make([]string, len(c.Processes))
+	for idx, process := range c.Processes {
+		items[idx] = fmt.Sprintf("%s(%d) %s", process.ProcessName, process.ProcessID,
+			net.JoinHostPort(process.IP, process.Port))
+	}
+	return fmt.Sprintf("host %d %s port %s: %s", c.HostID, c.Transport, c.Port, strings.Join(items, " vs "))
+}
@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseBindPorts(t *testing.T) {
+	tests := []struct {
+		port    string
+		ports   []propertyPortItem
+		invalid bool
+	}{
+		{port: "80", ports: []propertyPortItem{{start: 80, end: 80}}},
+		{port: "8000-8010", ports: []propertyPortItem{{start: 8000, end: 8010}}},
+		{port: "80,8000-8010", ports: []propertyPortItem{{start: 80, end: 80}, {start: 8000, end: 8010}}},
+		{port: "", invalid: true},
+		{port: "abc", invalid: true},
+		{port: "8010-8000", invalid: true},
+		{port: "70000", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.port, func(t *testing.T) {
+			ports, err := parseBindPorts(tt.port)
+			if (err != nil) != tt.invalid {
+				t.Fatalf("parseBindPorts() error = %v, want invalid %v", err, tt.invalid)
+			}
+			if !tt.invalid && !reflect.DeepEqual(ports, tt.ports) {
+				t.Errorf("parseBindPorts() = %v, want %v", ports, tt.ports)
+			}
+		})
+	}
+}
+
+func TestBindIPOverlap(t *testing.T) {
+	tests := []struct {
+		a       string
+		b       string
+		overlap bool
+	}{
+		{a: "127.0.0.1", b: "127.0.0.1", overlap: true},
+		{a: "127.0.0.1", b: "192.168.1.1", overlap: false},
+		// ipv4 wildcard overlaps with all the ipv4 addresses but not the ipv6 ones
+		{a: "0.0.0.0", b: "192.168.1.1", overlap: true},
+		{a: "192.168.1.1", b: "0.0.0.0", overlap: true},
+		{a: "0.0.0.0", b: "::1", overlap: false},
+		{a: "fe80::1", b: "0.0.0.0", overlap: false},
+		// ipv6 wildcard is dual stack and overlaps with all
+		{a: "::", b: "192.168.1.1", overlap: true},
+		{a: "::1", b: "::", overlap: true},
+		{a: "::", b: "0.0.0.0", overlap: true},
+		// ipv4 and ipv6 addresses
+		{a: "127.0.0.1", b: "::1", overlap: false},
+		{a: "fe80::1", b: "fe80::2", overlap: false},
+		{a: "fe80::1", b: "fe80:0::1", overlap: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" and "+tt.b, func(t *testing.T) {
+			if overlap := bindIPOverlap(net.ParseIP(tt.a), net.ParseIP(tt.b)); overlap != tt.overlap {
+				t.Errorf("bindIPOverlap() = %v, want %v", overlap, tt.overlap)
+			}
+		})
+	}
+}
+
+func TestOverlapPorts(t *testing.T) {
+	tests := []struct {
+		a       string
+		b       string
+		overlap string
+	}{
+		{a: "80", b: "80", overlap: "80"},
+		{a: "80", b: "81", overlap: ""},
+		{a: "80,8000-8010", b: "8005", overlap: "8005"},
+		{a: "80,8000-8010", b: "8008-8020", overlap: "8008-8010"},
+		{a: "80,8000-8010", b: "70-90,8010-8011", overlap: "80,8010"},
+		{a: "8000-8010", b: "8011-8020", overlap: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" and "+tt.b, func(t *testing.T) {
+			a, err := parseBindPorts(tt.a)
+			if err != nil {
+				t.Fatalf("parse port %s failed, err: %v", tt.a, err)
+			}
+			b, err := parseBindPorts(tt.b)
+			if err != nil {
+				t.Fatalf("parse port %s failed, err: %v", tt.b, err)
+			}
+
+			if overlap := formatPorts(overlapPorts(a, b)); overlap != tt.overlap {
+				t.Errorf("overlapPorts() = %s, want %s", overlap, tt.overlap)
+			}
+		})
+	}
+}
+
+func TestFindProcessPortConflicts(t *testing.T) {
+	// bind is a bind info of the process, the processes are built from the binds in order
+	type bind struct {
+		processID int64
+		ip        string
+		port      string
+		protocol  ProtocolType
+		disabled  bool
+	}
+
+	tests := []struct {
+		name      string
+		binds     []bind
+		conflicts []string
+	}{
+		{
+			name: "same ip and port",
+			binds: []bind{
+				{processID: 1, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP},
+			},
+			conflicts: []string{"1:tcp:80:1,2"},
+		},
+		{
+			name: "ipv4 wildcard with port range",
+			binds: []bind{
+				{processID: 1, ip: "0.0.0.0", port: "80,8000-8010", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "192.168.1.1", port: "8005-8020", protocol: ProtocolTypeTCP},
+			},
+			conflicts: []string{"1:tcp:8005-8010:1,2"},
+		},
+		{
+			name: "ipv4 wildcard and ipv6 address",
+			binds: []bind{
+				{processID: 1, ip: "0.0.0.0", port: "80", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "::1", port: "80", protocol: ProtocolTypeTCP6},
+			},
+		},
+		{
+			name: "ipv6 wildcard and ipv4 address",
+			binds: []bind{
+				{processID: 1, ip: "::", port: "80", protocol: ProtocolTypeTCP6},
+				{processID: 2, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP},
+			},
+			conflicts: []string{"1:tcp:80:1,2"},
+		},
+		{
+			name: "tcp and tcp6 share the port space",
+			binds: []bind{
+				{processID: 1, ip: "::1", port: "80", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "::1", port: "80", protocol: ProtocolTypeTCP6},
+			},
+			conflicts: []string{"1:tcp:80:1,2"},
+		},
+		{
+			name: "tcp and udp do not conflict",
+			binds: []bind{
+				{processID: 1, ip: "127.0.0.1", port: "53", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "127.0.0.1", port: "53", protocol: ProtocolTypeUDP},
+			},
+		},
+		{
+			name: "udp and udp6 share the port space",
+			binds: []bind{
+				{processID: 1, ip: "::", port: "53", protocol: ProtocolTypeUDP6},
+				{processID: 2, ip: "127.0.0.1", port: "53", protocol: ProtocolTypeUDP},
+			},
+			conflicts: []string{"1:udp:53:1,2"},
+		},
+		{
+			name: "disabled bind info",
+			binds: []bind{
+				{processID: 1, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP, disabled: true},
+				{processID: 2, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP},
+			},
+		},
+		{
+			name: "bind infos of the same process",
+			binds: []bind{
+				{processID: 1, ip: "0.0.0.0", port: "80", protocol: ProtocolTypeTCP},
+				{processID: 1, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP},
+			},
+		},
+		{
+			name: "invalid bind info",
+			binds: []bind{
+				{processID: 1, ip: "127.0.0.1", port: "abc", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "127.0.0.1", port: "80", protocol: ProtocolTypeTCP},
+			},
+		},
+		{
+			name: "multiple conflicts",
+			binds: []bind{
+				{processID: 3, ip: "0.0.0.0", port: "80", protocol: ProtocolTypeTCP},
+				{processID: 1, ip: "127.0.0.1", port: "80-81", protocol: ProtocolTypeTCP},
+				{processID: 2, ip: "192.168.1.1", port: "81", protocol: ProtocolTypeTCP},
+			},
+			conflicts: []string{"1:tcp:80:1,3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processes := make([]Process, 0)
+			for _, b := range tt.binds {
+				ip, port, protocol, enable := b.ip, b.port, string(b.protocol), !b.disabled
+				bindInfo := ProcBindInfo{Std: &stdProcBindInfo{IP: &ip, Port: &port, Protocol: &protocol,
+					Enable: &enable}}
+
+				last := len(processes) - 1
+				if last >= 0 && processes[last].ProcessID == b.processID {
+					processes[last].BindInfo = append(processes[last].BindInfo, bindInfo)
+					continue
+				}
+				processes = append(processes, Process{ProcessID: b.processID, BindInfo: []ProcBindInfo{bindInfo}})
+			}
+
+			conflicts := FindProcessPortConflicts(1, processes)
+			keys := make([]string, len(conflicts))
+			for idx := range conflicts {
+				keys[idx] = conflicts[idx].Key()
+			}
+
+			if len(keys) != len(tt.conflicts) || (len(keys) > 0 && !reflect.DeepEqual(keys, tt.conflicts)) {
+				t.Errorf("FindProcessPortConflicts() = %v, want %v", keys, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/util"
+)
+
+const (
+	// SetTemplateSyncCheckInterval the interval that the sets of the set template are checked for drift
+	SetTemplateSyncCheckInterval = 5 * time.Minute
+	// SetTemplateSyncMaxExcludedSets the max number of sets that can be excluded by a sync policy
+	SetTemplateSyncMaxExcludedSets = 1000
+	// SetTemplateSyncMaxNotifiers the max number of users that are notified of the drift
+	SetTemplateSyncMaxNotifiers = 20
+	// setTemplateSyncWindowLayout the layout of the start and end time of the scheduled sync window
+	setTemplateSyncWindowLayout = "15:04"
+)
+
+// SetTemplateSyncMode is the way that the set template changes are synchronized to the sets
+type SetTemplateSyncMode string
+
+const (
+	// SetTemplateSyncManual the sets are only synchronized by the user, the drift is still detected and notified
+	SetTemplateSyncManual SetTemplateSyncMode = "manual"
+	// SetTemplateSyncAutoOnChange the sets are synchronized as soon as the drift is detected
+	SetTemplateSyncAutoOnChange SetTemplateSyncMode = "auto_on_change"
+	// SetTemplateSyncScheduled the sets are synchronized when the drift is detected in the scheduled window
+	SetTemplateSyncScheduled SetTemplateSyncMode = "scheduled"
+)
+
+// Validate set template sync mode
+func (m SetTemplateSyncMode) Validate() bool {
+	switch m {
+	case SetTemplateSyncManual, SetTemplateSyncAutoOnChange, SetTemplateSyncScheduled:
+		return true
+	}
+	return false
+}
+
+// SetTemplateSyncWindow is the daily time window that the scheduled sync policy synchronizes the sets in, the time
+// is the local time of the task server, the window crosses midnight if the end time is before the start time
+type SetTemplateSyncWindow struct {
+	// Weekdays are the days of the week that the window is open, 0 is Sunday, the window is open every day if
+	// it is not set
+	Weekdays []int `json:"weekdays" bson:"weekdays"`
+	// StartTime and EndTime are in the "HH:MM" format
+	StartTime string `json:"start_time" bson:"start_time"`
+	EndTime   string `json:"end_time" bson:"end_time"`
+}
+
+// Validate set template sync window
+func (w *SetTemplateSyncWindow) Validate() ccErr.RawErrorInfo {
+	for _, weekday := range w.Weekdays {
+		if weekday < int(time.Sunday) || weekday > int(time.Saturday) {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"window.weekdays"}}
+		}
+	}
+
+	start, err := time.Parse(setTemplateSyncWindowLayout, w.StartTime)
+	if err != nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"window.start_time"}}
+	}
+
+	end, err := time.Parse(setTemplateSyncWindowLayout, w.EndTime)
+	if err != nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"window.end_time"}}
+	}
+
+	if start.Equal(end) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"window.end_time"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// Contains check if the time is in the window, the weekday of a window that crosses midnight is the day it opens
+func (w *SetTemplateSyncWindow) Contains(t time.Time) bool {
+	start, err := time.Parse(setTemplateSyncWindowLayout, w.StartTime)
+	if err != nil {
+		return false
+	}
+
+	end, err := time.Parse(setTemplateSyncWindowLayout, w.EndTime)
+	if err != nil {
+		return false
+	}
+
+	startMin, endMin := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	nowMin := t.Hour()*60 + t.Minute()
+	weekday := int(t.Weekday())
+
+	switch {
+	case startMin < endMin:
+		if nowMin < startMin || nowMin >= endMin {
+			return false
+		}
+	case nowMin >= startMin:
+	case nowMin < endMin:
+		// the window is opened on the day before and crosses midnight
+		weekday = (weekday + 6) % 7
+	default:
+		return false
+	}
+
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+
+	for _, day := range w.Weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTemplateSyncPolicy defines how the changes of the set template are synchronized to its sets by the task server,
+// the sets in the exclusion list are never synchronized automatically and are not reported as drifted
+type SetTemplateSyncPolicy struct {
+	ID            int64                  `json:"id" bson:"id"`
+	BizID         int64                  `json:"bk_biz_id" bson:"bk_biz_id"`
+	SetTemplateID int64                  `json:"set_template_id" bson:"set_template_id"`
+	Mode          SetTemplateSyncMode    `json:"mode" bson:"mode"`
+	Window        *SetTemplateSyncWindow `json:"window,omitempty" bson:"window,omitempty"`
+	// ExcludedSetIDs are the sets that must not be touched by the automatic synchronization
+	ExcludedSetIDs []int64 `json:"excluded_set_ids" bson:"excluded_set_ids"`
+	// NotifyDrift defines whether the creator and the notifiers are notified when sets drift from the set template
+	NotifyDrift bool     `json:"notify_drift" bson:"notify_drift"`
+	Notifiers   []string `json:"notifiers" bson:"notifiers"`
+	// OutOfSyncSetIDs are the sets that are not excluded and drift from the set template in the last check
+	OutOfSyncSetIDs []int64 `json:"out_of_sync_set_ids" bson:"out_of_sync_set_ids"`
+	LastCheckTime   *Time   `json:"last_check_time,omitempty" bson:"last_check_time,omitempty"`
+	LastSyncTime    *Time   `json:"last_sync_time,omitempty" bson:"last_sync_time,omitempty"`
+	// Message is the reason that the last check or synchronization failed
+	Message    string `json:"message" bson:"message"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string `json:"creator" bson:"creator"`
+	Modifier   string `json:"modifier" bson:"modifier"`
+	CreateTime Time   `json:"create_time" bson:"create_time"`
+	LastTime   Time   `json:"last_time" bson:"last_time"`
+}
+
+// SetTemplateSyncPolicyOption create or update set template sync policy option
+type SetTemplateSyncPolicyOption struct {
+	SetTemplateID  int64                  `json:"set_template_id"`
+	Mode           SetTemplateSyncMode    `json:"mode"`
+	Window         *SetTemplateSyncWindow `json:"window"`
+	ExcludedSetIDs []int64                `json:"excluded_set_ids"`
+	NotifyDrift    bool                   `json:"notify_drift"`
+	Notifiers      []string               `json:"notifiers"`
+}
+
+// Validate create or update set template sync policy option
+func (o *SetTemplateSyncPolicyOption) Validate() ccErr.RawErrorInfo {
+	if o.SetTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKSetTemplateIDField}}
+	}
+
+	if !o.Mode.Validate() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"mode"}}
+	}
+
+	if o.Mode == SetTemplateSyncScheduled {
+		if o.Window == nil {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"window"}}
+		}
+		if rawErr := o.Window.Validate(); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	} else {
+		o.Window = nil
+	}
+
+	o.ExcludedSetIDs = util.IntArrayUnique(o.ExcludedSetIDs)
+	if len(o.ExcludedSetIDs) > SetTemplateSyncMaxExcludedSets {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"excluded_set_ids", SetTemplateSyncMaxExcludedSets}}
+	}
+
+	o.Notifiers = util.StrArrayUnique(o.Notifiers)
+	if len(o.Notifiers) > SetTemplateSyncMaxNotifiers {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"notifiers", SetTemplateSyncMaxNotifiers}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// SearchSetTemplateSyncPolicyOption search set template sync policies option
+type SearchSetTemplateSyncPolicyOption struct {
+	BizID          int64                 `json:"bk_biz_id"`
+	IDs            []int64               `json:"ids"`
+	SetTemplateIDs []int64               `json:"set_template_ids"`
+	Modes          []SetTemplateSyncMode `json:"modes"`
+	Page           BasePage              `json:"page"`
+}
+
+// Validate search set template sync policies option
+func (o *SearchSetTemplateSyncPolicyOption) Validate() ccErr.RawErrorInfo {
+	if len(o.IDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"ids", common.BKMaxLimitSize}}
+	}
+
+	if len(o.SetTemplateIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"set_template_ids", common.BKMaxLimitSize}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchSetTemplateSyncPolicyResult search set template sync policies result
+type SearchSetTemplateSyncPolicyResult struct {
+	Count uint64                  `json:"count"`
+	Info  []SetTemplateSyncPolicy `json:"info"`
+}
+
+// SetTemplateSyncSummaryItem is the synchronization status of the sets of a set template
+type SetTemplateSyncSummaryItem struct {
+	SetTemplateID int64 `json:"set_template_id"`
+	// Mode is the mode of the sync policy of the set template, it is manual if the set template has no sync policy
+	Mode            SetTemplateSyncMode `json:"mode"`
+	SetCount        int                 `json:"set_count"`
+	OutOfSyncSetIDs []int64             `json:"out_of_sync_set_ids"`
+	// ExcludedSetIDs are the excluded sets that drift from the set template
+	ExcludedSetIDs []int64 `json:"excluded_set_ids"`
+}
+
+// SetTemplateSyncSummary is the summary report of the sets that are out of sync with their set templates in the
+// business
+type SetTemplateSyncSummary struct {
+	BizID            int64                        `json:"bk_biz_id"`
+	SetTemplateCount int                          `json:"set_template_count"`
+	SetCount         int                          `json:"set_count"`
+	OutOfSyncCount   int                          `json:"out_of_sync_count"`
+	ExcludedCount    int                          `json:"excluded_count"`
+	Templates        []SetTemplateSyncSummaryItem `json:"set_templates"`
+}
@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/util"
+)
+
+const (
+	// SyncRolloutDefaultConcurrency the default number of modules that are synchronized at once in each batch
+	SyncRolloutDefaultConcurrency = 10
+	// SyncRolloutMaxConcurrency the max number of modules that are synchronized at once in each batch
+	SyncRolloutMaxConcurrency = 100
+	// SyncRolloutMaxModules the max number of modules in a sync rollout
+	SyncRolloutMaxModules = 1000
+	// SyncRolloutMaxPauseDuration the max seconds that the rollout pauses after the canary modules, 7 days
+	SyncRolloutMaxPauseDuration = 7 * 24 * 60 * 60
+)
+
+// SyncRolloutStatus is the status of the service template sync rollout
+type SyncRolloutStatus string
+
+const (
+	// SyncRolloutRunning the modules of the current batch are being synchronized
+	SyncRolloutRunning SyncRolloutStatus = "running"
+	// SyncRolloutPaused the rollout waits for the confirmation or the pause window after the canary modules, or
+	// stops because some modules failed to synchronize
+	SyncRolloutPaused SyncRolloutStatus = "paused"
+	// SyncRolloutAborted the rollout is aborted, the modules that are not synchronized yet are skipped
+	SyncRolloutAborted SyncRolloutStatus = "aborted"
+	// SyncRolloutFinished all the modules are synchronized
+	SyncRolloutFinished SyncRolloutStatus = "finished"
+)
+
+// SyncRolloutUnfinishedStatus the status of the rollouts that may still synchronize modules
+var SyncRolloutUnfinishedStatus = []SyncRolloutStatus{SyncRolloutRunning, SyncRolloutPaused}
+
+// SyncRolloutModuleStatus is the synchronization status of a module in the rollout
+type SyncRolloutModuleStatus string
+
+const (
+	// SyncRolloutModuleWaiting the module is waiting for its batch
+	SyncRolloutModuleWaiting SyncRolloutModuleStatus = "waiting"
+	// SyncRolloutModuleSyncing the sync task of the module is created and not finished yet
+	SyncRolloutModuleSyncing SyncRolloutModuleStatus = "syncing"
+	// SyncRolloutModuleSuccess the module is synchronized
+	SyncRolloutModuleSuccess SyncRolloutModuleStatus = "success"
+	// SyncRolloutModuleFailed the module failed to synchronize
+	SyncRolloutModuleFailed SyncRolloutModuleStatus = "failed"
+	// SyncRolloutModuleSkipped the module is not synchronized because the rollout is aborted
+	SyncRolloutModuleSkipped SyncRolloutModuleStatus = "skipped"
+)
+
+// SyncRolloutModule is the synchronization result of a module in the rollout
+type SyncRolloutModule struct {
+	ModuleID int64 `json:"bk_module_id" bson:"bk_module_id"`
+	// Canary defines that the module is synchronized in the canary batch
+	Canary bool                    `json:"canary" bson:"canary"`
+	Status SyncRolloutModuleStatus `json:"status" bson:"status"`
+	// TaskID is the service instance sync task of the module
+	TaskID  string `json:"task_id" bson:"task_id"`
+	Message string `json:"message" bson:"message"`
+}
+
+// ServiceTemplateSyncRollout synchronizes the service template to the canary modules first, pauses for confirmation
+// or a time window, then synchronizes the other modules in batches
+type ServiceTemplateSyncRollout struct {
+	ID                int64 `json:"id" bson:"id"`
+	BizID             int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id" bson:"service_template_id"`
+	// Concurrency is the number of modules that are synchronized at once in each batch after the canary batch
+	Concurrency int `json:"concurrency" bson:"concurrency"`
+	// PauseDuration is the seconds that the rollout pauses after the canary batch succeeded, the rollout waits
+	// for the confirmation if it is not set
+	PauseDuration int64               `json:"pause_duration" bson:"pause_duration"`
+	Modules       []SyncRolloutModule `json:"modules" bson:"modules"`
+	Status        SyncRolloutStatus   `json:"status" bson:"status"`
+	// ResumeTime is the time that the paused rollout continues automatically
+	ResumeTime *Time `json:"resume_time,omitempty" bson:"resume_time,omitempty"`
+	// Message is the reason that the rollout is paused or aborted
+	Message    string `json:"message" bson:"message"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string `json:"creator" bson:"creator"`
+	Modifier   string `json:"modifier" bson:"modifier"`
+	CreateTime Time   `json:"create_time" bson:"create_time"`
+	LastTime   Time   `json:"last_time" bson:"last_time"`
+}
+
+// NextBatch returns the index of the modules to synchronize in the next batch, the canary modules are synchronized
+// in the first batch, returns nil if all the modules are synchronized
+func (r *ServiceTemplateSyncRollout) NextBatch() []int {
+	batch := make([]int, 0)
+	for idx, module := range r.Modules {
+		if module.Canary && module.Status == SyncRolloutModuleWaiting {
+			batch = append(batch, idx)
+		}
+	}
+	if len(batch) > 0 {
+		return batch
+	}
+
+	for idx, module := range r.Modules {
+		if module.Status != SyncRolloutModuleWaiting {
+			continue
+		}
+
+		batch = append(batch, idx)
+		if len(batch) >= r.Concurrency {
+			break
+		}
+	}
+	return batch
+}
+
+// CreateSyncRolloutOption create service template sync rollout option
+type CreateSyncRolloutOption struct {
+	ServiceTemplateID int64 `json:"service_template_id"`
+	// ModuleIDs are all the modules to synchronize, including the canary modules
+	ModuleIDs       []int64 `json:"bk_module_ids"`
+	CanaryModuleIDs []int64 `json:"canary_module_ids"`
+	Concurrency     int     `json:"concurrency"`
+	PauseDuration   int64   `json:"pause_duration"`
+}
+
+// Validate create service template sync rollout option
+func (o *CreateSyncRolloutOption) Validate() ccErr.RawErrorInfo {
+	if o.ServiceTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	o.ModuleIDs = util.IntArrayUnique(append(o.ModuleIDs, o.CanaryModuleIDs...))
+	if len(o.ModuleIDs) > SyncRolloutMaxModules {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"bk_module_ids", SyncRolloutMaxModules}}
+	}
+
+	o.CanaryModuleIDs = util.IntArrayUnique(o.CanaryModuleIDs)
+	if len(o.CanaryModuleIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"canary_module_ids"}}
+	}
+
+	if o.Concurrency == 0 {
+		o.Concurrency = SyncRolloutDefaultConcurrency
+	}
+	if o.Concurrency < 0 || o.Concurrency > SyncRolloutMaxConcurrency {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"concurrency"}}
+	}
+
+	if o.PauseDuration < 0 || o.PauseDuration > SyncRolloutMaxPauseDuration {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"pause_duration"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// SearchSyncRolloutOption search service template sync rollouts option
+type SearchSyncRolloutOption struct {
+	BizID             int64               `json:"bk_biz_id"`
+	IDs               []int64             `json:"ids"`
+	ServiceTemplateID int64               `json:"service_template_id"`
+	Status            []SyncRolloutStatus `json:"status"`
+	Page              BasePage            `json:"page"`
+}
+
+// Validate search service template sync rollouts option
+func (o *SearchSyncRolloutOption) Validate() ccErr.RawErrorInfo {
+	if len(o.IDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"ids", common.BKMaxLimitSize}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// SearchSyncRolloutResult search service template sync rollouts result
+type SearchSyncRolloutResult struct {
+	Count uint64                       `json:"count"`
+	Info  []ServiceTemplateSyncRollout `json:"info"`
+}
@@ -0,0 +1,435 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017,-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"sort"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// TemplateVersionMaxDescriptionLength the max length of the template version description
+const TemplateVersionMaxDescriptionLength = 500
+
+// TemplateVersionType is the type of the template that the version belongs to
+type TemplateVersionType string
+
+const (
+	// ServiceTemplateVersion is the version of the service template
+	ServiceTemplateVersion TemplateVersionType = "service_template"
+	// SetTemplateVersion is the version of the set template
+	SetTemplateVersion TemplateVersionType = "set_template"
+)
+
+// Validate template version type
+func (t TemplateVersionType) Validate() ccErr.RawErrorInfo {
+	switch t {
+	case ServiceTemplateVersion, SetTemplateVersion:
+		return ccErr.RawErrorInfo{}
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"template_type"}}
+	}
+}
+
+// TemplateVersionContent is the content of the template when the version is created
+type TemplateVersionContent struct {
+	Name string `json:"name" bson:"name"`
+
+	// ServiceCategoryID, ProcessTemplates, ServiceTemplateAttrs and the host apply settings are the content of the
+	// service template version
+	ServiceCategoryID    int64                 `json:"service_category_id,omitempty" bson:"service_category_id,omitempty"`
+	ProcessTemplates     []ProcessTemplate     `json:"process_templates,omitempty" bson:"process_templates,omitempty"`
+	ServiceTemplateAttrs []ServiceTemplateAttr `json:"service_template_attrs,omitempty" bson:"service_template_attrs,omitempty"`
+	HostApplyEnabled     bool                  `json:"host_apply_enabled" bson:"host_apply_enabled"`
+	HostApplyRules       []HostApplyRule       `json:"host_apply_rules,omitempty" bson:"host_apply_rules,omitempty"`
+
+	// ServiceTemplateIDs and SetTemplateAttrs are the content of the set template version
+	ServiceTemplateIDs []int64           `json:"service_template_ids,omitempty" bson:"service_template_ids,omitempty"`
+	SetTemplateAttrs   []SetTemplateAttr `json:"set_template_attrs,omitempty" bson:"set_template_attrs,omitempty"`
+}
+
+// TemplateVersionInfo is the immutable snapshot of the service template or the set template
+type TemplateVersionInfo struct {
+	ID           int64               `json:"id" bson:"id"`
+	BizID        int64               `json:"bk_biz_id" bson:"bk_biz_id"`
+	TemplateType TemplateVersionType `json:"template_type" bson:"template_type"`
+	TemplateID   int64               `json:"template_id" bson:"template_id"`
+	// Version is increased from 1 for each template
+	Version     int64                  `json:"version" bson:"version"`
+	Content     TemplateVersionContent `json:"content" bson:"content"`
+	Description string                 `json:"description" bson:"description"`
+	// RollbackFrom is the version that the template is rolled back to when this version is created
+	RollbackFrom int64  `json:"rollback_from,omitempty" bson:"rollback_from,omitempty"`
+	OwnerID      string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator      string `json:"creator" bson:"creator"`
+	CreateTime   Time   `json:"create_time" bson:"create_time"`
+}
+
+// Validate template version before it is created
+func (v *TemplateVersionInfo) Validate() ccErr.RawErrorInfo {
+	if v.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if rawErr := v.TemplateType.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if v.TemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if len(v.Description) > TemplateVersionMaxDescriptionLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{"description", TemplateVersionMaxDescriptionLength}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ListTemplateVersionOption list template versions option
+type ListTemplateVersionOption struct {
+	BizID        int64               `json:"bk_biz_id"`
+	TemplateType TemplateVersionType `json:"template_type"`
+	TemplateID   int64               `json:"template_id"`
+	Versions     []int64             `json:"versions"`
+	Fields       []string            `json:"fields"`
+	Page         BasePage            `json:"page"`
+}
+
+// Validate list template versions option
+func (o *ListTemplateVersionOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if rawErr := o.TemplateType.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if o.TemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if len(o.Versions) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"versions", common.BKMaxLimitSize}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false, common.BKMaxLimitSize)
+}
+
+// ListTemplateVersionResult list template versions result
+type ListTemplateVersionResult struct {
+	Count uint64                `json:"count"`
+	Info  []TemplateVersionInfo `json:"info"`
+}
+
+// TemplateVersionPin records the template version that the module or the set is synchronized to last time
+type TemplateVersionPin struct {
+	BizID        int64               `json:"bk_biz_id" bson:"bk_biz_id"`
+	TemplateType TemplateVersionType `json:"template_type" bson:"template_type"`
+	TemplateID   int64               `json:"template_id" bson:"template_id"`
+	// InstID is the module id for the service template, and the set id for the set template
+	InstID   int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	Version  int64  `json:"version" bson:"version"`
+	OwnerID  string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Modifier string `json:"modifier" bson:"modifier"`
+	LastTime Time   `json:"last_time" bson:"last_time"`
+}
+
+// PinTemplateVersionOption pin the modules or the sets to the template version option
+type PinTemplateVersionOption struct {
+	BizID        int64               `json:"bk_biz_id"`
+	TemplateType TemplateVersionType `json:"template_type"`
+	TemplateID   int64               `json:"template_id"`
+	InstIDs      []int64             `json:"bk_inst_ids"`
+	Version      int64               `json:"version"`
+}
+
+// Validate pin template version option
+func (o *PinTemplateVersionOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if rawErr := o.TemplateType.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if o.TemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if len(o.InstIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_inst_ids"}}
+	}
+
+	if len(o.InstIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"bk_inst_ids", common.BKMaxLimitSize}}
+	}
+
+	if o.Version <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ListTemplateVersionPinOption list the template versions that the modules or the sets are pinned to option
+type ListTemplateVersionPinOption struct {
+	BizID        int64               `json:"bk_biz_id"`
+	TemplateType TemplateVersionType `json:"template_type"`
+	TemplateID   int64               `json:"template_id"`
+	InstIDs      []int64             `json:"bk_inst_ids"`
+}
+
+// Validate list template version pins option
+func (o *ListTemplateVersionPinOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if rawErr := o.TemplateType.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if o.TemplateID == 0 && len(o.InstIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if len(o.InstIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"bk_inst_ids", common.BKMaxLimitSize}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// CreateTemplateVersionOption create the version of the current template content option
+type CreateTemplateVersionOption struct {
+	BizID       int64  `json:"bk_biz_id"`
+	TemplateID  int64  `json:"template_id"`
+	Description string `json:"description"`
+}
+
+// Validate create template version option
+func (o *CreateTemplateVersionOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if o.TemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if len(o.Description) > TemplateVersionMaxDescriptionLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{"description", TemplateVersionMaxDescriptionLength}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// DiffTemplateVersionOption diff two versions of the template option
+type DiffTemplateVersionOption struct {
+	BizID       int64 `json:"bk_biz_id"`
+	TemplateID  int64 `json:"template_id"`
+	FromVersion int64 `json:"from_version"`
+	// ToVersion is the version to compare with, the current content of the template is used if it is not set
+	ToVersion int64 `json:"to_version"`
+}
+
+// Validate diff template versions option
+func (o *DiffTemplateVersionOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if o.TemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if o.FromVersion <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"from_version"}}
+	}
+
+	if o.ToVersion < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"to_version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// RollbackTemplateVersionOption rollback the template content to the version option
+type RollbackTemplateVersionOption struct {
+	BizID      int64 `json:"bk_biz_id"`
+	TemplateID int64 `json:"template_id"`
+	Version    int64 `json:"version"`
+}
+
+// Validate rollback template version option
+func (o *RollbackTemplateVersionOption) Validate() ccErr.RawErrorInfo {
+	if o.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if o.TemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"template_id"}}
+	}
+
+	if o.Version <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ProcessTemplateVersionDiff is the changed process template between two template versions
+type ProcessTemplateVersionDiff struct {
+	ID          int64            `json:"id"`
+	ProcessName string           `json:"bk_process_name"`
+	From        *ProcessProperty `json:"from"`
+	To          *ProcessProperty `json:"to"`
+}
+
+// TemplateAttrVersionDiff is the changed attribute value between two template versions
+type TemplateAttrVersionDiff struct {
+	AttributeID int64       `json:"bk_attribute_id"`
+	From        interface{} `json:"from"`
+	To          interface{} `json:"to"`
+}
+
+// TemplateVersionDiff is the difference between two template versions
+type TemplateVersionDiff struct {
+	FromVersion int64 `json:"from_version"`
+	// ToVersion is 0 if the version is compared with the current content of the template
+	ToVersion int64 `json:"to_version"`
+
+	NameChanged            bool `json:"name_changed"`
+	ServiceCategoryChanged bool `json:"service_category_changed,omitempty"`
+
+	AddedProcesses   []ProcessTemplate            `json:"added_processes,omitempty"`
+	RemovedProcesses []ProcessTemplate            `json:"removed_processes,omitempty"`
+	ChangedProcesses []ProcessTemplateVersionDiff `json:"changed_processes,omitempty"`
+
+	HostApplyEnabledChanged bool                      `json:"host_apply_enabled_changed,omitempty"`
+	HostApplyRules          []TemplateAttrVersionDiff `json:"host_apply_rules,omitempty"`
+
+	AddedServiceTemplateIDs   []int64 `json:"added_service_template_ids,omitempty"`
+	RemovedServiceTemplateIDs []int64 `json:"removed_service_template_ids,omitempty"`
+
+	Attributes []TemplateAttrVersionDiff `json:"attributes,omitempty"`
+}
+
+// DiffTemplateVersionContent diff the content of two template versions, the process templates are compared by the
+// process name, because the process template is re-created with a new id if the template is rolled back
+func DiffTemplateVersionContent(from, to *TemplateVersionContent) *TemplateVersionDiff {
+	diff := &TemplateVersionDiff{
+		NameChanged:             from.Name != to.Name,
+		ServiceCategoryChanged:  from.ServiceCategoryID != to.ServiceCategoryID,
+		HostApplyEnabledChanged: from.HostApplyEnabled != to.HostApplyEnabled,
+	}
+
+	fromProcMap := make(map[string]ProcessTemplate)
+	for _, procTemp := range from.ProcessTemplates {
+		fromProcMap[procTemp.ProcessName] = procTemp
+	}
+	for _, procTemp := range to.ProcessTemplates {
+		fromProcTemp, exists := fromProcMap[procTemp.ProcessName]
+		if !exists {
+			diff.AddedProcesses = append(diff.AddedProcesses, procTemp)
+			continue
+		}
+
+		delete(fromProcMap, procTemp.ProcessName)
+		if !reflect.DeepEqual(fromProcTemp.Property, procTemp.Property) {
+			diff.ChangedProcesses = append(diff.ChangedProcesses, ProcessTemplateVersionDiff{
+				ID:          procTemp.ID,
+				ProcessName: procTemp.ProcessName,
+				From:        fromProcTemp.Property,
+				To:          procTemp.Property,
+			})
+		}
+	}
+	for _, procTemp := range from.ProcessTemplates {
+		if _, exists := fromProcMap[procTemp.ProcessName]; exists {
+			diff.RemovedProcesses = append(diff.RemovedProcesses, procTemp)
+		}
+	}
+
+	diff.Attributes = diffAttrValues(templateAttrValueMap(from), templateAttrValueMap(to))
+
+	fromRuleMap, toRuleMap := make(map[int64]interface{}), make(map[int64]interface{})
+	for _, rule := range from.HostApplyRules {
+		fromRuleMap[rule.AttributeID] = rule.PropertyValue
+	}
+	for _, rule := range to.HostApplyRules {
+		toRuleMap[rule.AttributeID] = rule.PropertyValue
+	}
+	diff.HostApplyRules = diffAttrValues(fromRuleMap, toRuleMap)
+
+	fromSvcTempMap := make(map[int64]struct{})
+	for _, id := range from.ServiceTemplateIDs {
+		fromSvcTempMap[id] = struct{}{}
+	}
+	for _, id := range to.ServiceTemplateIDs {
+		if _, exists := fromSvcTempMap[id]; !exists {
+			diff.AddedServiceTemplateIDs = append(diff.AddedServiceTemplateIDs, id)
+			continue
+		}
+		delete(fromSvcTempMap, id)
+	}
+	for _, id := range from.ServiceTemplateIDs {
+		if _, exists := fromSvcTempMap[id]; exists {
+			diff.RemovedServiceTemplateIDs = append(diff.RemovedServiceTemplateIDs, id)
+		}
+	}
+
+	return diff
+}
+
+// templateAttrValueMap returns the attribute id to value map of the service template or the set template attributes
+func templateAttrValueMap(content *TemplateVersionContent) map[int64]interface{} {
+	attrMap := make(map[int64]interface{})
+	for _, attr := range content.ServiceTemplateAttrs {
+		attrMap[attr.AttributeID] = attr.PropertyValue
+	}
+	for _, attr := range content.SetTemplateAttrs {
+		attrMap[attr.AttributeID] = attr.PropertyValue
+	}
+	return attrMap
+}
+
+func diffAttrValues(from, to map[int64]interface{}) []TemplateAttrVersionDiff {
+	diffs := make([]TemplateAttrVersionDiff, 0)
+	for attrID, toValue := range to {
+		fromValue, exists := from[attrID]
+		if !exists || !reflect.DeepEqual(fromValue, toValue) {
+			diffs = append(diffs, TemplateAttrVersionDiff{AttributeID: attrID, From: fromValue, To: toValue})
+		}
+	}
+	for attrID, fromValue := range from {
+		if _, exists := to[attrID]; !exists {
+			diffs = append(diffs, TemplateAttrVersionDiff{AttributeID: attrID, From: fromValue})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].AttributeID < diffs[j].AttributeID
+	})
+	return diffs
+}
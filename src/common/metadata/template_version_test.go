@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffServiceTemplateVersionContent(t *testing.T) {
+	oldPidFile, newPidFile := "/tmp/old.pid", "/tmp/new.pid"
+	from := &TemplateVersionContent{
+		Name:              "svc",
+		ServiceCategoryID: 1,
+		ProcessTemplates: []ProcessTemplate{
+			{ID: 1, ProcessName: "nginx", Property: &ProcessProperty{PidFile: PropertyString{Value: &oldPidFile}}},
+			{ID: 2, ProcessName: "redis", Property: &ProcessProperty{}},
+			{ID: 3, ProcessName: "mysql", Property: &ProcessProperty{}},
+		},
+		ServiceTemplateAttrs: []ServiceTemplateAttr{{AttributeID: 10, PropertyValue: "a"},
+			{AttributeID: 11, PropertyValue: "b"}},
+		HostApplyRules: []HostApplyRule{{ID: 100, AttributeID: 20, PropertyValue: "x"}},
+	}
+	to := &TemplateVersionContent{
+		Name:              "svc",
+		ServiceCategoryID: 2,
+		// the rolled back process templates are re-created with new ids, they are compared by the process name
+		ProcessTemplates: []ProcessTemplate{
+			{ID: 4, ProcessName: "nginx", Property: &ProcessProperty{PidFile: PropertyString{Value: &newPidFile}}},
+			{ID: 5, ProcessName: "redis", Property: &ProcessProperty{}},
+			{ID: 6, ProcessName: "kafka", Property: &ProcessProperty{}},
+		},
+		ServiceTemplateAttrs: []ServiceTemplateAttr{{AttributeID: 10, PropertyValue: "a"},
+			{AttributeID: 12, PropertyValue: "c"}},
+		HostApplyEnabled: true,
+		HostApplyRules:   []HostApplyRule{{ID: 101, AttributeID: 20, PropertyValue: "y"}},
+	}
+
+	diff := DiffTemplateVersionContent(from, to)
+
+	if diff.NameChanged || !diff.ServiceCategoryChanged || !diff.HostApplyEnabledChanged {
+		t.Errorf("diff name, category, host apply changed = %v, %v, %v, want false, true, true", diff.NameChanged,
+			diff.ServiceCategoryChanged, diff.HostApplyEnabledChanged)
+	}
+	if len(diff.AddedProcesses) != 1 || diff.AddedProcesses[0].ProcessName != "kafka" {
+		t.Errorf("added processes = %+v, want kafka", diff.AddedProcesses)
+	}
+	if len(diff.RemovedProcesses) != 1 || diff.RemovedProcesses[0].ProcessName != "mysql" {
+		t.Errorf("removed processes = %+v, want mysql", diff.RemovedProcesses)
+	}
+	if len(diff.ChangedProcesses) != 1 || diff.ChangedProcesses[0].ID != 4 ||
+		*diff.ChangedProcesses[0].From.PidFile.Value != oldPidFile ||
+		*diff.ChangedProcesses[0].To.PidFile.Value != newPidFile {
+		t.Errorf("changed processes = %+v, want nginx pid file changed", diff.ChangedProcesses)
+	}
+
+	wantAttrs := []TemplateAttrVersionDiff{{AttributeID: 11, From: "b"}, {AttributeID: 12, To: "c"}}
+	if !reflect.DeepEqual(diff.Attributes, wantAttrs) {
+		t.Errorf("attributes diff = %+v, want %+v", diff.Attributes, wantAttrs)
+	}
+	wantRules := []TemplateAttrVersionDiff{{AttributeID: 20, From: "x", To: "y"}}
+	if !reflect.DeepEqual(diff.HostApplyRules, wantRules) {
+		t.Errorf("host apply rules diff = %+v, want %+v", diff.HostApplyRules, wantRules)
+	}
+}
+
+func TestDiffSetTemplateVersionContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        *TemplateVersionContent
+		to          *TemplateVersionContent
+		wantAdded   []int64
+		wantRemoved []int64
+		wantAttrs   []TemplateAttrVersionDiff
+	}{
+		{
+			name:      "same content",
+			from:      &TemplateVersionContent{Name: "set", ServiceTemplateIDs: []int64{1, 2}},
+			to:        &TemplateVersionContent{Name: "set", ServiceTemplateIDs: []int64{2, 1}},
+			wantAttrs: []TemplateAttrVersionDiff{},
+		},
+		{
+			name:        "service templates changed",
+			from:        &TemplateVersionContent{Name: "set", ServiceTemplateIDs: []int64{1, 2}},
+			to:          &TemplateVersionContent{Name: "set", ServiceTemplateIDs: []int64{2, 3, 4}},
+			wantAdded:   []int64{3, 4},
+			wantRemoved: []int64{1},
+			wantAttrs:   []TemplateAttrVersionDiff{},
+		},
+		{
+			name: "attributes changed",
+			from: &TemplateVersionContent{Name: "set",
+				SetTemplateAttrs: []SetTemplateAttr{{AttributeID: 1, PropertyValue: []interface{}{"a"}}}},
+			to: &TemplateVersionContent{Name: "set",
+				SetTemplateAttrs: []SetTemplateAttr{{AttributeID: 1, PropertyValue: []interface{}{"a", "b"}}}},
+			wantAttrs: []TemplateAttrVersionDiff{{AttributeID: 1, From: []interface{}{"a"},
+				To: []interface{}{"a", "b"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffTemplateVersionContent(tt.from, tt.to)
+			if !reflect.DeepEqual(diff.AddedServiceTemplateIDs, tt.wantAdded) ||
+				!reflect.DeepEqual(diff.RemovedServiceTemplateIDs, tt.wantRemoved) {
+				t.Errorf("service templates diff = %v, %v, want %v, %v", diff.AddedServiceTemplateIDs,
+					diff.RemovedServiceTemplateIDs, tt.wantAdded, tt.wantRemoved)
+			}
+			if !reflect.DeepEqual(diff.Attributes, tt.wantAttrs) {
+				t.Errorf("attributes diff = %+v, want %+v", diff.Attributes, tt.wantAttrs)
+			}
+		})
+	}
+}
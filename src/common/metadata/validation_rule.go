@@ -0,0 +1,204 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"configcenter/pkg/formula"
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+const (
+	validationRuleNameMaxLen    = 128
+	validationRuleMessageMaxLen = 256
+	// ValidationRuleMaxNum is the maximum number of validation rules of one model
+	ValidationRuleMaxNum = 50
+)
+
+const (
+	// ValidationRuleConditionField validation rule condition field
+	ValidationRuleConditionField = "condition"
+	// ValidationRuleExpressionField validation rule expression field
+	ValidationRuleExpressionField = "expression"
+	// ValidationRuleMessageField validation rule message field
+	ValidationRuleMessageField = "message"
+)
+
+// ValidationRule is the model level validation rule that validates the instance across its fields, such as
+// "bk_os_version is required if bk_os_type is windows" or "end_date must be later than start_date".
+// condition and expression are formula expressions(refer to pkg/formula) that reference the instance's own fields.
+type ValidationRule struct {
+	ID    int64  `json:"id" bson:"id"`
+	ObjID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	Name  string `json:"name" bson:"name"`
+	// Condition decides if the rule applies to the instance, the rule always applies if it is not set.
+	Condition string `json:"condition" bson:"condition"`
+	// Expression must be evaluated to true for the instance that the rule applies to.
+	Expression string `json:"expression" bson:"expression"`
+	// Message is the error message returned when the instance violates the rule.
+	Message    string `json:"message" bson:"message"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string `json:"creator" bson:"creator"`
+	Modifier   string `json:"modifier" bson:"modifier"`
+	CreateTime *Time  `json:"create_time" bson:"create_time"`
+	LastTime   *Time  `json:"last_time" bson:"last_time"`
+}
+
+// Validate validation rule
+func (r *ValidationRule) Validate() ccErr.RawErrorInfo {
+	if len(r.ObjID) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if len(r.Name) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	if utf8.RuneCountInString(r.Name) > validationRuleNameMaxLen {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKFieldName, validationRuleNameMaxLen}}
+	}
+
+	if len(r.Message) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{
+			ValidationRuleMessageField}}
+	}
+
+	if utf8.RuneCountInString(r.Message) > validationRuleMessageMaxLen {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{ValidationRuleMessageField, validationRuleMessageMaxLen}}
+	}
+
+	if len(r.Expression) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{
+			ValidationRuleExpressionField}}
+	}
+
+	if _, err := r.Fields(); err != nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{err.Error()}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// Fields returns the instance fields that are referenced by the validation rule
+func (r *ValidationRule) Fields() ([]string, error) {
+	parsed, err := r.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := parsed.expression.Fields()
+	if parsed.condition != nil {
+		fields = append(fields, parsed.condition.Fields()...)
+	}
+	return fields, nil
+}
+
+// Parse parses the condition and expression of the validation rule, the parsed rule is used to check the instances
+// so that the rule is parsed only once when it is loaded instead of every time an instance is checked.
+func (r *ValidationRule) Parse() (*ParsedValidationRule, error) {
+	parsed := &ParsedValidationRule{ValidationRule: *r}
+	if len(r.Condition) != 0 {
+		f, err := formula.Parse(r.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("%s is invalid, err: %v", ValidationRuleConditionField, err)
+		}
+		if len(f.RelatedObjects()) > 0 {
+			return nil, fmt.Errorf("%s can not reference related objects", ValidationRuleConditionField)
+		}
+		parsed.condition = f
+	}
+
+	expression, err := formula.Parse(r.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("%s is invalid, err: %v", ValidationRuleExpressionField, err)
+	}
+	if len(expression.RelatedObjects()) > 0 {
+		return nil, fmt.Errorf("%s can not reference related objects", ValidationRuleExpressionField)
+	}
+	parsed.expression = expression
+
+	return parsed, nil
+}
+
+// ParsedValidationRule is the validation rule whose condition and expression are parsed
+type ParsedValidationRule struct {
+	ValidationRule
+	condition  *formula.Formula
+	expression *formula.Formula
+}
+
+// Check checks if the instance data satisfies the validation rule, the rule is satisfied if its condition is
+// not met or its expression is evaluated to true.
+func (r *ParsedValidationRule) Check(data map[string]interface{}) (bool, error) {
+	resolver := formula.MapResolver(data)
+	if r.condition != nil {
+		applied, err := r.condition.Eval(resolver)
+		if err != nil {
+			return false, err
+		}
+		if applied != true {
+			return true, nil
+		}
+	}
+
+	result, err := r.expression.Eval(resolver)
+	if err != nil {
+		return false, err
+	}
+	return result == true, nil
+}
+
+// CheckValidationRules checks the instance data with all the validation rules, returns the rules that the instance
+// violates. a rule that fails to be evaluated is regarded as violated, and its eval error is returned by rule id.
+func CheckValidationRules(rules []*ParsedValidationRule, data map[string]interface{}) ([]*ParsedValidationRule,
+	map[int64]error) {
+
+	violated := make([]*ParsedValidationRule, 0)
+	evalErrs := make(map[int64]error)
+	for _, rule := range rules {
+		ok, err := rule.Check(data)
+		if err != nil {
+			evalErrs[rule.ID] = err
+			violated = append(violated, rule)
+			continue
+		}
+
+		if !ok {
+			violated = append(violated, rule)
+		}
+	}
+
+	return violated, evalErrs
+}
+
+// ValidationRuleInfo validation rule info for list apis
+type ValidationRuleInfo struct {
+	Count uint64           `json:"count"`
+	Info  []ValidationRule `json:"info"`
+}
+
+// ListValidationRuleResp list validation rule response
+type ListValidationRuleResp struct {
+	BaseResp `json:",inline"`
+	Data     ValidationRuleInfo `json:"data"`
+}
@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckValidationRules(t *testing.T) {
+	rules := make([]*ParsedValidationRule, 0)
+	for _, rule := range []ValidationRule{
+		{ID: 1, Name: "os version", Condition: `bk_os_type == "2"`, Expression: `bk_os_version != ""`},
+		{ID: 2, Name: "date", Expression: "end_date > start_date"},
+		{ID: 3, Name: "cpu", Expression: "bk_cpu > 0"},
+	} {
+		parsed, err := rule.Parse()
+		if err != nil {
+			t.Fatalf("parse validation rule %s failed, err: %v", rule.Name, err)
+		}
+		rules = append(rules, parsed)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		violated []int64
+		evalErrs []int64
+	}{
+		{"all rules are satisfied", map[string]interface{}{"bk_os_type": "2", "bk_os_version": "2019",
+			"start_date": start, "end_date": start.AddDate(0, 1, 0), "bk_cpu": 8}, []int64{}, []int64{}},
+		{"condition is not met", map[string]interface{}{"bk_os_type": "1", "bk_os_version": "",
+			"start_date": start, "end_date": start.AddDate(0, 1, 0), "bk_cpu": 8}, []int64{}, []int64{}},
+		{"one rule is violated", map[string]interface{}{"bk_os_type": "2", "bk_os_version": "",
+			"start_date": start, "end_date": start.AddDate(0, 1, 0), "bk_cpu": 8}, []int64{1}, []int64{}},
+		{"all violated rules are returned", map[string]interface{}{"bk_os_type": "2", "bk_os_version": "",
+			"start_date": start, "end_date": start.AddDate(0, -1, 0), "bk_cpu": 0}, []int64{1, 2, 3}, []int64{}},
+		{"rule that fails to be evaluated is violated", map[string]interface{}{"bk_os_type": "1",
+			"start_date": start, "end_date": start.AddDate(0, 1, 0), "bk_cpu": "abc"}, []int64{3}, []int64{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violated, evalErrs := CheckValidationRules(rules, tt.data)
+			violatedIDs := make([]int64, 0)
+			for _, rule := range violated {
+				violatedIDs = append(violatedIDs, rule.ID)
+			}
+			if !reflect.DeepEqual(violatedIDs, tt.violated) {
+				t.Errorf("CheckValidationRules() violated = %v, want %v", violatedIDs, tt.violated)
+			}
+
+			if len(evalErrs) != len(tt.evalErrs) {
+				t.Errorf("CheckValidationRules() eval errors = %v, want rules %v", evalErrs, tt.evalErrs)
+			}
+			for _, id := range tt.evalErrs {
+				if evalErrs[id] == nil {
+					t.Errorf("CheckValidationRules() eval error of rule %d is not returned", id)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationRuleParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ValidationRule
+		fields  []string
+		wantErr bool
+	}{
+		{"expression only", ValidationRule{Expression: "end_date > start_date"},
+			[]string{"end_date", "start_date"}, false},
+		{"condition fields are returned after expression fields", ValidationRule{Condition: `bk_os_type == "2"`,
+			Expression: `bk_os_version != ""`}, []string{"bk_os_version", "bk_os_type"}, false},
+		{"invalid expression", ValidationRule{Expression: "bk_cpu >"}, nil, true},
+		{"invalid condition", ValidationRule{Condition: "(bk_cpu", Expression: "bk_cpu > 0"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := tt.rule.Fields()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Fields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("Fields() = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
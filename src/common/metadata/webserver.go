@@ -19,6 +19,7 @@ import (
 	"configcenter/pkg/filter"
 	"configcenter/src/common"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/querybuilder"
 
 	"github.com/gin-gonic/gin"
@@ -152,6 +153,7 @@ type ExcelAssociation struct {
 	Operate      ExcelAssociationOperate `json:"operate"`
 	SrcPrimary   string                  `json:"src_primary_key"`
 	DstPrimary   string                  `json:"dst_primary_key"`
+	Attributes   mapstr.MapStr           `json:"bk_asst_attrs,omitempty"`
 }
 
 // ObjectAsstIDStatisticsInfo TODO
@@ -109,6 +109,9 @@ const (
 	// BKTableNameWatchToken the table to store the latest watch token for collections
 	BKTableNameWatchToken = "cc_WatchToken"
 
+	// BKTableNameWatchEventArchive the table to store the archived watch events whose chain nodes may be expired
+	BKTableNameWatchEventArchive = "cc_WatchEventArchive"
+
 	// BKTableNameMainlineInstance is a virtual collection name which represent for mainline instance events
 	BKTableNameMainlineInstance = "cc_MainlineInstance"
 
@@ -123,6 +126,72 @@ const (
 
 	// BKTableNameObjFieldTemplateRelation  object and field template relationship table
 	BKTableNameObjFieldTemplateRelation = "cc_ObjFieldTemplateRelation"
+
+	// BKTableNameObjValidationRule  object instance validation rule table
+	BKTableNameObjValidationRule = "cc_ObjValidationRule"
+
+	// BKTableNameObjSchemaDraft  object schema draft table
+	BKTableNameObjSchemaDraft = "cc_ObjSchemaDraft"
+
+	// BKTableNameObjSchemaVersion  object schema published version table
+	BKTableNameObjSchemaVersion = "cc_ObjSchemaVersion"
+
+	// BKTableNameEventSubscription  event webhook subscription table
+	BKTableNameEventSubscription = "cc_EventSubscription"
+
+	// BKTableNameEventDeliveryHistory  event webhook subscription delivery history table
+	BKTableNameEventDeliveryHistory = "cc_EventDeliveryHistory"
+
+	// BKTableNameEventDeadLetter  event webhook subscription dead letter table
+	BKTableNameEventDeadLetter = "cc_EventDeadLetter"
+
+	// BKTableNameEventExportCheckpoint  the checkpoint table of the events exported to kafka
+	BKTableNameEventExportCheckpoint = "cc_EventExportCheckpoint"
+
+	// BKTableNameNotificationRule  the notification rule table which sends messages on resource changes
+	BKTableNameNotificationRule = "cc_NotificationRule"
+
+	// BKTableNameNotificationHistory  the sending history table of the notification rules
+	BKTableNameNotificationHistory = "cc_NotificationHistory"
+
+	// BKTableNameApprovalPolicy  the approval policy table which defines the operations that need to be approved
+	BKTableNameApprovalPolicy = "cc_ApprovalPolicy"
+
+	// BKTableNameChangeRequest  the change request table which stores the captured operations waiting for approval
+	BKTableNameChangeRequest = "cc_ChangeRequest"
+
+	// BKTableNameHostApplyScopeRule  the host apply rule table of business, set, set template and dynamic group scope
+	BKTableNameHostApplyScopeRule = "cc_HostApplyScopeRule"
+
+	// BKTableNameAttributePolicy  the attribute policy table which sets the default or enforced instance attribute values
+	BKTableNameAttributePolicy = "cc_AttributePolicy"
+
+	// BKTableNameHostLifecycle  the host lifecycle definition table of each supplier account
+	BKTableNameHostLifecycle = "cc_HostLifecycle"
+
+	// BKTableNameHostLifecycleHistory  the host lifecycle transition history table
+	BKTableNameHostLifecycleHistory = "cc_HostLifecycleHistory"
+
+	// BKTableNameHostLease the host lease table
+	BKTableNameHostLease = "cc_HostLease"
+
+	// BKTableNameHostLeaseHistory the host lease history table
+	BKTableNameHostLeaseHistory = "cc_HostLeaseHistory"
+
+	// BKTableNameSyncRollout the service template staged synchronization rollout table
+	BKTableNameSyncRollout = "cc_ServiceTemplateSyncRollout"
+
+	// BKTableNameTemplateVersion the service template and set template version table
+	BKTableNameTemplateVersion = "cc_TemplateVersion"
+
+	// BKTableNameTemplateVersionPin the table of the template versions that the modules and sets are synchronized to
+	BKTableNameTemplateVersionPin = "cc_TemplateVersionPin"
+
+	// BKTableNameSetTemplateSyncPolicy the set template automatic synchronization policy table
+	BKTableNameSetTemplateSyncPolicy = "cc_SetTemplateSyncPolicy"
+
+	// BKTableNameHostSnapHistory the host snapshot field change and drift history table
+	BKTableNameHostSnapHistory = "cc_HostSnapHistory"
 )
 
 // AllTables is all table names, not include the sharding tables which is created dynamically,
@@ -37,6 +37,14 @@ var delArchiveCollMap = map[string]string{
 	common.BKTableNameBasePlat:                common.BKTableNameDelArchive,
 	common.BKTableNameBaseProject:             common.BKTableNameDelArchive,
 	fullsynccond.BKTableNameFullSyncCond:      common.BKTableNameDelArchive,
+	common.BKTableNameObjDes:                  common.BKTableNameDelArchive,
+	common.BKTableNameObjAttDes:               common.BKTableNameDelArchive,
+	common.BKTableNamePropertyGroup:           common.BKTableNameDelArchive,
+	common.BKTableNameObjUnique:               common.BKTableNameDelArchive,
+	common.BKTableNameObjAsst:                 common.BKTableNameDelArchive,
+	common.BKTableNameServiceTemplate:         common.BKTableNameDelArchive,
+	common.BKTableNameFieldTemplate:           common.BKTableNameDelArchive,
+	common.BKTableNameHostApplyRule:           common.BKTableNameDelArchive,
 
 	common.BKTableNameBaseInst:         common.BKTableNameDelArchive,
 	common.BKTableNameMainlineInstance: common.BKTableNameDelArchive,
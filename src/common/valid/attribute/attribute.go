@@ -57,6 +57,13 @@ func ValidPropertyOption(kit *rest.Kit, propertyType string, option interface{},
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
 		}
 		return ValidIDRuleOption(kit, option, attrTypeMap)
+	case common.FieldTypeComputed:
+		attrTypeMap, ok := extraOpt.(map[string]string)
+		if !ok {
+			blog.Errorf("extra opt(%+v) type %T is invalid, rid: %s", extraOpt, extraOpt, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+		return ValidComputedOption(kit, option, attrTypeMap)
 	}
 
 	return nil
@@ -368,3 +375,27 @@ func ValidIDRuleOption(kit *rest.Kit, val interface{}, attrTypeMap map[string]st
 
 	return nil
 }
+
+// ValidComputedOption validate computed field type's option, the fields referenced by the formula must be the
+// existing non-computed attributes of the model
+func ValidComputedOption(kit *rest.Kit, val interface{}, attrTypeMap map[string]string) error {
+	_, f, err := metadata.ParseComputedFormula(val)
+	if err != nil {
+		blog.Errorf("parse computed option %+v failed, err: %v, rid: %s", val, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	for _, field := range f.Fields() {
+		attrType, exists := attrTypeMap[field]
+		if !exists {
+			blog.Errorf("computed formula field %s is invalid, attribute not exists, rid: %s", field, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+		if metadata.IsComputedAttr(attrType) {
+			blog.Errorf("computed formula can not reference computed field %s, rid: %s", field, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+	}
+
+	return nil
+}
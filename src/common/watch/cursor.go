@@ -79,6 +79,15 @@ var (
 		KubeWorkload:            20,
 		KubePod:                 21,
 		Project:                 22,
+		Model:                   23,
+		ModelAttribute:          24,
+		ModelAttrGroup:          25,
+		ModelUnique:             26,
+		ModelAssociation:        27,
+		ServiceTemplate:         28,
+		SetTemplate:             29,
+		FieldTemplate:           30,
+		HostApplyRule:           31,
 	}
 
 	intCursorTypeMap = make(map[int]CursorType)
@@ -124,6 +133,29 @@ const (
 	Plat CursorType = "plat"
 	// Project project event cursor type
 	Project CursorType = "project"
+
+	// model metadata related cursor types
+	// Model model event cursor type
+	Model CursorType = "model"
+	// ModelAttribute model attribute event cursor type
+	ModelAttribute CursorType = "model_attribute"
+	// ModelAttrGroup model attribute group event cursor type
+	ModelAttrGroup CursorType = "model_attribute_group"
+	// ModelUnique model unique rule event cursor type
+	ModelUnique CursorType = "model_unique"
+	// ModelAssociation model association event cursor type
+	ModelAssociation CursorType = "model_association"
+
+	// template related cursor types
+	// ServiceTemplate service template event cursor type
+	ServiceTemplate CursorType = "service_template"
+	// SetTemplate set template event cursor type
+	SetTemplate CursorType = "set_template"
+	// FieldTemplate field template event cursor type
+	FieldTemplate CursorType = "field_template"
+	// HostApplyRule host apply rule event cursor type
+	HostApplyRule CursorType = "host_apply_rule"
+
 	// kube related cursor types
 	// KubeCluster cursor type
 	KubeCluster CursorType = "kube_cluster"
@@ -162,7 +194,8 @@ func (ct *CursorType) ParseInt(typ int) {
 func ListCursorTypes() []CursorType {
 	return []CursorType{Host, ModuleHostRelation, Biz, Set, Module, ObjectBase, Process, ProcessInstanceRelation,
 		HostIdentifier, MainlineInstance, InstAsst, BizSet, BizSetRelation, Plat, KubeCluster, KubeNode, KubeNamespace,
-		KubeWorkload, KubePod, Project}
+		KubeWorkload, KubePod, Project, Model, ModelAttribute, ModelAttrGroup, ModelUnique, ModelAssociation,
+		ServiceTemplate, SetTemplate, FieldTemplate, HostApplyRule}
 }
 
 // Cursor is a self-defined token which is corresponding to the mongodb's resume token.
@@ -345,6 +378,15 @@ var collEventCursorTypeMap = map[string]CursorType{
 	kubetypes.BKTableNameBaseWorkload:         KubeWorkload,
 	kubetypes.BKTableNameBasePod:              KubePod,
 	common.BKTableNameBaseProject:             Project,
+	common.BKTableNameObjDes:                  Model,
+	common.BKTableNameObjAttDes:               ModelAttribute,
+	common.BKTableNamePropertyGroup:           ModelAttrGroup,
+	common.BKTableNameObjUnique:               ModelUnique,
+	common.BKTableNameObjAsst:                 ModelAssociation,
+	common.BKTableNameServiceTemplate:         ServiceTemplate,
+	common.BKTableNameSetTemplate:             SetTemplate,
+	common.BKTableNameFieldTemplate:           FieldTemplate,
+	common.BKTableNameHostApplyRule:           HostApplyRule,
 }
 
 // GetEventCursor get event cursor.
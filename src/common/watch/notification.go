@@ -0,0 +1,323 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// NotificationRuleIDHeader is the http header that stores the id of the notification rule that is posted to
+	// the webhook, the payload is signed the same way as the subscription payload.
+	NotificationRuleIDHeader = "X-Bkcmdb-Notification-Rule-Id"
+
+	// NotificationRuleNameMaxLength is the max length of the notification rule name
+	NotificationRuleNameMaxLength = 128
+	// NotificationDefaultRateLimit is the default max number of notifications that a rule sends in an hour
+	NotificationDefaultRateLimit = 60
+	// NotificationMaxRateLimit is the max rate limit that can be set
+	NotificationMaxRateLimit = 3600
+	// NotificationMaxDigestInterval is the max seconds that the events can be aggregated into one notification
+	NotificationMaxDigestInterval = 24 * 60 * 60
+	// NotificationMaxRecipients is the max number of the users, roles and instance fields of a rule respectively
+	NotificationMaxRecipients = 100
+)
+
+// NotifierType is the channel that the notification is sent through
+type NotifierType string
+
+const (
+	// EmailNotifier sends the notification by email through the smtp server
+	EmailNotifier NotifierType = "email"
+	// WebhookNotifier posts the notification to the webhook url of the rule
+	WebhookNotifier NotifierType = "webhook"
+	// NoticeNotifier sends the notification to the users through the bk-notice api gateway
+	NoticeNotifier NotifierType = "bk_notice"
+)
+
+// Validate the notifier type
+func (n NotifierType) Validate() error {
+	switch n {
+	case EmailNotifier, WebhookNotifier, NoticeNotifier:
+		return nil
+	default:
+		return fmt.Errorf("unsupported notifier %s", n)
+	}
+}
+
+// NotificationRuleStatus is the status of the notification rule
+type NotificationRuleStatus string
+
+const (
+	// NotificationRuleEnabled the matched events of the rule are notified
+	NotificationRuleEnabled NotificationRuleStatus = "enabled"
+	// NotificationRuleDisabled the rule is not evaluated, and the cursor is kept
+	NotificationRuleDisabled NotificationRuleStatus = "disabled"
+)
+
+// NotificationRoleFields are the role fields of the business whose members can be the recipients
+var NotificationRoleFields = []string{common.BKMaintainersField, common.BKProductPMField, common.BKDeveloperField,
+	common.BKTesterField, common.BKOperatorField}
+
+// NotificationRule evaluates the watch events of a resource, and sends a message to the recipients through the
+// notifiers when the events are matched.
+type NotificationRule struct {
+	ID   int64  `json:"id" bson:"id"`
+	Name string `json:"name" bson:"name"`
+	// Resource is the resource kind that the rule evaluates
+	Resource CursorType `json:"bk_resource" bson:"bk_resource"`
+	// EventTypes is the event types that the rule cares, empty means all
+	EventTypes []EventType `json:"bk_event_types" bson:"bk_event_types"`
+	// Filter is the filter that the events must match, it is the same with the filter of the subscription
+	Filter     SubscriptionFilter     `json:"bk_filter" bson:"bk_filter"`
+	Notifiers  []NotifierType         `json:"notifiers" bson:"notifiers"`
+	Recipients NotificationRecipients `json:"recipients" bson:"recipients"`
+	// WebhookURL is the http endpoint that the notification is posted to by the webhook notifier
+	WebhookURL string `json:"webhook_url" bson:"webhook_url"`
+	// Secret is used to sign the webhook payload with hmac-sha256, it is never returned by the query api
+	Secret string `json:"secret,omitempty" bson:"secret"`
+	// RateLimit is the max number of notifications that the rule sends in an hour, the notifications that exceed
+	// the limit are dropped and counted into the next notification
+	RateLimit int `json:"rate_limit" bson:"rate_limit"`
+	// DigestInterval is the seconds that the matched events are aggregated into one notification, 0 means the
+	// events are notified as soon as they are matched
+	DigestInterval int                    `json:"digest_interval" bson:"digest_interval"`
+	Status         NotificationRuleStatus `json:"status" bson:"status"`
+	// Cursor is the cursor of the last notified event, the rule is evaluated from this cursor
+	Cursor string `json:"bk_cursor" bson:"bk_cursor"`
+	// LastGap is the last time that the cursor is lost and the events in the gap are not evaluated
+	LastGap    *CursorGap `json:"last_gap,omitempty" bson:"last_gap,omitempty"`
+	OwnerID    string     `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string     `json:"creator" bson:"creator"`
+	Modifier   string     `json:"modifier" bson:"modifier"`
+	CreateTime time.Time  `json:"create_time" bson:"create_time"`
+	LastTime   time.Time  `json:"last_time" bson:"last_time"`
+}
+
+// NotificationRecipients are the recipients of the notification, they are resolved for each event
+type NotificationRecipients struct {
+	// Users are the user names or email addresses that receive the notification
+	Users []string `json:"users" bson:"users"`
+	// Roles are the role fields of the business that the event belongs to, such as bk_biz_maintainer, the users
+	// in these fields receive the notification
+	Roles []string `json:"roles" bson:"roles"`
+	// InstanceFields are the user type fields of the event instance, such as bk_host_operator, the users in these
+	// fields receive the notification
+	InstanceFields []string `json:"instance_fields" bson:"instance_fields"`
+}
+
+// IsEmpty returns if no recipient is set
+func (r NotificationRecipients) IsEmpty() bool {
+	return len(r.Users) == 0 && len(r.Roles) == 0 && len(r.InstanceFields) == 0
+}
+
+// Validate the notification recipients
+func (r NotificationRecipients) Validate() error {
+	if len(r.Users) > NotificationMaxRecipients || len(r.Roles) > NotificationMaxRecipients ||
+		len(r.InstanceFields) > NotificationMaxRecipients {
+		return fmt.Errorf("recipients exceed max length %d", NotificationMaxRecipients)
+	}
+
+	for _, user := range r.Users {
+		if len(user) == 0 {
+			return errors.New("recipients.users contains empty user")
+		}
+	}
+
+	for _, role := range r.Roles {
+		supported := false
+		for _, field := range NotificationRoleFields {
+			if role == field {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("recipients.roles has unsupported role %s", role)
+		}
+	}
+
+	for _, field := range r.InstanceFields {
+		if len(field) == 0 {
+			return errors.New("recipients.instance_fields contains empty field")
+		}
+	}
+
+	return nil
+}
+
+// WatchOptions returns the watch event options of the notification rule, all the fields are watched because the
+// recipients and the message are resolved from the event detail.
+func (n *NotificationRule) WatchOptions() *WatchEventOptions {
+	return &WatchEventOptions{
+		EventTypes: n.EventTypes,
+		Cursor:     n.Cursor,
+		Resource:   n.Resource,
+		Filter: WatchEventFilter{
+			SubResource:   n.Filter.SubResource,
+			Filter:        n.Filter.Filter,
+			ChangedFields: n.Filter.ChangedFields,
+		},
+	}
+}
+
+// HasNotifier returns if the notification is sent through the notifier
+func (n *NotificationRule) HasNotifier(typ NotifierType) bool {
+	for _, notifier := range n.Notifiers {
+		if notifier == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate the notification rule, the default values are set if they are not set
+func (n *NotificationRule) Validate() error {
+	if len(n.Name) == 0 {
+		return errors.New("name is not set")
+	}
+
+	if len(n.Name) > NotificationRuleNameMaxLength {
+		return fmt.Errorf("name exceeds max length %d", NotificationRuleNameMaxLength)
+	}
+
+	if len(n.Notifiers) == 0 {
+		return errors.New("notifiers is not set")
+	}
+
+	exists := make(map[NotifierType]struct{})
+	for _, notifier := range n.Notifiers {
+		if err := notifier.Validate(); err != nil {
+			return err
+		}
+		if _, ok := exists[notifier]; ok {
+			return fmt.Errorf("notifier %s is duplicated", notifier)
+		}
+		exists[notifier] = struct{}{}
+	}
+
+	if n.HasNotifier(WebhookNotifier) {
+		if err := validateHTTPURL("webhook_url", n.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	// webhook receiver dispatches the notification by itself, other notifiers need the recipients
+	if (n.HasNotifier(EmailNotifier) || n.HasNotifier(NoticeNotifier)) && n.Recipients.IsEmpty() {
+		return errors.New("recipients is not set")
+	}
+
+	if err := n.Recipients.Validate(); err != nil {
+		return err
+	}
+
+	switch {
+	case n.RateLimit == 0:
+		n.RateLimit = NotificationDefaultRateLimit
+	case n.RateLimit < 0 || n.RateLimit > NotificationMaxRateLimit:
+		return fmt.Errorf("rate_limit must be in range [1, %d]", NotificationMaxRateLimit)
+	}
+
+	if n.DigestInterval < 0 || n.DigestInterval > NotificationMaxDigestInterval {
+		return fmt.Errorf("digest_interval must be in range [0, %d]", NotificationMaxDigestInterval)
+	}
+
+	supported := false
+	for _, typ := range ListCursorTypes() {
+		if typ == n.Resource {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported bk_resource %s", n.Resource)
+	}
+
+	// the events are watched by the server with all fields, so the options are validated as an inner request
+	return n.WatchOptions().Validate(true)
+}
+
+// NotificationRuleUpdateFields are the fields of the notification rule that can be updated
+var NotificationRuleUpdateFields = []string{"name", "bk_event_types", "bk_filter", "notifiers", "recipients",
+	"webhook_url", "secret", "rate_limit", "digest_interval"}
+
+// NotificationPayload is the http request body that is posted to the webhook of the notification rule
+type NotificationPayload struct {
+	RuleID     int64    `json:"rule_id"`
+	RuleName   string   `json:"rule_name"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Recipients []string `json:"recipients"`
+	// Suppressed is the number of notifications that are dropped by the rate limit before this one
+	Suppressed int                 `json:"suppressed"`
+	Resource   CursorType          `json:"bk_resource"`
+	Events     []*WatchEventDetail `json:"bk_events"`
+}
+
+// NotificationStatus is the sending status of a notification
+type NotificationStatus string
+
+const (
+	// NotificationSuccess the notification is sent successfully
+	NotificationSuccess NotificationStatus = "success"
+	// NotificationFailed the notification is failed to send
+	NotificationFailed NotificationStatus = "failed"
+	// NotificationSuppressed the notification is dropped because the rate limit of the rule is exceeded
+	NotificationSuppressed NotificationStatus = "suppressed"
+)
+
+// NotificationHistory is the sending record of a notification of the rule
+type NotificationHistory struct {
+	RuleID     int64              `json:"rule_id" bson:"rule_id"`
+	Notifier   NotifierType       `json:"notifier" bson:"notifier"`
+	Status     NotificationStatus `json:"status" bson:"status"`
+	Recipients []string           `json:"recipients" bson:"recipients"`
+	EventCount int                `json:"event_count" bson:"event_count"`
+	Error      string             `json:"error,omitempty" bson:"error,omitempty"`
+	// FirstCursor and LastCursor are the cursors of the first and last event in the notification
+	FirstCursor string    `json:"first_cursor" bson:"first_cursor"`
+	LastCursor  string    `json:"last_cursor" bson:"last_cursor"`
+	OwnerID     string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime  time.Time `json:"create_time" bson:"create_time"`
+}
+
+// SearchNotificationRuleOption is the option to search notification rules
+type SearchNotificationRuleOption struct {
+	Resource CursorType             `json:"bk_resource"`
+	Status   NotificationRuleStatus `json:"status"`
+	Page     metadata.BasePage      `json:"page"`
+}
+
+// SearchNotificationRuleResult is the result of searching notification rules
+type SearchNotificationRuleResult struct {
+	Count int64              `json:"count"`
+	Info  []NotificationRule `json:"info"`
+}
+
+// SearchNotificationHistoryOption is the option to search the sending history of a notification rule
+type SearchNotificationHistoryOption struct {
+	Notifier NotifierType       `json:"notifier"`
+	Status   NotificationStatus `json:"status"`
+	Page     metadata.BasePage  `json:"page"`
+}
+
+// SearchNotificationHistoryResult is the result of searching notification history
+type SearchNotificationHistoryResult struct {
+	Count int64                 `json:"count"`
+	Info  []NotificationHistory `json:"info"`
+}
@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"testing"
+)
+
+func TestNotificationRuleValidate(t *testing.T) {
+	rule := &NotificationRule{
+		Name:       "biz_deleted",
+		Resource:   Biz,
+		EventTypes: []EventType{Delete},
+		Notifiers:  []NotifierType{EmailNotifier},
+		Recipients: NotificationRecipients{Users: []string{"admin"}},
+	}
+
+	if err := rule.Validate(); err != nil {
+		t.Errorf("validate notification rule failed, err: %v", err)
+		return
+	}
+
+	if rule.RateLimit != NotificationDefaultRateLimit {
+		t.Errorf("default rate limit is not set, rule: %+v", rule)
+		return
+	}
+
+	rule.Recipients = NotificationRecipients{}
+	if err := rule.Validate(); err == nil {
+		t.Errorf("email notification rule without recipients should not be valid")
+		return
+	}
+
+	rule.Notifiers = []NotifierType{WebhookNotifier}
+	if err := rule.Validate(); err == nil {
+		t.Errorf("webhook notification rule without webhook url should not be valid")
+		return
+	}
+
+	rule.WebhookURL = "http://127.0.0.1:8080/echo"
+	if err := rule.Validate(); err != nil {
+		t.Errorf("webhook notification rule without recipients should be valid, err: %v", err)
+		return
+	}
+
+	rule.Recipients = NotificationRecipients{Roles: []string{"bk_inst_name"}}
+	if err := rule.Validate(); err == nil {
+		t.Errorf("notification rule with unsupported role should not be valid")
+		return
+	}
+}
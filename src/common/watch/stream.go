@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// StreamLastEventIDHeader is the http header that the sse client sets to the cursor of the last received event
+	// when it reconnects, the stream is resumed from this cursor.
+	StreamLastEventIDHeader = "Last-Event-ID"
+
+	// DefaultStreamHeartbeatSeconds is the default interval of the heartbeat messages when no event is streamed
+	DefaultStreamHeartbeatSeconds = 15
+	// MinStreamHeartbeatSeconds is the min interval of the heartbeat messages
+	MinStreamHeartbeatSeconds = 5
+	// MaxStreamHeartbeatSeconds is the max interval of the heartbeat messages
+	MaxStreamHeartbeatSeconds = 60
+)
+
+// StreamMessageType is the type of the message in the watch stream
+type StreamMessageType string
+
+const (
+	// StreamEvent is the message that contains a watched event
+	StreamEvent StreamMessageType = "event"
+	// StreamHeartbeat is the message that keeps the connection alive when no event is streamed, it contains the
+	// latest cursor so that the client can resume from it.
+	StreamHeartbeat StreamMessageType = "heartbeat"
+	// StreamError is the message that is sent before the stream is closed because of an error
+	StreamError StreamMessageType = "error"
+)
+
+// StreamMessage is the message in the watch stream, it is the data of a sse message or a websocket text message.
+type StreamMessage struct {
+	Type    StreamMessageType `json:"type"`
+	Cursor  string            `json:"bk_cursor,omitempty"`
+	Event   *WatchEventDetail `json:"bk_event,omitempty"`
+	Code    int               `json:"bk_error_code,omitempty"`
+	Message string            `json:"bk_error_msg,omitempty"`
+}
+
+// StreamOptions is the options of the watch stream
+type StreamOptions struct {
+	WatchEventOptions `json:",inline"`
+	// HeartbeatSeconds is the interval of the heartbeat messages when no event is streamed
+	HeartbeatSeconds int `json:"heartbeat_seconds"`
+}
+
+// Validate the watch stream options, the default values are set if they are not set
+func (s *StreamOptions) Validate() error {
+	switch {
+	case s.HeartbeatSeconds == 0:
+		s.HeartbeatSeconds = DefaultStreamHeartbeatSeconds
+	case s.HeartbeatSeconds < MinStreamHeartbeatSeconds || s.HeartbeatSeconds > MaxStreamHeartbeatSeconds:
+		return fmt.Errorf("heartbeat_seconds must be in range [%d, %d]", MinStreamHeartbeatSeconds,
+			MaxStreamHeartbeatSeconds)
+	}
+
+	if len(s.Resource) == 0 {
+		return errors.New("bk_resource is not set")
+	}
+
+	return s.WatchEventOptions.Validate(false)
+}
@@ -0,0 +1,308 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// SubscriptionSignatureHeader is the http header that stores the hmac signature of the pushed payload
+	SubscriptionSignatureHeader = "X-Bkcmdb-Signature"
+	// SubscriptionTimestampHeader is the http header that stores the unix timestamp of the pushed payload
+	SubscriptionTimestampHeader = "X-Bkcmdb-Timestamp"
+	// SubscriptionIDHeader is the http header that stores the id of the subscription
+	SubscriptionIDHeader = "X-Bkcmdb-Subscription-Id"
+	// SubscriptionDeliveryHeader is the http header that stores the unique id of the delivery, the receiver can use
+	// it to de-duplicate the payload since the payload is delivered at least once
+	SubscriptionDeliveryHeader = "X-Bkcmdb-Delivery-Id"
+
+	// subscriptionSignaturePrefix is the prefix of the signature, which describes the hash algorithm
+	subscriptionSignaturePrefix = "sha256="
+
+	// SubscriptionDefaultTimeout is the default timeout seconds of a delivery request
+	SubscriptionDefaultTimeout = 10
+	// SubscriptionMaxTimeout is the max timeout seconds of a delivery request
+	SubscriptionMaxTimeout = 60
+	// SubscriptionDefaultMaxRetry is the default max retry times of a failed delivery
+	SubscriptionDefaultMaxRetry = 5
+	// SubscriptionMaxRetry is the max retry times of a failed delivery can be set
+	SubscriptionMaxRetry = 10
+	// SubscriptionNameMaxLength is the max length of the subscription name
+	SubscriptionNameMaxLength = 128
+)
+
+// SubscriptionStatus is the status of the subscription
+type SubscriptionStatus string
+
+const (
+	// SubscriptionActive the events of the subscription are pushed to the callback
+	SubscriptionActive SubscriptionStatus = "active"
+	// SubscriptionPaused the events of the subscription are not pushed, and the cursor is kept
+	SubscriptionPaused SubscriptionStatus = "paused"
+)
+
+// Subscription is a server managed watch that pushes the events of a resource to the http callback.
+type Subscription struct {
+	ID   int64  `json:"id" bson:"id"`
+	Name string `json:"name" bson:"name"`
+	// Resource is the resource kind that the subscription watches
+	Resource CursorType `json:"bk_resource" bson:"bk_resource"`
+	// EventTypes is the event types that the subscription cares, empty means all
+	EventTypes []EventType `json:"bk_event_types" bson:"bk_event_types"`
+	// Fields is the fields that the subscription cares, empty means all
+	Fields []string           `json:"bk_fields" bson:"bk_fields"`
+	Filter SubscriptionFilter `json:"bk_filter" bson:"bk_filter"`
+	// CallbackURL is the http endpoint that the events are pushed to
+	CallbackURL string `json:"callback_url" bson:"callback_url"`
+	// Secret is used to sign the pushed payload with hmac-sha256, it is never returned by the query api
+	Secret string `json:"secret,omitempty" bson:"secret"`
+	// Timeout is the timeout seconds of a delivery request
+	Timeout int `json:"timeout" bson:"timeout"`
+	// MaxRetry is the max retry times of a failed delivery, the failed payload is moved to the dead letter store
+	// after all retries are failed
+	MaxRetry int                `json:"max_retry" bson:"max_retry"`
+	Status   SubscriptionStatus `json:"status" bson:"status"`
+	// Cursor is the cursor of the last delivered event, the next delivery starts from this cursor
+	Cursor string `json:"bk_cursor" bson:"bk_cursor"`
+	// StartFrom is the unix seconds to watch from, it is set when the subscription is replayed and is cleared after
+	// the cursor is persisted
+	StartFrom int64 `json:"bk_start_from" bson:"bk_start_from"`
+	// LastGap is the last time that the cursor is lost and the events in the gap are not pushed
+	LastGap    *CursorGap `json:"last_gap,omitempty" bson:"last_gap,omitempty"`
+	OwnerID    string     `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string     `json:"creator" bson:"creator"`
+	Modifier   string     `json:"modifier" bson:"modifier"`
+	CreateTime time.Time  `json:"create_time" bson:"create_time"`
+	LastTime   time.Time  `json:"last_time" bson:"last_time"`
+}
+
+// SubscriptionFilter is the watch event filter of the subscription
+type SubscriptionFilter struct {
+	// SubResource the sub resource you want to watch, eg. object ID of the instance resource, watch all if not set
+	SubResource string `json:"bk_sub_resource,omitempty" bson:"bk_sub_resource,omitempty"`
+	// Filter is the expression that the event detail must match, same as the filter of the watch event options
+	Filter *filter.Expression `json:"filter,omitempty" bson:"filter,omitempty"`
+	// ChangedFields the update event must change at least one of these fields to be pushed
+	ChangedFields []string `json:"changed_fields,omitempty" bson:"changed_fields,omitempty"`
+}
+
+// WatchOptions returns the watch event options of the subscription
+func (s *Subscription) WatchOptions() *WatchEventOptions {
+	opts := &WatchEventOptions{
+		EventTypes: s.EventTypes,
+		Fields:     s.Fields,
+		Cursor:     s.Cursor,
+		Resource:   s.Resource,
+		Filter: WatchEventFilter{
+			SubResource:   s.Filter.SubResource,
+			Filter:        s.Filter.Filter,
+			ChangedFields: s.Filter.ChangedFields,
+		},
+	}
+
+	if len(s.Cursor) == 0 {
+		opts.StartFrom = s.StartFrom
+	}
+	return opts
+}
+
+// Validate the subscription, the default values are set if they are not set
+func (s *Subscription) Validate() error {
+	if len(s.Name) == 0 {
+		return errors.New("name is not set")
+	}
+
+	if len(s.Name) > SubscriptionNameMaxLength {
+		return fmt.Errorf("name exceeds max length %d", SubscriptionNameMaxLength)
+	}
+
+	if err := validateHTTPURL("callback_url", s.CallbackURL); err != nil {
+		return err
+	}
+
+	switch {
+	case s.Timeout == 0:
+		s.Timeout = SubscriptionDefaultTimeout
+	case s.Timeout < 0 || s.Timeout > SubscriptionMaxTimeout:
+		return fmt.Errorf("timeout must be in range [1, %d]", SubscriptionMaxTimeout)
+	}
+
+	switch {
+	case s.MaxRetry == 0:
+		s.MaxRetry = SubscriptionDefaultMaxRetry
+	case s.MaxRetry < 0 || s.MaxRetry > SubscriptionMaxRetry:
+		return fmt.Errorf("max_retry must be in range [1, %d]", SubscriptionMaxRetry)
+	}
+
+	supported := false
+	for _, typ := range ListCursorTypes() {
+		if typ == s.Resource {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported bk_resource %s", s.Resource)
+	}
+
+	// subscription is used by the users, so the options are validated as an outer watch request
+	return s.WatchOptions().Validate(false)
+}
+
+// validateHTTPURL validates the url field is an http or https url
+func validateHTTPURL(field, rawURL string) error {
+	if len(rawURL) == 0 {
+		return fmt.Errorf("%s is not set", field)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%s is invalid, err: %v", field, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("%s must be an http or https url", field)
+	}
+	return nil
+}
+
+// SubscriptionUpdateFields are the fields of the subscription that can be updated
+var SubscriptionUpdateFields = []string{"name", "bk_event_types", "bk_fields", "bk_filter", "callback_url",
+	"secret", "timeout", "max_retry"}
+
+// SubscriptionPayload is the http request body that is pushed to the callback of the subscription
+type SubscriptionPayload struct {
+	SubscriptionID int64               `json:"subscription_id"`
+	DeliveryID     string              `json:"delivery_id"`
+	Resource       CursorType          `json:"bk_resource"`
+	Events         []*WatchEventDetail `json:"bk_events"`
+}
+
+// SignSubscriptionPayload returns the signature of the payload, it's the hex encoded hmac-sha256 of the
+// "{timestamp}.{payload}" with the subscription secret.
+func SignSubscriptionPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return subscriptionSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySubscriptionPayload checks if the signature matches the payload, it's used by the callback receiver.
+func VerifySubscriptionPayload(secret string, timestamp int64, payload []byte, signature string) bool {
+	expected := SignSubscriptionPayload(secret, timestamp, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// DeliveryStatus is the status of a delivery
+type DeliveryStatus string
+
+const (
+	// DeliverySuccess the payload is delivered successfully
+	DeliverySuccess DeliveryStatus = "success"
+	// DeliveryFailed the payload is failed to deliver after all retries, it is moved to the dead letter store
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// DeliveryHistory is the delivery record of a payload of the subscription
+type DeliveryHistory struct {
+	SubscriptionID int64          `json:"subscription_id" bson:"subscription_id"`
+	DeliveryID     string         `json:"delivery_id" bson:"delivery_id"`
+	Status         DeliveryStatus `json:"status" bson:"status"`
+	// Attempts is the times that the payload has been pushed
+	Attempts int `json:"attempts" bson:"attempts"`
+	// StatusCode is the http status code of the last attempt, 0 means the request is not responded
+	StatusCode int    `json:"status_code" bson:"status_code"`
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	EventCount int    `json:"event_count" bson:"event_count"`
+	// FirstCursor and LastCursor are the cursors of the first and last event in the payload
+	FirstCursor string    `json:"first_cursor" bson:"first_cursor"`
+	LastCursor  string    `json:"last_cursor" bson:"last_cursor"`
+	Duration    int64     `json:"duration_ms" bson:"duration_ms"`
+	OwnerID     string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime  time.Time `json:"create_time" bson:"create_time"`
+}
+
+// DeadLetter is a payload that is failed to deliver after all retries.
+type DeadLetter struct {
+	ID             int64  `json:"id" bson:"id"`
+	SubscriptionID int64  `json:"subscription_id" bson:"subscription_id"`
+	DeliveryID     string `json:"delivery_id" bson:"delivery_id"`
+	// Payload is the json encoded SubscriptionPayload
+	Payload    string    `json:"payload" bson:"payload"`
+	Attempts   int       `json:"attempts" bson:"attempts"`
+	StatusCode int       `json:"status_code" bson:"status_code"`
+	Error      string    `json:"error" bson:"error"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+}
+
+// ReplaySubscriptionOption is the option to replay the events of the subscription from a time
+type ReplaySubscriptionOption struct {
+	// StartFrom unix seconds time to where the events are replayed from
+	StartFrom int64 `json:"bk_start_from"`
+}
+
+// Validate the replay option
+func (r *ReplaySubscriptionOption) Validate() error {
+	if r.StartFrom <= 0 {
+		return errors.New("bk_start_from is not set")
+	}
+
+	if r.StartFrom > time.Now().Unix() {
+		return errors.New("bk_start_from can not be a future time")
+	}
+	return nil
+}
+
+// SearchSubscriptionOption is the option to search subscriptions
+type SearchSubscriptionOption struct {
+	Resource CursorType         `json:"bk_resource"`
+	Status   SubscriptionStatus `json:"status"`
+	Page     metadata.BasePage  `json:"page"`
+}
+
+// SearchSubscriptionResult is the result of searching subscriptions
+type SearchSubscriptionResult struct {
+	Count int64          `json:"count"`
+	Info  []Subscription `json:"info"`
+}
+
+// SearchDeliveryOption is the option to search the delivery history or dead letters of a subscription
+type SearchDeliveryOption struct {
+	Status DeliveryStatus    `json:"status"`
+	Page   metadata.BasePage `json:"page"`
+}
+
+// SearchDeliveryHistoryResult is the result of searching delivery history
+type SearchDeliveryHistoryResult struct {
+	Count int64             `json:"count"`
+	Info  []DeliveryHistory `json:"info"`
+}
+
+// SearchDeadLetterResult is the result of searching dead letters
+type SearchDeadLetterResult struct {
+	Count int64        `json:"count"`
+	Info  []DeadLetter `json:"info"`
+}
@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"testing"
+)
+
+func TestSignSubscriptionPayload(t *testing.T) {
+	payload := []byte(`{"subscription_id":1,"bk_events":[]}`)
+	signature := SignSubscriptionPayload("secret", 1588853652, payload)
+
+	if !VerifySubscriptionPayload("secret", 1588853652, payload, signature) {
+		t.Errorf("verify signature %s failed", signature)
+		return
+	}
+
+	if VerifySubscriptionPayload("other", 1588853652, payload, signature) {
+		t.Errorf("signature %s should not be verified by another secret", signature)
+		return
+	}
+
+	if VerifySubscriptionPayload("secret", 1588853653, payload, signature) {
+		t.Errorf("signature %s should not be verified with another timestamp", signature)
+		return
+	}
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	sub := &Subscription{
+		Name:        "host_change",
+		Resource:    Host,
+		Fields:      []string{"bk_host_id"},
+		CallbackURL: "http://127.0.0.1:8080/echo",
+	}
+
+	if err := sub.Validate(); err != nil {
+		t.Errorf("validate subscription failed, err: %v", err)
+		return
+	}
+
+	if sub.Timeout != SubscriptionDefaultTimeout || sub.MaxRetry != SubscriptionDefaultMaxRetry {
+		t.Errorf("default timeout and max retry are not set, subscription: %+v", sub)
+		return
+	}
+
+	sub.CallbackURL = "tcp://127.0.0.1:8080"
+	if err := sub.Validate(); err == nil {
+		t.Errorf("subscription with invalid callback url should not be valid")
+		return
+	}
+
+	sub.CallbackURL = "http://127.0.0.1:8080/echo"
+	sub.Fields = nil
+	if err := sub.Validate(); err == nil {
+		t.Errorf("host subscription without fields should not be valid")
+		return
+	}
+}
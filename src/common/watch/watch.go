@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 
+	"configcenter/pkg/filter"
 	"configcenter/src/common/metadata"
 )
 
@@ -43,6 +44,65 @@ type WatchEventFilter struct {
 	SubResource string `json:"bk_sub_resource,omitempty"`
 	// SubResources is the sub resources you want to watch, NOTE: this is a special parameter for internal use only
 	SubResources []string `json:"-"`
+	// Filter is the expression that the event detail must match, it is evaluated against the whole event detail
+	// regardless of the Fields. the event detail only stores the current image, so an update event whose current
+	// image is not matched is still returned if it changes any of the filtered fields, since its previous image may
+	// be matched, e.g. the host is moved out of the filtered business.
+	Filter *filter.Expression `json:"filter,omitempty"`
+	// ChangedFields the update event is returned only if one of these fields is updated or removed, create and
+	// delete events are not affected by it.
+	ChangedFields []string `json:"changed_fields,omitempty"`
+}
+
+// NeedDetailFilter returns if the events need to be filtered by their details
+func (w *WatchEventFilter) NeedDetailFilter() bool {
+	return w.Filter != nil || len(w.ChangedFields) > 0
+}
+
+// DetailFields returns the fields of the detail that are needed to filter the events and to return to the user,
+// returns nil if all fields are needed.
+func (w *WatchEventFilter) DetailFields(fields []string) []string {
+	if len(fields) == 0 || w.Filter == nil {
+		return fields
+	}
+
+	all := make([]string, 0, len(fields))
+	exists := make(map[string]struct{})
+	for _, fieldArr := range [][]string{fields, w.Filter.RuleFields()} {
+		for _, field := range fieldArr {
+			if _, ok := exists[field]; ok {
+				continue
+			}
+			exists[field] = struct{}{}
+			all = append(all, field)
+		}
+	}
+	return all
+}
+
+// MaxWatchChangedFields is the max number of the changed fields filter
+const MaxWatchChangedFields = 50
+
+func (w *WatchEventFilter) validate() error {
+	if w.Filter != nil {
+		opt := filter.NewDefaultExprOpt(nil)
+		opt.IgnoreRuleFields = true
+		if err := w.Filter.Validate(opt); err != nil {
+			return fmt.Errorf("bk_filter.filter is invalid, err: %v", err)
+		}
+	}
+
+	if len(w.ChangedFields) > MaxWatchChangedFields {
+		return fmt.Errorf("bk_filter.changed_fields exceeds max length %d", MaxWatchChangedFields)
+	}
+
+	for _, field := range w.ChangedFields {
+		if len(field) == 0 {
+			return errors.New("bk_filter.changed_fields contains empty field")
+		}
+	}
+
+	return nil
 }
 
 // Validate watch event options
@@ -77,7 +137,7 @@ func (w *WatchEventOptions) Validate(isInner bool) error {
 		}
 	}
 
-	return nil
+	return w.Filter.validate()
 }
 
 // WatchEventResp watch event response
@@ -93,6 +153,17 @@ type WatchResp struct {
 	Events  []*WatchEventDetail `json:"bk_events"`
 }
 
+// CursorGap records that a server side watch lost its cursor in both the event chain and the event archive, the
+// events between the time of the lost cursor and the time that the watch is resumed from are not watched.
+type CursorGap struct {
+	// LostCursor is the cursor that is lost
+	LostCursor string `json:"lost_cursor" bson:"lost_cursor"`
+	// From is the unix seconds time of the lost cursor, 0 means the cursor can not be decoded
+	From int64 `json:"from" bson:"from"`
+	// To is the unix seconds time that the watch is resumed from
+	To int64 `json:"to" bson:"to"`
+}
+
 // WatchEventDetail TODO
 type WatchEventDetail struct {
 	Cursor    string     `json:"bk_cursor"`
@@ -0,0 +1,68 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/pkg/filter"
+)
+
+func TestWatchEventFilterDetailFields(t *testing.T) {
+	opt := &WatchEventFilter{
+		Filter: &filter.Expression{
+			RuleFactory: &filter.CombinedRule{
+				Condition: filter.And,
+				Rules: []filter.RuleFactory{
+					&filter.AtomRule{Field: "bk_biz_id", Operator: filter.Equal.Factory(), Value: 3},
+					&filter.AtomRule{Field: "bk_host_innerip", Operator: filter.NotEqual.Factory(), Value: ""},
+				},
+			},
+		},
+	}
+
+	if !opt.NeedDetailFilter() {
+		t.Errorf("filter with expression should need detail filter")
+		return
+	}
+
+	fields := []string{"bk_host_id", "bk_host_innerip"}
+	detailFields := opt.DetailFields(fields)
+	expected := []string{"bk_host_id", "bk_host_innerip", "bk_biz_id"}
+	if !reflect.DeepEqual(detailFields, expected) {
+		t.Errorf("detail fields %v is not as expected %v", detailFields, expected)
+		return
+	}
+
+	if !reflect.DeepEqual(fields, []string{"bk_host_id", "bk_host_innerip"}) {
+		t.Errorf("watched fields %v should not be changed", fields)
+		return
+	}
+
+	if opt.DetailFields(nil) != nil {
+		t.Errorf("all fields should be watched if no watched fields are set")
+		return
+	}
+
+	if err := opt.validate(); err != nil {
+		t.Errorf("validate filter failed, err: %v", err)
+		return
+	}
+
+	opt.ChangedFields = []string{""}
+	if err := opt.validate(); err == nil {
+		t.Errorf("empty changed field should be invalid")
+		return
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+)
+
+// ListFunc lists the ids of the items that need a worker with their last update time
+type ListFunc func(ctx context.Context, rid string) (map[int64]time.Time, error)
+
+// RunFunc runs the worker of the item until the context is canceled
+type RunFunc func(ctx context.Context, id int64)
+
+// Pool runs a worker for each listed item on the master, the worker of an item is restarted when the item is changed
+// and is stopped when the item is not listed any more.
+type Pool struct {
+	name     string
+	isMaster discovery.ServiceManageInterface
+	interval time.Duration
+	list     ListFunc
+	run      RunFunc
+
+	lock    sync.Mutex
+	workers map[int64]*worker
+}
+
+// worker is a running worker of an item
+type worker struct {
+	// lastTime is the last update time of the item when the worker is started
+	lastTime time.Time
+	cancel   context.CancelFunc
+}
+
+// NewPool new worker pool, name is the name of the items that is used in the logs, the items are listed and the
+// workers are synced with them every interval.
+func NewPool(name string, isMaster discovery.ServiceManageInterface, interval time.Duration, list ListFunc,
+	run RunFunc) *Pool {
+
+	return &Pool{
+		name:     name,
+		isMaster: isMaster,
+		interval: interval,
+		list:     list,
+		run:      run,
+		workers:  make(map[int64]*worker),
+	}
+}
+
+// Run loops to keep the workers consistent with the listed items until the context is canceled, only master runs
+// the workers.
+func (p *Pool) Run(ctx context.Context) {
+	blog.Infof("start to run %s workers", p.name)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if !p.isMaster.IsMaster() {
+			blog.V(4).Infof("loop run %s workers, but not master, skip.", p.name)
+			p.StopAll()
+		} else {
+			p.sync(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			p.StopAll()
+			blog.Infof("%s workers are stopped", p.name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync starts the workers of new or changed items and stops the workers of the items that are not listed.
+func (p *Pool) sync(ctx context.Context) {
+	rid := util.GenerateRID()
+
+	items, err := p.list(ctx, rid)
+	if err != nil {
+		blog.Errorf("list %s failed, err: %v, rid: %s", p.name, err, rid)
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id, lastTime := range items {
+		w, exists := p.workers[id]
+		if exists && w.lastTime.Equal(lastTime) {
+			continue
+		}
+
+		if exists {
+			blog.Infof("%s %d is changed, restart its worker, rid: %s", p.name, id, rid)
+			w.cancel()
+		}
+
+		workerCtx, cancel := context.WithCancel(ctx)
+		p.workers[id] = &worker{lastTime: lastTime, cancel: cancel}
+		go p.run(workerCtx, id)
+	}
+
+	for id, w := range p.workers {
+		if _, exists := items[id]; exists {
+			continue
+		}
+		blog.Infof("%s %d is disabled or deleted, stop its worker, rid: %s", p.name, id, rid)
+		w.cancel()
+		delete(p.workers, id)
+	}
+}
+
+// StopAll stops all the running workers
+func (p *Pool) StopAll() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id, w := range p.workers {
+		w.cancel()
+		delete(p.workers, id)
+	}
+}
+
+// Sleep sleeps for the duration or until the context is canceled
+func Sleep(ctx context.Context, duration time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+}
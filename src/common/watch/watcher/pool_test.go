@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeMaster bool
+
+func (f fakeMaster) IsMaster() bool {
+	return bool(f)
+}
+
+func TestPoolSync(t *testing.T) {
+	items := make(map[int64]time.Time)
+	var listErr error
+	list := func(context.Context, string) (map[int64]time.Time, error) {
+		return items, listErr
+	}
+
+	started := make(chan int64, 10)
+	stopped := make(chan int64, 10)
+	run := func(ctx context.Context, id int64) {
+		started <- id
+		<-ctx.Done()
+		stopped <- id
+	}
+
+	expect := func(ch chan int64, want int64) {
+		t.Helper()
+		select {
+		case id := <-ch:
+			if id != want {
+				t.Fatalf("got worker %d, want %d", id, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("worker %d is not started or stopped", want)
+		}
+	}
+	expectNone := func(ch chan int64) {
+		t.Helper()
+		select {
+		case id := <-ch:
+			t.Fatalf("worker %d is unexpectedly started or stopped", id)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	pool := NewPool("test item", fakeMaster(true), time.Hour, list, run)
+	ctx := context.Background()
+	now := time.Now()
+
+	// new item starts a worker
+	items[1] = now
+	pool.sync(ctx)
+	expect(started, 1)
+
+	// unchanged item keeps the worker
+	pool.sync(ctx)
+	expectNone(started)
+	expectNone(stopped)
+
+	// changed item restarts the worker
+	items[1] = now.Add(time.Second)
+	pool.sync(ctx)
+	expect(stopped, 1)
+	expect(started, 1)
+
+	// list failure keeps the workers
+	listErr = errors.New("list failed")
+	pool.sync(ctx)
+	expectNone(stopped)
+	listErr = nil
+
+	// removed item stops the worker
+	delete(items, 1)
+	items[2] = now
+	pool.sync(ctx)
+	expect(started, 2)
+	expect(stopped, 1)
+
+	pool.StopAll()
+	expect(stopped, 2)
+	if len(pool.workers) != 0 {
+		t.Errorf("workers %v are not removed after stop all", pool.workers)
+	}
+}
+
+func TestPoolRunNotMaster(t *testing.T) {
+	listed := false
+	list := func(context.Context, string) (map[int64]time.Time, error) {
+		listed = true
+		return map[int64]time.Time{1: time.Now()}, nil
+	}
+	run := func(ctx context.Context, id int64) {
+		t.Errorf("worker %d is started on a non master", id)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewPool("test item", fakeMaster(false), time.Hour, list, run).Run(ctx)
+
+	if listed {
+		t.Errorf("items are listed on a non master")
+	}
+}
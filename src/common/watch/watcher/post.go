@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common/watch"
+)
+
+// MaxErrorLength is the max length of the error message that is recorded
+const MaxErrorLength = 1024
+
+// PostSigned posts the json body to the url with the extra headers, the body is signed with the secret if it is set
+// so that the receiver can verify it by watch.VerifySubscriptionPayload. a response with 2xx status code means the
+// body is received, returns the status code of the response, 0 means the request is not responded.
+func PostSigned(ctx context.Context, client *http.Client, url string, header http.Header, secret string,
+	body []byte) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(watch.SubscriptionTimestampHeader, strconv.FormatInt(timestamp, 10))
+	if len(secret) > 0 {
+		req.Header.Set(watch.SubscriptionSignatureHeader, watch.SignSubscriptionPayload(secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		// drain the body so that the connection can be reused
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, MaxErrorLength))
+	return resp.StatusCode, fmt.Errorf("responds status %d, body: %s", resp.StatusCode, respBody)
+}
+
+// Truncate truncates the error message to the max length
+func Truncate(msg string) string {
+	if len(msg) <= MaxErrorLength {
+		return msg
+	}
+	return msg[:MaxErrorLength]
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watcher
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"configcenter/src/common/watch"
+)
+
+func TestPostSigned(t *testing.T) {
+	body := []byte(`{"bk_events":[]}`)
+	status := http.StatusOK
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("callback error"))
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set(watch.SubscriptionIDHeader, "1")
+
+	code, err := PostSigned(context.Background(), server.Client(), server.URL, header, "secret", body)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("PostSigned() = %d, %v, want 200", code, err)
+	}
+
+	if received.Method != http.MethodPost || string(receivedBody) != string(body) {
+		t.Errorf("received %s request with body %s", received.Method, receivedBody)
+	}
+	if received.Header.Get(watch.SubscriptionIDHeader) != "1" {
+		t.Errorf("extra header is not posted, header: %v", received.Header)
+	}
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(watch.SubscriptionTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("parse timestamp header failed, err: %v", err)
+	}
+	signature := received.Header.Get(watch.SubscriptionSignatureHeader)
+	if !watch.VerifySubscriptionPayload("secret", timestamp, receivedBody, signature) {
+		t.Errorf("signature %s can not be verified", signature)
+	}
+
+	// no signature without secret
+	if _, err := PostSigned(context.Background(), server.Client(), server.URL, nil, "", body); err != nil {
+		t.Fatalf("PostSigned() without secret failed, err: %v", err)
+	}
+	if signature := received.Header.Get(watch.SubscriptionSignatureHeader); signature != "" {
+		t.Errorf("signature %s is set without secret", signature)
+	}
+
+	// non 2xx response is an error with the response body
+	status = http.StatusInternalServerError
+	code, err = PostSigned(context.Background(), server.Client(), server.URL, nil, "", body)
+	if code != http.StatusInternalServerError || err == nil || !strings.Contains(err.Error(), "callback error") {
+		t.Errorf("PostSigned() = %d, %v, want 500 with the response body", code, err)
+	}
+
+	// not responded
+	code, err = PostSigned(context.Background(), server.Client(), "http://127.0.0.1:0", nil, "", body)
+	if code != 0 || err == nil {
+		t.Errorf("PostSigned() = %d, %v, want not responded error", code, err)
+	}
+}
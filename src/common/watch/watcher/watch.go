@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package watcher contains the helpers of the server side watches that watch the events with a persisted cursor.
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"configcenter/src/apimachinery/cacheservice/cache/event"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/watch"
+)
+
+// Result is the result of a round of watch
+type Result struct {
+	// Events are the watched events, it is empty if no event is watched
+	Events []*watch.WatchEventDetail
+	// Cursor is the cursor to watch from in the next round, it is saved after the events are handled
+	Cursor string
+	// Gap is set if the cursor is lost and the events after it can not all be watched, the caller should record it
+	// so that the lost events are visible to the user
+	Gap *watch.CursorGap
+}
+
+// WatchWithCursor watches the events from the cursor of the options. the cache service has already tried the event
+// archive when the cursor is not in the event chain, so if the cursor is still lost, the events are watched from the
+// time of the lost cursor, and only if that time is expired too, the events are watched from now on with a gap.
+func WatchWithCursor(ctx context.Context, cli event.Interface, header http.Header, opts *watch.WatchEventOptions) (
+	*Result, error) {
+
+	rid := httpheader.GetRid(header)
+
+	resp, err := watchOnce(ctx, cli, header, opts)
+	if err == nil {
+		return parseResult(opts.Cursor, resp), nil
+	}
+
+	if err.GetCode() != common.CCErrEventChainNodeNotExist || len(opts.Cursor) == 0 {
+		return nil, err
+	}
+
+	lost := new(watch.Cursor)
+	if decodeErr := lost.Decode(opts.Cursor); decodeErr != nil {
+		blog.Errorf("decode lost cursor %s failed, err: %v, rid: %s", opts.Cursor, decodeErr, rid)
+	} else {
+		resumeOpts := *opts
+		resumeOpts.Cursor = ""
+		resumeOpts.StartFrom = int64(lost.ClusterTime.Sec)
+		resp, err = watchOnce(ctx, cli, header, &resumeOpts)
+		if err == nil {
+			blog.Warnf("cursor %s is lost, resume watching %s events from its time %d, rid: %s", opts.Cursor,
+				opts.Resource, resumeOpts.StartFrom, rid)
+			return parseResult("", resp), nil
+		}
+
+		// start from time out of the event chain's ttl is invalid, other errors are returned to retry
+		if err.GetCode() != common.CCErrCommParamsInvalid {
+			return nil, err
+		}
+	}
+
+	gap := &watch.CursorGap{
+		LostCursor: opts.Cursor,
+		From:       int64(lost.ClusterTime.Sec),
+		To:         time.Now().Unix(),
+	}
+
+	nowOpts := *opts
+	nowOpts.Cursor = ""
+	nowOpts.StartFrom = gap.To
+	resp, err = watchOnce(ctx, cli, header, &nowOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	blog.Errorf("cursor %s is lost, the %s events from %d to %d are not watched, rid: %s", opts.Cursor,
+		opts.Resource, gap.From, gap.To, rid)
+
+	result := parseResult("", resp)
+	result.Gap = gap
+	return result, nil
+}
+
+func watchOnce(ctx context.Context, cli event.Interface, header http.Header, opts *watch.WatchEventOptions) (
+	*watch.WatchResp, errors.CCErrorCoder) {
+
+	result, err := cli.WatchEvent(ctx, header, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(watch.WatchResp)
+	if err := json.Unmarshal([]byte(*result), resp); err != nil {
+		blog.Errorf("unmarshal watch result failed, err: %v, result: %s, rid: %s", err, *result,
+			httpheader.GetRid(header))
+		return nil, errors.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+
+	return resp, nil
+}
+
+// parseResult parses the watch response, the cursor is kept if no event is returned.
+func parseResult(cursor string, resp *watch.WatchResp) *Result {
+	if len(resp.Events) == 0 {
+		return &Result{Cursor: cursor}
+	}
+
+	lastCursor := resp.Events[len(resp.Events)-1].Cursor
+	if !resp.Watched {
+		// no event is watched, the returned event only contains the latest cursor
+		return &Result{Cursor: lastCursor}
+	}
+
+	return &Result{Events: resp.Events, Cursor: lastCursor}
+}
@@ -0,0 +1,185 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/stream/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeEventClient responds the watch requests in order and records the options of the requests
+type fakeEventClient struct {
+	responses []fakeWatchResponse
+	requests  []watch.WatchEventOptions
+}
+
+type fakeWatchResponse struct {
+	resp *watch.WatchResp
+	err  errors.CCErrorCoder
+}
+
+func (f *fakeEventClient) WatchEvent(_ context.Context, _ http.Header, opts *watch.WatchEventOptions) (*string,
+	errors.CCErrorCoder) {
+
+	f.requests = append(f.requests, *opts)
+	res := f.responses[0]
+	f.responses = f.responses[1:]
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	js, _ := json.Marshal(res.resp)
+	result := string(js)
+	return &result, nil
+}
+
+func (f *fakeEventClient) InnerWatchEvent(_ context.Context, _ http.Header, _ *watch.WatchEventOptions) (
+	*watch.WatchResp, errors.CCErrorCoder) {
+	return nil, errors.New(common.CCErrCommHTTPDoRequestFailed, "not implemented")
+}
+
+func encodeCursor(t *testing.T, sec int64) string {
+	cursor := watch.Cursor{
+		Type:        watch.Host,
+		ClusterTime: types.TimeStamp{Sec: uint32(sec)},
+		Oid:         primitive.NewObjectID().Hex(),
+		Oper:        types.Insert,
+	}
+	encoded, err := cursor.Encode()
+	if err != nil {
+		t.Fatalf("encode cursor failed, err: %v", err)
+	}
+	return encoded
+}
+
+func TestWatchWithCursor(t *testing.T) {
+	lostTime := time.Now().Add(-time.Hour).Unix()
+	lostCursor := encodeCursor(t, lostTime)
+	nextCursor := encodeCursor(t, time.Now().Unix())
+	events := &watch.WatchResp{Watched: true, Events: []*watch.WatchEventDetail{{Cursor: nextCursor}}}
+	noEvents := &watch.WatchResp{Watched: false, Events: []*watch.WatchEventDetail{{Cursor: nextCursor}}}
+	nodeNotExist := errors.New(common.CCErrEventChainNodeNotExist, "node not exist")
+	startFromInvalid := errors.New(common.CCErrCommParamsInvalid, "bk_start_from")
+	requestFailed := errors.New(common.CCErrCommHTTPDoRequestFailed, "request failed")
+
+	tests := []struct {
+		name      string
+		responses []fakeWatchResponse
+		// startFroms are the start from times of the requests, -1 means the time of now
+		startFroms []int64
+		wantEvents int
+		wantCursor string
+		wantGap    bool
+		wantErr    bool
+	}{
+		{
+			name:       "watched events",
+			responses:  []fakeWatchResponse{{resp: events}},
+			startFroms: []int64{0},
+			wantEvents: 1,
+			wantCursor: nextCursor,
+		},
+		{
+			name:       "no event is watched",
+			responses:  []fakeWatchResponse{{resp: noEvents}},
+			startFroms: []int64{0},
+			wantCursor: nextCursor,
+		},
+		{
+			name:       "empty response keeps cursor",
+			responses:  []fakeWatchResponse{{resp: &watch.WatchResp{}}},
+			startFroms: []int64{0},
+			wantCursor: lostCursor,
+		},
+		{
+			name:       "lost cursor is resumed from its time",
+			responses:  []fakeWatchResponse{{err: nodeNotExist}, {resp: events}},
+			startFroms: []int64{0, lostTime},
+			wantEvents: 1,
+			wantCursor: nextCursor,
+		},
+		{
+			name:       "expired lost cursor is watched from now with a gap",
+			responses:  []fakeWatchResponse{{err: nodeNotExist}, {err: startFromInvalid}, {resp: noEvents}},
+			startFroms: []int64{0, lostTime, -1},
+			wantCursor: nextCursor,
+			wantGap:    true,
+		},
+		{
+			name:       "resume failure is returned to retry",
+			responses:  []fakeWatchResponse{{err: nodeNotExist}, {err: requestFailed}},
+			startFroms: []int64{0, lostTime},
+			wantErr:    true,
+		},
+		{
+			name:       "other error is returned",
+			responses:  []fakeWatchResponse{{err: requestFailed}},
+			startFroms: []int64{0},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &fakeEventClient{responses: tt.responses}
+			opts := &watch.WatchEventOptions{Resource: watch.Host, Cursor: lostCursor}
+			before := time.Now().Unix()
+			result, err := WatchWithCursor(context.Background(), cli, http.Header{}, opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WatchWithCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(cli.requests) != len(tt.startFroms) {
+				t.Fatalf("WatchWithCursor() requests %d times, want %d", len(cli.requests), len(tt.startFroms))
+			}
+			for idx, req := range cli.requests {
+				switch want := tt.startFroms[idx]; {
+				case want == 0 && (req.Cursor != lostCursor || req.StartFrom != 0):
+					t.Errorf("request %d watches from %s/%d, want the cursor", idx, req.Cursor, req.StartFrom)
+				case want > 0 && (req.Cursor != "" || req.StartFrom != want):
+					t.Errorf("request %d watches from %s/%d, want %d", idx, req.Cursor, req.StartFrom, want)
+				case want < 0 && (req.Cursor != "" || req.StartFrom < before):
+					t.Errorf("request %d watches from %s/%d, want now", idx, req.Cursor, req.StartFrom)
+				}
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(result.Events) != tt.wantEvents {
+				t.Errorf("WatchWithCursor() events = %d, want %d", len(result.Events), tt.wantEvents)
+			}
+			if result.Cursor != tt.wantCursor {
+				t.Errorf("WatchWithCursor() cursor = %s, want %s", result.Cursor, tt.wantCursor)
+			}
+			if (result.Gap != nil) != tt.wantGap {
+				t.Fatalf("WatchWithCursor() gap = %+v, want %v", result.Gap, tt.wantGap)
+			}
+			if tt.wantGap && (result.Gap.LostCursor != lostCursor || result.Gap.From != lostTime ||
+				result.Gap.To < before) {
+				t.Errorf("WatchWithCursor() gap = %+v is not from the lost cursor to now", result.Gap)
+			}
+		})
+	}
+}
@@ -119,4 +119,18 @@ import (
 
 	// 3.15.x
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202506231000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610191000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610201000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610211000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610221000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610231000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610241000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610251000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610261000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610271000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610281000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610291000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610301000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202610311000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.15.202611011000"
 )
@@ -135,6 +135,59 @@ func (s *Service) createWatchDBChainCollections(rid string) error {
 			return err
 		}
 	}
+
+	return s.createWatchEventArchiveCollection(rid)
+}
+
+// createWatchEventArchiveCollection create the table to store the archived watch events, the archived events are
+// removed by the ttl index when their expire time is reached.
+func (s *Service) createWatchEventArchiveCollection(rid string) error {
+	tableName := common.BKTableNameWatchEventArchive
+	exists, err := s.watchDB.HasTable(s.ctx, tableName)
+	if err != nil {
+		blog.Errorf("check if table %s exists failed, err: %v, rid: %s", tableName, err, rid)
+		return err
+	}
+
+	if !exists {
+		err = s.watchDB.CreateTable(s.ctx, tableName)
+		if err != nil && !s.watchDB.IsDuplicatedError(err) {
+			blog.Errorf("create table %s failed, err: %v, rid: %s", tableName, err, rid)
+			return err
+		}
+	}
+
+	indexes := []daltypes.Index{
+		{Name: "index_coll_id", Keys: bson.D{{"coll", 1}, {common.BKFieldID, 1}}, Background: true, Unique: true},
+		{Name: "index_coll_cursor", Keys: bson.D{{"coll", 1}, {common.BKCursorField, 1}}, Background: true,
+			Unique: true},
+		// expire time is set by cache service with the configured ttl, zero expire seconds is not supported by dal,
+		// so the archived events are removed one second after they are expired.
+		{Name: "index_expire_at", Keys: bson.D{{"expire_at", 1}}, Background: true, ExpireAfterSeconds: 1},
+	}
+
+	existIndexArr, err := s.watchDB.Table(tableName).Indexes(s.ctx)
+	if err != nil {
+		blog.Errorf("get exist indexes for table %s failed, err: %v, rid: %s", tableName, err, rid)
+		return err
+	}
+
+	existIdxMap := make(map[string]bool)
+	for _, index := range existIndexArr {
+		existIdxMap[index.Name] = true
+	}
+
+	for _, index := range indexes {
+		if _, exist := existIdxMap[index.Name]; exist {
+			continue
+		}
+
+		err = s.watchDB.Table(tableName).CreateIndex(s.ctx, index)
+		if err != nil && !s.watchDB.IsDuplicatedError(err) {
+			blog.Errorf("create indexes for table %s failed, err: %v, rid: %s", tableName, err, rid)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package y3_15_202610281000
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func addHostLeaseCollections(ctx context.Context, db dal.RDB) error {
+	leaseIndexes := []types.Index{
+		{
+			Name: common.CCLogicUniqueIdxNamePrefix + common.BKFieldID,
+			Keys: bson.D{
+				{
+					common.BKFieldID, 1,
+				},
+			},
+			Background: true,
+			Unique:     true,
+		},
+		{
+			Name: common.CCLogicIndexNamePrefix + "bkBizID_status",
+			Keys: bson.D{
+				{
+					common.BKAppIDField, 1,
+				},
+				{
+					common.BKStatusField, 1,
+				},
+			},
+			Background: true,
+		},
+		{
+			Name: common.CCLogicIndexNamePrefix + "status_expireTime",
+			Keys: bson.D{
+				{
+					common.BKStatusField, 1,
+				},
+				{
+					"expire_time", 1,
+				},
+			},
+			Background: true,
+		},
+		{
+			Name: common.CCLogicIndexNamePrefix + "bkHostIDs_status",
+			Keys: bson.D{
+				{
+					"bk_host_ids", 1,
+				},
+				{
+					common.BKStatusField, 1,
+				},
+			},
+			Background: true,
+		},
+	}
+
+	if err := createTableAndIndexes(ctx, db, common.BKTableNameHostLease, leaseIndexes); err != nil {
+		return err
+	}
+
+	historyIndexes := []types.Index{
+		{
+			Name: common.CCLogicUniqueIdxNamePrefix + common.BKFieldID,
+			Keys: bson.D{
+				{
+					common.BKFieldID, 1,
+				},
+			},
+			Background: true,
+			Unique:     true,
+		},
+		{
+			Name: common.CCLogicIndexNamePrefix + "leaseID_createTime",
+			Keys: bson.D{
+				{
+					"lease_id", 1,
+				},
+				{
+					common.CreateTimeField, -1,
+				},
+			},
+			Background: true,
+		},
+	}
+
+	return createTableAndIndexes(ctx, db, common.BKTableNameHostLeaseHistory, historyIndexes)
+}
+
+func createTableAndIndexes(ctx context.Context, db dal.RDB, table string, indexes []types.Index) error {
+	exists, err := db.HasTable(ctx, table)
+	if err != nil {
+		blog.Errorf("check if %s table exists failed, err: %v", table, err)
+		return err
+	}
+
+	if !exists {
+		if err := db.CreateTable(ctx, table); err != nil {
+			blog.Errorf("create %s table failed, err: %v", table, err)
+			return err
+		}
+	}
+
+	existIndexArr, err := db.Table(table).Indexes(ctx)
+	if err != nil {
+		blog.Errorf("get exist index for %s table failed, err: %v", table, err)
+		return err
+	}
+
+	existIdxMap := make(map[string]struct{})
+	for _, index := range existIndexArr {
+		existIdxMap[index.Name] = struct{}{}
+	}
+
+	for _, index := range indexes {
+		if _, exist := existIdxMap[index.Name]; exist {
+			continue
+		}
+
+		err = db.Table(table).CreateIndex(ctx, index)
+		if err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("create index failed, table: %s, index: %+v, err: %v", table, index, err)
+			return err
+		}
+	}
+
+	return nil
+}
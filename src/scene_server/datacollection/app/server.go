@@ -31,6 +31,7 @@ import (
 	"configcenter/src/common/errors"
 	headerutil "configcenter/src/common/http/header/util"
 	"configcenter/src/common/metadata"
+	apigwcli "configcenter/src/common/resource/apigw"
 	"configcenter/src/common/types"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/datacollection/app/options"
@@ -44,6 +45,7 @@ import (
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
+	"configcenter/src/thirdparty/apigw"
 	"configcenter/src/thirdparty/esbserver"
 	"configcenter/src/thirdparty/esbserver/esbutil"
 
@@ -220,6 +222,9 @@ func (c *DataCollection) OnHostConfigUpdate(prev, curr cc.ProcessConfig) {
 		c.config.Esb.Addrs, _ = cc.String("esb.addr")
 		c.config.Esb.AppCode, _ = cc.String("esb.appCode")
 		c.config.Esb.AppSecret, _ = cc.String("esb.appSecret")
+
+		// host snapshot drift alert rules.
+		hostsnap.ReloadSnapDriftRules()
 	}
 }
 
@@ -328,6 +333,11 @@ func (c *DataCollection) initModules() error {
 	// build logics comm.
 	c.service.SetLogics(mgoCli, esb)
 
+	// init the bk-notice client that sends the host snapshot drift alerts.
+	if err := initDriftAlertNotice(c.engine); err != nil {
+		return err
+	}
+
 	// connect to cc main redis.
 	redisCli, err := redis.NewFromConfig(c.config.CCRedis)
 	if err != nil {
@@ -555,6 +565,30 @@ func (c *DataCollection) Run() error {
 	return nil
 }
 
+// initDriftAlertNotice init the bk-notice client that sends the host snapshot drift alerts if it is enabled
+func initDriftAlertNotice(engine *backbone.Engine) error {
+	if !cc.IsExist("datacollection.hostsnap.driftAlert.notice.enabled") {
+		return nil
+	}
+
+	enabled, err := cc.Bool("datacollection.hostsnap.driftAlert.notice.enabled")
+	if err != nil {
+		blog.Errorf("get datacollection.hostsnap.driftAlert.notice.enabled failed, err: %v", err)
+		return err
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	if err := apigwcli.Init("apiGW", engine.Metric().Registry(), []apigw.ClientType{apigw.Notice}); err != nil {
+		blog.Errorf("init api gateway client failed, err: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 // Run setups a new datacollection app with a context and options and runs it as server instance.
 func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOption) error {
 	// create datacollection server.
@@ -0,0 +1,93 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"configcenter/src/common/metadata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tidwall/gjson"
+)
+
+var _ = Describe("Hostsnap history", func() {
+	Context("test build snapshot change histories", func() {
+		host := `{"bk_host_id":1,"bk_cpu":8,"bk_mem":16000,"bk_os_name":"linux centos","bk_host_name":"host-1"}`
+
+		It("unchanged fields are skipped", func() {
+			setter := map[string]interface{}{"bk_cpu": 8, "bk_mem": int64(16000), "bk_os_name": "linux centos"}
+			Expect(buildSnapChangeHistories(1, host, setter)).To(BeEmpty())
+		})
+
+		It("changed fields are recorded with previous and current data", func() {
+			setter := map[string]interface{}{"bk_cpu": 16, "bk_mem": int64(16000), "bk_host_name": "host-2"}
+			histories := buildSnapChangeHistories(1, host, setter)
+			Expect(histories).To(HaveLen(2))
+
+			historyMap := make(map[string]metadata.HostSnapHistory)
+			for _, history := range histories {
+				Expect(history.HostID).To(Equal(int64(1)))
+				Expect(history.Type).To(Equal(metadata.HostSnapHistoryChange))
+				historyMap[history.Field] = history
+			}
+			Expect(historyMap["bk_cpu"].PreData).To(BeNumerically("==", 8))
+			Expect(historyMap["bk_cpu"].CurData).To(Equal(16))
+			Expect(historyMap["bk_host_name"].PreData).To(Equal("host-1"))
+			Expect(historyMap["bk_host_name"].CurData).To(Equal("host-2"))
+		})
+
+		It("fields that are not set in cmdb are recorded", func() {
+			histories := buildSnapChangeHistories(1, host, map[string]interface{}{"bk_os_version": "7.9"})
+			Expect(histories).To(HaveLen(1))
+			Expect(histories[0].Field).To(Equal("bk_os_version"))
+			Expect(histories[0].PreData).To(BeNil())
+			Expect(histories[0].CurData).To(Equal("7.9"))
+		})
+
+		It("fields that are not managed by the snapshot are skipped", func() {
+			setter := map[string]interface{}{"bk_comment": "changed", "operator": "user"}
+			Expect(buildSnapChangeHistories(1, host, setter)).To(BeEmpty())
+		})
+	})
+
+	Context("test snapshot drift", func() {
+		// the values are compared numerically with tolerance or as strings
+		tests := []struct {
+			name             string
+			reported         string
+			declared         string
+			tolerancePercent float64
+			drifted          bool
+		}{
+			{"same number", `16000`, `16000`, 0, false},
+			{"different number without tolerance", `16001`, `16000`, 0, true},
+			{"larger number within tolerance", `16500`, `16000`, 5, false},
+			{"smaller number within tolerance", `15500`, `16000`, 5, false},
+			{"larger number out of tolerance", `17000`, `16000`, 5, true},
+			{"smaller number out of tolerance", `15000`, `16000`, 5, true},
+			{"number reported as string", `"16000"`, `16000`, 0, false},
+			{"declared zero", `1`, `0`, 50, true},
+			{"same string", `"linux centos"`, `"linux centos"`, 5, false},
+			{"different string", `"linux ubuntu"`, `"linux centos"`, 5, true},
+			{"number and string", `8`, `"eight"`, 5, true},
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			It(tt.name, func() {
+				drifted := isSnapDrifted(gjson.Parse(tt.reported), gjson.Parse(tt.declared), tt.tolerancePercent)
+				Expect(drifted).To(Equal(tt.drifted))
+			})
+		}
+	})
+})
@@ -0,0 +1,302 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	apigwcli "configcenter/src/common/resource/apigw"
+	"configcenter/src/common/util"
+	"configcenter/src/thirdparty/apigw/notice"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// redisSnapDriftPrefix is the prefix of the redis key that saves the last alerted drift value of a host field
+	redisSnapDriftPrefix = "hostSnapDrift:"
+	// snapDriftAlertExpire is the expire time of the alerted drift value, the drift is alerted again after it expires
+	snapDriftAlertExpire = 24 * time.Hour
+)
+
+// snapDriftRule is a host snapshot drift alert rule, the value reported by the snapshot of the field is compared with
+// the value declared in the cmdb host field, it is a drift if the difference exceeds the tolerance percentage
+type snapDriftRule struct {
+	field            string
+	declaredField    string
+	tolerancePercent float64
+}
+
+var (
+	// snapDriftRules is the cached drift alert rules, it is reloaded when the config changes
+	snapDriftRules []snapDriftRule
+	// snapHostFields is the cached host fields that need to be got for the snapshot analysis
+	snapHostFields = reqireFields
+	// snapDriftRulesLock protects the cached drift alert rules and host fields
+	snapDriftRulesLock sync.RWMutex
+)
+
+// ReloadSnapDriftRules parse the drift alert rules from the config and cache them together with the host fields that
+// the snapshot analysis needs, so that the rules are not parsed for every report. it is called when the host snap is
+// initialized and when the config changes
+func ReloadSnapDriftRules() {
+	rules := parseSnapDriftRules()
+	fields := buildHostSnapFields(rules)
+
+	snapDriftRulesLock.Lock()
+	snapDriftRules = rules
+	snapHostFields = fields
+	snapDriftRulesLock.Unlock()
+}
+
+// getSnapDriftRules get the cached drift alert rules
+func getSnapDriftRules() []snapDriftRule {
+	snapDriftRulesLock.RLock()
+	defer snapDriftRulesLock.RUnlock()
+	return snapDriftRules
+}
+
+// getHostSnapFields get the cached host fields that need to be got for the snapshot analysis
+func getHostSnapFields() []string {
+	snapDriftRulesLock.RLock()
+	defer snapDriftRulesLock.RUnlock()
+	return snapHostFields
+}
+
+// parseSnapDriftRules parse the drift alert rules from the config "datacollection.hostsnap.driftAlert.rules", the
+// rules are separated by comma in the format of "snapField:declaredField[:tolerancePercent]", like "bk_mem:mem_quota:5".
+// the snap field must be a snapshot managed field, and the declared field must not be one, invalid rules are skipped
+func parseSnapDriftRules() []snapDriftRule {
+	if !cc.IsExist("datacollection.hostsnap.driftAlert.rules") {
+		return nil
+	}
+
+	config, err := cc.String("datacollection.hostsnap.driftAlert.rules")
+	if err != nil {
+		blog.Errorf("get datacollection.hostsnap.driftAlert.rules failed, err: %v", err)
+		return nil
+	}
+
+	rules := make([]snapDriftRule, 0)
+	for _, item := range strings.Split(config, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 2 && len(parts) != 3 {
+			blog.Errorf("host snapshot drift rule %s is invalid, skip it", item)
+			continue
+		}
+
+		rule := snapDriftRule{field: strings.TrimSpace(parts[0]), declaredField: strings.TrimSpace(parts[1])}
+		if !util.InStrArr(compareFields, rule.field) || rule.declaredField == "" ||
+			util.InStrArr(reqireFields, rule.declaredField) {
+			blog.Errorf("host snapshot drift rule %s has invalid field, skip it", item)
+			continue
+		}
+
+		if len(parts) == 3 {
+			percent, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+			if err != nil || percent < 0 {
+				blog.Errorf("host snapshot drift rule %s has invalid tolerance percent, skip it", item)
+				continue
+			}
+			rule.tolerancePercent = percent
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// buildHostSnapFields build the host fields that need to be got for the snapshot analysis, the declared fields of the
+// drift rules and the operators who receive the drift alerts are included when drift rules are configured
+func buildHostSnapFields(rules []snapDriftRule) []string {
+	if len(rules) == 0 {
+		return reqireFields
+	}
+
+	fields := make([]string, len(reqireFields), len(reqireFields)+len(rules)+2)
+	copy(fields, reqireFields)
+	for _, rule := range rules {
+		fields = append(fields, rule.declaredField)
+	}
+	fields = append(fields, common.BKOperatorField, common.BKBakOperatorField)
+	return util.StrArrayUnique(fields)
+}
+
+// buildSnapChangeHistories build the change histories of the snapshot managed fields whose reported value differs from
+// the value in cmdb, the unchanged fields are skipped so that the repeated reports do not produce histories
+func buildSnapChangeHistories(hostID int64, host string, setter map[string]interface{}) []metadata.HostSnapHistory {
+	histories := make([]metadata.HostSnapHistory, 0)
+	for _, field := range compareFields {
+		curData, exists := setter[field]
+		if !exists {
+			continue
+		}
+
+		curJs, err := json.Marshal(curData)
+		if err != nil {
+			continue
+		}
+
+		preData := gjson.Get(host, field)
+		if gjson.ParseBytes(curJs).String() == preData.String() {
+			continue
+		}
+
+		histories = append(histories, metadata.HostSnapHistory{
+			HostID:  hostID,
+			Type:    metadata.HostSnapHistoryChange,
+			Field:   field,
+			PreData: preData.Value(),
+			CurData: curData,
+		})
+	}
+	return histories
+}
+
+// isSnapDrifted check if the reported value drifts from the declared value, the values are compared numerically with
+// the tolerance percentage if both of them are numbers, otherwise they are compared as strings
+func isSnapDrifted(reported, declared gjson.Result, tolerancePercent float64) bool {
+	reportedVal, reportedErr := strconv.ParseFloat(reported.String(), 64)
+	declaredVal, declaredErr := strconv.ParseFloat(declared.String(), 64)
+	if reportedErr == nil && declaredErr == nil {
+		return math.Abs(reportedVal-declaredVal) > math.Abs(declaredVal)*tolerancePercent/100.0
+	}
+
+	return reported.String() != declared.String()
+}
+
+// checkSnapDrift compare the reported snapshot values with the cmdb declared values by the drift alert rules, record
+// the drift histories and alert the host operators. the drifted values are only checked against the alerted values in
+// redis, so the reports without drift need no redis access. a drift is only alerted once for the same reported value
+// until the alert expires, so that the repeated reports do not produce duplicate alerts
+func (h *HostSnap) checkSnapDrift(header http.Header, rid string, hostID int64, host, raw string) {
+	rules := getSnapDriftRules()
+	if len(rules) == 0 {
+		return
+	}
+
+	histories := make([]metadata.HostSnapHistory, 0)
+	keys := make([]string, 0)
+	for _, rule := range rules {
+		reported := gjson.Get(raw, rule.field)
+		declared := gjson.Get(host, rule.declaredField)
+		if !reported.Exists() || !declared.Exists() || declared.String() == "" {
+			continue
+		}
+
+		if !isSnapDrifted(reported, declared, rule.tolerancePercent) {
+			continue
+		}
+
+		histories = append(histories, metadata.HostSnapHistory{
+			HostID:        hostID,
+			Type:          metadata.HostSnapHistoryDrift,
+			Field:         rule.field,
+			DeclaredField: rule.declaredField,
+			PreData:       declared.Value(),
+			CurData:       reported.Value(),
+		})
+		keys = append(keys, redisSnapDriftPrefix+strconv.FormatInt(hostID, 10)+":"+rule.field)
+	}
+
+	if len(histories) == 0 {
+		return
+	}
+
+	alerted, err := h.redisCli.MGet(h.ctx, keys...).Result()
+	if err != nil {
+		blog.Errorf("get host %d drift keys %v failed, err: %v, rid: %s", hostID, keys, err, rid)
+		return
+	}
+
+	newHistories := make([]metadata.HostSnapHistory, 0)
+	for idx, history := range histories {
+		reported := gjson.Get(raw, history.Field).String()
+		if value, ok := alerted[idx].(string); ok && value == reported {
+			continue
+		}
+
+		if err := h.redisCli.Set(h.ctx, keys[idx], reported, snapDriftAlertExpire).Err(); err != nil {
+			blog.Errorf("set host %d drift key %s failed, err: %v, rid: %s", hostID, keys[idx], err, rid)
+			continue
+		}
+		newHistories = append(newHistories, history)
+	}
+
+	if len(newHistories) == 0 {
+		return
+	}
+
+	opt := &metadata.CreateHostSnapHistoryOption{Histories: newHistories}
+	if err := h.CoreAPI.CoreService().Host().CreateHostSnapHistory(h.ctx, header, opt); err != nil {
+		blog.Errorf("create host %d snapshot drift histories failed, err: %v, rid: %s", hostID, err, rid)
+		return
+	}
+
+	h.sendSnapDriftAlert(header, rid, hostID, host, newHistories)
+}
+
+// sendSnapDriftAlert send the drift alert to the operator and backup operator of the host through bk-notice, it is
+// skipped if bk-notice is not enabled
+func (h *HostSnap) sendSnapDriftAlert(header http.Header, rid string, hostID int64, host string,
+	histories []metadata.HostSnapHistory) {
+
+	if apigwcli.Client() == nil || apigwcli.Client().Notice() == nil {
+		return
+	}
+
+	receivers := make([]string, 0)
+	for _, field := range []string{common.BKOperatorField, common.BKBakOperatorField} {
+		for _, user := range strings.Split(gjson.Get(host, field).String(), ",") {
+			if user = strings.TrimSpace(user); user != "" {
+				receivers = append(receivers, user)
+			}
+		}
+	}
+
+	if len(receivers) == 0 {
+		return
+	}
+
+	drifts := make([]string, len(histories))
+	for idx, history := range histories {
+		drifts[idx] = fmt.Sprintf("%s is reported as %v but declared as %v in %s", history.Field, history.CurData,
+			history.PreData, history.DeclaredField)
+	}
+
+	req := &notice.SendMsgReq{
+		Title:     fmt.Sprintf("host %d configuration drifts from cmdb", hostID),
+		Content:   strings.Join(drifts, "; "),
+		Receivers: util.StrArrayUnique(receivers),
+	}
+	if err := apigwcli.Client().Notice().SendMsg(h.ctx, header, req); err != nil {
+		blog.Errorf("send host %d snapshot drift alert failed, err: %v, rid: %s", hostID, err, rid)
+	}
+}
@@ -98,6 +98,7 @@ func NewHostSnap(ctx context.Context, redisCli redis.Client, db dal.RDB, engine
 		filter:      newFilter(),
 		window:      newWindow(),
 	}
+	ReloadSnapDriftRules()
 	return h
 }
 
@@ -365,6 +366,9 @@ func (h *HostSnap) Analyze(msg *string, sourceType string) (bool, error) {
 		setter, raw = parseSetter(&val, innerIP, outerIP)
 	}
 
+	// the drift is checked for every passed report, since the declared values may change while the snapshot not
+	h.checkSnapDrift(header, rid, hostID, host, raw)
+
 	// no need to update
 	if !needToUpdate(raw, host, elements[3].String()) {
 		return false, nil
@@ -466,12 +470,24 @@ func (h *HostSnap) updateHostWithColletorMsg(header http.Header, rid string, hos
 				hostOption.hostID, hostOption.innerIP, err, rid)
 			return err
 		}
+
 		// save audit log.
 		if err := audit.SaveAuditLog(kit, auditLog...); err != nil {
 			blog.Errorf("save host snap audit log failed after update host, host %d/%s, err: %v, rid: %s",
 				hostOption.hostID, hostOption.innerIP, err, rid)
 			return err
 		}
+
+		// record the change histories of the snapshot managed fields apart from the audit log, the histories are
+		// supplementary to the audit log, so the failure of them does not fail the host update.
+		histories := buildSnapChangeHistories(hostOption.hostID, hostOption.host, hostOption.setter)
+		if len(histories) > 0 {
+			historyOpt := &metadata.CreateHostSnapHistoryOption{Histories: histories}
+			if err := h.CoreAPI.CoreService().Host().CreateHostSnapHistory(h.ctx, header, historyOpt); err != nil {
+				blog.Errorf("create host %d/%s snapshot change histories failed, err: %v, rid: %s",
+					hostOption.hostID, hostOption.innerIP, err, rid)
+			}
+		}
 		blog.V(5).Infof("snapshot for host changed, update success, host id: %d, ip: %s, cloud id: %d, rid: %s",
 			hostOption.hostID, hostOption.innerIP, hostOption.cloudID, rid)
 
@@ -1116,7 +1132,7 @@ func (h *HostSnap) getHostByAgentID(header http.Header, rid, agentID string) (st
 
 	opt := &metadata.SearchHostWithAgentID{
 		AgentID: agentID,
-		Fields:  reqireFields,
+		Fields:  getHostSnapFields(),
 	}
 
 	host, err := h.Engine.CoreAPI.CacheService().Cache().Host().SearchHostWithAgentID(context.Background(), header, opt)
@@ -1138,7 +1154,7 @@ func (h *HostSnap) getHostByVal(header http.Header, rid string, cloudID int64, i
 		opt := &metadata.SearchHostWithInnerIPOption{
 			InnerIP: ip,
 			CloudID: cloudID,
-			Fields:  reqireFields,
+			Fields:  getHostSnapFields(),
 		}
 
 		host, err := h.Engine.CoreAPI.CacheService().Cache().Host().SearchHostWithInnerIPForStatic(context.Background(),
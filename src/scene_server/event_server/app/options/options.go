@@ -17,6 +17,8 @@ import (
 	"configcenter/src/ac/iam"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/scene_server/event_server/exporter"
+	"configcenter/src/scene_server/event_server/notification"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -63,4 +65,10 @@ type Config struct {
 
 	// ApiConf gse apiServer connection config
 	ApiConf *client.GseConnConfig
+
+	// ExporterConf kafka event exporter config
+	ExporterConf *exporter.Config
+
+	// NotificationConf notification rule engine config
+	NotificationConf *notification.Config
 }
@@ -29,7 +29,10 @@ import (
 	apigwcli "configcenter/src/common/resource/apigw"
 	"configcenter/src/common/types"
 	"configcenter/src/scene_server/event_server/app/options"
+	"configcenter/src/scene_server/event_server/exporter"
+	"configcenter/src/scene_server/event_server/notification"
 	svc "configcenter/src/scene_server/event_server/service"
+	"configcenter/src/scene_server/event_server/subscription"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
 	eventtype "configcenter/src/scene_server/event_server/types"
 	"configcenter/src/storage/dal"
@@ -184,6 +187,18 @@ func (es *EventServer) initConfigs() error {
 		return err
 	}
 
+	es.config.ExporterConf, err = exporter.ParseConfig()
+	if err != nil {
+		blog.Errorf("parse eventServer kafka exporter config error, err: %v", err)
+		return err
+	}
+
+	es.config.NotificationConf, err = notification.ParseConfig()
+	if err != nil {
+		blog.Errorf("parse eventServer notification config error, err: %v", err)
+		return err
+	}
+
 	identifierConf, err := hostidentifier.ParseIdentifierConf()
 	if err != nil {
 		blog.Errorf("parse eventServer host identifier config error, err: %v", err)
@@ -191,27 +206,34 @@ func (es *EventServer) initConfigs() error {
 	}
 	es.config.IdentifierConf = identifierConf
 
-	if !es.config.IdentifierConf.StartUp {
-		return nil
+	apigwClients := make([]apigw.ClientType, 0)
+	if es.config.NotificationConf.Enabled && es.config.NotificationConf.Notice {
+		apigwClients = append(apigwClients, apigw.Notice)
 	}
 
-	switch es.config.IdentifierConf.Version {
-	case eventtype.V1:
-		es.config.TaskConf, err = client.NewGseConnConfig("gse.taskServer")
-		if err != nil {
-			blog.Errorf("get gse taskServer Config error, err: %v", err)
-			return err
+	if es.config.IdentifierConf.StartUp {
+		switch es.config.IdentifierConf.Version {
+		case eventtype.V1:
+			es.config.TaskConf, err = client.NewGseConnConfig("gse.taskServer")
+			if err != nil {
+				blog.Errorf("get gse taskServer Config error, err: %v", err)
+				return err
+			}
+
+			es.config.ApiConf, err = client.NewGseConnConfig("gse.apiServer")
+			if err != nil {
+				blog.Errorf("get gse apiServer Config error, err: %v", err)
+				return err
+			}
+		case eventtype.V2:
+			apigwClients = append(apigwClients, apigw.Gse)
 		}
+	}
 
-		es.config.ApiConf, err = client.NewGseConnConfig("gse.apiServer")
+	if len(apigwClients) > 0 {
+		err = apigwcli.Init("apiGW", es.Engine().Metric().Registry(), apigwClients)
 		if err != nil {
-			blog.Errorf("get gse apiServer Config error, err: %v", err)
-			return err
-		}
-	case eventtype.V2:
-		err = apigwcli.Init("apiGW", es.Engine().Metric().Registry(), []apigw.ClientType{apigw.Gse})
-		if err != nil {
-			blog.Errorf("init gse api gateway client failed, err: %v", err)
+			blog.Errorf("init api gateway client failed, err: %v", err)
 			return err
 		}
 	}
@@ -271,6 +293,27 @@ func (es *EventServer) Run() error {
 	}
 	blog.Info("init modules success!")
 
+	// push the events of the webhook subscriptions to their callbacks
+	go subscription.NewPusher(es.ctx, es.engine, es.db).Run()
+
+	// evaluate the notification rules and send the notifications if it is enabled
+	if es.config.NotificationConf.Enabled {
+		ruleEngine, err := notification.NewEngine(es.ctx, es.engine, es.db, es.config.NotificationConf)
+		if err != nil {
+			return fmt.Errorf("new notification rule engine failed, err: %v", err)
+		}
+		go ruleEngine.Run()
+	}
+
+	// export the watch events to kafka if it is enabled
+	if es.config.ExporterConf.Enabled {
+		eventExporter, err := exporter.NewExporter(es.ctx, es.engine, es.db, es.config.ExporterConf)
+		if err != nil {
+			return fmt.Errorf("new kafka event exporter failed, err: %v", err)
+		}
+		go eventExporter.Run()
+	}
+
 	if err := es.runSyncData(); err != nil {
 		return err
 	}
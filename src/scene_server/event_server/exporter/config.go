@@ -0,0 +1,130 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package exporter
+
+import (
+	"errors"
+	"fmt"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/dal/kafka"
+)
+
+// defaultTopicPrefix is the default prefix of the kafka topics, the topic of a resource is prefix + resource
+const defaultTopicPrefix = "bk_cmdb_event_"
+
+// Config is the kafka event exporter config
+type Config struct {
+	// Enabled whether to export the watch events to kafka
+	Enabled bool
+	// TopicPrefix is the prefix of the kafka topics, each resource is exported to the topic of prefix + resource.
+	// if the topic of the kafka config is set, all the resources are exported to that topic.
+	TopicPrefix string
+	// Resources is the resources to export, all the resources are exported if not set
+	Resources []watch.CursorType
+	// Kafka is the kafka producer config
+	Kafka kafka.Config
+}
+
+// ParseConfig parse kafka event exporter config
+func ParseConfig() (*Config, error) {
+	conf := &Config{
+		TopicPrefix: defaultTopicPrefix,
+		Resources:   watch.ListCursorTypes(),
+	}
+
+	if !cc.IsExist("eventServer.kafkaExporter.enabled") {
+		return conf, nil
+	}
+
+	enabled, err := cc.Bool("eventServer.kafkaExporter.enabled")
+	if err != nil {
+		blog.Errorf("get eventServer.kafkaExporter.enabled error, err: %v", err)
+		return nil, err
+	}
+
+	if !enabled {
+		return conf, nil
+	}
+	conf.Enabled = true
+
+	if cc.IsExist("eventServer.kafkaExporter.topicPrefix") {
+		conf.TopicPrefix, err = cc.String("eventServer.kafkaExporter.topicPrefix")
+		if err != nil {
+			blog.Errorf("get eventServer.kafkaExporter.topicPrefix error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	if cc.IsExist("eventServer.kafkaExporter.resources") {
+		resources, err := cc.StringSlice("eventServer.kafkaExporter.resources")
+		if err != nil {
+			blog.Errorf("get eventServer.kafkaExporter.resources error, err: %v", err)
+			return nil, err
+		}
+
+		if len(resources) > 0 {
+			conf.Resources = make([]watch.CursorType, len(resources))
+			for idx, res := range resources {
+				conf.Resources[idx] = watch.CursorType(res)
+			}
+		}
+	}
+
+	conf.Kafka, err = cc.Kafka("kafka.eventExporter")
+	if err != nil {
+		blog.Errorf("get kafka.eventExporter config error, err: %v", err)
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("kafka event exporter config is invalid, err: %v", err)
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// Validate the kafka event exporter config
+func (c *Config) Validate() error {
+	if len(c.Kafka.Brokers) == 0 {
+		return errors.New("can not find kafka.eventExporter brokers config")
+	}
+
+	if len(c.Kafka.Topic) == 0 && len(c.TopicPrefix) == 0 {
+		return errors.New("kafka.eventExporter topic and eventServer.kafkaExporter.topicPrefix are both not set")
+	}
+
+	supported := make(map[watch.CursorType]struct{})
+	for _, typ := range watch.ListCursorTypes() {
+		supported[typ] = struct{}{}
+	}
+
+	for _, res := range c.Resources {
+		if _, exists := supported[res]; !exists {
+			return fmt.Errorf("eventServer.kafkaExporter.resources has unsupported resource %s", res)
+		}
+	}
+
+	return nil
+}
+
+// Topic returns the kafka topic of the resource
+func (c *Config) Topic(res watch.CursorType) string {
+	if len(c.Kafka.Topic) > 0 {
+		return c.Kafka.Topic
+	}
+	return c.TopicPrefix + string(res)
+}
@@ -0,0 +1,261 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package exporter exports the watch events of cmdb resources to kafka.
+package exporter
+
+import (
+	"context"
+	"time"
+
+	eventclient "configcenter/src/apimachinery/cacheservice/cache/event"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/kafka"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	// notMasterInterval is the interval to check again if the exporter is master
+	notMasterInterval = 5 * time.Second
+	// failInterval is the interval to retry after the events are failed to be watched or exported
+	failInterval = 3 * time.Second
+)
+
+// Checkpoint is the last cursor of the events of a resource that are exported to kafka
+type Checkpoint struct {
+	Resource watch.CursorType `bson:"bk_resource"`
+	Cursor   string           `bson:"bk_cursor"`
+	Topic    string           `bson:"topic"`
+	// LastGap is the last events that are lost because the cursor is expired, so that the consumers can know it
+	LastGap  *watch.CursorGap `bson:"last_gap,omitempty"`
+	LastTime time.Time        `bson:"last_time"`
+}
+
+// Exporter watches the events of the resources and publishes them to kafka on the master event server, the cursor of
+// the exported events is saved as a checkpoint in mongodb after they are acknowledged by kafka, so the events are
+// exported at least once, and the consumers can de-duplicate them by the cursor header.
+type Exporter struct {
+	ctx      context.Context
+	eventCli eventclient.Interface
+	db       dal.RDB
+	isMaster discovery.ServiceManageInterface
+	conf     *Config
+	producer sarama.SyncProducer
+}
+
+// NewExporter new kafka event exporter
+func NewExporter(ctx context.Context, engine *backbone.Engine, db dal.RDB, conf *Config) (*Exporter, error) {
+	producer, err := newProducer(conf.Kafka)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		ctx:      ctx,
+		eventCli: engine.CoreAPI.CacheService().Cache().Event(),
+		db:       db,
+		isMaster: engine.Discovery(),
+		conf:     conf,
+		producer: producer,
+	}, nil
+}
+
+// newProducer creates an idempotent sync producer, so that the retried messages are not duplicated in kafka
+func newProducer(conf kafka.Config) (sarama.SyncProducer, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V1_0_0_0
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Idempotent = true
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 5
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Net.MaxOpenRequests = 1
+	if conf.User != "" && conf.Password != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = conf.User
+		config.Net.SASL.Password = conf.Password
+		config.Net.SASL.Handshake = true
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &kafka.XDGSCRAMClient{HashGeneratorFcn: kafka.SHA512}
+		}
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+	}
+
+	producer, err := sarama.NewSyncProducer(conf.Brokers, config)
+	if err != nil {
+		blog.Errorf("create kafka event exporter producer failed, err: %v", err)
+		return nil, err
+	}
+	return producer, nil
+}
+
+// Run runs an export worker for each resource until the context is done.
+func (e *Exporter) Run() {
+	blog.Infof("start to run kafka event exporter, resources: %v", e.conf.Resources)
+
+	for _, res := range e.conf.Resources {
+		key, err := event.GetResourceKeyWithCursorType(res)
+		if err != nil {
+			blog.Errorf("get resource %s event key failed, skip exporting it, err: %v", res, err)
+			continue
+		}
+		go e.runWorker(res, key)
+	}
+
+	<-e.ctx.Done()
+	if err := e.producer.Close(); err != nil {
+		blog.Errorf("close kafka event exporter producer failed, err: %v", err)
+	}
+	blog.Infof("kafka event exporter is stopped")
+}
+
+// runWorker loops to export the events of the resource from the checkpoint, only master exports the events.
+func (e *Exporter) runWorker(res watch.CursorType, key event.Key) {
+	topic := e.conf.Topic(res)
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		default:
+		}
+
+		if !e.isMaster.IsMaster() {
+			blog.V(4).Infof("loop export %s events, but not master, skip.", res)
+			sleep(e.ctx, notMasterInterval)
+			continue
+		}
+
+		rid := util.GenerateRID()
+		// always load the checkpoint from db, because it may be changed by the previous master
+		checkpoint, err := e.getCheckpoint(res)
+		if err != nil {
+			blog.Errorf("get %s event export checkpoint failed, err: %v, rid: %s", res, err, rid)
+			sleep(e.ctx, failInterval)
+			continue
+		}
+
+		result, ok := e.watchEvents(res, checkpoint.Cursor, rid)
+		if !ok {
+			sleep(e.ctx, failInterval)
+			continue
+		}
+
+		if len(result.Events) > 0 {
+			if err := e.export(topic, key, result.Events, rid); err != nil {
+				blog.Errorf("export %s events to kafka topic %s failed, err: %v, rid: %s", res, topic, err, rid)
+				sleep(e.ctx, failInterval)
+				continue
+			}
+		}
+
+		if result.Cursor == checkpoint.Cursor && result.Gap == nil {
+			continue
+		}
+
+		if err := e.saveCheckpoint(res, topic, result.Cursor, result.Gap); err != nil {
+			blog.Errorf("save %s event export checkpoint %s failed, err: %v, rid: %s", res, result.Cursor, err, rid)
+			sleep(e.ctx, failInterval)
+		}
+	}
+}
+
+// watchEvents watches the events of all the tenants of the resource after the cursor, watch from now if the cursor is
+// not set. the lost cursor is resumed from its time, or from now on with a gap if its time is expired too.
+func (e *Exporter) watchEvents(res watch.CursorType, cursor, rid string) (*watcher.Result, bool) {
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, common.BKSuperOwnerID, rid)
+	// all types of events with all the fields are exported
+	opts := &watch.WatchEventOptions{
+		Resource: res,
+		Cursor:   cursor,
+	}
+
+	result, err := watcher.WatchWithCursor(e.ctx, e.eventCli, header, opts)
+	if err != nil {
+		blog.Errorf("watch %s events to export failed, err: %v, rid: %s", res, err, rid)
+		return nil, false
+	}
+
+	return result, true
+}
+
+// export publishes the events to kafka in one batch, the batch is exported again as a whole if any one of them fails.
+func (e *Exporter) export(topic string, key event.Key, events []*watch.WatchEventDetail, rid string) error {
+	messages := make([]*sarama.ProducerMessage, 0, len(events))
+	for _, evt := range events {
+		msg, err := newProducerMessage(topic, key, evt)
+		if err != nil {
+			// this should not happen, skip the event so that the following events can still be exported
+			blog.Errorf("encode %s event to kafka message failed, skip it, err: %v, rid: %s", evt.Resource, err, rid)
+			continue
+		}
+		messages = append(messages, msg)
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if err := e.producer.SendMessages(messages); err != nil {
+		return err
+	}
+
+	blog.V(4).Infof("export %d events to kafka topic %s, last cursor: %s, rid: %s", len(messages), topic,
+		events[len(events)-1].Cursor, rid)
+	return nil
+}
+
+func (e *Exporter) getCheckpoint(res watch.CursorType) (*Checkpoint, error) {
+	checkpoint := new(Checkpoint)
+	cond := mapstr.MapStr{"bk_resource": res}
+	err := e.db.Table(common.BKTableNameEventExportCheckpoint).Find(cond).One(e.ctx, checkpoint)
+	if err != nil {
+		if e.db.IsNotFoundError(err) {
+			return &Checkpoint{Resource: res}, nil
+		}
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+func (e *Exporter) saveCheckpoint(res watch.CursorType, topic, cursor string, gap *watch.CursorGap) error {
+	cond := mapstr.MapStr{"bk_resource": res}
+	doc := mapstr.MapStr{
+		"bk_resource": res,
+		"bk_cursor":   cursor,
+		"topic":       topic,
+		"last_time":   time.Now(),
+	}
+	// the last gap is kept until a new gap happens
+	if gap != nil {
+		doc["last_gap"] = gap
+	}
+	return e.db.Table(common.BKTableNameEventExportCheckpoint).Upsert(e.ctx, cond, doc)
+}
+
+func sleep(ctx context.Context, duration time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/Shopify/sarama"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeEventClient responds the watch requests in order and records the headers and options of the requests
+type fakeEventClient struct {
+	responses []*watch.WatchResp
+	errs      []errors.CCErrorCoder
+	headers   []http.Header
+	requests  []watch.WatchEventOptions
+}
+
+func (f *fakeEventClient) WatchEvent(_ context.Context, header http.Header, opts *watch.WatchEventOptions) (*string,
+	errors.CCErrorCoder) {
+
+	f.headers = append(f.headers, header)
+	f.requests = append(f.requests, *opts)
+	resp, err := f.responses[0], f.errs[0]
+	f.responses, f.errs = f.responses[1:], f.errs[1:]
+	if err != nil {
+		return nil, err
+	}
+
+	js, _ := json.Marshal(resp)
+	result := string(js)
+	return &result, nil
+}
+
+func (f *fakeEventClient) InnerWatchEvent(_ context.Context, _ http.Header, _ *watch.WatchEventOptions) (
+	*watch.WatchResp, errors.CCErrorCoder) {
+	return nil, errors.New(common.CCErrCommHTTPDoRequestFailed, "not implemented")
+}
+
+func encodeCursor(t *testing.T, res watch.CursorType, sec int64) string {
+	cursor := watch.Cursor{
+		Type:        res,
+		ClusterTime: types.TimeStamp{Sec: uint32(sec)},
+		Oid:         primitive.NewObjectID().Hex(),
+		Oper:        types.Insert,
+	}
+	encoded, err := cursor.Encode()
+	if err != nil {
+		t.Fatalf("encode cursor failed, err: %v", err)
+	}
+	return encoded
+}
+
+func TestExporterWatchEvents(t *testing.T) {
+	cli := &fakeEventClient{
+		responses: []*watch.WatchResp{{
+			Watched: true,
+			Events: []*watch.WatchEventDetail{
+				{Cursor: "c1", Detail: watch.JsonString(`{"bk_host_id":1,"bk_supplier_account":"0"}`)},
+				{Cursor: "c2", Detail: watch.JsonString(`{"bk_host_id":2,"bk_supplier_account":"tenant"}`)},
+			},
+		}},
+		errs: []errors.CCErrorCoder{nil},
+	}
+	e := &Exporter{ctx: context.Background(), eventCli: cli}
+
+	result, ok := e.watchEvents(watch.Host, "c0", "")
+	if !ok || len(result.Events) != 2 || result.Cursor != "c2" || result.Gap != nil {
+		t.Fatalf("watchEvents() = %+v, %v, want 2 events to c2", result, ok)
+	}
+
+	// the events of all the tenants are watched
+	if owner := httpheader.GetSupplierAccount(cli.headers[0]); owner != common.BKSuperOwnerID {
+		t.Errorf("watch supplier account = %s, want %s", owner, common.BKSuperOwnerID)
+	}
+	if cli.requests[0].Cursor != "c0" || cli.requests[0].Resource != watch.Host {
+		t.Errorf("watch request = %+v, want host events from c0", cli.requests[0])
+	}
+
+	// the expired cursor is watched from now with a gap, instead of being reset
+	lostTime := time.Now().Add(-time.Hour).Unix()
+	lostCursor := encodeCursor(t, watch.Host, lostTime)
+	cli = &fakeEventClient{
+		responses: []*watch.WatchResp{nil, nil, {Events: []*watch.WatchEventDetail{{Cursor: "latest"}}}},
+		errs: []errors.CCErrorCoder{errors.New(common.CCErrEventChainNodeNotExist, "node not exist"),
+			errors.New(common.CCErrCommParamsInvalid, "bk_start_from"), nil},
+	}
+	e.eventCli = cli
+
+	result, ok = e.watchEvents(watch.Host, lostCursor, "")
+	if !ok || len(result.Events) != 0 || result.Cursor != "latest" {
+		t.Fatalf("watchEvents() = %+v, %v, want the latest cursor", result, ok)
+	}
+	if result.Gap == nil || result.Gap.LostCursor != lostCursor || result.Gap.From != lostTime {
+		t.Errorf("watchEvents() gap = %+v, want the gap from the lost cursor", result.Gap)
+	}
+
+	// other errors are retried with the same checkpoint
+	cli = &fakeEventClient{
+		responses: []*watch.WatchResp{nil},
+		errs:      []errors.CCErrorCoder{errors.New(common.CCErrCommHTTPDoRequestFailed, "failed")},
+	}
+	e.eventCli = cli
+	if result, ok = e.watchEvents(watch.Host, "c0", ""); ok {
+		t.Errorf("watchEvents() = %+v, want failed", result)
+	}
+}
+
+func TestNewProducerMessage(t *testing.T) {
+	clusterTime := time.Now().Unix()
+	cursor := encodeCursor(t, watch.Host, clusterTime)
+	evt := &watch.WatchEventDetail{
+		Cursor:    cursor,
+		Resource:  watch.Host,
+		EventType: watch.Update,
+		Detail:    watch.JsonString(`{"bk_host_id":5,"bk_supplier_account":"tenant"}`),
+	}
+
+	msg, err := newProducerMessage("topic", event.HostKey, evt)
+	if err != nil {
+		t.Fatalf("newProducerMessage() failed, err: %v", err)
+	}
+
+	if msg.Topic != "topic" || msg.Key != sarama.StringEncoder("host:5") {
+		t.Errorf("message topic = %s, key = %v, want topic, host:5", msg.Topic, msg.Key)
+	}
+	if len(msg.Headers) != 2 || string(msg.Headers[0].Value) != cursor ||
+		string(msg.Headers[1].Value) != MessageSchemaVersion {
+		t.Errorf("message headers = %+v, want cursor and schema version", msg.Headers)
+	}
+
+	value, err := msg.Value.Encode()
+	if err != nil {
+		t.Fatalf("encode message value failed, err: %v", err)
+	}
+	got := new(Message)
+	if err := json.Unmarshal(value, got); err != nil {
+		t.Fatalf("unmarshal message value failed, err: %v", err)
+	}
+
+	// the tenant of the event is kept in the message
+	if got.SchemaVersion != MessageSchemaVersion || got.Cursor != cursor || got.Resource != watch.Host ||
+		got.EventType != watch.Update || got.ResourceID != 5 || got.SupplierAccount != "tenant" ||
+		got.ClusterTime != clusterTime || string(got.Detail) != string(evt.Detail.(watch.JsonString)) {
+		t.Errorf("message = %+v, want the event of host 5 of tenant", got)
+	}
+
+	// invalid events are not encoded
+	invalid := []*watch.WatchEventDetail{
+		{Cursor: cursor},
+		{Cursor: "invalid", Detail: watch.JsonString(`{"bk_host_id":5}`)},
+		{Cursor: cursor, Detail: watch.JsonString(`invalid`)},
+	}
+	for _, evt := range invalid {
+		if _, err := newProducerMessage("topic", event.HostKey, evt); err == nil {
+			t.Errorf("newProducerMessage() of invalid event %+v succeeded", evt)
+		}
+	}
+}
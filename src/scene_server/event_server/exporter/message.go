@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+
+	"github.com/Shopify/sarama"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// MessageSchemaVersion is the version of the exported message schema, fields can only be added to the schema
+	// in the same version.
+	MessageSchemaVersion = "v1"
+
+	// CursorHeader is the kafka message header that stores the cursor of the event, the consumer can use it to
+	// de-duplicate the events that are exported again after the exporter is restarted or the master is switched.
+	CursorHeader = "bk_cursor"
+	// SchemaVersionHeader is the kafka message header that stores the version of the message schema
+	SchemaVersionHeader = "schema_version"
+)
+
+// Message is the value of the kafka message that an exported watch event is encoded to
+type Message struct {
+	SchemaVersion string           `json:"schema_version"`
+	Cursor        string           `json:"bk_cursor"`
+	Resource      watch.CursorType `json:"bk_resource"`
+	EventType     watch.EventType  `json:"bk_event_type"`
+	// ResourceID is the id of the resource instance that the event belongs to, it is also the message key, so that
+	// the events of the same instance are sent to the same partition and keep in order.
+	ResourceID      int64           `json:"bk_resource_id"`
+	SupplierAccount string          `json:"bk_supplier_account"`
+	ClusterTime     int64           `json:"cluster_time"`
+	Detail          json.RawMessage `json:"bk_detail"`
+}
+
+// newProducerMessage encodes the watch event to a kafka producer message
+func newProducerMessage(topic string, key event.Key, e *watch.WatchEventDetail) (*sarama.ProducerMessage, error) {
+	detail, ok := e.Detail.(watch.JsonString)
+	if !ok {
+		return nil, fmt.Errorf("event %s detail type %T is invalid", e.Cursor, e.Detail)
+	}
+
+	cursor := new(watch.Cursor)
+	if err := cursor.Decode(e.Cursor); err != nil {
+		return nil, fmt.Errorf("decode event cursor %s failed, err: %v", e.Cursor, err)
+	}
+
+	doc := []byte(detail)
+	if !gjson.ValidBytes(doc) {
+		return nil, fmt.Errorf("event %s detail is not a valid json", e.Cursor)
+	}
+
+	msg := &Message{
+		SchemaVersion:   MessageSchemaVersion,
+		Cursor:          e.Cursor,
+		Resource:        e.Resource,
+		EventType:       e.EventType,
+		ResourceID:      key.InstanceID(doc),
+		SupplierAccount: key.SupplierAccount(doc),
+		ClusterTime:     int64(cursor.ClusterTime.Sec),
+		Detail:          json.RawMessage(doc),
+	}
+
+	value, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event %s message failed, err: %v", e.Cursor, err)
+	}
+
+	return &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(string(e.Resource) + ":" + strconv.FormatInt(msg.ResourceID, 10)),
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(CursorHeader), Value: []byte(e.Cursor)},
+			{Key: []byte(SchemaVersionHeader), Value: []byte(MessageSchemaVersion)},
+		},
+	}, nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"errors"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+)
+
+// defaultSMTPPort is the default port of the smtp server
+const defaultSMTPPort = 25
+
+// Config is the notification rule engine config
+type Config struct {
+	// Enabled whether to evaluate the notification rules and send the notifications
+	Enabled bool
+	// SMTP is the smtp server config of the email notifier, the email notifier is disabled if it is not set
+	SMTP *SMTPConfig
+	// Notice whether to enable the bk-notice notifier, it uses the apiGW config to send the notifications
+	Notice bool
+}
+
+// SMTPConfig is the smtp server config of the email notifier
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender address of the emails
+	From string
+	// MailDomain is appended to the user name as the email address if the recipient is not an email address
+	MailDomain string
+	// TLS whether to connect the smtp server with tls directly, otherwise STARTTLS is used if it is supported
+	TLS bool
+}
+
+// ParseConfig parse notification rule engine config
+func ParseConfig() (*Config, error) {
+	conf := new(Config)
+
+	if !cc.IsExist("eventServer.notification.enabled") {
+		return conf, nil
+	}
+
+	enabled, err := cc.Bool("eventServer.notification.enabled")
+	if err != nil {
+		blog.Errorf("get eventServer.notification.enabled error, err: %v", err)
+		return nil, err
+	}
+
+	if !enabled {
+		return conf, nil
+	}
+	conf.Enabled = true
+
+	if cc.IsExist("eventServer.notification.notice.enabled") {
+		conf.Notice, err = cc.Bool("eventServer.notification.notice.enabled")
+		if err != nil {
+			blog.Errorf("get eventServer.notification.notice.enabled error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	conf.SMTP, err = parseSMTPConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+func parseSMTPConfig() (*SMTPConfig, error) {
+	if !cc.IsExist("eventServer.notification.smtp.host") {
+		return nil, nil
+	}
+
+	host, err := cc.String("eventServer.notification.smtp.host")
+	if err != nil {
+		blog.Errorf("get eventServer.notification.smtp.host error, err: %v", err)
+		return nil, err
+	}
+
+	if len(host) == 0 {
+		return nil, nil
+	}
+
+	conf := &SMTPConfig{Host: host, Port: defaultSMTPPort}
+
+	if cc.IsExist("eventServer.notification.smtp.port") {
+		conf.Port, err = cc.Int("eventServer.notification.smtp.port")
+		if err != nil {
+			blog.Errorf("get eventServer.notification.smtp.port error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	strConf := map[string]*string{
+		"eventServer.notification.smtp.username":   &conf.Username,
+		"eventServer.notification.smtp.password":   &conf.Password,
+		"eventServer.notification.smtp.from":       &conf.From,
+		"eventServer.notification.smtp.mailDomain": &conf.MailDomain,
+	}
+	for key, val := range strConf {
+		if !cc.IsExist(key) {
+			continue
+		}
+
+		*val, err = cc.String(key)
+		if err != nil {
+			blog.Errorf("get %s error, err: %v", key, err)
+			return nil, err
+		}
+	}
+
+	if cc.IsExist("eventServer.notification.smtp.tls") {
+		conf.TLS, err = cc.Bool("eventServer.notification.smtp.tls")
+		if err != nil {
+			blog.Errorf("get eventServer.notification.smtp.tls error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("notification smtp config is invalid, err: %v", err)
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// Validate the smtp server config
+func (c *SMTPConfig) Validate() error {
+	if c.Port <= 0 || c.Port > 65535 {
+		return errors.New("eventServer.notification.smtp.port is invalid")
+	}
+
+	if len(c.From) == 0 {
+		return errors.New("eventServer.notification.smtp.from is not set")
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// smtpTimeout is the timeout of sending an email through the smtp server
+	smtpTimeout = 30 * time.Second
+	// mimeLineLength is the max line length of the base64 encoded email body
+	mimeLineLength = 76
+)
+
+// emailNotifier sends the notification by email through the smtp server
+type emailNotifier struct {
+	conf *SMTPConfig
+}
+
+func newEmailNotifier(conf *SMTPConfig) *emailNotifier {
+	return &emailNotifier{conf: conf}
+}
+
+// Notify sends the notification email to the recipients, the user name recipient is converted to email address with
+// the mail domain, and is ignored if the mail domain is not set.
+func (e *emailNotifier) Notify(ctx context.Context, msg *Message, rid string) error {
+	to := make([]string, 0, len(msg.Recipients))
+	for _, recipient := range msg.Recipients {
+		switch {
+		case isEmailAddress(recipient):
+			to = append(to, recipient)
+		case len(e.conf.MailDomain) > 0:
+			to = append(to, recipient+"@"+e.conf.MailDomain)
+		}
+	}
+
+	if len(to) == 0 {
+		return errors.New("no email address is found in the recipients")
+	}
+
+	return e.send(ctx, to, e.buildEmail(to, msg))
+}
+
+// send the email to the smtp server, the connection is closed after the email is sent.
+func (e *emailNotifier) send(ctx context.Context, to []string, body []byte) error {
+	addr := net.JoinHostPort(e.conf.Host, strconv.Itoa(e.conf.Port))
+	deadline := time.Now().Add(smtpTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if e.conf.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: e.conf.Host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect smtp server %s failed, err: %v", addr, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, e.conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !e.conf.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: e.conf.Host}); err != nil {
+				return fmt.Errorf("start tls failed, err: %v", err)
+			}
+		}
+	}
+
+	if len(e.conf.Username) > 0 {
+		auth := smtp.PlainAuth("", e.conf.Username, e.conf.Password, e.conf.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed, err: %v", err)
+		}
+	}
+
+	if err := client.Mail(e.conf.From); err != nil {
+		return err
+	}
+
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return fmt.Errorf("set recipient %s failed, err: %v", addr, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		writer.Close()
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildEmail builds the utf-8 plain text email, the body is base64 encoded.
+func (e *emailNotifier) buildEmail(to []string, msg *Message) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString("From: " + e.conf.From + "\r\n")
+	buf.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Title) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Content))
+	for len(encoded) > mimeLineLength {
+		buf.WriteString(encoded[:mimeLineLength] + "\r\n")
+		encoded = encoded[mimeLineLength:]
+	}
+	buf.WriteString(encoded + "\r\n")
+
+	return buf.Bytes()
+}
+
+func isEmailAddress(recipient string) bool {
+	return strings.Contains(recipient, "@")
+}
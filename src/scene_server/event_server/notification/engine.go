@@ -0,0 +1,317 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package notification evaluates the notification rules with the watch events, and sends the notifications of the
+// matched events to the recipients through the notifiers.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// syncInterval is the interval to sync the running workers with the notification rules in db
+	syncInterval = 10 * time.Second
+	// watchFailInterval is the interval to watch again after the watch is failed
+	watchFailInterval = 3 * time.Second
+	// maxDigestEvents is the max number of events that are aggregated into a digest, the digest is sent before the
+	// digest interval is reached if the events exceed this number.
+	maxDigestEvents = 1000
+)
+
+// Engine runs a worker for each enabled notification rule on the master event server, the worker watches the events
+// of the rule and sends the notifications of the matched events.
+type Engine struct {
+	ctx    context.Context
+	engine *backbone.Engine
+	db     dal.RDB
+	pool   *watcher.Pool
+
+	notifiers map[watch.NotifierType]Notifier
+}
+
+// ruleState is the evaluating state of a notification rule worker
+type ruleState struct {
+	// cursor is the cursor of the last watched event
+	cursor string
+	// gap is the cursor gap that is not saved yet
+	gap *watch.CursorGap
+	// events are the matched events that are waiting to be notified
+	events []*watch.WatchEventDetail
+	// digestStart is the time when the first waiting event is matched
+	digestStart time.Time
+	limiter     *rateLimiter
+}
+
+// add the watched result to the state, the events wait to be notified until the digest is ready.
+func (s *ruleState) add(result *watcher.Result, now time.Time) {
+	if len(s.events) == 0 && len(result.Events) > 0 {
+		s.digestStart = now
+	}
+	s.events = append(s.events, result.Events...)
+	s.cursor = result.Cursor
+	if result.Gap != nil {
+		s.gap = result.Gap
+	}
+}
+
+// ready returns if the waiting events need to be notified now, they are notified when the digest interval is reached
+// or the number of them exceeds the max digest events.
+func (s *ruleState) ready(now time.Time, digestInterval time.Duration) bool {
+	if len(s.events) == 0 {
+		return false
+	}
+	return now.Sub(s.digestStart) >= digestInterval || len(s.events) >= maxDigestEvents
+}
+
+// NewEngine new notification rule engine, the email and bk-notice notifiers are enabled by the config.
+func NewEngine(ctx context.Context, engine *backbone.Engine, db dal.RDB, conf *Config) (*Engine, error) {
+	notifiers := map[watch.NotifierType]Notifier{
+		watch.WebhookNotifier: newWebhookNotifier(),
+	}
+
+	if conf.SMTP != nil {
+		notifiers[watch.EmailNotifier] = newEmailNotifier(conf.SMTP)
+	}
+
+	if conf.Notice {
+		notifier, err := newNoticeNotifier()
+		if err != nil {
+			return nil, err
+		}
+		notifiers[watch.NoticeNotifier] = notifier
+	}
+
+	e := &Engine{
+		ctx:       ctx,
+		engine:    engine,
+		db:        db,
+		notifiers: notifiers,
+	}
+	e.pool = watcher.NewPool("notification rule", engine.Discovery(), syncInterval, e.listEnabled, e.runWorker)
+	return e, nil
+}
+
+// Run loops to keep the workers consistent with the enabled notification rules, only master runs the workers.
+func (e *Engine) Run() {
+	e.pool.Run(e.ctx)
+}
+
+// listEnabled lists the enabled rules, the worker is restarted when the rule is changed.
+func (e *Engine) listEnabled(ctx context.Context, _ string) (map[int64]time.Time, error) {
+	cond := mapstr.MapStr{"status": watch.NotificationRuleEnabled}
+	rules := make([]watch.NotificationRule, 0)
+	err := e.db.Table(common.BKTableNameNotificationRule).Find(cond).Fields(common.BKFieldID, "last_time").
+		All(ctx, &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make(map[int64]time.Time, len(rules))
+	for _, rule := range rules {
+		enabled[rule.ID] = rule.LastTime
+	}
+	return enabled, nil
+}
+
+// runWorker watches the events of the rule and notifies the matched events until the worker is stopped. the
+// matched events are aggregated in memory until the digest interval is reached, and the cursor is persisted only
+// when all the watched events are notified, so the events are notified at least once.
+func (e *Engine) runWorker(ctx context.Context, id int64) {
+	rule, ok := e.loadRule(ctx, id)
+	if !ok || rule.Status != watch.NotificationRuleEnabled {
+		return
+	}
+
+	state := &ruleState{
+		cursor:  rule.Cursor,
+		limiter: &rateLimiter{limit: rule.RateLimit},
+	}
+	digestInterval := time.Duration(rule.DigestInterval) * time.Second
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		rid := util.GenerateRID()
+		result, ok := e.watchEvents(ctx, rule, state.cursor, rid)
+		if !ok {
+			watcher.Sleep(ctx, watchFailInterval)
+			continue
+		}
+		state.add(result, time.Now())
+
+		if len(state.events) > 0 {
+			if !state.ready(time.Now(), digestInterval) {
+				continue
+			}
+
+			if !e.notify(ctx, rule, state, rid) {
+				// worker is stopped while notifying, the events will be notified again by the next worker
+				return
+			}
+			state.events = nil
+		}
+
+		if state.cursor == rule.Cursor && state.gap == nil {
+			continue
+		}
+
+		if err := e.saveCursor(ctx, rule, state.cursor, state.gap); err != nil {
+			blog.Errorf("save notification rule %d cursor %s failed, err: %v, rid: %s", id, state.cursor, err, rid)
+			continue
+		}
+		rule.Cursor = state.cursor
+		state.gap = nil
+	}
+}
+
+// loadRule loads the rule until it succeeds, returns false if the worker is stopped or the rule is deleted.
+func (e *Engine) loadRule(ctx context.Context, id int64) (*watch.NotificationRule, bool) {
+	for {
+		rid := util.GenerateRID()
+		rule := new(watch.NotificationRule)
+		cond := mapstr.MapStr{common.BKFieldID: id}
+		err := e.db.Table(common.BKTableNameNotificationRule).Find(cond).One(ctx, rule)
+		if err == nil {
+			return rule, true
+		}
+
+		if e.db.IsNotFoundError(err) {
+			return nil, false
+		}
+
+		blog.Errorf("get notification rule %d failed, err: %v, rid: %s", id, err, rid)
+		watcher.Sleep(ctx, watchFailInterval)
+		if ctx.Err() != nil {
+			return nil, false
+		}
+	}
+}
+
+// notify sends the notifications of the waiting events of the rule and saves their histories. returns false if the
+// worker is stopped before all are sent.
+func (e *Engine) notify(ctx context.Context, rule *watch.NotificationRule, state *ruleState, rid string) bool {
+	recipients := e.resolveRecipients(ctx, rule, state.events, rid)
+	histories, ok := e.send(ctx, rule, buildMessages(rule, state.events, recipients), state.limiter, rid)
+
+	for _, history := range histories {
+		if err := e.db.Table(common.BKTableNameNotificationHistory).Insert(ctx, history); err != nil {
+			blog.Errorf("save notification rule %d history failed, err: %v, history: %+v, rid: %s", rule.ID, err,
+				history, rid)
+		}
+	}
+
+	return ok
+}
+
+// send sends the messages through the notifiers of the rule, the messages that exceed the rate limit are suppressed.
+// returns the sending histories, so that the user can find out why a notification is not received, and false if
+// the worker is stopped before all are sent.
+func (e *Engine) send(ctx context.Context, rule *watch.NotificationRule, messages []*Message, limiter *rateLimiter,
+	rid string) ([]*watch.NotificationHistory, bool) {
+
+	histories := make([]*watch.NotificationHistory, 0)
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return histories, false
+		}
+
+		allowed, suppressed := limiter.allow(time.Now())
+		if !allowed {
+			blog.Warnf("notification rule %d exceeds rate limit %d, %d events are suppressed, rid: %s", rule.ID,
+				rule.RateLimit, len(msg.Events), rid)
+			for _, typ := range rule.Notifiers {
+				histories = append(histories, newHistory(msg, typ, watch.NotificationSuppressed, nil))
+			}
+			continue
+		}
+
+		msg.Suppressed = suppressed
+		msg.render()
+
+		for _, typ := range rule.Notifiers {
+			var err error
+			notifier, exists := e.notifiers[typ]
+			if exists {
+				err = notifier.Notify(ctx, msg, rid)
+			} else {
+				err = fmt.Errorf("notifier %s is not enabled", typ)
+			}
+
+			if ctx.Err() != nil {
+				return histories, false
+			}
+
+			status := watch.NotificationSuccess
+			if err != nil {
+				blog.Errorf("send notification rule %d message by %s failed, err: %v, rid: %s", rule.ID, typ, err, rid)
+				status = watch.NotificationFailed
+			}
+			histories = append(histories, newHistory(msg, typ, status, err))
+		}
+	}
+
+	return histories, true
+}
+
+// newHistory new the sending history of the notification
+func newHistory(msg *Message, typ watch.NotifierType, status watch.NotificationStatus,
+	err error) *watch.NotificationHistory {
+
+	history := &watch.NotificationHistory{
+		RuleID:      msg.Rule.ID,
+		Notifier:    typ,
+		Status:      status,
+		Recipients:  msg.Recipients,
+		EventCount:  len(msg.Events),
+		FirstCursor: msg.Events[0].Cursor,
+		LastCursor:  msg.Events[len(msg.Events)-1].Cursor,
+		OwnerID:     msg.Rule.OwnerID,
+		CreateTime:  time.Now(),
+	}
+	if err != nil {
+		history.Error = watcher.Truncate(err.Error())
+	}
+	return history
+}
+
+// saveCursor persists the cursor of the rule, the gap is recorded if the cursor was lost. the rule is only updated if
+// it is not changed after the worker loads it, the changed rule is evaluated by a new worker.
+func (e *Engine) saveCursor(ctx context.Context, rule *watch.NotificationRule, cursor string,
+	gap *watch.CursorGap) error {
+	cond := mapstr.MapStr{
+		common.BKFieldID: rule.ID,
+		"last_time":      rule.LastTime,
+	}
+	data := mapstr.MapStr{
+		"bk_cursor": cursor,
+	}
+	if gap != nil {
+		data["last_gap"] = gap
+	}
+	return e.db.Table(common.BKTableNameNotificationRule).Update(ctx, cond, data)
+}
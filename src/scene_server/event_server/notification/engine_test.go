@@ -0,0 +1,159 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+)
+
+// fakeNotifier records the sent messages, and fails the sending if err is set
+type fakeNotifier struct {
+	messages []*Message
+	err      error
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, msg *Message, _ string) error {
+	f.messages = append(f.messages, msg)
+	return f.err
+}
+
+func TestRuleStateDigest(t *testing.T) {
+	now := time.Now()
+	state := &ruleState{cursor: "c0"}
+	interval := time.Minute
+
+	state.add(&watcher.Result{Cursor: "c1"}, now)
+	if state.ready(now, interval) {
+		t.Errorf("state without events is ready")
+	}
+	if state.cursor != "c1" || !state.digestStart.IsZero() {
+		t.Errorf("state cursor = %s, digest start = %v, want c1 without digest", state.cursor, state.digestStart)
+	}
+
+	state.add(&watcher.Result{Events: []*watch.WatchEventDetail{{Cursor: "c2"}}, Cursor: "c2"}, now)
+	state.add(&watcher.Result{Events: []*watch.WatchEventDetail{{Cursor: "c3"}}, Cursor: "c3"},
+		now.Add(time.Second))
+	if len(state.events) != 2 || state.cursor != "c3" || !state.digestStart.Equal(now) {
+		t.Errorf("state = %+v, want 2 events to c3 digested from the first event", state)
+	}
+
+	if state.ready(now.Add(interval-time.Second), interval) {
+		t.Errorf("state is ready before the digest interval")
+	}
+	if !state.ready(now.Add(interval), interval) {
+		t.Errorf("state is not ready after the digest interval")
+	}
+	if !state.ready(now, 0) {
+		t.Errorf("state is not ready without digest interval")
+	}
+
+	state.events = make([]*watch.WatchEventDetail, maxDigestEvents)
+	if !state.ready(now, interval) {
+		t.Errorf("state is not ready when events exceed max digest events")
+	}
+
+	gap := &watch.CursorGap{LostCursor: "c3"}
+	state.add(&watcher.Result{Cursor: "c4", Gap: gap}, now)
+	state.add(&watcher.Result{Cursor: "c5"}, now)
+	if state.gap != gap {
+		t.Errorf("state gap = %+v, want the gap kept until it is saved", state.gap)
+	}
+}
+
+func TestEngineSend(t *testing.T) {
+	webhook := new(fakeNotifier)
+	email := &fakeNotifier{err: errors.New("smtp failed")}
+	e := &Engine{notifiers: map[watch.NotifierType]Notifier{
+		watch.WebhookNotifier: webhook,
+		watch.EmailNotifier:   email,
+	}}
+
+	rule := &watch.NotificationRule{
+		ID:         1,
+		Name:       "host changes",
+		Resource:   watch.Host,
+		Notifiers:  []watch.NotifierType{watch.WebhookNotifier, watch.EmailNotifier, watch.NoticeNotifier},
+		Recipients: watch.NotificationRecipients{Users: []string{"admin"}, InstanceFields: []string{"operator"}},
+		RateLimit:  1,
+	}
+	events := []*watch.WatchEventDetail{
+		{Cursor: "c1", Detail: watch.JsonString(`{"bk_host_id":1,"operator":"user1"}`)},
+		{Cursor: "c2", Detail: watch.JsonString(`{"bk_host_id":2,"operator":"user2"}`)},
+		{Cursor: "c3", Detail: watch.JsonString(`{"bk_host_id":3,"operator":"user1"}`)},
+	}
+
+	recipients := e.resolveRecipients(context.Background(), rule, events, "")
+	wantRecipients := [][]string{{"admin", "user1"}, {"admin", "user2"}, {"admin", "user1"}}
+	if !reflect.DeepEqual(recipients, wantRecipients) {
+		t.Fatalf("resolveRecipients() = %v, want %v", recipients, wantRecipients)
+	}
+
+	messages := buildMessages(rule, events, recipients)
+	if len(messages) != 2 || len(messages[0].Events) != 2 || len(messages[1].Events) != 1 {
+		t.Fatalf("buildMessages() = %+v, want events grouped by recipients", messages)
+	}
+
+	limiter := &rateLimiter{limit: rule.RateLimit}
+	histories, ok := e.send(context.Background(), rule, messages, limiter, "")
+	if !ok {
+		t.Fatalf("send() is not finished")
+	}
+
+	// the first message is sent by all notifiers, the second one is suppressed by the rate limit
+	wantStatus := []watch.NotificationStatus{watch.NotificationSuccess, watch.NotificationFailed,
+		watch.NotificationFailed, watch.NotificationSuppressed, watch.NotificationSuppressed,
+		watch.NotificationSuppressed}
+	if len(histories) != len(wantStatus) {
+		t.Fatalf("send() histories = %d, want %d", len(histories), len(wantStatus))
+	}
+	for idx, history := range histories {
+		if history.Status != wantStatus[idx] || history.Notifier != rule.Notifiers[idx%len(rule.Notifiers)] {
+			t.Errorf("history %d = %s by %s, want %s", idx, history.Status, history.Notifier, wantStatus[idx])
+		}
+	}
+
+	if histories[0].EventCount != 2 || histories[0].FirstCursor != "c1" || histories[0].LastCursor != "c3" {
+		t.Errorf("history = %+v, want the events of the first message", histories[0])
+	}
+	if histories[1].Error != "smtp failed" || histories[2].Error == "" {
+		t.Errorf("failed histories do not record the errors, %+v, %+v", histories[1], histories[2])
+	}
+
+	if len(webhook.messages) != 1 || webhook.messages[0].Title == "" || len(email.messages) != 1 {
+		t.Errorf("notifiers received %d, %d messages, want 1 rendered message", len(webhook.messages),
+			len(email.messages))
+	}
+
+	// the suppressed count is carried into the next allowed message
+	limiter.windowStart = time.Now().Add(-time.Hour)
+	if _, ok := e.send(context.Background(), rule, messages[:1], limiter, ""); !ok {
+		t.Fatalf("send() is not finished")
+	}
+	if webhook.messages[1].Suppressed != 1 {
+		t.Errorf("message suppressed = %d, want 1", webhook.messages[1].Suppressed)
+	}
+
+	// stopped worker does not send any more
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if histories, ok := e.send(ctx, rule, messages, limiter, ""); ok || len(histories) != 0 {
+		t.Errorf("send() with canceled context = %d histories, %v, want not finished", len(histories), ok)
+	}
+}
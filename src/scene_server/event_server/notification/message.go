@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/watch"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxContentEvents is the max number of events that are listed in the message content, the webhook payload still
+// contains all the events.
+const maxContentEvents = 50
+
+var (
+	// nameFields are the fields of the event detail that are used as the instance name in the message, the first
+	// existing one is used.
+	nameFields = []string{common.BKInstNameField, common.BKHostInnerIPField, common.BKAppNameField,
+		common.BKSetNameField, common.BKModuleNameField, common.BKObjNameField, common.BKPropertyNameField,
+		common.BKProcessNameField, common.BKFieldName}
+	// idFields are the fields of the event detail that are used as the instance id in the message, the first
+	// existing one is used.
+	idFields = []string{common.BKInstIDField, common.BKHostIDField, common.BKAppIDField, common.BKSetIDField,
+		common.BKModuleIDField, common.BKProcessIDField, common.BKFieldID}
+)
+
+// buildMessages groups the events by their recipients, and builds a message for each group, so that the users only
+// receive the events that they are related to.
+func buildMessages(rule *watch.NotificationRule, events []*watch.WatchEventDetail,
+	recipients [][]string) []*Message {
+
+	messages := make([]*Message, 0)
+	groupMsg := make(map[string]*Message)
+	for idx, event := range events {
+		key := strings.Join(recipients[idx], ",")
+		msg, exists := groupMsg[key]
+		if !exists {
+			msg = &Message{Rule: rule, Recipients: recipients[idx]}
+			groupMsg[key] = msg
+			messages = append(messages, msg)
+		}
+		msg.Events = append(msg.Events, event)
+	}
+
+	return messages
+}
+
+// render the title and content of the message
+func (m *Message) render() {
+	m.Title = fmt.Sprintf("[bk-cmdb] %s: %d %s event(s)", m.Rule.Name, len(m.Events), m.Rule.Resource)
+
+	lines := make([]string, 0)
+	if m.Suppressed > 0 {
+		lines = append(lines, fmt.Sprintf("%d notification(s) are suppressed by the rate limit before this one.",
+			m.Suppressed))
+	}
+
+	for idx, event := range m.Events {
+		if idx >= maxContentEvents {
+			lines = append(lines, fmt.Sprintf("... and %d more event(s)", len(m.Events)-maxContentEvents))
+			break
+		}
+		lines = append(lines, renderEvent(event))
+	}
+
+	m.Content = strings.Join(lines, "\n")
+}
+
+// renderEvent renders the event as "{time} {event type} {resource} {name}(id: {id})"
+func renderEvent(event *watch.WatchEventDetail) string {
+	eventTime := "-"
+	cursor := new(watch.Cursor)
+	if err := cursor.Decode(event.Cursor); err == nil {
+		eventTime = time.Unix(int64(cursor.ClusterTime.Sec), 0).Format(common.TimeTransferModel)
+	}
+
+	detail := eventDetail(event)
+	name, id := "", ""
+	for _, field := range nameFields {
+		if val := gjson.Get(detail, field); val.Exists() {
+			name = val.String()
+			break
+		}
+	}
+	for _, field := range idFields {
+		if val := gjson.Get(detail, field); val.Exists() {
+			id = fmt.Sprintf("%s: %s", field, val.String())
+			break
+		}
+	}
+
+	line := fmt.Sprintf("%s %s %s", eventTime, event.EventType, event.Resource)
+	if len(name) > 0 {
+		line += " " + name
+	}
+	if len(id) > 0 {
+		line += "(" + id + ")"
+	}
+	return line
+}
+
+// rateLimiter limits the number of notifications of a rule in an hour with a fixed window
+type rateLimiter struct {
+	limit       int
+	windowStart time.Time
+	count       int
+	// suppressed is the number of notifications that are dropped since the last allowed one
+	suppressed int
+}
+
+// allow returns if the notification is allowed to send and the number of the suppressed notifications before it
+func (r *rateLimiter) allow(now time.Time) (bool, int) {
+	if now.Sub(r.windowStart) >= time.Hour {
+		r.windowStart = now
+		r.count = 0
+	}
+
+	if r.count >= r.limit {
+		r.suppressed++
+		return false, 0
+	}
+
+	r.count++
+	suppressed := r.suppressed
+	r.suppressed = 0
+	return true, suppressed
+}
@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"configcenter/src/common"
+	headerutil "configcenter/src/common/http/header/util"
+	apigwcli "configcenter/src/common/resource/apigw"
+	"configcenter/src/thirdparty/apigw/notice"
+)
+
+// noticeNotifier sends the notification to the users through the bk-notice api gateway
+type noticeNotifier struct {
+	client notice.ClientI
+}
+
+func newNoticeNotifier() (*noticeNotifier, error) {
+	if apigwcli.Client() == nil || apigwcli.Client().Notice() == nil {
+		return nil, errors.New("bk-notice api gateway client is not initialized")
+	}
+	return &noticeNotifier{client: apigwcli.Client().Notice()}, nil
+}
+
+// Notify sends the notification to the recipients, the email addresses are not supported by bk-notice and ignored
+func (n *noticeNotifier) Notify(ctx context.Context, msg *Message, rid string) error {
+	receivers := make([]string, 0, len(msg.Recipients))
+	for _, recipient := range msg.Recipients {
+		if isEmailAddress(recipient) {
+			continue
+		}
+		receivers = append(receivers, recipient)
+	}
+
+	if len(receivers) == 0 {
+		return errors.New("no user is found in the recipients")
+	}
+
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, msg.Rule.OwnerID, rid)
+	req := &notice.SendMsgReq{
+		Title:     msg.Title,
+		Content:   msg.Content,
+		Receivers: receivers,
+	}
+	return n.client.SendMsg(ctx, header, req)
+}
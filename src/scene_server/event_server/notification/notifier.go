@@ -0,0 +1,35 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+
+	"configcenter/src/common/watch"
+)
+
+// Message is a notification of the matched events of a rule
+type Message struct {
+	Rule       *watch.NotificationRule
+	Title      string
+	Content    string
+	Recipients []string
+	Events     []*watch.WatchEventDetail
+	// Suppressed is the number of notifications that are dropped by the rate limit before this one
+	Suppressed int
+}
+
+// Notifier sends the notification message through a channel
+type Notifier interface {
+	Notify(ctx context.Context, msg *Message, rid string) error
+}
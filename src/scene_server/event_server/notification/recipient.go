@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+
+	"github.com/tidwall/gjson"
+)
+
+// resolveRecipients resolves the recipients of each event, returns the sorted recipients in the order of events.
+// role members are got from the business that the event belongs to, and the instance field users are got from the
+// event detail.
+func (e *Engine) resolveRecipients(ctx context.Context, rule *watch.NotificationRule,
+	events []*watch.WatchEventDetail, rid string) [][]string {
+
+	bizRoles := e.getBizRoles(ctx, rule, events, rid)
+
+	result := make([][]string, len(events))
+	for idx, event := range events {
+		detail := eventDetail(event)
+		users := make([]string, 0)
+		exists := make(map[string]struct{})
+		add := func(vals ...string) {
+			for _, val := range vals {
+				if _, ok := exists[val]; ok || len(val) == 0 {
+					continue
+				}
+				exists[val] = struct{}{}
+				users = append(users, val)
+			}
+		}
+
+		add(rule.Recipients.Users...)
+
+		for _, role := range rule.Recipients.Roles {
+			if rule.Resource == watch.Biz {
+				add(splitUsers(gjson.Get(detail, role).String())...)
+				continue
+			}
+
+			bizID := gjson.Get(detail, common.BKAppIDField).Int()
+			if biz, ok := bizRoles[bizID]; ok {
+				add(splitUsers(util.GetStrByInterface(biz[role]))...)
+			}
+		}
+
+		for _, field := range rule.Recipients.InstanceFields {
+			add(splitUsers(gjson.Get(detail, field).String())...)
+		}
+
+		sort.Strings(users)
+		result[idx] = users
+	}
+
+	return result
+}
+
+// getBizRoles get the role fields of the businesses that the events belong to, returns map of biz id to role fields.
+func (e *Engine) getBizRoles(ctx context.Context, rule *watch.NotificationRule, events []*watch.WatchEventDetail,
+	rid string) map[int64]mapstr.MapStr {
+
+	bizRoles := make(map[int64]mapstr.MapStr)
+	if len(rule.Recipients.Roles) == 0 || rule.Resource == watch.Biz {
+		return bizRoles
+	}
+
+	bizIDs := make([]int64, 0)
+	for _, event := range events {
+		bizID := gjson.Get(eventDetail(event), common.BKAppIDField).Int()
+		if bizID == 0 {
+			continue
+		}
+		if _, exists := bizRoles[bizID]; exists {
+			continue
+		}
+		bizRoles[bizID] = nil
+		bizIDs = append(bizIDs, bizID)
+	}
+
+	if len(bizIDs) == 0 {
+		return bizRoles
+	}
+
+	cond := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: bizIDs}},
+		Fields:         append([]string{common.BKAppIDField}, rule.Recipients.Roles...),
+		Page:           metadata.BasePage{Limit: len(bizIDs)},
+		DisableCounter: true,
+	}
+
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, rule.OwnerID, rid)
+	result, err := e.engine.CoreAPI.CoreService().Instance().ReadInstance(ctx, header, common.BKInnerObjIDApp, cond)
+	if err != nil {
+		blog.Errorf("get notification rule %d business roles failed, err: %v, biz ids: %v, rid: %s", rule.ID, err,
+			bizIDs, rid)
+		return bizRoles
+	}
+
+	for _, biz := range result.Info {
+		bizID, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil {
+			blog.Errorf("parse business id failed, err: %v, biz: %+v, rid: %s", err, biz, rid)
+			continue
+		}
+		bizRoles[bizID] = biz
+	}
+
+	return bizRoles
+}
+
+// splitUsers splits the value of the user type field, the users are separated by comma
+func splitUsers(val string) []string {
+	users := make([]string, 0)
+	for _, user := range strings.Split(val, ",") {
+		user = strings.TrimSpace(user)
+		if len(user) > 0 {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func eventDetail(event *watch.WatchEventDetail) string {
+	detail, ok := event.Detail.(watch.JsonString)
+	if !ok {
+		return ""
+	}
+	return string(detail)
+}
@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+)
+
+// watchEvents watches the events of the rule from the cursor, returns the matched events, the cursor of the last
+// watched event and the gap to be recorded if the cursor is lost.
+func (e *Engine) watchEvents(ctx context.Context, rule *watch.NotificationRule, cursor string,
+	rid string) (*watcher.Result, bool) {
+
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, rule.OwnerID, rid)
+	opts := rule.WatchOptions()
+	opts.Cursor = cursor
+
+	result, err := watcher.WatchWithCursor(ctx, e.engine.CoreAPI.CacheService().Cache().Event(), header, opts)
+	if err != nil {
+		blog.Errorf("watch notification rule %d events failed, err: %v, rid: %s", rule.ID, err, rid)
+		return nil, false
+	}
+
+	if result.Gap != nil {
+		blog.Errorf("notification rule %d cursor %s is lost, the events from %d to %d are not evaluated, rid: %s",
+			rule.ID, cursor, result.Gap.From, result.Gap.To, rid)
+	}
+
+	return result, true
+}
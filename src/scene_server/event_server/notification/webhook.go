@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+)
+
+// webhookTimeout is the timeout of posting the notification to the webhook
+const webhookTimeout = 10 * time.Second
+
+// webhookNotifier posts the notification to the webhook url of the rule
+type webhookNotifier struct {
+	client *http.Client
+}
+
+func newWebhookNotifier() *webhookNotifier {
+	return &webhookNotifier{client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Notify posts the notification payload to the webhook, the payload is signed with the secret of the rule
+func (w *webhookNotifier) Notify(ctx context.Context, msg *Message, rid string) error {
+	payload := &watch.NotificationPayload{
+		RuleID:     msg.Rule.ID,
+		RuleName:   msg.Rule.Name,
+		Title:      msg.Title,
+		Content:    msg.Content,
+		Recipients: msg.Recipients,
+		Suppressed: msg.Suppressed,
+		Resource:   msg.Rule.Resource,
+		Events:     msg.Events,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal notification payload failed, err: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set(watch.NotificationRuleIDHeader, strconv.FormatInt(msg.Rule.ID, 10))
+	_, err = watcher.PostSigned(ctx, w.client, msg.Rule.WebhookURL, header, msg.Rule.Secret, body)
+	return err
+}
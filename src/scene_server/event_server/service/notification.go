@@ -0,0 +1,380 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+)
+
+// CreateNotificationRule create a notification rule that sends messages when the watch events are matched
+func (s *Service) CreateNotificationRule(ctx *rest.Contexts) {
+	rule := new(watch.NotificationRule)
+	if err := ctx.DecodeInto(rule); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		blog.Errorf("notification rule is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	if authResp, authorized := s.authorizeNotificationRule(ctx.Kit, rule); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	if err := s.checkNotificationRuleName(ctx.Kit, rule.Name, 0); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.db.NextSequence(ctx.Kit.Ctx, common.BKTableNameNotificationRule)
+	if err != nil {
+		blog.Errorf("generate notification rule id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	now := time.Now()
+	rule.ID = int64(id)
+	rule.Status = watch.NotificationRuleEnabled
+	rule.Cursor = ""
+	rule.LastGap = nil
+	rule.OwnerID = ctx.Kit.SupplierAccount
+	rule.Creator = ctx.Kit.User
+	rule.Modifier = ctx.Kit.User
+	rule.CreateTime = now
+	rule.LastTime = now
+
+	if err := s.db.Table(common.BKTableNameNotificationRule).Insert(ctx.Kit.Ctx, rule); err != nil {
+		blog.Errorf("create notification rule failed, err: %v, data: %+v, rid: %s", err, rule, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	ctx.RespEntity(metadata.RspID{ID: rule.ID})
+}
+
+// UpdateNotificationRule update the notification rule, the cursor is kept so that no event is missed
+func (s *Service) UpdateNotificationRule(ctx *rest.Contexts) {
+	rule, err := s.getNotificationRuleByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	data := make(mapstr.MapStr)
+	if err := ctx.DecodeInto(&data); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	updateData := make(mapstr.MapStr)
+	for _, field := range watch.NotificationRuleUpdateFields {
+		if val, exists := data[field]; exists {
+			updateData[field] = val
+		}
+	}
+
+	if len(updateData) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "data"))
+		return
+	}
+
+	// merge the update data with the rule to validate it, resource is not allowed to change. the filter and the
+	// recipients are replaced as a whole, so they are reset before the update data is decoded into the rule.
+	if _, exists := updateData["bk_filter"]; exists {
+		rule.Filter = watch.SubscriptionFilter{}
+	}
+	if _, exists := updateData["recipients"]; exists {
+		rule.Recipients = watch.NotificationRecipients{}
+	}
+	if err := updateData.MarshalJSONInto(rule); err != nil {
+		blog.Errorf("decode notification rule update data failed, err: %v, data: %+v, rid: %s", err, updateData,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed))
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		blog.Errorf("notification rule is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	// sub resource may be changed, so authorize with the updated rule
+	if authResp, authorized := s.authorizeNotificationRule(ctx.Kit, rule); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	if err := s.checkNotificationRuleName(ctx.Kit, rule.Name, rule.ID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	doc := mapstr.MapStr{
+		"name":            rule.Name,
+		"bk_event_types":  rule.EventTypes,
+		"bk_filter":       rule.Filter,
+		"notifiers":       rule.Notifiers,
+		"recipients":      rule.Recipients,
+		"webhook_url":     rule.WebhookURL,
+		"secret":          rule.Secret,
+		"rate_limit":      rule.RateLimit,
+		"digest_interval": rule.DigestInterval,
+	}
+	if err := s.updateNotificationRule(ctx.Kit, rule.ID, doc); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteNotificationRule delete the notification rule, its sending history is expired by ttl
+func (s *Service) DeleteNotificationRule(ctx *rest.Contexts) {
+	rule, err := s.getNotificationRuleByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if authResp, authorized := s.authorizeNotificationRule(ctx.Kit, rule); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: rule.ID}
+	if err := s.db.Table(common.BKTableNameNotificationRule).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete notification rule %d failed, err: %v, rid: %s", rule.ID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchNotificationRule search the notification rules, the webhook secrets are not returned
+func (s *Service) SearchNotificationRule(ctx *rest.Contexts) {
+	opt := new(watch.SearchNotificationRuleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Page.ValidateLimit(common.BKMaxPageSize); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "page.limit"))
+		return
+	}
+
+	cond := mapstr.MapStr{}
+	if len(opt.Resource) > 0 {
+		cond["bk_resource"] = opt.Resource
+	}
+	if len(opt.Status) > 0 {
+		cond["status"] = opt.Status
+	}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	table := s.db.Table(common.BKTableNameNotificationRule)
+	count, err := table.Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count notification rules failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	rules := make([]watch.NotificationRule, 0)
+	sort := opt.Page.Sort
+	if len(sort) == 0 {
+		sort = common.BKFieldID
+	}
+	err = table.Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).
+		All(ctx.Kit.Ctx, &rules)
+	if err != nil {
+		blog.Errorf("search notification rules failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	for idx := range rules {
+		rules[idx].Secret = ""
+	}
+
+	ctx.RespEntity(watch.SearchNotificationRuleResult{Count: int64(count), Info: rules})
+}
+
+// EnableNotificationRule enable the notification rule, it is evaluated from the kept cursor
+func (s *Service) EnableNotificationRule(ctx *rest.Contexts) {
+	s.setNotificationRuleStatus(ctx, watch.NotificationRuleEnabled)
+}
+
+// DisableNotificationRule disable the notification rule, the cursor is kept
+func (s *Service) DisableNotificationRule(ctx *rest.Contexts) {
+	s.setNotificationRuleStatus(ctx, watch.NotificationRuleDisabled)
+}
+
+func (s *Service) setNotificationRuleStatus(ctx *rest.Contexts, status watch.NotificationRuleStatus) {
+	rule, err := s.getNotificationRuleByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if authResp, authorized := s.authorizeNotificationRule(ctx.Kit, rule); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	if rule.Status == status {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	if err := s.updateNotificationRule(ctx.Kit, rule.ID, mapstr.MapStr{"status": status}); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchNotificationHistory search the sending history of the notification rule
+func (s *Service) SearchNotificationHistory(ctx *rest.Contexts) {
+	opt := new(watch.SearchNotificationHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Page.ValidateLimit(common.BKMaxPageSize); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "page.limit"))
+		return
+	}
+
+	rule, err := s.getNotificationRuleByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if authResp, authorized := s.authorizeNotificationRule(ctx.Kit, rule); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	cond := mapstr.MapStr{"rule_id": rule.ID}
+	if len(opt.Notifier) > 0 {
+		cond["notifier"] = opt.Notifier
+	}
+	if len(opt.Status) > 0 {
+		cond["status"] = opt.Status
+	}
+
+	table := s.db.Table(common.BKTableNameNotificationHistory)
+	count, dbErr := table.Find(cond).Count(ctx.Kit.Ctx)
+	if dbErr != nil {
+		blog.Errorf("count notification history failed, err: %v, cond: %+v, rid: %s", dbErr, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	history := make([]watch.NotificationHistory, 0)
+	dbErr = table.Find(cond).Sort("-"+common.CreateTimeField).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(ctx.Kit.Ctx, &history)
+	if dbErr != nil {
+		blog.Errorf("search notification history failed, err: %v, cond: %+v, rid: %s", dbErr, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(watch.SearchNotificationHistoryResult{Count: int64(count), Info: history})
+}
+
+// getNotificationRuleByPath get the notification rule by the id in the request path
+func (s *Service) getNotificationRuleByPath(ctx *rest.Contexts) (*watch.NotificationRule, errors.CCErrorCoder) {
+	idStr := ctx.Request.PathParameter(common.BKFieldID)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("notification rule id %s is invalid, err: %v, rid: %s", idStr, err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	rule := new(watch.NotificationRule)
+	if err := s.db.Table(common.BKTableNameNotificationRule).Find(cond).One(ctx.Kit.Ctx, rule); err != nil {
+		if s.db.IsNotFoundError(err) {
+			return nil, ctx.Kit.CCError.CCErrorf(common.CCErrEventNotificationRuleNotExist, idStr)
+		}
+		blog.Errorf("get notification rule %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return rule, nil
+}
+
+// checkNotificationRuleName check if the rule name is duplicated with other notification rules
+func (s *Service) checkNotificationRuleName(kit *rest.Kit, name string, id int64) errors.CCErrorCoder {
+	cond := mapstr.MapStr{common.BKFieldName: name}
+	if id != 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBNE: id}
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	count, err := s.db.Table(common.BKTableNameNotificationRule).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count notification rules failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count > 0 {
+		return kit.CCError.CCErrorf(common.CCErrEventNotificationRuleNameDuplicated, name)
+	}
+	return nil
+}
+
+// updateNotificationRule update the rule, the last time is changed so that the engine restarts its worker
+func (s *Service) updateNotificationRule(kit *rest.Kit, id int64, doc mapstr.MapStr) errors.CCErrorCoder {
+	doc["modifier"] = kit.User
+	doc["last_time"] = time.Now()
+
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	if err := s.db.Table(common.BKTableNameNotificationRule).Update(kit.Ctx, cond, doc); err != nil {
+		blog.Errorf("update notification rule %d failed, err: %v, data: %+v, rid: %s", id, err, doc, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// authorizeNotificationRule authorize the watch permission of the rule resource, because the notifications contain
+// the event details of the resource.
+func (s *Service) authorizeNotificationRule(kit *rest.Kit, rule *watch.NotificationRule) (*metadata.BaseResp,
+	bool) {
+
+	return s.authorizeWatch(kit, rule.Resource, rule.Filter.SubResource)
+}
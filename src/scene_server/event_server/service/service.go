@@ -28,6 +28,7 @@ import (
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/common/webservice/restfulservice"
+	"configcenter/src/scene_server/event_server/stream"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/redis"
@@ -48,11 +49,14 @@ type Service struct {
 
 	// SyncData is sync host identifier operator
 	SyncData *hostidentifier.HostIdentifier
+
+	// streamer streams the watch events through server-sent events or websocket
+	streamer *stream.Streamer
 }
 
 // NewService creates a new Service object.
 func NewService(ctx context.Context, engine *backbone.Engine) *Service {
-	return &Service{ctx: ctx, engine: engine}
+	return &Service{ctx: ctx, engine: engine, streamer: stream.NewStreamer(engine)}
 }
 
 // SetDB setups database.
@@ -107,11 +111,50 @@ func (s *Service) initService(web *restful.WebService) {
 	})
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/watch/resource/{resource}", Handler: s.WatchEvent})
+	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/watch/stream/resource/{resource}",
+		Handler: s.WatchEventStream})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/watch/stream/resource/{resource}",
+		Handler: s.WatchEventStream})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/sync/host_identifier", Handler: s.SyncHostIdentifier})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/push/host_identifier", Handler: s.PushHostIdentifier})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host_identifier_push_result",
 		Handler: s.GetHostIdentifierPushResult})
 
+	// event webhook subscription apis
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/subscription", Handler: s.CreateSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/subscription/{id}",
+		Handler: s.UpdateSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/subscription/{id}",
+		Handler: s.DeleteSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/subscription",
+		Handler: s.SearchSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/pause/subscription/{id}",
+		Handler: s.PauseSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/resume/subscription/{id}",
+		Handler: s.ResumeSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/replay/subscription/{id}",
+		Handler: s.ReplaySubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/subscription/{id}/delivery_history",
+		Handler: s.SearchDeliveryHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/subscription/{id}/dead_letter",
+		Handler: s.SearchDeadLetter})
+
+	// event notification rule apis
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/notification_rule",
+		Handler: s.CreateNotificationRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/notification_rule/{id}",
+		Handler: s.UpdateNotificationRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/notification_rule/{id}",
+		Handler: s.DeleteNotificationRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/notification_rule",
+		Handler: s.SearchNotificationRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/enable/notification_rule/{id}",
+		Handler: s.EnableNotificationRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/disable/notification_rule/{id}",
+		Handler: s.DisableNotificationRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/notification_rule/{id}/history",
+		Handler: s.SearchNotificationHistory})
+
 	utility.AddToRestfulWebService(web)
 
 }
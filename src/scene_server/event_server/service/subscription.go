@@ -0,0 +1,506 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+)
+
+// CreateSubscription create a webhook subscription that pushes the watch events to the callback
+func (s *Service) CreateSubscription(ctx *rest.Contexts) {
+	sub := new(watch.Subscription)
+	if err := ctx.DecodeInto(sub); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := sub.Validate(); err != nil {
+		blog.Errorf("subscription is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	if authResp, authorized := s.authorizeSubscription(ctx.Kit, sub); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	if err := s.checkSubscriptionName(ctx.Kit, sub.Name, 0); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.db.NextSequence(ctx.Kit.Ctx, common.BKTableNameEventSubscription)
+	if err != nil {
+		blog.Errorf("generate subscription id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrEventSubscribeInsertFailed))
+		return
+	}
+
+	now := time.Now()
+	sub.ID = int64(id)
+	sub.Status = watch.SubscriptionActive
+	sub.Cursor = ""
+	sub.StartFrom = 0
+	sub.LastGap = nil
+	sub.OwnerID = ctx.Kit.SupplierAccount
+	sub.Creator = ctx.Kit.User
+	sub.Modifier = ctx.Kit.User
+	sub.CreateTime = now
+	sub.LastTime = now
+
+	if err := s.db.Table(common.BKTableNameEventSubscription).Insert(ctx.Kit.Ctx, sub); err != nil {
+		blog.Errorf("create subscription failed, err: %v, data: %+v, rid: %s", err, sub, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrEventSubscribeInsertFailed))
+		return
+	}
+
+	ctx.RespEntity(metadata.RspID{ID: sub.ID})
+}
+
+// UpdateSubscription update the webhook subscription, the cursor is kept so that no event is lost
+func (s *Service) UpdateSubscription(ctx *rest.Contexts) {
+	sub, err := s.getSubscriptionByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	data := make(mapstr.MapStr)
+	if err := ctx.DecodeInto(&data); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	updateData := make(mapstr.MapStr)
+	for _, field := range watch.SubscriptionUpdateFields {
+		if val, exists := data[field]; exists {
+			updateData[field] = val
+		}
+	}
+
+	if len(updateData) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "data"))
+		return
+	}
+
+	// merge the update data with the subscription to validate it, resource is not allowed to change. filter is
+	// replaced as a whole, so it is reset before the update data is decoded into the subscription.
+	if _, exists := updateData["bk_filter"]; exists {
+		sub.Filter = watch.SubscriptionFilter{}
+	}
+	if err := updateData.MarshalJSONInto(sub); err != nil {
+		blog.Errorf("decode subscription update data failed, err: %v, data: %+v, rid: %s", err, updateData,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed))
+		return
+	}
+
+	if err := sub.Validate(); err != nil {
+		blog.Errorf("subscription is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	// sub resource may be changed, so authorize with the updated subscription
+	if authResp, authorized := s.authorizeSubscription(ctx.Kit, sub); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	if err := s.checkSubscriptionName(ctx.Kit, sub.Name, sub.ID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	doc := mapstr.MapStr{
+		"name":           sub.Name,
+		"bk_event_types": sub.EventTypes,
+		"bk_fields":      sub.Fields,
+		"bk_filter":      sub.Filter,
+		"callback_url":   sub.CallbackURL,
+		"secret":         sub.Secret,
+		"timeout":        sub.Timeout,
+		"max_retry":      sub.MaxRetry,
+	}
+	if err := s.updateSubscription(ctx.Kit, sub.ID, doc); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteSubscription delete the webhook subscription, its delivery history and dead letters are expired by ttl
+func (s *Service) DeleteSubscription(ctx *rest.Contexts) {
+	sub, err := s.getSubscriptionByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if authResp, authorized := s.authorizeSubscription(ctx.Kit, sub); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: sub.ID}
+	if err := s.db.Table(common.BKTableNameEventSubscription).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete subscription %d failed, err: %v, rid: %s", sub.ID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrEventSubscribeDeleteFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchSubscription search the webhook subscriptions, the secrets are not returned
+func (s *Service) SearchSubscription(ctx *rest.Contexts) {
+	opt := new(watch.SearchSubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Page.ValidateLimit(common.BKMaxPageSize); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "page.limit"))
+		return
+	}
+
+	cond := mapstr.MapStr{}
+	if len(opt.Resource) > 0 {
+		cond["bk_resource"] = opt.Resource
+	}
+	if len(opt.Status) > 0 {
+		cond["status"] = opt.Status
+	}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	table := s.db.Table(common.BKTableNameEventSubscription)
+	count, err := table.Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count subscriptions failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrEventSubscribeSelectFailed))
+		return
+	}
+
+	subs := make([]watch.Subscription, 0)
+	sort := opt.Page.Sort
+	if len(sort) == 0 {
+		sort = common.BKFieldID
+	}
+	err = table.Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).
+		All(ctx.Kit.Ctx, &subs)
+	if err != nil {
+		blog.Errorf("search subscriptions failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrEventSubscribeSelectFailed))
+		return
+	}
+
+	for idx := range subs {
+		subs[idx].Secret = ""
+	}
+
+	ctx.RespEntity(watch.SearchSubscriptionResult{Count: int64(count), Info: subs})
+}
+
+// PauseSubscription pause pushing the events of the webhook subscription, the cursor is kept
+func (s *Service) PauseSubscription(ctx *rest.Contexts) {
+	s.setSubscriptionStatus(ctx, watch.SubscriptionPaused)
+}
+
+// ResumeSubscription resume pushing the events of the webhook subscription from the kept cursor
+func (s *Service) ResumeSubscription(ctx *rest.Contexts) {
+	s.setSubscriptionStatus(ctx, watch.SubscriptionActive)
+}
+
+func (s *Service) setSubscriptionStatus(ctx *rest.Contexts, status watch.SubscriptionStatus) {
+	sub, err := s.getSubscriptionByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if authResp, authorized := s.authorizeSubscription(ctx.Kit, sub); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	if sub.Status == status {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	if err := s.updateSubscription(ctx.Kit, sub.ID, mapstr.MapStr{"status": status}); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ReplaySubscription replay the events of the webhook subscription from the specified time
+func (s *Service) ReplaySubscription(ctx *rest.Contexts) {
+	opt := new(watch.ReplaySubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	sub, err := s.getSubscriptionByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if authResp, authorized := s.authorizeSubscription(ctx.Kit, sub); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	// clear the cursor so that the pusher watches from the start time
+	doc := mapstr.MapStr{
+		"bk_cursor":     "",
+		"bk_start_from": opt.StartFrom,
+	}
+	if err := s.updateSubscription(ctx.Kit, sub.ID, doc); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchDeliveryHistory search the delivery history of the webhook subscription
+func (s *Service) SearchDeliveryHistory(ctx *rest.Contexts) {
+	opt, cond, ok := s.parseSearchDeliveryOption(ctx)
+	if !ok {
+		return
+	}
+
+	table := s.db.Table(common.BKTableNameEventDeliveryHistory)
+	count, err := table.Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count delivery history failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	history := make([]watch.DeliveryHistory, 0)
+	err = table.Find(cond).Sort("-"+common.CreateTimeField).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(ctx.Kit.Ctx, &history)
+	if err != nil {
+		blog.Errorf("search delivery history failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(watch.SearchDeliveryHistoryResult{Count: int64(count), Info: history})
+}
+
+// SearchDeadLetter search the payloads of the webhook subscription that are failed to deliver
+func (s *Service) SearchDeadLetter(ctx *rest.Contexts) {
+	opt, cond, ok := s.parseSearchDeliveryOption(ctx)
+	if !ok {
+		return
+	}
+	// dead letters are all failed deliveries
+	delete(cond, "status")
+
+	table := s.db.Table(common.BKTableNameEventDeadLetter)
+	count, err := table.Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count dead letters failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	letters := make([]watch.DeadLetter, 0)
+	err = table.Find(cond).Sort("-"+common.CreateTimeField).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(ctx.Kit.Ctx, &letters)
+	if err != nil {
+		blog.Errorf("search dead letters failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(watch.SearchDeadLetterResult{Count: int64(count), Info: letters})
+}
+
+// parseSearchDeliveryOption parse the delivery search option and authorize the subscription, the payloads contain
+// the event details, so the user must have the watch permission of the subscription.
+func (s *Service) parseSearchDeliveryOption(ctx *rest.Contexts) (*watch.SearchDeliveryOption, mapstr.MapStr, bool) {
+	opt := new(watch.SearchDeliveryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return nil, nil, false
+	}
+
+	if err := opt.Page.ValidateLimit(common.BKMaxPageSize); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "page.limit"))
+		return nil, nil, false
+	}
+
+	sub, err := s.getSubscriptionByPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return nil, nil, false
+	}
+
+	if authResp, authorized := s.authorizeSubscription(ctx.Kit, sub); !authorized {
+		ctx.RespNoAuth(authResp)
+		return nil, nil, false
+	}
+
+	cond := mapstr.MapStr{"subscription_id": sub.ID}
+	if len(opt.Status) > 0 {
+		cond["status"] = opt.Status
+	}
+	return opt, cond, true
+}
+
+// getSubscriptionByPath get the subscription by the id in the request path
+func (s *Service) getSubscriptionByPath(ctx *rest.Contexts) (*watch.Subscription, errors.CCErrorCoder) {
+	idStr := ctx.Request.PathParameter(common.BKFieldID)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("subscription id %s is invalid, err: %v, rid: %s", idStr, err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	sub := new(watch.Subscription)
+	if err := s.db.Table(common.BKTableNameEventSubscription).Find(cond).One(ctx.Kit.Ctx, sub); err != nil {
+		if s.db.IsNotFoundError(err) {
+			return nil, ctx.Kit.CCError.CCErrorf(common.CCErrEventSubscriptionNotExist, idStr)
+		}
+		blog.Errorf("get subscription %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCError(common.CCErrEventSubscribeSelectFailed)
+	}
+
+	return sub, nil
+}
+
+// checkSubscriptionName check if the subscription name is duplicated with other subscriptions
+func (s *Service) checkSubscriptionName(kit *rest.Kit, name string, id int64) errors.CCErrorCoder {
+	cond := mapstr.MapStr{common.BKFieldName: name}
+	if id != 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBNE: id}
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	count, err := s.db.Table(common.BKTableNameEventSubscription).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count subscriptions failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrEventSubscribeSelectFailed)
+	}
+
+	if count > 0 {
+		return kit.CCError.CCErrorf(common.CCErrEventSubscriptionNameDuplicated, name)
+	}
+	return nil
+}
+
+// updateSubscription update the subscription, the last time is changed so that the pusher restarts its worker
+func (s *Service) updateSubscription(kit *rest.Kit, id int64, doc mapstr.MapStr) errors.CCErrorCoder {
+	doc["modifier"] = kit.User
+	doc["last_time"] = time.Now()
+
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	if err := s.db.Table(common.BKTableNameEventSubscription).Update(kit.Ctx, cond, doc); err != nil {
+		blog.Errorf("update subscription %d failed, err: %v, data: %+v, rid: %s", id, err, doc, kit.Rid)
+		return kit.CCError.CCError(common.CCErrEventSubscribeUpdateFailed)
+	}
+	return nil
+}
+
+// authorizeSubscription authorize the watch permission of the subscription resource, which is the same with the
+// permission of watching the resource directly.
+func (s *Service) authorizeSubscription(kit *rest.Kit, sub *watch.Subscription) (*metadata.BaseResp, bool) {
+	return s.authorizeWatch(kit, sub.Resource, sub.Filter.SubResource)
+}
+
+// authorizeWatch authorize the watch permission of the resource, the sub resource is authorized if it is set.
+func (s *Service) authorizeWatch(kit *rest.Kit, res watch.CursorType, subResource string) (*metadata.BaseResp,
+	bool) {
+
+	resource := res
+	switch resource {
+	case watch.HostIdentifier:
+		// redirect host identity resource to host resource in iam.
+		resource = watch.Host
+	case watch.BizSetRelation:
+		// redirect biz set relation resource to biz set resource in iam.
+		resource = watch.BizSet
+	}
+
+	authRes := meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   meta.EventWatch,
+			Action: meta.Action(resource),
+		},
+	}
+
+	if len(subResource) > 0 {
+		switch res {
+		case watch.ObjectBase, watch.MainlineInstance, watch.InstAsst:
+			modelID, err := s.getModelID(kit, subResource)
+			if err != nil {
+				return &metadata.BaseResp{Code: err.GetCode(), ErrMsg: err.Error()}, false
+			}
+			authRes.InstanceID = modelID
+		case watch.KubeWorkload:
+			authRes.InstanceIDEx = subResource
+		}
+	}
+
+	return s.AuthManager.Authorize(kit, authRes)
+}
+
+func (s *Service) getModelID(kit *rest.Kit, objID string) (int64, errors.CCErrorCoder) {
+	cond := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{common.BKObjIDField: objID},
+		Fields:         []string{common.BKFieldID},
+		Page:           metadata.BasePage{Limit: 1},
+		DisableCounter: true,
+	}
+
+	models, err := s.engine.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, cond)
+	if err != nil {
+		blog.Errorf("get model %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrTopoModuleSelectFailed)
+	}
+
+	if len(models.Info) == 0 {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKSubResourceField)
+	}
+	return models.Info[0].ID, nil
+}
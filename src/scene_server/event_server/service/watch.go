@@ -13,6 +13,11 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"configcenter/src/common"
@@ -42,3 +47,87 @@ func (s *Service) WatchEvent(ctx *rest.Contexts) {
 
 	ctx.RespString(resp)
 }
+
+// WatchEventStream streams the watch events through server-sent events, or websocket if the request asks to upgrade
+// to websocket. the stream is resumed from the cursor of the Last-Event-ID header or the bk_cursor option.
+func (s *Service) WatchEventStream(ctx *rest.Contexts) {
+	opts, err := parseStreamOptions(ctx)
+	if err != nil {
+		blog.Errorf("parse watch stream options failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	if err := opts.Validate(); err != nil {
+		blog.Errorf("watch stream options are invalid, err: %v, opts: %+v, rid: %s", err, opts, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	// the stream api is not authorized by api server because the options may be in the query parameters
+	if authResp, authorized := s.authorizeWatch(ctx.Kit, opts.Resource, opts.Filter.SubResource); !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	s.streamer.Serve(ctx.Kit, ctx.Request.Request, ctx.RawResponse(), opts)
+}
+
+// parseStreamOptions parse the watch stream options from the request body of the POST request, or from the query
+// parameters of the GET request, because the sse client in browser and the websocket client can not send a body.
+func parseStreamOptions(ctx *rest.Contexts) (*watch.StreamOptions, error) {
+	opts := new(watch.StreamOptions)
+
+	req := ctx.Request.Request
+	if req.Method == http.MethodPost {
+		if err := ctx.DecodeInto(opts); err != nil {
+			return nil, err
+		}
+	} else {
+		query := req.URL.Query()
+		opts.Cursor = query.Get("bk_cursor")
+
+		if startFrom := query.Get("bk_start_from"); len(startFrom) > 0 {
+			val, err := strconv.ParseInt(startFrom, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bk_start_from %s is invalid", startFrom)
+			}
+			opts.StartFrom = val
+		}
+
+		if eventTypes := query.Get("bk_event_types"); len(eventTypes) > 0 {
+			for _, eventType := range strings.Split(eventTypes, ",") {
+				opts.EventTypes = append(opts.EventTypes, watch.EventType(eventType))
+			}
+		}
+
+		if fields := query.Get("bk_fields"); len(fields) > 0 {
+			opts.Fields = strings.Split(fields, ",")
+		}
+
+		// bk_filter is a json string, because the filter expression can not be expressed by query parameters
+		if filter := query.Get("bk_filter"); len(filter) > 0 {
+			if err := json.Unmarshal([]byte(filter), &opts.Filter); err != nil {
+				return nil, fmt.Errorf("bk_filter is invalid, err: %v", err)
+			}
+		}
+
+		if heartbeat := query.Get("heartbeat_seconds"); len(heartbeat) > 0 {
+			val, err := strconv.Atoi(heartbeat)
+			if err != nil {
+				return nil, fmt.Errorf("heartbeat_seconds %s is invalid", heartbeat)
+			}
+			opts.HeartbeatSeconds = val
+		}
+	}
+
+	opts.Resource = watch.CursorType(ctx.Request.PathParameter("resource"))
+
+	// the sse client sets the Last-Event-ID header to the cursor of the last received event when it reconnects
+	if lastEventID := req.Header.Get(watch.StreamLastEventIDHeader); len(lastEventID) > 0 {
+		opts.Cursor = lastEventID
+		opts.StartFrom = 0
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// reasonClientClosed the stream is closed because the client closed the connection
+	reasonClientClosed = "client_closed"
+	// reasonSlowConsumer the stream is closed because the client can not receive the messages in time
+	reasonSlowConsumer = "slow_consumer"
+	// reasonWatchFailed the stream is closed because the events are failed to be watched
+	reasonWatchFailed = "watch_failed"
+)
+
+type metrics struct {
+	// connections is the count of the current stream connections
+	connections *prometheus.GaugeVec
+	// events is the total count of the streamed events
+	events *prometheus.CounterVec
+	// disconnections is the total count of the closed stream connections
+	disconnections *prometheus.CounterVec
+}
+
+func newMetrics(registry prometheus.Registerer) *metrics {
+	m := &metrics{
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cmdb_event_watch_stream_connections",
+			Help: "current number of the watch stream connections.",
+		}, []string{"transport", "resource"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cmdb_event_watch_stream_events_total",
+			Help: "total number of the events that are sent by the watch streams.",
+		}, []string{"transport", "resource"}),
+		disconnections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cmdb_event_watch_stream_disconnections_total",
+			Help: "total number of the closed watch stream connections.",
+		}, []string{"transport", "reason"}),
+	}
+
+	registry.MustRegister(m.connections, m.events, m.disconnections)
+	return m
+}
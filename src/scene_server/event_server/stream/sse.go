@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	sseTransport = "sse"
+	// sseRetryMillis is the reconnection time that the sse client waits before it reconnects
+	sseRetryMillis = "3000"
+)
+
+// serveSSE streams the events as server-sent events, the id of the message is the cursor, so the client resumes the
+// stream from the last received event with the Last-Event-ID header when it reconnects.
+func (s *Streamer) serveSSE(kit *rest.Kit, resp *restful.Response, opts *watch.StreamOptions) {
+	writer := unwrapResponseWriter(resp)
+	controller := http.NewResponseController(writer)
+
+	header := resp.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable the response buffering of the nginx proxy
+	header.Set("X-Accel-Buffering", "no")
+	httpheader.AddRid(header, kit.Rid)
+	resp.WriteHeader(http.StatusOK)
+
+	snd := &sseSender{writer: writer, controller: controller}
+	if err := snd.write([]byte("retry: " + sseRetryMillis + "\n\n")); err != nil {
+		blog.Errorf("start sse stream failed, err: %v, rid: %s", err, kit.Rid)
+		return
+	}
+
+	s.serve(kit.Ctx, kit, opts, sseTransport, snd)
+}
+
+type sseSender struct {
+	writer     http.ResponseWriter
+	controller *http.ResponseController
+}
+
+func (s *sseSender) send(msg *watch.StreamMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)+128))
+	if len(msg.Cursor) > 0 {
+		buf.WriteString("id: " + msg.Cursor + "\n")
+	}
+	buf.WriteString("event: " + string(msg.Type) + "\n")
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+
+	return s.write(buf.Bytes())
+}
+
+// write the data and flush it to the client, the write deadline is set so that a slow client does not block the
+// stream forever.
+func (s *sseSender) write(data []byte) error {
+	err := s.controller.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	if _, err := s.writer.Write(data); err != nil {
+		return err
+	}
+
+	return s.controller.Flush()
+}
+
+// unwrapResponseWriter returns the underlying response writer of the restful response, so that the write deadline
+// of the connection can be set by the response controller.
+func unwrapResponseWriter(resp *restful.Response) http.ResponseWriter {
+	var writer http.ResponseWriter = resp
+	for {
+		restfulResp, ok := writer.(*restful.Response)
+		if !ok || restfulResp.ResponseWriter == nil {
+			return writer
+		}
+		writer = restfulResp.ResponseWriter
+	}
+}
@@ -0,0 +1,197 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package stream streams the watch events to the clients through server-sent events or websocket, so that the
+// clients do not need to poll the watch api again and again.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// writeTimeout is the timeout of writing a message to the client, the client that can not receive the message
+	// in time is regarded as a slow consumer, and the stream is closed, the client can resume it from the last cursor.
+	writeTimeout = 10 * time.Second
+	// bufferSize is the max count of the watched batches that are waiting to be sent, the events are not watched
+	// until the buffered batches are sent, so a slow client only slows down its own stream.
+	bufferSize = 2
+)
+
+// sender sends the stream messages through a transport
+type sender interface {
+	send(msg *watch.StreamMessage) error
+}
+
+// Streamer streams the watch events with the cache service watch api, each stream watches the events from its own
+// cursor, so the stream can be resumed from the cursor of the last received event.
+type Streamer struct {
+	engine  *backbone.Engine
+	metrics *metrics
+}
+
+// NewStreamer new watch event streamer
+func NewStreamer(engine *backbone.Engine) *Streamer {
+	return &Streamer{
+		engine:  engine,
+		metrics: newMetrics(engine.Metric().Registry()),
+	}
+}
+
+// Serve streams the watch events to the client until the client is disconnected, websocket is used if the request
+// asks to upgrade to websocket, otherwise server-sent events is used.
+func (s *Streamer) Serve(kit *rest.Kit, req *http.Request, resp *restful.Response, opts *watch.StreamOptions) {
+	if isWebsocketRequest(req) {
+		s.serveWebsocket(kit, req, resp, opts)
+		return
+	}
+	s.serveSSE(kit, resp, opts)
+}
+
+// serve watches the events and sends them with the sender, heartbeat messages are sent when no event is watched.
+func (s *Streamer) serve(ctx context.Context, kit *rest.Kit, opts *watch.StreamOptions, transport string,
+	snd sender) {
+
+	labels := prometheus.Labels{"transport": transport, "resource": string(opts.Resource)}
+	s.metrics.connections.With(labels).Inc()
+	defer s.metrics.connections.With(labels).Dec()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan *watch.WatchResp, bufferSize)
+	watchErr := make(chan ccErr.CCErrorCoder, 1)
+	go s.watch(ctx, kit, opts.WatchEventOptions, batches, watchErr)
+
+	interval := time.Duration(opts.HeartbeatSeconds) * time.Second
+	heartbeat := time.NewTimer(interval)
+	defer heartbeat.Stop()
+
+	cursor := opts.Cursor
+	for {
+		var msgs []*watch.StreamMessage
+
+		select {
+		case <-ctx.Done():
+			s.metrics.disconnections.WithLabelValues(transport, reasonClientClosed).Inc()
+			return
+
+		case err := <-watchErr:
+			blog.Errorf("watch %s events to stream failed, err: %v, rid: %s", opts.Resource, err, kit.Rid)
+			_ = snd.send(&watch.StreamMessage{Type: watch.StreamError, Code: err.GetCode(), Message: err.Error()})
+			s.metrics.disconnections.WithLabelValues(transport, reasonWatchFailed).Inc()
+			return
+
+		case resp := <-batches:
+			if len(resp.Events) == 0 {
+				continue
+			}
+
+			if !resp.Watched {
+				// no event is watched, the returned event only contains the latest cursor
+				cursor = resp.Events[len(resp.Events)-1].Cursor
+				continue
+			}
+
+			for _, e := range resp.Events {
+				msgs = append(msgs, &watch.StreamMessage{Type: watch.StreamEvent, Cursor: e.Cursor, Event: e})
+				cursor = e.Cursor
+			}
+
+		case <-heartbeat.C:
+			msgs = append(msgs, &watch.StreamMessage{Type: watch.StreamHeartbeat, Cursor: cursor})
+		}
+
+		for _, msg := range msgs {
+			if err := snd.send(msg); err != nil {
+				reason := reasonClientClosed
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					reason = reasonSlowConsumer
+				}
+				blog.Warnf("send %s stream message failed, close the stream, reason: %s, err: %v, rid: %s",
+					transport, reason, err, kit.Rid)
+				s.metrics.disconnections.WithLabelValues(transport, reason).Inc()
+				return
+			}
+		}
+
+		if msgs[0].Type == watch.StreamEvent {
+			s.metrics.events.With(labels).Add(float64(len(msgs)))
+		}
+
+		// heartbeat is only needed when no message is sent in the interval
+		if !heartbeat.Stop() {
+			select {
+			case <-heartbeat.C:
+			default:
+			}
+		}
+		heartbeat.Reset(interval)
+	}
+}
+
+// watch loops to watch the events from the cursor and puts them into the batches, it blocks when the batches are
+// full, so that the events are not watched faster than they are sent.
+func (s *Streamer) watch(ctx context.Context, kit *rest.Kit, opts watch.WatchEventOptions,
+	batches chan<- *watch.WatchResp, watchErr chan<- ccErr.CCErrorCoder) {
+
+	for {
+		result, err := s.engine.CoreAPI.CacheService().Cache().Event().WatchEvent(ctx, kit.Header, &opts)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			watchErr <- err
+			return
+		}
+
+		resp := new(watch.WatchResp)
+		if err := json.Unmarshal([]byte(*result), resp); err != nil {
+			blog.Errorf("unmarshal watch result failed, err: %v, result: %s, rid: %s", err, *result, kit.Rid)
+			watchErr <- kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+			return
+		}
+
+		select {
+		case batches <- resp:
+		case <-ctx.Done():
+			return
+		}
+
+		if len(resp.Events) > 0 {
+			opts.Cursor = resp.Events[len(resp.Events)-1].Cursor
+			opts.StartFrom = 0
+		}
+	}
+}
+
+func isWebsocketRequest(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(req.Header.Get("Connection")), "upgrade")
+}
@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful/v3"
+	"golang.org/x/net/websocket"
+)
+
+const websocketTransport = "websocket"
+
+// serveWebsocket streams the events as websocket text messages, the client resumes the stream by connecting with the
+// cursor of the last received event.
+func (s *Streamer) serveWebsocket(kit *rest.Kit, req *http.Request, resp *restful.Response,
+	opts *watch.StreamOptions) {
+
+	server := websocket.Server{
+		// the request is authorized by the cmdb headers, not the cookies, so the origin is not checked
+		Handshake: func(*websocket.Config, *http.Request) error {
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+
+			ctx, cancel := context.WithCancel(kit.Ctx)
+			defer cancel()
+
+			// the client does not send messages, read the connection only to find out when it is closed
+			go func() {
+				defer cancel()
+				var msg []byte
+				for {
+					if err := websocket.Message.Receive(conn, &msg); err != nil {
+						blog.V(4).Infof("websocket stream is closed by client, err: %v, rid: %s", err, kit.Rid)
+						return
+					}
+				}
+			}()
+
+			s.serve(ctx, kit, opts, websocketTransport, &websocketSender{conn: conn})
+		},
+	}
+
+	server.ServeHTTP(resp, req)
+}
+
+type websocketSender struct {
+	conn *websocket.Conn
+}
+
+func (w *websocketSender) send(msg *watch.StreamMessage) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(w.conn, msg)
+}
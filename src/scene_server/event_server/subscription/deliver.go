@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+)
+
+const (
+	// retryBaseInterval is the interval before the first retry, it is doubled after each retry
+	retryBaseInterval = time.Second
+	// retryMaxInterval is the max interval between two retries
+	retryMaxInterval = 5 * time.Minute
+)
+
+// attemptResult is the result of an attempt to push the payload
+type attemptResult struct {
+	statusCode int
+	err        error
+}
+
+// deliver pushes the events to the callback of the subscription at least once, the payload is retried with
+// exponential backoff and moved to the dead letter store after all retries are failed. returns false if the worker
+// is stopped before the delivery is finished.
+func (p *Pusher) deliver(ctx context.Context, sub *watch.Subscription, events []*watch.WatchEventDetail,
+	rid string) bool {
+
+	firstCursor, lastCursor := events[0].Cursor, events[len(events)-1].Cursor
+	payload := &watch.SubscriptionPayload{
+		SubscriptionID: sub.ID,
+		DeliveryID:     genDeliveryID(sub.ID, firstCursor, lastCursor),
+		Resource:       sub.Resource,
+		Events:         events,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		// this should not happen, the events can not be delivered at all, so put them into the dead letter store
+		blog.Errorf("marshal event subscription %d payload failed, err: %v, rid: %s", sub.ID, err, rid)
+	}
+
+	client := &http.Client{Timeout: time.Duration(sub.Timeout) * time.Second}
+	start := time.Now()
+	history := &watch.DeliveryHistory{
+		SubscriptionID: sub.ID,
+		DeliveryID:     payload.DeliveryID,
+		EventCount:     len(events),
+		FirstCursor:    firstCursor,
+		LastCursor:     lastCursor,
+		OwnerID:        sub.OwnerID,
+	}
+
+	var result attemptResult
+	interval := retryBaseInterval
+	for history.Attempts <= sub.MaxRetry && err == nil {
+		if history.Attempts > 0 {
+			blog.Warnf("push event subscription %d payload %s failed, retry after %s, status: %d, err: %v, rid: %s",
+				sub.ID, payload.DeliveryID, interval, result.statusCode, result.err, rid)
+			watcher.Sleep(ctx, interval)
+			interval *= 2
+			if interval > retryMaxInterval {
+				interval = retryMaxInterval
+			}
+		}
+
+		if ctx.Err() != nil {
+			return false
+		}
+
+		history.Attempts++
+		result = push(ctx, client, sub, payload.DeliveryID, body)
+		if result.err == nil {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err == nil {
+		err = result.err
+	}
+
+	history.StatusCode = result.statusCode
+	history.Duration = time.Since(start).Milliseconds()
+	history.CreateTime = time.Now()
+	history.Status = watch.DeliverySuccess
+	if err != nil {
+		history.Status = watch.DeliveryFailed
+		history.Error = watcher.Truncate(err.Error())
+		p.saveDeadLetter(ctx, sub, history, body, rid)
+	}
+
+	if err := p.db.Table(common.BKTableNameEventDeliveryHistory).Insert(ctx, history); err != nil {
+		blog.Errorf("save event subscription %d delivery history failed, err: %v, history: %+v, rid: %s", sub.ID, err,
+			history, rid)
+	}
+
+	return true
+}
+
+// push the payload to the callback once, a response with 2xx status code means the payload is delivered.
+func push(ctx context.Context, client *http.Client, sub *watch.Subscription, deliveryID string,
+	body []byte) attemptResult {
+
+	header := http.Header{}
+	header.Set(watch.SubscriptionIDHeader, strconv.FormatInt(sub.ID, 10))
+	header.Set(watch.SubscriptionDeliveryHeader, deliveryID)
+
+	statusCode, err := watcher.PostSigned(ctx, client, sub.CallbackURL, header, sub.Secret, body)
+	return attemptResult{statusCode: statusCode, err: err}
+}
+
+// saveDeadLetter saves the payload that is failed to deliver, so that it can be inspected and handled by the user.
+func (p *Pusher) saveDeadLetter(ctx context.Context, sub *watch.Subscription, history *watch.DeliveryHistory,
+	body []byte, rid string) {
+
+	id, err := p.db.NextSequence(ctx, common.BKTableNameEventDeadLetter)
+	if err != nil {
+		blog.Errorf("generate event subscription %d dead letter id failed, err: %v, rid: %s", sub.ID, err, rid)
+		return
+	}
+
+	letter := &watch.DeadLetter{
+		ID:             int64(id),
+		SubscriptionID: sub.ID,
+		DeliveryID:     history.DeliveryID,
+		Payload:        string(body),
+		Attempts:       history.Attempts,
+		StatusCode:     history.StatusCode,
+		Error:          history.Error,
+		OwnerID:        sub.OwnerID,
+		CreateTime:     history.CreateTime,
+	}
+
+	if err := p.db.Table(common.BKTableNameEventDeadLetter).Insert(ctx, letter); err != nil {
+		blog.Errorf("save event subscription %d dead letter failed, err: %v, delivery id: %s, rid: %s", sub.ID, err,
+			history.DeliveryID, rid)
+		return
+	}
+
+	blog.Errorf("event subscription %d payload %s is moved to dead letter after %d attempts, err: %s, rid: %s",
+		sub.ID, history.DeliveryID, history.Attempts, history.Error, rid)
+}
+
+// genDeliveryID generates the delivery id by the events in the payload, so that the same events that are delivered
+// again after the worker is restarted have the same delivery id, and the receiver can de-duplicate them.
+func genDeliveryID(subID int64, firstCursor, lastCursor string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%s", subID, firstCursor, lastCursor)))
+	return hex.EncodeToString(sum[:16])
+}
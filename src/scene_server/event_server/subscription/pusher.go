@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package subscription pushes the watch events of the webhook subscriptions to their http callbacks.
+package subscription
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// syncInterval is the interval to sync the running workers with the subscriptions in db
+	syncInterval = 10 * time.Second
+	// watchFailInterval is the interval to watch again after the watch is failed
+	watchFailInterval = 3 * time.Second
+)
+
+// Pusher runs a worker for each active subscription on the master event server, the worker watches the events of
+// the subscription and pushes them to the callback.
+type Pusher struct {
+	ctx    context.Context
+	engine *backbone.Engine
+	db     dal.RDB
+	pool   *watcher.Pool
+}
+
+// NewPusher new subscription event pusher
+func NewPusher(ctx context.Context, engine *backbone.Engine, db dal.RDB) *Pusher {
+	p := &Pusher{
+		ctx:    ctx,
+		engine: engine,
+		db:     db,
+	}
+	p.pool = watcher.NewPool("event subscription", engine.Discovery(), syncInterval, p.listActive, p.runWorker)
+	return p
+}
+
+// Run loops to keep the workers consistent with the active subscriptions, only master runs the workers.
+func (p *Pusher) Run() {
+	p.pool.Run(p.ctx)
+}
+
+// listActive lists the active subscriptions, the worker is restarted when the subscription is changed.
+func (p *Pusher) listActive(ctx context.Context, _ string) (map[int64]time.Time, error) {
+	cond := mapstr.MapStr{"status": watch.SubscriptionActive}
+	subs := make([]watch.Subscription, 0)
+	err := p.db.Table(common.BKTableNameEventSubscription).Find(cond).Fields(common.BKFieldID, "last_time").
+		All(ctx, &subs)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[int64]time.Time, len(subs))
+	for _, sub := range subs {
+		active[sub.ID] = sub.LastTime
+	}
+	return active, nil
+}
+
+// runWorker watches the events of the subscription and pushes them until the worker is stopped.
+func (p *Pusher) runWorker(ctx context.Context, id int64) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		rid := util.GenerateRID()
+		sub, err := p.getSubscription(ctx, id)
+		if err != nil {
+			blog.Errorf("get event subscription %d failed, err: %v, rid: %s", id, err, rid)
+			watcher.Sleep(ctx, watchFailInterval)
+			continue
+		}
+
+		if sub.Status != watch.SubscriptionActive {
+			return
+		}
+
+		result, ok := p.watchEvents(ctx, sub, rid)
+		if !ok {
+			watcher.Sleep(ctx, watchFailInterval)
+			continue
+		}
+
+		if len(result.Events) > 0 {
+			if !p.deliver(ctx, sub, result.Events, rid) {
+				// worker is stopped while delivering, the events will be delivered again by the next worker
+				return
+			}
+		}
+
+		if result.Cursor == sub.Cursor && result.Gap == nil {
+			continue
+		}
+
+		if err := p.saveCursor(ctx, sub, result.Cursor, result.Gap); err != nil {
+			blog.Errorf("save event subscription %d cursor %s failed, err: %v, rid: %s", id, result.Cursor, err,
+				rid)
+			watcher.Sleep(ctx, watchFailInterval)
+		}
+	}
+}
+
+func (p *Pusher) getSubscription(ctx context.Context, id int64) (*watch.Subscription, error) {
+	sub := new(watch.Subscription)
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	if err := p.db.Table(common.BKTableNameEventSubscription).Find(cond).One(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// saveCursor persists the cursor of the subscription and clears the replay start time, the gap is recorded if the
+// cursor was lost. the subscription is only updated if it is not changed after the worker loads it, so that a
+// replay request is not overwritten.
+func (p *Pusher) saveCursor(ctx context.Context, sub *watch.Subscription, cursor string, gap *watch.CursorGap) error {
+	cond := mapstr.MapStr{
+		common.BKFieldID: sub.ID,
+		"last_time":      sub.LastTime,
+	}
+	data := mapstr.MapStr{
+		"bk_cursor":     cursor,
+		"bk_start_from": 0,
+	}
+	if gap != nil {
+		data["last_gap"] = gap
+	}
+	return p.db.Table(common.BKTableNameEventSubscription).Update(ctx, cond, data)
+}
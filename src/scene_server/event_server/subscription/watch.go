@@ -0,0 +1,43 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+)
+
+// watchEvents watches the events of the subscription, returns the events and the cursor to be saved after the
+// events are delivered, and the gap to be recorded if the cursor of the subscription is lost.
+func (p *Pusher) watchEvents(ctx context.Context, sub *watch.Subscription, rid string) (*watcher.Result, bool) {
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, sub.OwnerID, rid)
+
+	result, err := watcher.WatchWithCursor(ctx, p.engine.CoreAPI.CacheService().Cache().Event(), header,
+		sub.WatchOptions())
+	if err != nil {
+		blog.Errorf("watch event subscription %d events failed, err: %v, rid: %s", sub.ID, err, rid)
+		return nil, false
+	}
+
+	if result.Gap != nil {
+		blog.Errorf("event subscription %d cursor %s is lost, the events from %d to %d are not pushed, rid: %s",
+			sub.ID, sub.Cursor, result.Gap.From, result.Gap.To, rid)
+	}
+
+	return result, true
+}
@@ -404,6 +404,18 @@ func (lgc *Logics) TransferHostAcrossBusiness(kit *rest.Kit, srcBizID, dstAppID
 		return kit.CCError.Errorf(common.CCErrHostModuleConfigNotMatch, util.PrettyIPStr(notExistHostIP))
 	}
 
+	// check the approval of the transfer, it can only be executed by its approved change request if it needs approval
+	approvalOpt := &metadata.ApprovalCheckOption{
+		Operation: metadata.ApprovalOperationTransferHostAcrossBiz,
+		TransferHost: &metadata.TransferHostAcrossBusinessParameter{SrcAppID: srcBizID, DstAppID: dstAppID,
+			HostID: hostID, DstModuleID: moduleID},
+	}
+	if err := lgc.CoreAPI.TaskServer().Approval().CheckApproval(kit.Ctx, kit.Header, approvalOpt); err != nil {
+		blog.Errorf("check transfer host across biz approval failed, err: %v, opt: %#v, rid: %s", err,
+			approvalOpt.TransferHost, kit.Rid)
+		return err
+	}
+
 	// do transfer and save audit log
 	audit := auditlog.NewHostModuleLog(lgc.CoreAPI.CoreService(), hostID)
 	if err := audit.WithPrevious(kit); err != nil {
@@ -681,6 +681,13 @@ func (s *Service) UpdateHostBatch(ctx *rest.Contexts) {
 		return
 	}
 
+	// check if the host state update is allowed by the host lifecycle
+	transitions, err := s.checkHostStateUpdate(ctx.Kit, hostIDArr, data)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	// for audit log.
 	audit := auditlog.NewHostAudit(s.CoreAPI.CoreService())
 
@@ -713,7 +720,7 @@ func (s *Service) UpdateHostBatch(ctx *rest.Contexts) {
 			return err
 		}
 
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -1004,6 +1011,7 @@ func (s *Service) MoveSetHost2IdleModule(ctx *rest.Contexts) {
 			hostIDMHMap[item.HostID] = append(hostIDMHMap[item.HostID], item)
 		}
 
+		idleHostIDs := make([]int64, 0)
 		for _, hostID := range hostIDArr {
 			hostMHArr, ok := hostIDMHMap[hostID]
 			if !ok {
@@ -1027,6 +1035,7 @@ func (s *Service) MoveSetHost2IdleModule(ctx *rest.Contexts) {
 			var opResult []meta.ExceptionResult
 			var ccErr ccErrs.CCErrorCoder
 			if toEmptyModule {
+				idleHostIDs = append(idleHostIDs, hostID)
 				input := &meta.TransferHostToInnerModule{
 					ApplicationID: data.ApplicationID,
 					ModuleID:      idleModuleID,
@@ -1068,7 +1077,15 @@ func (s *Service) MoveSetHost2IdleModule(ctx *rest.Contexts) {
 			blog.Errorf("MoveSetHost2IdleModule has exception. exception:%#v, rid:%s", exceptionArr, ctx.Kit.Rid)
 			return ctx.Kit.CCError.CCError(common.CCErrHostDeleteFail)
 		}
-		return nil
+
+		// check if the hosts moved to the idle module are allowed by the host lifecycle, the transfer is rolled back
+		// if not allowed since the moved hosts are only known after their relations are got
+		transitions, err := s.checkHostTransferState(ctx.Kit, data.ApplicationID, idleHostIDs,
+			common.DefaultResModuleFlag)
+		if err != nil {
+			return err
+		}
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -1248,13 +1265,19 @@ func (s *Service) CloneHostProperty(ctx *rest.Contexts) {
 		return
 	}
 
+	transitions, err := s.checkCloneHostState(ctx.Kit, orgID, dstID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		err = s.Logic.CloneHostProperty(ctx.Kit, input.AppID, orgID, dstID)
 		if nil != err {
 			blog.Errorf("CloneHostProperty  error , err: %v, input:%#v, rid:%s", err, input, ctx.Kit.Rid)
 			return err
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -1341,6 +1364,19 @@ func (s *Service) UpdateImportHosts(ctx *rest.Contexts) {
 		return
 	}
 
+	// the hosts whose bk_state update is not allowed by the host lifecycle are not updated
+	transitions, stateErrMsg, err := s.checkImportHostsState(ctx.Kit, hosts, hostIDArr, indexHostIDMap)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	errMsg = append(errMsg, stateErrMsg...)
+
+	if len(hosts) == 0 {
+		ctx.RespEntity(map[string]interface{}{"error": errMsg, "success": []string{}})
+		return
+	}
+
 	successData, errData, err := s.Logic.UpdateHostByExcel(ctx.Kit, hosts, hostIDArr, indexHostIDMap)
 	if err != nil {
 		blog.Errorf("update host by excel failed, err: %v, rid: %s", err, ctx.Kit.Rid)
@@ -1350,6 +1386,22 @@ func (s *Service) UpdateImportHosts(ctx *rest.Contexts) {
 	successMsg = append(successMsg, successData...)
 	errMsg = append(errMsg, errData...)
 
+	// only the transitions of the successfully updated hosts are saved
+	successHostIDs := make(map[int64]struct{})
+	for _, index := range successData {
+		successHostIDs[indexHostIDMap[index]] = struct{}{}
+	}
+	successTransitions := make([]meta.HostLifecycleHistory, 0)
+	for _, transition := range transitions {
+		if _, exists := successHostIDs[transition.HostID]; exists {
+			successTransitions = append(successTransitions, transition)
+		}
+	}
+	if err := s.saveHostLifecycleTransitions(ctx.Kit, successTransitions); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	ctx.RespEntity(map[string]interface{}{"error": errMsg, "success": successMsg})
 }
 
@@ -2015,6 +2067,17 @@ func (s *Service) updateHost(kit *rest.Kit, hostIDArr []int64, parameter *meta.U
 		if err != nil {
 			return err
 		}
+
+		// check if the host state updates are allowed by the host lifecycle
+		transitions := make([]meta.HostLifecycleHistory, 0)
+		for _, update := range parameter.Update {
+			histories, err := s.checkHostStateUpdate(kit, update.HostIDs, update.Properties)
+			if err != nil {
+				return err
+			}
+			transitions = append(transitions, histories...)
+		}
+
 		auditContexts := make([]meta.AuditLog, 0)
 		audit := auditlog.NewHostAudit(s.CoreAPI.CoreService())
 		var wg sync.WaitGroup
@@ -2085,7 +2148,7 @@ func (s *Service) updateHost(kit *rest.Kit, hostIDArr []int64, parameter *meta.U
 			blog.Errorf("add hosts %+v audit failed, err: %v, rid: %s", hostIDArr, err, kit.Rid)
 			return err
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(kit, transitions)
 	})
 
 	return txnErr
@@ -0,0 +1,329 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"sort"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/logics"
+)
+
+// CreateHostApplyScopeRule create host apply rule of business, set, set template or host dynamic group scope
+func (s *Service) CreateHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("parse biz id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := metadata.CreateHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	var rule metadata.HostApplyScopeRule
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		rule, err = s.CoreAPI.CoreService().HostApplyRule().CreateHostApplyScopeRule(ctx.Kit.Ctx, ctx.Kit.Header,
+			bizID, option)
+		if err != nil {
+			blog.Errorf("create host apply scope rule failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID,
+				option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(rule)
+}
+
+// UpdateHostApplyScopeRule update the property value of the host apply scope rule
+func (s *Service) UpdateHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("parse biz id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	ruleID, err := strconv.ParseInt(ctx.Request.PathParameter(common.HostApplyRuleIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("parse host apply rule id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, common.HostApplyRuleIDField))
+		return
+	}
+
+	option := metadata.UpdateHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var rule metadata.HostApplyScopeRule
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		rule, err = s.CoreAPI.CoreService().HostApplyRule().UpdateHostApplyScopeRule(ctx.Kit.Ctx, ctx.Kit.Header,
+			bizID, ruleID, option)
+		if err != nil {
+			blog.Errorf("update host apply scope rule failed, bizID: %d, ruleID: %d, option: %+v, err: %v, rid: %s",
+				bizID, ruleID, option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(rule)
+}
+
+// DeleteHostApplyScopeRule delete host apply scope rules
+func (s *Service) DeleteHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("parse biz id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := metadata.DeleteHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		err := s.CoreAPI.CoreService().HostApplyRule().DeleteHostApplyScopeRule(ctx.Kit.Ctx, ctx.Kit.Header, bizID,
+			option)
+		if err != nil {
+			blog.Errorf("delete host apply scope rule failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID,
+				option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListHostApplyScopeRule list host apply scope rules
+func (s *Service) ListHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("parse biz id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := metadata.ListHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Page.ValidateLimit(common.BKMaxPageSize); rawErr != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "page.limit"))
+		return
+	}
+
+	rules, ccErr := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyScopeRule(ctx.Kit.Ctx, ctx.Kit.Header,
+		bizID, option)
+	if ccErr != nil {
+		blog.Errorf("list host apply scope rule failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID, option,
+			ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(rules)
+}
+
+// mergeModuleScopeRules merge the business, set and set template scope rules into the module or service template
+// rules of the modules by the scope precedence, returns the final rules and the overridden scope rules
+func (s *Service) mergeModuleScopeRules(kit *rest.Kit, bizID int64, modules []metadata.ModuleInst,
+	moduleRules []metadata.HostApplyRule) ([]metadata.HostApplyRule, []metadata.HostApplyScopeConflict,
+	errors.CCErrorCoder) {
+
+	option := metadata.ListHostApplyScopeRuleOption{
+		Scopes: []metadata.HostApplyScope{metadata.HostApplyScopeBiz, metadata.HostApplyScopeSet,
+			metadata.HostApplyScopeSetTemplate},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	scopeRules, err := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyScopeRule(kit.Ctx, kit.Header, bizID,
+		option)
+	if err != nil {
+		blog.Errorf("list host apply scope rules failed, bizID: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+		return nil, nil, err
+	}
+
+	if len(scopeRules.Info) == 0 {
+		return moduleRules, make([]metadata.HostApplyScopeConflict, 0), nil
+	}
+
+	finalRules, conflicts := metadata.MergeHostApplyScopeRules(modules, moduleRules, scopeRules.Info)
+	return finalRules, conflicts, nil
+}
+
+// getDynamicGroupHostRules get the dynamic group scope rules of the hosts that matches the host dynamic groups, if
+// multiple dynamic groups set the same attribute of a host, the earliest created rule is used
+func (s *Service) getDynamicGroupHostRules(kit *rest.Kit, bizID int64, hostIDs []int64) (
+	[]metadata.HostApplyHostRules, errors.CCErrorCoder) {
+
+	option := metadata.ListHostApplyScopeRuleOption{
+		Scopes: []metadata.HostApplyScope{metadata.HostApplyScopeDynamicGroup},
+		Page:   metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	scopeRules, err := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyScopeRule(kit.Ctx, kit.Header, bizID,
+		option)
+	if err != nil {
+		blog.Errorf("list dynamic group host apply rules failed, bizID: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(scopeRules.Info) == 0 || len(hostIDs) == 0 {
+		return make([]metadata.HostApplyHostRules, 0), nil
+	}
+
+	sort.Slice(scopeRules.Info, func(i, j int) bool {
+		return scopeRules.Info[i].ID < scopeRules.Info[j].ID
+	})
+
+	groupRules := make(map[string][]metadata.HostApplyScopeRule)
+	groupIDs := make([]string, 0)
+	for _, rule := range scopeRules.Info {
+		if _, exists := groupRules[rule.DynamicGroupID]; !exists {
+			groupIDs = append(groupIDs, rule.DynamicGroupID)
+		}
+		groupRules[rule.DynamicGroupID] = append(groupRules[rule.DynamicGroupID], rule)
+	}
+
+	hostRuleMap := make(map[int64][]metadata.HostApplyRule)
+	for _, groupID := range groupIDs {
+		matchedHostIDs, err := s.getDynamicGroupMatchedHosts(kit, bizID, groupID, hostIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hostID := range matchedHostIDs {
+			for _, rule := range groupRules[groupID] {
+				hostRuleMap[hostID] = append(hostRuleMap[hostID], rule.ToHostApplyRule(0))
+			}
+		}
+	}
+
+	hostRules := make([]metadata.HostApplyHostRules, 0)
+	for _, hostID := range hostIDs {
+		if rules, exists := hostRuleMap[hostID]; exists {
+			hostRules = append(hostRules, metadata.HostApplyHostRules{HostID: hostID, Rules: rules})
+		}
+	}
+	return hostRules, nil
+}
+
+// getDynamicGroupMatchedHosts get the hosts in hostIDs that matches the host dynamic group
+func (s *Service) getDynamicGroupMatchedHosts(kit *rest.Kit, bizID int64, groupID string, hostIDs []int64) (
+	[]int64, errors.CCErrorCoder) {
+
+	result, err := s.CoreAPI.CoreService().Host().GetDynamicGroup(kit.Ctx, strconv.FormatInt(bizID, 10), groupID,
+		kit.Header)
+	if err != nil {
+		blog.Errorf("get dynamic group failed, bizID: %d, id: %s, err: %v, rid: %s", bizID, groupID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if ccErr := result.CCError(); ccErr != nil {
+		blog.Errorf("get dynamic group failed, bizID: %d, id: %s, err: %v, rid: %s", bizID, groupID, ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
+	// the dynamic group may be changed to set dynamic group after the rule is created, skip it
+	if result.Data.ObjID != common.BKInnerObjIDHost {
+		blog.Warnf("dynamic group %s is not host dynamic group, skip its host apply rules, rid: %s", groupID,
+			kit.Rid)
+		return make([]int64, 0), nil
+	}
+
+	cond := make([]metadata.DynamicGroupInfoCondition, 0)
+	cond = append(cond, result.Data.Info.Condition...)
+	cond = append(cond, result.Data.Info.VariableCondition...)
+
+	matchedHostIDs := make([]int64, 0)
+	for start := 0; start < len(hostIDs); start += common.BKMaxPageSize {
+		end := start + common.BKMaxPageSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+
+		searchConds := parseCond(cond)
+		hostIDCond := metadata.ConditionItem{Field: common.BKHostIDField, Operator: common.BKDBIN,
+			Value: hostIDs[start:end]}
+		hasHostCond := false
+		for idx := range searchConds {
+			if searchConds[idx].ObjectID == common.BKInnerObjIDHost {
+				searchConds[idx].Condition = append(searchConds[idx].Condition, hostIDCond)
+				hasHostCond = true
+			}
+		}
+		if !hasHostCond {
+			searchConds = append(searchConds, metadata.SearchCondition{ObjectID: common.BKInnerObjIDHost,
+				Condition: []metadata.ConditionItem{hostIDCond}})
+		}
+
+		searchCond := metadata.HostCommonSearch{AppID: bizID, Condition: searchConds,
+			Page: metadata.BasePage{Limit: common.BKMaxPageSize}}
+		data, err := logics.NewLogics(s.Engine, s.CacheDB, s.AuthManager).ExecuteHostDynamicGroup(kit, &searchCond,
+			[]string{common.BKHostIDField}, true)
+		if err != nil {
+			blog.Errorf("execute dynamic group %s failed, err: %v, rid: %s", groupID, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrGetUserCustomQueryDetailFailed, err.Error())
+		}
+
+		for _, host := range data.Info {
+			hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+			if err != nil {
+				blog.Errorf("parse host id failed, host: %v, err: %v, rid: %s", host, err, kit.Rid)
+				return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+			}
+			matchedHostIDs = append(matchedHostIDs, hostID)
+		}
+	}
+
+	return matchedHostIDs, nil
+}
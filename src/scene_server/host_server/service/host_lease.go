@@ -0,0 +1,596 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateHostLease allocate the hosts in a resource directory to a module of the business until the lease expires
+func (s *Service) CreateHostLease(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.CreateHostLeaseOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+	opt.HostIDs = util.IntArrayUnique(opt.HostIDs)
+
+	lease, resBizID, moduleDefault, err := s.prepareHostLease(ctx.Kit, bizID, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	transitions, err := s.checkHostTransferState(ctx.Kit, bizID, lease.HostIDs, moduleDefault)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		lease, err = s.CoreAPI.CoreService().Host().CreateHostLease(ctx.Kit.Ctx, ctx.Kit.Header, lease)
+		if err != nil {
+			blog.Errorf("create host lease failed, err: %v, opt: %#v, rid: %s", err, opt, ctx.Kit.Rid)
+			return err
+		}
+
+		transferOpt := &metadata.TransferHostsCrossBusinessRequest{
+			SrcApplicationIDs: []int64{resBizID},
+			HostIDArr:         lease.HostIDs,
+			DstApplicationID:  bizID,
+			DstModuleIDArr:    []int64{lease.ModuleID},
+		}
+		if err := s.transferHostsAcrossBizWithAudit(ctx.Kit, transferOpt); err != nil {
+			return err
+		}
+
+		if err := s.saveHostLifecycleTransitions(ctx.Kit, transitions); err != nil {
+			return err
+		}
+
+		return s.saveHostLeaseHistory(ctx.Kit, lease, metadata.HostLeaseActionAllocate, opt.Reason,
+			metadata.AuditCreate, nil)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(lease)
+}
+
+// prepareHostLease validate the create host lease option, returns the lease to create, the resource pool business id
+// and the default flag of the module that the hosts are allocated to
+func (s *Service) prepareHostLease(kit *rest.Kit, bizID int64, opt *metadata.CreateHostLeaseOption) (
+	*metadata.HostLease, int64, int, error) {
+
+	resBizID, err := s.Logic.GetDefaultAppID(kit)
+	if err != nil {
+		blog.Errorf("get resource pool business id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, 0, 0, err
+	}
+
+	if bizID == resBizID {
+		return nil, 0, 0, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+
+	if _, err := s.getBizModule(kit, resBizID, opt.ResDirID); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if opt.ModuleID == 0 {
+		idleCond := mapstr.MapStr{common.BKAppIDField: bizID, common.BKDefaultField: common.DefaultResModuleFlag}
+		opt.ModuleID, _, err = s.Logic.GetResourcePoolModuleID(kit, idleCond)
+		if err != nil {
+			blog.Errorf("get idle module of business %d failed, err: %v, rid: %s", bizID, err, kit.Rid)
+			return nil, 0, 0, err
+		}
+	}
+
+	module, err := s.getBizModule(kit, bizID, opt.ModuleID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	relOpt := metadata.HostModuleRelationRequest{
+		HostIDArr: opt.HostIDs,
+		Fields:    []string{common.BKAppIDField, common.BKHostIDField, common.BKModuleIDField},
+	}
+	relations, err := s.Logic.GetHostRelations(kit, relOpt)
+	if err != nil {
+		blog.Errorf("get host relations failed, hostIDs: %v, err: %v, rid: %s", opt.HostIDs, err, kit.Rid)
+		return nil, 0, 0, err
+	}
+
+	inResDir := make(map[int64]bool)
+	for _, relation := range relations {
+		if relation.AppID != resBizID || relation.ModuleID != opt.ResDirID {
+			return nil, 0, 0, kit.CCError.CCErrorf(common.CCErrHostLeaseHostNotInResDir, relation.HostID,
+				opt.ResDirID)
+		}
+		inResDir[relation.HostID] = true
+	}
+
+	for _, hostID := range opt.HostIDs {
+		if !inResDir[hostID] {
+			return nil, 0, 0, kit.CCError.CCErrorf(common.CCErrHostLeaseHostNotInResDir, hostID, opt.ResDirID)
+		}
+	}
+
+	lease := &metadata.HostLease{
+		BizID:        bizID,
+		ModuleID:     opt.ModuleID,
+		HostIDs:      opt.HostIDs,
+		ResDirID:     opt.ResDirID,
+		ReturnMode:   opt.ReturnMode,
+		ExpireTime:   opt.ExpireTime,
+		RemindBefore: opt.RemindBefore,
+		Notifiers:    opt.Notifiers,
+		Reason:       opt.Reason,
+	}
+	if len(lease.Notifiers) == 0 {
+		lease.Notifiers = []string{kit.User}
+	}
+
+	return lease, resBizID, int(module.Default), nil
+}
+
+// getBizModule get the module of the business
+func (s *Service) getBizModule(kit *rest.Kit, bizID, moduleID int64) (*metadata.ModuleInst, error) {
+	query := &metadata.QueryCondition{
+		Fields:    []string{common.BKModuleIDField, common.BKDefaultField},
+		Condition: mapstr.MapStr{common.BKAppIDField: bizID, common.BKModuleIDField: moduleID},
+		Page:      metadata.BasePage{Limit: 1},
+	}
+
+	moduleRes := new(metadata.ResponseModuleInstance)
+	err := s.CoreAPI.CoreService().Instance().ReadInstanceStruct(kit.Ctx, kit.Header, common.BKInnerObjIDModule, query,
+		&moduleRes)
+	if err != nil {
+		blog.Errorf("get module failed, input: %#v, err: %v, rid: %s", query, err, kit.Rid)
+		return nil, err
+	}
+	if err := moduleRes.CCError(); err != nil {
+		blog.Errorf("get module failed, input: %#v, err: %v, rid: %s", query, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(moduleRes.Data.Info) == 0 {
+		blog.Errorf("module %d is not found in business %d, rid: %s", moduleID, bizID, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrTopoModuleIDNotfoundFailed)
+	}
+
+	return &moduleRes.Data.Info[0], nil
+}
+
+// transferHostsAcrossBizWithAudit transfer the hosts across businesses and save the host module audit log
+func (s *Service) transferHostsAcrossBizWithAudit(kit *rest.Kit, opt *metadata.TransferHostsCrossBusinessRequest) error {
+	audit := auditlog.NewHostModuleLog(s.CoreAPI.CoreService(), opt.HostIDArr)
+	if err := audit.WithPrevious(kit); err != nil {
+		blog.Errorf("get prev module host config failed, hostIDs: %v, err: %v, rid: %s", opt.HostIDArr, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+
+	result, err := s.CoreAPI.CoreService().Host().TransferToAnotherBusiness(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("transfer hosts across business failed, err: %v, opt: %#v, rid: %s", err, opt, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if err := result.CCError(); err != nil {
+		blog.Errorf("transfer hosts across business failed, err: %v, opt: %#v, rid: %s", err, opt, kit.Rid)
+		return err
+	}
+
+	if err := audit.SaveAudit(kit); err != nil {
+		blog.Errorf("save host module audit log failed, hostIDs: %v, err: %v, rid: %s", opt.HostIDArr, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+
+	return nil
+}
+
+// ExtendHostLease extend the expire time of the active host lease
+func (s *Service) ExtendHostLease(ctx *rest.Contexts) {
+	lease, err := s.getHostLeaseFromPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := new(metadata.ExtendHostLeaseOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(lease); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		updateOpt := &metadata.UpdateHostLeaseOption{ExpireTime: &opt.ExpireTime, Extend: true}
+		var err error
+		lease, err = s.CoreAPI.CoreService().Host().UpdateHostLease(ctx.Kit.Ctx, ctx.Kit.Header, lease.ID, updateOpt)
+		if err != nil {
+			blog.Errorf("extend host lease %d failed, err: %v, rid: %s", lease.ID, err, ctx.Kit.Rid)
+			return err
+		}
+
+		return s.saveHostLeaseHistory(ctx.Kit, lease, metadata.HostLeaseActionExtend, opt.Reason,
+			metadata.AuditUpdate, map[string]interface{}{"expire_time": opt.ExpireTime})
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(lease)
+}
+
+// ReturnHostLease return the hosts of the active host lease before it expires
+func (s *Service) ReturnHostLease(ctx *rest.Contexts) {
+	lease, err := s.getHostLeaseFromPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if !lease.Status.CanReturn() {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrHostLeaseNotActive, lease.ID))
+		return
+	}
+
+	if err := s.returnHostLease(ctx.Kit, lease, metadata.HostLeaseActionReturn); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ReturnExpiredHostLeaseTask return the hosts of the expired host lease, called by the task queue
+func (s *Service) ReturnExpiredHostLeaseTask(ctx *rest.Contexts) {
+	opt := new(metadata.ReturnHostLeaseTaskOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	lease, err := s.getHostLease(ctx.Kit, 0, opt.ID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the lease may be returned or extended after the task is created
+	if lease.Status != metadata.HostLeaseActive || lease.ExpireTime.After(time.Now()) {
+		blog.Infof("host lease %d is %s and expires at %s, skip returning, rid: %s", lease.ID, lease.Status,
+			lease.ExpireTime.String(), ctx.Kit.Rid)
+		ctx.RespEntity(nil)
+		return
+	}
+
+	if err := s.returnHostLease(ctx.Kit, lease, metadata.HostLeaseActionExpire); err != nil {
+		s.recordHostLeaseReturnFailure(ctx.Kit, lease, err)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// recordHostLeaseReturnFailure records the failed automatic return of the expired lease, the lease is set to return
+// failed status after it fails too many times, so that it is not returned again and again by the timer, e.g. when
+// the host lifecycle does not allow the return, the user needs to fix it and then return the hosts manually.
+func (s *Service) recordHostLeaseReturnFailure(kit *rest.Kit, lease *metadata.HostLease, returnErr error) {
+	updateOpt := &metadata.UpdateHostLeaseOption{ReturnError: returnErr.Error()}
+	updated, err := s.CoreAPI.CoreService().Host().UpdateHostLease(kit.Ctx, kit.Header, lease.ID, updateOpt)
+	if err != nil {
+		blog.Errorf("record host lease %d return failure failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+		return
+	}
+
+	if updated.Status != metadata.HostLeaseReturnFailed {
+		blog.Warnf("return host lease %d failed %d times, err: %v, rid: %s", lease.ID, updated.ReturnFailures,
+			returnErr, kit.Rid)
+		return
+	}
+
+	blog.Errorf("return host lease %d failed %d times, stop returning it, err: %v, rid: %s", lease.ID,
+		updated.ReturnFailures, returnErr, kit.Rid)
+	if err := s.saveHostLeaseHistory(kit, updated, metadata.HostLeaseActionReturnFailed, updated.ReturnError,
+		metadata.AuditUpdate, map[string]interface{}{common.BKStatusField: updated.Status}); err != nil {
+		blog.Errorf("save host lease %d return failed history failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+	}
+}
+
+// returnHostLease transfer the leased hosts that are still in the business to the recycle module of the business,
+// and then back to the resource directory if the lease requires, the hosts that are already transferred out of the
+// business are not affected
+func (s *Service) returnHostLease(kit *rest.Kit, lease *metadata.HostLease, action metadata.HostLeaseAction) error {
+	relOpt := metadata.HostModuleRelationRequest{
+		ApplicationID: lease.BizID,
+		HostIDArr:     lease.HostIDs,
+		Fields:        []string{common.BKHostIDField},
+	}
+	relations, err := s.Logic.GetHostRelations(kit, relOpt)
+	if err != nil {
+		blog.Errorf("get host relations failed, lease: %d, err: %v, rid: %s", lease.ID, err, kit.Rid)
+		return err
+	}
+
+	hostIDs := make([]int64, 0)
+	for _, relation := range relations {
+		hostIDs = append(hostIDs, relation.HostID)
+	}
+	hostIDs = util.IntArrayUnique(hostIDs)
+
+	var recycleModuleID int64
+	transitions := make([]metadata.HostLifecycleHistory, 0)
+	if len(hostIDs) > 0 {
+		recycleCond := mapstr.MapStr{common.BKAppIDField: lease.BizID,
+			common.BKDefaultField: common.DefaultRecycleModuleFlag}
+		recycleModuleID, _, err = s.Logic.GetResourcePoolModuleID(kit, recycleCond)
+		if err != nil {
+			blog.Errorf("get recycle module of business %d failed, err: %v, rid: %s", lease.BizID, err, kit.Rid)
+			return err
+		}
+
+		transitions, err = s.checkHostLeaseReturnState(kit, lease, hostIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	status := metadata.HostLeaseReturned
+	if action == metadata.HostLeaseActionExpire {
+		status = metadata.HostLeaseExpired
+	}
+
+	return s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(kit.Ctx, kit.Header, func() error {
+		if len(hostIDs) > 0 {
+			if err := s.transferLeasedHostsBack(kit, lease, hostIDs, recycleModuleID); err != nil {
+				return err
+			}
+
+			if err := s.saveHostLifecycleTransitions(kit, transitions); err != nil {
+				return err
+			}
+		}
+
+		updateOpt := &metadata.UpdateHostLeaseOption{Status: status}
+		updated, err := s.CoreAPI.CoreService().Host().UpdateHostLease(kit.Ctx, kit.Header, lease.ID, updateOpt)
+		if err != nil {
+			blog.Errorf("update host lease %d status to %s failed, err: %v, rid: %s", lease.ID, status, err, kit.Rid)
+			return err
+		}
+
+		return s.saveHostLeaseHistory(kit, updated, action, "", metadata.AuditUpdate,
+			map[string]interface{}{common.BKStatusField: status})
+	})
+}
+
+// checkHostLeaseReturnState check if the leased hosts can be returned when the host lifecycle is enabled
+func (s *Service) checkHostLeaseReturnState(kit *rest.Kit, lease *metadata.HostLease, hostIDs []int64) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	if lease.ReturnMode == metadata.HostLeaseReturnToRecycle {
+		return s.checkHostTransferState(kit, lease.BizID, hostIDs, common.DefaultRecycleModuleFlag)
+	}
+
+	resBizID, err := s.Logic.GetDefaultAppID(kit)
+	if err != nil {
+		blog.Errorf("get resource pool business id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	module, err := s.getBizModule(kit, resBizID, lease.ResDirID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.checkHostTransferState(kit, resBizID, hostIDs, int(module.Default))
+}
+
+// transferLeasedHostsBack transfer the leased hosts to the recycle module of the business, and then to the resource
+// directory that they are leased from if the lease requires
+func (s *Service) transferLeasedHostsBack(kit *rest.Kit, lease *metadata.HostLease, hostIDs []int64,
+	recycleModuleID int64) error {
+
+	audit := auditlog.NewHostModuleLog(s.CoreAPI.CoreService(), hostIDs)
+	if err := audit.WithPrevious(kit); err != nil {
+		blog.Errorf("get prev module host config failed, hostIDs: %v, err: %v, rid: %s", hostIDs, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+
+	transferOpt := &metadata.TransferHostToInnerModule{
+		ApplicationID: lease.BizID,
+		ModuleID:      recycleModuleID,
+		HostID:        hostIDs,
+	}
+	if _, err := s.CoreAPI.CoreService().Host().TransferToInnerModule(kit.Ctx, kit.Header, transferOpt); err != nil {
+		blog.Errorf("transfer leased hosts to recycle module failed, err: %v, opt: %#v, rid: %s", err, transferOpt,
+			kit.Rid)
+		return err
+	}
+
+	if err := audit.SaveAudit(kit); err != nil {
+		blog.Errorf("save host module audit log failed, hostIDs: %v, err: %v, rid: %s", hostIDs, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+
+	if lease.ReturnMode != metadata.HostLeaseReturnToResourceDir {
+		return nil
+	}
+
+	resPoolOpt := &metadata.DefaultModuleHostConfigParams{
+		ApplicationID: lease.BizID,
+		HostIDs:       hostIDs,
+		ModuleID:      lease.ResDirID,
+	}
+	if _, err := s.Logic.MoveHostToResourcePool(kit, resPoolOpt); err != nil {
+		blog.Errorf("move leased hosts to resource directory failed, err: %v, opt: %#v, rid: %s", err, resPoolOpt,
+			kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// SearchHostLease search the host leases of the business
+func (s *Service) SearchHostLease(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.SearchHostLeaseOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.BizID = bizID
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, ccErr := s.CoreAPI.CoreService().Host().SearchHostLease(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if ccErr != nil {
+		blog.Errorf("search host leases failed, err: %v, opt: %#v, rid: %s", ccErr, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// SearchHostLeaseHistory search the host lease histories of the business
+func (s *Service) SearchHostLeaseHistory(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.SearchHostLeaseHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.BizID = bizID
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, ccErr := s.CoreAPI.CoreService().Host().SearchHostLeaseHistory(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if ccErr != nil {
+		blog.Errorf("search host lease histories failed, err: %v, opt: %#v, rid: %s", ccErr, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// getHostLeaseFromPath get the host lease by the id and business id in the request path
+func (s *Service) getHostLeaseFromPath(ctx *rest.Contexts) (*metadata.HostLease, error) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	return s.getHostLease(ctx.Kit, bizID, id)
+}
+
+// getHostLease get the host lease by id, the business id is not checked if it is 0
+func (s *Service) getHostLease(kit *rest.Kit, bizID, id int64) (*metadata.HostLease, errors.CCErrorCoder) {
+	opt := &metadata.SearchHostLeaseOption{
+		BizID: bizID,
+		IDs:   []int64{id},
+		Page:  metadata.BasePage{Limit: 1},
+	}
+	result, err := s.CoreAPI.CoreService().Host().SearchHostLease(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("get host lease %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(result.Info) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrHostLeaseNotExist, id)
+	}
+
+	return &result.Info[0], nil
+}
+
+// saveHostLeaseHistory save the host lease history and audit log of the lease action
+func (s *Service) saveHostLeaseHistory(kit *rest.Kit, lease *metadata.HostLease, action metadata.HostLeaseAction,
+	reason string, auditAction metadata.ActionType, updateFields map[string]interface{}) error {
+
+	history := metadata.HostLeaseHistory{
+		LeaseID:    lease.ID,
+		BizID:      lease.BizID,
+		HostIDs:    lease.HostIDs,
+		Action:     action,
+		ExpireTime: lease.ExpireTime,
+		Reason:     reason,
+		Operator:   kit.User,
+	}
+	histories := []metadata.HostLeaseHistory{history}
+	if err := s.CoreAPI.CoreService().Host().CreateHostLeaseHistory(kit.Ctx, kit.Header, histories); err != nil {
+		blog.Errorf("create host lease %d history failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+		return err
+	}
+
+	audit := auditlog.NewHostLeaseAuditLog(s.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, auditAction).WithUpdateFields(updateFields)
+	auditLog := audit.GenerateAuditLog(auditParam, lease)
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save host lease %d audit log failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,540 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// UpdateHostLifecycle update the host lifecycle definition
+func (s *Service) UpdateHostLifecycle(ctx *rest.Contexts) {
+	lifecycle := new(metadata.HostLifecycle)
+	if err := ctx.DecodeInto(lifecycle); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := lifecycle.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.validateHostLifecycleStates(ctx.Kit, lifecycle); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		err := s.CoreAPI.CoreService().Host().UpdateHostLifecycle(ctx.Kit.Ctx, ctx.Kit.Header, lifecycle)
+		if err != nil {
+			blog.Errorf("update host lifecycle failed, err: %v, lifecycle: %#v, rid: %s", err, lifecycle, ctx.Kit.Rid)
+			return err
+		}
+
+		updateFields, convErr := mapstr.Struct2Map(lifecycle)
+		if convErr != nil {
+			blog.Errorf("convert host lifecycle to map failed, err: %v, rid: %s", convErr, ctx.Kit.Rid)
+			return ctx.Kit.CCError.CCError(common.CCErrCommParseDataFailed)
+		}
+
+		audit := auditlog.NewHostLifecycleAuditLog(s.CoreAPI.CoreService())
+		auditParam := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditUpdate).
+			WithUpdateFields(updateFields)
+		auditLog := audit.GenerateLifecycleAuditLog(auditParam, lifecycle)
+		if err := audit.SaveAuditLog(ctx.Kit, *auditLog); err != nil {
+			blog.Errorf("save host lifecycle audit log failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// validateHostLifecycleStates validate that the lifecycle states are the enum options of the host bk_state attribute
+func (s *Service) validateHostLifecycleStates(kit *rest.Kit, lifecycle *metadata.HostLifecycle) error {
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKObjIDField:      common.BKInnerObjIDHost,
+			common.BKPropertyIDField: common.BKHostState,
+		},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	attrs, err := s.CoreAPI.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.Errorf("get host state attribute failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	if len(attrs.Info) == 0 {
+		blog.Errorf("host state attribute is not found, rid: %s", kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommNotFound, common.BKHostState)
+	}
+
+	options, err := metadata.ParseEnumOption(attrs.Info[0].Option)
+	if err != nil {
+		blog.Errorf("parse host state attribute option failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostState)
+	}
+
+	optionIDs := make([]string, 0)
+	for _, option := range options {
+		optionIDs = append(optionIDs, option.ID)
+	}
+
+	for _, state := range lifecycle.States {
+		if !util.InStrArr(optionIDs, state.ID) {
+			return kit.CCError.CCErrorf(common.CCErrHostLifecycleStateInvalid, state.ID)
+		}
+	}
+
+	return nil
+}
+
+// GetHostLifecycle get the host lifecycle definition
+func (s *Service) GetHostLifecycle(ctx *rest.Contexts) {
+	lifecycle, err := s.CoreAPI.CoreService().Host().GetHostLifecycle(ctx.Kit.Ctx, ctx.Kit.Header)
+	if err != nil {
+		blog.Errorf("get host lifecycle failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(lifecycle)
+}
+
+// SearchHostLifecycleHistory search the lifecycle transition histories of the hosts
+func (s *Service) SearchHostLifecycleHistory(ctx *rest.Contexts) {
+	opt := new(metadata.SearchHostLifecycleHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Host().SearchHostLifecycleHistory(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search host lifecycle history failed, err: %v, opt: %#v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ListStuckHosts list the hosts that stay in a lifecycle state longer than the specified duration
+func (s *Service) ListStuckHosts(ctx *rest.Contexts) {
+	opt := new(metadata.ListStuckHostOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Host().ListStuckHosts(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list stuck hosts failed, err: %v, opt: %#v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// getEnabledHostLifecycle get the host lifecycle definition, returns nil if the lifecycle is not enabled
+func (s *Service) getEnabledHostLifecycle(kit *rest.Kit) (*metadata.HostLifecycle, error) {
+	lifecycle, err := s.CoreAPI.CoreService().Host().GetHostLifecycle(kit.Ctx, kit.Header)
+	if err != nil {
+		blog.Errorf("get host lifecycle failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	if !lifecycle.Enabled {
+		return nil, nil
+	}
+	return lifecycle, nil
+}
+
+// checkHostStateUpdate check if the hosts can be updated with the data when the host lifecycle is enabled, returns
+// the lifecycle transitions of the hosts whose bk_state is changed by the update
+func (s *Service) checkHostStateUpdate(kit *rest.Kit, hostIDs []int64, data mapstr.MapStr) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	if _, exists := data[common.BKHostState]; !exists {
+		return nil, nil
+	}
+
+	lifecycle, err := s.getEnabledHostLifecycle(kit)
+	if err != nil || lifecycle == nil {
+		return nil, err
+	}
+
+	hostBizMap, hostMap, err := s.getHostBizMapAndHostInfoMap(kit, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.checkHostStateTransitions(kit, lifecycle, hostIDs, data, hostBizMap, hostMap)
+}
+
+// checkHostStateTransitions check if the hosts can be updated with the data by the enabled host lifecycle, the hosts
+// and their business are got in advance so that the hosts updated one by one do not need to get them repeatedly
+func (s *Service) checkHostStateTransitions(kit *rest.Kit, lifecycle *metadata.HostLifecycle, hostIDs []int64,
+	data mapstr.MapStr, hostBizMap map[int64]int64, hostMap map[int64]mapstr.MapStr) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	stateVal, exists := data[common.BKHostState]
+	if !exists {
+		return nil, nil
+	}
+
+	to := util.GetStrByInterface(stateVal)
+	histories := make([]metadata.HostLifecycleHistory, 0)
+	for _, hostID := range hostIDs {
+		host, exists := hostMap[hostID]
+		if !exists {
+			blog.Errorf("host %d is not found, rid: %s", hostID, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKHostIDField)
+		}
+
+		from := util.GetStrByInterface(host[common.BKHostState])
+		if from == to {
+			continue
+		}
+
+		newHost := host.Clone()
+		newHost.Merge(data)
+		if rawErr := lifecycle.CheckTransition(hostID, from, to, kit.User, newHost); rawErr.ErrCode != 0 {
+			blog.Errorf("host %d can not transit from %s to %s, err: %v, rid: %s", hostID, from, to, rawErr, kit.Rid)
+			return nil, rawErr.ToCCError(kit.CCError)
+		}
+
+		histories = append(histories, metadata.HostLifecycleHistory{
+			HostID:   hostID,
+			InnerIP:  util.GetStrByInterface(host[common.BKHostInnerIPField]),
+			BizID:    hostBizMap[hostID],
+			From:     from,
+			To:       to,
+			Trigger:  metadata.HostLifecycleUpdate,
+			Operator: kit.User,
+		})
+	}
+
+	return histories, nil
+}
+
+// checkCloneHostState check if the destination host can be cloned with the properties of the source host when the
+// host lifecycle is enabled, the bk_state of the source host is cloned along with the other properties
+func (s *Service) checkCloneHostState(kit *rest.Kit, srcHostID, dstHostID int64) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	lifecycle, err := s.getEnabledHostLifecycle(kit)
+	if err != nil || lifecycle == nil {
+		return nil, err
+	}
+
+	hostBizMap, hostMap, err := s.getHostBizMapAndHostInfoMap(kit, []int64{srcHostID, dstHostID})
+	if err != nil {
+		return nil, err
+	}
+
+	srcHost, exists := hostMap[srcHostID]
+	if !exists {
+		blog.Errorf("host %d is not found, rid: %s", srcHostID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKHostIDField)
+	}
+
+	// the identity fields are not cloned, they must not be used to check the required fields of the new state
+	data := srcHost.Clone()
+	for _, field := range []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKHostInnerIPv6Field,
+		common.BKCloudIDField, common.BKAgentIDField} {
+		delete(data, field)
+	}
+
+	return s.checkHostStateTransitions(kit, lifecycle, []int64{dstHostID}, data, hostBizMap, hostMap)
+}
+
+// checkImportHostsState check if the imported hosts can be updated when the host lifecycle is enabled, the hosts
+// whose bk_state update is not allowed are removed from the hosts to update and returned as the error messages
+func (s *Service) checkImportHostsState(kit *rest.Kit, hosts map[int64]map[string]interface{}, hostIDs []int64,
+	indexHostIDMap map[int64]int64) ([]metadata.HostLifecycleHistory, []string, error) {
+
+	hasState := false
+	for _, host := range hosts {
+		if _, exists := host[common.BKHostState]; exists {
+			hasState = true
+			break
+		}
+	}
+
+	if !hasState {
+		return nil, nil, nil
+	}
+
+	lifecycle, err := s.getEnabledHostLifecycle(kit)
+	if err != nil || lifecycle == nil {
+		return nil, nil, err
+	}
+
+	hostBizMap, hostMap, err := s.getHostBizMapAndHostInfoMap(kit, hostIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ccLang := s.Language.CreateDefaultCCLanguageIf(httpheader.GetLanguage(kit.Header))
+	transitions := make([]metadata.HostLifecycleHistory, 0)
+	errMsg := make([]string, 0)
+	for _, index := range util.SortedMapInt64Keys(hosts) {
+		histories, err := s.checkHostStateTransitions(kit, lifecycle, []int64{indexHostIDMap[index]}, hosts[index],
+			hostBizMap, hostMap)
+		if err != nil {
+			errMsg = append(errMsg, ccLang.Languagef("import_host_update_fail", index, err.Error()))
+			delete(hosts, index)
+			continue
+		}
+		transitions = append(transitions, histories...)
+	}
+
+	return transitions, errMsg, nil
+}
+
+// checkHostTransferState check if the hosts can be transferred to the module with the default flag in the business
+// when the host lifecycle is enabled, returns the lifecycle transitions of the hosts whose bk_state changes to the
+// state that the module leads to
+func (s *Service) checkHostTransferState(kit *rest.Kit, bizID int64, hostIDs []int64, moduleDefault int) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	if len(hostIDs) == 0 {
+		return nil, nil
+	}
+
+	lifecycle, err := s.getEnabledHostLifecycle(kit)
+	if err != nil || lifecycle == nil {
+		return nil, err
+	}
+
+	state, exists := lifecycle.GetStateByModuleDefault(moduleDefault)
+	if !exists {
+		return nil, nil
+	}
+
+	_, hostMap, err := s.getHostBizMapAndHostInfoMap(kit, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]metadata.HostLifecycleHistory, 0)
+	for _, hostID := range hostIDs {
+		host, exists := hostMap[hostID]
+		if !exists {
+			blog.Errorf("host %d is not found, rid: %s", hostID, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKHostIDField)
+		}
+
+		from := util.GetStrByInterface(host[common.BKHostState])
+		if from == state.ID {
+			continue
+		}
+
+		if rawErr := lifecycle.CheckTransition(hostID, from, state.ID, kit.User, host); rawErr.ErrCode != 0 {
+			blog.Errorf("host %d can not transit from %s to %s, err: %v, rid: %s", hostID, from, state.ID, rawErr,
+				kit.Rid)
+			return nil, rawErr.ToCCError(kit.CCError)
+		}
+
+		histories = append(histories, metadata.HostLifecycleHistory{
+			HostID:   hostID,
+			InnerIP:  util.GetStrByInterface(host[common.BKHostInnerIPField]),
+			BizID:    bizID,
+			From:     from,
+			To:       state.ID,
+			Trigger:  metadata.HostLifecycleTransfer,
+			Operator: kit.User,
+		})
+	}
+
+	return histories, nil
+}
+
+// checkTransferPlansState check if the hosts can be transferred by the transfer plans when the host lifecycle is
+// enabled, the hosts transferred to the inner module enter the state that the inner module leads to, and the hosts
+// transferred to the normal modules enter the state that the normal modules lead to
+func (s *Service) checkTransferPlansState(kit *rest.Kit, bizID int64,
+	transToInnerOpt *metadata.TransferHostToInnerModule, transToNormalPlans map[string]*metadata.HostsModuleRelation) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	transitions := make([]metadata.HostLifecycleHistory, 0)
+	if transToInnerOpt != nil {
+		moduleDefault, err := s.getModuleDefaultFlag(kit, transToInnerOpt.ModuleID)
+		if err != nil {
+			return nil, err
+		}
+
+		histories, err := s.checkHostTransferState(kit, bizID, transToInnerOpt.HostID, moduleDefault)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, histories...)
+	}
+
+	normalHostIDs := make([]int64, 0)
+	for _, plan := range transToNormalPlans {
+		normalHostIDs = append(normalHostIDs, plan.HostID...)
+	}
+
+	histories, err := s.checkHostTransferState(kit, bizID, normalHostIDs, common.DefaultFlagDefaultValue)
+	if err != nil {
+		return nil, err
+	}
+	return append(transitions, histories...), nil
+}
+
+// checkResourcePoolTransferState check if the hosts can be moved to the resource pool directory when the host
+// lifecycle is enabled, the hosts are moved to the idle module of the resource pool if the directory is not specified
+func (s *Service) checkResourcePoolTransferState(kit *rest.Kit, conf *metadata.DefaultModuleHostConfigParams) (
+	[]metadata.HostLifecycleHistory, error) {
+
+	resBizID, err := s.Logic.GetDefaultAppID(kit)
+	if err != nil {
+		blog.Errorf("get resource pool business id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	moduleDefault := common.DefaultResModuleFlag
+	if conf.ModuleID != 0 {
+		moduleDefault, err = s.getModuleDefaultFlag(kit, conf.ModuleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.checkHostTransferState(kit, resBizID, conf.HostIDs, moduleDefault)
+}
+
+// getModuleDefaultFlag get the default flag of the module
+func (s *Service) getModuleDefaultFlag(kit *rest.Kit, moduleID int64) (int, error) {
+	query := &metadata.QueryCondition{
+		Fields:    []string{common.BKModuleIDField, common.BKDefaultField},
+		Condition: mapstr.MapStr{common.BKModuleIDField: moduleID},
+		Page:      metadata.BasePage{Limit: 1},
+	}
+
+	moduleRes := new(metadata.ResponseModuleInstance)
+	err := s.CoreAPI.CoreService().Instance().ReadInstanceStruct(kit.Ctx, kit.Header, common.BKInnerObjIDModule, query,
+		&moduleRes)
+	if err != nil {
+		blog.Errorf("get module failed, input: %#v, err: %v, rid: %s", query, err, kit.Rid)
+		return 0, err
+	}
+	if err := moduleRes.CCError(); err != nil {
+		blog.Errorf("get module failed, input: %#v, err: %v, rid: %s", query, err, kit.Rid)
+		return 0, err
+	}
+
+	if len(moduleRes.Data.Info) == 0 {
+		blog.Errorf("module %d is not found, rid: %s", moduleID, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrTopoModuleIDNotfoundFailed)
+	}
+
+	return int(moduleRes.Data.Info[0].Default), nil
+}
+
+// saveHostLifecycleTransitions save the host lifecycle transitions after the operation succeeds. the bk_state of
+// the transferred hosts are updated here, the updated hosts already have the new bk_state.
+func (s *Service) saveHostLifecycleTransitions(kit *rest.Kit, histories []metadata.HostLifecycleHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+
+	stateHostMap := make(map[string][]int64)
+	for _, history := range histories {
+		if history.Trigger != metadata.HostLifecycleTransfer {
+			continue
+		}
+		stateHostMap[history.To] = append(stateHostMap[history.To], history.HostID)
+	}
+
+	for state, hostIDs := range stateHostMap {
+		if err := s.updateHostState(kit, hostIDs, state); err != nil {
+			return err
+		}
+	}
+
+	opt := &metadata.CreateHostLifecycleHistoryOption{Histories: histories}
+	if err := s.CoreAPI.CoreService().Host().CreateHostLifecycleHistory(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("create host lifecycle histories failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	audit := auditlog.NewHostLifecycleAuditLog(s.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate)
+	auditLogs := make([]metadata.AuditLog, 0)
+	for idx := range histories {
+		auditLogs = append(auditLogs, *audit.GenerateTransitionAuditLog(auditParam, &histories[idx]))
+	}
+
+	if err := audit.SaveAuditLog(kit, auditLogs...); err != nil {
+		blog.Errorf("save host lifecycle transition audit logs failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// updateHostState update the bk_state of the transferred hosts to the state that the target module leads to
+func (s *Service) updateHostState(kit *rest.Kit, hostIDs []int64, state string) errors.CCErrorCoder {
+	data := mapstr.MapStr{common.BKHostState: state}
+	audit := auditlog.NewHostAudit(s.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).WithUpdateFields(data)
+	auditCond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}}
+	auditLogs, err := audit.GenerateAuditLogByCond(auditParam, 0, auditCond)
+	if err != nil {
+		blog.Errorf("generate host audit log failed, hostIDs: %v, err: %v, rid: %s", hostIDs, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+	}
+
+	opt := &metadata.UpdateOption{Condition: auditCond, Data: data}
+	_, err = s.CoreAPI.CoreService().Instance().UpdateInstance(kit.Ctx, kit.Header, common.BKInnerObjIDHost, opt)
+	if err != nil {
+		blog.Errorf("update host state failed, hostIDs: %v, state: %s, err: %v, rid: %s", hostIDs, state, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if err := audit.SaveAuditLog(kit, auditLogs...); err != nil {
+		blog.Errorf("save host audit log failed, hostIDs: %v, err: %v, rid: %s", hostIDs, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// SearchHostSnapHistory search the snapshot field change or drift histories of the hosts
+func (s *Service) SearchHostSnapHistory(ctx *rest.Contexts) {
+	opt := new(metadata.SearchHostSnapHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Host().SearchHostSnapHistory(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search host snapshot history failed, err: %v, opt: %#v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ListHostSnapChangedHosts list the hosts whose snapshot fields are changed or drifted in the time range
+func (s *Service) ListHostSnapChangedHosts(ctx *rest.Contexts) {
+	opt := new(metadata.ListHostSnapChangedHostOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Host().ListHostSnapChangedHosts(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list host snapshot changed hosts failed, err: %v, opt: %#v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
@@ -521,9 +521,32 @@ func (s *Service) generateModuleApplyPlan(ctx *rest.Contexts, planRequest *metad
 		finalRules = append(finalRules, item)
 	}
 
+	// merge the business, set and set template scope rules, the overridden scope rules are reported as conflicts
+	modules, err := s.getModuleRelateHostApply(ctx.Kit, planRequest.BizID, planRequest.ModuleIDs, nil)
+	if err != nil {
+		blog.Errorf("get modules failed, bizID: %d, ids: %v, err: %v, rid: %s", planRequest.BizID,
+			planRequest.ModuleIDs, err, rid)
+		return metadata.HostApplyPlanResult{}, ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	finalRules, scopeConflicts, ccErr := s.mergeModuleScopeRules(ctx.Kit, planRequest.BizID, modules, finalRules)
+	if ccErr != nil {
+		return metadata.HostApplyPlanResult{}, ccErr
+	}
+
+	hostIDs := make([]int64, 0)
+	for hostID := range hostModuleMap {
+		hostIDs = append(hostIDs, hostID)
+	}
+	hostRules, ccErr := s.getDynamicGroupHostRules(ctx.Kit, planRequest.BizID, hostIDs)
+	if ccErr != nil {
+		return metadata.HostApplyPlanResult{}, ccErr
+	}
+
 	planOption := metadata.HostApplyPlanOption{
 		Rules:       finalRules,
 		HostModules: hostModules,
+		HostRules:   hostRules,
 	}
 
 	planResult, ccErr := s.CoreAPI.CoreService().HostApplyRule().GenerateApplyPlan(ctx.Kit.Ctx, ctx.Kit.Header,
@@ -533,7 +556,14 @@ func (s *Service) generateModuleApplyPlan(ctx *rest.Contexts, planRequest *metad
 			planRequest.BizID, planOption, ccErr, rid)
 		return planResult, ccErr
 	}
+
 	planResult.Rules = rules.Info
+	for _, rule := range finalRules {
+		if rule.Scope.IsScopeRule() {
+			planResult.Rules = append(planResult.Rules, rule)
+		}
+	}
+	planResult.ScopeConflicts = scopeConflicts
 	return planResult, nil
 }
 
@@ -806,8 +836,9 @@ func (s *Service) getModuleRelateHostApply(kit *rest.Kit, bizID int64, moduleIDs
 	[]metadata.ModuleInst, error) {
 
 	moduleFilter := &metadata.QueryCondition{
-		Page:   metadata.BasePage{Limit: common.BKNoLimit},
-		Fields: []string{common.BKModuleIDField, common.HostApplyEnabledField, common.BKServiceTemplateIDField},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+		Fields: []string{common.BKAppIDField, common.BKSetIDField, common.BKModuleIDField, common.HostApplyEnabledField,
+			common.BKServiceTemplateIDField, common.BKSetTemplateIDField},
 		Condition: mapstr.MapStr{
 			common.BKAppIDField: bizID,
 		},
@@ -16,6 +16,7 @@ import (
 	"configcenter/src/ac"
 	"configcenter/src/ac/meta"
 	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
@@ -31,11 +32,12 @@ func (s *Service) LockHost(ctx *rest.Contexts) {
 		return
 	}
 
-	if 0 == len(input.IDS) {
-		blog.Errorf("lock host, id_list is empty,input:%+v, rid:%s", input, ctx.Kit.Rid)
-		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsNeedSet, "id_list"))
+	if rawErr := input.ValidateLock(); rawErr.ErrCode != 0 {
+		blog.Errorf("lock host, input is invalid, input: %+v, rid: %s", input, ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
 		return
 	}
+	input.Force = false
 
 	// auth: check authorization
 	if err := s.AuthManager.AuthorizeByHostsIDs(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update, input.IDS...); err != nil {
@@ -82,6 +84,8 @@ func (s *Service) UnlockHost(ctx *rest.Contexts) {
 		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsNeedSet, "id_list"))
 		return
 	}
+	// the locks of other users can only be released by the administrators with the force unlock api
+	input.Force = false
 
 	// auth: check authorization
 	if err := s.AuthManager.AuthorizeByHostsIDs(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update, input.IDS...); err != nil {
@@ -156,3 +160,87 @@ func (s *Service) QueryHostLock(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(hostLockInfos)
 }
+
+// ForceUnlockHost release the host locks regardless of the lock owners, it is used by the administrators
+func (s *Service) ForceUnlockHost(ctx *rest.Contexts) {
+	input := new(metadata.HostLockRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := input.ValidateLock(); rawErr.ErrCode != 0 {
+		blog.Errorf("force unlock host, input is invalid, input: %+v, rid: %s", input, ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+	input.Force = true
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		listOpt := &metadata.ListHostLockOption{HostIDs: input.IDS, Page: metadata.BasePage{Limit: len(input.IDS)}}
+		locks, err := s.CoreAPI.CoreService().Host().ListHostLock(ctx.Kit.Ctx, ctx.Kit.Header, listOpt)
+		if err != nil {
+			blog.Errorf("list host locks failed, err: %v, host ids: %v, rid: %s", err, input.IDS, ctx.Kit.Rid)
+			return err
+		}
+
+		if err := s.Logic.UnlockHost(ctx.Kit, input); err != nil {
+			blog.Errorf("force unlock host failed, err: %v, input: %+v, rid: %s", err, input, ctx.Kit.Rid)
+			return err
+		}
+
+		return s.saveForceUnlockAuditLog(ctx.Kit, locks.Info, input.Reason)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// saveForceUnlockAuditLog save the audit logs of the host locks that are force released with the reason, the operator
+// is recorded as the user of the audit log
+func (s *Service) saveForceUnlockAuditLog(kit *rest.Kit, locks []metadata.HostLockData, reason string) error {
+	if len(locks) == 0 {
+		return nil
+	}
+
+	audit := auditlog.NewHostLockAuditLog(s.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditDelete)
+	auditLogs := make([]metadata.AuditLog, len(locks))
+	for idx, lock := range locks {
+		auditLogs[idx] = *audit.GenerateForceUnlockAuditLog(auditParam, lock, reason)
+	}
+
+	if err := audit.SaveAuditLog(kit, auditLogs...); err != nil {
+		blog.Errorf("save force unlock host audit log failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// ListHostLock list the active host locks with the owners, reasons, scopes and expire times
+func (s *Service) ListHostLock(ctx *rest.Contexts) {
+	opt := new(metadata.ListHostLockOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Host().ListHostLock(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list host locks failed, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
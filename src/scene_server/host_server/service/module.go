@@ -49,6 +49,13 @@ func (s *Service) TransferHostModule(ctx *rest.Contexts) {
 		}
 	}
 
+	transitions, err := s.checkHostTransferState(ctx.Kit, config.ApplicationID, config.HostID,
+		common.DefaultFlagDefaultValue)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	audit := auditlog.NewHostModuleLog(s.CoreAPI.CoreService(), config.HostID)
 	if err := audit.WithPrevious(ctx.Kit); err != nil {
 		blog.Errorf("host module relation, get prev module host config failed, err: %v,param:%+v,rid:%s", err, config, ctx.Kit.Rid)
@@ -69,7 +76,7 @@ func (s *Service) TransferHostModule(ctx *rest.Contexts) {
 			blog.Errorf("host module relation, save audit log failed, err: %v,input:%+v,rid:%s", err, config, ctx.Kit.Rid)
 			return ctx.Kit.CCError.Errorf(common.CCErrCommHTTPDoRequestFailed, err.Error())
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -107,6 +114,12 @@ func (s *Service) MoveHostToResourcePool(ctx *rest.Contexts) {
 		return
 	}
 
+	transitions, err := s.checkResourcePoolTransferState(ctx.Kit, conf)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	var exceptionArr []metadata.ExceptionResult
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		var err error
@@ -115,7 +128,7 @@ func (s *Service) MoveHostToResourcePool(ctx *rest.Contexts) {
 			blog.Errorf("move host to resource pool failed, err:%s, input:%#v, rid:%s", err.Error(), conf, ctx.Kit.Rid)
 			return err
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -134,6 +147,13 @@ func (s *Service) AssignHostToApp(ctx *rest.Contexts) {
 		return
 	}
 
+	transitions, err := s.checkHostTransferState(ctx.Kit, conf.ApplicationID, conf.HostIDs,
+		common.DefaultResModuleFlag)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	var exceptionArr []metadata.ExceptionResult
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		var err error
@@ -142,7 +162,7 @@ func (s *Service) AssignHostToApp(ctx *rest.Contexts) {
 			blog.Errorf("assign host to app, but assign to app http do error. err: %v, input:%+v,rid:%s", err, conf, ctx.Kit.Rid)
 			return err
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -259,13 +279,25 @@ func (s *Service) TransferHostAcrossBusiness(ctx *rest.Contexts) {
 		return
 	}
 
+	moduleDefault, err := s.getModuleDefaultFlag(ctx.Kit, data.DstModuleID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	transitions, err := s.checkHostTransferState(ctx.Kit, data.DstAppID, data.HostID, moduleDefault)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		err := s.Logic.TransferHostAcrossBusiness(ctx.Kit, data.SrcAppID, data.DstAppID, data.HostID, data.DstModuleID)
 		if err != nil {
 			blog.Errorf("TransferHostAcrossBusiness logcis err:%s,input:%#v,rid:%s", err.Error(), data, ctx.Kit.Rid)
 			return err
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -373,6 +405,12 @@ func (s *Service) moveHostToDefaultModule(ctx *rest.Contexts, defaultModuleFlag
 		return
 	}
 
+	transitions, err := s.checkHostTransferState(ctx.Kit, bizID, conf.HostIDs, defaultModuleFlag)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	audit := auditlog.NewHostModuleLog(s.CoreAPI.CoreService(), conf.HostIDs)
 	if err := audit.WithPrevious(ctx.Kit); err != nil {
 		blog.Errorf("move host to default module s failed, get prev module host config failed, hostIDs: %v, err: %s, rid: %s", conf.HostIDs, err.Error(), ctx.Kit.Rid)
@@ -399,7 +437,7 @@ func (s *Service) moveHostToDefaultModule(ctx *rest.Contexts, defaultModuleFlag
 			blog.ErrorJSON("move host to default module failed, save audit log failed, input:%s, err:%s, rid:%s", conf, err, ctx.Kit.Rid)
 			return ctx.Kit.CCError.Errorf(common.CCErrCommResourceInitFailed, "audit server")
 		}
-		return nil
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -443,16 +481,29 @@ func (s *Service) TransferHostResourceDirectory(ctx *rest.Contexts) {
 		return
 	}
 
-	err := s.CoreAPI.CoreService().Host().TransferHostResourceDirectory(ctx.Kit.Ctx, ctx.Kit.Header, input)
+	transitions, err := s.checkResourcePoolTransferState(ctx.Kit,
+		&metadata.DefaultModuleHostConfigParams{ModuleID: input.ModuleID, HostIDs: input.HostID})
 	if err != nil {
-		blog.Errorf("TransferHostResourceDirectory failed with coreservice http failed, input: %v, err: %v, rid: %s", input, err, ctx.Kit.Rid)
 		ctx.RespAutoError(err)
 		return
 	}
 
-	if err := audit.SaveAudit(ctx.Kit); err != nil {
-		blog.Errorf("move host to resource pool, but save audit log failed, err: %v, input:%+v,rid:%s", err, input.HostID, ctx.Kit.Rid)
-		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommResourceInitFailed, "audit server"))
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		err := s.CoreAPI.CoreService().Host().TransferHostResourceDirectory(ctx.Kit.Ctx, ctx.Kit.Header, input)
+		if err != nil {
+			blog.Errorf("TransferHostResourceDirectory failed with coreservice http failed, input: %v, err: %v, rid: %s", input, err, ctx.Kit.Rid)
+			return err
+		}
+
+		if err := audit.SaveAudit(ctx.Kit); err != nil {
+			blog.Errorf("move host to resource pool, but save audit log failed, err: %v, input:%+v,rid:%s", err, input.HostID, ctx.Kit.Rid)
+			return ctx.Kit.CCError.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+		}
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
 		return
 	}
 
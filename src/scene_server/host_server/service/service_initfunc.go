@@ -16,6 +16,9 @@ func (s *Service) initService(web *restful.WebService) {
 	s.initHost(web)
 	s.initHostapplyrule(web)
 	s.initHostlock(web)
+	s.initHostLifecycle(web)
+	s.initHostSnapHistory(web)
+	s.initHostLease(web)
 	s.initModule(web)
 	s.initSpecial(web)
 	s.initTransfer(web)
@@ -190,6 +193,16 @@ func (s *Service) initHostapplyrule(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/host/createmany/module/host_apply_plan/preview",
 		Handler: s.GenerateModuleApplyPlan})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/host_apply_scope_rule/bk_biz_id/{bk_biz_id}",
+		Handler: s.CreateHostApplyScopeRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path:    "/update/host_apply_scope_rule/{host_apply_rule_id}/bk_biz_id/{bk_biz_id}",
+		Handler: s.UpdateHostApplyScopeRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete,
+		Path:    "/deletemany/host_apply_scope_rule/bk_biz_id/{bk_biz_id}",
+		Handler: s.DeleteHostApplyScopeRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_apply_scope_rule/bk_biz_id/{bk_biz_id}",
+		Handler: s.ListHostApplyScopeRule})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost,
 		Path:    "/findmany/host_apply_rule/bk_biz_id/{bk_biz_id}/host_related_rules",
 		Handler: s.ListHostRelatedApplyRule})
@@ -239,11 +252,67 @@ func (s *Service) initHostlock(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/host/lock", Handler: s.LockHost})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/host/lock", Handler: s.UnlockHost})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/host/lock/search", Handler: s.QueryHostLock})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/host/lock/list", Handler: s.ListHostLock})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/host/lock/force", Handler: s.ForceUnlockHost})
 
 	utility.AddToRestfulWebService(web)
 
 }
 
+func (s *Service) initHostLifecycle(web *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.Engine.CCErr,
+		Language: s.Engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host_lifecycle/definition",
+		Handler: s.UpdateHostLifecycle})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host_lifecycle/definition",
+		Handler: s.GetHostLifecycle})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lifecycle/history",
+		Handler: s.SearchHostLifecycleHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lifecycle/stuck_host",
+		Handler: s.ListStuckHosts})
+
+	utility.AddToRestfulWebService(web)
+}
+
+func (s *Service) initHostSnapHistory(web *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.Engine.CCErr,
+		Language: s.Engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_snap/history",
+		Handler: s.SearchHostSnapHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_snap/changed_host",
+		Handler: s.ListHostSnapChangedHosts})
+
+	utility.AddToRestfulWebService(web)
+}
+
+func (s *Service) initHostLease(web *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.Engine.CCErr,
+		Language: s.Engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/host_lease/bk_biz_id/{bk_biz_id}",
+		Handler: s.CreateHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host_lease/{id}/extend/bk_biz_id/{bk_biz_id}",
+		Handler: s.ExtendHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host_lease/{id}/return/bk_biz_id/{bk_biz_id}",
+		Handler: s.ReturnHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lease/bk_biz_id/{bk_biz_id}",
+		Handler: s.SearchHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lease/history/bk_biz_id/{bk_biz_id}",
+		Handler: s.SearchHostLeaseHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/return/host_lease/task",
+		Handler: s.ReturnExpiredHostLeaseTask})
+
+	utility.AddToRestfulWebService(web)
+}
+
 func (s *Service) initModule(web *restful.WebService) {
 
 	utility := rest.NewRestUtility(rest.Config{
@@ -93,9 +93,19 @@ func (s *Service) TransferHostWithAutoClearServiceInstance(ctx *rest.Contexts) {
 		len(option.RemoveFromModules) == 0, transferPlans, svcInstMap,
 		option.Options.HostApplyTransPropertyRule.Changed)
 
+	transitions, err := s.checkTransferPlansState(ctx.Kit, bizID, transToInnerOpt, transToNormalPlans)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		return s.transferHostWithAutoClearServiceInstance(ctx.Kit, bizID, option, transToInnerOpt, transToNormalPlans,
-			svcInstMap, hostIDs)
+		err := s.transferHostWithAutoClearServiceInstance(ctx.Kit, bizID, option, transToInnerOpt,
+			transToNormalPlans, svcInstMap, hostIDs)
+		if err != nil {
+			return err
+		}
+		return s.saveHostLifecycleTransitions(ctx.Kit, transitions)
 	})
 
 	if txnErr != nil {
@@ -1007,5 +1017,12 @@ func (s *Service) getRulesPriorityFromTemplate(kit *rest.Kit, moduleIDs []int64,
 		}
 	}
 
+	// 4.合并业务、集群、集群模板维度的规则，优先级低于模块和服务模板的规则
+	rules, _, ccErr := s.mergeModuleScopeRules(kit, bizID, moduleRes, rules)
+	if ccErr != nil {
+		blog.Errorf("merge host apply scope rules failed, err: %v, rid: %s", ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
 	return rules, nil
 }
@@ -13,6 +13,8 @@
 package logics
 
 import (
+	"sort"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
@@ -616,3 +618,57 @@ func (lgc *Logic) GetHostIPMapByID(kit *rest.Kit, hostIDs []int64) (map[int64]ma
 
 	return hostMap, nil
 }
+
+// ListProcessPortConflicts list the process bind info port conflicts on the hosts in the business, all hosts that have
+// processes in the business are checked if the host ids are not set
+func (lgc *Logic) ListProcessPortConflicts(kit *rest.Kit, bizID int64, hostIDs []int64) (
+	[]metadata.ProcessPortConflict, errors.CCErrorCoder) {
+
+	relOpt := &metadata.ListProcessInstanceRelationOption{
+		BusinessID: bizID,
+		HostIDs:    hostIDs,
+		Page:       metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	relRes, err := lgc.CoreAPI.CoreService().Process().ListProcessInstanceRelation(kit.Ctx, kit.Header, relOpt)
+	if err != nil {
+		blog.Errorf("list process relations failed, option: %+v, err: %v, rid: %s", relOpt, err, kit.Rid)
+		return nil, err
+	}
+
+	procHostMap := make(map[int64]int64)
+	procIDs := make([]int64, 0)
+	for _, relation := range relRes.Info {
+		procHostMap[relation.ProcessID] = relation.HostID
+		procIDs = append(procIDs, relation.ProcessID)
+	}
+
+	hostProcMap := make(map[int64][]metadata.Process)
+	for start := 0; start < len(procIDs); start += common.BKMaxPageSize {
+		end := start + common.BKMaxPageSize
+		if end > len(procIDs) {
+			end = len(procIDs)
+		}
+
+		processes, err := lgc.ListProcessInstanceWithIDs(kit, procIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, process := range processes {
+			hostID := procHostMap[process.ProcessID]
+			hostProcMap[hostID] = append(hostProcMap[hostID], process)
+		}
+	}
+
+	procHostIDs := make([]int64, 0)
+	for hostID := range hostProcMap {
+		procHostIDs = append(procHostIDs, hostID)
+	}
+	sort.Slice(procHostIDs, func(i, j int) bool { return procHostIDs[i] < procHostIDs[j] })
+
+	conflicts := make([]metadata.ProcessPortConflict, 0)
+	for _, hostID := range procHostIDs {
+		conflicts = append(conflicts, metadata.FindProcessPortConflicts(hostID, hostProcMap[hostID])...)
+	}
+	return conflicts, nil
+}
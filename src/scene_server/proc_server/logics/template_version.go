@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"sort"
+
+	"configcenter/src/common/metadata"
+)
+
+// DiffSvcTempProcTemps cross compare the previous process templates and the updated process templates of the service
+// template, returns the process templates to add and update, and the ids of the process templates to delete
+func DiffSvcTempProcTemps(bizID, id int64, prevProcTemps, updateProcTemps []metadata.ProcessTemplate) (
+	[]metadata.ProcessTemplate, []metadata.ProcessTemplate, []int64) {
+
+	procTempMap := make(map[int64]*metadata.ProcessProperty)
+	for _, procTemp := range prevProcTemps {
+		procTempMap[procTemp.ID] = procTemp.Property
+	}
+
+	var addedProcTemps, updatedProcTemps []metadata.ProcessTemplate
+	for _, procTemp := range updateProcTemps {
+		value, exists := procTempMap[procTemp.ID]
+		if !exists {
+			procTemp.BizID = bizID
+			procTemp.ServiceTemplateID = id
+			addedProcTemps = append(addedProcTemps, procTemp)
+			continue
+		}
+
+		delete(procTempMap, procTemp.ID)
+		if !reflect.DeepEqual(value, procTemp.Property) {
+			updatedProcTemps = append(updatedProcTemps, procTemp)
+		}
+	}
+
+	deletedIDs := make([]int64, 0, len(procTempMap))
+	for procTempID := range procTempMap {
+		deletedIDs = append(deletedIDs, procTempID)
+	}
+	sort.Slice(deletedIDs, func(i, j int) bool {
+		return deletedIDs[i] < deletedIDs[j]
+	})
+
+	return addedProcTemps, updatedProcTemps, deletedIDs
+}
+
+// GetSvcTempHostApplyRollback returns the host apply rules of the service template to create or update for rolling
+// back to the target version, and the ids of the current rules whose attributes are not in the target version
+func GetSvcTempHostApplyRollback(id int64, current, target *metadata.TemplateVersionContent) (
+	[]metadata.CreateOrUpdateApplyRuleOption, []int64) {
+
+	targetAttrMap := make(map[int64]struct{})
+	rules := make([]metadata.CreateOrUpdateApplyRuleOption, len(target.HostApplyRules))
+	for idx, rule := range target.HostApplyRules {
+		targetAttrMap[rule.AttributeID] = struct{}{}
+		rules[idx] = metadata.CreateOrUpdateApplyRuleOption{
+			ServiceTemplateID: id,
+			AttributeID:       rule.AttributeID,
+			PropertyValue:     rule.PropertyValue,
+		}
+	}
+
+	deletedRuleIDs := make([]int64, 0)
+	for _, rule := range current.HostApplyRules {
+		if _, exists := targetAttrMap[rule.AttributeID]; !exists {
+			deletedRuleIDs = append(deletedRuleIDs, rule.ID)
+		}
+	}
+
+	return rules, deletedRuleIDs
+}
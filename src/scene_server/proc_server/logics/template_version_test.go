@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestDiffSvcTempProcTemps(t *testing.T) {
+	oldPidFile, newPidFile := "/tmp/old.pid", "/tmp/new.pid"
+	current := []metadata.ProcessTemplate{
+		{ID: 1, ProcessName: "nginx", Property: &metadata.ProcessProperty{
+			PidFile: metadata.PropertyString{Value: &newPidFile}}},
+		{ID: 2, ProcessName: "redis", Property: &metadata.ProcessProperty{}},
+		{ID: 3, ProcessName: "kafka", Property: &metadata.ProcessProperty{}},
+		{ID: 5, ProcessName: "etcd", Property: &metadata.ProcessProperty{}},
+	}
+	// the target version has the old nginx pid file, and the mysql process template that is deleted after the version
+	target := []metadata.ProcessTemplate{
+		{ID: 1, ProcessName: "nginx", Property: &metadata.ProcessProperty{
+			PidFile: metadata.PropertyString{Value: &oldPidFile}}},
+		{ID: 2, ProcessName: "redis", Property: &metadata.ProcessProperty{}},
+		{ID: 4, ProcessName: "mysql", Property: &metadata.ProcessProperty{}},
+	}
+
+	added, updated, deletedIDs := DiffSvcTempProcTemps(2, 10, current, target)
+
+	if len(added) != 1 || added[0].ProcessName != "mysql" || added[0].BizID != 2 || added[0].ServiceTemplateID != 10 {
+		t.Errorf("added process templates = %+v, want mysql of service template 10 in biz 2", added)
+	}
+	if len(updated) != 1 || updated[0].ID != 1 || *updated[0].Property.PidFile.Value != oldPidFile {
+		t.Errorf("updated process templates = %+v, want nginx rolled back to the old pid file", updated)
+	}
+	if !reflect.DeepEqual(deletedIDs, []int64{3, 5}) {
+		t.Errorf("deleted process templates = %v, want [3 5]", deletedIDs)
+	}
+
+	// rolling back to the same content changes nothing
+	added, updated, deletedIDs = DiffSvcTempProcTemps(2, 10, current, current)
+	if len(added) != 0 || len(updated) != 0 || len(deletedIDs) != 0 {
+		t.Errorf("DiffSvcTempProcTemps() = %+v, %+v, %v, want no change", added, updated, deletedIDs)
+	}
+}
+
+func TestGetSvcTempHostApplyRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     []metadata.HostApplyRule
+		target      []metadata.HostApplyRule
+		wantRules   []metadata.CreateOrUpdateApplyRuleOption
+		wantDeleted []int64
+	}{
+		{
+			name:        "no rule",
+			wantRules:   []metadata.CreateOrUpdateApplyRuleOption{},
+			wantDeleted: []int64{},
+		},
+		{
+			name: "rules changed",
+			current: []metadata.HostApplyRule{{ID: 1, AttributeID: 20, PropertyValue: "y"},
+				{ID: 2, AttributeID: 21, PropertyValue: "z"}},
+			target: []metadata.HostApplyRule{{ID: 1, AttributeID: 20, PropertyValue: "x"},
+				{ID: 3, AttributeID: 22, PropertyValue: "w"}},
+			wantRules: []metadata.CreateOrUpdateApplyRuleOption{
+				{ServiceTemplateID: 10, AttributeID: 20, PropertyValue: "x"},
+				{ServiceTemplateID: 10, AttributeID: 22, PropertyValue: "w"},
+			},
+			wantDeleted: []int64{2},
+		},
+		{
+			name:        "rules removed",
+			current:     []metadata.HostApplyRule{{ID: 1, AttributeID: 20, PropertyValue: "y"}},
+			wantRules:   []metadata.CreateOrUpdateApplyRuleOption{},
+			wantDeleted: []int64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &metadata.TemplateVersionContent{HostApplyRules: tt.current}
+			target := &metadata.TemplateVersionContent{HostApplyRules: tt.target}
+
+			rules, deletedIDs := GetSvcTempHostApplyRollback(10, current, target)
+			if !reflect.DeepEqual(rules, tt.wantRules) || !reflect.DeepEqual(deletedIDs, tt.wantDeleted) {
+				t.Errorf("GetSvcTempHostApplyRollback() = %+v, %v, want %+v, %v", rules, deletedIDs, tt.wantRules,
+					tt.wantDeleted)
+			}
+		})
+	}
+}
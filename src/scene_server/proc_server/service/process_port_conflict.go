@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// maxPortConflictsInError the max number of port conflicts that are described in the error message
+const maxPortConflictsInError = 3
+
+// ListProcessPortConflicts list the existing process bind info port conflicts on the hosts in the business
+func (ps *ProcServer) ListProcessPortConflicts(ctx *rest.Contexts) {
+	opt := new(metadata.ListProcessPortConflictOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	conflicts, err := ps.Logic.ListProcessPortConflicts(ctx.Kit, opt.BizID, util.IntArrayUnique(opt.HostIDs))
+	if err != nil {
+		blog.Errorf("list process port conflicts failed, option: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(metadata.ListProcessPortConflictResult{
+		Count: int64(len(conflicts)),
+		Info:  conflicts,
+	})
+}
+
+// listInvolvedPortConflicts list the port conflicts on the hosts of the processes matched by the relation option
+// that involve these processes
+func (ps *ProcServer) listInvolvedPortConflicts(kit *rest.Kit, relOpt *metadata.ListProcessInstanceRelationOption) (
+	[]metadata.ProcessPortConflict, errors.CCErrorCoder) {
+
+	relOpt.Page = metadata.BasePage{Limit: common.BKNoLimit}
+	relRes, err := ps.CoreAPI.CoreService().Process().ListProcessInstanceRelation(kit.Ctx, kit.Header, relOpt)
+	if err != nil {
+		blog.Errorf("list process relations failed, option: %+v, err: %v, rid: %s", relOpt, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(relRes.Info) == 0 {
+		return make([]metadata.ProcessPortConflict, 0), nil
+	}
+
+	hostIDs := make([]int64, 0)
+	procIDMap := make(map[int64]struct{})
+	for _, relation := range relRes.Info {
+		hostIDs = append(hostIDs, relation.HostID)
+		procIDMap[relation.ProcessID] = struct{}{}
+	}
+
+	conflicts, err := ps.Logic.ListProcessPortConflicts(kit, relOpt.BusinessID, util.IntArrayUnique(hostIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	involved := make([]metadata.ProcessPortConflict, 0)
+	for idx := range conflicts {
+		if conflicts[idx].Involves(procIDMap) {
+			involved = append(involved, conflicts[idx])
+		}
+	}
+	return involved, nil
+}
+
+// getExistingPortConflicts get the keys of the port conflicts that the processes matched by the relation option
+// already have, it is called before the processes are changed so that only the conflicts introduced by the change
+// are blocked, the existing ones are left to be resolved by the users
+func (ps *ProcServer) getExistingPortConflicts(kit *rest.Kit, relOpt *metadata.ListProcessInstanceRelationOption) (
+	map[string]struct{}, errors.CCErrorCoder) {
+
+	conflicts, err := ps.listInvolvedPortConflicts(kit, relOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{}, len(conflicts))
+	for idx := range conflicts {
+		existing[conflicts[idx].Key()] = struct{}{}
+	}
+	return existing, nil
+}
+
+// validateProcessPortConflict check if the processes matched by the relation option conflict with the other
+// processes on their hosts, the existing conflicts that are got before the change are skipped. conflicts caused by
+// the service template are only blocked when it is configured so that the creation and synchronization of the
+// existing templates are not broken, they are logged otherwise
+func (ps *ProcServer) validateProcessPortConflict(kit *rest.Kit, relOpt *metadata.ListProcessInstanceRelationOption,
+	byTemplate bool, existing map[string]struct{}) errors.CCErrorCoder {
+
+	conflicts, err := ps.listInvolvedPortConflicts(kit, relOpt)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]string, 0)
+	for idx := range conflicts {
+		if _, exists := existing[conflicts[idx].Key()]; exists {
+			continue
+		}
+		messages = append(messages, conflicts[idx].String())
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if byTemplate && !blockTemplatePortConflict() {
+		blog.Warnf("processes created or synchronized by service template have port conflicts: %s, rid: %s",
+			strings.Join(messages, "; "), kit.Rid)
+		return nil
+	}
+
+	blog.Errorf("processes have port conflicts: %s, rid: %s", strings.Join(messages, "; "), kit.Rid)
+	if len(messages) > maxPortConflictsInError {
+		messages = append(messages[:maxPortConflictsInError], "...")
+	}
+	return kit.CCError.CCErrorf(common.CCErrProcBindInfoPortConflict, strings.Join(messages, "; "))
+}
+
+// blockTemplatePortConflict returns if the port conflicts caused by the service template creation or synchronization
+// are blocked, they are not blocked by default
+func blockTemplatePortConflict() bool {
+	block, err := cc.Bool("procServer.processPortConflict.blockTemplateSync")
+	if err != nil {
+		return false
+	}
+	return block
+}
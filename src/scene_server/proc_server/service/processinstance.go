@@ -62,6 +62,11 @@ func (ps *ProcServer) CreateProcessInstances(ctx *rest.Contexts) {
 			return err
 		}
 
+		relOpt := &metadata.ListProcessInstanceRelationOption{BusinessID: input.BizID, ProcessIDs: processIDs}
+		if err = ps.validateProcessPortConflict(ctx.Kit, relOpt, false, nil); err != nil {
+			return err
+		}
+
 		// generate and save audit log after processes are created
 		audit := auditlog.NewSvcInstAudit(ps.CoreAPI.CoreService())
 		if err = audit.WithServiceInstanceByIDs(ctx.Kit, input.BizID, []int64{input.ServiceInstanceID},
@@ -218,6 +223,17 @@ func (ps *ProcServer) UpdateProcessInstancesByIDs(ctx *rest.Contexts) {
 	}
 	auditLogs := audit.GenerateAuditLog(generateAuditParameter)
 
+	// get the port conflicts before the bind info is updated, only the conflicts introduced by the update are blocked
+	_, isBindInfoUpdated := input.UpdateData[common.BKProcBindInfo]
+	var existingConflicts map[string]struct{}
+	if isBindInfoUpdated {
+		existingConflicts, err = ps.getExistingPortConflicts(ctx.Kit, relOpt)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
 	// parse update data and update the processes
 	raws := make([]map[string]interface{}, 0)
 	for _, process := range processResult.Info {
@@ -245,6 +261,13 @@ func (ps *ProcServer) UpdateProcessInstancesByIDs(ctx *rest.Contexts) {
 			return err
 		}
 
+		if isBindInfoUpdated {
+			relOpt := &metadata.ListProcessInstanceRelationOption{BusinessID: input.BizID, ProcessIDs: result}
+			if err = ps.validateProcessPortConflict(ctx.Kit, relOpt, false, existingConflicts); err != nil {
+				return err
+			}
+		}
+
 		// save audit logs
 		if err := audit.SaveAuditLog(ctx.Kit, auditLogs...); err != nil {
 			return err
@@ -284,6 +307,22 @@ func (ps *ProcServer) UpdateProcessInstances(ctx *rest.Contexts) {
 		return
 	}
 
+	// get the port conflicts before the bind info is updated, only the conflicts introduced by the update are blocked
+	bindInfoRelOpt, err := getBindInfoUpdatedRelationOption(ctx.Kit, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var existingConflicts map[string]struct{}
+	if bindInfoRelOpt != nil {
+		existingConflicts, err = ps.getExistingPortConflicts(ctx.Kit, bindInfoRelOpt)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
 	var result []int64
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		// update process instances
@@ -293,6 +332,12 @@ func (ps *ProcServer) UpdateProcessInstances(ctx *rest.Contexts) {
 			return err
 		}
 
+		if bindInfoRelOpt != nil {
+			if err = ps.validateProcessPortConflict(ctx.Kit, bindInfoRelOpt, false, existingConflicts); err != nil {
+				return err
+			}
+		}
+
 		// save audit log
 		audit := auditlog.NewSvcInstAudit(ps.CoreAPI.CoreService())
 		if err := audit.SaveAuditLog(ctx.Kit, auditLogs...); err != nil {
@@ -308,6 +353,32 @@ func (ps *ProcServer) UpdateProcessInstances(ctx *rest.Contexts) {
 	ctx.RespEntity(result)
 }
 
+// getBindInfoUpdatedRelationOption get the relation option of the updated processes whose bind info is changed,
+// returns nil if no bind info is changed
+func getBindInfoUpdatedRelationOption(kit *rest.Kit, input metadata.UpdateRawProcessInstanceInput) (
+	*metadata.ListProcessInstanceRelationOption, errors.CCErrorCoder) {
+
+	processIDs := make([]int64, 0)
+	for _, raw := range input.Raw {
+		if _, exists := raw[common.BKProcBindInfo]; !exists {
+			continue
+		}
+
+		processID, err := util.GetInt64ByInterface(raw[common.BKProcessIDField])
+		if err != nil {
+			blog.Errorf("process id %v is invalid, err: %v, rid: %s", raw[common.BKProcessIDField], err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKProcessIDField)
+		}
+		processIDs = append(processIDs, processID)
+	}
+
+	if len(processIDs) == 0 {
+		return nil, nil
+	}
+
+	return &metadata.ListProcessInstanceRelationOption{BusinessID: input.BizID, ProcessIDs: processIDs}, nil
+}
+
 // generateUpdateProcessAudit generate audit logs for process update operation
 func (ps *ProcServer) generateUpdateProcessAudit(kit *rest.Kit, input metadata.UpdateRawProcessInstanceInput) (
 	[]metadata.AuditLog, error) {
@@ -146,6 +146,18 @@ func (ps *ProcServer) newProcessService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/attribute",
 		Handler: ps.ListServiceTemplateAttribute})
 
+	// service template version
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/service_template/version",
+		Handler: ps.CreateServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/version",
+		Handler: ps.ListServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/proc/service_template/version/difference",
+		Handler: ps.DiffServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template/version/rollback",
+		Handler: ps.RollbackServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/version/pin",
+		Handler: ps.ListServiceTemplateVersionPin})
+
 	// process template
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/proc/proc_template",
 		Handler: ps.CreateProcessTemplateBatch})
@@ -225,6 +237,8 @@ func (ps *ProcServer) newProcessService(web *restful.WebService) {
 		Path: "/findmany/proc/process_instance/detail/biz/{bk_biz_id}", Handler: ps.ListProcessInstancesDetails})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut,
 		Path: "/update/proc/process_instance/by_ids", Handler: ps.UpdateProcessInstancesByIDs})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/process_port_conflict",
+		Handler: ps.ListProcessPortConflicts})
 
 	// module
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete,
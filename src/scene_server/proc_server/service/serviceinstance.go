@@ -115,6 +115,12 @@ func (ps *ProcServer) createServiceInstances(ctx *rest.Contexts, input metadata.
 		return nil, err
 	}
 
+	relOpt := &metadata.ListProcessInstanceRelationOption{BusinessID: bizID, ServiceInstanceIDs: serviceInstanceIDs}
+	byTemplate := module.ServiceTemplateID != common.ServiceTemplateIDNotSet
+	if err := ps.validateProcessPortConflict(ctx.Kit, relOpt, byTemplate, nil); err != nil {
+		return nil, err
+	}
+
 	// generate and save audit log after service instance is created
 	audit := auditlog.NewSvcInstAudit(ps.CoreAPI.CoreService())
 	generateAuditParameter := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditCreate)
@@ -813,18 +819,30 @@ func (ps *ProcServer) DiffServiceTemplateGeneral(ctx *rest.Contexts) {
 		return
 	}
 
-	processTemplates, cErr := ps.getProcessTemplate(ctx.Kit, option.BizID, option.ServiceTemplateID)
+	// compare the module with the specified service template version if it is set
+	version, cErr := ps.getDiffServiceTemplateVersion(ctx.Kit, option)
 	if cErr != nil {
-		blog.Errorf("get process templates failed, option: %v, err %v, rid: %s", option, cErr, rid)
-		err := ctx.Kit.CCError.CCErrorf(common.CCErrProcGetProcessTemplatesFailed, cErr.Error())
-		ctx.RespAutoError(err)
+		ctx.RespAutoError(cErr)
 		return
 	}
 
-	// processTemplates->pTemplateMap
-	pTemplateMap := make(map[int64]*metadata.ProcessTemplate)
-	for idx, pTemplate := range processTemplates.Info {
-		pTemplateMap[pTemplate.ID] = &processTemplates.Info[idx]
+	var pTemplateMap map[int64]*metadata.ProcessTemplate
+	if version != nil {
+		pTemplateMap = versionProcTemplateMap(version)
+	} else {
+		processTemplates, cErr := ps.getProcessTemplate(ctx.Kit, option.BizID, option.ServiceTemplateID)
+		if cErr != nil {
+			blog.Errorf("get process templates failed, option: %v, err %v, rid: %s", option, cErr, rid)
+			err := ctx.Kit.CCError.CCErrorf(common.CCErrProcGetProcessTemplatesFailed, cErr.Error())
+			ctx.RespAutoError(err)
+			return
+		}
+
+		// processTemplates->pTemplateMap
+		pTemplateMap = make(map[int64]*metadata.ProcessTemplate)
+		for idx, pTemplate := range processTemplates.Info {
+			pTemplateMap[pTemplate.ID] = &processTemplates.Info[idx]
+		}
 	}
 
 	// module detail
@@ -834,7 +852,7 @@ func (ps *ProcServer) DiffServiceTemplateGeneral(ctx *rest.Contexts) {
 		return
 	}
 
-	result, cErr := ps.serviceTemplateGeneralDiff(ctx, option, modules[0], pTemplateMap)
+	result, cErr := ps.serviceTemplateGeneralDiff(ctx, option, modules[0], pTemplateMap, version)
 	if cErr != nil {
 		blog.Errorf("calc service template diff failed, option: %+v, err: %v, rid: %s", option, cErr, rid)
 		ctx.RespAutoError(cErr)
@@ -1609,8 +1627,8 @@ func (ps *ProcServer) handleAddedServiceInsts(hostMap map[int64]map[string]inter
 }
 
 func (ps *ProcServer) serviceTemplateGeneralDiff(ctx *rest.Contexts, option *metadata.ServiceTemplateDiffOption,
-	module mapstr.MapStr, pTemplateMap map[int64]*metadata.ProcessTemplate) (*metadata.ServiceTemplateGeneralDiff,
-	ccErr.CCErrorCoder) {
+	module mapstr.MapStr, pTemplateMap map[int64]*metadata.ProcessTemplate, version *metadata.TemplateVersionInfo) (
+	*metadata.ServiceTemplateGeneralDiff, ccErr.CCErrorCoder) {
 
 	// 获取所有的服务实例
 	serviceInstances, cErr := ps.getServiceInstances(ctx, option, []string{common.BKFieldID, common.BKHostIDField})
@@ -1630,7 +1648,7 @@ func (ps *ProcServer) serviceTemplateGeneralDiff(ctx *rest.Contexts, option *met
 		return nil, cErr
 	}
 
-	attrs, cErr := ps.getAttributesResult(ctx.Kit, option, module)
+	attrs, cErr := ps.getAttributesResult(ctx.Kit, option, module, version)
 	if cErr != nil {
 		blog.Errorf("get service template or module attributes failed, option: %+v, err: %v, rid: %s", *option,
 			cErr, ctx.Kit.Rid)
@@ -1736,14 +1754,26 @@ func (ps *ProcServer) getModuleAttrIDAndPropertyID(kit *rest.Kit, attrIDs []int6
 
 // getAttributesResult 获取同一属性ID的模板和模块的属性值
 func (ps *ProcServer) getAttributesResult(kit *rest.Kit, option *metadata.ServiceTemplateDiffOption,
-	module mapstr.MapStr) ([]metadata.AttributeFields, ccErr.CCErrorCoder) {
+	module mapstr.MapStr, version *metadata.TemplateVersionInfo) ([]metadata.AttributeFields, ccErr.CCErrorCoder) {
 
 	attrValues := make([]metadata.AttributeFields, 0)
-	// 1、获取指定服务模板的属性ID及属性值
-	attrIDs, srvTemplateAttrValueMap, cErr := ps.getSrvTemplateAttrIdAndPropertyValue(kit, option.BizID,
-		option.ServiceTemplateID)
-	if cErr != nil {
-		return attrValues, cErr
+	// 1、获取指定服务模板(或服务模板版本)的属性ID及属性值
+	var attrIDs []int64
+	var srvTemplateAttrValueMap map[int64]interface{}
+	if version != nil {
+		attrIDs = make([]int64, 0)
+		srvTemplateAttrValueMap = make(map[int64]interface{})
+		for _, attr := range version.Content.ServiceTemplateAttrs {
+			attrIDs = append(attrIDs, attr.AttributeID)
+			srvTemplateAttrValueMap[attr.AttributeID] = attr.PropertyValue
+		}
+	} else {
+		var cErr ccErr.CCErrorCoder
+		attrIDs, srvTemplateAttrValueMap, cErr = ps.getSrvTemplateAttrIdAndPropertyValue(kit, option.BizID,
+			option.ServiceTemplateID)
+		if cErr != nil {
+			return attrValues, cErr
+		}
 	}
 	if len(attrIDs) == 0 {
 		return attrValues, nil
@@ -1863,6 +1893,7 @@ func (ps *ProcServer) SyncServiceInstanceByTemplate(ctx *rest.Contexts) {
 		tasks = append(tasks, taskReq)
 	}
 
+	result := &metadata.SyncServiceInstanceByTemplateResult{Tasks: make([]metadata.ServiceTemplateSyncTask, 0)}
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		taskRes, err := ps.CoreAPI.TaskServer().Task().CreateBatch(ctx.Kit.Ctx, ctx.Kit.Header, tasks)
 		if err != nil {
@@ -1870,14 +1901,21 @@ func (ps *ProcServer) SyncServiceInstanceByTemplate(ctx *rest.Contexts) {
 			return err
 		}
 		blog.V(4).Infof("successfully created service template sync task: %#v, rid: %s", taskRes, ctx.Kit.Rid)
-		return nil
+
+		for _, task := range taskRes {
+			result.Tasks = append(result.Tasks, metadata.ServiceTemplateSyncTask{ModuleID: task.InstID,
+				TaskID: task.TaskID})
+		}
+
+		// record the service template version that the modules are synchronized to
+		return ps.pinServiceTemplateVersion(ctx.Kit, syncOpt.BizID, syncOpt.ServiceTemplateID, syncOpt.ModuleIDs)
 	})
 
 	if txnErr != nil {
 		ctx.RespAutoError(txnErr)
 		return
 	}
-	ctx.RespEntity(nil)
+	ctx.RespEntity(result)
 }
 
 // DoSyncServiceInstanceTask do sync one module's service instance by service template task
@@ -2342,6 +2380,19 @@ func (ps *ProcServer) doSyncServiceInstanceTask(kit *rest.Kit,
 		return cErr
 	}
 
+	// get the port conflicts before synchronization, only the conflicts introduced by the synchronization are checked
+	var existingConflicts map[string]struct{}
+	relOpt, cErr := ps.getSyncedProcessRelationOption(kit, syncOption)
+	if cErr != nil {
+		return cErr
+	}
+	if relOpt != nil {
+		existingConflicts, cErr = ps.getExistingPortConflicts(kit, relOpt)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
 	processRelationInfo, relations, cErr := ps.getProcessInfo(kit, syncOption, serviceInstanceInfo)
 	if cErr != nil {
 		blog.Errorf("get process info failed, option: %+v, err: %v, rid: %s", syncOption, cErr, kit.Rid)
@@ -2379,7 +2430,45 @@ func (ps *ProcServer) doSyncServiceInstanceTask(kit *rest.Kit,
 		return cErr
 	}
 
-	return nil
+	relOpt, cErr = ps.getSyncedProcessRelationOption(kit, syncOption)
+	if cErr != nil {
+		return cErr
+	}
+	if relOpt == nil {
+		return nil
+	}
+	return ps.validateProcessPortConflict(kit, relOpt, true, existingConflicts)
+}
+
+// getSyncedProcessRelationOption get the relation option of the processes in the synchronized service instances,
+// returns nil if there is no service instance
+func (ps *ProcServer) getSyncedProcessRelationOption(kit *rest.Kit,
+	syncOption *metadata.SyncServiceTemplateOption) (*metadata.ListProcessInstanceRelationOption, ccErr.CCErrorCoder) {
+
+	svcInstOpt := &metadata.ListServiceInstanceOption{
+		BusinessID: syncOption.BizID,
+		ModuleIDs:  []int64{syncOption.ModuleID},
+		HostIDs:    syncOption.HostIDs,
+		Fields:     []string{common.BKFieldID},
+		Page:       metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	svcInsts, err := ps.CoreAPI.CoreService().Process().ListServiceInstance(kit.Ctx, kit.Header, svcInstOpt)
+	if err != nil {
+		blog.Errorf("list service instances failed, option: %+v, err: %v, rid: %s", svcInstOpt, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(svcInsts.Info) == 0 {
+		return nil, nil
+	}
+
+	svcInstIDs := make([]int64, len(svcInsts.Info))
+	for idx, svcInst := range svcInsts.Info {
+		svcInstIDs[idx] = svcInst.ID
+	}
+
+	relOpt := &metadata.ListProcessInstanceRelationOption{BusinessID: syncOption.BizID, ServiceInstanceIDs: svcInstIDs}
+	return relOpt, nil
 }
 
 func (ps *ProcServer) saveProcessLog(kit *rest.Kit, procRelation *processInfo, process *metadata.Process,
@@ -27,6 +27,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/proc_server/logics"
 )
 
 // CreateServiceTemplate TODO
@@ -907,30 +908,12 @@ func (ps *ProcServer) updateSvcTempAllAttrs(kit *rest.Kit, id, bizID int64, prev
 func (ps *ProcServer) updateSvcTempAllProcTemps(kit *rest.Kit, id, bizID int64, prevProcTemps,
 	updateProcTemps []metadata.ProcessTemplate) errors.CCErrorCoder {
 
-	procTempMap := make(map[int64]*metadata.ProcessProperty)
-	for _, procTemp := range prevProcTemps {
-		procTempMap[procTemp.ID] = procTemp.Property
-	}
-
 	// cross compare previous procTemps and update procTemps to find need add/update/delete procTemps
-	var addedProcTemps, updatedProcTemps []metadata.ProcessTemplate
-	for _, procTemp := range updateProcTemps {
-		value, exists := procTempMap[procTemp.ID]
-		if !exists {
-			procTemp.BizID = bizID
-			procTemp.ServiceTemplateID = id
-			addedProcTemps = append(addedProcTemps, procTemp)
-			continue
-		}
-
-		delete(procTempMap, procTemp.ID)
-		if !reflect.DeepEqual(value, procTemp.Property) {
-			updatedProcTemps = append(updatedProcTemps, procTemp)
-		}
-	}
+	addedProcTemps, updatedProcTemps, deletedIDs := logics.DiffSvcTempProcTemps(bizID, id, prevProcTemps,
+		updateProcTemps)
 
 	// delete service template procTemps
-	for procTempID := range procTempMap {
+	for _, procTempID := range deletedIDs {
 		err := ps.CoreAPI.CoreService().Process().DeleteProcessTemplate(kit.Ctx, kit.Header, procTempID)
 		if err != nil {
 			blog.Errorf("delete process template %d failed, err: %v, rid: %s", procTempID, err, kit.Rid)
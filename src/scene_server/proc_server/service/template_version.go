@@ -0,0 +1,397 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/proc_server/logics"
+)
+
+// CreateServiceTemplateVersion create an immutable version of the current service template content, including the
+// process templates, the attributes and the host apply settings
+func (ps *ProcServer) CreateServiceTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.CreateTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	var version *metadata.TemplateVersionInfo
+	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		version, err = ps.createServiceTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.Description, 0)
+		return err
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(version)
+}
+
+func (ps *ProcServer) createServiceTemplateVersion(kit *rest.Kit, bizID, id int64, description string,
+	rollbackFrom int64) (*metadata.TemplateVersionInfo, errors.CCErrorCoder) {
+
+	content, err := ps.getServiceTemplateVersionContent(kit, bizID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &metadata.TemplateVersionInfo{
+		BizID:        bizID,
+		TemplateType: metadata.ServiceTemplateVersion,
+		TemplateID:   id,
+		Content:      *content,
+		Description:  description,
+		RollbackFrom: rollbackFrom,
+	}
+	version, err = ps.CoreAPI.CoreService().Process().CreateTemplateVersion(kit.Ctx, kit.Header, version)
+	if err != nil {
+		blog.Errorf("create service template %d version failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// getServiceTemplateVersionContent get the current content of the service template as the version content
+func (ps *ProcServer) getServiceTemplateVersionContent(kit *rest.Kit, bizID, id int64) (
+	*metadata.TemplateVersionContent, errors.CCErrorCoder) {
+
+	allInfo, err := ps.getServiceTemplateAllInfo(kit, id, bizID)
+	if err != nil {
+		return nil, err
+	}
+
+	svcTemp, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(kit.Ctx, kit.Header, id)
+	if err != nil {
+		blog.Errorf("get service template %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, err
+	}
+
+	ruleOpt := metadata.ListHostApplyRuleOption{
+		ApplicationID:      bizID,
+		ServiceTemplateIDs: []int64{id},
+		Page:               metadata.BasePage{Limit: common.BKNoLimit, Sort: common.BKAttributeIDField},
+	}
+	rules, err := ps.CoreAPI.CoreService().HostApplyRule().ListHostApplyRule(kit.Ctx, kit.Header, bizID, ruleOpt)
+	if err != nil {
+		blog.Errorf("list service template %d host apply rules failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, err
+	}
+
+	return &metadata.TemplateVersionContent{
+		Name:                 allInfo.Name,
+		ServiceCategoryID:    allInfo.ServiceCategoryID,
+		ProcessTemplates:     allInfo.Processes,
+		ServiceTemplateAttrs: allInfo.Attributes,
+		HostApplyEnabled:     svcTemp.HostApplyEnabled,
+		HostApplyRules:       rules.Info,
+	}, nil
+}
+
+// ListServiceTemplateVersion list the versions of the service template
+func (ps *ProcServer) ListServiceTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.ListTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.TemplateType = metadata.ServiceTemplateVersion
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := ps.CoreAPI.CoreService().Process().ListTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list service template versions failed, opt: %#v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// getServiceTemplateVersion get the version of the service template
+func (ps *ProcServer) getServiceTemplateVersion(kit *rest.Kit, bizID, id, version int64) (
+	*metadata.TemplateVersionInfo, errors.CCErrorCoder) {
+
+	opt := &metadata.ListTemplateVersionOption{
+		BizID:        bizID,
+		TemplateType: metadata.ServiceTemplateVersion,
+		TemplateID:   id,
+		Versions:     []int64{version},
+		Page:         metadata.BasePage{Limit: 1},
+	}
+	result, err := ps.CoreAPI.CoreService().Process().ListTemplateVersion(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("get service template %d version %d failed, err: %v, rid: %s", id, version, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(result.Info) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrProcTemplateVersionNotExist, id, version)
+	}
+
+	return &result.Info[0], nil
+}
+
+// DiffServiceTemplateVersion diff two versions of the service template, or diff the version with the current
+// service template content if the version to compare with is not set
+func (ps *ProcServer) DiffServiceTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.DiffTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	from, err := ps.getServiceTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.FromVersion)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var toContent *metadata.TemplateVersionContent
+	if opt.ToVersion > 0 {
+		to, err := ps.getServiceTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.ToVersion)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		toContent = &to.Content
+	} else {
+		toContent, err = ps.getServiceTemplateVersionContent(ctx.Kit, opt.BizID, opt.TemplateID)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	diff := metadata.DiffTemplateVersionContent(&from.Content, toContent)
+	diff.FromVersion = opt.FromVersion
+	diff.ToVersion = opt.ToVersion
+	ctx.RespEntity(diff)
+}
+
+// RollbackServiceTemplateVersion rollback the service template content to the version, then create a new version
+// for the rolled back content. the modules are not synchronized until they are synchronized by the user
+func (ps *ProcServer) RollbackServiceTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.RollbackTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	version, err := ps.getServiceTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.Version)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	current, err := ps.getServiceTemplateVersionContent(ctx.Kit, opt.BizID, opt.TemplateID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var newVersion *metadata.TemplateVersionInfo
+	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		if err := ps.rollbackServiceTemplate(ctx.Kit, opt.BizID, opt.TemplateID, current, &version.Content); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("rollback to version %d", opt.Version)
+		newVersion, err = ps.createServiceTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, description,
+			opt.Version)
+		return err
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(newVersion)
+}
+
+func (ps *ProcServer) rollbackServiceTemplate(kit *rest.Kit, bizID, id int64, current,
+	target *metadata.TemplateVersionContent) errors.CCErrorCoder {
+
+	if current.Name != target.Name || current.ServiceCategoryID != target.ServiceCategoryID {
+		svcTemp := &metadata.ServiceTemplate{Name: target.Name, ServiceCategoryID: target.ServiceCategoryID}
+		if _, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(kit.Ctx, kit.Header, id,
+			svcTemp); err != nil {
+			blog.Errorf("rollback service template %d failed, err: %v, rid: %s", id, err, kit.Rid)
+			return err
+		}
+	}
+
+	attrs := make([]metadata.SvcTempAttr, len(target.ServiceTemplateAttrs))
+	for idx, attr := range target.ServiceTemplateAttrs {
+		attrs[idx] = metadata.SvcTempAttr{AttributeID: attr.AttributeID, PropertyValue: attr.PropertyValue}
+	}
+	if err := ps.updateSvcTempAllAttrs(kit, id, bizID, current.ServiceTemplateAttrs, attrs); err != nil {
+		return err
+	}
+
+	if err := ps.updateSvcTempAllProcTemps(kit, id, bizID, current.ProcessTemplates,
+		target.ProcessTemplates); err != nil {
+		return err
+	}
+
+	return ps.rollbackServiceTemplateHostApply(kit, bizID, id, current, target)
+}
+
+// rollbackServiceTemplateHostApply rollback the host apply status and rules of the service template, the rules are
+// applied to the hosts when the host apply of the service template is executed
+func (ps *ProcServer) rollbackServiceTemplateHostApply(kit *rest.Kit, bizID, id int64, current,
+	target *metadata.TemplateVersionContent) errors.CCErrorCoder {
+
+	if current.HostApplyEnabled != target.HostApplyEnabled {
+		updateOpt := &metadata.UpdateOption{
+			Condition: map[string]interface{}{common.BKAppIDField: bizID, common.BKFieldID: id},
+			Data:      map[string]interface{}{common.HostApplyEnabledField: target.HostApplyEnabled},
+		}
+		err := ps.CoreAPI.CoreService().Process().UpdateBatchServiceTemplate(kit.Ctx, kit.Header, updateOpt)
+		if err != nil {
+			blog.Errorf("rollback service template %d host apply status failed, err: %v, rid: %s", id, err, kit.Rid)
+			return err
+		}
+	}
+
+	rules, deletedRuleIDs := logics.GetSvcTempHostApplyRollback(id, current, target)
+	if len(deletedRuleIDs) > 0 {
+		deleteOpt := metadata.DeleteHostApplyRuleOption{RuleIDs: deletedRuleIDs, ServiceTemplateIDs: []int64{id}}
+		err := ps.CoreAPI.CoreService().HostApplyRule().DeleteHostApplyRule(kit.Ctx, kit.Header, bizID, deleteOpt)
+		if err != nil {
+			blog.Errorf("delete service template %d host apply rules failed, err: %v, rid: %s", id, err, kit.Rid)
+			return err
+		}
+	}
+
+	if len(rules) > 0 {
+		updateOpt := metadata.BatchCreateOrUpdateApplyRuleOption{Rules: rules}
+		_, err := ps.CoreAPI.CoreService().HostApplyRule().BatchUpdateHostApplyRule(kit.Ctx, kit.Header, bizID,
+			updateOpt)
+		if err != nil {
+			blog.Errorf("rollback service template %d host apply rules failed, err: %v, rid: %s", id, err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListServiceTemplateVersionPin list the service template versions that the modules are synchronized to
+func (ps *ProcServer) ListServiceTemplateVersionPin(ctx *rest.Contexts) {
+	opt := new(metadata.ListTemplateVersionPinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.TemplateType = metadata.ServiceTemplateVersion
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	pins, err := ps.CoreAPI.CoreService().Process().ListTemplateVersionPin(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list service template version pins failed, opt: %#v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(pins)
+}
+
+// pinServiceTemplateVersion pin the synchronized modules to the latest version of the service template, the
+// modules are not pinned if the service template has no version
+func (ps *ProcServer) pinServiceTemplateVersion(kit *rest.Kit, bizID, id int64, moduleIDs []int64) error {
+	listOpt := &metadata.ListTemplateVersionOption{
+		BizID:        bizID,
+		TemplateType: metadata.ServiceTemplateVersion,
+		TemplateID:   id,
+		Fields:       []string{"version"},
+		Page:         metadata.BasePage{Limit: 1, Sort: "-version"},
+	}
+	result, err := ps.CoreAPI.CoreService().Process().ListTemplateVersion(kit.Ctx, kit.Header, listOpt)
+	if err != nil {
+		blog.Errorf("get service template %d latest version failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	if len(result.Info) == 0 {
+		return nil
+	}
+
+	pinOpt := &metadata.PinTemplateVersionOption{
+		BizID:        bizID,
+		TemplateType: metadata.ServiceTemplateVersion,
+		TemplateID:   id,
+		InstIDs:      moduleIDs,
+		Version:      result.Info[0].Version,
+	}
+	if err := ps.CoreAPI.CoreService().Process().PinTemplateVersion(kit.Ctx, kit.Header, pinOpt); err != nil {
+		blog.Errorf("pin modules %v to service template %d version %d failed, err: %v, rid: %s", moduleIDs, id,
+			pinOpt.Version, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// getDiffServiceTemplateVersion get the service template version to compare the module with, returns nil if the
+// current service template is compared
+func (ps *ProcServer) getDiffServiceTemplateVersion(kit *rest.Kit, option *metadata.ServiceTemplateDiffOption) (
+	*metadata.TemplateVersionInfo, errors.CCErrorCoder) {
+
+	if option.Version <= 0 {
+		return nil, nil
+	}
+
+	return ps.getServiceTemplateVersion(kit, option.BizID, option.ServiceTemplateID, option.Version)
+}
+
+// versionProcTemplateMap returns the process template id to process template map of the version
+func versionProcTemplateMap(version *metadata.TemplateVersionInfo) map[int64]*metadata.ProcessTemplate {
+	pTemplateMap := make(map[int64]*metadata.ProcessTemplate)
+	for idx, pTemplate := range version.Content.ProcessTemplates {
+		pTemplateMap[pTemplate.ID] = &version.Content.ProcessTemplates[idx]
+	}
+	return pTemplateMap
+}
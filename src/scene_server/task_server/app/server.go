@@ -22,11 +22,13 @@ import (
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
+	apigwcli "configcenter/src/common/resource/apigw"
 	"configcenter/src/common/types"
 	"configcenter/src/scene_server/task_server/app/options"
 	"configcenter/src/scene_server/task_server/logics"
 	tasksvc "configcenter/src/scene_server/task_server/service"
 	"configcenter/src/storage/dal/redis"
+	"configcenter/src/thirdparty/apigw"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -92,6 +94,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return initErr
 	}
 
+	if err := initHostLeaseNotice(engine); err != nil {
+		return err
+	}
+
 	service.Engine = engine
 	service.Config = taskSrv.Config
 	service.CacheDB = cacheDB
@@ -102,6 +108,16 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	// cron job delete history task
 	go taskSrv.Service.TimerDeleteHistoryTask(ctx)
+	// cron job expire the change requests that are not approved in time
+	go taskSrv.Service.TimerExpireChangeRequest(ctx)
+	// cron job apply the auto apply attribute policies to the created or re-associated instances
+	go taskSrv.Service.TimerAutoApplyAttributePolicy(ctx)
+	// cron job remind the host leases that are about to expire and return the expired ones
+	go taskSrv.Service.TimerCheckHostLease(ctx)
+	// cron job advance the service template sync rollouts batch by batch
+	go taskSrv.Service.TimerAdvanceSyncRollout(ctx)
+	// cron job check the drift of the sets and synchronize them by the set template sync policies
+	go taskSrv.Service.TimerCheckSetTemplateSyncPolicy(ctx)
 
 	if err := backbone.StartServer(ctx, cancel, engine, service.WebService(), true); err != nil {
 		blog.Errorf("start backbone failed, err: %+v", err)
@@ -116,6 +132,30 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	return nil
 }
 
+// initHostLeaseNotice init the bk-notice client that sends the host lease expiration reminders if it is enabled
+func initHostLeaseNotice(engine *backbone.Engine) error {
+	if !cc.IsExist("taskServer.hostLease.notice.enabled") {
+		return nil
+	}
+
+	enabled, err := cc.Bool("taskServer.hostLease.notice.enabled")
+	if err != nil {
+		blog.Errorf("get taskServer.hostLease.notice.enabled failed, err: %v", err)
+		return err
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	if err := apigwcli.Init("apiGW", engine.Metric().Registry(), []apigw.ClientType{apigw.Notice}); err != nil {
+		blog.Errorf("init api gateway client failed, err: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 // TaskServer TODO
 type TaskServer struct {
 	Core      *backbone.Engine
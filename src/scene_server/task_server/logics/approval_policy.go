@@ -0,0 +1,211 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// CreateApprovalPolicy create approval policy, each operation can only have one approval policy
+func (lgc *Logics) CreateApprovalPolicy(kit *rest.Kit, policy *metadata.ApprovalPolicy) (*metadata.ApprovalPolicy,
+	error) {
+
+	if !policy.Operation.Validate() {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "operation")
+	}
+
+	if rawErr := policy.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{
+		"operation":           policy.Operation,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	count, err := lgc.db.Table(common.BKTableNameApprovalPolicy).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count approval policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count > 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskApprovalPolicyDuplicated, policy.Operation)
+	}
+
+	id, err := lgc.db.NextSequence(kit.Ctx, common.BKTableNameApprovalPolicy)
+	if err != nil {
+		blog.Errorf("generate approval policy id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	policy.ID = int64(id)
+	policy.OwnerID = kit.SupplierAccount
+	policy.Creator = kit.User
+	policy.Modifier = kit.User
+	policy.CreateTime = now
+	policy.LastTime = now
+
+	if err := lgc.db.Table(common.BKTableNameApprovalPolicy).Insert(kit.Ctx, policy); err != nil {
+		blog.Errorf("create approval policy failed, err: %v, policy: %#v, rid: %s", err, policy, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	audit := auditlog.NewChangeApprovalAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditCreate)
+	auditLog := audit.GenerateApprovalPolicyAuditLog(auditParam, policy)
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save approval policy %d audit log failed, err: %v, rid: %s", policy.ID, err, kit.Rid)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// UpdateApprovalPolicy update approval policy, the operation of the policy can not be changed
+func (lgc *Logics) UpdateApprovalPolicy(kit *rest.Kit, id int64, policy *metadata.ApprovalPolicy) error {
+	prev, err := lgc.getApprovalPolicy(kit, id)
+	if err != nil {
+		return err
+	}
+
+	if len(policy.Operation) > 0 && policy.Operation != prev.Operation {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "operation")
+	}
+	policy.Operation = prev.Operation
+
+	if rawErr := policy.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	doc := mapstr.MapStr{
+		common.BKFieldName:    policy.Name,
+		"production_only":     policy.ProductionOnly,
+		"approvers":           policy.Approvers,
+		"timeout":             policy.Timeout,
+		"enabled":             policy.Enabled,
+		common.ModifierField:  kit.User,
+		common.LastTimeField:  metadata.Now(),
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+
+	// generate audit log before the policy is updated
+	audit := auditlog.NewChangeApprovalAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).WithUpdateFields(doc)
+	auditLog := audit.GenerateApprovalPolicyAuditLog(auditParam, prev)
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if err := lgc.db.Table(common.BKTableNameApprovalPolicy).Update(kit.Ctx, cond, doc); err != nil {
+		blog.Errorf("update approval policy %d failed, err: %v, doc: %#v, rid: %s", id, err, doc, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save approval policy %d audit log failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteApprovalPolicy delete approval policy, the captured change requests of the policy are not affected
+func (lgc *Logics) DeleteApprovalPolicy(kit *rest.Kit, id int64) error {
+	prev, err := lgc.getApprovalPolicy(kit, id)
+	if err != nil {
+		return err
+	}
+
+	audit := auditlog.NewChangeApprovalAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditDelete)
+	auditLog := audit.GenerateApprovalPolicyAuditLog(auditParam, prev)
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if err := lgc.db.Table(common.BKTableNameApprovalPolicy).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete approval policy %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save approval policy %d audit log failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// SearchApprovalPolicy search approval policies
+func (lgc *Logics) SearchApprovalPolicy(kit *rest.Kit, opt *metadata.SearchApprovalPolicyOption) (
+	*metadata.SearchApprovalPolicyResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{common.BKOwnerIDField: kit.SupplierAccount}
+	if len(opt.Operation) > 0 {
+		cond["operation"] = opt.Operation
+	}
+
+	table := lgc.db.Table(common.BKTableNameApprovalPolicy)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count approval policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchApprovalPolicyResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = common.BKFieldID
+	}
+
+	policies := make([]metadata.ApprovalPolicy, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &policies)
+	if err != nil {
+		blog.Errorf("search approval policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchApprovalPolicyResult{Info: policies}, nil
+}
+
+func (lgc *Logics) getApprovalPolicy(kit *rest.Kit, id int64) (*metadata.ApprovalPolicy, error) {
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+
+	policy := new(metadata.ApprovalPolicy)
+	if err := lgc.db.Table(common.BKTableNameApprovalPolicy).Find(cond).One(kit.Ctx, policy); err != nil {
+		if lgc.db.IsNotFoundError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrTaskApprovalPolicyNotExist, id)
+		}
+		blog.Errorf("get approval policy %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return policy, nil
+}
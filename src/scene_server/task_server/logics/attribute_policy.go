@@ -0,0 +1,625 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package logics
+
+import (
+	"encoding/json"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateAttributePolicy create attribute policy
+func (lgc *Logics) CreateAttributePolicy(kit *rest.Kit, policy *metadata.AttributePolicy) (*metadata.AttributePolicy,
+	error) {
+
+	if len(policy.ObjID) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+
+	if len(policy.PropertyID) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKPropertyIDField)
+	}
+
+	if err := lgc.validateAttributePolicy(kit, policy); err != nil {
+		return nil, err
+	}
+
+	id, err := lgc.db.NextSequence(kit.Ctx, common.BKTableNameAttributePolicy)
+	if err != nil {
+		blog.Errorf("generate attribute policy id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	policy.ID = int64(id)
+	policy.OwnerID = kit.SupplierAccount
+	policy.Creator = kit.User
+	policy.Modifier = kit.User
+	policy.CreateTime = now
+	policy.LastTime = now
+
+	if err := lgc.db.Table(common.BKTableNameAttributePolicy).Insert(kit.Ctx, policy); err != nil {
+		blog.Errorf("create attribute policy failed, err: %v, policy: %#v, rid: %s", err, policy, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	audit := auditlog.NewAttributePolicyAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditCreate)
+	auditLog := audit.GenerateAuditLog(auditParam, policy)
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save attribute policy %d audit log failed, err: %v, rid: %s", policy.ID, err, kit.Rid)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// UpdateAttributePolicy update attribute policy, the model and the attribute of the policy can not be changed
+func (lgc *Logics) UpdateAttributePolicy(kit *rest.Kit, id int64, policy *metadata.AttributePolicy) error {
+	prev, err := lgc.getAttributePolicy(kit, id)
+	if err != nil {
+		return err
+	}
+
+	if len(policy.ObjID) > 0 && policy.ObjID != prev.ObjID {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+	policy.ObjID = prev.ObjID
+
+	if len(policy.PropertyID) > 0 && policy.PropertyID != prev.PropertyID {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+	}
+	policy.PropertyID = prev.PropertyID
+
+	if err := lgc.validateAttributePolicy(kit, policy); err != nil {
+		return err
+	}
+
+	doc := mapstr.MapStr{
+		common.BKFieldName:       policy.Name,
+		"bk_property_value":      policy.PropertyValue,
+		"mode":                   policy.Mode,
+		"condition":              policy.Condition,
+		"auto_apply":             policy.AutoApply,
+		common.ModifierField:     kit.User,
+		common.LastTimeField:     metadata.Now(),
+		common.BkSupplierAccount: kit.SupplierAccount,
+	}
+
+	// generate audit log before the policy is updated
+	audit := auditlog.NewAttributePolicyAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).WithUpdateFields(doc)
+	auditLog := audit.GenerateAuditLog(auditParam, prev)
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if err := lgc.db.Table(common.BKTableNameAttributePolicy).Update(kit.Ctx, cond, doc); err != nil {
+		blog.Errorf("update attribute policy %d failed, err: %v, doc: %#v, rid: %s", id, err, doc, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save attribute policy %d audit log failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteAttributePolicy delete attribute policy, the attribute values that are already set by it are not changed
+func (lgc *Logics) DeleteAttributePolicy(kit *rest.Kit, id int64) error {
+	prev, err := lgc.getAttributePolicy(kit, id)
+	if err != nil {
+		return err
+	}
+
+	audit := auditlog.NewAttributePolicyAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditDelete)
+	auditLog := audit.GenerateAuditLog(auditParam, prev)
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if err := lgc.db.Table(common.BKTableNameAttributePolicy).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete attribute policy %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save attribute policy %d audit log failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// SearchAttributePolicy search attribute policies
+func (lgc *Logics) SearchAttributePolicy(kit *rest.Kit, opt *metadata.SearchAttributePolicyOption) (
+	*metadata.SearchAttributePolicyResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{common.BKOwnerIDField: kit.SupplierAccount}
+	if len(opt.ObjID) > 0 {
+		cond[common.BKObjIDField] = opt.ObjID
+	}
+	if len(opt.PropertyID) > 0 {
+		cond[common.BKPropertyIDField] = opt.PropertyID
+	}
+
+	table := lgc.db.Table(common.BKTableNameAttributePolicy)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count attribute policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchAttributePolicyResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = common.BKFieldID
+	}
+
+	policies := make([]metadata.AttributePolicy, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &policies)
+	if err != nil {
+		blog.Errorf("search attribute policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchAttributePolicyResult{Info: policies}, nil
+}
+
+// PreviewAttributePolicy preview the attribute changes of the instances if the policies are applied
+func (lgc *Logics) PreviewAttributePolicy(kit *rest.Kit, opt *metadata.AttributePolicyApplyOption) (
+	*metadata.AttributePolicyPreviewResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	policies, err := lgc.listAttributePolicies(kit, opt.ObjID, opt.PolicyIDs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metadata.AttributePolicyPreviewResult{Info: make([]metadata.AttributePolicyInstChange, 0)}
+	err = lgc.computeAttributePolicyChanges(kit, opt.ObjID, policies, opt.InstIDs,
+		func(changes []metadata.AttributePolicyInstChange) error {
+			result.Count += len(changes)
+			if remain := common.BKMaxLimitSize - len(result.Info); remain > 0 {
+				if len(changes) > remain {
+					changes = changes[:remain]
+				}
+				result.Info = append(result.Info, changes...)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CreateApplyAttributePolicyTask create the task that applies the attribute policies of the model, only one task
+// of the same model can be executed at the same time
+func (lgc *Logics) CreateApplyAttributePolicyTask(kit *rest.Kit, opt *metadata.AttributePolicyApplyOption) (
+	*metadata.AttributePolicyApplyResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	object, err := lgc.getAttributePolicyObject(kit, opt.ObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	taskOpt := &metadata.CreateTaskRequest{
+		TaskType: common.ApplyAttributePolicyTaskFlag,
+		InstID:   object.ID,
+		Extra:    opt.ObjID,
+		Data:     []interface{}{opt},
+	}
+	task, err := lgc.Create(kit, taskOpt)
+	if err != nil {
+		blog.Errorf("create apply attribute policy task failed, err: %v, opt: %#v, rid: %s", err, opt, kit.Rid)
+		return nil, err
+	}
+
+	return &metadata.AttributePolicyApplyResult{TaskID: task.TaskID}, nil
+}
+
+// ApplyAttributePolicy apply the attribute policies to the instances, called by the task queue
+func (lgc *Logics) ApplyAttributePolicy(kit *rest.Kit, opt *metadata.AttributePolicyApplyOption) error {
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	policies, err := lgc.listAttributePolicies(kit, opt.ObjID, opt.PolicyIDs, false)
+	if err != nil {
+		return err
+	}
+
+	return lgc.applyAttributePolicies(kit, opt.ObjID, policies, opt.InstIDs)
+}
+
+// AutoApplyAttributePolicy apply the auto apply attribute policies of the model to the created or re-associated
+// instances
+func (lgc *Logics) AutoApplyAttributePolicy(kit *rest.Kit, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	policies, err := lgc.listAttributePolicies(kit, objID, nil, true)
+	if err != nil {
+		return err
+	}
+
+	return lgc.applyAttributePolicies(kit, objID, policies, instIDs)
+}
+
+// ListAutoApplyAttributePolicyObjects list the models that have auto apply attribute policies of each supplier
+// account, returns the supplier account to object ids map
+func (lgc *Logics) ListAutoApplyAttributePolicyObjects(kit *rest.Kit) (map[string][]string, error) {
+	cond := mapstr.MapStr{"auto_apply": true}
+	policies := make([]metadata.AttributePolicy, 0)
+	err := lgc.db.Table(common.BKTableNameAttributePolicy).Find(cond).
+		Fields(common.BKObjIDField, common.BkSupplierAccount).All(kit.Ctx, &policies)
+	if err != nil {
+		blog.Errorf("list auto apply attribute policy failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	objMap := make(map[string][]string)
+	for _, policy := range policies {
+		objMap[policy.OwnerID] = append(objMap[policy.OwnerID], policy.ObjID)
+	}
+
+	for ownerID, objIDs := range objMap {
+		objMap[ownerID] = util.StrArrayUnique(objIDs)
+	}
+
+	return objMap, nil
+}
+
+func (lgc *Logics) applyAttributePolicies(kit *rest.Kit, objID string, policies []metadata.AttributePolicy,
+	instIDs []int64) error {
+
+	if len(policies) == 0 {
+		return nil
+	}
+
+	return lgc.computeAttributePolicyChanges(kit, objID, policies, instIDs,
+		func(changes []metadata.AttributePolicyInstChange) error {
+			for idx := range changes {
+				if err := lgc.updateAttributePolicyInst(kit, objID, &changes[idx]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (lgc *Logics) updateAttributePolicyInst(kit *rest.Kit, objID string,
+	change *metadata.AttributePolicyInstChange) error {
+
+	data := change.UpdateData()
+	cond := mapstr.MapStr{common.GetInstIDField(objID): change.InstID}
+
+	audit := auditlog.NewInstanceAudit(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).WithUpdateFields(data)
+	auditLog, err := audit.GenerateAuditLogByCondGetData(auditParam, objID, cond)
+	if err != nil {
+		blog.Errorf("generate %s instance %d audit log failed, err: %v, rid: %s", objID, change.InstID, err, kit.Rid)
+		return err
+	}
+
+	opt := &metadata.UpdateOption{Data: data, Condition: cond}
+	if _, err := lgc.CoreAPI.CoreService().Instance().UpdateInstance(kit.Ctx, kit.Header, objID, opt); err != nil {
+		blog.Errorf("apply attribute policy to %s instance %d failed, err: %v, data: %#v, rid: %s", objID,
+			change.InstID, err, data, kit.Rid)
+		return err
+	}
+
+	if err := audit.SaveAuditLog(kit, auditLog...); err != nil {
+		blog.Errorf("save %s instance %d audit log failed, err: %v, rid: %s", objID, change.InstID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+	}
+
+	return nil
+}
+
+// computeAttributePolicyChanges compute the attribute changes of the instances page by page, and handle the changes
+// of each page, empty instance ids means all instances of the model
+func (lgc *Logics) computeAttributePolicyChanges(kit *rest.Kit, objID string, policies []metadata.AttributePolicy,
+	instIDs []int64, handler func(changes []metadata.AttributePolicyInstChange) error) error {
+
+	if len(policies) == 0 {
+		return nil
+	}
+
+	instIDField := common.GetInstIDField(objID)
+	instNameField := common.GetInstNameField(objID)
+	fields := []string{instIDField, instNameField, common.BKAppIDField}
+	for _, policy := range policies {
+		fields = append(fields, policy.PropertyID)
+	}
+	fields = util.StrArrayUnique(fields)
+
+	cond := mapstr.MapStr{}
+	if len(instIDs) > 0 {
+		cond[instIDField] = mapstr.MapStr{common.BKDBIN: instIDs}
+	}
+
+	page := metadata.BasePage{Start: 0, Limit: common.BKMaxInstanceLimit, Sort: instIDField}
+	for {
+		query := &metadata.QueryCondition{Condition: cond, Fields: fields, Page: page, DisableCounter: true}
+		result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, objID, query)
+		if err != nil {
+			blog.Errorf("read %s instances failed, err: %v, cond: %#v, rid: %s", objID, err, cond, kit.Rid)
+			return err
+		}
+
+		if len(result.Info) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, 0)
+		for _, inst := range result.Info {
+			id, err := util.GetInt64ByInterface(inst[instIDField])
+			if err != nil {
+				blog.Errorf("parse %s instance id failed, err: %v, inst: %#v, rid: %s", objID, err, inst, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, instIDField)
+			}
+			ids = append(ids, id)
+		}
+
+		relations, err := lgc.getAttributePolicyInstRelations(kit, objID, ids)
+		if err != nil {
+			return err
+		}
+
+		changes := make([]metadata.AttributePolicyInstChange, 0)
+		for idx, inst := range result.Info {
+			relation := relations[ids[idx]]
+			relation.BizID, _ = util.GetInt64ByInterface(inst[common.BKAppIDField])
+
+			instChanges := metadata.ComputeAttributePolicyChange(policies, inst, relation, isSameAttributeValue)
+			if len(instChanges) == 0 {
+				continue
+			}
+
+			name, _ := inst[instNameField].(string)
+			changes = append(changes, metadata.AttributePolicyInstChange{
+				InstID:   ids[idx],
+				InstName: name,
+				Changes:  instChanges,
+			})
+		}
+
+		if len(changes) > 0 {
+			if err := handler(changes); err != nil {
+				return err
+			}
+		}
+
+		if len(result.Info) < common.BKMaxInstanceLimit {
+			return nil
+		}
+		page.Start += common.BKMaxInstanceLimit
+	}
+}
+
+// getAttributePolicyInstRelations get the associated instances of the instances in both directions
+func (lgc *Logics) getAttributePolicyInstRelations(kit *rest.Kit, objID string, instIDs []int64) (
+	map[int64]*metadata.AttributePolicyInstRelation, error) {
+
+	relations := make(map[int64]*metadata.AttributePolicyInstRelation)
+	for _, id := range instIDs {
+		relations[id] = &metadata.AttributePolicyInstRelation{Associations: make(map[string]map[int64]struct{})}
+	}
+
+	addRelation := func(instID int64, asstObjID string, asstInstID int64) {
+		relation, exists := relations[instID]
+		if !exists {
+			return
+		}
+		if _, exists := relation.Associations[asstObjID]; !exists {
+			relation.Associations[asstObjID] = make(map[int64]struct{})
+		}
+		relation.Associations[asstObjID][asstInstID] = struct{}{}
+	}
+
+	opt := &metadata.InstAsstQueryCondition{
+		ObjID: objID,
+		Cond: metadata.QueryCondition{
+			Condition: mapstr.MapStr{
+				common.BKDBOR: []mapstr.MapStr{
+					{
+						common.BKObjIDField:  objID,
+						common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+					},
+					{
+						common.BKAsstObjIDField:  objID,
+						common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+					},
+				},
+			},
+			Page:           metadata.BasePage{Limit: common.BKNoLimit},
+			DisableCounter: true,
+		},
+	}
+	result, err := lgc.CoreAPI.CoreService().Association().ReadInstAssociation(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("read %s instance associations failed, err: %v, ids: %v, rid: %s", objID, err, instIDs, kit.Rid)
+		return nil, err
+	}
+
+	for _, asst := range result.Info {
+		if asst.ObjectID == objID {
+			addRelation(asst.InstID, asst.AsstObjectID, asst.AsstInstID)
+		}
+		if asst.AsstObjectID == objID {
+			addRelation(asst.AsstInstID, asst.ObjectID, asst.InstID)
+		}
+	}
+
+	return relations, nil
+}
+
+// listAttributePolicies list the attribute policies of the model sorted by id, empty ids means all policies
+func (lgc *Logics) listAttributePolicies(kit *rest.Kit, objID string, ids []int64, autoApply bool) (
+	[]metadata.AttributePolicy, error) {
+
+	cond := mapstr.MapStr{
+		common.BKObjIDField:   objID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if len(ids) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: ids}
+	}
+	if autoApply {
+		cond["auto_apply"] = true
+	}
+
+	policies := make([]metadata.AttributePolicy, 0)
+	err := lgc.db.Table(common.BKTableNameAttributePolicy).Find(cond).Sort(common.BKFieldID).All(kit.Ctx, &policies)
+	if err != nil {
+		blog.Errorf("list attribute policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return policies, nil
+}
+
+func (lgc *Logics) getAttributePolicy(kit *rest.Kit, id int64) (*metadata.AttributePolicy, error) {
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+
+	policy := new(metadata.AttributePolicy)
+	if err := lgc.db.Table(common.BKTableNameAttributePolicy).Find(cond).One(kit.Ctx, policy); err != nil {
+		if lgc.db.IsNotFoundError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrTaskAttributePolicyNotExist, id)
+		}
+		blog.Errorf("get attribute policy %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return policy, nil
+}
+
+// validateAttributePolicy validate the policy and its property value by the attribute of the model
+func (lgc *Logics) validateAttributePolicy(kit *rest.Kit, policy *metadata.AttributePolicy) error {
+	if rawErr := policy.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	if _, err := lgc.getAttributePolicyObject(kit, policy.ObjID); err != nil {
+		return err
+	}
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKObjIDField:      policy.ObjID,
+			common.BKPropertyIDField: policy.PropertyID,
+		},
+		DisableCounter: true,
+	}
+	attrs, err := lgc.CoreAPI.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, policy.ObjID, query)
+	if err != nil {
+		blog.Errorf("get %s attribute %s failed, err: %v, rid: %s", policy.ObjID, policy.PropertyID, err, kit.Rid)
+		return err
+	}
+
+	if len(attrs.Info) == 0 || !attrs.Info[0].IsEditable {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+	}
+
+	if rawErr := attrs.Info[0].Validate(kit.Ctx, policy.PropertyValue, "bk_property_value"); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	return nil
+}
+
+// getAttributePolicyObject get the model of the attribute policy, only the custom models that are not mainline
+// models support attribute policy
+func (lgc *Logics) getAttributePolicyObject(kit *rest.Kit, objID string) (*metadata.Object, error) {
+	query := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{common.BKObjIDField: objID},
+		DisableCounter: true,
+	}
+	objects, err := lgc.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("get object %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(objects.Info) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+
+	object := &objects.Info[0]
+	if object.IsPre || common.IsInnerModel(objID) {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskAttributePolicyObjNotSupported, objID)
+	}
+
+	filter := []map[string]interface{}{{
+		common.AssociationKindIDField: common.AssociationKindMainline,
+		common.BKAsstObjIDField:       objID,
+	}}
+	counts, ccErr := lgc.CoreAPI.CoreService().Count().GetCountByFilter(kit.Ctx, kit.Header,
+		common.BKTableNameObjAsst, filter)
+	if ccErr != nil {
+		blog.Errorf("check if object %s is mainline failed, err: %v, rid: %s", objID, ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
+	if len(counts) > 0 && counts[0] > 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskAttributePolicyObjNotSupported, objID)
+	}
+
+	return object, nil
+}
+
+// isSameAttributeValue compares the attribute values by their json form, so that the numbers decoded from db and
+// from json are regarded as the same
+func isSameAttributeValue(a, b interface{}) bool {
+	aJs, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bJs, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(aJs) == string(bJs)
+}
@@ -0,0 +1,749 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apirest "configcenter/src/apimachinery/rest"
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CaptureChangeRequest captures the api server request as a pending change request if it matches an enabled approval
+// policy. The request is not captured if the policy does not cover it, e.g. the updated set is not a production set,
+// or the request is invalid so that the scene server can return the validation error.
+func (lgc *Logics) CaptureChangeRequest(kit *rest.Kit, opt *metadata.CaptureChangeRequestOption) (
+	*metadata.CaptureChangeRequestResult, error) {
+
+	notCaptured := &metadata.CaptureChangeRequestResult{Captured: false}
+
+	operation, params, matched := metadata.MatchApprovalOperation(opt.Method, opt.Path)
+	if !matched {
+		return notCaptured, nil
+	}
+
+	policy, err := lgc.getEnabledApprovalPolicy(kit, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy == nil {
+		return notCaptured, nil
+	}
+
+	checkOpt, valid := metadata.ParseApprovalCheckOption(operation, params, opt.Body)
+	if !valid {
+		return notCaptured, nil
+	}
+
+	request := &metadata.ChangeRequest{
+		PolicyID:  policy.ID,
+		Operation: operation,
+		Request: metadata.ChangeRequestContent{
+			Method:   opt.Method,
+			Path:     opt.Path,
+			Body:     opt.Body,
+			Language: httpheader.GetLanguage(kit.Header),
+			AppCode:  httpheader.GetAppCode(kit.Header),
+		},
+	}
+
+	captured, err := lgc.analyzeChange(kit, policy, request, checkOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !captured {
+		return notCaptured, nil
+	}
+
+	request.Approvers, err = lgc.resolveApprovers(kit, policy, request.BizIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.Approvers) == 0 {
+		blog.Errorf("approval policy %d has no approver for businesses %v, rid: %s", policy.ID, request.BizIDs, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "approvers")
+	}
+
+	id, err := lgc.db.NextSequence(kit.Ctx, common.BKTableNameChangeRequest)
+	if err != nil {
+		blog.Errorf("generate change request id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	request.ID = int64(id)
+	request.Status = metadata.ChangeRequestPending
+	request.Requester = kit.User
+	request.ExpireTime = metadata.Time{Time: now.Add(time.Duration(policy.Timeout) * time.Second)}
+	request.OwnerID = kit.SupplierAccount
+	request.CreateTime = now
+	request.LastTime = now
+
+	if err := lgc.db.Table(common.BKTableNameChangeRequest).Insert(kit.Ctx, request); err != nil {
+		blog.Errorf("create change request failed, err: %v, request: %#v, rid: %s", err, request, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	if err := lgc.saveChangeRequestAudit(kit, request, metadata.AuditCreate); err != nil {
+		return nil, err
+	}
+
+	return &metadata.CaptureChangeRequestResult{Captured: true, ID: request.ID}, nil
+}
+
+// CheckApproval checks the sensitive operation before the scene server executes it. The operation can be executed
+// if no enabled approval policy covers it, or it is made by the executing change request that approved all of its
+// changes, so that the approval can not be bypassed by any api that does the operation.
+func (lgc *Logics) CheckApproval(kit *rest.Kit, opt *metadata.ApprovalCheckOption) error {
+	if !opt.Validate() {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "operation")
+	}
+
+	policy, err := lgc.getEnabledApprovalPolicy(kit, opt.Operation)
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		return nil
+	}
+
+	request := &metadata.ChangeRequest{PolicyID: policy.ID, Operation: opt.Operation}
+	captured, err := lgc.analyzeChange(kit, policy, request, opt)
+	if err != nil {
+		return err
+	}
+
+	if !captured {
+		return nil
+	}
+
+	idStr := kit.Header.Get(httpheader.ChangeRequestIDHeader)
+	if len(idStr) == 0 {
+		blog.Errorf("operation %s needs approval, diff: %#v, rid: %s", opt.Operation, request.Diff, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestApprovalRequired, opt.Operation)
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		blog.Errorf("parse change request id %s failed, err: %v, rid: %s", idStr, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestVerifyFailed, idStr)
+	}
+
+	approved, err := lgc.GetChangeRequest(kit, id)
+	if err != nil {
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestVerifyFailed, id)
+	}
+
+	if approved.Status != metadata.ChangeRequestExecuting || approved.Requester != kit.User ||
+		approved.Operation != opt.Operation || !approved.Covers(request.Diff) {
+
+		blog.Errorf("operation %s does not match change request %d, status: %s, diff: %#v, rid: %s", opt.Operation,
+			id, approved.Status, request.Diff, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestVerifyFailed, id)
+	}
+
+	return nil
+}
+
+// getEnabledApprovalPolicy returns the enabled approval policy of the operation, returns nil if it does not exist
+func (lgc *Logics) getEnabledApprovalPolicy(kit *rest.Kit, operation metadata.ApprovalOperation) (
+	*metadata.ApprovalPolicy, error) {
+
+	cond := mapstr.MapStr{
+		"operation":           operation,
+		"enabled":             true,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	policy := new(metadata.ApprovalPolicy)
+	if err := lgc.db.Table(common.BKTableNameApprovalPolicy).Find(cond).One(kit.Ctx, policy); err != nil {
+		if lgc.db.IsNotFoundError(err) {
+			return nil, nil
+		}
+		blog.Errorf("get approval policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return policy, nil
+}
+
+// analyzeChange computes the related businesses and the diff of the change request, returns if it is captured
+func (lgc *Logics) analyzeChange(kit *rest.Kit, policy *metadata.ApprovalPolicy, request *metadata.ChangeRequest,
+	opt *metadata.ApprovalCheckOption) (bool, error) {
+
+	switch request.Operation {
+	case metadata.ApprovalOperationDeleteBiz:
+		return lgc.analyzeDeleteBiz(kit, request, opt.DeleteBiz)
+	case metadata.ApprovalOperationTransferHostAcrossBiz:
+		return lgc.analyzeTransferHostAcrossBiz(kit, request, opt.TransferHost)
+	case metadata.ApprovalOperationUpdateSet:
+		return lgc.analyzeUpdateSet(kit, policy, request, opt.UpdateSet)
+	default:
+		return false, nil
+	}
+}
+
+func (lgc *Logics) analyzeDeleteBiz(kit *rest.Kit, request *metadata.ChangeRequest,
+	opt *metadata.DeleteBizParam) (bool, error) {
+
+	cond := mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: opt.BizID}}
+	bizs, err := lgc.readInstances(kit, common.BKInnerObjIDApp, cond, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if len(bizs) == 0 {
+		return false, nil
+	}
+
+	for _, biz := range bizs {
+		bizID, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil {
+			blog.Errorf("parse business id failed, err: %v, biz: %+v, rid: %s", err, biz, kit.Rid)
+			return false, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
+
+		request.BizIDs = append(request.BizIDs, bizID)
+		request.Diff = append(request.Diff, metadata.ChangeRequestDiff{
+			ObjectID: common.BKInnerObjIDApp,
+			InstID:   bizID,
+			InstName: util.GetStrByInterface(biz[common.BKAppNameField]),
+			Before:   biz,
+		})
+	}
+
+	return true, nil
+}
+
+func (lgc *Logics) analyzeTransferHostAcrossBiz(kit *rest.Kit, request *metadata.ChangeRequest,
+	opt *metadata.TransferHostAcrossBusinessParameter) (bool, error) {
+
+	relOpt := &metadata.HostModuleRelationRequest{
+		ApplicationID: opt.SrcAppID,
+		HostIDArr:     opt.HostID,
+		Page:          metadata.BasePage{Limit: common.BKNoLimit},
+		Fields:        []string{common.BKHostIDField, common.BKModuleIDField},
+	}
+	relations, err := lgc.CoreAPI.CoreService().Host().GetHostModuleRelation(kit.Ctx, kit.Header, relOpt)
+	if err != nil {
+		blog.Errorf("get host module relation failed, err: %v, opt: %#v, rid: %s", err, relOpt, kit.Rid)
+		return false, err
+	}
+
+	hostModules := make(map[int64][]int64)
+	for _, relation := range relations.Info {
+		hostModules[relation.HostID] = append(hostModules[relation.HostID], relation.ModuleID)
+	}
+
+	hostOpt := &metadata.QueryInput{
+		Condition: mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: opt.HostID}},
+		Fields:    strings.Join([]string{common.BKHostIDField, common.BKHostInnerIPField}, ","),
+		Limit:     len(opt.HostID),
+	}
+	hosts, err := lgc.CoreAPI.CoreService().Host().GetHosts(kit.Ctx, kit.Header, hostOpt)
+	if err != nil {
+		blog.Errorf("get hosts failed, err: %v, host ids: %v, rid: %s", err, opt.HostID, kit.Rid)
+		return false, err
+	}
+
+	hostIPs := make(map[int64]string)
+	for _, host := range hosts.Info {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			blog.Errorf("parse host id failed, err: %v, host: %+v, rid: %s", err, host, kit.Rid)
+			continue
+		}
+		hostIPs[hostID] = util.GetStrByInterface(host[common.BKHostInnerIPField])
+	}
+
+	for _, hostID := range opt.HostID {
+		request.Diff = append(request.Diff, metadata.ChangeRequestDiff{
+			ObjectID: common.BKInnerObjIDHost,
+			InstID:   hostID,
+			InstName: hostIPs[hostID],
+			Before: map[string]interface{}{
+				common.BKAppIDField:    opt.SrcAppID,
+				common.BKModuleIDField: hostModules[hostID],
+			},
+			After: map[string]interface{}{
+				common.BKAppIDField:    opt.DstAppID,
+				common.BKModuleIDField: []int64{opt.DstModuleID},
+			},
+		})
+	}
+	request.BizIDs = []int64{opt.SrcAppID, opt.DstAppID}
+
+	return true, nil
+}
+
+func (lgc *Logics) analyzeUpdateSet(kit *rest.Kit, policy *metadata.ApprovalPolicy, request *metadata.ChangeRequest,
+	opt *metadata.ApprovalUpdateSetOption) (bool, error) {
+
+	cond := mapstr.MapStr{common.BKAppIDField: opt.BizID, common.BKSetIDField: opt.SetID}
+	sets, err := lgc.readInstances(kit, common.BKInnerObjIDSet, cond, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if len(sets) == 0 {
+		return false, nil
+	}
+	set := sets[0]
+
+	if policy.ProductionOnly && util.GetStrByInterface(set[common.BKSetEnvField]) != metadata.SetEnvProduction {
+		return false, nil
+	}
+
+	before, after := make(map[string]interface{}), make(map[string]interface{})
+	for field, value := range opt.Data {
+		if field == common.BKAppIDField || field == common.BKSetIDField || field == common.BKOwnerIDField {
+			continue
+		}
+
+		if prev, exists := set[field]; exists && fmt.Sprint(prev) == fmt.Sprint(value) {
+			continue
+		}
+		before[field] = set[field]
+		after[field] = value
+	}
+
+	// the update does not change anything, no need to approve it
+	if len(after) == 0 {
+		return false, nil
+	}
+
+	request.BizIDs = []int64{opt.BizID}
+	request.Diff = []metadata.ChangeRequestDiff{{
+		ObjectID: common.BKInnerObjIDSet,
+		InstID:   opt.SetID,
+		InstName: util.GetStrByInterface(set[common.BKSetNameField]),
+		Before:   before,
+		After:    after,
+	}}
+
+	return true, nil
+}
+
+// resolveApprovers resolves the approver users of the policy, the approver roles are resolved to the users of the
+// role fields of the businesses that the change request is related to.
+func (lgc *Logics) resolveApprovers(kit *rest.Kit, policy *metadata.ApprovalPolicy, bizIDs []int64) ([]string,
+	error) {
+
+	approvers := make([]string, 0)
+	exists := make(map[string]struct{})
+	add := func(user string) {
+		user = strings.TrimSpace(user)
+		if len(user) == 0 {
+			return
+		}
+		if _, ok := exists[user]; ok {
+			return
+		}
+		exists[user] = struct{}{}
+		approvers = append(approvers, user)
+	}
+
+	for _, user := range policy.Approvers.Users {
+		add(user)
+	}
+
+	if len(policy.Approvers.Roles) == 0 || len(bizIDs) == 0 {
+		return approvers, nil
+	}
+
+	cond := mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: bizIDs}}
+	bizs, err := lgc.readInstances(kit, common.BKInnerObjIDApp, cond, policy.Approvers.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, biz := range bizs {
+		for _, role := range policy.Approvers.Roles {
+			for _, user := range strings.Split(util.GetStrByInterface(biz[role]), ",") {
+				add(user)
+			}
+		}
+	}
+
+	return approvers, nil
+}
+
+func (lgc *Logics) readInstances(kit *rest.Kit, objID string, cond mapstr.MapStr, fields []string) (
+	[]mapstr.MapStr, error) {
+
+	query := &metadata.QueryCondition{
+		Condition:      cond,
+		Fields:         fields,
+		Page:           metadata.BasePage{Limit: common.BKNoLimit},
+		DisableCounter: true,
+	}
+	result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("read %s instances failed, err: %v, cond: %#v, rid: %s", objID, err, cond, kit.Rid)
+		return nil, err
+	}
+
+	return result.Info, nil
+}
+
+// ApproveChangeRequest approves the pending change request, and creates a task to execute it
+func (lgc *Logics) ApproveChangeRequest(kit *rest.Kit, id int64, opt *metadata.ChangeRequestDecisionOption) error {
+	request, err := lgc.GetChangeRequest(kit, id)
+	if err != nil {
+		return err
+	}
+
+	if err := lgc.checkApprover(kit, request); err != nil {
+		return err
+	}
+
+	doc := mapstr.MapStr{"approver": kit.User, "comment": opt.Comment}
+	if err := lgc.transitChangeRequest(kit, request, metadata.ChangeRequestApproved, doc); err != nil {
+		return err
+	}
+
+	taskOpt := &metadata.CreateTaskRequest{
+		TaskType: common.ExecuteChangeRequestTaskFlag,
+		InstID:   id,
+		Data:     []interface{}{metadata.ExecuteChangeRequestOption{ID: id}},
+	}
+	task, err := lgc.Create(kit, taskOpt)
+	if err != nil {
+		blog.Errorf("create change request %d execute task failed, err: %v, rid: %s", id, err, kit.Rid)
+		// set the change request back to pending so that it can be approved again
+		rollback := mapstr.MapStr{"approver": "", "comment": ""}
+		if err := lgc.transitChangeRequest(kit, request, metadata.ChangeRequestPending, rollback); err != nil {
+			blog.Errorf("rollback change request %d status failed, err: %v, rid: %s", id, err, kit.Rid)
+		}
+		return err
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: id, common.BKOwnerIDField: kit.SupplierAccount}
+	if err := lgc.db.Table(common.BKTableNameChangeRequest).Update(kit.Ctx, cond,
+		mapstr.MapStr{"task_id": task.TaskID}); err != nil {
+		blog.Errorf("update change request %d task id failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	request.TaskID = task.TaskID
+
+	return lgc.saveChangeRequestAudit(kit, request, metadata.AuditApprove)
+}
+
+// RejectChangeRequest rejects the pending change request
+func (lgc *Logics) RejectChangeRequest(kit *rest.Kit, id int64, opt *metadata.ChangeRequestDecisionOption) error {
+	request, err := lgc.GetChangeRequest(kit, id)
+	if err != nil {
+		return err
+	}
+
+	if err := lgc.checkApprover(kit, request); err != nil {
+		return err
+	}
+
+	doc := mapstr.MapStr{"approver": kit.User, "comment": opt.Comment}
+	if err := lgc.transitChangeRequest(kit, request, metadata.ChangeRequestRejected, doc); err != nil {
+		return err
+	}
+
+	return lgc.saveChangeRequestAudit(kit, request, metadata.AuditReject)
+}
+
+// CancelChangeRequest cancels the pending change request, only the requester can cancel it
+func (lgc *Logics) CancelChangeRequest(kit *rest.Kit, id int64, opt *metadata.ChangeRequestDecisionOption) error {
+	request, err := lgc.GetChangeRequest(kit, id)
+	if err != nil {
+		return err
+	}
+
+	if request.Requester != kit.User {
+		return kit.CCError.CCErrorf(common.CCErrCommAuthNotHavePermission)
+	}
+
+	doc := mapstr.MapStr{"comment": opt.Comment}
+	if err := lgc.transitChangeRequest(kit, request, metadata.ChangeRequestCanceled, doc); err != nil {
+		return err
+	}
+
+	return lgc.saveChangeRequestAudit(kit, request, metadata.AuditCancel)
+}
+
+// checkApprover checks if the user can approve or reject the change request, the requester can not approve
+// the change request of its own even if it is one of the approvers.
+func (lgc *Logics) checkApprover(kit *rest.Kit, request *metadata.ChangeRequest) error {
+	if request.Status != metadata.ChangeRequestPending {
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestStatusInvalid, request.Status)
+	}
+
+	if time.Now().After(request.ExpireTime.Time) {
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestStatusInvalid, metadata.ChangeRequestExpired)
+	}
+
+	if request.Requester == kit.User || !util.InStrArr(request.Approvers, kit.User) {
+		return kit.CCError.CCError(common.CCErrTaskChangeRequestNotApprover)
+	}
+
+	return nil
+}
+
+// ExecuteChangeRequest executes the approved change request by replaying the captured request to api server on
+// behalf of the requester, the api server verifies the replayed request with the change request id header.
+func (lgc *Logics) ExecuteChangeRequest(kit *rest.Kit, id int64) error {
+	request, err := lgc.GetChangeRequest(kit, id)
+	if err != nil {
+		return err
+	}
+
+	if request.Status != metadata.ChangeRequestApproved {
+		blog.Infof("change request %d status is %s, skip executing it, rid: %s", id, request.Status, kit.Rid)
+		return nil
+	}
+
+	if err := lgc.transitChangeRequest(kit, request, metadata.ChangeRequestExecuting, nil); err != nil {
+		return err
+	}
+
+	header := headerutil.GenCommonHeader(request.Requester, request.OwnerID, kit.Rid)
+	httpheader.SetLanguage(header, request.Request.Language)
+	httpheader.SetAppCode(header, request.Request.AppCode)
+	header.Set(httpheader.ChangeRequestIDHeader, strconv.FormatInt(id, 10))
+
+	resp := new(metadata.Response)
+	err = lgc.CoreAPI.ApiServer().Client().Verb(apirest.VerbType(request.Request.Method)).
+		WithContext(kit.Ctx).
+		Body([]byte(request.Request.Body)).
+		SubResourcef(strings.TrimPrefix(request.Request.Path, "/api/v3")).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+
+	status := metadata.ChangeRequestExecuted
+	result := &resp.BaseResp
+	if err != nil {
+		blog.Errorf("execute change request %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		status = metadata.ChangeRequestFailed
+		result = &metadata.BaseResp{Code: common.CCErrCommHTTPDoRequestFailed, ErrMsg: err.Error()}
+	} else if !resp.Result {
+		blog.Errorf("execute change request %d failed, resp: %#v, rid: %s", id, resp.BaseResp, kit.Rid)
+		status = metadata.ChangeRequestFailed
+	}
+
+	if err := lgc.transitChangeRequest(kit, request, status, mapstr.MapStr{"result": result}); err != nil {
+		return err
+	}
+	request.Result = result
+
+	if err := lgc.saveChangeRequestAudit(kit, request, metadata.AuditExecute); err != nil {
+		return err
+	}
+
+	if status == metadata.ChangeRequestFailed {
+		return kit.CCError.New(result.Code, result.ErrMsg)
+	}
+	return nil
+}
+
+// VerifyChangeRequest verifies that the api server request is the replayed request of the executing change request
+func (lgc *Logics) VerifyChangeRequest(kit *rest.Kit, opt *metadata.VerifyChangeRequestOption) error {
+	request, err := lgc.GetChangeRequest(kit, opt.ID)
+	if err != nil {
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestVerifyFailed, opt.ID)
+	}
+
+	if request.Status != metadata.ChangeRequestExecuting || request.Requester != kit.User ||
+		request.Request.Method != opt.Method || request.Request.Path != opt.Path ||
+		request.Request.Body != opt.Body {
+
+		blog.Errorf("request does not match change request %d, status: %s, opt: %#v, rid: %s", opt.ID,
+			request.Status, opt, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestVerifyFailed, opt.ID)
+	}
+
+	return nil
+}
+
+// ListExpiredChangeRequests list the pending change requests that exceed the expire time
+func (lgc *Logics) ListExpiredChangeRequests(ctx context.Context, limit uint64, rid string) (
+	[]metadata.ChangeRequest, error) {
+
+	cond := mapstr.MapStr{
+		common.BKStatusField: metadata.ChangeRequestPending,
+		"expire_time":        mapstr.MapStr{common.BKDBLT: time.Now()},
+	}
+
+	requests := make([]metadata.ChangeRequest, 0)
+	err := lgc.db.Table(common.BKTableNameChangeRequest).Find(cond).Sort(common.BKFieldID).Limit(limit).
+		All(ctx, &requests)
+	if err != nil {
+		blog.Errorf("list expired change requests failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// ExpireChangeRequest sets the pending change request to expired status
+func (lgc *Logics) ExpireChangeRequest(kit *rest.Kit, request *metadata.ChangeRequest) error {
+	if err := lgc.transitChangeRequest(kit, request, metadata.ChangeRequestExpired, nil); err != nil {
+		return err
+	}
+
+	return lgc.saveChangeRequestAudit(kit, request, metadata.AuditExpire)
+}
+
+// SearchChangeRequest search change requests
+func (lgc *Logics) SearchChangeRequest(kit *rest.Kit, opt *metadata.SearchChangeRequestOption) (
+	*metadata.SearchChangeRequestResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{common.BKOwnerIDField: kit.SupplierAccount}
+	if opt.ID != 0 {
+		cond[common.BKFieldID] = opt.ID
+	}
+	if len(opt.Operation) > 0 {
+		cond["operation"] = opt.Operation
+	}
+	if len(opt.Status) > 0 {
+		cond[common.BKStatusField] = mapstr.MapStr{common.BKDBIN: opt.Status}
+	}
+	if opt.BizID != 0 {
+		cond["bk_biz_ids"] = opt.BizID
+	}
+	if len(opt.Requester) > 0 {
+		cond["requester"] = opt.Requester
+	}
+	if len(opt.Approver) > 0 {
+		cond["approvers"] = opt.Approver
+	}
+
+	table := lgc.db.Table(common.BKTableNameChangeRequest)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count change request failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchChangeRequestResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-" + common.BKFieldID
+	}
+
+	requests := make([]metadata.ChangeRequest, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &requests)
+	if err != nil {
+		blog.Errorf("search change request failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchChangeRequestResult{Info: requests}, nil
+}
+
+// GetChangeRequest get change request by id
+func (lgc *Logics) GetChangeRequest(kit *rest.Kit, id int64) (*metadata.ChangeRequest, error) {
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+
+	request := new(metadata.ChangeRequest)
+	if err := lgc.db.Table(common.BKTableNameChangeRequest).Find(cond).One(kit.Ctx, request); err != nil {
+		if lgc.db.IsNotFoundError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrTaskChangeRequestNotExist, id)
+		}
+		blog.Errorf("get change request %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return request, nil
+}
+
+// transitChangeRequest changes the status of the change request from its current status, it fails if the status is
+// already changed by others, so that a change request can only be approved, rejected or executed once.
+func (lgc *Logics) transitChangeRequest(kit *rest.Kit, request *metadata.ChangeRequest,
+	status metadata.ChangeRequestStatus, doc mapstr.MapStr) error {
+
+	if !request.Status.CanTransitTo(status) {
+		blog.Errorf("change request %d can not be changed from %s to %s, rid: %s", request.ID, request.Status,
+			status, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestStatusInvalid, request.Status)
+	}
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      request.ID,
+		common.BKOwnerIDField: request.OwnerID,
+		common.BKStatusField:  request.Status,
+	}
+
+	if doc == nil {
+		doc = mapstr.New()
+	}
+	now := metadata.Now()
+	doc[common.BKStatusField] = status
+	doc[common.LastTimeField] = now
+
+	count, err := lgc.db.Table(common.BKTableNameChangeRequest).UpdateMany(kit.Ctx, cond, doc)
+	if err != nil {
+		blog.Errorf("update change request %d status to %s failed, err: %v, rid: %s", request.ID, status, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if count == 0 {
+		return kit.CCError.CCErrorf(common.CCErrTaskChangeRequestStatusInvalid, request.Status)
+	}
+
+	request.Status = status
+	request.LastTime = now
+	if approver, ok := doc["approver"].(string); ok {
+		request.Approver = approver
+	}
+	if comment, ok := doc["comment"].(string); ok {
+		request.Comment = comment
+	}
+	return nil
+}
+
+func (lgc *Logics) saveChangeRequestAudit(kit *rest.Kit, request *metadata.ChangeRequest,
+	action metadata.ActionType) error {
+
+	audit := auditlog.NewChangeApprovalAuditLog(lgc.CoreAPI.CoreService())
+	auditParam := auditlog.NewGenerateAuditCommonParameter(kit, action)
+	auditLog := audit.GenerateChangeRequestAuditLog(auditParam, request)
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save change request %d %s audit log failed, err: %v, rid: %s", request.ID, action, err,
+			kit.Rid)
+		return err
+	}
+
+	return nil
+}
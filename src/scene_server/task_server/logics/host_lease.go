@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package logics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	apigwcli "configcenter/src/common/resource/apigw"
+	"configcenter/src/common/util"
+	"configcenter/src/thirdparty/apigw/notice"
+)
+
+// ListExpiredHostLeases list the active host leases of all the tenants that are expired
+func (lgc *Logics) ListExpiredHostLeases(ctx context.Context, limit uint64, rid string) ([]metadata.HostLease,
+	error) {
+
+	cond := mapstr.MapStr{
+		common.BKStatusField: metadata.HostLeaseActive,
+		"expire_time":        mapstr.MapStr{common.BKDBLTE: time.Now()},
+	}
+
+	leases := make([]metadata.HostLease, 0)
+	err := lgc.db.Table(common.BKTableNameHostLease).Find(cond).Sort("expire_time").Limit(limit).All(ctx, &leases)
+	if err != nil {
+		blog.Errorf("list expired host leases failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// ListHostLeasesToRemind list the active host leases of all the tenants that reach the remind time and are not
+// reminded yet
+func (lgc *Logics) ListHostLeasesToRemind(ctx context.Context, limit uint64, rid string) ([]metadata.HostLease,
+	error) {
+
+	now := time.Now()
+	cond := mapstr.MapStr{
+		common.BKStatusField: metadata.HostLeaseActive,
+		"reminded":           false,
+		"remind_time":        mapstr.MapStr{common.BKDBLTE: now},
+		"expire_time":        mapstr.MapStr{common.BKDBGT: now},
+	}
+
+	leases := make([]metadata.HostLease, 0)
+	err := lgc.db.Table(common.BKTableNameHostLease).Find(cond).Sort("remind_time").Limit(limit).All(ctx, &leases)
+	if err != nil {
+		blog.Errorf("list host leases to remind failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// CreateReturnHostLeaseTask create the task that returns the hosts of the expired host lease, the task is not
+// created again if the former one is not finished yet
+func (lgc *Logics) CreateReturnHostLeaseTask(kit *rest.Kit, lease *metadata.HostLease) error {
+	task := &metadata.CreateTaskRequest{
+		TaskType: common.ReturnHostLeaseTaskFlag,
+		InstID:   lease.ID,
+		Data:     []interface{}{metadata.ReturnHostLeaseTaskOption{ID: lease.ID}},
+	}
+
+	_, err := lgc.Create(kit, task)
+	if err == nil {
+		return nil
+	}
+
+	if ccErr, ok := err.(errors.CCErrorCoder); ok && ccErr.GetCode() == common.CCErrTaskCreateConflict {
+		return nil
+	}
+
+	blog.Errorf("create return host lease %d task failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+	return err
+}
+
+// RemindHostLease send the expiration reminder of the host lease to the notifiers and the creator through bk-notice,
+// then mark the lease as reminded, the lease is only marked if bk-notice is not enabled
+func (lgc *Logics) RemindHostLease(kit *rest.Kit, lease *metadata.HostLease) error {
+	if apigwcli.Client() != nil && apigwcli.Client().Notice() != nil {
+		receivers := util.StrArrayUnique(append([]string{lease.Creator}, lease.Notifiers...))
+		req := &notice.SendMsgReq{
+			Title: fmt.Sprintf("host lease %d is about to expire", lease.ID),
+			Content: fmt.Sprintf("the %d hosts leased to business %d will be returned at %s, please extend the "+
+				"lease if they are still in use", len(lease.HostIDs), lease.BizID,
+				lease.ExpireTime.Format(time.RFC3339)),
+			Receivers: receivers,
+		}
+		if err := apigwcli.Client().Notice().SendMsg(kit.Ctx, kit.Header, req); err != nil {
+			blog.Errorf("send host lease %d reminder failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+			return err
+		}
+	}
+
+	reminded := true
+	updateOpt := &metadata.UpdateHostLeaseOption{Reminded: &reminded}
+	if _, err := lgc.CoreAPI.CoreService().Host().UpdateHostLease(kit.Ctx, kit.Header, lease.ID, updateOpt); err != nil {
+		blog.Errorf("mark host lease %d as reminded failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+		return err
+	}
+
+	history := metadata.HostLeaseHistory{
+		LeaseID:    lease.ID,
+		BizID:      lease.BizID,
+		HostIDs:    lease.HostIDs,
+		Action:     metadata.HostLeaseActionRemind,
+		ExpireTime: lease.ExpireTime,
+		Operator:   kit.User,
+	}
+	histories := []metadata.HostLeaseHistory{history}
+	if err := lgc.CoreAPI.CoreService().Host().CreateHostLeaseHistory(kit.Ctx, kit.Header, histories); err != nil {
+		blog.Errorf("create host lease %d remind history failed, err: %v, rid: %s", lease.ID, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
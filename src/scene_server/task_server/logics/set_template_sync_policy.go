@@ -0,0 +1,530 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package logics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	apigwcli "configcenter/src/common/resource/apigw"
+	"configcenter/src/common/util"
+	"configcenter/src/thirdparty/apigw/notice"
+)
+
+// CreateSetTemplateSyncPolicy create the sync policy of the set template, a set template has at most one policy
+func (lgc *Logics) CreateSetTemplateSyncPolicy(kit *rest.Kit, bizID int64,
+	opt *metadata.SetTemplateSyncPolicyOption) (*metadata.SetTemplateSyncPolicy, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	if err := lgc.validateSetTemplateSyncPolicy(kit, bizID, opt); err != nil {
+		return nil, err
+	}
+
+	existCond := mapstr.MapStr{
+		common.BKAppIDField:         bizID,
+		common.BKSetTemplateIDField: opt.SetTemplateID,
+		common.BKOwnerIDField:       kit.SupplierAccount,
+	}
+	exists := make([]metadata.SetTemplateSyncPolicy, 0)
+	err := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Find(existCond).Fields(common.BKFieldID).Limit(1).
+		All(kit.Ctx, &exists)
+	if err != nil {
+		blog.Errorf("get set template sync policy failed, err: %v, cond: %#v, rid: %s", err, existCond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(exists) > 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskSetTemplateSyncPolicyDuplicated, opt.SetTemplateID,
+			exists[0].ID)
+	}
+
+	id, err := lgc.db.NextSequence(kit.Ctx, common.BKTableNameSetTemplateSyncPolicy)
+	if err != nil {
+		blog.Errorf("generate set template sync policy id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	policy := &metadata.SetTemplateSyncPolicy{
+		ID:              int64(id),
+		BizID:           bizID,
+		SetTemplateID:   opt.SetTemplateID,
+		Mode:            opt.Mode,
+		Window:          opt.Window,
+		ExcludedSetIDs:  opt.ExcludedSetIDs,
+		NotifyDrift:     opt.NotifyDrift,
+		Notifiers:       opt.Notifiers,
+		OutOfSyncSetIDs: make([]int64, 0),
+		OwnerID:         kit.SupplierAccount,
+		Creator:         kit.User,
+		Modifier:        kit.User,
+		CreateTime:      now,
+		LastTime:        now,
+	}
+
+	if err := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Insert(kit.Ctx, policy); err != nil {
+		blog.Errorf("create set template sync policy failed, err: %v, policy: %#v, rid: %s", err, policy, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return policy, nil
+}
+
+// validateSetTemplateSyncPolicy check if the set template belongs to the business and all the excluded sets are
+// created by the set template
+func (lgc *Logics) validateSetTemplateSyncPolicy(kit *rest.Kit, bizID int64,
+	opt *metadata.SetTemplateSyncPolicyOption) error {
+
+	tmplCond := mapstr.MapStr{
+		common.BKFieldID:      opt.SetTemplateID,
+		common.BKAppIDField:   bizID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	cnt, err := lgc.db.Table(common.BKTableNameSetTemplate).Find(tmplCond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count set template failed, err: %v, cond: %#v, rid: %s", err, tmplCond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKSetTemplateIDField)
+	}
+
+	if len(opt.ExcludedSetIDs) == 0 {
+		return nil
+	}
+
+	setCond := mapstr.MapStr{
+		common.BKAppIDField:         bizID,
+		common.BKSetTemplateIDField: opt.SetTemplateID,
+		common.BKSetIDField:         mapstr.MapStr{common.BKDBIN: opt.ExcludedSetIDs},
+		common.BKOwnerIDField:       kit.SupplierAccount,
+	}
+	sets := make([]metadata.SetInst, 0)
+	if err := lgc.db.Table(common.BKTableNameBaseSet).Find(setCond).Fields(common.BKSetIDField).All(kit.Ctx,
+		&sets); err != nil {
+		blog.Errorf("get set template sync policy excluded sets failed, err: %v, cond: %#v, rid: %s", err, setCond,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	existMap := make(map[int64]struct{})
+	for _, set := range sets {
+		existMap[set.SetID] = struct{}{}
+	}
+	for _, setID := range opt.ExcludedSetIDs {
+		if _, exists := existMap[setID]; !exists {
+			return kit.CCError.CCErrorf(common.CCErrTaskSetTemplateSyncPolicySetInvalid, setID, opt.SetTemplateID)
+		}
+	}
+
+	return nil
+}
+
+// UpdateSetTemplateSyncPolicy update the sync policy, the set template of the policy can not be changed, the drift is
+// checked again by the timer with the new policy
+func (lgc *Logics) UpdateSetTemplateSyncPolicy(kit *rest.Kit, bizID, id int64,
+	opt *metadata.SetTemplateSyncPolicyOption) error {
+
+	policy, err := lgc.getSetTemplateSyncPolicy(kit, bizID, id)
+	if err != nil {
+		return err
+	}
+	opt.SetTemplateID = policy.SetTemplateID
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	if err := lgc.validateSetTemplateSyncPolicy(kit, bizID, opt); err != nil {
+		return err
+	}
+
+	doc := mapstr.MapStr{
+		"mode":               opt.Mode,
+		"window":             opt.Window,
+		"excluded_set_ids":   opt.ExcludedSetIDs,
+		"notify_drift":       opt.NotifyDrift,
+		"notifiers":          opt.Notifiers,
+		"last_check_time":    nil,
+		common.ModifierField: kit.User,
+		common.LastTimeField: metadata.Now(),
+	}
+	return lgc.updateSetTemplateSyncPolicy(kit, policy, doc)
+}
+
+// DeleteSetTemplateSyncPolicy delete the sync policy, the set template falls back to manual synchronization
+func (lgc *Logics) DeleteSetTemplateSyncPolicy(kit *rest.Kit, bizID, id int64) error {
+	policy, err := lgc.getSetTemplateSyncPolicy(kit, bizID, id)
+	if err != nil {
+		return err
+	}
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      policy.ID,
+		common.BKOwnerIDField: policy.OwnerID,
+	}
+	if err := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete set template sync policy %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
+
+// SearchSetTemplateSyncPolicy search set template sync policies with the results of their last drift check
+func (lgc *Logics) SearchSetTemplateSyncPolicy(kit *rest.Kit, opt *metadata.SearchSetTemplateSyncPolicyOption) (
+	*metadata.SearchSetTemplateSyncPolicyResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{
+		common.BKAppIDField:   opt.BizID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if len(opt.SetTemplateIDs) > 0 {
+		cond[common.BKSetTemplateIDField] = mapstr.MapStr{common.BKDBIN: opt.SetTemplateIDs}
+	}
+	if len(opt.Modes) > 0 {
+		cond["mode"] = mapstr.MapStr{common.BKDBIN: opt.Modes}
+	}
+
+	table := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count set template sync policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchSetTemplateSyncPolicyResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = common.BKFieldID
+	}
+
+	policies := make([]metadata.SetTemplateSyncPolicy, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &policies)
+	if err != nil {
+		blog.Errorf("search set template sync policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchSetTemplateSyncPolicyResult{Info: policies}, nil
+}
+
+func (lgc *Logics) getSetTemplateSyncPolicy(kit *rest.Kit, bizID, id int64) (*metadata.SetTemplateSyncPolicy,
+	error) {
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKAppIDField:   bizID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	policies := make([]metadata.SetTemplateSyncPolicy, 0)
+	if err := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Find(cond).All(kit.Ctx, &policies); err != nil {
+		blog.Errorf("get set template sync policy failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(policies) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskSetTemplateSyncPolicyNotExist, id)
+	}
+	return &policies[0], nil
+}
+
+func (lgc *Logics) updateSetTemplateSyncPolicy(kit *rest.Kit, policy *metadata.SetTemplateSyncPolicy,
+	doc mapstr.MapStr) error {
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      policy.ID,
+		common.BKOwnerIDField: policy.OwnerID,
+	}
+	if err := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Update(kit.Ctx, cond, doc); err != nil {
+		blog.Errorf("update set template sync policy %d failed, err: %v, doc: %#v, rid: %s", policy.ID, err, doc,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// GetSetTemplateSyncSummary get the summary report of the sets that are out of sync with their set templates in the
+// business, the drift is checked at once for all the set templates no matter whether they have sync policies
+func (lgc *Logics) GetSetTemplateSyncSummary(kit *rest.Kit, bizID int64) (*metadata.SetTemplateSyncSummary,
+	error) {
+
+	tmplCond := mapstr.MapStr{
+		common.BKAppIDField:   bizID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	templates := make([]metadata.SetTemplate, 0)
+	err := lgc.db.Table(common.BKTableNameSetTemplate).Find(tmplCond).Fields(common.BKFieldID).
+		Sort(common.BKFieldID).All(kit.Ctx, &templates)
+	if err != nil {
+		blog.Errorf("get set templates failed, err: %v, cond: %#v, rid: %s", err, tmplCond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	policies := make([]metadata.SetTemplateSyncPolicy, 0)
+	err = lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Find(tmplCond).
+		Fields(common.BKSetTemplateIDField, "mode", "excluded_set_ids").All(kit.Ctx, &policies)
+	if err != nil {
+		blog.Errorf("get set template sync policies failed, err: %v, cond: %#v, rid: %s", err, tmplCond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	policyMap := make(map[int64]*metadata.SetTemplateSyncPolicy)
+	for idx := range policies {
+		policyMap[policies[idx].SetTemplateID] = &policies[idx]
+	}
+
+	summary := &metadata.SetTemplateSyncSummary{
+		BizID:            bizID,
+		SetTemplateCount: len(templates),
+		Templates:        make([]metadata.SetTemplateSyncSummaryItem, 0),
+	}
+	for _, template := range templates {
+		status, err := lgc.CoreAPI.TopoServer().SetTemplate().CheckSetInstUpdateToDateStatus(kit.Ctx, kit.Header,
+			bizID, template.ID)
+		if err != nil {
+			blog.Errorf("check set template %d sets status failed, err: %v, rid: %s", template.ID, err, kit.Rid)
+			return nil, err
+		}
+
+		addSetTemplateSyncSummary(summary, template.ID, status, policyMap[template.ID])
+	}
+
+	return summary, nil
+}
+
+// addSetTemplateSyncSummary add the drift status of the set template to the summary, the set template is synchronized
+// manually if it has no policy, only the set templates that have drifted sets are listed in the summary
+func addSetTemplateSyncSummary(summary *metadata.SetTemplateSyncSummary, templateID int64,
+	status *metadata.SetTemplateUpdateToDateStatus, policy *metadata.SetTemplateSyncPolicy) {
+
+	item := metadata.SetTemplateSyncSummaryItem{
+		SetTemplateID: templateID,
+		Mode:          metadata.SetTemplateSyncManual,
+		SetCount:      len(status.Sets),
+	}
+
+	var excluded []int64
+	if policy != nil {
+		item.Mode = policy.Mode
+		excluded = policy.ExcludedSetIDs
+	}
+	item.OutOfSyncSetIDs, item.ExcludedSetIDs = splitDriftedSets(status, excluded)
+
+	summary.SetCount += item.SetCount
+	summary.OutOfSyncCount += len(item.OutOfSyncSetIDs)
+	summary.ExcludedCount += len(item.ExcludedSetIDs)
+	if len(item.OutOfSyncSetIDs) > 0 || len(item.ExcludedSetIDs) > 0 {
+		summary.Templates = append(summary.Templates, item)
+	}
+}
+
+// splitDriftedSets returns the drifted sets that are not excluded and the drifted sets that are excluded
+func splitDriftedSets(status *metadata.SetTemplateUpdateToDateStatus, excludedSetIDs []int64) ([]int64, []int64) {
+	excludedMap := make(map[int64]struct{})
+	for _, setID := range excludedSetIDs {
+		excludedMap[setID] = struct{}{}
+	}
+
+	outOfSync, excluded := make([]int64, 0), make([]int64, 0)
+	for _, set := range status.Sets {
+		if !set.NeedSync {
+			continue
+		}
+
+		if _, exists := excludedMap[set.SetID]; exists {
+			excluded = append(excluded, set.SetID)
+			continue
+		}
+		outOfSync = append(outOfSync, set.SetID)
+	}
+	return outOfSync, excluded
+}
+
+// ListSetTemplateSyncPoliciesToCheck list the sync policies of all the tenants that are not checked in the check
+// interval
+func (lgc *Logics) ListSetTemplateSyncPoliciesToCheck(ctx context.Context, limit uint64, rid string) (
+	[]metadata.SetTemplateSyncPolicy, error) {
+
+	cond := mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{"last_check_time": nil},
+			{"last_check_time": mapstr.MapStr{
+				common.BKDBLTE: time.Now().Add(-metadata.SetTemplateSyncCheckInterval)},
+			},
+		},
+	}
+
+	policies := make([]metadata.SetTemplateSyncPolicy, 0)
+	err := lgc.db.Table(common.BKTableNameSetTemplateSyncPolicy).Find(cond).Sort("last_check_time").Limit(limit).
+		All(ctx, &policies)
+	if err != nil {
+		blog.Errorf("list set template sync policies to check failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// CheckSetTemplateSyncPolicy check the drift of the sets of the set template, notify the newly drifted sets and
+// synchronize the drifted sets that are not excluded if the policy allows to synchronize them now
+func (lgc *Logics) CheckSetTemplateSyncPolicy(kit *rest.Kit, policy *metadata.SetTemplateSyncPolicy) error {
+	now := metadata.Now()
+	doc := mapstr.MapStr{"last_check_time": now}
+
+	// check and synchronize the sets as the system user, the automatic synchronization is done by the policy, not by
+	// the user who modified the policy last, so the audit logs do not attribute the changes to that user
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, policy.OwnerID, kit.Rid)
+	status, err := lgc.CoreAPI.TopoServer().SetTemplate().CheckSetInstUpdateToDateStatus(kit.Ctx, header,
+		policy.BizID, policy.SetTemplateID)
+	if err != nil {
+		blog.Errorf("check set template %d sets status failed, err: %v, rid: %s", policy.SetTemplateID, err, kit.Rid)
+		doc["message"] = fmt.Sprintf("check sets status failed, err: %v", err)
+		if updateErr := lgc.updateSetTemplateSyncPolicy(kit, policy, doc); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	outOfSync, _ := splitDriftedSets(status, policy.ExcludedSetIDs)
+	doc["out_of_sync_set_ids"] = outOfSync
+	doc["message"] = ""
+
+	if policy.NotifyDrift {
+		lgc.notifySetTemplateDrift(kit, policy, outOfSync)
+	}
+
+	if len(outOfSync) > 0 && shouldSyncSetTemplate(policy, time.Now()) {
+		if err := lgc.syncSetTemplateSets(kit, header, policy, outOfSync); err != nil {
+			doc["message"] = fmt.Sprintf("synchronize sets failed, err: %v", err)
+		} else {
+			doc["last_sync_time"] = now
+		}
+	}
+
+	return lgc.updateSetTemplateSyncPolicy(kit, policy, doc)
+}
+
+// shouldSyncSetTemplate check if the drifted sets can be synchronized automatically by the policy at the time
+func shouldSyncSetTemplate(policy *metadata.SetTemplateSyncPolicy, t time.Time) bool {
+	switch policy.Mode {
+	case metadata.SetTemplateSyncAutoOnChange:
+		return true
+	case metadata.SetTemplateSyncScheduled:
+		return policy.Window != nil && policy.Window.Contains(t)
+	default:
+		return false
+	}
+}
+
+// syncSetTemplateSets synchronize the drifted sets, the sets whose previous sync tasks are not finished yet are
+// skipped to avoid dispatching duplicate sync tasks
+func (lgc *Logics) syncSetTemplateSets(kit *rest.Kit, header http.Header,
+	policy *metadata.SetTemplateSyncPolicy, setIDs []int64) error {
+
+	cond := mapstr.MapStr{
+		common.BKTaskTypeField: common.SyncSetTaskFlag,
+		common.BKInstIDField:   mapstr.MapStr{common.BKDBIN: setIDs},
+		common.BKStatusField: mapstr.MapStr{common.BKDBIN: []metadata.APITaskStatus{metadata.APITaskStatusNew,
+			metadata.APITaskStatusWaitExecute, metadata.APITaskStatusExecute}},
+	}
+	tasks := make([]metadata.APITaskDetail, 0)
+	err := lgc.db.Table(common.BKTableNameAPITask).Find(cond).Fields(common.BKInstIDField).All(kit.Ctx, &tasks)
+	if err != nil {
+		blog.Errorf("get unfinished set sync tasks failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	syncingMap := make(map[int64]struct{})
+	for _, task := range tasks {
+		syncingMap[task.InstID] = struct{}{}
+	}
+
+	syncSetIDs := make([]int64, 0)
+	for _, setID := range setIDs {
+		if _, exists := syncingMap[setID]; !exists {
+			syncSetIDs = append(syncSetIDs, setID)
+		}
+	}
+	if len(syncSetIDs) == 0 {
+		return nil
+	}
+
+	opt := &metadata.SyncSetTplToInstOption{SetIDs: syncSetIDs}
+	if err := lgc.CoreAPI.TopoServer().SetTemplate().SyncSetTplToInst(kit.Ctx, header, policy.BizID,
+		policy.SetTemplateID, opt); err != nil {
+		blog.Errorf("sync set template %d to sets %v failed, err: %v, rid: %s", policy.SetTemplateID, syncSetIDs,
+			err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// notifySetTemplateDrift send the drift notification to the notifiers and the creator through bk-notice if there are
+// sets that are not drifted in the last check, the notification is skipped if bk-notice is not enabled
+func (lgc *Logics) notifySetTemplateDrift(kit *rest.Kit, policy *metadata.SetTemplateSyncPolicy, outOfSync []int64) {
+	if apigwcli.Client() == nil || apigwcli.Client().Notice() == nil {
+		return
+	}
+
+	drifted := newlyDriftedSets(policy.OutOfSyncSetIDs, outOfSync)
+	if len(drifted) == 0 {
+		return
+	}
+
+	req := &notice.SendMsgReq{
+		Title: fmt.Sprintf("sets drift from set template %d", policy.SetTemplateID),
+		Content: fmt.Sprintf("%d sets of set template %d in business %d are out of sync with the set template, "+
+			"sets: %v, sync mode: %s", len(drifted), policy.SetTemplateID, policy.BizID, drifted, policy.Mode),
+		Receivers: util.StrArrayUnique(append([]string{policy.Creator}, policy.Notifiers...)),
+	}
+	if err := apigwcli.Client().Notice().SendMsg(kit.Ctx, kit.Header, req); err != nil {
+		blog.Errorf("send set template sync policy %d drift notification failed, err: %v, rid: %s", policy.ID, err,
+			kit.Rid)
+	}
+}
+
+// newlyDriftedSets returns the out of sync sets that are not out of sync in the last check
+func newlyDriftedSets(prevOutOfSync, outOfSync []int64) []int64 {
+	prevMap := make(map[int64]struct{})
+	for _, setID := range prevOutOfSync {
+		prevMap[setID] = struct{}{}
+	}
+
+	drifted := make([]int64, 0)
+	for _, setID := range outOfSync {
+		if _, exists := prevMap[setID]; !exists {
+			drifted = append(drifted, setID)
+		}
+	}
+	return drifted
+}
@@ -0,0 +1,146 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestShouldSyncSetTemplate(t *testing.T) {
+	// 2026-10-19 is Monday
+	monday := func(hour, min int) time.Time { return time.Date(2026, 10, 19, hour, min, 0, 0, time.Local) }
+	nightly := &metadata.SetTemplateSyncWindow{StartTime: "22:00", EndTime: "02:00",
+		Weekdays: []int{int(time.Sunday)}}
+	daytime := &metadata.SetTemplateSyncWindow{StartTime: "09:00", EndTime: "18:00"}
+
+	tests := []struct {
+		name   string
+		policy *metadata.SetTemplateSyncPolicy
+		time   time.Time
+		sync   bool
+	}{
+		{name: "manual", policy: &metadata.SetTemplateSyncPolicy{Mode: metadata.SetTemplateSyncManual},
+			time: monday(10, 0), sync: false},
+		{name: "auto on change", policy: &metadata.SetTemplateSyncPolicy{Mode: metadata.SetTemplateSyncAutoOnChange},
+			time: monday(3, 0), sync: true},
+		{name: "scheduled without window", policy: &metadata.SetTemplateSyncPolicy{
+			Mode: metadata.SetTemplateSyncScheduled}, time: monday(10, 0), sync: false},
+		{name: "scheduled in window", policy: &metadata.SetTemplateSyncPolicy{Mode: metadata.SetTemplateSyncScheduled,
+			Window: daytime}, time: monday(9, 0), sync: true},
+		{name: "scheduled at window end", policy: &metadata.SetTemplateSyncPolicy{
+			Mode: metadata.SetTemplateSyncScheduled, Window: daytime}, time: monday(18, 0), sync: false},
+		{name: "scheduled before window", policy: &metadata.SetTemplateSyncPolicy{
+			Mode: metadata.SetTemplateSyncScheduled, Window: daytime}, time: monday(8, 59), sync: false},
+		// the window that crosses midnight belongs to the weekday it opens
+		{name: "scheduled after midnight of the window opened on sunday", policy: &metadata.SetTemplateSyncPolicy{
+			Mode: metadata.SetTemplateSyncScheduled, Window: nightly}, time: monday(1, 0), sync: true},
+		{name: "scheduled before midnight of the window opened on monday", policy: &metadata.SetTemplateSyncPolicy{
+			Mode: metadata.SetTemplateSyncScheduled, Window: nightly}, time: monday(23, 0), sync: false},
+		{name: "unknown mode", policy: &metadata.SetTemplateSyncPolicy{Mode: "unknown"}, time: monday(10, 0),
+			sync: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sync := shouldSyncSetTemplate(tt.policy, tt.time); sync != tt.sync {
+				t.Errorf("shouldSyncSetTemplate() = %v, want %v", sync, tt.sync)
+			}
+		})
+	}
+}
+
+func TestSplitDriftedSets(t *testing.T) {
+	status := &metadata.SetTemplateUpdateToDateStatus{Sets: []metadata.SetUpdateToDateStatus{
+		{SetID: 1, NeedSync: true}, {SetID: 2}, {SetID: 3, NeedSync: true}, {SetID: 4, NeedSync: true}}}
+
+	tests := []struct {
+		name      string
+		excluded  []int64
+		outOfSync []int64
+		drifted   []int64
+	}{
+		{name: "no excluded sets", outOfSync: []int64{1, 3, 4}, drifted: []int64{}},
+		{name: "excluded drifted sets", excluded: []int64{3, 4}, outOfSync: []int64{1}, drifted: []int64{3, 4}},
+		{name: "excluded sets that are not drifted", excluded: []int64{2, 5}, outOfSync: []int64{1, 3, 4},
+			drifted: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outOfSync, drifted := splitDriftedSets(status, tt.excluded)
+			if !reflect.DeepEqual(outOfSync, tt.outOfSync) || !reflect.DeepEqual(drifted, tt.drifted) {
+				t.Errorf("splitDriftedSets() = %v, %v, want %v, %v", outOfSync, drifted, tt.outOfSync, tt.drifted)
+			}
+		})
+	}
+}
+
+func TestAddSetTemplateSyncSummary(t *testing.T) {
+	summary := &metadata.SetTemplateSyncSummary{BizID: 1, SetTemplateCount: 3,
+		Templates: make([]metadata.SetTemplateSyncSummaryItem, 0)}
+
+	// set template without policy is synchronized manually
+	addSetTemplateSyncSummary(summary, 1, &metadata.SetTemplateUpdateToDateStatus{
+		Sets: []metadata.SetUpdateToDateStatus{{SetID: 1, NeedSync: true}, {SetID: 2}}}, nil)
+	// set template whose drifted sets are all excluded is still listed
+	addSetTemplateSyncSummary(summary, 2, &metadata.SetTemplateUpdateToDateStatus{
+		Sets: []metadata.SetUpdateToDateStatus{{SetID: 1, NeedSync: true}, {SetID: 2, NeedSync: true}, {SetID: 3}}},
+		&metadata.SetTemplateSyncPolicy{Mode: metadata.SetTemplateSyncScheduled, ExcludedSetIDs: []int64{1, 2}})
+	// set template without drifted sets is only counted
+	addSetTemplateSyncSummary(summary, 3, &metadata.SetTemplateUpdateToDateStatus{
+		Sets: []metadata.SetUpdateToDateStatus{{SetID: 1}}},
+		&metadata.SetTemplateSyncPolicy{Mode: metadata.SetTemplateSyncAutoOnChange})
+
+	expected := &metadata.SetTemplateSyncSummary{
+		BizID:            1,
+		SetTemplateCount: 3,
+		SetCount:         6,
+		OutOfSyncCount:   1,
+		ExcludedCount:    2,
+		Templates: []metadata.SetTemplateSyncSummaryItem{
+			{SetTemplateID: 1, Mode: metadata.SetTemplateSyncManual, SetCount: 2, OutOfSyncSetIDs: []int64{1},
+				ExcludedSetIDs: []int64{}},
+			{SetTemplateID: 2, Mode: metadata.SetTemplateSyncScheduled, SetCount: 3, OutOfSyncSetIDs: []int64{},
+				ExcludedSetIDs: []int64{1, 2}},
+		},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("summary %+v is not equal to %+v", summary, expected)
+	}
+}
+
+func TestNewlyDriftedSets(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      []int64
+		outOfSync []int64
+		drifted   []int64
+	}{
+		{name: "first check", prev: nil, outOfSync: []int64{1, 2}, drifted: []int64{1, 2}},
+		{name: "already notified", prev: []int64{1, 2}, outOfSync: []int64{1, 2}, drifted: []int64{}},
+		{name: "newly drifted", prev: []int64{1, 3}, outOfSync: []int64{1, 2}, drifted: []int64{2}},
+		{name: "all synchronized", prev: []int64{1}, outOfSync: []int64{}, drifted: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if drifted := newlyDriftedSets(tt.prev, tt.outOfSync); !reflect.DeepEqual(drifted, tt.drifted) {
+				t.Errorf("newlyDriftedSets() = %v, want %v", drifted, tt.drifted)
+			}
+		})
+	}
+}
@@ -0,0 +1,537 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package logics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// CreateSyncRollout create service template sync rollout, the canary modules are synchronized by the timer at once
+func (lgc *Logics) CreateSyncRollout(kit *rest.Kit, bizID int64, opt *metadata.CreateSyncRolloutOption) (
+	*metadata.ServiceTemplateSyncRollout, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	if err := lgc.validateSyncRolloutModules(kit, bizID, opt); err != nil {
+		return nil, err
+	}
+
+	unfinishedCond := mapstr.MapStr{
+		common.BKAppIDField:             bizID,
+		common.BKServiceTemplateIDField: opt.ServiceTemplateID,
+		common.BKStatusField:            mapstr.MapStr{common.BKDBIN: metadata.SyncRolloutUnfinishedStatus},
+		common.BKOwnerIDField:           kit.SupplierAccount,
+	}
+	unfinished := make([]metadata.ServiceTemplateSyncRollout, 0)
+	err := lgc.db.Table(common.BKTableNameSyncRollout).Find(unfinishedCond).Fields(common.BKFieldID).Limit(1).
+		All(kit.Ctx, &unfinished)
+	if err != nil {
+		blog.Errorf("get unfinished sync rollout failed, err: %v, cond: %#v, rid: %s", err, unfinishedCond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(unfinished) > 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskSyncRolloutDuplicated, opt.ServiceTemplateID,
+			unfinished[0].ID)
+	}
+
+	id, err := lgc.db.NextSequence(kit.Ctx, common.BKTableNameSyncRollout)
+	if err != nil {
+		blog.Errorf("generate sync rollout id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	canaryMap := make(map[int64]struct{})
+	for _, moduleID := range opt.CanaryModuleIDs {
+		canaryMap[moduleID] = struct{}{}
+	}
+	modules := make([]metadata.SyncRolloutModule, len(opt.ModuleIDs))
+	for idx, moduleID := range opt.ModuleIDs {
+		_, isCanary := canaryMap[moduleID]
+		modules[idx] = metadata.SyncRolloutModule{
+			ModuleID: moduleID,
+			Canary:   isCanary,
+			Status:   metadata.SyncRolloutModuleWaiting,
+		}
+	}
+
+	now := metadata.Now()
+	rollout := &metadata.ServiceTemplateSyncRollout{
+		ID:                int64(id),
+		BizID:             bizID,
+		ServiceTemplateID: opt.ServiceTemplateID,
+		Concurrency:       opt.Concurrency,
+		PauseDuration:     opt.PauseDuration,
+		Modules:           modules,
+		Status:            metadata.SyncRolloutRunning,
+		OwnerID:           kit.SupplierAccount,
+		Creator:           kit.User,
+		Modifier:          kit.User,
+		CreateTime:        now,
+		LastTime:          now,
+	}
+
+	if err := lgc.db.Table(common.BKTableNameSyncRollout).Insert(kit.Ctx, rollout); err != nil {
+		blog.Errorf("create sync rollout failed, err: %v, rollout: %#v, rid: %s", err, rollout, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return rollout, nil
+}
+
+// validateSyncRolloutModules check if all the modules of the rollout are created by the service template
+func (lgc *Logics) validateSyncRolloutModules(kit *rest.Kit, bizID int64,
+	opt *metadata.CreateSyncRolloutOption) error {
+
+	cond := mapstr.MapStr{
+		common.BKAppIDField:             bizID,
+		common.BKServiceTemplateIDField: opt.ServiceTemplateID,
+		common.BKModuleIDField:          mapstr.MapStr{common.BKDBIN: opt.ModuleIDs},
+		common.BKOwnerIDField:           kit.SupplierAccount,
+	}
+	modules := make([]metadata.ModuleInst, 0)
+	err := lgc.db.Table(common.BKTableNameBaseModule).Find(cond).Fields(common.BKModuleIDField).All(kit.Ctx,
+		&modules)
+	if err != nil {
+		blog.Errorf("get sync rollout modules failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	existMap := make(map[int64]struct{})
+	for _, module := range modules {
+		existMap[module.ModuleID] = struct{}{}
+	}
+	for _, moduleID := range opt.ModuleIDs {
+		if _, exists := existMap[moduleID]; !exists {
+			return kit.CCError.CCErrorf(common.CCErrTaskSyncRolloutModuleInvalid, moduleID, opt.ServiceTemplateID)
+		}
+	}
+
+	return nil
+}
+
+// SearchSyncRollout search service template sync rollouts
+func (lgc *Logics) SearchSyncRollout(kit *rest.Kit, opt *metadata.SearchSyncRolloutOption) (
+	*metadata.SearchSyncRolloutResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{
+		common.BKAppIDField:   opt.BizID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if opt.ServiceTemplateID > 0 {
+		cond[common.BKServiceTemplateIDField] = opt.ServiceTemplateID
+	}
+	if len(opt.Status) > 0 {
+		cond[common.BKStatusField] = mapstr.MapStr{common.BKDBIN: opt.Status}
+	}
+
+	table := lgc.db.Table(common.BKTableNameSyncRollout)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count sync rollout failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchSyncRolloutResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-" + common.BKFieldID
+	}
+
+	rollouts := make([]metadata.ServiceTemplateSyncRollout, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &rollouts)
+	if err != nil {
+		blog.Errorf("search sync rollout failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchSyncRolloutResult{Info: rollouts}, nil
+}
+
+// ResumeSyncRollout confirm the paused sync rollout to continue synchronizing the remaining modules
+func (lgc *Logics) ResumeSyncRollout(kit *rest.Kit, bizID, id int64) error {
+	rollout, err := lgc.getSyncRollout(kit, bizID, id)
+	if err != nil {
+		return err
+	}
+
+	if rollout.Status != metadata.SyncRolloutPaused {
+		return kit.CCError.CCErrorf(common.CCErrTaskSyncRolloutStatusInvalid, rollout.Status)
+	}
+
+	doc := mapstr.MapStr{
+		common.BKStatusField: metadata.SyncRolloutRunning,
+		"resume_time":        nil,
+		"message":            "",
+		common.ModifierField: kit.User,
+		common.LastTimeField: metadata.Now(),
+	}
+	return lgc.updateSyncRolloutStatus(kit, rollout, doc)
+}
+
+// AbortSyncRollout abort the sync rollout, the modules that are not synchronized yet are skipped, the modules that
+// are being synchronized are not affected
+func (lgc *Logics) AbortSyncRollout(kit *rest.Kit, bizID, id int64) error {
+	rollout, err := lgc.getSyncRollout(kit, bizID, id)
+	if err != nil {
+		return err
+	}
+
+	if !isSyncRolloutUnfinished(rollout.Status) {
+		return kit.CCError.CCErrorf(common.CCErrTaskSyncRolloutStatusInvalid, rollout.Status)
+	}
+
+	for idx := range rollout.Modules {
+		if rollout.Modules[idx].Status == metadata.SyncRolloutModuleWaiting {
+			rollout.Modules[idx].Status = metadata.SyncRolloutModuleSkipped
+		}
+	}
+
+	doc := mapstr.MapStr{
+		common.BKStatusField: metadata.SyncRolloutAborted,
+		"modules":            rollout.Modules,
+		"resume_time":        nil,
+		"message":            fmt.Sprintf("aborted by %s", kit.User),
+		common.ModifierField: kit.User,
+		common.LastTimeField: metadata.Now(),
+	}
+	return lgc.updateSyncRolloutStatus(kit, rollout, doc)
+}
+
+func isSyncRolloutUnfinished(status metadata.SyncRolloutStatus) bool {
+	for _, unfinished := range metadata.SyncRolloutUnfinishedStatus {
+		if status == unfinished {
+			return true
+		}
+	}
+	return false
+}
+
+func (lgc *Logics) getSyncRollout(kit *rest.Kit, bizID, id int64) (*metadata.ServiceTemplateSyncRollout, error) {
+	cond := mapstr.MapStr{
+		common.BKFieldID:      id,
+		common.BKAppIDField:   bizID,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+
+	rollouts := make([]metadata.ServiceTemplateSyncRollout, 0)
+	if err := lgc.db.Table(common.BKTableNameSyncRollout).Find(cond).All(kit.Ctx, &rollouts); err != nil {
+		blog.Errorf("get sync rollout %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(rollouts) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTaskSyncRolloutNotExist, id)
+	}
+
+	return &rollouts[0], nil
+}
+
+// updateSyncRolloutStatus update the sync rollout only if its status is not changed by others in the meantime
+func (lgc *Logics) updateSyncRolloutStatus(kit *rest.Kit, rollout *metadata.ServiceTemplateSyncRollout,
+	doc mapstr.MapStr) error {
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:      rollout.ID,
+		common.BKStatusField:  rollout.Status,
+		common.BKOwnerIDField: rollout.OwnerID,
+	}
+
+	cnt, err := lgc.db.Table(common.BKTableNameSyncRollout).UpdateMany(kit.Ctx, cond, doc)
+	if err != nil {
+		blog.Errorf("update sync rollout %d failed, err: %v, doc: %#v, rid: %s", rollout.ID, err, doc, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if cnt == 0 {
+		return kit.CCError.CCErrorf(common.CCErrTaskSyncRolloutStatusInvalid, rollout.Status)
+	}
+
+	return nil
+}
+
+// ListSyncRolloutsToAdvance list the sync rollouts of all the tenants that need to be advanced, including the
+// running ones, the paused ones that reach the resume time and the aborted ones with modules still being synchronized
+func (lgc *Logics) ListSyncRolloutsToAdvance(ctx context.Context, limit uint64, rid string) (
+	[]metadata.ServiceTemplateSyncRollout, error) {
+
+	cond := mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKStatusField: metadata.SyncRolloutRunning},
+			{
+				common.BKStatusField: metadata.SyncRolloutPaused,
+				"resume_time":        mapstr.MapStr{common.BKDBLTE: time.Now()},
+			},
+			{
+				common.BKStatusField: metadata.SyncRolloutAborted,
+				"modules.status":     metadata.SyncRolloutModuleSyncing,
+			},
+		},
+	}
+
+	rollouts := make([]metadata.ServiceTemplateSyncRollout, 0)
+	err := lgc.db.Table(common.BKTableNameSyncRollout).Find(cond).Sort(common.LastTimeField).Limit(limit).
+		All(ctx, &rollouts)
+	if err != nil {
+		blog.Errorf("list sync rollouts to advance failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, err
+	}
+
+	return rollouts, nil
+}
+
+// AdvanceSyncRollout refresh the results of the modules that are being synchronized, when the current batch is done,
+// pause the rollout if the batch is the canary batch or some modules failed, otherwise start the next batch
+func (lgc *Logics) AdvanceSyncRollout(kit *rest.Kit, rollout *metadata.ServiceTemplateSyncRollout) error {
+	doc := mapstr.MapStr{common.LastTimeField: metadata.Now()}
+
+	if rollout.Status == metadata.SyncRolloutPaused {
+		// the pause window after the canary batch is over, continue the rollout
+		doc[common.BKStatusField] = metadata.SyncRolloutRunning
+		doc["resume_time"] = nil
+		doc["message"] = ""
+		if err := lgc.updateSyncRolloutStatus(kit, rollout, doc); err != nil {
+			return err
+		}
+		rollout.Status = metadata.SyncRolloutRunning
+		rollout.ResumeTime = nil
+		rollout.Message = ""
+		doc = mapstr.MapStr{common.LastTimeField: metadata.Now()}
+	}
+
+	done, err := lgc.refreshSyncRolloutModules(kit, rollout)
+	if err != nil {
+		return err
+	}
+	doc["modules"] = rollout.Modules
+
+	if rollout.Status != metadata.SyncRolloutRunning || hasSyncingModule(rollout) {
+		return lgc.updateSyncRolloutStatus(kit, rollout, doc)
+	}
+
+	// the current batch is done, check its result before starting the next batch
+	if len(done) > 0 {
+		failedCnt, isCanaryBatch := 0, true
+		for _, idx := range done {
+			if rollout.Modules[idx].Status == metadata.SyncRolloutModuleFailed {
+				failedCnt++
+			}
+			if !rollout.Modules[idx].Canary {
+				isCanaryBatch = false
+			}
+		}
+
+		if failedCnt > 0 {
+			doc[common.BKStatusField] = metadata.SyncRolloutPaused
+			doc["message"] = fmt.Sprintf("%d modules failed to synchronize, resume the rollout to continue",
+				failedCnt)
+			return lgc.updateSyncRolloutStatus(kit, rollout, doc)
+		}
+
+		if isCanaryBatch && len(rollout.NextBatch()) > 0 {
+			doc[common.BKStatusField] = metadata.SyncRolloutPaused
+			doc["message"] = "canary modules are synchronized, waiting for the confirmation"
+			if rollout.PauseDuration > 0 {
+				resumeTime := metadata.Time{Time: time.Now().Add(time.Duration(rollout.PauseDuration) * time.Second)}
+				doc["resume_time"] = resumeTime
+				doc["message"] = fmt.Sprintf("canary modules are synchronized, the rollout continues at %s",
+					resumeTime.Format(time.RFC3339))
+			}
+			return lgc.updateSyncRolloutStatus(kit, rollout, doc)
+		}
+	}
+
+	lgc.startSyncRolloutBatch(kit, rollout, doc)
+	return lgc.updateSyncRolloutStatus(kit, rollout, doc)
+}
+
+func hasSyncingModule(rollout *metadata.ServiceTemplateSyncRollout) bool {
+	for _, module := range rollout.Modules {
+		if module.Status == metadata.SyncRolloutModuleSyncing {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshSyncRolloutModules update the status of the syncing modules by their sync tasks, returns the index of the
+// modules whose sync tasks are done
+func (lgc *Logics) refreshSyncRolloutModules(kit *rest.Kit, rollout *metadata.ServiceTemplateSyncRollout) ([]int,
+	error) {
+
+	taskIDs := make([]string, 0)
+	for _, module := range rollout.Modules {
+		if module.Status == metadata.SyncRolloutModuleSyncing {
+			taskIDs = append(taskIDs, module.TaskID)
+		}
+	}
+	if len(taskIDs) == 0 {
+		return make([]int, 0), nil
+	}
+
+	cond := mapstr.MapStr{common.BKTaskIDField: mapstr.MapStr{common.BKDBIN: taskIDs}}
+	tasks := make([]metadata.APITaskDetail, 0)
+	if err := lgc.db.Table(common.BKTableNameAPITask).Find(cond).All(kit.Ctx, &tasks); err != nil {
+		blog.Errorf("get sync rollout %d tasks failed, err: %v, cond: %#v, rid: %s", rollout.ID, err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return setSyncRolloutModuleResults(rollout, tasks), nil
+}
+
+// setSyncRolloutModuleResults update the status of the syncing modules by the results of their sync tasks, returns
+// the index of the modules whose sync tasks are done
+func setSyncRolloutModuleResults(rollout *metadata.ServiceTemplateSyncRollout, tasks []metadata.APITaskDetail) []int {
+	taskMap := make(map[string]metadata.APITaskDetail)
+	for _, task := range tasks {
+		taskMap[task.TaskID] = task
+	}
+
+	done := make([]int, 0)
+	for idx := range rollout.Modules {
+		module := &rollout.Modules[idx]
+		if module.Status != metadata.SyncRolloutModuleSyncing {
+			continue
+		}
+
+		task, exists := taskMap[module.TaskID]
+		if !exists {
+			// the task is deleted as history task, its result can not be known any more
+			module.Status = metadata.SyncRolloutModuleFailed
+			module.Message = fmt.Sprintf("sync task %s is not found", module.TaskID)
+			done = append(done, idx)
+			continue
+		}
+
+		switch task.Status {
+		case metadata.APITaskStatusSuccess:
+			module.Status = metadata.SyncRolloutModuleSuccess
+			module.Message = ""
+		case metadata.APITAskStatusFail:
+			module.Status = metadata.SyncRolloutModuleFailed
+			module.Message = getSyncTaskFailMessage(&task)
+		default:
+			continue
+		}
+		done = append(done, idx)
+	}
+
+	return done
+}
+
+// getSyncTaskFailMessage get the error message of the first failed sub task
+func getSyncTaskFailMessage(task *metadata.APITaskDetail) string {
+	for _, subTask := range task.Detail {
+		if subTask.Status == metadata.APITAskStatusFail && subTask.Response != nil {
+			return subTask.Response.ErrMsg
+		}
+	}
+	return fmt.Sprintf("sync task %s failed", task.TaskID)
+}
+
+// startSyncRolloutBatch create the sync tasks of the modules in the next batch and set them as syncing, the rollout
+// is finished if there is no module to synchronize, and paused if the sync tasks failed to be created
+func (lgc *Logics) startSyncRolloutBatch(kit *rest.Kit, rollout *metadata.ServiceTemplateSyncRollout,
+	doc mapstr.MapStr) {
+
+	batch := rollout.NextBatch()
+	if len(batch) == 0 {
+		doc[common.BKStatusField] = metadata.SyncRolloutFinished
+		doc["message"] = ""
+		return
+	}
+
+	moduleIDs := make([]int64, len(batch))
+	for i, idx := range batch {
+		moduleIDs[i] = rollout.Modules[idx].ModuleID
+	}
+
+	// sync the modules as the creator of the rollout, so that the audit logs record the actual operator
+	header := headerutil.GenCommonHeader(rollout.Creator, rollout.OwnerID, kit.Rid)
+	opt := &metadata.SyncServiceInstanceByTemplateOption{
+		BizID:             rollout.BizID,
+		ModuleIDs:         moduleIDs,
+		ServiceTemplateID: rollout.ServiceTemplateID,
+	}
+	result, err := lgc.CoreAPI.ProcServer().Service().SyncServiceInstanceByTemplate(kit.Ctx, header, opt)
+	if err != nil {
+		blog.Errorf("sync rollout %d modules %v failed, err: %v, rid: %s", rollout.ID, moduleIDs, err, kit.Rid)
+		lgc.failSyncRolloutBatch(rollout, batch, err.Error(), doc)
+		return
+	}
+
+	setSyncRolloutBatchTasks(rollout, batch, result, doc)
+}
+
+// setSyncRolloutBatchTasks set the modules of the batch as syncing with the sync tasks created for them, the rollout
+// is paused if no sync task is created
+func setSyncRolloutBatchTasks(rollout *metadata.ServiceTemplateSyncRollout, batch []int,
+	result *metadata.SyncServiceInstanceByTemplateResult, doc mapstr.MapStr) {
+
+	// the sync tasks created for this batch are recorded by their ids, so that the tasks created by others are
+	// never mistaken for the ones of this batch
+	taskMap := make(map[int64]string)
+	if result != nil {
+		for _, task := range result.Tasks {
+			taskMap[task.ModuleID] = task.TaskID
+		}
+	}
+
+	for _, idx := range batch {
+		module := &rollout.Modules[idx]
+		taskID, exists := taskMap[module.ModuleID]
+		if !exists {
+			module.Status = metadata.SyncRolloutModuleFailed
+			module.Message = "sync task is not created"
+			continue
+		}
+		module.Status = metadata.SyncRolloutModuleSyncing
+		module.TaskID = taskID
+		module.Message = ""
+	}
+
+	if !hasSyncingModule(rollout) {
+		doc[common.BKStatusField] = metadata.SyncRolloutPaused
+		doc["message"] = "sync tasks are not created, resume the rollout to continue"
+	}
+}
+
+// failSyncRolloutBatch set the modules of the batch as failed and pause the rollout
+func (lgc *Logics) failSyncRolloutBatch(rollout *metadata.ServiceTemplateSyncRollout, batch []int, msg string,
+	doc mapstr.MapStr) {
+
+	for _, idx := range batch {
+		rollout.Modules[idx].Status = metadata.SyncRolloutModuleFailed
+		rollout.Modules[idx].Message = msg
+	}
+	doc[common.BKStatusField] = metadata.SyncRolloutPaused
+	doc["message"] = fmt.Sprintf("create sync tasks failed, err: %s, resume the rollout to continue", msg)
+}
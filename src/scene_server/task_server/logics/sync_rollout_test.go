@@ -0,0 +1,121 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestSetSyncRolloutBatchTasks(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *metadata.SyncServiceInstanceByTemplateResult
+		wantStatus []metadata.SyncRolloutModuleStatus
+		wantTaskID []string
+		paused     bool
+	}{
+		{
+			name: "tasks created for all modules",
+			result: &metadata.SyncServiceInstanceByTemplateResult{Tasks: []metadata.ServiceTemplateSyncTask{
+				{ModuleID: 2, TaskID: "t2"}, {ModuleID: 1, TaskID: "t1"},
+			}},
+			wantStatus: []metadata.SyncRolloutModuleStatus{metadata.SyncRolloutModuleSyncing,
+				metadata.SyncRolloutModuleSyncing, metadata.SyncRolloutModuleWaiting},
+			wantTaskID: []string{"t1", "t2", ""},
+		},
+		{
+			// the task of the module out of the batch is not recorded
+			name: "task not created for a module",
+			result: &metadata.SyncServiceInstanceByTemplateResult{Tasks: []metadata.ServiceTemplateSyncTask{
+				{ModuleID: 1, TaskID: "t1"}, {ModuleID: 3, TaskID: "t3"},
+			}},
+			wantStatus: []metadata.SyncRolloutModuleStatus{metadata.SyncRolloutModuleSyncing,
+				metadata.SyncRolloutModuleFailed, metadata.SyncRolloutModuleWaiting},
+			wantTaskID: []string{"t1", "", ""},
+		},
+		{
+			name: "no task returned",
+			wantStatus: []metadata.SyncRolloutModuleStatus{metadata.SyncRolloutModuleFailed,
+				metadata.SyncRolloutModuleFailed, metadata.SyncRolloutModuleWaiting},
+			wantTaskID: []string{"", "", ""},
+			paused:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollout := &metadata.ServiceTemplateSyncRollout{Modules: []metadata.SyncRolloutModule{
+				{ModuleID: 1, Status: metadata.SyncRolloutModuleWaiting},
+				{ModuleID: 2, Status: metadata.SyncRolloutModuleWaiting},
+				{ModuleID: 3, Status: metadata.SyncRolloutModuleWaiting},
+			}}
+			doc := mapstr.New()
+
+			setSyncRolloutBatchTasks(rollout, []int{0, 1}, tt.result, doc)
+
+			status := make([]metadata.SyncRolloutModuleStatus, 0)
+			taskIDs := make([]string, 0)
+			for _, module := range rollout.Modules {
+				status = append(status, module.Status)
+				taskIDs = append(taskIDs, module.TaskID)
+			}
+			if !reflect.DeepEqual(status, tt.wantStatus) || !reflect.DeepEqual(taskIDs, tt.wantTaskID) {
+				t.Errorf("modules = %v, %v, want %v, %v", status, taskIDs, tt.wantStatus, tt.wantTaskID)
+			}
+			if paused := doc[common.BKStatusField] == metadata.SyncRolloutPaused; paused != tt.paused {
+				t.Errorf("rollout paused = %v, want %v", paused, tt.paused)
+			}
+		})
+	}
+}
+
+func TestSetSyncRolloutModuleResults(t *testing.T) {
+	rollout := &metadata.ServiceTemplateSyncRollout{Modules: []metadata.SyncRolloutModule{
+		{ModuleID: 1, Status: metadata.SyncRolloutModuleSyncing, TaskID: "t1"},
+		{ModuleID: 2, Status: metadata.SyncRolloutModuleSyncing, TaskID: "t2"},
+		{ModuleID: 3, Status: metadata.SyncRolloutModuleSyncing, TaskID: "t3"},
+		{ModuleID: 4, Status: metadata.SyncRolloutModuleSyncing, TaskID: "t4"},
+		{ModuleID: 5, Status: metadata.SyncRolloutModuleWaiting},
+	}}
+	failResp := &metadata.Response{BaseResp: metadata.BaseResp{ErrMsg: "process not found"}}
+	tasks := []metadata.APITaskDetail{
+		{TaskID: "t1", InstID: 1, Status: metadata.APITaskStatusSuccess},
+		{TaskID: "t2", InstID: 2, Status: metadata.APITAskStatusFail, Detail: []metadata.APISubTaskDetail{
+			{Status: metadata.APITaskStatusSuccess}, {Status: metadata.APITAskStatusFail, Response: failResp},
+		}},
+		{TaskID: "t3", InstID: 3, Status: metadata.APITaskStatusExecute},
+		// the task of the same module created by others is not the task of the rollout
+		{TaskID: "other", InstID: 4, Status: metadata.APITaskStatusSuccess},
+	}
+
+	done := setSyncRolloutModuleResults(rollout, tasks)
+
+	if !reflect.DeepEqual(done, []int{0, 1, 3}) {
+		t.Errorf("done modules = %v, want [0 1 3]", done)
+	}
+	want := []metadata.SyncRolloutModule{
+		{ModuleID: 1, Status: metadata.SyncRolloutModuleSuccess, TaskID: "t1"},
+		{ModuleID: 2, Status: metadata.SyncRolloutModuleFailed, TaskID: "t2", Message: "process not found"},
+		{ModuleID: 3, Status: metadata.SyncRolloutModuleSyncing, TaskID: "t3"},
+		{ModuleID: 4, Status: metadata.SyncRolloutModuleFailed, TaskID: "t4", Message: "sync task t4 is not found"},
+		{ModuleID: 5, Status: metadata.SyncRolloutModuleWaiting},
+	}
+	if !reflect.DeepEqual(rollout.Modules, want) {
+		t.Errorf("modules = %+v, want %+v", rollout.Modules, want)
+	}
+}
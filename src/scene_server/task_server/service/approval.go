@@ -0,0 +1,264 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateApprovalPolicy create approval policy
+func (s *Service) CreateApprovalPolicy(ctx *rest.Contexts) {
+	policy := new(metadata.ApprovalPolicy)
+	if err := ctx.DecodeInto(policy); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.CreateApprovalPolicy(ctx.Kit, policy)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// UpdateApprovalPolicy update approval policy
+func (s *Service) UpdateApprovalPolicy(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return
+	}
+
+	policy := new(metadata.ApprovalPolicy)
+	if err := ctx.DecodeInto(policy); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.UpdateApprovalPolicy(ctx.Kit, id, policy); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteApprovalPolicy delete approval policy
+func (s *Service) DeleteApprovalPolicy(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return
+	}
+
+	if err := s.Logics.DeleteApprovalPolicy(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchApprovalPolicy search approval policies
+func (s *Service) SearchApprovalPolicy(ctx *rest.Contexts) {
+	opt := new(metadata.SearchApprovalPolicyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.SearchApprovalPolicy(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// SearchChangeRequest search change requests
+func (s *Service) SearchChangeRequest(ctx *rest.Contexts) {
+	opt := new(metadata.SearchChangeRequestOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.SearchChangeRequest(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// FindChangeRequest find change request detail by id
+func (s *Service) FindChangeRequest(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return
+	}
+
+	request, err := s.Logics.GetChangeRequest(ctx.Kit, id)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(request)
+}
+
+// ApproveChangeRequest approve change request
+func (s *Service) ApproveChangeRequest(ctx *rest.Contexts) {
+	s.decideChangeRequest(ctx, s.Logics.ApproveChangeRequest)
+}
+
+// RejectChangeRequest reject change request
+func (s *Service) RejectChangeRequest(ctx *rest.Contexts) {
+	s.decideChangeRequest(ctx, s.Logics.RejectChangeRequest)
+}
+
+// CancelChangeRequest cancel change request
+func (s *Service) CancelChangeRequest(ctx *rest.Contexts) {
+	s.decideChangeRequest(ctx, s.Logics.CancelChangeRequest)
+}
+
+func (s *Service) decideChangeRequest(ctx *rest.Contexts,
+	decide func(*rest.Kit, int64, *metadata.ChangeRequestDecisionOption) error) {
+
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return
+	}
+
+	opt := new(metadata.ChangeRequestDecisionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := decide(ctx.Kit, id, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// CaptureChangeRequest capture the api server request as a change request if it needs to be approved
+func (s *Service) CaptureChangeRequest(ctx *rest.Contexts) {
+	opt := new(metadata.CaptureChangeRequestOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.CaptureChangeRequest(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// VerifyChangeRequest verify the api server request is the execution of an approved change request
+func (s *Service) VerifyChangeRequest(ctx *rest.Contexts) {
+	opt := new(metadata.VerifyChangeRequestOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.VerifyChangeRequest(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// CheckApproval check if the sensitive operation can be executed by the scene server
+func (s *Service) CheckApproval(ctx *rest.Contexts) {
+	opt := new(metadata.ApprovalCheckOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.CheckApproval(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ExecuteChangeRequestTask execute the approved change request, called by the task queue
+func (s *Service) ExecuteChangeRequestTask(ctx *rest.Contexts) {
+	opt := new(metadata.ExecuteChangeRequestOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.ExecuteChangeRequest(ctx.Kit, opt.ID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// TimerExpireChangeRequest expire the pending change requests that are not approved in time
+func (s *Service) TimerExpireChangeRequest(ctx context.Context) {
+	for {
+		time.Sleep(time.Minute)
+
+		isMaster := s.Engine.ServiceManageInterface.IsMaster()
+		if !isMaster {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		requests, err := s.Logics.ListExpiredChangeRequests(ctx, 100, rid)
+		if err != nil {
+			continue
+		}
+
+		for idx := range requests {
+			request := &requests[idx]
+			header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, request.OwnerID, rid)
+			kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+			if err := s.Logics.ExpireChangeRequest(kit, request); err != nil {
+				blog.Errorf("expire change request %d failed, err: %v, rid: %s", request.ID, err, rid)
+				continue
+			}
+			blog.Infof("change request %d is expired, rid: %s", request.ID, rid)
+		}
+	}
+}
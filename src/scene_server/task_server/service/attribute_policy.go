@@ -0,0 +1,308 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"configcenter/src/apimachinery/cacheservice/cache/event"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/common/watch/watcher"
+	"configcenter/src/storage/dal/redis"
+)
+
+// attributePolicyCursorKeyPrefix is the redis key prefix of the watch cursors of the auto apply attribute policies
+const attributePolicyCursorKeyPrefix = common.BKCacheKeyV3Prefix + "attribute_policy:cursor:"
+
+// attributePolicyEventDetail is the watched event detail of the instance and the instance association
+type attributePolicyEventDetail struct {
+	ObjID      string `json:"bk_obj_id"`
+	InstID     int64  `json:"bk_inst_id"`
+	AsstObjID  string `json:"bk_asst_obj_id"`
+	AsstInstID int64  `json:"bk_asst_inst_id"`
+}
+
+// CreateAttributePolicy create attribute policy
+func (s *Service) CreateAttributePolicy(ctx *rest.Contexts) {
+	policy := new(metadata.AttributePolicy)
+	if err := ctx.DecodeInto(policy); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.CreateAttributePolicy(ctx.Kit, policy)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// UpdateAttributePolicy update attribute policy
+func (s *Service) UpdateAttributePolicy(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return
+	}
+
+	policy := new(metadata.AttributePolicy)
+	if err := ctx.DecodeInto(policy); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.UpdateAttributePolicy(ctx.Kit, id, policy); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteAttributePolicy delete attribute policy
+func (s *Service) DeleteAttributePolicy(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return
+	}
+
+	if err := s.Logics.DeleteAttributePolicy(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchAttributePolicy search attribute policies
+func (s *Service) SearchAttributePolicy(ctx *rest.Contexts) {
+	opt := new(metadata.SearchAttributePolicyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.SearchAttributePolicy(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// PreviewAttributePolicy preview the instance attribute changes of the attribute policies
+func (s *Service) PreviewAttributePolicy(ctx *rest.Contexts) {
+	opt := new(metadata.AttributePolicyApplyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.PreviewAttributePolicy(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ApplyAttributePolicy create the task that applies the attribute policies to the instances
+func (s *Service) ApplyAttributePolicy(ctx *rest.Contexts) {
+	opt := new(metadata.AttributePolicyApplyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.CreateApplyAttributePolicyTask(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ApplyAttributePolicyTask apply the attribute policies to the instances, called by the task queue
+func (s *Service) ApplyAttributePolicyTask(ctx *rest.Contexts) {
+	opt := new(metadata.AttributePolicyApplyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.ApplyAttributePolicy(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// TimerAutoApplyAttributePolicy watches the created instances and the instances whose business or associations are
+// changed of the models that have auto apply attribute policies, and applies the policies to them
+func (s *Service) TimerAutoApplyAttributePolicy(ctx context.Context) {
+	for {
+		time.Sleep(10 * time.Second)
+
+		isMaster := s.Engine.ServiceManageInterface.IsMaster()
+		if !isMaster {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID, rid)
+		kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+		objMap, err := s.Logics.ListAutoApplyAttributePolicyObjects(kit)
+		if err != nil {
+			continue
+		}
+
+		for ownerID, objIDs := range objMap {
+			header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, ownerID, rid)
+			kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+			for _, objID := range objIDs {
+				s.autoApplyAttributePolicy(kit, objID, watch.ObjectBase)
+				s.autoApplyAttributePolicy(kit, objID, watch.InstAsst)
+			}
+		}
+	}
+}
+
+// autoApplyAttributePolicy watch the resource events of the model from the stored cursor and apply the auto apply
+// policies to the related instances, the cursor is only saved after the policies are applied, so that the events
+// are watched again if the apply failed
+func (s *Service) autoApplyAttributePolicy(kit *rest.Kit, objID string, resource watch.CursorType) {
+	key := attributePolicyCursorKeyPrefix + kit.SupplierAccount + ":" + objID + ":" + string(resource)
+	cursor, err := s.CacheDB.Get(kit.Ctx, key).Result()
+	if err != nil && !redis.IsNilErr(err) {
+		blog.Errorf("get attribute policy %s cursor of %s failed, err: %v, rid: %s", resource, objID, err, kit.Rid)
+		return
+	}
+
+	eventCli := s.Engine.CoreAPI.CacheService().Cache().Event()
+	for {
+		instIDs, nextCursor, watched, err := watchAttributePolicyInsts(kit, eventCli, objID, resource, cursor)
+		if err != nil {
+			return
+		}
+
+		if len(instIDs) > 0 {
+			if err := s.Logics.AutoApplyAttributePolicy(kit, objID, instIDs); err != nil {
+				blog.Errorf("auto apply %s attribute policy to %v failed, err: %v, rid: %s", objID, instIDs, err,
+					kit.Rid)
+				return
+			}
+		}
+
+		if nextCursor != cursor {
+			if err := s.CacheDB.Set(kit.Ctx, key, nextCursor, 0).Err(); err != nil {
+				blog.Errorf("save attribute policy %s cursor of %s failed, err: %v, rid: %s", resource, objID, err,
+					kit.Rid)
+				return
+			}
+			cursor = nextCursor
+		}
+
+		if !watched {
+			return
+		}
+	}
+}
+
+// watchAttributePolicyInsts watch the events of the resource, returns the ids of the related instances of the model,
+// the next cursor and if any event is watched
+func watchAttributePolicyInsts(kit *rest.Kit, cli event.Interface, objID string, resource watch.CursorType,
+	cursor string) ([]int64, string, bool, error) {
+
+	opts := &watch.WatchEventOptions{
+		EventTypes: []watch.EventType{watch.Create},
+		Cursor:     cursor,
+		Resource:   resource,
+		Filter:     watch.WatchEventFilter{SubResource: objID},
+	}
+
+	switch resource {
+	case watch.ObjectBase:
+		// the instance is created or moved to another business
+		opts.EventTypes = append(opts.EventTypes, watch.Update)
+		opts.Fields = []string{common.BKInstIDField}
+		opts.Filter.ChangedFields = []string{common.BKAppIDField}
+	case watch.InstAsst:
+		opts.Fields = []string{common.BKObjIDField, common.BKInstIDField, common.BKAsstObjIDField,
+			common.BKAsstInstIDField}
+	}
+
+	if len(cursor) == 0 {
+		opts.StartFrom = time.Now().Unix()
+	}
+
+	result, err := watcher.WatchWithCursor(kit.Ctx, cli, kit.Header, opts)
+	if err != nil {
+		blog.Errorf("watch attribute policy %s events of %s failed, err: %v, rid: %s", resource, objID, err, kit.Rid)
+		return nil, "", false, err
+	}
+
+	if result.Gap != nil {
+		blog.Errorf("attribute policy %s cursor %s of %s is lost, the policies are not applied to the instances "+
+			"changed from %d to %d, rid: %s", resource, cursor, objID, result.Gap.From, result.Gap.To, kit.Rid)
+	}
+
+	if len(result.Events) == 0 {
+		return nil, result.Cursor, false, nil
+	}
+
+	return attributePolicyInstIDs(objID, resource, result.Events, kit.Rid), result.Cursor, true, nil
+}
+
+// attributePolicyInstIDs returns the ids of the instances of the model that the events are related to, which are the
+// created or updated instances, or the instances on either side of the created associations.
+func attributePolicyInstIDs(objID string, resource watch.CursorType, events []*watch.WatchEventDetail,
+	rid string) []int64 {
+
+	instIDs := make([]int64, 0)
+	for _, event := range events {
+		detail, ok := event.Detail.(watch.JsonString)
+		if !ok {
+			continue
+		}
+
+		eventDetail := new(attributePolicyEventDetail)
+		if err := json.Unmarshal([]byte(detail), eventDetail); err != nil {
+			blog.Errorf("unmarshal %s event detail failed, err: %v, detail: %s, rid: %s", resource, err, detail, rid)
+			continue
+		}
+
+		switch {
+		case resource == watch.ObjectBase, eventDetail.ObjID == objID:
+			instIDs = append(instIDs, eventDetail.InstID)
+		case eventDetail.AsstObjID == objID:
+			instIDs = append(instIDs, eventDetail.AsstInstID)
+		}
+	}
+
+	return util.IntArrayUnique(instIDs)
+}
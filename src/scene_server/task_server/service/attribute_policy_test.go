@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/stream/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeEventClient responds the watch requests in order and records the options of the requests
+type fakeEventClient struct {
+	responses []*watch.WatchResp
+	errs      []errors.CCErrorCoder
+	requests  []watch.WatchEventOptions
+}
+
+func (f *fakeEventClient) WatchEvent(_ context.Context, _ http.Header, opts *watch.WatchEventOptions) (*string,
+	errors.CCErrorCoder) {
+
+	f.requests = append(f.requests, *opts)
+	resp, err := f.responses[0], f.errs[0]
+	f.responses, f.errs = f.responses[1:], f.errs[1:]
+	if err != nil {
+		return nil, err
+	}
+
+	js, _ := json.Marshal(resp)
+	result := string(js)
+	return &result, nil
+}
+
+func (f *fakeEventClient) InnerWatchEvent(_ context.Context, _ http.Header, _ *watch.WatchEventOptions) (
+	*watch.WatchResp, errors.CCErrorCoder) {
+	return nil, errors.New(common.CCErrCommHTTPDoRequestFailed, "not implemented")
+}
+
+func TestAttributePolicyInstIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource watch.CursorType
+		details  []string
+		want     []int64
+	}{
+		{
+			name:     "created or updated instances",
+			resource: watch.ObjectBase,
+			details:  []string{`{"bk_inst_id":1}`, `{"bk_inst_id":2}`, `{"bk_inst_id":1}`},
+			want:     []int64{1, 2},
+		},
+		{
+			name:     "instances on either side of the associations",
+			resource: watch.InstAsst,
+			details: []string{
+				`{"bk_obj_id":"switch","bk_inst_id":1,"bk_asst_obj_id":"host","bk_asst_inst_id":10}`,
+				`{"bk_obj_id":"host","bk_inst_id":11,"bk_asst_obj_id":"switch","bk_asst_inst_id":2}`,
+				`{"bk_obj_id":"switch","bk_inst_id":3,"bk_asst_obj_id":"switch","bk_asst_inst_id":4}`,
+			},
+			want: []int64{1, 2, 3},
+		},
+		{
+			name:     "invalid details are skipped",
+			resource: watch.ObjectBase,
+			details:  []string{`invalid`, `{"bk_inst_id":5}`},
+			want:     []int64{5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make([]*watch.WatchEventDetail, 0)
+			for _, detail := range tt.details {
+				events = append(events, &watch.WatchEventDetail{Detail: watch.JsonString(detail)})
+			}
+			// event without detail is skipped
+			events = append(events, &watch.WatchEventDetail{})
+
+			got := attributePolicyInstIDs("switch", tt.resource, events, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("attributePolicyInstIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchAttributePolicyInstsLostCursor(t *testing.T) {
+	lostTime := time.Now().Add(-time.Hour).Unix()
+	lost := watch.Cursor{
+		Type:        watch.ObjectBase,
+		ClusterTime: types.TimeStamp{Sec: uint32(lostTime)},
+		Oid:         primitive.NewObjectID().Hex(),
+		Oper:        types.Insert,
+	}
+	lostCursor, err := lost.Encode()
+	if err != nil {
+		t.Fatalf("encode cursor failed, err: %v", err)
+	}
+
+	cli := &fakeEventClient{
+		responses: []*watch.WatchResp{nil, {
+			Watched: true,
+			Events:  []*watch.WatchEventDetail{{Cursor: "next", Detail: watch.JsonString(`{"bk_inst_id":1}`)}},
+		}},
+		errs: []errors.CCErrorCoder{errors.New(common.CCErrEventChainNodeNotExist, "node not exist"), nil},
+	}
+	kit := &rest.Kit{Ctx: context.Background(), Header: http.Header{}}
+
+	instIDs, cursor, watched, err := watchAttributePolicyInsts(kit, cli, "switch", watch.ObjectBase, lostCursor)
+	if err != nil {
+		t.Fatalf("watchAttributePolicyInsts() failed, err: %v", err)
+	}
+
+	// the lost cursor is resumed from its time, instead of being reset to watch from now
+	if len(cli.requests) != 2 || cli.requests[1].Cursor != "" || cli.requests[1].StartFrom != lostTime {
+		t.Fatalf("watch requests = %+v, want resuming from the lost cursor time %d", cli.requests, lostTime)
+	}
+	if !watched || cursor != "next" || !reflect.DeepEqual(instIDs, []int64{1}) {
+		t.Errorf("watchAttributePolicyInsts() = %v, %s, %v, want [1], next, true", instIDs, cursor, watched)
+	}
+
+	// the expired cursor is watched from now on and the cursor is not reset to empty
+	cli = &fakeEventClient{
+		responses: []*watch.WatchResp{nil, nil, {Events: []*watch.WatchEventDetail{{Cursor: "latest"}}}},
+		errs: []errors.CCErrorCoder{errors.New(common.CCErrEventChainNodeNotExist, "node not exist"),
+			errors.New(common.CCErrCommParamsInvalid, "bk_start_from"), nil},
+	}
+	before := time.Now().Unix()
+	instIDs, cursor, watched, err = watchAttributePolicyInsts(kit, cli, "switch", watch.ObjectBase, lostCursor)
+	if err != nil {
+		t.Fatalf("watchAttributePolicyInsts() failed, err: %v", err)
+	}
+	if len(cli.requests) != 3 || cli.requests[2].StartFrom < before {
+		t.Fatalf("watch requests = %+v, want watching from now at last", cli.requests)
+	}
+	if watched || cursor != "latest" || len(instIDs) != 0 {
+		t.Errorf("watchAttributePolicyInsts() = %v, %s, %v, want no instance with the latest cursor", instIDs,
+			cursor, watched)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/util"
+)
+
+// TimerCheckHostLease reminds the notifiers of the host leases that are about to expire, and creates the tasks that
+// return the hosts of the expired host leases
+func (s *Service) TimerCheckHostLease(ctx context.Context) {
+	for {
+		time.Sleep(time.Minute)
+
+		isMaster := s.Engine.ServiceManageInterface.IsMaster()
+		if !isMaster {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		s.remindHostLeases(ctx, rid)
+		s.returnExpiredHostLeases(ctx, rid)
+	}
+}
+
+func (s *Service) remindHostLeases(ctx context.Context, rid string) {
+	leases, err := s.Logics.ListHostLeasesToRemind(ctx, 100, rid)
+	if err != nil {
+		return
+	}
+
+	for idx := range leases {
+		lease := &leases[idx]
+		header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, lease.OwnerID, rid)
+		kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+		if err := s.Logics.RemindHostLease(kit, lease); err != nil {
+			blog.Errorf("remind host lease %d failed, err: %v, rid: %s", lease.ID, err, rid)
+			continue
+		}
+		blog.Infof("host lease %d is reminded, rid: %s", lease.ID, rid)
+	}
+}
+
+func (s *Service) returnExpiredHostLeases(ctx context.Context, rid string) {
+	leases, err := s.Logics.ListExpiredHostLeases(ctx, 100, rid)
+	if err != nil {
+		return
+	}
+
+	for idx := range leases {
+		lease := &leases[idx]
+		header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, lease.OwnerID, rid)
+		kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+		if err := s.Logics.CreateReturnHostLeaseTask(kit, lease); err != nil {
+			continue
+		}
+	}
+}
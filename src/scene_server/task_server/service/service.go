@@ -95,6 +95,74 @@ func (s *Service) addAPIService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/find/field_template/task_sync_result",
 		Handler: s.ListFieldTmplTaskSyncResult})
 
+	// change approval
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/approval_policy",
+		Handler: s.CreateApprovalPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/approval_policy/{id}",
+		Handler: s.UpdateApprovalPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/approval_policy/{id}",
+		Handler: s.DeleteApprovalPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/approval_policy",
+		Handler: s.SearchApprovalPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/change_request",
+		Handler: s.SearchChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/change_request/{id}",
+		Handler: s.FindChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/change_request/{id}/approve",
+		Handler: s.ApproveChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/change_request/{id}/reject",
+		Handler: s.RejectChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/change_request/{id}/cancel",
+		Handler: s.CancelChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/capture/change_request",
+		Handler: s.CaptureChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/verify/change_request",
+		Handler: s.VerifyChangeRequest})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/check/approval",
+		Handler: s.CheckApproval})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/execute/change_request/task",
+		Handler: s.ExecuteChangeRequestTask})
+
+	// attribute policy
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/attribute_policy",
+		Handler: s.CreateAttributePolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/attribute_policy/{id}",
+		Handler: s.UpdateAttributePolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/attribute_policy/{id}",
+		Handler: s.DeleteAttributePolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/attribute_policy",
+		Handler: s.SearchAttributePolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/preview/attribute_policy",
+		Handler: s.PreviewAttributePolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/apply/attribute_policy",
+		Handler: s.ApplyAttributePolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/apply/attribute_policy/task",
+		Handler: s.ApplyAttributePolicyTask})
+
+	// service template sync rollout
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/create/service_template_sync_rollout/bk_biz_id/{bk_biz_id}", Handler: s.CreateSyncRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/findmany/service_template_sync_rollout/bk_biz_id/{bk_biz_id}", Handler: s.SearchSyncRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path:    "/update/service_template_sync_rollout/{id}/resume/bk_biz_id/{bk_biz_id}",
+		Handler: s.ResumeSyncRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path:    "/update/service_template_sync_rollout/{id}/abort/bk_biz_id/{bk_biz_id}",
+		Handler: s.AbortSyncRollout})
+
+	// set template sync policy
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/create/set_template_sync_policy/bk_biz_id/{bk_biz_id}", Handler: s.CreateSetTemplateSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path: "/update/set_template_sync_policy/{id}/bk_biz_id/{bk_biz_id}", Handler: s.UpdateSetTemplateSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete,
+		Path: "/delete/set_template_sync_policy/{id}/bk_biz_id/{bk_biz_id}", Handler: s.DeleteSetTemplateSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/findmany/set_template_sync_policy/bk_biz_id/{bk_biz_id}", Handler: s.SearchSetTemplateSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/find/set_template_sync_summary/bk_biz_id/{bk_biz_id}", Handler: s.GetSetTemplateSyncSummary})
+
 	utility.AddToRestfulWebService(web)
 
 }
@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateSetTemplateSyncPolicy create set template sync policy
+func (s *Service) CreateSetTemplateSyncPolicy(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.SetTemplateSyncPolicyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.CreateSetTemplateSyncPolicy(ctx.Kit, bizID, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// UpdateSetTemplateSyncPolicy update set template sync policy
+func (s *Service) UpdateSetTemplateSyncPolicy(ctx *rest.Contexts) {
+	bizID, id, err := s.parseBizIDAndIDPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := new(metadata.SetTemplateSyncPolicyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.UpdateSetTemplateSyncPolicy(ctx.Kit, bizID, id, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteSetTemplateSyncPolicy delete set template sync policy
+func (s *Service) DeleteSetTemplateSyncPolicy(ctx *rest.Contexts) {
+	bizID, id, err := s.parseBizIDAndIDPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.DeleteSetTemplateSyncPolicy(ctx.Kit, bizID, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchSetTemplateSyncPolicy search set template sync policies
+func (s *Service) SearchSetTemplateSyncPolicy(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.SearchSetTemplateSyncPolicyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.BizID = bizID
+
+	result, err := s.Logics.SearchSetTemplateSyncPolicy(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// GetSetTemplateSyncSummary get the summary report of the sets that are out of sync in the business
+func (s *Service) GetSetTemplateSyncSummary(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField))
+		return
+	}
+
+	result, err := s.Logics.GetSetTemplateSyncSummary(ctx.Kit, bizID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// TimerCheckSetTemplateSyncPolicy checks the drift of the sets of the set templates with sync policies, notifies
+// the drift and synchronizes the drifted sets by the policies
+func (s *Service) TimerCheckSetTemplateSyncPolicy(ctx context.Context) {
+	for {
+		time.Sleep(time.Minute)
+
+		isMaster := s.Engine.ServiceManageInterface.IsMaster()
+		if !isMaster {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		policies, err := s.Logics.ListSetTemplateSyncPoliciesToCheck(ctx, 100, rid)
+		if err != nil {
+			continue
+		}
+
+		for idx := range policies {
+			policy := &policies[idx]
+			header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, policy.OwnerID, rid)
+			kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+			if err := s.Logics.CheckSetTemplateSyncPolicy(kit, policy); err != nil {
+				blog.Errorf("check set template sync policy %d failed, err: %v, rid: %s", policy.ID, err, rid)
+				continue
+			}
+		}
+	}
+}
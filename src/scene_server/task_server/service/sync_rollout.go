@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateSyncRollout create service template sync rollout
+func (s *Service) CreateSyncRollout(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.CreateSyncRolloutOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.CreateSyncRollout(ctx.Kit, bizID, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// SearchSyncRollout search service template sync rollouts with the results of the modules
+func (s *Service) SearchSyncRollout(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField))
+		return
+	}
+
+	opt := new(metadata.SearchSyncRolloutOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.BizID = bizID
+
+	result, err := s.Logics.SearchSyncRollout(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ResumeSyncRollout confirm the paused service template sync rollout to continue
+func (s *Service) ResumeSyncRollout(ctx *rest.Contexts) {
+	bizID, id, err := s.parseBizIDAndIDPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.ResumeSyncRollout(ctx.Kit, bizID, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// AbortSyncRollout abort the service template sync rollout
+func (s *Service) AbortSyncRollout(ctx *rest.Contexts) {
+	bizID, id, err := s.parseBizIDAndIDPath(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.Logics.AbortSyncRollout(ctx.Kit, bizID, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// parseBizIDAndIDPath parse the business id and the record id from the request path
+func (s *Service) parseBizIDAndIDPath(ctx *rest.Contexts) (int64, int64, error) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		return 0, 0, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+	}
+
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		return 0, 0, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+
+	return bizID, id, nil
+}
+
+// TimerAdvanceSyncRollout refreshes the module results of the service template sync rollouts, pauses them after
+// the canary batch and starts the next batches
+func (s *Service) TimerAdvanceSyncRollout(ctx context.Context) {
+	for {
+		time.Sleep(10 * time.Second)
+
+		isMaster := s.Engine.ServiceManageInterface.IsMaster()
+		if !isMaster {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		rollouts, err := s.Logics.ListSyncRolloutsToAdvance(ctx, 100, rid)
+		if err != nil {
+			continue
+		}
+
+		for idx := range rollouts {
+			rollout := &rollouts[idx]
+			header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, rollout.OwnerID, rid)
+			kit := rest.NewKitFromHeader(header, s.Engine.CCErr)
+			if err := s.Logics.AdvanceSyncRollout(kit, rollout); err != nil {
+				blog.Errorf("advance sync rollout %d failed, err: %v, rid: %s", rollout.ID, err, rid)
+				continue
+			}
+		}
+	}
+}
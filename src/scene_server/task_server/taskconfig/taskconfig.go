@@ -50,6 +50,11 @@ func init() {
 		"/topo/v3/sync/field_template/object/task", 1, 2)
 	AddCodeTaskConfig(common.SyncInstIDRuleTaskFlag, types.CC_MODULE_TOPO,
 		"/topo/v3/sync/id_rule/inst/task", 1, 2)
+	AddCodeTaskConfig(common.ExecuteChangeRequestTaskFlag, types.CC_MODULE_TASK,
+		"/task/v3/execute/change_request/task", 1, 2)
+	AddCodeTaskConfig(common.ApplyAttributePolicyTaskFlag, types.CC_MODULE_TASK,
+		"/task/v3/apply/attribute_policy/task", 1, 2)
+	AddCodeTaskConfig(common.ReturnHostLeaseTaskFlag, types.CC_MODULE_HOST, "/host/v3/return/host_lease/task", 1, 2)
 }
 
 // AddCodeTaskConfig add task
@@ -34,6 +34,8 @@ type AssociationOperationInterface interface {
 		*metadata.CreateManyInstAsstResultDetail, error)
 	// DeleteInstAssociation delete association between instances
 	DeleteInstAssociation(kit *rest.Kit, objID string, asstIDList []int64) (uint64, error)
+	// UpdateInstAssociationAttrs update the attribute values of the association between instances
+	UpdateInstAssociationAttrs(kit *rest.Kit, objID string, id int64, attrs mapstr.MapStr) error
 	// CheckAssociations returns error if the instances has associations with exist instances, clear dirty associations
 	CheckAssociations(*rest.Kit, string, []int64) error
 
@@ -282,6 +284,12 @@ func (assoc *association) CreateInstanceAssociation(kit *rest.Kit, request *meta
 		return nil, err
 	}
 
+	if rawErr := result.Info[0].ValidateAttrValues(kit.Ctx, request.Attributes); rawErr.ErrCode != 0 {
+		blog.Errorf("association attributes %+v are invalid, err: %v, rid: %s", request.Attributes,
+			rawErr.ToCCError(kit.CCError), kit.Rid)
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
 	input := metadata.CreateOneInstanceAssociation{
 		Data: metadata.InstAsst{
 			ObjectAsstID:      request.ObjectAsstID,
@@ -290,6 +298,7 @@ func (assoc *association) CreateInstanceAssociation(kit *rest.Kit, request *meta
 			ObjectID:          result.Info[0].ObjectID,
 			AsstObjectID:      result.Info[0].AsstObjID,
 			AssociationKindID: result.Info[0].AsstKindID,
+			Attributes:        request.Attributes,
 		},
 	}
 	createResult, err := assoc.clientSet.CoreService().Association().CreateInstAssociation(kit.Ctx, kit.Header, &input)
@@ -355,6 +364,12 @@ func (assoc *association) CreateManyInstAssociation(kit *rest.Kit, request *meta
 
 	param := &metadata.CreateManyInstanceAssociation{}
 	for _, item := range request.Details {
+		if rawErr := result.Info[0].ValidateAttrValues(kit.Ctx, item.Attributes); rawErr.ErrCode != 0 {
+			blog.Errorf("association attributes %+v are invalid, err: %v, rid: %s", item.Attributes,
+				rawErr.ToCCError(kit.CCError), kit.Rid)
+			return nil, rawErr.ToCCError(kit.CCError)
+		}
+
 		param.Datas = append(param.Datas, metadata.InstAsst{
 			InstID:            item.InstID,
 			ObjectID:          request.ObjectID,
@@ -362,6 +377,7 @@ func (assoc *association) CreateManyInstAssociation(kit *rest.Kit, request *meta
 			AsstObjectID:      request.AsstObjectID,
 			ObjectAsstID:      request.ObjectAsstID,
 			AssociationKindID: result.Info[0].AsstKindID,
+			Attributes:        item.Attributes,
 		})
 	}
 
@@ -467,6 +483,74 @@ func (assoc *association) DeleteInstAssociation(kit *rest.Kit, objID string, ass
 	return rsp.Count, nil
 }
 
+// UpdateInstAssociationAttrs update the attribute values of the association between instances, the updated values
+// are merged into the existing ones and validated by the attributes defined in the object association.
+func (assoc *association) UpdateInstAssociationAttrs(kit *rest.Kit, objID string, id int64,
+	attrs mapstr.MapStr) error {
+
+	searchCond := &metadata.InstAsstQueryCondition{
+		Cond:  metadata.QueryCondition{Condition: mapstr.MapStr{common.BKFieldID: id}, DisableCounter: true},
+		ObjID: objID,
+	}
+	data, err := assoc.clientSet.CoreService().Association().ReadInstAssociation(kit.Ctx, kit.Header, searchCond)
+	if err != nil {
+		blog.Errorf("get instance association %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	if len(data.Info) != 1 {
+		blog.Errorf("get %d instance associations by id %d, rid: %s", len(data.Info), id, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+	instAsst := data.Info[0]
+
+	cond := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{common.AssociationObjAsstIDField: instAsst.ObjectAsstID},
+		DisableCounter: true,
+	}
+	result, err := assoc.clientSet.CoreService().Association().ReadModelAssociation(kit.Ctx, kit.Header, cond)
+	if err != nil {
+		blog.Errorf("search object association with cond[%#v] failed, err: %v, rid: %s", cond, err, kit.Rid)
+		return err
+	}
+
+	if len(result.Info) == 0 {
+		blog.Errorf("can not find object association[%s], rid: %s", instAsst.ObjectAsstID, kit.Rid)
+		return kit.CCError.Error(common.CCErrorTopoObjectAssociationNotExist)
+	}
+
+	merged := metadata.MergeInstAsstAttrs(instAsst.Attributes, attrs)
+	if rawErr := result.Info[0].ValidateAttrValues(kit.Ctx, merged); rawErr.ErrCode != 0 {
+		blog.Errorf("association attributes %+v are invalid, err: %v, rid: %s", merged,
+			rawErr.ToCCError(kit.CCError), kit.Rid)
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	// generate audit log before update to record the previous attribute values
+	audit := auditlog.NewInstanceAssociationAudit(assoc.clientSet.CoreService())
+	generateAuditParameter := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).
+		WithUpdateFields(merged)
+	auditLog, err := audit.GenerateAuditLog(generateAuditParameter, id, objID, &instAsst)
+	if err != nil {
+		blog.Errorf("generate instance association %d audit log failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	input := &metadata.UpdateInstAsstAttrsOption{ObjectID: objID, ID: id, Attributes: merged}
+	if err = assoc.clientSet.CoreService().Association().UpdateInstAssociationAttrs(kit.Ctx, kit.Header,
+		input); err != nil {
+		blog.Errorf("update instance association %d attributes failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	if err = audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save instance association %d audit log failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+	}
+
+	return nil
+}
+
 // CheckAssociations returns error if the instances has associations with exist instances, clear dirty associations
 func (assoc *association) CheckAssociations(kit *rest.Kit, objectID string, instIDs []int64) error {
 	if len(instIDs) == 0 {
@@ -30,6 +30,16 @@ func (b *business) DeleteBusiness(kit *rest.Kit, bizIDs []int64) error {
 		return err
 	}
 
+	// check the approval of the deletion, it can only be executed by its approved change request if it needs approval
+	approvalOpt := &metadata.ApprovalCheckOption{
+		Operation: metadata.ApprovalOperationDeleteBiz,
+		DeleteBiz: &metadata.DeleteBizParam{BizID: bizIDs},
+	}
+	if err := b.clientSet.TaskServer().Approval().CheckApproval(kit.Ctx, kit.Header, approvalOpt); err != nil {
+		blog.Errorf("check delete biz approval failed, err: %v, biz ids: %v, rid: %s", err, bizIDs, kit.Rid)
+		return err
+	}
+
 	// clean business and related resources
 	for _, bizID := range bizIDs {
 		if err := b.cleanBizAndRelatedResources(kit, bizID); err != nil {
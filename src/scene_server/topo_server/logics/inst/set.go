@@ -324,6 +324,16 @@ func (s *set) UpdateSet(kit *rest.Kit, data mapstr.MapStr, bizID, setID int64) e
 		return err
 	}
 
+	// check the approval of the update, it can only be executed by its approved change request if it needs approval
+	approvalOpt := &metadata.ApprovalCheckOption{
+		Operation: metadata.ApprovalOperationUpdateSet,
+		UpdateSet: &metadata.ApprovalUpdateSetOption{BizID: bizID, SetID: setID, Data: data},
+	}
+	if err := s.clientSet.TaskServer().Approval().CheckApproval(kit.Ctx, kit.Header, approvalOpt); err != nil {
+		blog.Errorf("check update set %d approval failed, err: %v, rid: %s", setID, err, kit.Rid)
+		return err
+	}
+
 	data.Remove(common.MetadataField)
 	data.Remove(common.BKAppIDField)
 	data.Remove(common.BKSetIDField)
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/scene_server/topo_server/logics/kube"
 	"configcenter/src/scene_server/topo_server/logics/model"
 	modelquote "configcenter/src/scene_server/topo_server/logics/model_quote"
+	modelschema "configcenter/src/scene_server/topo_server/logics/model_schema"
 	"configcenter/src/scene_server/topo_server/logics/operation"
 	"configcenter/src/scene_server/topo_server/logics/settemplate"
 )
@@ -47,6 +48,7 @@ type Logics interface {
 	ProjectOperation() inst.ProjectOperationInterface
 	ModelQuoteOperation() modelquote.ModelQuoteOperation
 	FieldTemplateOperation() fieldtemplate.FieldTemplateOperation
+	ModelSchemaOperation() modelschema.ModelSchemaOperation
 }
 
 type logics struct {
@@ -69,6 +71,7 @@ type logics struct {
 	project           inst.ProjectOperationInterface
 	modelQuote        modelquote.ModelQuoteOperation
 	fieldTemplate     fieldtemplate.FieldTemplateOperation
+	modelSchema       modelschema.ModelSchemaOperation
 }
 
 // New create a logics manager
@@ -123,6 +126,8 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 		project:           projectOperation,
 		modelQuote:        modelquote.NewModelQuoteOperation(client),
 		fieldTemplate:     fieldtemplate.NewFieldTemplateOperation(client, associationOperation),
+		modelSchema: modelschema.NewModelSchemaOperation(client, classificationOperation, objectOperation,
+			attributeOperation, groupOperation, associationOperation),
 	}
 }
 
@@ -220,3 +225,8 @@ func (l *logics) ModelQuoteOperation() modelquote.ModelQuoteOperation {
 func (l *logics) FieldTemplateOperation() fieldtemplate.FieldTemplateOperation {
 	return l.fieldTemplate
 }
+
+// ModelSchemaOperation return an instance providing model schema draft and version operations
+func (l *logics) ModelSchemaOperation() modelschema.ModelSchemaOperation {
+	return l.modelSchema
+}
@@ -13,6 +13,7 @@
 package model
 
 import (
+	"encoding/json"
 	"strconv"
 
 	"configcenter/src/ac/extensions"
@@ -25,6 +26,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	attrvalid "configcenter/src/common/valid/attribute"
 	"configcenter/src/scene_server/topo_server/logics/inst"
 )
 
@@ -270,6 +272,11 @@ func (assoc *association) CreateCommonAssociation(kit *rest.Kit, data *metadata.
 		return nil, err
 	}
 
+	if err := validateAsstAttributes(kit, data.Attributes); err != nil {
+		blog.Errorf("association attributes are invalid, attrs: %+v, err: %v, rid: %s", data.Attributes, err, kit.Rid)
+		return nil, err
+	}
+
 	// create a new
 	cond := &metadata.CreateModelAssociation{Spec: *data}
 	rspAsst, err := assoc.clientSet.CoreService().Association().CreateModelAssociation(kit.Ctx, kit.Header, cond)
@@ -376,6 +383,20 @@ func (assoc *association) UpdateObjectAssociation(kit *rest.Kit, data mapstr.Map
 		return err
 	}
 
+	if attrVal, exists := data[metadata.AssociationFieldAttributes]; exists {
+		attrs := make([]metadata.AssociationAttribute, 0)
+		if err = decodeAsstAttributes(attrVal, &attrs); err != nil {
+			blog.Errorf("decode association attributes failed, data: %+v, err: %v, rid: %s", attrVal, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AssociationFieldAttributes)
+		}
+
+		if err = validateAsstAttributes(kit, attrs); err != nil {
+			blog.Errorf("association attributes are invalid, attrs: %+v, err: %v, rid: %s", attrs, err, kit.Rid)
+			return err
+		}
+		data[metadata.AssociationFieldAttributes] = attrs
+	}
+
 	updateCond := &metadata.UpdateOption{
 		Condition: mapstr.MapStr{common.BKFieldID: assoID},
 		Data:      data,
@@ -494,6 +515,44 @@ func canUpdate(data mapstr.MapStr) (field string, can bool) {
 		return common.BKIsPre, false
 	}
 
-	// only on delete, association kind id, alias name can be update.
+	// only on delete, association kind id, alias name and attributes can be update.
 	return "", true
 }
+
+// validateAsstAttributes validate the attribute definitions of the instance associations, the option is validated in
+// the same way as the object attribute creation.
+func validateAsstAttributes(kit *rest.Kit, attrs []metadata.AssociationAttribute) error {
+	if rawErr := metadata.ValidateAssociationAttributes(attrs); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	for idx := range attrs {
+		var extraOpt interface{}
+		switch attrs[idx].PropertyType {
+		case common.FieldTypeEnum, common.FieldTypeEnumMulti:
+			if attrs[idx].IsMultiple == nil {
+				isMultiple := attrs[idx].PropertyType == common.FieldTypeEnumMulti
+				attrs[idx].IsMultiple = &isMultiple
+			}
+			extraOpt = attrs[idx].IsMultiple
+		case common.FieldTypeInt, common.FieldTypeList, common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		default:
+			continue
+		}
+
+		if err := attrvalid.ValidPropertyOption(kit, attrs[idx].PropertyType, attrs[idx].Option, extraOpt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeAsstAttributes(data interface{}, attrs *[]metadata.AssociationAttribute) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, attrs)
+}
@@ -0,0 +1,397 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// validateChanges validate that the draft changes can be applied to the current model schema
+func validateChanges(kit *rest.Kit, objID string, detail *schemaDetail, changes []metadata.ModelSchemaChange) error {
+	createdAttrs := make(map[string]struct{})
+	deletedAttrs := make(map[string]struct{})
+	deletedUniques := make(map[string]struct{})
+	for _, change := range changes {
+		switch {
+		case change.Kind == metadata.SchemaAttribute && change.Op == metadata.SchemaChangeCreate:
+			createdAttrs[change.Key] = struct{}{}
+		case change.Kind == metadata.SchemaAttribute && change.Op == metadata.SchemaChangeDelete:
+			deletedAttrs[change.Key] = struct{}{}
+		case change.Kind == metadata.SchemaUnique && change.Op == metadata.SchemaChangeDelete:
+			deletedUniques[change.Key] = struct{}{}
+		}
+	}
+
+	for _, change := range changes {
+		var exists bool
+		switch change.Kind {
+		case metadata.SchemaAttribute:
+			var attr metadata.Attribute
+			attr, exists = detail.attrs[change.Key]
+			if attr.PropertyType == common.FieldTypeInnerTable ||
+				change.Data[metadata.AttributeFieldPropertyType] == common.FieldTypeInnerTable {
+				return invalidChangeErr(kit, change, "inner table attribute is not supported")
+			}
+			if change.Op == metadata.SchemaChangeDelete && attr.IsPre {
+				return invalidChangeErr(kit, change, "pre-defined attribute can not be deleted")
+			}
+		case metadata.SchemaGroup:
+			_, exists = detail.groups[change.Key]
+		case metadata.SchemaUnique:
+			_, exists = detail.uniques[change.Key]
+			if change.Op != metadata.SchemaChangeCreate {
+				break
+			}
+			for _, propertyID := range strings.Split(change.Key, ",") {
+				_, attrExists := detail.attrs[propertyID]
+				_, attrCreated := createdAttrs[propertyID]
+				_, attrDeleted := deletedAttrs[propertyID]
+				if (!attrExists && !attrCreated) || attrDeleted {
+					return invalidChangeErr(kit, change, fmt.Sprintf("attribute %s not exists", propertyID))
+				}
+			}
+		case metadata.SchemaAssociation:
+			_, exists = detail.assts[change.Key]
+			if change.Op != metadata.SchemaChangeCreate {
+				break
+			}
+			asstID := util.GetStrByInterface(change.Data[metadata.AssociationFieldAssociationKind])
+			asstObjID := util.GetStrByInterface(change.Data[metadata.AssociationFieldAssociationObjectID])
+			if change.Key != fmt.Sprintf("%s_%s_%s", objID, asstID, asstObjID) {
+				return invalidChangeErr(kit, change, "key must be the combination of bk_obj_id, bk_asst_id and "+
+					"bk_asst_obj_id")
+			}
+		}
+
+		if change.Op == metadata.SchemaChangeCreate && exists {
+			return invalidChangeErr(kit, change, "already exists")
+		}
+		if change.Op != metadata.SchemaChangeCreate && !exists {
+			return invalidChangeErr(kit, change, "not exists")
+		}
+	}
+
+	// the attribute used by unique rule can not be deleted unless the unique rule is deleted too
+	for key := range detail.uniques {
+		if _, exists := deletedUniques[key]; exists {
+			continue
+		}
+		for _, propertyID := range strings.Split(key, ",") {
+			if _, exists := deletedAttrs[propertyID]; exists {
+				return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid,
+					fmt.Sprintf("attribute %s is used by unique %s", propertyID, key))
+			}
+		}
+	}
+
+	return nil
+}
+
+func invalidChangeErr(kit *rest.Kit, change metadata.ModelSchemaChange, reason string) error {
+	blog.Errorf("model schema change %s %s %s is invalid, reason: %s, rid: %s", change.Op, change.Kind, change.Key,
+		reason, kit.Rid)
+	return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid,
+		fmt.Sprintf("%s %s %s, %s", change.Op, change.Kind, change.Key, reason))
+}
+
+// applyChanges apply the draft changes to the model, the changes are applied in the order that the resources
+// depended on are created before and deleted after the resources that depend on them.
+func (m *modelSchema) applyChanges(kit *rest.Kit, objID string, detail *schemaDetail,
+	changes []metadata.ModelSchemaChange) error {
+
+	changeMap := make(map[metadata.SchemaResourceKind]map[metadata.SchemaChangeOp][]metadata.ModelSchemaChange)
+	for _, change := range changes {
+		if _, exists := changeMap[change.Kind]; !exists {
+			changeMap[change.Kind] = make(map[metadata.SchemaChangeOp][]metadata.ModelSchemaChange)
+		}
+		changeMap[change.Kind][change.Op] = append(changeMap[change.Kind][change.Op], change)
+	}
+
+	steps := []func() error{
+		func() error { return m.upsertGroups(kit, objID, detail, changeMap[metadata.SchemaGroup]) },
+		func() error { return m.upsertAttrs(kit, objID, detail, changeMap[metadata.SchemaAttribute]) },
+		func() error {
+			return m.deleteUniques(kit, objID, detail, changeMap[metadata.SchemaUnique][metadata.SchemaChangeDelete])
+		},
+		func() error {
+			return m.deleteAttrs(kit, detail, changeMap[metadata.SchemaAttribute][metadata.SchemaChangeDelete])
+		},
+		func() error {
+			return m.createUniques(kit, objID, changeMap[metadata.SchemaUnique][metadata.SchemaChangeCreate])
+		},
+		func() error {
+			return m.deleteGroups(kit, detail, changeMap[metadata.SchemaGroup][metadata.SchemaChangeDelete])
+		},
+		func() error { return m.applyAssts(kit, objID, detail, changeMap[metadata.SchemaAssociation]) },
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *modelSchema) upsertGroups(kit *rest.Kit, objID string, detail *schemaDetail,
+	changes map[metadata.SchemaChangeOp][]metadata.ModelSchemaChange) error {
+
+	for _, change := range changes[metadata.SchemaChangeCreate] {
+		group := new(metadata.Group)
+		if err := decodeChangeData(kit, change, group); err != nil {
+			return err
+		}
+		group.ObjectID = objID
+		group.GroupID = change.Key
+		group.OwnerID = kit.SupplierAccount
+
+		if _, err := m.group.CreateObjectGroup(kit, group); err != nil {
+			blog.Errorf("create group failed, group: %+v, err: %v, rid: %s", group, err, kit.Rid)
+			return err
+		}
+	}
+
+	for _, change := range changes[metadata.SchemaChangeUpdate] {
+		group := detail.groups[change.Key]
+		if err := decodeChangeData(kit, change, &group); err != nil {
+			return err
+		}
+
+		cond := &metadata.UpdateGroupCondition{}
+		cond.Condition.ID = group.ID
+		if _, exists := change.Data[common.BKPropertyGroupNameField]; exists {
+			cond.Data.Name = &group.GroupName
+		}
+		if _, exists := change.Data[common.BKPropertyGroupIndexField]; exists {
+			cond.Data.Index = &group.GroupIndex
+		}
+		if _, exists := change.Data[common.BKIsCollapseField]; exists {
+			cond.Data.IsCollapse = &group.IsCollapse
+		}
+
+		if err := m.group.UpdateObjectGroup(kit, cond); err != nil {
+			blog.Errorf("update group failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *modelSchema) upsertAttrs(kit *rest.Kit, objID string, detail *schemaDetail,
+	changes map[metadata.SchemaChangeOp][]metadata.ModelSchemaChange) error {
+
+	for _, change := range changes[metadata.SchemaChangeCreate] {
+		attr := new(metadata.Attribute)
+		if err := decodeChangeData(kit, change, attr); err != nil {
+			return err
+		}
+		attr.ObjectID = objID
+		attr.PropertyID = change.Key
+		attr.OwnerID = kit.SupplierAccount
+		attr.Creator = kit.User
+
+		if _, err := m.attr.CreateObjectAttribute(kit, attr); err != nil {
+			blog.Errorf("create attribute failed, attr: %+v, err: %v, rid: %s", attr, err, kit.Rid)
+			return err
+		}
+	}
+
+	for _, change := range changes[metadata.SchemaChangeUpdate] {
+		attrID := detail.attrs[change.Key].ID
+		if err := m.attr.UpdateObjectAttribute(kit, change.Data, attrID, 0, false); err != nil {
+			blog.Errorf("update attribute %d failed, data: %+v, err: %v, rid: %s", attrID, change.Data, err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *modelSchema) deleteAttrs(kit *rest.Kit, detail *schemaDetail, changes []metadata.ModelSchemaChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	attrs := make([]metadata.Attribute, 0)
+	for _, change := range changes {
+		attrs = append(attrs, detail.attrs[change.Key])
+	}
+
+	if err := m.attr.DeleteObjectAttribute(kit, attrs); err != nil {
+		blog.Errorf("delete attributes failed, attrs: %+v, err: %v, rid: %s", attrs, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+func (m *modelSchema) deleteUniques(kit *rest.Kit, objID string, detail *schemaDetail,
+	changes []metadata.ModelSchemaChange) error {
+
+	audit := auditlog.NewObjectUniqueAuditLog(m.clientSet.CoreService())
+	generateAuditParameter := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditDelete)
+	for _, change := range changes {
+		unique := detail.uniques[change.Key]
+		_, err := m.clientSet.CoreService().Model().DeleteModelAttrUnique(kit.Ctx, kit.Header, objID, unique.ID)
+		if err != nil {
+			blog.Errorf("delete unique %d failed, err: %v, rid: %s", unique.ID, err, kit.Rid)
+			return err
+		}
+
+		auditLog, err := audit.GenerateAuditLog(generateAuditParameter, int64(unique.ID), &unique)
+		if err != nil {
+			blog.Errorf("generate unique audit log failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+
+		if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+			blog.Errorf("save audit log failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *modelSchema) createUniques(kit *rest.Kit, objID string, changes []metadata.ModelSchemaChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// the unique rules may use the attributes that are created in the same draft, so get attributes again
+	cond := &metadata.QueryCondition{
+		Fields:    []string{common.BKFieldID, common.BKPropertyIDField},
+		Condition: mapstr.MapStr{common.BKObjIDField: objID, common.BKAppIDField: 0},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	attrs, err := m.clientSet.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, objID, cond)
+	if err != nil {
+		blog.Errorf("read model attributes failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return err
+	}
+
+	propertyIDMap := make(map[string]int64)
+	for _, attr := range attrs.Info {
+		propertyIDMap[attr.PropertyID] = attr.ID
+	}
+
+	audit := auditlog.NewObjectUniqueAuditLog(m.clientSet.CoreService())
+	generateAuditParameter := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditCreate)
+	for _, change := range changes {
+		keys := make([]metadata.UniqueKey, 0)
+		for _, propertyID := range strings.Split(change.Key, ",") {
+			keys = append(keys, metadata.UniqueKey{Kind: metadata.UniqueKeyKindProperty,
+				ID: uint64(propertyIDMap[propertyID])})
+		}
+
+		unique := metadata.CreateModelAttrUnique{Data: metadata.ObjectUnique{ObjID: objID, Keys: keys}}
+		rsp, err := m.clientSet.CoreService().Model().CreateModelAttrUnique(kit.Ctx, kit.Header, objID, unique)
+		if err != nil {
+			blog.Errorf("create unique failed, unique: %+v, err: %v, rid: %s", unique, err, kit.Rid)
+			return err
+		}
+
+		auditLog, err := audit.GenerateAuditLog(generateAuditParameter, int64(rsp.Created.ID), nil)
+		if err != nil {
+			blog.Errorf("generate unique audit log failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+
+		if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+			blog.Errorf("save audit log failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *modelSchema) deleteGroups(kit *rest.Kit, detail *schemaDetail, changes []metadata.ModelSchemaChange) error {
+	for _, change := range changes {
+		groupID := detail.groups[change.Key].ID
+		if err := m.group.DeleteObjectGroup(kit, groupID); err != nil {
+			blog.Errorf("delete group %d failed, err: %v, rid: %s", groupID, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *modelSchema) applyAssts(kit *rest.Kit, objID string, detail *schemaDetail,
+	changes map[metadata.SchemaChangeOp][]metadata.ModelSchemaChange) error {
+
+	for _, change := range changes[metadata.SchemaChangeDelete] {
+		asstID := detail.assts[change.Key].ID
+		if err := m.asst.DeleteAssociationWithPreCheck(kit, asstID); err != nil {
+			blog.Errorf("delete association %d failed, err: %v, rid: %s", asstID, err, kit.Rid)
+			return err
+		}
+	}
+
+	for _, change := range changes[metadata.SchemaChangeUpdate] {
+		asstID := detail.assts[change.Key].ID
+		if err := m.asst.UpdateObjectAssociation(kit, change.Data, asstID); err != nil {
+			blog.Errorf("update association %d failed, data: %+v, err: %v, rid: %s", asstID, change.Data, err,
+				kit.Rid)
+			return err
+		}
+	}
+
+	for _, change := range changes[metadata.SchemaChangeCreate] {
+		asst := new(metadata.Association)
+		if err := decodeChangeData(kit, change, asst); err != nil {
+			return err
+		}
+		asst.ObjectID = objID
+		asst.AssociationName = change.Key
+		asst.OwnerID = kit.SupplierAccount
+		asst.IsPre = nil
+
+		if _, err := m.asst.CreateCommonAssociation(kit, asst); err != nil {
+			blog.Errorf("create association failed, asst: %+v, err: %v, rid: %s", asst, err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeChangeData decode the change data into the schema resource by its json tags
+func decodeChangeData(kit *rest.Kit, change metadata.ModelSchemaChange, result interface{}) error {
+	js, err := json.Marshal(change.Data)
+	if err != nil {
+		blog.Errorf("marshal change data failed, change: %+v, err: %v, rid: %s", change, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "data")
+	}
+
+	if err := json.Unmarshal(js, result); err != nil {
+		blog.Errorf("unmarshal change data failed, change: %+v, err: %v, rid: %s", change, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "data")
+	}
+	return nil
+}
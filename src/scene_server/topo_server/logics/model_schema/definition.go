@@ -0,0 +1,412 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+var (
+	clsDefinitionFields      = []string{common.BKClassificationNameField, common.BKClassificationIconField}
+	asstKindDefinitionFields = []string{common.AssociationKindNameField, "src_des", "dest_des", "direction"}
+	objDefinitionFields      = []string{common.BKObjNameField, common.BKObjIconField, common.BKClassificationIDField}
+)
+
+// DefinitionApplyResult is the result of applying model definition, the created classifications and objects are
+// returned so that they can be registered to iam by the caller.
+type DefinitionApplyResult struct {
+	Plan            *metadata.ModelDefinitionPlan
+	Classifications []metadata.Classification
+	Objects         []metadata.Object
+}
+
+// definitionState is the current state of the resources in the model definition
+type definitionState struct {
+	classifications map[string]metadata.Classification
+	asstKinds       map[string]metadata.AssociationKind
+	objects         map[string]metadata.Object
+}
+
+// PlanDefinition get the changes that converge the cmdb to the model definition, the schema changes of the objects
+// that are not created yet are planned against an empty schema.
+func (m *modelSchema) PlanDefinition(kit *rest.Kit, def *metadata.ModelDefinition) (*metadata.ModelDefinitionPlan,
+	error) {
+
+	state, err := m.getDefinitionState(kit, def)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &metadata.ModelDefinitionPlan{Changes: planTopResources(state, def)}
+	for idx := range def.Objects {
+		detail := newSchemaDetail()
+		if _, exists := state.objects[def.Objects[idx].ObjectID]; exists {
+			if detail, err = m.getSchemaDetail(kit, def.Objects[idx].ObjectID); err != nil {
+				return nil, err
+			}
+		}
+
+		changes := diffObjectDefinition(detail, &def.Objects[idx], def.Prune)
+		plan.Changes = append(plan.Changes, toDefinitionChanges(def.Objects[idx].ObjectID, changes)...)
+	}
+
+	return plan, nil
+}
+
+// ApplyDefinition converge the cmdb to the model definition, applying the same definition again makes no changes.
+// it should be called in transaction.
+func (m *modelSchema) ApplyDefinition(kit *rest.Kit, def *metadata.ModelDefinition) (*DefinitionApplyResult,
+	error) {
+
+	state, err := m.getDefinitionState(kit, def)
+	if err != nil {
+		return nil, err
+	}
+
+	// classifications and association kinds are applied first, since objects and associations depend on them
+	plan := &metadata.ModelDefinitionPlan{Changes: planTopResources(state, def)}
+	result := &DefinitionApplyResult{
+		Plan:            plan,
+		Classifications: make([]metadata.Classification, 0),
+		Objects:         make([]metadata.Object, 0),
+	}
+	for _, change := range plan.Changes {
+		if err := m.applyTopResource(kit, state, change, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// schema resources are applied after all objects are created, so that associations between the defined objects
+	// can be created regardless of the definition order.
+	for idx := range def.Objects {
+		objID := def.Objects[idx].ObjectID
+		detail, err := m.getSchemaDetail(kit, objID)
+		if err != nil {
+			return nil, err
+		}
+
+		changes := diffObjectDefinition(detail, &def.Objects[idx], def.Prune)
+		if len(changes) == 0 {
+			continue
+		}
+
+		if err := m.checkInstances(kit, detail, &metadata.ModelSchemaDraft{ObjID: objID, Changes: changes}); err != nil {
+			return nil, err
+		}
+
+		if err := m.applyChanges(kit, objID, detail, changes); err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, toDefinitionChanges(objID, changes)...)
+	}
+
+	return result, nil
+}
+
+func (m *modelSchema) getDefinitionState(kit *rest.Kit, def *metadata.ModelDefinition) (*definitionState, error) {
+	state := &definitionState{
+		classifications: make(map[string]metadata.Classification),
+		asstKinds:       make(map[string]metadata.AssociationKind),
+		objects:         make(map[string]metadata.Object),
+	}
+
+	if len(def.Classifications) > 0 {
+		clsIDs := make([]string, 0)
+		for _, cls := range def.Classifications {
+			clsIDs = append(clsIDs, cls.ClassificationID)
+		}
+
+		cond := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.BKClassificationIDField: mapstr.MapStr{common.BKDBIN: clsIDs}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		res, err := m.clientSet.CoreService().Model().ReadModelClassification(kit.Ctx, kit.Header, cond)
+		if err != nil {
+			blog.Errorf("read classifications failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+			return nil, err
+		}
+
+		for _, cls := range res.Info {
+			state.classifications[cls.ClassificationID] = cls
+		}
+	}
+
+	if len(def.AssociationKinds) > 0 {
+		asstKindIDs := make([]string, 0)
+		for _, kind := range def.AssociationKinds {
+			asstKindIDs = append(asstKindIDs, kind.AssociationKindID)
+		}
+
+		cond := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.AssociationKindIDField: mapstr.MapStr{common.BKDBIN: asstKindIDs}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		res, err := m.clientSet.CoreService().Association().ReadAssociationType(kit.Ctx, kit.Header, cond)
+		if err != nil {
+			blog.Errorf("read association kinds failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+			return nil, err
+		}
+
+		for _, kind := range res.Info {
+			state.asstKinds[kind.AssociationKindID] = *kind
+		}
+	}
+
+	if len(def.Objects) > 0 {
+		objIDs := make([]string, 0)
+		for _, obj := range def.Objects {
+			objIDs = append(objIDs, obj.ObjectID)
+		}
+
+		cond := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		res, err := m.clientSet.CoreService().Model().ReadModel(kit.Ctx, kit.Header, cond)
+		if err != nil {
+			blog.Errorf("read models failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+			return nil, err
+		}
+
+		for _, obj := range res.Info {
+			state.objects[obj.ObjectID] = obj
+		}
+	}
+
+	return state, nil
+}
+
+// planTopResources get the changes of classifications, association kinds and objects in the order of execution
+func planTopResources(state *definitionState, def *metadata.ModelDefinition) []metadata.ModelDefinitionChange {
+	changes := make([]metadata.ModelDefinitionChange, 0)
+
+	base, target := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, cls := range def.Classifications {
+		if current, exists := state.classifications[cls.ClassificationID]; exists {
+			base[cls.ClassificationID] = toMapStr(current)
+		}
+		target[cls.ClassificationID] = definedFields(toMapStr(cls), clsDefinitionFields)
+	}
+	changes = append(changes, toDefinitionChanges("", diffDefinition(metadata.DefinitionClassification, base, target,
+		clsDefinitionFields, clsDefinitionFields, false, nil))...)
+
+	base, target = make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, kind := range def.AssociationKinds {
+		if current, exists := state.asstKinds[kind.AssociationKindID]; exists {
+			base[kind.AssociationKindID] = toMapStr(current)
+		}
+		target[kind.AssociationKindID] = definedFields(toMapStr(kind), asstKindDefinitionFields)
+	}
+	changes = append(changes, toDefinitionChanges("", diffDefinition(metadata.DefinitionAssociationKind, base,
+		target, asstKindDefinitionFields, asstKindDefinitionFields, false, nil))...)
+
+	base, target = make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, obj := range def.Objects {
+		if current, exists := state.objects[obj.ObjectID]; exists {
+			base[obj.ObjectID] = toMapStr(current)
+		}
+		target[obj.ObjectID] = definedFields(toMapStr(obj), objDefinitionFields)
+	}
+	changes = append(changes, toDefinitionChanges("", diffDefinition(metadata.DefinitionObject, base, target,
+		objDefinitionFields, objDefinitionFields, false, nil))...)
+
+	return changes
+}
+
+// applyTopResource apply the change of classification, association kind or object
+func (m *modelSchema) applyTopResource(kit *rest.Kit, state *definitionState, change metadata.ModelDefinitionChange,
+	result *DefinitionApplyResult) error {
+
+	data := change.Data.Clone()
+	switch change.Kind {
+	case metadata.DefinitionClassification:
+		if change.Op == metadata.SchemaChangeUpdate {
+			return m.cls.UpdateClassification(kit, data, state.classifications[change.Key].ID)
+		}
+
+		data[common.BKClassificationIDField] = change.Key
+		cls, err := m.cls.CreateClassification(kit, data)
+		if err != nil {
+			return err
+		}
+		result.Classifications = append(result.Classifications, *cls)
+	case metadata.DefinitionAssociationKind:
+		if change.Op == metadata.SchemaChangeUpdate {
+			opt := &metadata.UpdateOption{
+				Condition: mapstr.MapStr{common.BKFieldID: state.asstKinds[change.Key].ID},
+				Data:      data,
+			}
+			_, err := m.clientSet.CoreService().Association().UpdateAssociationType(kit.Ctx, kit.Header, opt)
+			if err != nil {
+				blog.Errorf("update association kind failed, opt: %+v, err: %v, rid: %s", opt, err, kit.Rid)
+				return err
+			}
+			return nil
+		}
+
+		kind := metadata.AssociationKind{AssociationKindID: change.Key, OwnerID: kit.SupplierAccount}
+		if err := decodeChangeData(kit, change.ModelSchemaChange, &kind); err != nil {
+			return err
+		}
+		// association kind is registered to iam when it is created
+		return m.asst.CreateOrUpdateAssociationType(kit, []metadata.AssociationKind{kind})
+	case metadata.DefinitionObject:
+		if change.Op == metadata.SchemaChangeUpdate {
+			return m.obj.UpdateObject(kit, data, state.objects[change.Key].ID)
+		}
+
+		data[common.BKObjIDField] = change.Key
+		obj, err := m.obj.CreateObject(kit, false, data)
+		if err != nil {
+			return err
+		}
+		result.Objects = append(result.Objects, *obj)
+	}
+
+	return nil
+}
+
+// diffObjectDefinition get the schema changes that converge the model to the object definition
+func diffObjectDefinition(detail *schemaDetail, def *metadata.ObjectDefinition,
+	prune bool) []metadata.ModelSchemaChange {
+
+	changes := make([]metadata.ModelSchemaChange, 0)
+
+	// pre-defined resources and inner table attributes are not managed by model definition, they are not deleted
+	base, target, undeletable := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr),
+		make(map[string]struct{})
+	for key, attr := range detail.attrs {
+		base[key] = toMapStr(attr)
+		if attr.IsPre || attr.PropertyType == common.FieldTypeInnerTable {
+			undeletable[key] = struct{}{}
+		}
+	}
+	for idx, key := range def.ResourceKeys(metadata.SchemaAttribute) {
+		target[key] = toMapStr(def.Attributes[idx])
+	}
+	changes = append(changes, diffDefinition(metadata.SchemaAttribute, base, target, attrCreateFields, attrUpdateFields,
+		prune,
+		undeletable)...)
+
+	base, target, undeletable = make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr),
+		make(map[string]struct{})
+	for key, group := range detail.groups {
+		base[key] = toMapStr(group)
+		if group.IsDefault || group.IsPre {
+			undeletable[key] = struct{}{}
+		}
+	}
+	for idx, key := range def.ResourceKeys(metadata.SchemaGroup) {
+		target[key] = toMapStr(def.Groups[idx])
+	}
+	changes = append(changes, diffDefinition(metadata.SchemaGroup, base, target, groupCreateFields, groupUpdateFields,
+		prune,
+		undeletable)...)
+
+	base, target, undeletable = make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr),
+		make(map[string]struct{})
+	for key, unique := range detail.uniques {
+		base[key] = mapstr.MapStr{}
+		if unique.Ispre {
+			undeletable[key] = struct{}{}
+		}
+	}
+	for _, key := range def.ResourceKeys(metadata.SchemaUnique) {
+		target[key] = mapstr.MapStr{}
+	}
+	changes = append(changes, diffDefinition(metadata.SchemaUnique, base, target, nil, nil, prune, undeletable)...)
+
+	base, target, undeletable = make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr),
+		make(map[string]struct{})
+	for key, asst := range detail.assts {
+		base[key] = toMapStr(asst)
+		if asst.IsPre != nil && *asst.IsPre {
+			undeletable[key] = struct{}{}
+		}
+	}
+	for idx, key := range def.ResourceKeys(metadata.SchemaAssociation) {
+		target[key] = toMapStr(def.Associations[idx])
+	}
+	changes = append(changes, diffDefinition(metadata.SchemaAssociation, base, target, asstCreateFields, asstUpdateFields,
+		prune,
+		undeletable)...)
+
+	return changes
+}
+
+// diffDefinition get the changes of the defined resources, unlike Diff, only the fields that are set in the
+// definition are compared, and the resources that are not defined are deleted only when prune is enabled.
+func diffDefinition(kind metadata.SchemaResourceKind, base, target map[string]mapstr.MapStr, createFields,
+	updateFields []string, prune bool, undeletable map[string]struct{}) []metadata.ModelSchemaChange {
+
+	changes := make([]metadata.ModelSchemaChange, 0)
+	for _, change := range diffResource(kind, base, target, createFields, updateFields) {
+		switch change.Op {
+		case metadata.SchemaChangeUpdate:
+			for field := range change.Data {
+				if _, exists := target[change.Key][field]; !exists {
+					delete(change.Data, field)
+				}
+			}
+			if len(change.Data) == 0 {
+				continue
+			}
+		case metadata.SchemaChangeDelete:
+			if _, exists := undeletable[change.Key]; !prune || exists {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// definedFields returns the fields in the field list that are set in the data, empty strings are regarded as not set
+func definedFields(data mapstr.MapStr, fields []string) mapstr.MapStr {
+	result := mapstr.MapStr{}
+	for _, field := range fields {
+		value, exists := data[field]
+		if !exists || value == "" {
+			continue
+		}
+		result[field] = value
+	}
+	return result
+}
+
+func toDefinitionChanges(objID string, changes []metadata.ModelSchemaChange) []metadata.ModelDefinitionChange {
+	result := make([]metadata.ModelDefinitionChange, len(changes))
+	for idx, change := range changes {
+		result[idx] = metadata.ModelDefinitionChange{ObjID: objID, ModelSchemaChange: change}
+	}
+	return result
+}
+
+func newSchemaDetail() *schemaDetail {
+	return &schemaDetail{
+		schema:  new(metadata.ModelSchema),
+		attrs:   make(map[string]metadata.Attribute),
+		groups:  make(map[string]metadata.Group),
+		uniques: make(map[string]metadata.ObjectUnique),
+		assts:   make(map[string]metadata.Association),
+	}
+}
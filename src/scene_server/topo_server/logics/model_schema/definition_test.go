@@ -0,0 +1,68 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestDiffObjectDefinition(t *testing.T) {
+	detail := newSchemaDetail()
+	detail.attrs["bk_inst_name"] = metadata.Attribute{PropertyID: "bk_inst_name", PropertyName: "name",
+		PropertyType: "singlechar", IsPre: true, PropertyIndex: 1}
+	detail.attrs["os"] = metadata.Attribute{PropertyID: "os", PropertyName: "os", PropertyType: "singlechar",
+		Unit: "-"}
+	detail.groups["default"] = metadata.Group{GroupID: "default", GroupName: "Default", IsDefault: true}
+	detail.uniques["bk_inst_name"] = metadata.ObjectUnique{Ispre: true}
+
+	def := &metadata.ObjectDefinition{
+		ObjectID: "switch",
+		Attributes: []mapstr.MapStr{
+			{"bk_property_id": "bk_inst_name", "bk_property_name": "name", "bk_property_index": 1},
+			{"bk_property_id": "ip", "bk_property_name": "ip", "bk_property_type": "singlechar"},
+		},
+		Uniques: [][]string{{"ip"}},
+	}
+
+	// the fields that are not defined keep their current values, and nothing is deleted without prune
+	changes := diffObjectDefinition(detail, def, false)
+	if len(changes) != 2 {
+		t.Fatalf("expect 2 changes, got %d: %+v", len(changes), changes)
+	}
+	if changes[0].Op != metadata.SchemaChangeCreate || changes[0].Key != "ip" {
+		t.Errorf("expect attribute ip is created, got %+v", changes[0])
+	}
+	if changes[1].Kind != metadata.SchemaUnique || changes[1].Op != metadata.SchemaChangeCreate {
+		t.Errorf("expect unique ip is created, got %+v", changes[1])
+	}
+
+	// pre-defined resources are not deleted by prune
+	changes = diffObjectDefinition(detail, def, true)
+	if len(changes) != 3 || changes[1].Op != metadata.SchemaChangeDelete || changes[1].Key != "os" {
+		t.Fatalf("expect only attribute os is deleted, got %+v", changes)
+	}
+
+	def.Attributes[0]["bk_property_name"] = "inst name"
+	changes = diffObjectDefinition(detail, def, false)
+	if changes[0].Op != metadata.SchemaChangeUpdate || len(changes[0].Data) != 1 {
+		t.Errorf("expect only bk_property_name of bk_inst_name is updated, got %+v", changes[0])
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+var (
+	// attrUpdateFields are the attribute fields that can be updated
+	attrUpdateFields = []string{metadata.AttributeFieldPropertyName, metadata.AttributeFieldPropertyGroup,
+		metadata.AttributeFieldPropertyIndex, metadata.AttributeFieldUnit, metadata.AttributeFieldPlaceHolder,
+		metadata.AttributeFieldIsEditable, metadata.AttributeFieldIsRequired, metadata.AttributeFieldIsReadOnly,
+		metadata.AttributeFieldOption, metadata.AttributeFieldDefault, metadata.AttributeFieldIsMultiple,
+		metadata.AttributeFieldDescription}
+	// attrCreateFields are the attribute fields that are used to create the attribute
+	attrCreateFields = append([]string{metadata.AttributeFieldPropertyType, metadata.AttributeFieldIsOnly},
+		attrUpdateFields...)
+
+	groupUpdateFields = []string{common.BKPropertyGroupNameField, common.BKPropertyGroupIndexField,
+		common.BKIsCollapseField}
+	groupCreateFields = groupUpdateFields
+
+	// association kind is a part of the association's bk_obj_asst_id, so only the alias name, on delete action and
+	// attributes can be updated, refer to metadata.Association CanUpdate
+	asstUpdateFields = []string{"bk_obj_asst_name", "on_delete", metadata.AssociationFieldAttributes}
+	asstCreateFields = append([]string{metadata.AssociationFieldAssociationKind,
+		metadata.AssociationFieldAssociationObjectID, "mapping"}, asstUpdateFields...)
+)
+
+// Diff returns the changes that changes the base model schema to the target model schema. inner table attributes
+// are not managed by model schema draft, so they are ignored.
+func Diff(base, target *metadata.ModelSchema) []metadata.ModelSchemaChange {
+	changes := make([]metadata.ModelSchemaChange, 0)
+
+	baseAttrs, targetAttrs := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, attr := range base.Attributes {
+		if attr.PropertyType != common.FieldTypeInnerTable {
+			baseAttrs[attr.PropertyID] = toMapStr(attr)
+		}
+	}
+	for _, attr := range target.Attributes {
+		if attr.PropertyType != common.FieldTypeInnerTable {
+			targetAttrs[attr.PropertyID] = toMapStr(attr)
+		}
+	}
+	changes = append(changes, diffResource(metadata.SchemaAttribute, baseAttrs, targetAttrs, attrCreateFields,
+		attrUpdateFields)...)
+
+	baseGroups, targetGroups := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, group := range base.Groups {
+		baseGroups[group.GroupID] = toMapStr(group)
+	}
+	for _, group := range target.Groups {
+		targetGroups[group.GroupID] = toMapStr(group)
+	}
+	changes = append(changes, diffResource(metadata.SchemaGroup, baseGroups, targetGroups, groupCreateFields,
+		groupUpdateFields)...)
+
+	baseUniques, targetUniques := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, unique := range base.Uniques {
+		baseUniques[unique] = mapstr.MapStr{}
+	}
+	for _, unique := range target.Uniques {
+		targetUniques[unique] = mapstr.MapStr{}
+	}
+	changes = append(changes, diffResource(metadata.SchemaUnique, baseUniques, targetUniques, nil, nil)...)
+
+	baseAssts, targetAssts := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, asst := range base.Associations {
+		baseAssts[asst.AssociationName] = toMapStr(asst)
+	}
+	for _, asst := range target.Associations {
+		targetAssts[asst.AssociationName] = toMapStr(asst)
+	}
+	changes = append(changes, diffResource(metadata.SchemaAssociation, baseAssts, targetAssts, asstCreateFields,
+		asstUpdateFields)...)
+
+	return changes
+}
+
+func diffResource(kind metadata.SchemaResourceKind, base, target map[string]mapstr.MapStr, createFields,
+	updateFields []string) []metadata.ModelSchemaChange {
+
+	changes := make([]metadata.ModelSchemaChange, 0)
+	for _, key := range sortedKeys(target) {
+		baseData, exists := base[key]
+		if !exists {
+			change := metadata.ModelSchemaChange{Kind: kind, Op: metadata.SchemaChangeCreate, Key: key}
+			if len(createFields) > 0 {
+				change.Data = pickFields(target[key], createFields)
+			}
+			changes = append(changes, change)
+			continue
+		}
+
+		updateData := mapstr.MapStr{}
+		for _, field := range updateFields {
+			if !reflect.DeepEqual(baseData[field], target[key][field]) {
+				updateData[field] = target[key][field]
+			}
+		}
+
+		if len(updateData) > 0 {
+			changes = append(changes, metadata.ModelSchemaChange{Kind: kind, Op: metadata.SchemaChangeUpdate, Key: key,
+				Data: updateData})
+		}
+	}
+
+	for _, key := range sortedKeys(base) {
+		if _, exists := target[key]; !exists {
+			changes = append(changes, metadata.ModelSchemaChange{Kind: kind, Op: metadata.SchemaChangeDelete, Key: key})
+		}
+	}
+
+	return changes
+}
+
+// toMapStr converts the schema resource to its json form, so that resources decoded from db or api are comparable
+func toMapStr(data interface{}) mapstr.MapStr {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return mapstr.MapStr{}
+	}
+
+	result := mapstr.MapStr{}
+	if err := json.Unmarshal(js, &result); err != nil {
+		return mapstr.MapStr{}
+	}
+	return result
+}
+
+func pickFields(data mapstr.MapStr, fields []string) mapstr.MapStr {
+	result := mapstr.MapStr{}
+	for _, field := range fields {
+		if value, exists := data[field]; exists {
+			result[field] = value
+		}
+	}
+	return result
+}
+
+func sortedKeys(data map[string]mapstr.MapStr) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestDiff(t *testing.T) {
+	base := &metadata.ModelSchema{
+		Attributes: []metadata.Attribute{
+			{PropertyID: "name", PropertyName: "name", PropertyType: "singlechar"},
+			{PropertyID: "os", PropertyName: "os", PropertyType: "singlechar"},
+			{PropertyID: "table", PropertyName: "table", PropertyType: "innertable"},
+		},
+		Groups:  []metadata.Group{{GroupID: "default", GroupName: "Default"}},
+		Uniques: []string{"name"},
+		Associations: []metadata.Association{
+			{AssociationName: "host_run_app", AssociationAliasName: "run", AsstKindID: "run", AsstObjID: "app"},
+		},
+	}
+
+	target := &metadata.ModelSchema{
+		Attributes: []metadata.Attribute{
+			{PropertyID: "name", PropertyName: "name", PropertyType: "singlechar", IsRequired: true},
+			{PropertyID: "ip", PropertyName: "ip", PropertyType: "singlechar"},
+		},
+		Groups:  []metadata.Group{{GroupID: "default", GroupName: "Default"}},
+		Uniques: []string{"ip,name"},
+	}
+
+	changes := Diff(base, target)
+
+	expected := []struct {
+		kind metadata.SchemaResourceKind
+		op   metadata.SchemaChangeOp
+		key  string
+	}{
+		{metadata.SchemaAttribute, metadata.SchemaChangeCreate, "ip"},
+		{metadata.SchemaAttribute, metadata.SchemaChangeUpdate, "name"},
+		{metadata.SchemaAttribute, metadata.SchemaChangeDelete, "os"},
+		{metadata.SchemaUnique, metadata.SchemaChangeCreate, "ip,name"},
+		{metadata.SchemaUnique, metadata.SchemaChangeDelete, "name"},
+		{metadata.SchemaAssociation, metadata.SchemaChangeDelete, "host_run_app"},
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("expect %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+
+	for idx, change := range changes {
+		if change.Kind != expected[idx].kind || change.Op != expected[idx].op || change.Key != expected[idx].key {
+			t.Errorf("change %d expect %s %s %s, got %s %s %s", idx, expected[idx].op, expected[idx].kind,
+				expected[idx].key, change.Op, change.Kind, change.Key)
+		}
+	}
+
+	update := changes[1].Data
+	if len(update) != 1 || update[metadata.AttributeFieldIsRequired] != true {
+		t.Errorf("expect only isrequired is updated, got %+v", update)
+	}
+
+	if changes[0].Data[metadata.AttributeFieldPropertyType] != "singlechar" {
+		t.Errorf("expect created attribute has property type, got %+v", changes[0].Data)
+	}
+}
+
+func TestDiffNoChange(t *testing.T) {
+	schema := &metadata.ModelSchema{
+		Attributes: []metadata.Attribute{{ID: 1, PropertyID: "name", PropertyName: "name", Option: map[string]int{
+			"min": 1}}},
+		Groups:  []metadata.Group{{ID: 1, GroupID: "default", GroupName: "Default"}},
+		Uniques: []string{"name"},
+	}
+
+	// the ids are not a part of the schema, and the option decoded from api is compared by its json form
+	target := &metadata.ModelSchema{
+		Attributes: []metadata.Attribute{{ID: 2, PropertyID: "name", PropertyName: "name",
+			Option: map[string]interface{}{"min": float64(1)}}},
+		Groups:  []metadata.Group{{ID: 2, GroupID: "default", GroupName: "Default"}},
+		Uniques: []string{"name"},
+	}
+
+	if changes := Diff(schema, target); len(changes) != 0 {
+		t.Errorf("expect no changes, got %+v", changes)
+	}
+}
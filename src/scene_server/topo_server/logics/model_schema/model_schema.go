@@ -0,0 +1,447 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package modelschema defines model schema draft, publish and version logics.
+package modelschema
+
+import (
+	"sort"
+
+	"configcenter/pkg/filter"
+	filtertools "configcenter/pkg/tools/filter"
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/logics/model"
+)
+
+// ModelSchemaOperation model schema operation methods
+type ModelSchemaOperation interface {
+	// GetModelSchema get the current schema of the model
+	GetModelSchema(kit *rest.Kit, objID string) (*metadata.ModelSchema, error)
+	// GetDraft get the schema draft of the model, returns nil if the model has no draft
+	GetDraft(kit *rest.Kit, objID string) (*metadata.ModelSchemaDraft, error)
+	// SaveDraft validate and save the schema draft of the model
+	SaveDraft(kit *rest.Kit, draft *metadata.ModelSchemaDraft) error
+	// DeleteDraft delete the schema draft of the model
+	DeleteDraft(kit *rest.Kit, objID string) error
+	// CheckDraft check the schema draft of the model against the existing instances
+	CheckDraft(kit *rest.Kit, objID string) (*metadata.ModelSchemaCheckResult, error)
+	// Publish apply the changes of the schema draft to the model and create a new schema version
+	Publish(kit *rest.Kit, objID string, opt *metadata.PublishModelSchemaOption) (*metadata.ModelSchemaVersion, error)
+	// GetVersion get the schema version of the model
+	GetVersion(kit *rest.Kit, objID string, version int64) (*metadata.ModelSchemaVersion, error)
+	// Diff get the changes between two schema versions of the model
+	Diff(kit *rest.Kit, objID string, opt *metadata.DiffModelSchemaOption) ([]metadata.ModelSchemaChange, error)
+	// Revert stage the changes that revert the model to the schema version into the draft of the model
+	Revert(kit *rest.Kit, objID string, version int64) (*metadata.ModelSchemaDraft, error)
+	// PlanDefinition get the changes that converge the cmdb to the model definition
+	PlanDefinition(kit *rest.Kit, def *metadata.ModelDefinition) (*metadata.ModelDefinitionPlan, error)
+	// ApplyDefinition converge the cmdb to the model definition
+	ApplyDefinition(kit *rest.Kit, def *metadata.ModelDefinition) (*DefinitionApplyResult, error)
+}
+
+// NewModelSchemaOperation create a new model schema operation instance
+func NewModelSchemaOperation(client apimachinery.ClientSetInterface, cls model.ClassificationOperationInterface,
+	obj model.ObjectOperationInterface, attr model.AttributeOperationInterface, group model.GroupOperationInterface,
+	asst model.AssociationOperationInterface) ModelSchemaOperation {
+
+	return &modelSchema{
+		clientSet: client,
+		cls:       cls,
+		obj:       obj,
+		attr:      attr,
+		group:     group,
+		asst:      asst,
+	}
+}
+
+type modelSchema struct {
+	clientSet apimachinery.ClientSetInterface
+	cls       model.ClassificationOperationInterface
+	obj       model.ObjectOperationInterface
+	attr      model.AttributeOperationInterface
+	group     model.GroupOperationInterface
+	asst      model.AssociationOperationInterface
+}
+
+// schemaDetail is the model schema with the resources indexed by their keys
+type schemaDetail struct {
+	schema  *metadata.ModelSchema
+	attrs   map[string]metadata.Attribute
+	groups  map[string]metadata.Group
+	uniques map[string]metadata.ObjectUnique
+	assts   map[string]metadata.Association
+}
+
+// GetModelSchema get the current schema of the model
+func (m *modelSchema) GetModelSchema(kit *rest.Kit, objID string) (*metadata.ModelSchema, error) {
+	detail, err := m.getSchemaDetail(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+	return detail.schema, nil
+}
+
+func (m *modelSchema) getSchemaDetail(kit *rest.Kit, objID string) (*schemaDetail, error) {
+	detail := newSchemaDetail()
+
+	// only the public attributes and groups are the schema of the model, the business private ones are not included.
+	cond := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKObjIDField: objID, common.BKAppIDField: 0},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	attrs, err := m.clientSet.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, objID, cond)
+	if err != nil {
+		blog.Errorf("read model attributes failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, err
+	}
+
+	attrIDMap := make(map[int64]string)
+	for _, attr := range attrs.Info {
+		detail.attrs[attr.PropertyID] = attr
+		attrIDMap[attr.ID] = attr.PropertyID
+	}
+	detail.schema.Attributes = attrs.Info
+	sort.SliceStable(detail.schema.Attributes, func(i, j int) bool {
+		if detail.schema.Attributes[i].PropertyIndex != detail.schema.Attributes[j].PropertyIndex {
+			return detail.schema.Attributes[i].PropertyIndex < detail.schema.Attributes[j].PropertyIndex
+		}
+		return detail.schema.Attributes[i].ID < detail.schema.Attributes[j].ID
+	})
+
+	groups, err := m.clientSet.CoreService().Model().ReadAttributeGroup(kit.Ctx, kit.Header, objID, *cond)
+	if err != nil {
+		blog.Errorf("read model attribute groups failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, err
+	}
+
+	for _, group := range groups.Info {
+		detail.groups[group.GroupID] = group
+	}
+	detail.schema.Groups = groups.Info
+	sort.SliceStable(detail.schema.Groups, func(i, j int) bool {
+		return detail.schema.Groups[i].GroupIndex < detail.schema.Groups[j].GroupIndex
+	})
+
+	uniqueCond := metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: objID}}
+	uniques, err := m.clientSet.CoreService().Model().ReadModelAttrUnique(kit.Ctx, kit.Header, uniqueCond)
+	if err != nil {
+		blog.Errorf("read model uniques failed, cond: %+v, err: %v, rid: %s", uniqueCond, err, kit.Rid)
+		return nil, err
+	}
+
+	detail.schema.Uniques = make([]string, 0)
+	for _, unique := range uniques.Info {
+		propertyIDs := make([]string, 0)
+		for _, key := range unique.Keys {
+			propertyIDs = append(propertyIDs, attrIDMap[int64(key.ID)])
+		}
+		key := metadata.SchemaUniqueKey(propertyIDs)
+		detail.uniques[key] = unique
+		detail.schema.Uniques = append(detail.schema.Uniques, key)
+	}
+	sort.Strings(detail.schema.Uniques)
+
+	asstCond := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKObjIDField:           objID,
+			common.AssociationKindIDField: mapstr.MapStr{common.BKDBNE: common.AssociationKindMainline},
+		},
+	}
+	assts, err := m.clientSet.CoreService().Association().ReadModelAssociation(kit.Ctx, kit.Header, asstCond)
+	if err != nil {
+		blog.Errorf("read model associations failed, cond: %+v, err: %v, rid: %s", asstCond, err, kit.Rid)
+		return nil, err
+	}
+
+	for _, asst := range assts.Info {
+		detail.assts[asst.AssociationName] = asst
+	}
+	detail.schema.Associations = assts.Info
+	sort.SliceStable(detail.schema.Associations, func(i, j int) bool {
+		return detail.schema.Associations[i].AssociationName < detail.schema.Associations[j].AssociationName
+	})
+
+	return detail, nil
+}
+
+// GetDraft get the schema draft of the model, returns nil if the model has no draft
+func (m *modelSchema) GetDraft(kit *rest.Kit, objID string) (*metadata.ModelSchemaDraft, error) {
+	opt := &metadata.CommonQueryOption{
+		CommonFilterOption: metadata.CommonFilterOption{
+			Filter: filtertools.GenAtomFilter(common.BKObjIDField, filter.Equal, objID),
+		},
+		Page: metadata.BasePage{Limit: 1},
+	}
+	res, err := m.clientSet.CoreService().ModelSchema().ListModelSchemaDraft(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list model schema draft failed, obj: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(res.Info) == 0 {
+		return nil, nil
+	}
+	return &res.Info[0], nil
+}
+
+// SaveDraft validate and save the schema draft of the model
+func (m *modelSchema) SaveDraft(kit *rest.Kit, draft *metadata.ModelSchemaDraft) error {
+	detail, err := m.getSchemaDetail(kit, draft.ObjID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateChanges(kit, draft.ObjID, detail, draft.Changes); err != nil {
+		return err
+	}
+
+	if err := m.clientSet.CoreService().ModelSchema().SaveModelSchemaDraft(kit.Ctx, kit.Header, draft); err != nil {
+		blog.Errorf("save model schema draft failed, draft: %+v, err: %v, rid: %s", draft, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteDraft delete the schema draft of the model
+func (m *modelSchema) DeleteDraft(kit *rest.Kit, objID string) error {
+	opt := &metadata.DeleteOption{Condition: mapstr.MapStr{common.BKObjIDField: objID}}
+	if err := m.clientSet.CoreService().ModelSchema().DeleteModelSchemaDraft(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("delete model schema draft failed, obj: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// CheckDraft check the schema draft of the model against the existing instances
+func (m *modelSchema) CheckDraft(kit *rest.Kit, objID string) (*metadata.ModelSchemaCheckResult, error) {
+	draft, err := m.getNotEmptyDraft(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := m.getSchemaDetail(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.checkDraft(kit, detail, draft)
+}
+
+func (m *modelSchema) getNotEmptyDraft(kit *rest.Kit, objID string) (*metadata.ModelSchemaDraft, error) {
+	draft, err := m.GetDraft(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if draft == nil || len(draft.Changes) == 0 {
+		blog.Errorf("model %s has no schema draft changes, rid: %s", objID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommNotFound, "model schema draft")
+	}
+	return draft, nil
+}
+
+func (m *modelSchema) checkDraft(kit *rest.Kit, detail *schemaDetail, draft *metadata.ModelSchemaDraft) (
+	*metadata.ModelSchemaCheckResult, error) {
+
+	// the model may be changed after the draft is saved, so the draft is validated again.
+	if err := validateChanges(kit, draft.ObjID, detail, draft.Changes); err != nil {
+		return nil, err
+	}
+
+	res, err := m.clientSet.CoreService().ModelSchema().CheckModelSchemaDraft(kit.Ctx, kit.Header, draft)
+	if err != nil {
+		blog.Errorf("check model schema draft failed, draft: %+v, err: %v, rid: %s", draft, err, kit.Rid)
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkInstances check the draft changes against the existing instances, returns error if any instance conflicts
+func (m *modelSchema) checkInstances(kit *rest.Kit, detail *schemaDetail, draft *metadata.ModelSchemaDraft) error {
+	checkRes, err := m.checkDraft(kit, detail, draft)
+	if err != nil {
+		return err
+	}
+
+	if len(checkRes.Issues) > 0 {
+		issue := checkRes.Issues[0]
+		blog.Errorf("model schema draft conflicts with instances, issues: %+v, rid: %s", checkRes.Issues, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrTopoModelSchemaCheckFailed, string(issue.Kind)+" "+issue.Key,
+			issue.Reason)
+	}
+	return nil
+}
+
+// Publish apply the changes of the schema draft to the model and create a new schema version,
+// it should be called in transaction.
+func (m *modelSchema) Publish(kit *rest.Kit, objID string, opt *metadata.PublishModelSchemaOption) (
+	*metadata.ModelSchemaVersion, error) {
+
+	draft, err := m.getNotEmptyDraft(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := m.getSchemaDetail(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.checkInstances(kit, detail, draft); err != nil {
+		return nil, err
+	}
+
+	// save the schema before the first publish as the initial version, so that the model can be reverted to it.
+	latest, err := m.getLatestVersion(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest == nil {
+		initial := &metadata.ModelSchemaVersion{ObjID: objID, Schema: *detail.schema}
+		if _, err := m.clientSet.CoreService().ModelSchema().CreateModelSchemaVersion(kit.Ctx, kit.Header,
+			initial); err != nil {
+			blog.Errorf("create initial model schema version failed, obj: %s, err: %v, rid: %s", objID, err, kit.Rid)
+			return nil, err
+		}
+	}
+
+	if err := m.applyChanges(kit, objID, detail, draft.Changes); err != nil {
+		return nil, err
+	}
+
+	published, err := m.GetModelSchema(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &metadata.ModelSchemaVersion{ObjID: objID, Description: opt.Description, Schema: *published}
+	version, err = m.clientSet.CoreService().ModelSchema().CreateModelSchemaVersion(kit.Ctx, kit.Header, version)
+	if err != nil {
+		blog.Errorf("create model schema version failed, obj: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if err := m.DeleteDraft(kit, objID); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+func (m *modelSchema) getLatestVersion(kit *rest.Kit, objID string) (*metadata.ModelSchemaVersion, error) {
+	opt := &metadata.CommonQueryOption{
+		CommonFilterOption: metadata.CommonFilterOption{
+			Filter: filtertools.GenAtomFilter(common.BKObjIDField, filter.Equal, objID),
+		},
+		Page:   metadata.BasePage{Limit: 1, Sort: "-version"},
+		Fields: []string{common.BKFieldID, "version"},
+	}
+	res, err := m.clientSet.CoreService().ModelSchema().ListModelSchemaVersion(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list model schema version failed, obj: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(res.Info) == 0 {
+		return nil, nil
+	}
+	return &res.Info[0], nil
+}
+
+// GetVersion get the schema version of the model
+func (m *modelSchema) GetVersion(kit *rest.Kit, objID string, version int64) (*metadata.ModelSchemaVersion,
+	error) {
+
+	expr, err := filtertools.And(filtertools.GenAtomFilter(common.BKObjIDField, filter.Equal, objID),
+		filtertools.GenAtomFilter("version", filter.Equal, version))
+	if err != nil {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	opt := &metadata.CommonQueryOption{
+		CommonFilterOption: metadata.CommonFilterOption{Filter: expr},
+		Page:               metadata.BasePage{Limit: 1},
+	}
+	res, err := m.clientSet.CoreService().ModelSchema().ListModelSchemaVersion(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list model schema version failed, opt: %+v, err: %v, rid: %s", opt, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(res.Info) == 0 {
+		blog.Errorf("model %s schema version %d not exists, rid: %s", objID, version, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrTopoModelSchemaVersionNotExist, version)
+	}
+	return &res.Info[0], nil
+}
+
+// Diff get the changes between two schema versions of the model
+func (m *modelSchema) Diff(kit *rest.Kit, objID string, opt *metadata.DiffModelSchemaOption) (
+	[]metadata.ModelSchemaChange, error) {
+
+	base, err := m.getVersionSchema(kit, objID, opt.BaseVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := m.getVersionSchema(kit, objID, opt.TargetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(base, target), nil
+}
+
+// getVersionSchema get the schema of the model version, version 0 means the current model schema
+func (m *modelSchema) getVersionSchema(kit *rest.Kit, objID string, version int64) (*metadata.ModelSchema, error) {
+	if version == 0 {
+		return m.GetModelSchema(kit, objID)
+	}
+
+	res, err := m.GetVersion(kit, objID, version)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Schema, nil
+}
+
+// Revert stage the changes that revert the model to the schema version into the draft of the model, the previous
+// draft changes are overwritten, and the model is reverted after the draft is published.
+func (m *modelSchema) Revert(kit *rest.Kit, objID string, version int64) (*metadata.ModelSchemaDraft, error) {
+	target, err := m.GetVersion(kit, objID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := m.GetModelSchema(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &metadata.ModelSchemaDraft{ObjID: objID, Changes: Diff(current, &target.Schema)}
+	if err := m.SaveDraft(kit, draft); err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
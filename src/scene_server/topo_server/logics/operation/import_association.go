@@ -345,6 +345,7 @@ func (ia *importAssociation) checkExcelAssociationOperate(idx int, srcInstID, ds
 			ObjectAsstID: asstInfo.ObjectAsstID,
 			InstID:       srcInstID,
 			AsstInstID:   dstInstID,
+			Attributes:   asstInfo.Attributes,
 		}
 
 		if err = ia.cli.asst.CheckInstAsstMapping(ia.kit, ia.objID, asst.Mapping, input); err != nil {
@@ -352,7 +353,12 @@ func (ia *importAssociation) checkExcelAssociationOperate(idx int, srcInstID, ds
 			return false
 		}
 
-		ia.addSrcAssociation(idx, asst.AssociationName, srcInstID, dstInstID)
+		if rawErr := asst.ValidateAttrValues(ia.kit.Ctx, asstInfo.Attributes); rawErr.ErrCode != 0 {
+			ia.parseImportDataErr[idx] = rawErr.ToCCError(ia.kit.CCError).Error()
+			return false
+		}
+
+		ia.addSrcAssociation(idx, asst.AssociationName, srcInstID, dstInstID, asstInfo.Attributes)
 		return true
 
 	case metadata.ExcelAssociationOperateDelete:
@@ -699,7 +705,8 @@ func (ia *importAssociation) delSrcAssociation(idx int, objID string, cond mapst
 	}
 }
 
-func (ia *importAssociation) addSrcAssociation(idx int, asstFlag string, instID, assInstID int64) {
+func (ia *importAssociation) addSrcAssociation(idx int, asstFlag string, instID, assInstID int64,
+	attrs mapstr.MapStr) {
 
 	_, ok := ia.parseImportDataErr[idx]
 	if ok {
@@ -715,6 +722,7 @@ func (ia *importAssociation) addSrcAssociation(idx int, asstFlag string, instID,
 	inst.Data.AsstObjectID = asstInfo.AsstObjID
 	inst.Data.AsstInstID = assInstID
 	inst.Data.AssociationKindID = asstInfo.AsstKindID
+	inst.Data.Attributes = attrs
 	_, err := ia.cli.clientSet.CoreService().Association().CreateInstAssociation(ia.kit.Ctx, ia.kit.Header, &inst)
 	if err != nil {
 		ia.parseImportDataErr[idx] = err.Error()
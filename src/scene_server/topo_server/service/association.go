@@ -1207,6 +1207,42 @@ func (s *Service) DeleteAssociationInst(ctx *rest.Contexts) {
 	ctx.RespEntity(nil)
 }
 
+// UpdateAssociationInstAttrs update the attribute values of the instance association
+func (s *Service) UpdateAssociationInstAttrs(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if len(objID) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKObjIDField))
+		return
+	}
+
+	id, err := strconv.ParseInt(ctx.Request.PathParameter("association_id"), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedInt, "association_id"))
+		return
+	}
+
+	request := new(metadata.UpdateInstAsstAttrsRequest)
+	if err := ctx.DecodeInto(request); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := request.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		return s.Logics.InstAssociationOperation().UpdateInstAssociationAttrs(ctx.Kit, objID, id, request.Attributes)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
 // DeleteAssociationInstBatch batch delete instance association
 func (s *Service) DeleteAssociationInstBatch(ctx *rest.Contexts) {
 	request := &metadata.DeleteAssociationInstBatchRequest{}
@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	modelschema "configcenter/src/scene_server/topo_server/logics/model_schema"
+	"configcenter/src/storage/driver/redis"
+)
+
+// PlanModelDefinition get the changes that converge the cmdb to the model definition without applying them
+func (s *service) PlanModelDefinition(ctx *rest.Contexts) {
+	def := new(metadata.ModelDefinition)
+	if err := ctx.DecodeInto(def); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := def.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	plan, err := s.Logics.ModelSchemaOperation().PlanDefinition(ctx.Kit, def)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(plan)
+}
+
+// ApplyModelDefinition converge the cmdb to the model definition atomically, returns the applied changes
+func (s *service) ApplyModelDefinition(ctx *rest.Contexts) {
+	def := new(metadata.ModelDefinition)
+	if err := ctx.DecodeInto(def); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := def.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	plan, err := s.Logics.ModelSchemaOperation().PlanDefinition(ctx.Kit, def)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(plan.Changes) == 0 {
+		ctx.RespEntity(plan)
+		return
+	}
+
+	if err := s.authorizeDefinitionApply(ctx, plan); err != nil {
+		return
+	}
+
+	// create the tables of the new objects before the transaction, refer to Service.createObjectTable
+	newObjIDs := make([]string, 0)
+	for _, change := range plan.Changes {
+		if change.Kind == metadata.DefinitionObject && change.Op == metadata.SchemaChangeCreate {
+			newObjIDs = append(newObjIDs, change.Key)
+		}
+	}
+
+	if len(newObjIDs) > 0 {
+		input := &metadata.CreateModelTable{ObjectIDs: newObjIDs}
+		if err := s.ClientSet.CoreService().Model().CreateModelTables(ctx.Kit.Ctx, ctx.Kit.Header, input); err != nil {
+			blog.Errorf("create model tables failed, objIDs: %v, err: %v, rid: %s", newObjIDs, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	var result *modelschema.DefinitionApplyResult
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		result, err = s.Logics.ModelSchemaOperation().ApplyDefinition(ctx.Kit, def)
+		if err != nil {
+			return err
+		}
+
+		return s.registerDefinitionResources(ctx.Kit, result)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(result.Plan)
+}
+
+// registerDefinitionResources register the created classifications and objects to iam
+func (s *service) registerDefinitionResources(kit *rest.Kit, result *modelschema.DefinitionApplyResult) error {
+	if !auth.EnableAuthorize() {
+		return nil
+	}
+
+	for _, cls := range result.Classifications {
+		iamInstance := metadata.IamInstanceWithCreator{
+			Type:    string(iam.SysModelGroup),
+			ID:      strconv.FormatInt(cls.ID, 10),
+			Name:    cls.ClassificationName,
+			Creator: kit.User,
+		}
+		if _, err := s.AuthManager.Authorizer.RegisterResourceCreatorAction(kit.Ctx, kit.Header,
+			iamInstance); err != nil {
+			blog.Errorf("register created classification to iam failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+
+	if len(result.Objects) == 0 {
+		return nil
+	}
+
+	iamInstances := make([]metadata.IamInstanceWithCreator, 0)
+	for _, obj := range result.Objects {
+		iamInstances = append(iamInstances, metadata.IamInstanceWithCreator{
+			Type:    string(iam.SysModel),
+			ID:      strconv.FormatInt(obj.ID, 10),
+			Name:    obj.ObjectName,
+			Creator: kit.User,
+		})
+	}
+	if err := s.AuthManager.CreateObjectOnIAM(kit.Ctx, kit.Header, result.Objects, iamInstances,
+		redis.Client()); err != nil {
+		blog.Errorf("create objects on iam failed, iam instances: %v, err: %v, rid: %s", iamInstances, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// authorizeDefinitionApply applying model definition requires the permissions of all the planned changes,
+// the response is written if the authorization failed.
+func (s *service) authorizeDefinitionApply(ctx *rest.Contexts, plan *metadata.ModelDefinitionPlan) error {
+	resources := make([]meta.ResourceAttribute, 0)
+	createdObjs := make(map[string]struct{})
+	updatedCls, updatedKinds, updatedObjs := make([]string, 0), make([]string, 0), make([]string, 0)
+	for _, change := range plan.Changes {
+		switch change.Kind {
+		case metadata.DefinitionClassification:
+			if change.Op == metadata.SchemaChangeCreate {
+				resources = append(resources, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelClassification,
+					Action: meta.Create}})
+				continue
+			}
+			updatedCls = append(updatedCls, change.Key)
+		case metadata.DefinitionAssociationKind:
+			if change.Op == metadata.SchemaChangeCreate {
+				resources = append(resources, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.AssociationType,
+					Action: meta.Create}})
+				continue
+			}
+			updatedKinds = append(updatedKinds, change.Key)
+		case metadata.DefinitionObject:
+			if change.Op == metadata.SchemaChangeCreate {
+				createdObjs[change.Key] = struct{}{}
+				resources = append(resources, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model,
+					Action: meta.Create}})
+				continue
+			}
+			updatedObjs = append(updatedObjs, change.Key)
+		default:
+			// the schema changes of the created objects are authorized by the object creation
+			if _, exists := createdObjs[change.ObjID]; !exists {
+				updatedObjs = append(updatedObjs, change.ObjID)
+			}
+		}
+	}
+
+	if len(updatedCls) > 0 {
+		cond := &metadata.QueryCondition{
+			Fields:    []string{common.BKFieldID},
+			Condition: mapstr.MapStr{common.BKClassificationIDField: mapstr.MapStr{common.BKDBIN: updatedCls}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		res, err := s.ClientSet.CoreService().Model().ReadModelClassification(ctx.Kit.Ctx, ctx.Kit.Header, cond)
+		if err != nil {
+			blog.Errorf("read classifications failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return err
+		}
+
+		for _, cls := range res.Info {
+			resources = append(resources, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelClassification,
+				Action: meta.Update, InstanceID: cls.ID}})
+		}
+	}
+
+	if len(updatedKinds) > 0 {
+		cond := &metadata.QueryCondition{
+			Fields:    []string{common.BKFieldID},
+			Condition: mapstr.MapStr{common.AssociationKindIDField: mapstr.MapStr{common.BKDBIN: updatedKinds}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		res, err := s.ClientSet.CoreService().Association().ReadAssociationType(ctx.Kit.Ctx, ctx.Kit.Header, cond)
+		if err != nil {
+			blog.Errorf("read association kinds failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return err
+		}
+
+		for _, kind := range res.Info {
+			resources = append(resources, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.AssociationType,
+				Action: meta.Update, InstanceID: kind.ID}})
+		}
+	}
+
+	if len(updatedObjs) > 0 {
+		cond := &metadata.QueryCondition{
+			Fields:    []string{common.BKFieldID},
+			Condition: mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: updatedObjs}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		res, err := s.ClientSet.CoreService().Model().ReadModel(ctx.Kit.Ctx, ctx.Kit.Header, cond)
+		if err != nil {
+			blog.Errorf("read models failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return err
+		}
+
+		for _, obj := range res.Info {
+			resources = append(resources, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model,
+				Action: meta.Update, InstanceID: obj.ID}})
+		}
+	}
+
+	authResp, authorized := s.AuthManager.Authorize(ctx.Kit, resources...)
+	if !authorized {
+		ctx.RespNoAuth(authResp)
+		return ctx.Kit.CCError.CCError(common.CCErrCommAuthNotHavePermission)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"strconv"
+
+	"configcenter/pkg/filter"
+	filtertools "configcenter/pkg/tools/filter"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// FindModelSchemaDraft find the schema draft of the model, returns nil if the model has no draft
+func (s *service) FindModelSchemaDraft(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	draft, err := s.Logics.ModelSchemaOperation().GetDraft(ctx.Kit, objID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(draft)
+}
+
+// UpdateModelSchemaDraft update the changes of the model schema draft, the draft is created if not exists
+func (s *service) UpdateModelSchemaDraft(ctx *rest.Contexts) {
+	draft := new(metadata.ModelSchemaDraft)
+	if err := ctx.DecodeInto(draft); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	draft.ObjID = ctx.Request.PathParameter(common.BKObjIDField)
+
+	if rawErr := draft.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.authorizeModelUpdate(ctx, draft.ObjID); err != nil {
+		return
+	}
+
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		return s.Logics.ModelSchemaOperation().SaveDraft(ctx.Kit, draft)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteModelSchemaDraft discard the schema draft of the model
+func (s *service) DeleteModelSchemaDraft(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if err := s.authorizeModelUpdate(ctx, objID); err != nil {
+		return
+	}
+
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		return s.Logics.ModelSchemaOperation().DeleteDraft(ctx.Kit, objID)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// CheckModelSchemaDraft check the schema draft of the model against the existing instances
+func (s *service) CheckModelSchemaDraft(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	res, err := s.Logics.ModelSchemaOperation().CheckDraft(ctx.Kit, objID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// PublishModelSchemaDraft apply the schema draft to the model atomically and create a new schema version
+func (s *service) PublishModelSchemaDraft(ctx *rest.Contexts) {
+	opt := new(metadata.PublishModelSchemaOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if err := s.authorizeModelUpdate(ctx, objID); err != nil {
+		return
+	}
+
+	var version *metadata.ModelSchemaVersion
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		version, err = s.Logics.ModelSchemaOperation().Publish(ctx.Kit, objID, opt)
+		return err
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(version)
+}
+
+// ListModelSchemaVersion list the schema versions of the model
+func (s *service) ListModelSchemaVersion(ctx *rest.Contexts) {
+	opt := new(metadata.CommonQueryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	expr, err := filtertools.And(filtertools.GenAtomFilter(common.BKObjIDField, filter.Equal, objID), opt.Filter)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+	opt.Filter = expr
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-version"
+	}
+
+	res, err := s.ClientSet.CoreService().ModelSchema().ListModelSchemaVersion(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list model schema version failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// FindModelSchemaVersion find the schema version of the model
+func (s *service) FindModelSchemaVersion(ctx *rest.Contexts) {
+	version, err := parseVersion(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	res, err := s.Logics.ModelSchemaOperation().GetVersion(ctx.Kit, objID, version)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// DiffModelSchemaVersion get the changes from the base schema version to the target schema version of the model
+func (s *service) DiffModelSchemaVersion(ctx *rest.Contexts) {
+	opt := new(metadata.DiffModelSchemaOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	changes, err := s.Logics.ModelSchemaOperation().Diff(ctx.Kit, objID, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(metadata.ModelSchemaDiffResult{Changes: changes})
+}
+
+// RevertModelSchemaVersion stage the changes that revert the model to the schema version into the model draft
+func (s *service) RevertModelSchemaVersion(ctx *rest.Contexts) {
+	version, err := parseVersion(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if err := s.authorizeModelUpdate(ctx, objID); err != nil {
+		return
+	}
+
+	var draft *metadata.ModelSchemaDraft
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		draft, err = s.Logics.ModelSchemaOperation().Revert(ctx.Kit, objID, version)
+		return err
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(draft)
+}
+
+func parseVersion(ctx *rest.Contexts) (int64, error) {
+	versionStr := ctx.Request.PathParameter("version")
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil || version <= 0 {
+		blog.Errorf("parse model schema version(%s) failed, err: %v, rid: %s", versionStr, err, ctx.Kit.Rid)
+		return 0, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedInt, "version")
+	}
+	return version, nil
+}
+
+// authorizeModelUpdate model schema changes require the model's update permission,
+// the response is written if the authorization failed.
+func (s *service) authorizeModelUpdate(ctx *rest.Contexts, objID string) error {
+	cond := &metadata.QueryCondition{
+		Fields:    []string{common.BKFieldID},
+		Condition: mapstr.MapStr{common.BKObjIDField: objID},
+	}
+	models, err := s.ClientSet.CoreService().Model().ReadModel(ctx.Kit.Ctx, ctx.Kit.Header, cond)
+	if err != nil {
+		blog.Errorf("read model failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return err
+	}
+	if len(models.Info) == 0 {
+		blog.Errorf("model %s not exists, rid: %s", objID, ctx.Kit.Rid)
+		err := ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+		ctx.RespAutoError(err)
+		return err
+	}
+
+	authResp, authorized := s.AuthManager.Authorize(ctx.Kit, meta.ResourceAttribute{Basic: meta.Basic{
+		Type: meta.Model, Action: meta.Update, InstanceID: models.Info[0].ID}})
+	if !authorized {
+		ctx.RespNoAuth(authResp)
+		return ctx.Kit.CCError.CCError(common.CCErrCommAuthNotHavePermission)
+	}
+	return nil
+}
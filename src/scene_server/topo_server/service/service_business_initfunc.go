@@ -198,6 +198,8 @@ func (s *Service) initBusinessAssociation(web *restful.WebService) {
 		Handler: s.CreateManyInstAssociation})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete,
 		Path: "/delete/instassociation/{bk_obj_id}/{association_id}", Handler: s.DeleteAssociationInst})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path: "/update/instassociation/{bk_obj_id}/{association_id}", Handler: s.UpdateAssociationInstAttrs})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/instassociation/batch",
 		Handler: s.DeleteAssociationInstBatch})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/search/instance_associations/object/{bk_obj_id}",
@@ -20,6 +20,8 @@ import (
 	fieldtmpl "configcenter/src/scene_server/topo_server/service/field_template"
 	"configcenter/src/scene_server/topo_server/service/id_rule"
 	"configcenter/src/scene_server/topo_server/service/kube"
+	modelschema "configcenter/src/scene_server/topo_server/service/model_schema"
+	validationrule "configcenter/src/scene_server/topo_server/service/validation_rule"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -341,5 +343,9 @@ func (s *Service) initService(web *restful.WebService) {
 
 	idrule.InitIDRule(utility, c)
 
+	validationrule.InitValidationRule(utility, c)
+
+	modelschema.InitModelSchema(utility, c)
+
 	utility.AddToRestfulWebService(web)
 }
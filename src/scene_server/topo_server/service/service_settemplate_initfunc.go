@@ -81,5 +81,17 @@ func (s *Service) initSetTemplate(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/topo/set_template/attribute",
 		Handler: s.ListSetTemplateAttribute})
 
+	// set template version
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/topo/set_template/version",
+		Handler: s.CreateSetTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/topo/set_template/version",
+		Handler: s.ListSetTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/topo/set_template/version/difference",
+		Handler: s.DiffSetTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/topo/set_template/version/rollback",
+		Handler: s.RollbackSetTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/topo/set_template/version/pin",
+		Handler: s.ListSetTemplateVersionPin})
+
 	utility.AddToRestfulWebService(web)
 }
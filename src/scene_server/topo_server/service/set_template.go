@@ -908,7 +908,9 @@ func (s *Service) SyncSetTplToInst(ctx *rest.Contexts) {
 				"option: %+v err: %s, rid: %s", bizID, setTemplateID, option, err.Error(), ctx.Kit.Rid)
 			return err
 		}
-		return nil
+
+		// record the set template version that the sets are synchronized to
+		return s.pinSetTemplateVersion(ctx.Kit, bizID, setTemplateID, option.SetIDs)
 	})
 
 	if txnErr != nil {
@@ -0,0 +1,302 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// CreateSetTemplateVersion create an immutable version of the current set template content, including the
+// service templates and the attributes
+func (s *Service) CreateSetTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.CreateTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	var version *metadata.TemplateVersionInfo
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		version, err = s.createSetTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.Description, 0)
+		return err
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(version)
+}
+
+func (s *Service) createSetTemplateVersion(kit *rest.Kit, bizID, id int64, description string,
+	rollbackFrom int64) (*metadata.TemplateVersionInfo, errors.CCErrorCoder) {
+
+	allInfo, err := s.getSetTemplateAllInfo(kit, id, bizID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &metadata.TemplateVersionInfo{
+		BizID:        bizID,
+		TemplateType: metadata.SetTemplateVersion,
+		TemplateID:   id,
+		Content:      *setTemplateVersionContent(allInfo),
+		Description:  description,
+		RollbackFrom: rollbackFrom,
+	}
+	version, err = s.Engine.CoreAPI.CoreService().Process().CreateTemplateVersion(kit.Ctx, kit.Header, version)
+	if err != nil {
+		blog.Errorf("create set template %d version failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// setTemplateVersionContent convert the set template all info to the version content
+func setTemplateVersionContent(allInfo *metadata.SetTempAllInfo) *metadata.TemplateVersionContent {
+	return &metadata.TemplateVersionContent{
+		Name:               allInfo.Name,
+		ServiceTemplateIDs: allInfo.ServiceTemplateIDs,
+		SetTemplateAttrs:   allInfo.Attributes,
+	}
+}
+
+// ListSetTemplateVersion list the versions of the set template
+func (s *Service) ListSetTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.ListTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.TemplateType = metadata.SetTemplateVersion
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Process().ListTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list set template versions failed, opt: %#v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// getSetTemplateVersion get the version of the set template
+func (s *Service) getSetTemplateVersion(kit *rest.Kit, bizID, id, version int64) (*metadata.TemplateVersionInfo,
+	errors.CCErrorCoder) {
+
+	opt := &metadata.ListTemplateVersionOption{
+		BizID:        bizID,
+		TemplateType: metadata.SetTemplateVersion,
+		TemplateID:   id,
+		Versions:     []int64{version},
+		Page:         metadata.BasePage{Limit: 1},
+	}
+	result, err := s.Engine.CoreAPI.CoreService().Process().ListTemplateVersion(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("get set template %d version %d failed, err: %v, rid: %s", id, version, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(result.Info) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrProcTemplateVersionNotExist, id, version)
+	}
+
+	return &result.Info[0], nil
+}
+
+// DiffSetTemplateVersion diff two versions of the set template, or diff the version with the current set template
+// content if the version to compare with is not set
+func (s *Service) DiffSetTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.DiffTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	from, err := s.getSetTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.FromVersion)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var toContent *metadata.TemplateVersionContent
+	if opt.ToVersion > 0 {
+		to, err := s.getSetTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.ToVersion)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		toContent = &to.Content
+	} else {
+		allInfo, err := s.getSetTemplateAllInfo(ctx.Kit, opt.TemplateID, opt.BizID)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		toContent = setTemplateVersionContent(allInfo)
+	}
+
+	diff := metadata.DiffTemplateVersionContent(&from.Content, toContent)
+	diff.FromVersion = opt.FromVersion
+	diff.ToVersion = opt.ToVersion
+	ctx.RespEntity(diff)
+}
+
+// RollbackSetTemplateVersion rollback the set template content to the version, then create a new version for the
+// rolled back content. the sets are not synchronized until they are synchronized by the user
+func (s *Service) RollbackSetTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.RollbackTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	version, err := s.getSetTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, opt.Version)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	allInfo, err := s.getSetTemplateAllInfo(ctx.Kit, opt.TemplateID, opt.BizID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var newVersion *metadata.TemplateVersionInfo
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		target := version.Content
+		updateOpt := metadata.UpdateSetTemplateOption{
+			Name:               target.Name,
+			ServiceTemplateIDs: target.ServiceTemplateIDs,
+		}
+		_, err := s.Engine.CoreAPI.CoreService().SetTemplate().UpdateSetTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+			opt.BizID, opt.TemplateID, updateOpt)
+		if err != nil {
+			blog.Errorf("rollback set template %d failed, opt: %+v, err: %v, rid: %s", opt.TemplateID, updateOpt,
+				err, ctx.Kit.Rid)
+			return err
+		}
+
+		attrs := make([]metadata.SetTempAttr, len(target.SetTemplateAttrs))
+		for idx, attr := range target.SetTemplateAttrs {
+			attrs[idx] = metadata.SetTempAttr{AttributeID: attr.AttributeID, PropertyValue: attr.PropertyValue}
+		}
+		if err := s.updateSetTempAllAttrs(ctx.Kit, opt.TemplateID, opt.BizID, allInfo.Attributes,
+			attrs); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("rollback to version %d", opt.Version)
+		var ccErr errors.CCErrorCoder
+		newVersion, ccErr = s.createSetTemplateVersion(ctx.Kit, opt.BizID, opt.TemplateID, description, opt.Version)
+		if ccErr != nil {
+			return ccErr
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(newVersion)
+}
+
+// ListSetTemplateVersionPin list the set template versions that the sets are synchronized to
+func (s *Service) ListSetTemplateVersionPin(ctx *rest.Contexts) {
+	opt := new(metadata.ListTemplateVersionPinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.TemplateType = metadata.SetTemplateVersion
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	pins, err := s.Engine.CoreAPI.CoreService().Process().ListTemplateVersionPin(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list set template version pins failed, opt: %#v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(pins)
+}
+
+// pinSetTemplateVersion pin the synchronized sets to the latest version of the set template, the sets are not
+// pinned if the set template has no version
+func (s *Service) pinSetTemplateVersion(kit *rest.Kit, bizID, id int64, setIDs []int64) error {
+	listOpt := &metadata.ListTemplateVersionOption{
+		BizID:        bizID,
+		TemplateType: metadata.SetTemplateVersion,
+		TemplateID:   id,
+		Fields:       []string{"version"},
+		Page:         metadata.BasePage{Limit: 1, Sort: "-version"},
+	}
+	result, err := s.Engine.CoreAPI.CoreService().Process().ListTemplateVersion(kit.Ctx, kit.Header, listOpt)
+	if err != nil {
+		blog.Errorf("get set template %d latest version failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+
+	if len(result.Info) == 0 {
+		return nil
+	}
+
+	pinOpt := &metadata.PinTemplateVersionOption{
+		BizID:        bizID,
+		TemplateType: metadata.SetTemplateVersion,
+		TemplateID:   id,
+		InstIDs:      setIDs,
+		Version:      result.Info[0].Version,
+	}
+	if err := s.Engine.CoreAPI.CoreService().Process().PinTemplateVersion(kit.Ctx, kit.Header, pinOpt); err != nil {
+		blog.Errorf("pin sets %v to set template %d version %d failed, err: %v, rid: %s", setIDs, id,
+			pinOpt.Version, err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
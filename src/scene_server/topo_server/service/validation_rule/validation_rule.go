@@ -0,0 +1,262 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package validationrule
+
+import (
+	"strconv"
+
+	"configcenter/pkg/filter"
+	filtertools "configcenter/pkg/tools/filter"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// ListValidationRule list validation rules of the model
+func (s *service) ListValidationRule(ctx *rest.Contexts) {
+	opt := new(metadata.CommonQueryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	expr, err := filtertools.And(filtertools.GenAtomFilter(common.BKObjIDField, filter.Equal, objID), opt.Filter)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+	opt.Filter = expr
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	res, err := s.ClientSet.CoreService().ValidationRule().ListValidationRule(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list validation rule failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// CreateValidationRule create validation rule for the model
+func (s *service) CreateValidationRule(ctx *rest.Contexts) {
+	rule := new(metadata.ValidationRule)
+	if err := ctx.DecodeInto(rule); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	rule.ObjID = ctx.Request.PathParameter(common.BKObjIDField)
+
+	if rawErr := rule.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.authorizeModelUpdate(ctx, rule.ObjID); err != nil {
+		return
+	}
+
+	result := new(metadata.RspID)
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		rsp, err := s.ClientSet.CoreService().ValidationRule().CreateValidationRule(ctx.Kit.Ctx, ctx.Kit.Header,
+			rule)
+		if err != nil {
+			blog.Errorf("create validation rule failed, rule: %+v, err: %v, rid: %s", rule, err, ctx.Kit.Rid)
+			return err
+		}
+		result.ID = rsp.ID
+		rule.ID = rsp.ID
+
+		audit := auditlog.NewValidationRuleAuditLog(s.ClientSet.CoreService())
+		parameter := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditCreate)
+		auditLog := audit.GenerateAuditLog(parameter, rule)
+		if err := audit.SaveAuditLog(ctx.Kit, *auditLog); err != nil {
+			blog.Errorf("save validation rule audit log failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// UpdateValidationRule update validation rule of the model
+func (s *service) UpdateValidationRule(ctx *rest.Contexts) {
+	rule := new(metadata.ValidationRule)
+	if err := ctx.DecodeInto(rule); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.parseRuleID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	rule.ID = id
+	rule.ObjID = ctx.Request.PathParameter(common.BKObjIDField)
+
+	if rawErr := rule.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.authorizeModelUpdate(ctx, rule.ObjID); err != nil {
+		return
+	}
+
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		err := s.ClientSet.CoreService().ValidationRule().UpdateValidationRule(ctx.Kit.Ctx, ctx.Kit.Header, rule)
+		if err != nil {
+			blog.Errorf("update validation rule failed, rule: %+v, err: %v, rid: %s", rule, err, ctx.Kit.Rid)
+			return err
+		}
+
+		audit := auditlog.NewValidationRuleAuditLog(s.ClientSet.CoreService())
+		parameter := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditUpdate)
+		auditLog := audit.GenerateAuditLog(parameter, rule)
+		if err := audit.SaveAuditLog(ctx.Kit, *auditLog); err != nil {
+			blog.Errorf("save validation rule audit log failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteValidationRule delete validation rule of the model
+func (s *service) DeleteValidationRule(ctx *rest.Contexts) {
+	id, err := s.parseRuleID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	if err := s.authorizeModelUpdate(ctx, objID); err != nil {
+		return
+	}
+
+	expr, rawErr := filtertools.And(filtertools.GenAtomFilter(common.BKFieldID, filter.Equal, id),
+		filtertools.GenAtomFilter(common.BKObjIDField, filter.Equal, objID))
+	if rawErr != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, rawErr.Error()))
+		return
+	}
+	listOpt := &metadata.CommonQueryOption{
+		CommonFilterOption: metadata.CommonFilterOption{Filter: expr},
+		Page:               metadata.BasePage{Limit: 1},
+	}
+	res, err := s.ClientSet.CoreService().ValidationRule().ListValidationRule(ctx.Kit.Ctx, ctx.Kit.Header, listOpt)
+	if err != nil {
+		blog.Errorf("list validation rule failed, opt: %+v, err: %v, rid: %s", listOpt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(res.Info) == 0 {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		opt := &metadata.DeleteOption{
+			Condition: mapstr.MapStr{common.BKFieldID: id, common.BKObjIDField: objID},
+		}
+		err := s.ClientSet.CoreService().ValidationRule().DeleteValidationRule(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+		if err != nil {
+			blog.Errorf("delete validation rule failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+			return err
+		}
+
+		audit := auditlog.NewValidationRuleAuditLog(s.ClientSet.CoreService())
+		parameter := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditDelete)
+		auditLog := audit.GenerateAuditLog(parameter, &res.Info[0])
+		if err := audit.SaveAuditLog(ctx.Kit, *auditLog); err != nil {
+			blog.Errorf("save validation rule audit log failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (s *service) parseRuleID(ctx *rest.Contexts) (int64, errors.CCErrorCoder) {
+	idStr := ctx.Request.PathParameter(common.BKFieldID)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("parse validation rule id(%s) failed, err: %v, rid: %s", idStr, err, ctx.Kit.Rid)
+		return 0, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedInt, common.BKFieldID)
+	}
+	return id, nil
+}
+
+// authorizeModelUpdate validation rule is a part of the model, so it requires the model's update permission,
+// the response is written if the authorization failed.
+func (s *service) authorizeModelUpdate(ctx *rest.Contexts, objID string) error {
+	cond := &metadata.QueryCondition{
+		Fields:    []string{common.BKFieldID},
+		Condition: mapstr.MapStr{common.BKObjIDField: objID},
+	}
+	models, err := s.ClientSet.CoreService().Model().ReadModel(ctx.Kit.Ctx, ctx.Kit.Header, cond)
+	if err != nil {
+		blog.Errorf("read model failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return err
+	}
+	if len(models.Info) == 0 {
+		blog.Errorf("model %s not exists, rid: %s", objID, ctx.Kit.Rid)
+		err := ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+		ctx.RespAutoError(err)
+		return err
+	}
+
+	authResp, authorized := s.AuthManager.Authorize(ctx.Kit, meta.ResourceAttribute{Basic: meta.Basic{
+		Type: meta.Model, Action: meta.Update, InstanceID: models.Info[0].ID}})
+	if !authorized {
+		ctx.RespNoAuth(authResp)
+		return ctx.Kit.CCError.CCError(common.CCErrCommAuthNotHavePermission)
+	}
+	return nil
+}
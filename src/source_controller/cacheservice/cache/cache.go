@@ -23,6 +23,7 @@ import (
 	"configcenter/src/source_controller/cacheservice/cache/mainline"
 	"configcenter/src/source_controller/cacheservice/cache/topology"
 	"configcenter/src/source_controller/cacheservice/cache/topotree"
+	"configcenter/src/source_controller/cacheservice/event/archive"
 	"configcenter/src/source_controller/cacheservice/event/watch"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
@@ -33,7 +34,7 @@ import (
 
 // NewCache new cache service
 func NewCache(reflector reflector.Interface, loopW stream.LoopInterface, isMaster discovery.ServiceManageInterface,
-	watchDB dal.DB) (*ClientSet, error) {
+	watchDB dal.DB, eventArchive *archive.Archive) (*ClientSet, error) {
 
 	if err := mainline.NewMainlineCache(loopW); err != nil {
 		return nil, fmt.Errorf("new business cache failed, err: %v", err)
@@ -56,7 +57,7 @@ func NewCache(reflector reflector.Interface, loopW stream.LoopInterface, isMaste
 		return nil, fmt.Errorf("new common topo cache failed, err: %v", err)
 	}
 
-	watchCli := watch.NewClient(watchDB, mongodb.Client(), redis.Client())
+	watchCli := watch.NewClient(watchDB, mongodb.Client(), redis.Client(), eventArchive)
 
 	generalCache, err := general.New(isMaster, loopW, watchCli)
 	if err != nil {
@@ -38,6 +38,16 @@ func init() {
 	addCache(newMapStrCacheWithID(general.ModuleKey, false, common.BKTableNameBaseModule, common.BKModuleIDField))
 	addCache(newMapStrCacheWithID(general.BizSetKey, false, common.BKTableNameBaseBizSet, common.BKBizSetIDField))
 	addCache(newMapStrCacheWithID(general.PlatKey, false, common.BKTableNameBasePlat, common.BKCloudIDField))
+	addCache(newMapStrCacheWithID(general.ModelKey, false, common.BKTableNameObjDes, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.ModelAttributeKey, false, common.BKTableNameObjAttDes, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.ModelAttrGroupKey, false, common.BKTableNamePropertyGroup, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.ModelUniqueKey, false, common.BKTableNameObjUnique, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.ModelAssociationKey, false, common.BKTableNameObjAsst, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.ServiceTemplateKey, false, common.BKTableNameServiceTemplate,
+		common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.SetTemplateKey, false, common.BKTableNameSetTemplate, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.FieldTemplateKey, false, common.BKTableNameFieldTemplate, common.BKFieldID))
+	addCache(newMapStrCacheWithID(general.HostApplyRuleKey, false, common.BKTableNameHostApplyRule, common.BKFieldID))
 }
 
 // newMapStrCacheWithID new general cache whose data is of mapstr type and uses id as id key
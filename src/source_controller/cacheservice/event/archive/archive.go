@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package archive stores the watch events durably in mongodb, so that the events whose cursors are expired in the
+// event chain can still be watched from the archive.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/stream/types"
+)
+
+// Event is an archived watch event, it contains the event chain node and the compressed detail of the event
+type Event struct {
+	// Coll is the collection of the event key, used to distinguish the events of different resources
+	Coll            string `bson:"coll"`
+	watch.ChainNode `bson:",inline"`
+	// Detail is the gzip compressed resource detail of the event
+	Detail []byte `bson:"detail"`
+	// ChangedFields is the updated and removed fields of the update event
+	ChangedFields []string `bson:"changed_fields,omitempty"`
+	// ExpireAt is the time when the archived event is removed by the ttl index
+	ExpireAt time.Time `bson:"expire_at"`
+}
+
+// GetDetail returns the decompressed resource detail of the archived event
+func (e *Event) GetDetail() ([]byte, error) {
+	if len(e.Detail) == 0 {
+		return e.Detail, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(e.Detail))
+	if err != nil {
+		return nil, fmt.Errorf("new gzip reader failed, err: %v", err)
+	}
+	defer reader.Close()
+
+	detail, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("decompress event detail failed, err: %v", err)
+	}
+	return detail, nil
+}
+
+// Archive is the event archive client
+type Archive struct {
+	// db is the event watch database that the event chain nodes are stored in, so that the events can be archived
+	// in the same transaction with the chain nodes.
+	db   dal.DB
+	conf *Config
+}
+
+// New new event archive client
+func New(db dal.DB, conf *Config) *Archive {
+	return &Archive{db: db, conf: conf}
+}
+
+// Enabled returns if the event archive is enabled
+func (a *Archive) Enabled() bool {
+	return a != nil && a.conf != nil && a.conf.Enabled
+}
+
+// NewEvent generates the archived event by the event chain node, the event info and the resource detail
+func (a *Archive) NewEvent(key event.Key, node *watch.ChainNode, eventInfo []byte, detail []byte) (*Event, error) {
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(detail); err != nil {
+		return nil, fmt.Errorf("compress event detail failed, err: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("compress event detail failed, err: %v", err)
+	}
+
+	e := &Event{
+		Coll:      key.Collection(),
+		ChainNode: *node,
+		Detail:    buf.Bytes(),
+		ExpireAt:  time.Now().Add(a.conf.TTL()),
+	}
+	// the resume token is useless for the archived event
+	e.Token = ""
+
+	if node.EventType != watch.Update || len(eventInfo) == 0 {
+		return e, nil
+	}
+
+	info := new(types.EventInfo)
+	if err := json.Unmarshal(eventInfo, info); err != nil {
+		return nil, fmt.Errorf("unmarshal event info %s failed, err: %v", eventInfo, err)
+	}
+
+	for field := range info.UpdatedFields {
+		e.ChangedFields = append(e.ChangedFields, field)
+	}
+	e.ChangedFields = append(e.ChangedFields, info.RemovedFields...)
+	return e, nil
+}
+
+// Insert the archived events, ctx can be a session context so that the events are archived with the chain nodes
+func (a *Archive) Insert(ctx context.Context, events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return a.db.Table(common.BKTableNameWatchEventArchive).Insert(ctx, events)
+}
+
+// GetEvent get the archived event by cursor without its detail
+func (a *Archive) GetEvent(ctx context.Context, key event.Key, cursor string, rid string) (*Event, bool, error) {
+	filter := map[string]interface{}{
+		"coll":               key.Collection(),
+		common.BKCursorField: cursor,
+	}
+
+	e := new(Event)
+	err := a.db.Table(common.BKTableNameWatchEventArchive).Find(filter).Fields(common.BKFieldID,
+		common.BKCursorField).One(ctx, e)
+	if err != nil {
+		if a.db.IsNotFoundError(err) {
+			return nil, false, nil
+		}
+		blog.Errorf("get archived event by cursor %s failed, err: %v, rid: %s", cursor, err, rid)
+		return nil, false, err
+	}
+	return e, true, nil
+}
+
+// SearchOption is the option to search the archived events
+type SearchOption struct {
+	Key event.Key
+	// ID is the id of the event to search after, the event itself is excluded
+	ID           uint64
+	Limit        uint64
+	Types        []watch.EventType
+	SubResource  string
+	SubResources []string
+}
+
+// SearchFollowingEvents search the archived events after the event with the specified id in order
+func (a *Archive) SearchFollowingEvents(ctx context.Context, opt *SearchOption, rid string) ([]*Event, error) {
+	filter := map[string]interface{}{
+		"coll":           opt.Key.Collection(),
+		common.BKFieldID: map[string]interface{}{common.BKDBGT: opt.ID},
+	}
+
+	if len(opt.Types) > 0 {
+		filter[common.BKEventTypeField] = map[string]interface{}{common.BKDBIN: opt.Types}
+	}
+
+	if len(opt.SubResource) > 0 {
+		filter[common.BKSubResourceField] = opt.SubResource
+	}
+
+	if len(opt.SubResources) > 0 {
+		filter[common.BKSubResourceField] = map[string]interface{}{common.BKDBIN: opt.SubResources}
+	}
+
+	events := make([]*Event, 0)
+	err := a.db.Table(common.BKTableNameWatchEventArchive).Find(filter).Sort(common.BKFieldID).Limit(opt.Limit).
+		All(ctx, &events)
+	if err != nil {
+		blog.Errorf("search archived events failed, err: %v, filter: %+v, rid: %s", err, filter, rid)
+		return nil, err
+	}
+	return events, nil
+}
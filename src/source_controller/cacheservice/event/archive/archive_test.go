@@ -0,0 +1,52 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	"sort"
+	"testing"
+
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+)
+
+func TestNewEvent(t *testing.T) {
+	a := New(nil, &Config{Enabled: true, TTLDays: defaultTTLDays})
+
+	node := &watch.ChainNode{ID: 1, Cursor: "cursor", EventType: watch.Update, Token: "token"}
+	detail := []byte(`{"id":1,"name":"test"}`)
+	eventInfo := []byte(`{"update_fields":{"name":"test"},"deleted_fields":["desc"]}`)
+
+	e, err := a.NewEvent(event.ModelKey, node, eventInfo, detail)
+	if err != nil {
+		t.Fatalf("new archived event failed, err: %v", err)
+	}
+
+	if e.Coll != event.ModelKey.Collection() || e.Cursor != node.Cursor || e.Token != "" {
+		t.Fatalf("archived event %+v is invalid", e)
+	}
+
+	sort.Strings(e.ChangedFields)
+	if len(e.ChangedFields) != 2 || e.ChangedFields[0] != "desc" || e.ChangedFields[1] != "name" {
+		t.Fatalf("archived event changed fields %v is invalid", e.ChangedFields)
+	}
+
+	decompressed, err := e.GetDetail()
+	if err != nil {
+		t.Fatalf("get archived event detail failed, err: %v", err)
+	}
+
+	if string(decompressed) != string(detail) {
+		t.Fatalf("archived event detail %s is not equal to %s", decompressed, detail)
+	}
+}
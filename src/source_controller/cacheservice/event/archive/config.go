@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	"fmt"
+	"time"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+)
+
+const (
+	// defaultTTLDays is the default days that the archived events are kept
+	defaultTTLDays = 7
+	// maxTTLDays is the max days that the archived events can be kept
+	maxTTLDays = 90
+)
+
+// Config is the event archive config
+type Config struct {
+	// Enabled defines if the events are archived and expired cursors are served from the archive
+	Enabled bool
+	// TTLDays is the days that the archived events are kept
+	TTLDays int
+}
+
+// TTL returns the duration that the archived events are kept
+func (c *Config) TTL() time.Duration {
+	return time.Duration(c.TTLDays) * 24 * time.Hour
+}
+
+// ParseConfig parse event archive config
+func ParseConfig() (*Config, error) {
+	conf := &Config{TTLDays: defaultTTLDays}
+
+	if !cc.IsExist("cacheService.eventArchive.enabled") {
+		return conf, nil
+	}
+
+	enabled, err := cc.Bool("cacheService.eventArchive.enabled")
+	if err != nil {
+		blog.Errorf("get cacheService.eventArchive.enabled error, err: %v", err)
+		return nil, err
+	}
+	conf.Enabled = enabled
+
+	if cc.IsExist("cacheService.eventArchive.ttlDays") {
+		conf.TTLDays, err = cc.Int("cacheService.eventArchive.ttlDays")
+		if err != nil {
+			blog.Errorf("get cacheService.eventArchive.ttlDays error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	if conf.TTLDays <= 0 || conf.TTLDays > maxTTLDays {
+		return nil, fmt.Errorf("cacheService.eventArchive.ttlDays %d is invalid, should be in range [1, %d]",
+			conf.TTLDays, maxTTLDays)
+	}
+
+	return conf, nil
+}
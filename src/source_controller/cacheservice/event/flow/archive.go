@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flow
+
+import (
+	"context"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/source_controller/cacheservice/event/archive"
+)
+
+// addArchiveEvent generates the archived event of the chain node and adds it to the events map keyed by cursor, the
+// later event with the same cursor overwrites the former one, which is the same with the chain nodes.
+func (f *Flow) addArchiveEvent(events map[string]*archive.Event, key event.Key, node *watch.ChainNode,
+	detail *eventDetail, rid string) {
+
+	if !f.archive.Enabled() {
+		return
+	}
+
+	e, err := f.archive.NewEvent(key, node, detail.eventInfo, detail.resDetail)
+	if err != nil {
+		blog.Errorf("generate %s archived event failed, skip it, err: %v, cursor: %s, rid: %s", key.Collection(), err,
+			node.Cursor, rid)
+		return
+	}
+	events[node.Cursor] = e
+}
+
+// archiveEvents archives the events of the chain nodes, it is called in the transaction that inserts the chain nodes
+// so that the archived events are always consistent with the chain nodes.
+func (f *Flow) archiveEvents(ctx context.Context, chainNodes []*watch.ChainNode, events map[string]*archive.Event,
+	rid string) error {
+
+	if !f.archive.Enabled() || len(events) == 0 {
+		return nil
+	}
+
+	archived := make([]*archive.Event, 0, len(chainNodes))
+	for _, node := range chainNodes {
+		e, exists := events[node.Cursor]
+		if !exists {
+			continue
+		}
+		archived = append(archived, e)
+	}
+
+	if err := f.archive.Insert(ctx, archived); err != nil {
+		blog.Errorf("archive %s events failed, err: %v, rid: %s", f.key.Collection(), err, rid)
+		f.metrics.CollectMongoError()
+		return err
+	}
+	return nil
+}
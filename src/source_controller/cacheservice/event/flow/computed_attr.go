@@ -0,0 +1,526 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package flow
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"configcenter/pkg/formula"
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// computedQueueSize is the maximum number of instances waiting to be re-computed
+	computedQueueSize = 10000
+	// computedBatchInterval is the interval to re-compute the collected instances
+	computedBatchInterval = time.Second
+	// computedAttrSyncInterval is the interval to refresh the computed attributes from db
+	computedAttrSyncInterval = time.Minute
+	// computedRelatedLimit is the maximum number of related instances that a formula aggregates
+	computedRelatedLimit = 10000
+	// computedBackfillPageSize is the page size of the instances to be back filled
+	computedBackfillPageSize = 500
+)
+
+// computedAttr is a computed attribute with its parsed formula
+type computedAttr struct {
+	attr    metadata.Attribute
+	option  *metadata.ComputedOption
+	formula *formula.Formula
+}
+
+// computedTarget is an instance whose computed attributes and dependents need to be re-computed
+type computedTarget struct {
+	ownerID string
+	objID   string
+	instID  int64
+	// deleted defines if the instance is deleted, only its dependents are re-computed
+	deleted bool
+	// doc is the document of the deleted instance got from the del archive, it is used to resolve the instances
+	// that were related to the deleted instance, like its mainline parent
+	doc string
+	// onlyComputedChanged defines if only the computed fields are changed, which is caused by the re-computation
+	// itself, so the instance's own computed attributes do not need to be re-computed again
+	onlyComputedChanged bool
+}
+
+// computedAttrRefresher re-computes the computed attributes of the instances that are affected by the watched
+// events, it is triggered by the event flows after their events are handled. only the master re-computes the
+// instances, and the changed values are saved by core service which records the audit logs.
+type computedAttrRefresher struct {
+	ccDB      dal.DB
+	isMaster  discovery.ServiceManageInterface
+	clientSet apimachinery.ClientSetInterface
+	queue     chan *computedTarget
+
+	lock sync.RWMutex
+	// loaded defines if the computed attributes have been loaded from db
+	loaded bool
+	// attrs is the object id to its computed attributes map
+	attrs map[string][]*computedAttr
+	// dependents is the object id to the computed attributes that aggregate its instances map
+	dependents map[string][]*computedAttr
+}
+
+func newComputedAttrRefresher(ccDB dal.DB, isMaster discovery.ServiceManageInterface,
+	clientSet apimachinery.ClientSetInterface) *computedAttrRefresher {
+
+	return &computedAttrRefresher{
+		ccDB:       ccDB,
+		isMaster:   isMaster,
+		clientSet:  clientSet,
+		queue:      make(chan *computedTarget, computedQueueSize),
+		attrs:      make(map[string][]*computedAttr),
+		dependents: make(map[string][]*computedAttr),
+	}
+}
+
+// run starts the computed attribute refresher
+func (c *computedAttrRefresher) run() {
+	c.syncComputedAttrs()
+	go func() {
+		for {
+			time.Sleep(computedAttrSyncInterval)
+			c.syncComputedAttrs()
+		}
+	}()
+
+	go c.loopRefresh()
+}
+
+// syncComputedAttrs refresh the computed attributes and their dependency from db, the existing instances are back
+// filled by the master if a computed attribute is added or its formula is changed since the last sync. the first
+// sync after start does not back fill since the previous definitions are unknown, the instances are re-computed
+// when they change. the slaves also sync the definitions so that they know what is changed when becoming master.
+func (c *computedAttrRefresher) syncComputedAttrs() {
+	attrs := make([]metadata.Attribute, 0)
+	cond := mapstr.MapStr{common.BKPropertyTypeField: common.FieldTypeComputed}
+	if err := c.ccDB.Table(common.BKTableNameObjAttDes).Find(cond).All(context.Background(), &attrs); err != nil {
+		blog.Errorf("get computed attributes failed, err: %v", err)
+		return
+	}
+
+	attrMap := make(map[string][]*computedAttr)
+	dependents := make(map[string][]*computedAttr)
+	for _, attr := range attrs {
+		option, f, err := metadata.ParseComputedFormula(attr.Option)
+		if err != nil {
+			blog.Errorf("parse computed attribute %s.%s formula failed, err: %v", attr.ObjectID, attr.PropertyID, err)
+			continue
+		}
+
+		ca := &computedAttr{attr: attr, option: option, formula: f}
+		attrMap[attr.ObjectID] = append(attrMap[attr.ObjectID], ca)
+		for _, objID := range f.RelatedObjects() {
+			dependents[objID] = append(dependents[objID], ca)
+		}
+	}
+
+	c.lock.Lock()
+	prevAttrs, loaded := c.attrs, c.loaded
+	c.attrs = attrMap
+	c.dependents = dependents
+	c.loaded = true
+	c.lock.Unlock()
+
+	if !loaded || !c.isMaster.IsMaster() {
+		return
+	}
+
+	rid := util.GenerateRID()
+	for objID, changed := range changedComputedAttrs(prevAttrs, attrMap) {
+		c.backfill(context.Background(), objID, changed, rid)
+	}
+}
+
+// changedComputedAttrs returns the object id to its computed attributes that are added or whose formula or value
+// type are changed map
+func changedComputedAttrs(prev, cur map[string][]*computedAttr) map[string][]*computedAttr {
+	prevOptions := make(map[string]metadata.ComputedOption)
+	for objID, attrs := range prev {
+		for _, attr := range attrs {
+			prevOptions[objID+"."+attr.attr.PropertyID] = *attr.option
+		}
+	}
+
+	changed := make(map[string][]*computedAttr)
+	for objID, attrs := range cur {
+		for _, attr := range attrs {
+			option, exists := prevOptions[objID+"."+attr.attr.PropertyID]
+			if exists && option == *attr.option {
+				continue
+			}
+			changed[objID] = append(changed[objID], attr)
+		}
+	}
+	return changed
+}
+
+// backfill re-computes the computed attributes of all the existing instances of the object page by page
+func (c *computedAttrRefresher) backfill(ctx context.Context, objID string, attrs []*computedAttr, rid string) {
+	ownerAttrs := make(map[string][]*computedAttr)
+	for _, attr := range attrs {
+		ownerAttrs[attr.attr.OwnerID] = append(ownerAttrs[attr.attr.OwnerID], attr)
+	}
+
+	idField := common.GetInstIDField(objID)
+	for ownerID, attrs := range ownerAttrs {
+		table := common.GetInstTableName(objID, ownerID)
+		lastID := int64(0)
+		count := 0
+		for {
+			insts := make([]map[string]interface{}, 0)
+			cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBGT: lastID}}
+			err := c.ccDB.Table(table).Find(cond).Fields(idField).Sort(idField).Limit(computedBackfillPageSize).
+				All(ctx, &insts)
+			if err != nil {
+				blog.Errorf("get %s instances to back fill computed attributes failed, last id: %d, err: %v, rid: %s",
+					objID, lastID, err, rid)
+				break
+			}
+
+			updates := make([]metadata.ComputedFieldsData, 0)
+			for _, inst := range insts {
+				instID, err := util.GetInt64ByInterface(inst[idField])
+				if err != nil {
+					continue
+				}
+				if data := c.computeInst(ctx, ownerID, objID, instID, attrs, rid); len(data) > 0 {
+					updates = append(updates, metadata.ComputedFieldsData{InstID: instID, Data: data})
+				}
+				lastID = instID
+			}
+			c.save(ctx, ownerID, objID, updates, rid)
+			count += len(insts)
+
+			if len(insts) < computedBackfillPageSize {
+				break
+			}
+		}
+
+		blog.Infof("back fill %d %s instances computed attributes finished, rid: %s", count, objID, rid)
+	}
+}
+
+func (c *computedAttrRefresher) getComputedAttrs(objID string) []*computedAttr {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.attrs[objID]
+}
+
+func (c *computedAttrRefresher) getDependents(objID string) []*computedAttr {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.dependents[objID]
+}
+
+func (c *computedAttrRefresher) isEmpty() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.attrs) == 0
+}
+
+// notify collect the instances related to the events that need to be re-computed, oidDetailMap is the deleted
+// documents of the delete events got from the del archive
+func (c *computedAttrRefresher) notify(es []*types.Event, oidDetailMap map[oidCollKey][]byte) {
+	if c == nil || c.isEmpty() {
+		return
+	}
+
+	for _, e := range es {
+		for _, target := range c.parseTargets(e, oidDetailMap) {
+			select {
+			case c.queue <- target:
+			default:
+				blog.Errorf("computed attribute refresh queue is full, drop target: %+v", *target)
+			}
+		}
+	}
+}
+
+// parseTargets parse the instances that are related to the event
+func (c *computedAttrRefresher) parseTargets(e *types.Event, oidDetailMap map[oidCollKey][]byte) []*computedTarget {
+	doc := e.DocBytes
+	deleted := e.OperationType == types.Delete
+	if deleted {
+		// delete event has no full document, the deleted document is got from the del archive
+		detail, exists := oidDetailMap[oidCollKey{oid: e.Oid, coll: e.Collection}]
+		if !exists {
+			return nil
+		}
+		doc = detail
+	}
+	ownerID := gjson.GetBytes(doc, common.BkSupplierAccount).String()
+
+	switch {
+	case e.Collection == common.BKTableNameModuleHostConfig:
+		// host relation changes affect the host and its topology instances on both sides
+		targets := make([]*computedTarget, 0)
+		for _, objID := range []string{common.BKInnerObjIDHost, common.BKInnerObjIDModule, common.BKInnerObjIDSet,
+			common.BKInnerObjIDApp} {
+			targets = append(targets, &computedTarget{ownerID: ownerID, objID: objID,
+				instID: gjson.GetBytes(doc, common.GetInstIDField(objID)).Int()})
+		}
+		return targets
+
+	case e.Collection == common.BKTableNameInstAsst || common.IsObjectInstAsstShardingTable(e.Collection):
+		return []*computedTarget{
+			{ownerID: ownerID, objID: gjson.GetBytes(doc, common.BKObjIDField).String(),
+				instID: gjson.GetBytes(doc, common.BKInstIDField).Int()},
+			{ownerID: ownerID, objID: gjson.GetBytes(doc, common.BKAsstObjIDField).String(),
+				instID: gjson.GetBytes(doc, common.BKAsstInstIDField).Int()},
+		}
+	}
+
+	objID, err := common.GetInstObjIDByTableName(e.Collection, ownerID)
+	if err != nil {
+		return nil
+	}
+
+	target := &computedTarget{
+		ownerID: ownerID,
+		objID:   objID,
+		instID:  gjson.GetBytes(doc, common.GetInstIDField(objID)).Int(),
+		deleted: deleted,
+	}
+	if deleted {
+		target.doc = string(doc)
+	}
+
+	if e.OperationType == types.Update && e.ChangeDesc != nil && len(e.ChangeDesc.RemovedFields) == 0 {
+		computedFields := make(map[string]struct{})
+		for _, attr := range c.getComputedAttrs(objID) {
+			computedFields[attr.attr.PropertyID] = struct{}{}
+		}
+
+		target.onlyComputedChanged = true
+		for field := range e.ChangeDesc.UpdatedFields {
+			if _, exists := computedFields[field]; !exists && field != common.LastTimeField {
+				target.onlyComputedChanged = false
+				break
+			}
+		}
+	}
+
+	return []*computedTarget{target}
+}
+
+// loopRefresh collects the targets and re-computes them in batch, so that the same instance changed many times
+// in a short period is only re-computed once.
+func (c *computedAttrRefresher) loopRefresh() {
+	ticker := time.NewTicker(computedBatchInterval)
+	defer ticker.Stop()
+
+	targets := make(map[computedTarget]struct{})
+	for {
+		select {
+		case target := <-c.queue:
+			if target.instID <= 0 || target.objID == "" {
+				continue
+			}
+			targets[*target] = struct{}{}
+		case <-ticker.C:
+			if len(targets) == 0 {
+				continue
+			}
+			// the targets collected before the master is switched are re-computed by the new master
+			if !c.isMaster.IsMaster() {
+				targets = make(map[computedTarget]struct{})
+				continue
+			}
+			rid := util.GenerateRID()
+			for target := range targets {
+				c.refresh(context.Background(), &target, rid)
+			}
+			targets = make(map[computedTarget]struct{})
+		}
+	}
+}
+
+// refresh re-computes the target instance's computed attributes and the computed attributes that aggregate it
+func (c *computedAttrRefresher) refresh(ctx context.Context, target *computedTarget, rid string) {
+	if !target.deleted && !target.onlyComputedChanged {
+		if attrs := c.getComputedAttrs(target.objID); len(attrs) > 0 {
+			data := c.computeInst(ctx, target.ownerID, target.objID, target.instID, attrs, rid)
+			if len(data) > 0 {
+				c.save(ctx, target.ownerID, target.objID,
+					[]metadata.ComputedFieldsData{{InstID: target.instID, Data: data}}, rid)
+			}
+		}
+	}
+
+	dependents := c.getDependents(target.objID)
+	if len(dependents) == 0 {
+		return
+	}
+
+	resolver := &instResolver{ctx: ctx, db: c.ccDB, ownerID: target.ownerID, objID: target.objID,
+		instID: target.instID}
+	if !target.deleted {
+		inst, err := resolver.getInst(target.objID, target.instID)
+		if err != nil {
+			blog.Errorf("get %s instance %d failed, err: %v, rid: %s", target.objID, target.instID, err, rid)
+			return
+		}
+		resolver.inst = inst
+	} else if target.doc != "" {
+		inst := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(target.doc), &inst); err != nil {
+			blog.Errorf("unmarshal deleted %s instance %d failed, err: %v, rid: %s", target.objID, target.instID,
+				err, rid)
+			return
+		}
+		resolver.inst = inst
+	}
+
+	handled := make(map[string]struct{})
+	for _, dependent := range dependents {
+		objID := dependent.attr.ObjectID
+		if _, exists := handled[objID]; exists {
+			continue
+		}
+		handled[objID] = struct{}{}
+
+		instIDs, err := resolver.relatedInstIDs(objID)
+		if err != nil {
+			blog.Errorf("get %s instances related to %s %d failed, err: %v, rid: %s", objID, target.objID,
+				target.instID, err, rid)
+			continue
+		}
+
+		attrs := c.getComputedAttrs(objID)
+		updates := make([]metadata.ComputedFieldsData, 0)
+		for _, instID := range instIDs {
+			if data := c.computeInst(ctx, target.ownerID, objID, instID, attrs, rid); len(data) > 0 {
+				updates = append(updates, metadata.ComputedFieldsData{InstID: instID, Data: data})
+			}
+		}
+		c.save(ctx, target.ownerID, objID, updates, rid)
+	}
+}
+
+// computeInst evaluates all the computed attributes of the instance, and returns the changed values
+func (c *computedAttrRefresher) computeInst(ctx context.Context, ownerID, objID string, instID int64,
+	attrs []*computedAttr, rid string) mapstr.MapStr {
+
+	resolver := &instResolver{ctx: ctx, db: c.ccDB, ownerID: ownerID, objID: objID, instID: instID}
+	inst, err := resolver.getInst(objID, instID)
+	if err != nil {
+		blog.Errorf("get %s instance %d failed, err: %v, rid: %s", objID, instID, err, rid)
+		return nil
+	}
+	if inst == nil {
+		return nil
+	}
+	resolver.inst = inst
+
+	bizID, err := resolver.bizID()
+	if err != nil {
+		blog.Errorf("get %s instance %d biz id failed, err: %v, rid: %s", objID, instID, err, rid)
+		return nil
+	}
+
+	updateData := make(mapstr.MapStr)
+	for _, attr := range attrs {
+		// biz custom attributes only take effect on the instances in the biz
+		if attr.attr.BizID > 0 && attr.attr.BizID != bizID {
+			continue
+		}
+
+		val, err := attr.formula.Eval(resolver)
+		if err != nil {
+			blog.Errorf("evaluate %s.%s formula for instance %d failed, err: %v, rid: %s", objID,
+				attr.attr.PropertyID, instID, err, rid)
+			val = nil
+		}
+
+		val, err = metadata.ConvertComputedValue(attr.option.ValueType, val)
+		if err != nil {
+			blog.Errorf("convert %s.%s computed value for instance %d failed, err: %v, rid: %s", objID,
+				attr.attr.PropertyID, instID, err, rid)
+			val = nil
+		}
+
+		if isSameComputedValue(inst[attr.attr.PropertyID], val) {
+			continue
+		}
+		updateData[attr.attr.PropertyID] = val
+	}
+
+	return updateData
+}
+
+// save updates the changed computed values of the instances by core service in batches
+func (c *computedAttrRefresher) save(ctx context.Context, ownerID, objID string,
+	updates []metadata.ComputedFieldsData, rid string) {
+
+	header := headerutil.BuildHeader(common.CCSystemOperatorUserName, ownerID)
+	httpheader.SetRid(header, rid)
+	for start := 0; start < len(updates); start += common.BKMaxUpdateOrCreatePageSize {
+		end := start + common.BKMaxUpdateOrCreatePageSize
+		if end > len(updates) {
+			end = len(updates)
+		}
+
+		opt := &metadata.UpdateComputedFieldsOption{Data: updates[start:end]}
+		if err := c.clientSet.CoreService().Instance().UpdateComputedFields(ctx, header, objID, opt); err != nil {
+			blog.Errorf("update %s instances computed fields failed, data: %+v, err: %v, rid: %s", objID,
+				opt.Data, err, rid)
+			continue
+		}
+
+		blog.V(4).Infof("update %s instances computed fields %+v, rid: %s", objID, opt.Data, rid)
+	}
+}
+
+func isSameComputedValue(dbVal, val interface{}) bool {
+	if dbVal == nil || val == nil {
+		return dbVal == nil && val == nil
+	}
+
+	_, dbIsStr := dbVal.(string)
+	_, isStr := val.(string)
+	if dbIsStr || isStr {
+		return dbVal == val
+	}
+
+	// numeric value in db may be decoded as a different type from the computed one, so compare them as float
+	dbNum, dbErr := util.GetFloat64ByInterface(dbVal)
+	num, err := util.GetFloat64ByInterface(val)
+	if dbErr == nil && err == nil {
+		return dbNum == num
+	}
+
+	return reflect.DeepEqual(dbVal, val)
+}
@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flow
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/stream/types"
+)
+
+func TestParseTargets(t *testing.T) {
+	c := newComputedAttrRefresher(nil, nil, nil)
+	c.attrs[common.BKInnerObjIDSet] = []*computedAttr{{
+		attr:   metadata.Attribute{ObjectID: common.BKInnerObjIDSet, PropertyID: "module_count"},
+		option: &metadata.ComputedOption{Formula: "count(module)", ValueType: common.FieldTypeInt},
+	}}
+
+	deletedModule := `{"bk_module_id":3,"bk_set_id":2,"bk_biz_id":1,"bk_supplier_account":"0"}`
+	deletedAsst := `{"bk_obj_id":"switch","bk_inst_id":5,"bk_asst_obj_id":"router","bk_asst_inst_id":6,` +
+		`"bk_supplier_account":"0"}`
+	deletedRelation := `{"bk_host_id":4,"bk_module_id":3,"bk_set_id":2,"bk_biz_id":1,"bk_supplier_account":"0"}`
+	oidDetailMap := map[oidCollKey][]byte{
+		{oid: "module", coll: common.BKTableNameBaseModule}:         []byte(deletedModule),
+		{oid: "asst", coll: common.BKTableNameInstAsst}:             []byte(deletedAsst),
+		{oid: "relation", coll: common.BKTableNameModuleHostConfig}: []byte(deletedRelation),
+	}
+
+	// update instance
+	targets := c.parseTargets(&types.Event{Oid: "set", Collection: common.BKTableNameBaseSet,
+		OperationType: types.Update, DocBytes: []byte(`{"bk_set_id":2,"bk_supplier_account":"0"}`),
+		ChangeDesc: &types.ChangeDescription{UpdatedFields: map[string]interface{}{"bk_set_name": "a"}}}, oidDetailMap)
+	if len(targets) != 1 || *targets[0] != (computedTarget{ownerID: "0", objID: common.BKInnerObjIDSet, instID: 2}) {
+		t.Fatalf("invalid update set targets")
+	}
+
+	// update only computed fields
+	targets = c.parseTargets(&types.Event{Oid: "set", Collection: common.BKTableNameBaseSet,
+		OperationType: types.Update, DocBytes: []byte(`{"bk_set_id":2,"bk_supplier_account":"0"}`),
+		ChangeDesc: &types.ChangeDescription{UpdatedFields: map[string]interface{}{"module_count": 1,
+			common.LastTimeField: "now"}}}, oidDetailMap)
+	if len(targets) != 1 || !targets[0].onlyComputedChanged {
+		t.Fatalf("invalid update set computed fields targets")
+	}
+
+	// delete instance uses del archive detail
+	targets = c.parseTargets(&types.Event{Oid: "module", Collection: common.BKTableNameBaseModule,
+		OperationType: types.Delete}, oidDetailMap)
+	if len(targets) != 1 || *targets[0] != (computedTarget{ownerID: "0", objID: common.BKInnerObjIDModule, instID: 3,
+		deleted: true, doc: deletedModule}) {
+		t.Fatalf("invalid delete module targets")
+	}
+
+	// delete association uses del archive detail
+	targets = c.parseTargets(&types.Event{Oid: "asst", Collection: common.BKTableNameInstAsst,
+		OperationType: types.Delete}, oidDetailMap)
+	if len(targets) != 2 || *targets[0] != (computedTarget{ownerID: "0", objID: "switch", instID: 5}) ||
+		*targets[1] != (computedTarget{ownerID: "0", objID: "router", instID: 6}) {
+		t.Fatalf("invalid delete association targets")
+	}
+
+	// delete host relation uses del archive detail
+	targets = c.parseTargets(&types.Event{Oid: "relation", Collection: common.BKTableNameModuleHostConfig,
+		OperationType: types.Delete}, oidDetailMap)
+	if len(targets) != 4 || targets[0].instID != 4 || targets[1].instID != 3 || targets[2].instID != 2 ||
+		targets[3].instID != 1 {
+		t.Fatalf("invalid delete host relation targets")
+	}
+
+	// delete without del archive detail
+	targets = c.parseTargets(&types.Event{Oid: "unknown", Collection: common.BKTableNameBaseSet,
+		OperationType: types.Delete}, oidDetailMap)
+	if len(targets) != 0 {
+		t.Fatalf("delete event without del archive detail should have no targets")
+	}
+}
+
+func TestDeletedInstResolver(t *testing.T) {
+	doc := `{"bk_module_id":3,"bk_set_id":2,"bk_biz_id":1,"bk_supplier_account":"0"}`
+	inst := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(doc), &inst); err != nil {
+		t.Fatalf("unmarshal deleted module failed, err: %v", err)
+	}
+
+	// the mainline parents of the deleted instance are resolved from its deleted document without db
+	resolver := &instResolver{ownerID: "0", objID: common.BKInnerObjIDModule, instID: 3, inst: inst}
+	for objID, expected := range map[string]int64{common.BKInnerObjIDSet: 2, common.BKInnerObjIDApp: 1} {
+		instIDs, err := resolver.relatedInstIDs(objID)
+		if err != nil {
+			t.Fatalf("get %s related to deleted module failed, err: %v", objID, err)
+		}
+
+		if len(instIDs) != 1 || instIDs[0] != expected {
+			t.Fatalf("%s related to deleted module %v is not equal to %d", objID, instIDs, expected)
+		}
+	}
+}
+
+func TestChangedComputedAttrs(t *testing.T) {
+	prev := map[string][]*computedAttr{
+		"switch": {
+			{attr: metadata.Attribute{PropertyID: "port_count"},
+				option: &metadata.ComputedOption{Formula: "count(port)", ValueType: common.FieldTypeInt}},
+			{attr: metadata.Attribute{PropertyID: "name_len"},
+				option: &metadata.ComputedOption{Formula: "len(name)", ValueType: common.FieldTypeInt}},
+		},
+		"router": {{attr: metadata.Attribute{PropertyID: "port_count"},
+			option: &metadata.ComputedOption{Formula: "count(port)", ValueType: common.FieldTypeInt}}},
+	}
+
+	changedFormula := &computedAttr{attr: metadata.Attribute{PropertyID: "port_count"},
+		option: &metadata.ComputedOption{Formula: "count(port) + 1", ValueType: common.FieldTypeInt}}
+	changedType := &computedAttr{attr: metadata.Attribute{PropertyID: "port_count"},
+		option: &metadata.ComputedOption{Formula: "count(port)", ValueType: common.FieldTypeFloat}}
+	added := &computedAttr{attr: metadata.Attribute{PropertyID: "disk_count"},
+		option: &metadata.ComputedOption{Formula: "count(disk)", ValueType: common.FieldTypeInt}}
+	cur := map[string][]*computedAttr{
+		"switch": {changedFormula, prev["switch"][1]},
+		"router": {changedType},
+		"server": {added},
+	}
+
+	changed := changedComputedAttrs(prev, cur)
+	if len(changed) != 3 {
+		t.Fatalf("changed objects %d is not equal to 3", len(changed))
+	}
+
+	if len(changed["switch"]) != 1 || changed["switch"][0] != changedFormula {
+		t.Fatalf("attribute whose formula is changed should be back filled")
+	}
+
+	if len(changed["router"]) != 1 || changed["router"][0] != changedType {
+		t.Fatalf("attribute whose value type is changed should be back filled")
+	}
+
+	if len(changed["server"]) != 1 || changed["server"][0] != added {
+		t.Fatalf("added attribute should be back filled")
+	}
+
+	if len(changedComputedAttrs(cur, cur)) != 0 {
+		t.Fatal("unchanged computed attributes should not be back filled")
+	}
+}
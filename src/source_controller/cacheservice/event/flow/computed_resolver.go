@@ -0,0 +1,181 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package flow
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// instResolver implements the formula.Resolver for an instance, its related instances are:
+// 1. host and its business, set, module are related by the host relation.
+// 2. business, set and module are related by the mainline topology.
+// 3. other instances are related by the instance associations.
+type instResolver struct {
+	ctx     context.Context
+	db      dal.DB
+	ownerID string
+	objID   string
+	instID  int64
+	inst    map[string]interface{}
+}
+
+// Field returns the value of the instance's own field
+func (r *instResolver) Field(field string) interface{} {
+	return r.inst[field]
+}
+
+// Related returns the instances of object objID that are related to the instance
+func (r *instResolver) Related(objID string, fields []string) ([]map[string]interface{}, error) {
+	instIDs, err := r.relatedInstIDs(objID)
+	if err != nil {
+		return nil, err
+	}
+
+	insts := make([]map[string]interface{}, 0)
+	if len(instIDs) == 0 {
+		return insts, nil
+	}
+
+	idField := common.GetInstIDField(objID)
+	cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: instIDs}}
+	err = r.db.Table(common.GetInstTableName(objID, r.ownerID)).Find(cond).Fields(fields...).Sort(idField).
+		Limit(computedRelatedLimit).All(r.ctx, &insts)
+	if err != nil {
+		return nil, err
+	}
+	return insts, nil
+}
+
+func (r *instResolver) getInst(objID string, instID int64) (map[string]interface{}, error) {
+	insts := make([]map[string]interface{}, 0)
+	cond := mapstr.MapStr{common.GetInstIDField(objID): instID}
+	err := r.db.Table(common.GetInstTableName(objID, r.ownerID)).Find(cond).Limit(1).All(r.ctx, &insts)
+	if err != nil {
+		return nil, err
+	}
+	if len(insts) == 0 {
+		return nil, nil
+	}
+	return insts[0], nil
+}
+
+// bizID returns the business id of the instance, host's business id is got from its host relation
+func (r *instResolver) bizID() (int64, error) {
+	if r.objID != common.BKInnerObjIDHost {
+		bizID, _ := util.GetInt64ByInterface(r.inst[common.BKAppIDField])
+		return bizID, nil
+	}
+
+	bizIDs, err := r.relatedInstIDs(common.BKInnerObjIDApp)
+	if err != nil {
+		return 0, err
+	}
+	if len(bizIDs) == 0 {
+		return 0, nil
+	}
+	return bizIDs[0], nil
+}
+
+// relatedInstIDs returns the ids of the instances of object objID that are related to the instance
+func (r *instResolver) relatedInstIDs(objID string) ([]int64, error) {
+	switch {
+	case isHostTopoObject(r.objID) && isHostTopoObject(objID) &&
+		(r.objID == common.BKInnerObjIDHost || objID == common.BKInnerObjIDHost):
+		cond := mapstr.MapStr{common.GetInstIDField(r.objID): r.instID}
+		return r.distinctIDs(common.BKTableNameModuleHostConfig, common.GetInstIDField(objID), cond)
+
+	case isMainlineParent(objID, r.objID):
+		// the child instance stores its parent's id, and its biz id
+		if r.inst == nil {
+			return make([]int64, 0), nil
+		}
+		parentID, err := util.GetInt64ByInterface(r.inst[common.GetInstIDField(objID)])
+		if err != nil {
+			return make([]int64, 0), nil
+		}
+		return []int64{parentID}, nil
+
+	case isMainlineParent(r.objID, objID):
+		cond := mapstr.MapStr{common.GetInstIDField(r.objID): r.instID}
+		return r.distinctIDs(common.GetInstTableName(objID, r.ownerID), common.GetInstIDField(objID), cond)
+	}
+
+	// instance association is stored in both of the two objects' association tables, so use the one of itself
+	table := common.GetObjectInstAsstTableName(r.objID, r.ownerID)
+	srcCond := mapstr.MapStr{
+		common.BKObjIDField:      objID,
+		common.BKAsstObjIDField:  r.objID,
+		common.BKAsstInstIDField: r.instID,
+	}
+	srcIDs, err := r.distinctIDs(table, common.BKInstIDField, srcCond)
+	if err != nil {
+		return nil, err
+	}
+
+	dstCond := mapstr.MapStr{
+		common.BKObjIDField:     r.objID,
+		common.BKInstIDField:    r.instID,
+		common.BKAsstObjIDField: objID,
+	}
+	dstIDs, err := r.distinctIDs(table, common.BKAsstInstIDField, dstCond)
+	if err != nil {
+		return nil, err
+	}
+
+	// for self related association, the instance itself is excluded
+	instIDs := make([]int64, 0)
+	for _, id := range append(srcIDs, dstIDs...) {
+		if objID == r.objID && id == r.instID {
+			continue
+		}
+		instIDs = append(instIDs, id)
+	}
+	return util.IntArrayUnique(instIDs), nil
+}
+
+func (r *instResolver) distinctIDs(table, field string, cond mapstr.MapStr) ([]int64, error) {
+	ids, err := r.db.Table(table).Distinct(r.ctx, field, cond)
+	if err != nil {
+		return nil, err
+	}
+	return util.SliceInterfaceToInt64(ids)
+}
+
+func isHostTopoObject(objID string) bool {
+	switch objID {
+	case common.BKInnerObjIDHost, common.BKInnerObjIDModule, common.BKInnerObjIDSet, common.BKInnerObjIDApp:
+		return true
+	}
+	return false
+}
+
+// isMainlineParent returns if parent object is the inner mainline ancestor of the child object
+func isMainlineParent(parent, child string) bool {
+	switch parent {
+	case common.BKInnerObjIDApp:
+		return child == common.BKInnerObjIDSet || child == common.BKInnerObjIDModule
+	case common.BKInnerObjIDSet:
+		return child == common.BKInnerObjIDModule
+	}
+	return false
+}
@@ -16,10 +16,12 @@ import (
 	"context"
 	"fmt"
 
+	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/source_controller/cacheservice/event/archive"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/stream"
@@ -27,7 +29,7 @@ import (
 
 // NewEvent TODO
 func NewEvent(watch stream.LoopInterface, isMaster discovery.ServiceManageInterface, watchDB dal.DB,
-	ccDB dal.DB) error {
+	ccDB dal.DB, archive *archive.Archive, clientSet apimachinery.ClientSetInterface) error {
 	watchMongoDB, ok := watchDB.(*local.Mongo)
 	if !ok {
 		blog.Errorf("watch event, but watch db is not an instance of local mongo to start transaction")
@@ -39,7 +41,10 @@ func NewEvent(watch stream.LoopInterface, isMaster discovery.ServiceManageInterf
 		isMaster: isMaster,
 		watchDB:  watchMongoDB,
 		ccDB:     ccDB,
+		computed: newComputedAttrRefresher(ccDB, isMaster, clientSet),
+		archive:  archive,
 	}
+	e.computed.run()
 
 	if err := e.runHost(context.Background()); err != nil {
 		blog.Errorf("run host event flow failed, err: %v", err)
@@ -99,6 +104,13 @@ func NewEvent(watch stream.LoopInterface, isMaster discovery.ServiceManageInterf
 		blog.Errorf("run project event flow failed, err: %v", err)
 	}
 
+	// model metadata, templates and host apply rules are watched in the same way
+	for _, key := range schemaAndTemplateKeys {
+		if err := e.runGeneralResource(context.Background(), key); err != nil {
+			blog.Errorf("run %s event flow failed, err: %v", key.Collection(), err)
+		}
+	}
+
 	return nil
 }
 
@@ -108,6 +120,8 @@ type Event struct {
 	watchDB  *local.Mongo
 	ccDB     dal.DB
 	isMaster discovery.ServiceManageInterface
+	computed *computedAttrRefresher
+	archive  *archive.Archive
 }
 
 func (e *Event) runHost(ctx context.Context) error {
@@ -118,6 +132,8 @@ func (e *Event) runHost(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(metadata.HostMapStr),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getHostDeleteEventDetails, parseEvent)
@@ -131,6 +147,8 @@ func (e *Event) runModuleHostRelation(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -144,6 +162,8 @@ func (e *Event) runBiz(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -157,6 +177,8 @@ func (e *Event) runSet(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -170,6 +192,8 @@ func (e *Event) runModule(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -183,6 +207,8 @@ func (e *Event) runObjectBase(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newInstanceFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -196,6 +222,8 @@ func (e *Event) runProcess(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -209,6 +237,7 @@ func (e *Event) runProcessInstanceRelation(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -222,6 +251,8 @@ func (e *Event) runInstAsst(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newInstAsstFlow(ctx, opts, getDeleteEventDetails, parseInstAsstEvent)
@@ -235,6 +266,8 @@ func (e *Event) runBizSet(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -248,6 +281,8 @@ func (e *Event) runPlat(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
@@ -261,6 +296,28 @@ func (e *Event) runProject(ctx context.Context) error {
 		ccDB:        e.ccDB,
 		isMaster:    e.isMaster,
 		EventStruct: new(map[string]interface{}),
+		computed:    e.computed,
+		archive:     e.archive,
+	}
+
+	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
+}
+
+// schemaAndTemplateKeys is the event keys of model metadata, templates and host apply rules
+var schemaAndTemplateKeys = []event.Key{event.ModelKey, event.ModelAttributeKey, event.ModelAttrGroupKey,
+	event.ModelUniqueKey, event.ModelAssociationKey, event.ServiceTemplateKey, event.SetTemplateKey,
+	event.FieldTemplateKey, event.HostApplyRuleKey}
+
+// runGeneralResource run the event flow of the resource whose events need no special handling
+func (e *Event) runGeneralResource(ctx context.Context, key event.Key) error {
+	opts := flowOptions{
+		key:         key,
+		watch:       e.watch,
+		watchDB:     e.watchDB,
+		ccDB:        e.ccDB,
+		isMaster:    e.isMaster,
+		EventStruct: new(map[string]interface{}),
+		archive:     e.archive,
 	}
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
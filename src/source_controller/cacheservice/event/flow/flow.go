@@ -26,6 +26,7 @@ import (
 	types2 "configcenter/src/common/types"
 	"configcenter/src/common/watch"
 	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/source_controller/cacheservice/event/archive"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/driver/redis"
@@ -44,6 +45,10 @@ type flowOptions struct {
 	watchDB     *local.Mongo
 	ccDB        dal.DB
 	EventStruct interface{}
+	// computed is used to re-compute the computed attributes affected by the events, nil means no need to do it
+	computed *computedAttrRefresher
+	// archive is used to archive the events durably, nil means the events are not archived
+	archive *archive.Archive
 }
 
 // oidCollKey key for oid to detail map. Since oid can duplicate in different collections, we need oid & coll for unique
@@ -226,6 +231,8 @@ func (f *Flow) doBatch(es []*types.Event) (retry bool) {
 	// process events into db chain nodes to store in db and details to store in redis
 	pipe := redis.Client().Pipeline()
 	cursorMap := make(map[string]struct{})
+	archiveEvents := make(map[string]*archive.Event)
+	parsedEvents := make([]*parsedEvent, 0, eventLen)
 	hitConflict := false
 	for index, e := range es {
 		// collect event's basic metrics
@@ -239,11 +246,7 @@ func (f *Flow) doBatch(es []*types.Event) (retry bool) {
 			continue
 		}
 
-		// if hit cursor conflict, the former cursor node's detail will be overwrite by the later one, so it
-		// is not needed to remove the overlapped cursor node's detail again.
-		ttl := time.Duration(f.key.TTLSeconds()) * time.Second
-		pipe.Set(f.key.DetailKey(chainNode.Cursor), string(detail.eventInfo), ttl)
-		pipe.Set(f.key.GeneralResDetailKey(chainNode), string(detail.resDetail), ttl)
+		parsedEvents = append(parsedEvents, &parsedEvent{key: f.key, node: chainNode, detail: detail})
 
 		// validate if the cursor already exists in the batch, this happens when the concurrency is very high.
 		// which will generate the same operation event with same cluster time, and generate with the same cursor
@@ -264,6 +267,17 @@ func (f *Flow) doBatch(es []*types.Event) (retry bool) {
 		oids[index] = e.ID()
 		chainNodes = append(chainNodes, chainNode)
 	}
+
+	fillPreviousFields(parsedEvents, rid)
+	for _, e := range parsedEvents {
+		// if hit cursor conflict, the former cursor node's detail will be overwrite by the later one, so it
+		// is not needed to remove the overlapped cursor node's detail again.
+		ttl := time.Duration(e.key.TTLSeconds()) * time.Second
+		pipe.Set(e.key.DetailKey(e.node.Cursor), string(e.detail.eventInfo), ttl)
+		pipe.Set(e.key.GeneralResDetailKey(e.node), string(e.detail.resDetail), ttl)
+		f.addArchiveEvent(archiveEvents, e.key, e.node, e.detail, rid)
+	}
+
 	lastTokenData := map[string]interface{}{
 		common.BKTokenField:       es[eventLen-1].Token.Data,
 		common.BKStartAtTimeField: es[eventLen-1].ClusterTime,
@@ -291,12 +305,13 @@ func (f *Flow) doBatch(es []*types.Event) (retry bool) {
 		chainNodes = f.rearrangeEvents(chainNodes, rid)
 	}
 
-	retry, err = f.doInsertEvents(chainNodes, lastTokenData, rid)
+	retry, err = f.doInsertEvents(chainNodes, archiveEvents, lastTokenData, rid)
 	if err != nil {
 		return retry
 	}
 
 	blog.Infof("insert watch event for %s success, oids: %v, rid: %s", f.key.Collection(), oids, rid)
+	f.computed.notify(es, oidDetailMap)
 	hasError = false
 	return false
 }
@@ -329,8 +344,8 @@ func (f *Flow) rearrangeEvents(chainNodes []*watch.ChainNode, rid string) []*wat
 	return pickedChainNodes
 }
 
-func (f *Flow) doInsertEvents(chainNodes []*watch.ChainNode, lastTokenData map[string]interface{}, rid string) (
-	bool, error) {
+func (f *Flow) doInsertEvents(chainNodes []*watch.ChainNode, archiveEvents map[string]*archive.Event,
+	lastTokenData map[string]interface{}, rid string) (bool, error) {
 
 	count := len(chainNodes)
 
@@ -371,6 +386,11 @@ func (f *Flow) doInsertEvents(chainNodes []*watch.ChainNode, lastTokenData map[s
 			return err
 		}
 
+		if err := f.archiveEvents(sc, chainNodes, archiveEvents, rid); err != nil {
+			_ = session.AbortTransaction(context.Background())
+			return err
+		}
+
 		lastNode := chainNodes[len(chainNodes)-1]
 		lastTokenData[common.BKFieldID] = lastNode.ID
 		lastTokenData[common.BKCursorField] = lastNode.Cursor
@@ -418,7 +438,7 @@ func (f *Flow) doInsertEvents(chainNodes []*watch.ChainNode, lastTokenData map[s
 					blog.ErrorJSON("run flow, insert %s event with reduce node %s, remain nodes: %s, rid: %s",
 						f.key.Collection(), reducedChainNode, chainNodes, rid)
 
-					return f.doInsertEvents(chainNodes, lastTokenData, rid)
+					return f.doInsertEvents(chainNodes, archiveEvents, lastTokenData, rid)
 				}
 			}
 
@@ -426,7 +446,7 @@ func (f *Flow) doInsertEvents(chainNodes []*watch.ChainNode, lastTokenData map[s
 			blog.ErrorJSON("run flow, insert %s event with reduce node %s, remain nodes: %s, rid: %s",
 				f.key.Collection(), chainNodes[0], chainNodes[1:], rid)
 
-			return f.doInsertEvents(chainNodes[1:], lastTokenData, rid)
+			return f.doInsertEvents(chainNodes[1:], archiveEvents, lastTokenData, rid)
 		}
 
 		// if an error occurred, roll back and re-watch again
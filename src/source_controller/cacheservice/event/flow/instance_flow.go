@@ -28,6 +28,7 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/common/watch"
 	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/source_controller/cacheservice/event/archive"
 	dbtypes "configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/redis"
 	"configcenter/src/storage/stream/types"
@@ -207,7 +208,9 @@ func (f *InstanceFlow) doBatch(es []*types.Event) (retry bool) {
 	pipe := redis.Client().Pipeline()
 	oids := make([]string, 0)
 	chainNodesMap := make(map[string][]*watch.ChainNode)
+	archiveEvents := make(map[string]*archive.Event)
 	lastChainNode := new(watch.ChainNode)
+	parsedEvents := make([]*parsedEvent, 0, eventLen)
 	for coll, events := range eventMap {
 		key := f.getKeyByCollection(coll)
 		cursorMap := make(map[string]struct{})
@@ -241,12 +244,7 @@ func (f *InstanceFlow) doBatch(es []*types.Event) (retry bool) {
 
 			oids = append(oids, e.ID())
 			chainNodesMap[coll] = append(chainNodesMap[coll], chainNode)
-
-			// if hit cursor conflict, the former cursor node's detail will be overwrite by the later one, so it
-			// is not needed to remove the overlapped cursor node's detail again.
-			ttl := time.Duration(key.TTLSeconds()) * time.Second
-			pipe.Set(key.DetailKey(chainNode.Cursor), string(detail.eventInfo), ttl)
-			pipe.Set(key.GeneralResDetailKey(chainNode), string(detail.resDetail), ttl)
+			parsedEvents = append(parsedEvents, &parsedEvent{key: key, node: chainNode, detail: detail})
 		}
 
 		if hitConflict {
@@ -254,6 +252,16 @@ func (f *InstanceFlow) doBatch(es []*types.Event) (retry bool) {
 		}
 	}
 
+	fillPreviousFields(parsedEvents, rid)
+	for _, e := range parsedEvents {
+		// if hit cursor conflict, the former cursor node's detail will be overwrite by the later one, so it
+		// is not needed to remove the overlapped cursor node's detail again.
+		ttl := time.Duration(e.key.TTLSeconds()) * time.Second
+		pipe.Set(e.key.DetailKey(e.node.Cursor), string(e.detail.eventInfo), ttl)
+		pipe.Set(e.key.GeneralResDetailKey(e.node), string(e.detail.resDetail), ttl)
+		f.addArchiveEvent(archiveEvents, e.key, e.node, e.detail, rid)
+	}
+
 	lastTokenData := map[string]interface{}{
 		common.BKTokenField:       aggregationEvents[eventLen-1].Token.Data,
 		common.BKStartAtTimeField: aggregationEvents[eventLen-1].ClusterTime,
@@ -280,11 +288,12 @@ func (f *InstanceFlow) doBatch(es []*types.Event) (retry bool) {
 		return true
 	}
 
-	retry, err = f.doInsertEvents(chainNodesMap, lastTokenData, rid)
+	retry, err = f.doInsertEvents(chainNodesMap, archiveEvents, lastTokenData, rid)
 	if err != nil {
 		return retry
 	}
 
+	f.computed.notify(es, oidDetailMap)
 	hasError = false
 	return false
 }
@@ -484,8 +493,8 @@ func (f *InstanceFlow) convertToInstEvents(es map[int]*types.Event, srcObjIDInst
 	return aggregationInstEvents, nil
 }
 
-func (f *InstanceFlow) doInsertEvents(chainNodesMap map[string][]*watch.ChainNode, lastTokenData map[string]interface{},
-	rid string) (bool, error) {
+func (f *InstanceFlow) doInsertEvents(chainNodesMap map[string][]*watch.ChainNode,
+	archiveEvents map[string]*archive.Event, lastTokenData map[string]interface{}, rid string) (bool, error) {
 
 	if len(chainNodesMap) == 0 {
 		return false, nil
@@ -531,6 +540,11 @@ func (f *InstanceFlow) doInsertEvents(chainNodesMap map[string][]*watch.ChainNod
 				}
 				return err
 			}
+
+			if err := f.archiveEvents(sc, chainNodes, archiveEvents, rid); err != nil {
+				_ = session.AbortTransaction(context.Background())
+				return err
+			}
 		}
 
 		if err := f.tokenHandler.setLastWatchToken(sc, lastTokenData); err != nil {
@@ -583,7 +597,7 @@ func (f *InstanceFlow) doInsertEvents(chainNodesMap map[string][]*watch.ChainNod
 					blog.ErrorJSON("run flow, insert %s event with reduce node %s, remain nodes: %s, rid: %s",
 						key.Collection(), reducedChainNode, chainNodes, rid)
 
-					return f.doInsertEvents(chainNodesMap, lastTokenData, rid)
+					return f.doInsertEvents(chainNodesMap, archiveEvents, lastTokenData, rid)
 				}
 			}
 
@@ -592,7 +606,7 @@ func (f *InstanceFlow) doInsertEvents(chainNodesMap map[string][]*watch.ChainNod
 				key.Collection(), chainNodes[0], chainNodes[1:], rid)
 
 			chainNodesMap[conflictColl] = chainNodes[1:]
-			return f.doInsertEvents(chainNodesMap, lastTokenData, rid)
+			return f.doInsertEvents(chainNodesMap, archiveEvents, lastTokenData, rid)
 		}
 
 		// if an error occurred, roll back and re-watch again
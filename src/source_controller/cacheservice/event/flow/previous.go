@@ -0,0 +1,130 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package flow
+
+import (
+	"context"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// parsedEvent is the chain node and detail that an event is parsed into, its detail is stored after the previous
+// values of its changed fields are filled
+type parsedEvent struct {
+	key    event.Key
+	node   *watch.ChainNode
+	detail *eventDetail
+}
+
+// fillPreviousFields fills the previous values of the changed fields into the event infos of the general resource
+// update events, so that the watchers can tell if the resource matched their filters before it is updated.
+func fillPreviousFields(events []*parsedEvent, rid string) {
+	setPreviousFields(events, getPreviousDetails(events, rid), rid)
+}
+
+// getPreviousDetails get the details of the updated general resources from the general resource detail cache before
+// the details of this batch are stored, returns the map of the detail key to the previous detail.
+func getPreviousDetails(events []*parsedEvent, rid string) map[string]string {
+	previousDetails := make(map[string]string)
+
+	detailKeys := make([]string, 0)
+	for _, e := range events {
+		if e.node.EventType == watch.Update && e.key.IsGeneralRes() {
+			detailKeys = append(detailKeys, e.key.GeneralResDetailKey(e.node))
+		}
+	}
+
+	if len(detailKeys) == 0 {
+		return previousDetails
+	}
+
+	detailKeys = util.StrArrayUnique(detailKeys)
+	results, err := redis.Client().MGet(context.Background(), detailKeys...).Result()
+	if err != nil {
+		// the previous values of the changed fields are left unknown, the watchers will treat them conservatively
+		blog.Errorf("get previous details by keys(%+v) failed, err: %v, rid: %s", detailKeys, err, rid)
+		return previousDetails
+	}
+
+	for idx, result := range results {
+		detail, ok := result.(string)
+		if !ok || len(detail) == 0 {
+			continue
+		}
+		previousDetails[detailKeys[idx]] = detail
+	}
+
+	return previousDetails
+}
+
+// setPreviousFields sets the previous values of the changed fields into the event infos of the update events, the
+// previous detail of a resource is the detail of its former event in this batch, or the one in previousDetails.
+func setPreviousFields(events []*parsedEvent, previousDetails map[string]string, rid string) {
+	for _, e := range events {
+		if !e.key.IsGeneralRes() {
+			continue
+		}
+
+		detailKey := e.key.GeneralResDetailKey(e.node)
+		if previous, exists := previousDetails[detailKey]; exists && e.node.EventType == watch.Update {
+			e.detail.eventInfo = addPreviousFields(e.detail.eventInfo, previous, rid)
+		}
+		previousDetails[detailKey] = string(e.detail.resDetail)
+	}
+}
+
+// addPreviousFields adds the values of the changed fields in the previous detail to the event info, the previous
+// fields are set even if they are empty, so that the watchers know that the previous values are known.
+func addPreviousFields(eventInfo []byte, previousDetail string, rid string) []byte {
+	info := new(types.EventInfo)
+	if err := json.Unmarshal(eventInfo, info); err != nil {
+		blog.Errorf("unmarshal event info %s failed, err: %v, rid: %s", eventInfo, err, rid)
+		return eventInfo
+	}
+
+	previous := make(map[string]interface{})
+	fields := make([]string, 0, len(info.UpdatedFields)+len(info.RemovedFields))
+	for field := range info.UpdatedFields {
+		fields = append(fields, field)
+	}
+	fields = append(fields, info.RemovedFields...)
+
+	for _, field := range fields {
+		value := gjson.Get(previousDetail, field)
+		if value.Exists() {
+			previous[field] = value.Value()
+		}
+	}
+
+	filled, err := sjson.SetBytes(eventInfo, "previous_fields", previous)
+	if err != nil {
+		blog.Errorf("set previous fields %+v to event info %s failed, err: %v, rid: %s", previous, eventInfo, err, rid)
+		return eventInfo
+	}
+
+	return filled
+}
@@ -0,0 +1,127 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package flow
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/json"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+)
+
+func TestSetPreviousFields(t *testing.T) {
+	cachedHost := &parsedEvent{
+		key:  event.HostKey,
+		node: &watch.ChainNode{InstanceID: 1, Oid: "oid", EventType: watch.Update},
+		detail: &eventDetail{
+			eventInfo: []byte(`{"update_fields":{"bk_host_name":"b","operator":"x"},"deleted_fields":["comment"]}`),
+			resDetail: []byte(`{"bk_host_id":1,"bk_host_name":"b","operator":"x"}`),
+		},
+	}
+	batchHost := &parsedEvent{
+		key:  event.HostKey,
+		node: &watch.ChainNode{InstanceID: 1, Oid: "oid", EventType: watch.Update},
+		detail: &eventDetail{eventInfo: []byte(`{"update_fields":{"bk_host_name":"c"}}`),
+			resDetail: []byte(`{"bk_host_id":1,"bk_host_name":"c","operator":"x"}`)},
+	}
+	unknownHost := &parsedEvent{
+		key:  event.HostKey,
+		node: &watch.ChainNode{InstanceID: 2, Oid: "oid", EventType: watch.Update},
+		detail: &eventDetail{eventInfo: []byte(`{"update_fields":{"bk_host_name":"d"}}`),
+			resDetail: []byte(`{"bk_host_id":2,"bk_host_name":"d"}`)},
+	}
+	createdHost := &parsedEvent{
+		key:    event.HostKey,
+		node:   &watch.ChainNode{InstanceID: 3, Oid: "oid", EventType: watch.Create},
+		detail: &eventDetail{eventInfo: []byte(`{}`), resDetail: []byte(`{"bk_host_id":3,"bk_host_name":"e"}`)},
+	}
+	updatedHost := &parsedEvent{
+		key:  event.HostKey,
+		node: &watch.ChainNode{InstanceID: 3, Oid: "oid", EventType: watch.Update},
+		detail: &eventDetail{eventInfo: []byte(`{"update_fields":{"bk_host_name":"f"}}`),
+			resDetail: []byte(`{"bk_host_id":3,"bk_host_name":"f"}`)},
+	}
+
+	previousDetails := map[string]string{
+		event.HostKey.GeneralResDetailKey(cachedHost.node): `{"bk_host_id":1,"bk_host_name":"a","comment":"y"}`,
+	}
+	events := []*parsedEvent{cachedHost, batchHost, unknownHost, createdHost, updatedHost}
+	setPreviousFields(events, previousDetails, "")
+
+	tests := []struct {
+		name     string
+		event    *parsedEvent
+		previous map[string]interface{}
+	}{
+		{
+			name:  "previous detail in cache",
+			event: cachedHost,
+			// operator did not exist before, so it is not in the previous fields
+			previous: map[string]interface{}{"bk_host_name": "a", "comment": "y"},
+		},
+		{
+			name:     "previous detail of the former event in batch",
+			event:    batchHost,
+			previous: map[string]interface{}{"bk_host_name": "b"},
+		},
+		{
+			name:     "previous detail is unknown",
+			event:    unknownHost,
+			previous: nil,
+		},
+		{
+			name:     "previous detail of the create event in batch",
+			event:    updatedHost,
+			previous: map[string]interface{}{"bk_host_name": "e"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := new(types.EventInfo)
+			if err := json.Unmarshal(tt.event.detail.eventInfo, info); err != nil {
+				t.Fatalf("unmarshal event info failed, err: %v", err)
+			}
+
+			if !reflect.DeepEqual(info.PreviousFields, tt.previous) {
+				t.Errorf("previous fields = %v, want %v", info.PreviousFields, tt.previous)
+			}
+		})
+	}
+
+	if string(createdHost.detail.eventInfo) != `{}` {
+		t.Errorf("previous fields should not be set to create event, event info: %s", createdHost.detail.eventInfo)
+	}
+}
+
+func TestAddEmptyPreviousFields(t *testing.T) {
+	// the previous fields are kept even if all the changed fields did not exist before, so that it is known
+	eventInfo := addPreviousFields([]byte(`{"update_fields":{"operator":"x"}}`), `{"bk_host_id":1}`, "")
+
+	info := new(types.EventInfo)
+	if err := json.Unmarshal(eventInfo, info); err != nil {
+		t.Fatalf("unmarshal event info failed, err: %v", err)
+	}
+
+	if info.PreviousFields == nil || len(info.PreviousFields) != 0 {
+		t.Fatalf("previous fields %v should be empty but known, event info: %s", info.PreviousFields, eventInfo)
+	}
+}
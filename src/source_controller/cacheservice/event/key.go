@@ -511,6 +511,227 @@ var ProjectKey = Key{
 	},
 }
 
+var modelFields = []string{common.BKFieldID, common.BKObjIDField, common.BKObjNameField}
+
+// ModelKey model event watch key
+var ModelKey = Key{
+	namespace:          watchCacheNamespace + "model",
+	collection:         common.BKTableNameObjDes,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.ModelKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelFields...)
+		for idx := range modelFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKObjNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelAttrFields = []string{common.BKFieldID, common.BKObjIDField, common.BKPropertyIDField}
+
+// ModelAttributeKey model attribute event watch key
+var ModelAttributeKey = Key{
+	namespace:          watchCacheNamespace + "model_attribute",
+	collection:         common.BKTableNameObjAttDes,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.ModelAttributeKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelAttrFields...)
+		for idx := range modelAttrFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelAttrFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKPropertyNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelAttrGroupFields = []string{common.BKFieldID, common.BKObjIDField, common.BKPropertyGroupIDField}
+
+// ModelAttrGroupKey model attribute group event watch key
+var ModelAttrGroupKey = Key{
+	namespace:          watchCacheNamespace + "model_attribute_group",
+	collection:         common.BKTableNamePropertyGroup,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.ModelAttrGroupKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelAttrGroupFields...)
+		for idx := range modelAttrGroupFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelAttrGroupFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKPropertyGroupNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelUniqueFields = []string{common.BKFieldID, common.BKObjIDField}
+
+// ModelUniqueKey model unique rule event watch key
+var ModelUniqueKey = Key{
+	namespace:          watchCacheNamespace + "model_unique",
+	collection:         common.BKTableNameObjUnique,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.ModelUniqueKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelUniqueFields...)
+		for idx := range modelUniqueFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelUniqueFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKObjIDField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelAsstFields = []string{common.BKFieldID, common.AssociationObjAsstIDField}
+
+// ModelAssociationKey model association event watch key
+var ModelAssociationKey = Key{
+	namespace:          watchCacheNamespace + "model_association",
+	collection:         common.BKTableNameObjAsst,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.ModelAssociationKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelAsstFields...)
+		for idx := range modelAsstFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelAsstFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.AssociationObjAsstIDField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var templateFields = []string{common.BKFieldID, common.BKFieldName}
+
+// ServiceTemplateKey service template event watch key
+var ServiceTemplateKey = Key{
+	namespace:          watchCacheNamespace + "service_template",
+	collection:         common.BKTableNameServiceTemplate,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.ServiceTemplateKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, templateFields...)
+		for idx := range templateFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", templateFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// SetTemplateKey set template event watch key
+var SetTemplateKey = Key{
+	namespace:          watchCacheNamespace + "set_template",
+	collection:         common.BKTableNameSetTemplate,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.SetTemplateKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, templateFields...)
+		for idx := range templateFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", templateFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// FieldTemplateKey field template event watch key
+var FieldTemplateKey = Key{
+	namespace:          watchCacheNamespace + "field_template",
+	collection:         common.BKTableNameFieldTemplate,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.FieldTemplateKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, templateFields...)
+		for idx := range templateFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", templateFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var hostApplyRuleFields = []string{common.BKFieldID, common.BKAttributeIDField}
+
+// HostApplyRuleKey host apply rule event watch key
+var HostApplyRuleKey = Key{
+	namespace:          watchCacheNamespace + "host_apply_rule",
+	collection:         common.BKTableNameHostApplyRule,
+	ttlSeconds:         6 * 60 * 60,
+	generalResCacheKey: general.HostApplyRuleKey,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, hostApplyRuleFields...)
+		for idx := range hostApplyRuleFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", hostApplyRuleFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKAttributeIDField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
 // Key TODO
 type Key struct {
 	namespace string
@@ -44,6 +44,15 @@ var resourceKeyMap = map[watch.CursorType]Key{
 	watch.KubeWorkload:            KubeWorkloadKey,
 	watch.KubePod:                 KubePodKey,
 	watch.Project:                 ProjectKey,
+	watch.Model:                   ModelKey,
+	watch.ModelAttribute:          ModelAttributeKey,
+	watch.ModelAttrGroup:          ModelAttrGroupKey,
+	watch.ModelUnique:             ModelUniqueKey,
+	watch.ModelAssociation:        ModelAssociationKey,
+	watch.ServiceTemplate:         ServiceTemplateKey,
+	watch.SetTemplate:             SetTemplateKey,
+	watch.FieldTemplate:           FieldTemplateKey,
+	watch.HostApplyRule:           HostApplyRuleKey,
 }
 
 // GetResourceKeyWithCursorType get resource key
@@ -0,0 +1,110 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/source_controller/cacheservice/event/archive"
+)
+
+// watchWithArchivedCursor watches the events after the cursor that is expired in the event chain from the event
+// archive, so that the user can catch up with the events after a long outage. the events are returned without waiting,
+// and the user is switched back to the event chain once the returned cursor is not expired in it.
+// returns false if the cursor is not archived either.
+func (c *Client) watchWithArchivedCursor(kit *rest.Kit, key event.Key, opts *watch.WatchEventOptions) (
+	[]*watch.WatchEventDetail, bool, error) {
+
+	startEvent, exists, err := c.archive.GetEvent(kit.Ctx, key, opts.Cursor, kit.Rid)
+	if err != nil {
+		return nil, false, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if !exists {
+		return nil, false, nil
+	}
+
+	searchOpt := &archive.SearchOption{
+		Key:          key,
+		ID:           startEvent.ID,
+		Limit:        eventStep,
+		Types:        opts.EventTypes,
+		SubResource:  opts.Filter.SubResource,
+		SubResources: opts.Filter.SubResources,
+	}
+	archivedEvents, err := c.archive.SearchFollowingEvents(kit.Ctx, searchOpt, kit.Rid)
+	if err != nil {
+		return nil, true, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(archivedEvents) == 0 {
+		return make([]*watch.WatchEventDetail, 0), true, nil
+	}
+
+	blog.V(4).Infof("watch %s events after archived cursor %s, got %d events, rid: %s", key.Collection(),
+		opts.Cursor, len(archivedEvents), kit.Rid)
+
+	// details need to contain the filtered fields, they are cut to the watched fields after they are filtered
+	fields := opts.Fields
+	if opts.Filter.NeedDetailFilter() {
+		fields = opts.Filter.DetailFields(opts.Fields)
+	}
+
+	events := make([]*watch.WatchEventDetail, len(archivedEvents))
+	changeMap := make(map[string]*eventChange)
+	for idx, e := range archivedEvents {
+		detail, err := e.GetDetail()
+		if err != nil {
+			blog.Errorf("get archived event %s detail failed, err: %v, rid: %s", e.Cursor, err, kit.Rid)
+			return nil, true, err
+		}
+
+		detailStr := string(detail)
+		events[idx] = &watch.WatchEventDetail{
+			Cursor:    e.Cursor,
+			Resource:  opts.Resource,
+			EventType: e.EventType,
+			Detail:    watch.JsonString(*json.CutJsonDataWithFields(&detailStr, fields)),
+			ChainNode: &archivedEvents[idx].ChainNode,
+		}
+
+		if len(e.ChangedFields) == 0 {
+			continue
+		}
+
+		changed := make(map[string]struct{}, len(e.ChangedFields))
+		for _, field := range e.ChangedFields {
+			changed[field] = struct{}{}
+		}
+		changeMap[e.Cursor] = &eventChange{fields: changed}
+	}
+
+	if !opts.Filter.NeedDetailFilter() {
+		return events, true, nil
+	}
+
+	hitEvents := filterEventsWithChanges(kit, opts, events, changeMap)
+	if len(hitEvents) == 0 {
+		return []*watch.WatchEventDetail{{
+			Cursor:   events[len(events)-1].Cursor,
+			Resource: opts.Resource,
+			Detail:   nil,
+		}}, true, nil
+	}
+
+	return hitEvents, true, nil
+}
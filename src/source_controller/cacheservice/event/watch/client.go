@@ -26,6 +26,7 @@ import (
 	"configcenter/src/common/watch"
 	kubetypes "configcenter/src/kube/types"
 	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/source_controller/cacheservice/event/archive"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/redis"
 	daltypes "configcenter/src/storage/dal/types"
@@ -45,11 +46,14 @@ type Client struct {
 
 	// db is cc main database.
 	db dal.DB
+
+	// archive is the event archive client, it is used to watch the events whose cursors are expired in event chain.
+	archive *archive.Archive
 }
 
 // NewClient TODO
-func NewClient(watchDB dal.DB, db dal.DB, cache redis.Client) *Client {
-	return &Client{watchDB: watchDB, db: db, cache: cache}
+func NewClient(watchDB dal.DB, db dal.DB, cache redis.Client, archive *archive.Archive) *Client {
+	return &Client{watchDB: watchDB, db: db, cache: cache, archive: archive}
 }
 
 // getLatestEvent search latest event chain node in not expired nodes
@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/sjson"
+)
+
+// eventChange is the changed fields of an update event and their values before the update
+type eventChange struct {
+	fields map[string]struct{}
+	// previous is the values of the changed fields before the update, nil means the previous values are unknown
+	previous map[string]interface{}
+}
+
+// filterEventsByDetail filters the events by the detail filter and changed fields of the watch options, the details
+// of the events must contain all the fields that are needed by the filter, they are cut to the watched fields after
+// they are filtered.
+func (c *Client) filterEventsByDetail(kit *rest.Kit, key event.Key, opts *watch.WatchEventOptions,
+	events []*watch.WatchEventDetail) ([]*watch.WatchEventDetail, error) {
+
+	changeMap, err := c.searchEventChanges(kit, key, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterEventsWithChanges(kit, opts, events, changeMap), nil
+}
+
+// filterEventsWithChanges filters the events by the detail filter and changed fields of the watch options,
+// changeMap is the map of event cursor to its changes.
+func filterEventsWithChanges(kit *rest.Kit, opts *watch.WatchEventOptions, events []*watch.WatchEventDetail,
+	changeMap map[string]*eventChange) []*watch.WatchEventDetail {
+
+	hitEvents := make([]*watch.WatchEventDetail, 0)
+	for _, e := range events {
+		detail, ok := e.Detail.(watch.JsonString)
+		if !ok || len(detail) == 0 {
+			continue
+		}
+
+		change := changeMap[e.Cursor]
+		if !isEventHitChangedFields(e, opts.Filter.ChangedFields, change) {
+			continue
+		}
+
+		if !isEventHitFilter(e, opts.Filter.Filter, detail, change, kit.Rid) {
+			continue
+		}
+
+		if len(opts.Fields) > 0 {
+			detailStr := string(detail)
+			e.Detail = watch.JsonString(*json.CutJsonDataWithFields(&detailStr, opts.Fields))
+		}
+		hitEvents = append(hitEvents, e)
+	}
+
+	return hitEvents
+}
+
+// isEventHitChangedFields checks if the update event changes any of the changed fields, create and delete events
+// are always hit. if the changes of the event is unknown, the event is regarded as hit so that no event is lost.
+func isEventHitChangedFields(e *watch.WatchEventDetail, fields []string, change *eventChange) bool {
+	if len(fields) == 0 || e.EventType != watch.Update || change == nil {
+		return true
+	}
+
+	return isFieldsChanged(fields, change.fields)
+}
+
+// isEventHitFilter checks if the event detail matches the filter. an update event whose current detail is not
+// matched is also hit if its previous detail is matched, so that the watchers know the resource leaves the filter.
+// if the previous detail is unknown, the update event is hit if it changes any of the filtered fields.
+func isEventHitFilter(e *watch.WatchEventDetail, expr *filter.Expression, detail watch.JsonString,
+	change *eventChange, rid string) bool {
+
+	if expr == nil {
+		return true
+	}
+
+	matched, err := expr.Match(filter.JsonString(detail))
+	if err != nil {
+		blog.V(4).Infof("match event %s detail with filter failed, err: %v, rid: %s", e.Cursor, err, rid)
+		matched = false
+	}
+
+	if matched || e.EventType != watch.Update {
+		return matched
+	}
+
+	if change == nil {
+		return true
+	}
+
+	if !isFieldsChanged(expr.RuleFields(), change.fields) {
+		return false
+	}
+
+	if change.previous == nil {
+		return true
+	}
+
+	previousDetail, err := buildPreviousDetail(detail, change)
+	if err != nil {
+		blog.Errorf("build event %s previous detail failed, err: %v, rid: %s", e.Cursor, err, rid)
+		return true
+	}
+
+	previousMatched, err := expr.Match(filter.JsonString(previousDetail))
+	if err != nil {
+		blog.V(4).Infof("match event %s previous detail with filter failed, err: %v, rid: %s", e.Cursor, err, rid)
+		return false
+	}
+
+	return previousMatched
+}
+
+// buildPreviousDetail restores the detail before the update by resetting the changed fields to their previous values,
+// the changed fields that did not exist before are removed.
+func buildPreviousDetail(detail watch.JsonString, change *eventChange) (string, error) {
+	previousDetail := string(detail)
+	for field := range change.fields {
+		var err error
+		if value, exists := change.previous[field]; exists {
+			previousDetail, err = sjson.Set(previousDetail, field, value)
+		} else {
+			previousDetail, err = sjson.Delete(previousDetail, field)
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return previousDetail, nil
+}
+
+// isFieldsChanged checks if any of the fields is changed, the sub field of an object field is regarded as changed
+// if the object field is changed, and vice versa.
+func isFieldsChanged(fields []string, changed map[string]struct{}) bool {
+	for _, field := range fields {
+		if _, exists := changed[field]; exists {
+			return true
+		}
+
+		for changedField := range changed {
+			if strings.HasPrefix(field, changedField+".") || strings.HasPrefix(changedField, field+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// searchEventChanges get the updated and removed fields of the update events and their previous values from redis,
+// returns the map of event cursor to its changes. the events whose changes are not found are not in the map.
+func (c *Client) searchEventChanges(kit *rest.Kit, key event.Key, events []*watch.WatchEventDetail) (
+	map[string]*eventChange, error) {
+
+	changeMap := make(map[string]*eventChange)
+
+	detailKeys := make([]string, 0)
+	cursors := make([]string, 0)
+	for _, e := range events {
+		if e.EventType != watch.Update {
+			continue
+		}
+		detailKeys = append(detailKeys, key.DetailKey(e.Cursor))
+		cursors = append(cursors, e.Cursor)
+	}
+
+	if len(detailKeys) == 0 {
+		return changeMap, nil
+	}
+
+	results, err := c.cache.MGet(kit.Ctx, detailKeys...).Result()
+	if err != nil {
+		blog.Errorf("search event changes by keys(%+v) failed, err: %v, rid: %s", detailKeys, err, kit.Rid)
+		return nil, fmt.Errorf("search event changes failed, err: %v", err)
+	}
+
+	for idx, result := range results {
+		resultStr, ok := result.(string)
+		if !ok || len(resultStr) == 0 {
+			continue
+		}
+
+		info := new(types.EventInfo)
+		if err := json.UnmarshalFromString(resultStr, info); err != nil {
+			blog.Errorf("unmarshal event %s info failed, err: %v, info: %s, rid: %s", cursors[idx], err, resultStr,
+				kit.Rid)
+			continue
+		}
+
+		changed := make(map[string]struct{}, len(info.UpdatedFields)+len(info.RemovedFields))
+		for field := range info.UpdatedFields {
+			changed[field] = struct{}{}
+		}
+		for _, field := range info.RemovedFields {
+			changed[field] = struct{}{}
+		}
+		changeMap[cursors[idx]] = &eventChange{fields: changed, previous: info.PreviousFields}
+	}
+
+	return changeMap, nil
+}
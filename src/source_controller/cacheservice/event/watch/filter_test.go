@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"strings"
+	"testing"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+)
+
+func TestFilterEventsWithChanges(t *testing.T) {
+	opts := &watch.WatchEventOptions{
+		Filter: watch.WatchEventFilter{
+			Filter: &filter.Expression{
+				RuleFactory: &filter.AtomRule{Field: "bk_biz_id", Operator: filter.Equal.Factory(), Value: 3},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		event  *watch.WatchEventDetail
+		change *eventChange
+		hit    bool
+	}{
+		{
+			name: "matched now",
+			event: &watch.WatchEventDetail{Cursor: "1", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":3,"bk_host_name":"a"}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_host_name": {}}, previous: map[string]interface{}{}},
+			hit:    true,
+		},
+		{
+			name: "matched before but not now",
+			event: &watch.WatchEventDetail{Cursor: "2", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_biz_id": {}},
+				previous: map[string]interface{}{"bk_biz_id": 3}},
+			hit: true,
+		},
+		{
+			name: "filtered field changed but not matched before nor now",
+			event: &watch.WatchEventDetail{Cursor: "3", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_biz_id": {}},
+				previous: map[string]interface{}{"bk_biz_id": 5}},
+			hit: false,
+		},
+		{
+			name: "filtered field did not exist before",
+			event: &watch.WatchEventDetail{Cursor: "4", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_biz_id": {}},
+				previous: map[string]interface{}{}},
+			hit: false,
+		},
+		{
+			name: "matched before and the filtered field is removed",
+			event: &watch.WatchEventDetail{Cursor: "5", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_biz_id": {}},
+				previous: map[string]interface{}{"bk_biz_id": 3}},
+			hit: true,
+		},
+		{
+			name: "filtered field not changed",
+			event: &watch.WatchEventDetail{Cursor: "6", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4,"bk_host_name":"a"}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_host_name": {}}},
+			hit:    false,
+		},
+		{
+			name: "previous values unknown",
+			event: &watch.WatchEventDetail{Cursor: "7", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4}`)},
+			change: &eventChange{fields: map[string]struct{}{"bk_biz_id": {}}},
+			hit:    true,
+		},
+		{
+			name: "changes unknown",
+			event: &watch.WatchEventDetail{Cursor: "8", EventType: watch.Update,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4}`)},
+			hit: true,
+		},
+		{
+			name: "create event not matched",
+			event: &watch.WatchEventDetail{Cursor: "9", EventType: watch.Create,
+				Detail: watch.JsonString(`{"bk_host_id":1,"bk_biz_id":4}`)},
+			hit: false,
+		},
+	}
+
+	kit := &rest.Kit{Rid: "test"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changeMap := make(map[string]*eventChange)
+			if tt.change != nil {
+				changeMap[tt.event.Cursor] = tt.change
+			}
+
+			hitEvents := filterEventsWithChanges(kit, opts, []*watch.WatchEventDetail{tt.event}, changeMap)
+			if (len(hitEvents) == 1) != tt.hit {
+				t.Errorf("event hit = %v, want %v", len(hitEvents) == 1, tt.hit)
+			}
+		})
+	}
+}
+
+func TestBuildPreviousDetail(t *testing.T) {
+	change := &eventChange{
+		fields:   map[string]struct{}{"bk_host_name": {}, "operator": {}, "labels.env": {}},
+		previous: map[string]interface{}{"bk_host_name": "a", "labels.env": "test"},
+	}
+
+	previous, err := buildPreviousDetail(watch.JsonString(`{"bk_host_id":1,"bk_host_name":"b","operator":"x",`+
+		`"labels":{"env":"prod","app":"cc"}}`), change)
+	if err != nil {
+		t.Fatalf("build previous detail failed, err: %v", err)
+	}
+
+	expr := &filter.Expression{
+		RuleFactory: &filter.CombinedRule{
+			Condition: filter.And,
+			Rules: []filter.RuleFactory{
+				&filter.AtomRule{Field: "bk_host_name", Operator: filter.Equal.Factory(), Value: "a"},
+				&filter.AtomRule{Field: "labels.env", Operator: filter.Equal.Factory(), Value: "test"},
+				&filter.AtomRule{Field: "labels.app", Operator: filter.Equal.Factory(), Value: "cc"},
+			},
+		},
+	}
+
+	matched, err := expr.Match(filter.JsonString(previous))
+	if err != nil || !matched {
+		t.Fatalf("previous detail %s is not restored, matched: %v, err: %v", previous, matched, err)
+	}
+
+	// the operator did not exist before, so it is removed from the previous detail
+	if strings.Contains(previous, "operator") {
+		t.Fatalf("previous detail %s should not contain operator", previous)
+	}
+}
@@ -92,6 +92,10 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 			}}, nil
 		}
 
+		if opts.Filter.NeedDetailFilter() {
+			return c.getEventDetailsWithNodes(kit, opts, []*watch.ChainNode{tailNode}, key)
+		}
+
 		detail, exists, err := c.getEventDetail(kit, tailNode, opts.Fields, key)
 		if err != nil {
 			blog.Errorf("get latest event detail failed, err: %v, rid: %s", err, rid)
@@ -184,10 +188,45 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 	return c.getEventDetailsWithNodes(kit, opts, nodes, key)
 }
 
-// getEventDetailsWithNodes get event details with nodes, first get from redis, then get failed ones from mongo
+// getEventDetailsWithNodes get event details with nodes, and filter them by the detail filter if it is set.
+// if all the events are filtered out, the last node's cursor is returned with no detail so that the user can watch
+// from it in the next round.
 func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventOptions, hitNodes []*watch.ChainNode,
-	key event.Key) (
-	[]*watch.WatchEventDetail, error) {
+	key event.Key) ([]*watch.WatchEventDetail, error) {
+
+	if !opts.Filter.NeedDetailFilter() {
+		return c.searchEventDetailsWithNodes(kit, opts, opts.Fields, hitNodes, key)
+	}
+
+	if len(hitNodes) == 0 {
+		return make([]*watch.WatchEventDetail, 0), nil
+	}
+
+	// details need to contain the filtered fields, they are cut to the watched fields after they are filtered
+	events, err := c.searchEventDetailsWithNodes(kit, opts, opts.Filter.DetailFields(opts.Fields), hitNodes, key)
+	if err != nil {
+		return nil, err
+	}
+
+	hitEvents, err := c.filterEventsByDetail(kit, key, opts, events)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hitEvents) == 0 {
+		return []*watch.WatchEventDetail{{
+			Cursor:   hitNodes[len(hitNodes)-1].Cursor,
+			Resource: opts.Resource,
+			Detail:   nil,
+		}}, nil
+	}
+
+	return hitEvents, nil
+}
+
+// searchEventDetailsWithNodes get event details with nodes, first get from redis, then get failed ones from mongo
+func (c *Client) searchEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventOptions, fields []string,
+	hitNodes []*watch.ChainNode, key event.Key) ([]*watch.WatchEventDetail, error) {
 
 	if len(hitNodes) == 0 {
 		return make([]*watch.WatchEventDetail, 0), nil
@@ -211,7 +250,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 	if len(errNodes) == 0 {
 		resp := make([]*watch.WatchEventDetail, len(details))
 		for idx, detail := range details {
-			detail = *json.CutJsonDataWithFields(&detail, opts.Fields)
+			detail = *json.CutJsonDataWithFields(&detail, fields)
 			resp[idx] = &watch.WatchEventDetail{
 				Cursor:    hitNodes[idx].Cursor,
 				Resource:  opts.Resource,
@@ -223,7 +262,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 		return resp, nil
 	}
 
-	indexDetailMap, err := c.searchEventDetailsFromMongo(kit, errNodes, opts.Fields, errCursorIndexMap, key)
+	indexDetailMap, err := c.searchEventDetailsFromMongo(kit, errNodes, fields, errCursorIndexMap, key)
 	if err != nil {
 		blog.Errorf("get details from mongo failed, err: %v, cursors: %+v, rid: %s", err, errNodes, kit.Rid)
 		return nil, err
@@ -239,7 +278,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 			if !key.IsGeneralRes() {
 				jsonStr = types.GetEventDetail(&detail)
 			}
-			detail = *json.CutJsonDataWithFields(jsonStr, opts.Fields)
+			detail = *json.CutJsonDataWithFields(jsonStr, fields)
 		}
 
 		resp[idx] = &watch.WatchEventDetail{
@@ -534,6 +573,15 @@ func (c *Client) WatchFromNow(kit *rest.Kit, key event.Key, opts *watch.WatchEve
 		}, nil
 	}
 
+	if opts.Filter.NeedDetailFilter() {
+		events, err := c.getEventDetailsWithNodes(kit, opts, []*watch.ChainNode{node}, key)
+		if err != nil {
+			blog.Errorf("watch from now, but get latest event detail failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+		return events[0], nil
+	}
+
 	detail, exists, err := c.getEventDetail(kit, node, opts.Fields, key)
 	if err != nil {
 		blog.Errorf("watch from now, but get latest event detail failed, err: %v, rid: %s", err, rid)
@@ -594,12 +642,35 @@ func (c *Client) WatchWithCursor(kit *rest.Kit, key event.Key, opts *watch.Watch
 	}
 
 	if !exists && opts.Cursor != watch.NoEventCursor {
+		// the cursor may be expired in the event chain, try to watch from the event archive
+		if c.archive.Enabled() {
+			events, archived, err := c.watchWithArchivedCursor(kit, key, opts)
+			if err != nil {
+				blog.Errorf("watch with archived cursor %s failed, err: %v, rid: %s", opts.Cursor, err, kit.Rid)
+				return nil, err
+			}
+
+			if archived {
+				return events, nil
+			}
+		}
+
 		return nil, kit.CCError.CCError(common.CCErrEventChainNodeNotExist)
 	}
 
 	for {
 		if len(nodes) != 0 {
-			return c.getEventDetailsWithNodes(kit, opts, nodes, key)
+			events, err := c.getEventDetailsWithNodes(kit, opts, nodes, key)
+			if err != nil {
+				return nil, err
+			}
+
+			// all events are filtered out by their details, continue to watch the following events until timeout
+			if !opts.Filter.NeedDetailFilter() || events[0].Detail != nil ||
+				time.Now().Unix()-start > timeoutWatchLoopSeconds {
+				return events, nil
+			}
+			nodeID = nodes[len(nodes)-1].ID
 		}
 
 		// we got not even one event, sleep a little, and then try to continue the loop watch
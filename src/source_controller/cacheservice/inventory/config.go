@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"configcenter/pkg/cache/general"
+	"configcenter/pkg/filter"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+)
+
+const (
+	// defaultIntervalSeconds is the default interval seconds of computing the inventory metrics
+	defaultIntervalSeconds = 300
+	// minIntervalSeconds is the min interval seconds of computing the inventory metrics, computing them needs to
+	// traverse all the cached resources, so it can not be too frequent
+	minIntervalSeconds = 60
+	// defaultMaxSeries is the default max series count of a metric
+	defaultMaxSeries = 500
+	// maxMaxSeries is the upper limit of the max series count of a metric
+	maxMaxSeries = 10000
+)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Config is the inventory exporter config
+type Config struct {
+	// Enabled defines if the inventory metrics are exported
+	Enabled bool
+	// IntervalSeconds is the interval seconds of computing the inventory metrics
+	IntervalSeconds int
+	// MaxSeries is the max series count of a metric, the series with smaller values exceeding this limit are
+	// merged into one series whose label values are all "other"
+	MaxSeries int
+	// CustomMetrics are the user defined metrics that count the matched resources
+	CustomMetrics []CustomMetric
+}
+
+// CustomMetric is a user defined metric that counts the resources matching the filter, grouped by the labels
+type CustomMetric struct {
+	// Name is the metric name without the "cmdb_inventory_" prefix
+	Name string
+	Help string
+	// Resource and SubResource are the general cache resource type that is counted
+	Resource    general.ResType
+	SubResource string
+	// Filter is the json string of the filter expression, all resources are counted if it is not set
+	Filter string
+	// Labels are the resource fields that the counts are grouped by
+	Labels []string
+
+	expr *filter.Expression
+}
+
+// Interval returns the interval of computing the inventory metrics
+func (c *Config) Interval() time.Duration {
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// ParseConfig parse inventory exporter config
+func ParseConfig() (*Config, error) {
+	conf := &Config{IntervalSeconds: defaultIntervalSeconds, MaxSeries: defaultMaxSeries}
+
+	if !cc.IsExist("cacheService.inventoryExporter.enabled") {
+		return conf, nil
+	}
+
+	enabled, err := cc.Bool("cacheService.inventoryExporter.enabled")
+	if err != nil {
+		blog.Errorf("get cacheService.inventoryExporter.enabled error, err: %v", err)
+		return nil, err
+	}
+	conf.Enabled = enabled
+
+	if cc.IsExist("cacheService.inventoryExporter.intervalSeconds") {
+		conf.IntervalSeconds, err = cc.Int("cacheService.inventoryExporter.intervalSeconds")
+		if err != nil {
+			blog.Errorf("get cacheService.inventoryExporter.intervalSeconds error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	if cc.IsExist("cacheService.inventoryExporter.maxSeries") {
+		conf.MaxSeries, err = cc.Int("cacheService.inventoryExporter.maxSeries")
+		if err != nil {
+			blog.Errorf("get cacheService.inventoryExporter.maxSeries error, err: %v", err)
+			return nil, err
+		}
+	}
+
+	if cc.IsExist("cacheService.inventoryExporter.customMetrics") {
+		err = cc.UnmarshalKey("cacheService.inventoryExporter.customMetrics", &conf.CustomMetrics)
+		if err != nil {
+			blog.Errorf("parse cacheService.inventoryExporter.customMetrics failed, err: %v", err)
+			return nil, err
+		}
+	}
+
+	if err = conf.Validate(); err != nil {
+		blog.Errorf("inventory exporter config is invalid, err: %v", err)
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// Validate inventory exporter config, and parse the custom metric filters
+func (c *Config) Validate() error {
+	if c.IntervalSeconds < minIntervalSeconds {
+		return fmt.Errorf("interval seconds %d is less than %d", c.IntervalSeconds, minIntervalSeconds)
+	}
+
+	if c.MaxSeries <= 0 || c.MaxSeries > maxMaxSeries {
+		return fmt.Errorf("max series %d exceeds the range [1, %d]", c.MaxSeries, maxMaxSeries)
+	}
+
+	names := make(map[string]struct{})
+	for _, name := range builtinMetricNames {
+		names[name] = struct{}{}
+	}
+
+	for idx := range c.CustomMetrics {
+		metric := &c.CustomMetrics[idx]
+
+		if !metricNameRegexp.MatchString(metric.Name) {
+			return fmt.Errorf("custom metric name %s is invalid, index: %d", metric.Name, idx)
+		}
+
+		if _, exists := names[metric.Name]; exists {
+			return fmt.Errorf("custom metric name %s is duplicated", metric.Name)
+		}
+		names[metric.Name] = struct{}{}
+
+		if err := metric.validate(); err != nil {
+			return fmt.Errorf("custom metric %s is invalid, %v", metric.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CustomMetric) validate() error {
+	if rawErr := m.Resource.ValidateWithSubRes(m.SubResource); rawErr.ErrCode != 0 {
+		return fmt.Errorf("resource %s or sub resource %s is invalid", m.Resource, m.SubResource)
+	}
+
+	labels := make(map[string]struct{})
+	for _, label := range m.Labels {
+		if !labelNameRegexp.MatchString(label) {
+			return fmt.Errorf("label %s is invalid", label)
+		}
+
+		if _, exists := labels[label]; exists {
+			return fmt.Errorf("label %s is duplicated", label)
+		}
+		labels[label] = struct{}{}
+	}
+
+	if len(m.Help) == 0 {
+		m.Help = fmt.Sprintf("count of %s resources matching the custom filter", m.Resource)
+	}
+
+	if len(m.Filter) == 0 {
+		return nil
+	}
+
+	expr := new(filter.Expression)
+	if err := json.Unmarshal([]byte(m.Filter), expr); err != nil {
+		return fmt.Errorf("filter is invalid, err: %v", err)
+	}
+
+	opt := filter.NewDefaultExprOpt(nil)
+	opt.IgnoreRuleFields = true
+	if err := expr.Validate(opt); err != nil {
+		return fmt.Errorf("filter is invalid, err: %v", err)
+	}
+	m.expr = expr
+
+	return nil
+}
+
+// fields returns the resource fields that are needed to compute the custom metric, nil means all fields
+func (m *CustomMetric) fields() []string {
+	if m.expr != nil {
+		return nil
+	}
+	return m.Labels
+}
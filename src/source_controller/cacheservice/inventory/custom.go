@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"configcenter/pkg/filter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+
+	"github.com/tidwall/gjson"
+)
+
+// computeCustomMetric counts the resources matching the custom metric filter, grouped by the label fields
+func (e *Exporter) computeCustomMetric(kit *rest.Kit, metric *CustomMetric) (*gauge, error) {
+	g := newGauge(len(metric.Labels))
+
+	if metric.expr == nil && len(metric.Labels) == 0 {
+		cnt, err := e.count(kit, metric.Resource, metric.SubResource)
+		if err != nil {
+			return nil, err
+		}
+		g.add(float64(cnt))
+		return g, nil
+	}
+
+	err := e.listAll(kit, metric.Resource, metric.SubResource, metric.fields(), func(detail string) error {
+		matched, err := metric.match(detail)
+		if err != nil {
+			blog.Errorf("match custom metric %s filter failed, data: %s, err: %v, rid: %s", metric.Name, detail, err,
+				kit.Rid)
+			return err
+		}
+
+		if matched {
+			g.add(1, metric.labelValues(detail)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (m *CustomMetric) match(detail string) (bool, error) {
+	if m.expr == nil {
+		return true, nil
+	}
+	return m.expr.Match(filter.JsonString(detail))
+}
+
+func (m *CustomMetric) labelValues(detail string) []string {
+	values := make([]string, len(m.Labels))
+	for idx, label := range m.Labels {
+		values[idx] = gjson.Get(detail, label).String()
+	}
+	return values
+}
@@ -0,0 +1,239 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package inventory exports the cmdb inventory metrics computed from the general resource cache to prometheus
+package inventory
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"configcenter/pkg/cache/general"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metrics"
+	"configcenter/src/common/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dataLister lists the general resource count or details from cache
+type dataLister interface {
+	ListData(kit *rest.Kit, opt *general.ListDetailOpt) (int64, []string, error)
+}
+
+// Exporter computes the inventory metrics periodically in the master cache service, and exports the last computed
+// metrics as a prometheus collector
+type Exporter struct {
+	conf     *Config
+	lister   dataLister
+	isMaster discovery.ServiceManageInterface
+	descs    map[string]*prometheus.Desc
+
+	lock    sync.RWMutex
+	metrics []prometheus.Metric
+}
+
+// New create an inventory exporter
+func New(conf *Config, lister dataLister, isMaster discovery.ServiceManageInterface) *Exporter {
+	e := &Exporter{
+		conf:     conf,
+		lister:   lister,
+		isMaster: isMaster,
+		descs:    make(map[string]*prometheus.Desc),
+	}
+
+	for name, desc := range builtinDescs {
+		e.descs[name] = prometheus.NewDesc(metricName(name), desc.help, desc.labels, nil)
+	}
+
+	for _, custom := range conf.CustomMetrics {
+		e.descs[custom.Name] = prometheus.NewDesc(metricName(custom.Name), custom.Help, custom.Labels, nil)
+	}
+
+	return e
+}
+
+func metricName(name string) string {
+	return metrics.Namespace + "_inventory_" + name
+}
+
+// Run registers the exporter to the prometheus registry and starts computing the inventory metrics if it is enabled
+func (e *Exporter) Run(registry prometheus.Registerer) error {
+	if !e.conf.Enabled {
+		return nil
+	}
+
+	if err := registry.Register(e); err != nil {
+		blog.Errorf("register inventory exporter failed, err: %v", err)
+		return err
+	}
+
+	go e.loop()
+	return nil
+}
+
+// Describe implements prometheus.Collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range e.descs {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector, it only exports the last computed metrics, the slave cache services do
+// not export the inventory metrics to avoid duplication
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	for _, metric := range e.metrics {
+		ch <- metric
+	}
+}
+
+func (e *Exporter) loop() {
+	for {
+		if !e.isMaster.IsMaster() {
+			e.setMetrics(nil)
+			time.Sleep(time.Minute)
+			continue
+		}
+
+		kit := &rest.Kit{
+			Rid:             util.GenerateRID(),
+			Header:          make(http.Header),
+			Ctx:             context.Background(),
+			CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("zh-cn"),
+			User:            common.CCSystemOperatorUserName,
+			SupplierAccount: common.BKSuperOwnerID,
+		}
+
+		start := time.Now()
+		metricList, err := e.compute(kit)
+		if err != nil {
+			// keep exporting the last computed metrics, they are computed again in the next round
+			blog.Errorf("compute inventory metrics failed, err: %v, rid: %s", err, kit.Rid)
+		} else {
+			e.setMetrics(metricList)
+			blog.Infof("compute inventory metrics success, cost: %s, rid: %s", time.Since(start), kit.Rid)
+		}
+
+		time.Sleep(e.conf.Interval())
+	}
+}
+
+func (e *Exporter) setMetrics(metricList []prometheus.Metric) {
+	e.lock.Lock()
+	e.metrics = metricList
+	e.lock.Unlock()
+}
+
+// compute all the inventory metrics
+func (e *Exporter) compute(kit *rest.Kit) ([]prometheus.Metric, error) {
+	gauges := make(map[string]*gauge)
+	for name, desc := range builtinDescs {
+		gauges[name] = newGauge(len(desc.labels))
+	}
+
+	topo, err := e.computeTopoMetrics(kit, gauges)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = e.computeHostMetrics(kit, gauges); err != nil {
+		return nil, err
+	}
+
+	if err = e.computeModelMetrics(kit, gauges); err != nil {
+		return nil, err
+	}
+
+	if err = e.computeDriftMetrics(kit, topo, gauges); err != nil {
+		return nil, err
+	}
+
+	for idx := range e.conf.CustomMetrics {
+		custom := &e.conf.CustomMetrics[idx]
+		g, err := e.computeCustomMetric(kit, custom)
+		if err != nil {
+			return nil, err
+		}
+		gauges[custom.Name] = g
+	}
+
+	metricList := make([]prometheus.Metric, 0)
+	for name, g := range gauges {
+		for _, s := range g.limit(e.conf.MaxSeries) {
+			metric, err := prometheus.NewConstMetric(e.descs[name], prometheus.GaugeValue, s.value, s.labels...)
+			if err != nil {
+				blog.Errorf("new inventory metric %s failed, labels: %v, err: %v, rid: %s", name, s.labels, err,
+					kit.Rid)
+				return nil, err
+			}
+			metricList = append(metricList, metric)
+		}
+	}
+
+	return metricList, nil
+}
+
+// listAll traverses all the general resource details in cache
+func (e *Exporter) listAll(kit *rest.Kit, resource general.ResType, subResource string, fields []string,
+	handler func(detail string) error) error {
+
+	opt := &general.ListDetailOpt{
+		Resource:    resource,
+		SubResource: subResource,
+		Fields:      fields,
+		Page:        &general.PagingOption{Limit: common.BKMaxLimitSize},
+	}
+
+	for {
+		_, details, err := e.lister.ListData(kit, opt)
+		if err != nil {
+			blog.Errorf("list %s %s data from cache failed, err: %v, rid: %s", resource, subResource, err, kit.Rid)
+			return err
+		}
+
+		for _, detail := range details {
+			if err = handler(detail); err != nil {
+				return err
+			}
+		}
+
+		if len(details) < common.BKMaxLimitSize {
+			return nil
+		}
+		opt.Page.StartIndex += common.BKMaxLimitSize
+	}
+}
+
+// count the general resources in cache
+func (e *Exporter) count(kit *rest.Kit, resource general.ResType, subResource string) (int64, error) {
+	opt := &general.ListDetailOpt{
+		Resource:    resource,
+		SubResource: subResource,
+		Page:        &general.PagingOption{EnableCount: true},
+	}
+
+	cnt, _, err := e.lister.ListData(kit, opt)
+	if err != nil {
+		blog.Errorf("count %s %s data from cache failed, err: %v, rid: %s", resource, subResource, err, kit.Rid)
+		return 0, err
+	}
+	return cnt, nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"sort"
+	"strings"
+)
+
+// otherLabelValue is the label value of the series that the exceeded series are merged into
+const otherLabelValue = "other"
+
+type series struct {
+	labels []string
+	value  float64
+}
+
+// gauge is the series of an inventory metric keyed by the label values
+type gauge struct {
+	labelCnt int
+	series   map[string]*series
+}
+
+func newGauge(labelCnt int) *gauge {
+	return &gauge{
+		labelCnt: labelCnt,
+		series:   make(map[string]*series),
+	}
+}
+
+// add the value to the series with the label values
+func (g *gauge) add(value float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	s, exists := g.series[key]
+	if !exists {
+		s = &series{labels: labels}
+		g.series[key] = s
+	}
+	s.value += value
+}
+
+// limit the series count to avoid high label cardinality, the series with the largest values are kept, and the
+// others are merged into one series whose label values are all "other"
+func (g *gauge) limit(maxSeries int) []series {
+	result := make([]series, 0, len(g.series))
+	for _, s := range g.series {
+		result = append(result, *s)
+	}
+
+	if len(result) <= maxSeries {
+		return result
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].value != result[j].value {
+			return result[i].value > result[j].value
+		}
+		return strings.Join(result[i].labels, "\x00") < strings.Join(result[j].labels, "\x00")
+	})
+
+	other := series{labels: make([]string, g.labelCnt)}
+	for idx := range other.labels {
+		other.labels[idx] = otherLabelValue
+	}
+
+	for _, s := range result[maxSeries-1:] {
+		other.value += s.value
+	}
+
+	return append(result[:maxSeries-1], other)
+}
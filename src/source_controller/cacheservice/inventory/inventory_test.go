@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/pkg/cache/general"
+	"configcenter/src/common/http/rest"
+)
+
+func TestGaugeLimit(t *testing.T) {
+	g := newGauge(1)
+	g.add(5, "a")
+	g.add(3, "b")
+	g.add(1, "c")
+	g.add(2, "d")
+	g.add(1, "a")
+
+	if cnt := len(g.limit(4)); cnt != 4 {
+		t.Fatalf("series count %d should not be limited", cnt)
+	}
+
+	result := g.limit(2)
+	if len(result) != 2 {
+		t.Fatalf("series count %d is not limited to 2", len(result))
+	}
+
+	if result[0].labels[0] != "a" || result[0].value != 6 {
+		t.Fatalf("the largest series %+v is not kept", result[0])
+	}
+
+	if result[1].labels[0] != otherLabelValue || result[1].value != 6 {
+		t.Fatalf("the other series %+v is invalid", result[1])
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	conf := &Config{
+		IntervalSeconds: defaultIntervalSeconds,
+		MaxSeries:       defaultMaxSeries,
+		CustomMetrics: []CustomMetric{{
+			Name:     "linux_hosts",
+			Resource: general.Host,
+			Filter:   `{"condition":"AND","rules":[{"field":"bk_os_type","operator":"equal","value":"1"}]}`,
+			Labels:   []string{"bk_cloud_id"},
+		}},
+	}
+
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("validate config failed, err: %v", err)
+	}
+
+	if conf.CustomMetrics[0].expr == nil || len(conf.CustomMetrics[0].Help) == 0 {
+		t.Fatalf("custom metric %+v is not parsed", conf.CustomMetrics[0])
+	}
+
+	invalidMetrics := []CustomMetric{
+		{Name: bizHostsMetric, Resource: general.Host},
+		{Name: "insts", Resource: general.ObjectInstance},
+		{Name: "hosts", Resource: general.Host, Labels: []string{"bk_cloud_id.id"}},
+		{Name: "hosts", Resource: general.Host, Filter: `{"condition":"AND"}`},
+	}
+
+	for _, metric := range invalidMetrics {
+		conf.CustomMetrics = []CustomMetric{metric}
+		if err := conf.Validate(); err == nil {
+			t.Fatalf("custom metric %+v should be invalid", metric)
+		}
+	}
+}
+
+type fakeLister struct {
+	details []string
+}
+
+// ListData returns the fake details by page
+func (f *fakeLister) ListData(_ *rest.Kit, opt *general.ListDetailOpt) (int64, []string, error) {
+	if opt.Page.EnableCount {
+		return int64(len(f.details)), nil, nil
+	}
+
+	start := int(opt.Page.StartIndex)
+	if start >= len(f.details) {
+		return 0, make([]string, 0), nil
+	}
+
+	end := start + int(opt.Page.Limit)
+	if end > len(f.details) {
+		end = len(f.details)
+	}
+	return 0, f.details[start:end], nil
+}
+
+func TestComputeCustomMetric(t *testing.T) {
+	conf := &Config{
+		IntervalSeconds: defaultIntervalSeconds,
+		MaxSeries:       defaultMaxSeries,
+		CustomMetrics: []CustomMetric{{
+			Name:     "linux_hosts",
+			Resource: general.Host,
+			Filter:   `{"condition":"AND","rules":[{"field":"bk_os_type","operator":"equal","value":"1"}]}`,
+			Labels:   []string{"bk_cloud_id"},
+		}},
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("validate config failed, err: %v", err)
+	}
+
+	lister := &fakeLister{details: []string{
+		`{"bk_host_id":1,"bk_os_type":"1","bk_cloud_id":0}`,
+		`{"bk_host_id":2,"bk_os_type":"2","bk_cloud_id":0}`,
+		`{"bk_host_id":3,"bk_os_type":"1","bk_cloud_id":1}`,
+		`{"bk_host_id":4,"bk_os_type":"1","bk_cloud_id":0}`,
+	}}
+
+	e := New(conf, lister, nil)
+	kit := &rest.Kit{Header: make(http.Header)}
+
+	g, err := e.computeCustomMetric(kit, &conf.CustomMetrics[0])
+	if err != nil {
+		t.Fatalf("compute custom metric failed, err: %v", err)
+	}
+
+	if len(g.series) != 2 || g.series["0"].value != 2 || g.series["1"].value != 1 {
+		t.Fatalf("custom metric series %+v is invalid", g.series)
+	}
+}
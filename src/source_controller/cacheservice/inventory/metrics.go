@@ -0,0 +1,421 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"configcenter/pkg/cache/general"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+)
+
+const (
+	bizHostsMetric           = "biz_hosts"
+	setHostsMetric           = "set_hosts"
+	moduleHostsMetric        = "module_hosts"
+	osTypeHostsMetric        = "os_type_hosts"
+	defaultModuleHostsMetric = "default_module_hosts"
+	hostsWithoutAgentMetric  = "hosts_without_agent"
+	staleSnapshotHostsMetric = "stale_snapshot_hosts"
+	modelInstancesMetric     = "model_instances"
+	templateSyncDriftMetric  = "template_sync_drift"
+)
+
+type builtinDesc struct {
+	help   string
+	labels []string
+}
+
+var builtinDescs = map[string]builtinDesc{
+	bizHostsMetric: {
+		help:   "host count of each business",
+		labels: []string{common.BKAppIDField, common.BKAppNameField},
+	},
+	setHostsMetric: {
+		help:   "host count of each set",
+		labels: []string{common.BKAppIDField, common.BKSetIDField, common.BKSetNameField},
+	},
+	moduleHostsMetric: {
+		help:   "host count of each module",
+		labels: []string{common.BKAppIDField, common.BKModuleIDField, common.BKModuleNameField},
+	},
+	osTypeHostsMetric: {
+		help:   "host count of each os type",
+		labels: []string{common.BKOSTypeField},
+	},
+	defaultModuleHostsMetric: {
+		help:   "host count of the idle, fault and recycle modules of each business",
+		labels: []string{common.BKAppIDField, "module_type"},
+	},
+	hostsWithoutAgentMetric: {
+		help: "count of the hosts that are not bound to an agent",
+	},
+	staleSnapshotHostsMetric: {
+		help: "count of the hosts bound to an agent but have no snapshot reported in the last 10 minutes",
+	},
+	modelInstancesMetric: {
+		help:   "instance count of each model",
+		labels: []string{common.BKObjIDField},
+	},
+	templateSyncDriftMetric: {
+		help:   "count of the sets and modules whose template is changed after they are last updated",
+		labels: []string{"template_type"},
+	},
+}
+
+var builtinMetricNames = []string{bizHostsMetric, setHostsMetric, moduleHostsMetric, osTypeHostsMetric,
+	defaultModuleHostsMetric, hostsWithoutAgentMetric, staleSnapshotHostsMetric, modelInstancesMetric,
+	templateSyncDriftMetric}
+
+var defaultModuleTypes = map[int]string{
+	common.DefaultResModuleFlag:     "idle",
+	common.DefaultFaultModuleFlag:   "fault",
+	common.DefaultRecycleModuleFlag: "recycle",
+}
+
+type bizInfo struct {
+	ID   int64  `json:"bk_biz_id"`
+	Name string `json:"bk_biz_name"`
+}
+
+type setInfo struct {
+	ID            int64     `json:"bk_set_id"`
+	Name          string    `json:"bk_set_name"`
+	BizID         int64     `json:"bk_biz_id"`
+	SetTemplateID int64     `json:"set_template_id"`
+	LastTime      time.Time `json:"last_time"`
+}
+
+type moduleInfo struct {
+	ID                int64     `json:"bk_module_id"`
+	Name              string    `json:"bk_module_name"`
+	BizID             int64     `json:"bk_biz_id"`
+	Default           int       `json:"default"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	LastTime          time.Time `json:"last_time"`
+}
+
+type templateInfo struct {
+	ID       int64     `json:"id"`
+	LastTime time.Time `json:"last_time"`
+}
+
+type hostInfo struct {
+	ID      int64  `json:"bk_host_id"`
+	OSType  string `json:"bk_os_type"`
+	AgentID string `json:"bk_agent_id"`
+}
+
+// topoInfo is the business topology used to compute the inventory metrics
+type topoInfo struct {
+	sets    []setInfo
+	modules []moduleInfo
+}
+
+// computeTopoMetrics computes the host count metrics of the business topology
+func (e *Exporter) computeTopoMetrics(kit *rest.Kit, gauges map[string]*gauge) (*topoInfo, error) {
+	bizNames := make(map[int64]string)
+	err := e.listAll(kit, general.Biz, "", []string{common.BKAppIDField, common.BKAppNameField},
+		func(detail string) error {
+			biz := new(bizInfo)
+			if err := json.Unmarshal([]byte(detail), biz); err != nil {
+				blog.Errorf("unmarshal biz %s failed, err: %v, rid: %s", detail, err, kit.Rid)
+				return err
+			}
+			bizNames[biz.ID] = biz.Name
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	topo := new(topoInfo)
+	err = e.listAll(kit, general.Set, "", []string{common.BKSetIDField, common.BKSetNameField, common.BKAppIDField,
+		common.BKSetTemplateIDField, common.LastTimeField}, func(detail string) error {
+		set := setInfo{}
+		if err := json.Unmarshal([]byte(detail), &set); err != nil {
+			blog.Errorf("unmarshal set %s failed, err: %v, rid: %s", detail, err, kit.Rid)
+			return err
+		}
+		topo.sets = append(topo.sets, set)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.listAll(kit, general.Module, "", []string{common.BKModuleIDField, common.BKModuleNameField,
+		common.BKAppIDField, common.BKDefaultField, common.BKServiceTemplateIDField, common.LastTimeField},
+		func(detail string) error {
+			module := moduleInfo{}
+			if err := json.Unmarshal([]byte(detail), &module); err != nil {
+				blog.Errorf("unmarshal module %s failed, err: %v, rid: %s", detail, err, kit.Rid)
+				return err
+			}
+			topo.modules = append(topo.modules, module)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	bizHostCnt, err := countHostsByRelation(kit, common.BKAppIDField)
+	if err != nil {
+		return nil, err
+	}
+	for bizID, cnt := range bizHostCnt {
+		gauges[bizHostsMetric].add(float64(cnt), formatID(bizID), bizNames[bizID])
+	}
+
+	setHostCnt, err := countHostsByRelation(kit, common.BKSetIDField)
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range topo.sets {
+		gauges[setHostsMetric].add(float64(setHostCnt[set.ID]), formatID(set.BizID), formatID(set.ID), set.Name)
+	}
+
+	moduleHostCnt, err := countHostsByRelation(kit, common.BKModuleIDField)
+	if err != nil {
+		return nil, err
+	}
+	for _, module := range topo.modules {
+		cnt := float64(moduleHostCnt[module.ID])
+		gauges[moduleHostsMetric].add(cnt, formatID(module.BizID), formatID(module.ID), module.Name)
+
+		if moduleType, exists := defaultModuleTypes[module.Default]; exists {
+			gauges[defaultModuleHostsMetric].add(cnt, formatID(module.BizID), moduleType)
+		}
+	}
+
+	return topo, nil
+}
+
+// countHostsByRelation counts the distinct hosts of each business, set or module by the host relations, the host
+// relations are not in the general cache, so they are aggregated from db
+func countHostsByRelation(kit *rest.Kit, field string) (map[int64]int64, error) {
+	pipeline := []map[string]interface{}{
+		{common.BKDBGroup: map[string]interface{}{
+			"_id": map[string]interface{}{"id": "$" + field, "host": "$" + common.BKHostIDField},
+		}},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":   "$_id.id",
+			"count": map[string]interface{}{common.BKDBSum: 1},
+		}},
+	}
+
+	result := make([]struct {
+		ID    int64 `bson:"_id"`
+		Count int64 `bson:"count"`
+	}, 0)
+	err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).AggregateAll(kit.Ctx, pipeline, &result)
+	if err != nil {
+		blog.Errorf("count hosts by %s failed, err: %v, rid: %s", field, err, kit.Rid)
+		return nil, err
+	}
+
+	cntMap := make(map[int64]int64, len(result))
+	for _, item := range result {
+		cntMap[item.ID] = item.Count
+	}
+	return cntMap, nil
+}
+
+// computeHostMetrics computes the os type, agent and snapshot metrics of the hosts
+func (e *Exporter) computeHostMetrics(kit *rest.Kit, gauges map[string]*gauge) error {
+	agentHostIDs := make([]int64, 0)
+	withoutAgentCnt := 0
+	err := e.listAll(kit, general.Host, "", []string{common.BKHostIDField, common.BKOSTypeField,
+		common.BKAgentIDField}, func(detail string) error {
+		host := new(hostInfo)
+		if err := json.Unmarshal([]byte(detail), host); err != nil {
+			blog.Errorf("unmarshal host %s failed, err: %v, rid: %s", detail, err, kit.Rid)
+			return err
+		}
+
+		gauges[osTypeHostsMetric].add(1, host.OSType)
+
+		if host.AgentID == "" {
+			withoutAgentCnt++
+			return nil
+		}
+		agentHostIDs = append(agentHostIDs, host.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	gauges[hostsWithoutAgentMetric].add(float64(withoutAgentCnt))
+
+	// the snapshot is saved with 10 minutes expiration by data collection, so the hosts whose snapshot key does not
+	// exist have not reported snapshot in the last 10 minutes
+	staleCnt := int64(0)
+	for start := 0; start < len(agentHostIDs); start += common.BKMaxLimitSize {
+		end := start + common.BKMaxLimitSize
+		if end > len(agentHostIDs) {
+			end = len(agentHostIDs)
+		}
+
+		keys := make([]string, 0, end-start)
+		for _, hostID := range agentHostIDs[start:end] {
+			keys = append(keys, common.RedisSnapKeyPrefix+strconv.FormatInt(hostID, 10))
+		}
+
+		existCnt, err := redis.Client().Exists(kit.Ctx, keys...).Result()
+		if err != nil {
+			blog.Errorf("check host snapshot keys exists failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+		staleCnt += int64(len(keys)) - existCnt
+	}
+	gauges[staleSnapshotHostsMetric].add(float64(staleCnt))
+
+	return nil
+}
+
+// computeModelMetrics computes the instance count of each model
+func (e *Exporter) computeModelMetrics(kit *rest.Kit, gauges map[string]*gauge) error {
+	objIDs := make([]string, 0)
+	err := e.listAll(kit, general.Model, "", []string{common.BKObjIDField}, func(detail string) error {
+		model := new(struct {
+			ObjID string `json:"bk_obj_id"`
+		})
+		if err := json.Unmarshal([]byte(detail), model); err != nil {
+			blog.Errorf("unmarshal model %s failed, err: %v, rid: %s", detail, err, kit.Rid)
+			return err
+		}
+		objIDs = append(objIDs, model.ObjID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	mainlineCond := mapstr.MapStr{common.AssociationKindIDField: common.AssociationKindMainline}
+	mainlineObjs, err := mongodb.Client().Table(common.BKTableNameObjAsst).Distinct(kit.Ctx, common.BKObjIDField,
+		mainlineCond)
+	if err != nil {
+		blog.Errorf("get mainline objects failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	mainlineObjMap := make(map[string]struct{})
+	for _, obj := range mainlineObjs {
+		mainlineObjMap[util.GetStrByInterface(obj)] = struct{}{}
+	}
+
+	for _, objID := range objIDs {
+		resource, subResource, ok := objResource(objID, mainlineObjMap)
+		if !ok {
+			continue
+		}
+
+		cnt, err := e.count(kit, resource, subResource)
+		if err != nil {
+			return err
+		}
+		gauges[modelInstancesMetric].add(float64(cnt), objID)
+	}
+
+	return nil
+}
+
+var innerObjResources = map[string]general.ResType{
+	common.BKInnerObjIDApp:    general.Biz,
+	common.BKInnerObjIDSet:    general.Set,
+	common.BKInnerObjIDModule: general.Module,
+	common.BKInnerObjIDHost:   general.Host,
+	common.BKInnerObjIDPlat:   general.Plat,
+	common.BKInnerObjIDBizSet: general.BizSet,
+}
+
+// objResource returns the general cache resource type of the model instances, the inner models that are not cached
+// are skipped
+func objResource(objID string, mainlineObjMap map[string]struct{}) (general.ResType, string, bool) {
+	if resource, exists := innerObjResources[objID]; exists {
+		return resource, "", true
+	}
+
+	if common.IsInnerModel(objID) {
+		return "", "", false
+	}
+
+	if _, exists := mainlineObjMap[objID]; exists {
+		return general.MainlineInstance, objID, true
+	}
+
+	return general.ObjectInstance, objID, true
+}
+
+// computeDriftMetrics computes the count of the sets and modules that may need to be synchronized with their
+// templates, a set or module is regarded as drifted if its template is changed after it is last updated
+func (e *Exporter) computeDriftMetrics(kit *rest.Kit, topo *topoInfo, gauges map[string]*gauge) error {
+	setTemplates, err := e.listTemplateLastTime(kit, general.SetTemplate)
+	if err != nil {
+		return err
+	}
+
+	driftCnt := 0
+	for _, set := range topo.sets {
+		lastTime, exists := setTemplates[set.SetTemplateID]
+		if set.SetTemplateID != 0 && exists && lastTime.After(set.LastTime) {
+			driftCnt++
+		}
+	}
+	gauges[templateSyncDriftMetric].add(float64(driftCnt), string(general.SetTemplate))
+
+	svcTemplates, err := e.listTemplateLastTime(kit, general.ServiceTemplate)
+	if err != nil {
+		return err
+	}
+
+	driftCnt = 0
+	for _, module := range topo.modules {
+		lastTime, exists := svcTemplates[module.ServiceTemplateID]
+		if module.ServiceTemplateID != 0 && exists && lastTime.After(module.LastTime) {
+			driftCnt++
+		}
+	}
+	gauges[templateSyncDriftMetric].add(float64(driftCnt), string(general.ServiceTemplate))
+
+	return nil
+}
+
+func (e *Exporter) listTemplateLastTime(kit *rest.Kit, resource general.ResType) (map[int64]time.Time, error) {
+	templates := make(map[int64]time.Time)
+	err := e.listAll(kit, resource, "", []string{common.BKFieldID, common.LastTimeField}, func(detail string) error {
+		template := new(templateInfo)
+		if err := json.Unmarshal([]byte(detail), template); err != nil {
+			blog.Errorf("unmarshal %s %s failed, err: %v, rid: %s", resource, detail, err, kit.Rid)
+			return err
+		}
+		templates[template.ID] = template.LastTime
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -32,9 +32,11 @@ import (
 	"configcenter/src/source_controller/cacheservice/app/options"
 	"configcenter/src/source_controller/cacheservice/cache"
 	cacheop "configcenter/src/source_controller/cacheservice/cache"
+	"configcenter/src/source_controller/cacheservice/event/archive"
 	"configcenter/src/source_controller/cacheservice/event/bsrelation"
 	"configcenter/src/source_controller/cacheservice/event/flow"
 	"configcenter/src/source_controller/cacheservice/event/identifier"
+	"configcenter/src/source_controller/cacheservice/inventory"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/reflector"
@@ -112,13 +114,32 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 		return dbErr
 	}
 
-	c, cacheErr := cacheop.NewCache(event, loopW, engine.ServiceManageInterface, watchDB)
+	archiveConf, archiveErr := archive.ParseConfig()
+	if archiveErr != nil {
+		blog.Errorf("parse event archive config failed, err: %v", archiveErr)
+		return archiveErr
+	}
+	eventArchive := archive.New(watchDB, archiveConf)
+
+	c, cacheErr := cacheop.NewCache(event, loopW, engine.ServiceManageInterface, watchDB, eventArchive)
 	if cacheErr != nil {
 		blog.Errorf("new cache instance failed, err: %v", cacheErr)
 		return cacheErr
 	}
 	s.cacheSet = c
 
+	inventoryConf, inventoryErr := inventory.ParseConfig()
+	if inventoryErr != nil {
+		blog.Errorf("parse inventory exporter config failed, err: %v", inventoryErr)
+		return inventoryErr
+	}
+
+	exporter := inventory.New(inventoryConf, c.General, engine.ServiceManageInterface)
+	if runErr := exporter.Run(engine.Metric().Registry()); runErr != nil {
+		blog.Errorf("run inventory exporter failed, err: %v", runErr)
+		return runErr
+	}
+
 	watcher, watchErr := stream.NewLoopStream(s.cfg.Mongo.GetMongoConf(), engine.ServiceManageInterface)
 	if watchErr != nil {
 		blog.Errorf("new loop watch stream failed, err: %v", watchErr)
@@ -131,7 +152,7 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 		return dbErr
 	}
 
-	flowErr := flow.NewEvent(watcher, engine.ServiceManageInterface, watchDB, ccDB)
+	flowErr := flow.NewEvent(watcher, engine.ServiceManageInterface, watchDB, ccDB, eventArchive, engine.CoreAPI)
 	if flowErr != nil {
 		blog.Errorf("new watch event failed, err: %v", flowErr)
 		return flowErr
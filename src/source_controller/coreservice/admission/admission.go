@@ -0,0 +1,244 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package admission calls the configured admission webhooks synchronously before the resource instances are changed,
+// so that site-specific rules can validate or mutate the changes without modifying the code.
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/rs/xid"
+)
+
+// maxRespBodyLength is the max length of the admission webhook response body
+const maxRespBodyLength = 1 << 20
+
+// protectedFields can not be patched by the mutating webhooks
+var protectedFields = map[string]struct{}{
+	common.BKOwnerIDField:   {},
+	common.BKObjIDField:     {},
+	common.BKFieldID:        {},
+	common.BKInstIDField:    {},
+	common.BKHostIDField:    {},
+	common.BKAppIDField:     {},
+	common.BKSetIDField:     {},
+	common.BKModuleIDField:  {},
+	common.BKBizSetIDField:  {},
+	common.BKProjectIDField: {},
+	common.BKParentIDField:  {},
+	common.BKDefaultField:   {},
+	common.CreateTimeField:  {},
+	common.LastTimeField:    {},
+	common.BKCreatedAt:      {},
+	common.BKCreatedBy:      {},
+	common.BKUpdatedAt:      {},
+	common.BKUpdatedBy:      {},
+}
+
+var admitter = &controller{client: &http.Client{}}
+
+type controller struct {
+	mutating   []WebhookConfig
+	validating []WebhookConfig
+	client     *http.Client
+}
+
+// Init initialize the admission webhooks, it must be called before the core service starts
+func Init(conf *Config) {
+	for _, webhook := range conf.Webhooks {
+		if webhook.Type == MutatingWebhook {
+			admitter.mutating = append(admitter.mutating, webhook)
+		} else {
+			admitter.validating = append(admitter.validating, webhook)
+		}
+		blog.Infof("add %s admission webhook %s, url: %s", webhook.Type, webhook.Name, webhook.URL)
+	}
+}
+
+// Enabled returns if there is any admission webhook for the resource action, it can be used to avoid preparing the
+// admission data when no webhook is configured.
+func Enabled(resource string, action metadata.AdmissionAction) bool {
+	for _, webhooks := range [][]WebhookConfig{admitter.mutating, admitter.validating} {
+		for idx := range webhooks {
+			if webhooks[idx].match(resource, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Admit calls the mutating webhooks and then the validating webhooks of the resource action, returns the object
+// patched by the mutating webhooks. The object is returned as it is if no mutating webhook patches it.
+func Admit(kit *rest.Kit, resource string, action metadata.AdmissionAction, oldObject, object mapstr.MapStr) (
+	mapstr.MapStr, errors.CCErrorCoder) {
+
+	if !Enabled(resource, action) {
+		return object, nil
+	}
+
+	for idx := range admitter.mutating {
+		webhook := &admitter.mutating[idx]
+		if !webhook.match(resource, action) {
+			continue
+		}
+
+		resp, err := admitter.call(kit, webhook, resource, action, oldObject, object)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp == nil || len(resp.Patch) == 0 {
+			continue
+		}
+
+		object = applyPatch(object, resp.Patch)
+		blog.V(4).Infof("admission webhook %s patched %s %s data, patch: %v, rid: %s", webhook.Name, resource,
+			action, resp.Patch, kit.Rid)
+	}
+
+	for idx := range admitter.validating {
+		webhook := &admitter.validating[idx]
+		if !webhook.match(resource, action) {
+			continue
+		}
+
+		if _, err := admitter.call(kit, webhook, resource, action, oldObject, object); err != nil {
+			return nil, err
+		}
+	}
+
+	return object, nil
+}
+
+// call posts the admission request to the webhook, returns nil response if the call failed and is ignored by the
+// failure policy, returns error if the request is denied or the call failed.
+func (c *controller) call(kit *rest.Kit, webhook *WebhookConfig, resource string, action metadata.AdmissionAction,
+	oldObject, object mapstr.MapStr) (*metadata.AdmissionResponse, errors.CCErrorCoder) {
+
+	req := &metadata.AdmissionRequest{
+		UID:       xid.New().String(),
+		Resource:  resource,
+		Action:    action,
+		User:      kit.User,
+		OwnerID:   kit.SupplierAccount,
+		AppCode:   httpheader.GetAppCode(kit.Header),
+		Rid:       kit.Rid,
+		OldObject: oldObject,
+		Object:    object,
+	}
+
+	resp, err := c.post(kit.Ctx, webhook, req)
+	if err != nil {
+		if webhook.FailurePolicy == FailurePolicyIgnore {
+			blog.Warnf("call admission webhook %s failed, ignore it, err: %v, uid: %s, rid: %s", webhook.Name, err,
+				req.UID, kit.Rid)
+			return nil, nil
+		}
+
+		blog.Errorf("call admission webhook %s failed, err: %v, uid: %s, rid: %s", webhook.Name, err, req.UID,
+			kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAdmissionWebhookFailed, webhook.Name, err.Error())
+	}
+
+	if !resp.Allowed {
+		blog.Errorf("%s %s is denied by admission webhook %s, message: %s, uid: %s, rid: %s", resource, action,
+			webhook.Name, resp.Message, req.UID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAdmissionDenied, resource, action, webhook.Name,
+			resp.Message)
+	}
+
+	return resp, nil
+}
+
+func (c *controller) post(ctx context.Context, webhook *WebhookConfig, req *metadata.AdmissionRequest) (
+	*metadata.AdmissionResponse, error) {
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal admission request failed, err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhook.timeout())
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpheader.SetRid(httpReq.Header, req.Rid)
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, maxRespBodyLength))
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed, err: %v", err)
+	}
+
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("webhook responds status %d, body: %s", httpResp.StatusCode, respBody)
+	}
+
+	resp := new(metadata.AdmissionResponse)
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed, err: %v, body: %s", err, respBody)
+	}
+
+	if resp.UID != req.UID {
+		return nil, fmt.Errorf("response uid %s does not match request uid %s", resp.UID, req.UID)
+	}
+
+	return resp, nil
+}
+
+// applyPatch merges the patch into a copy of the object, the fields with null value in the patch are removed,
+// the protected fields are not patched.
+func applyPatch(object, patch mapstr.MapStr) mapstr.MapStr {
+	patched := make(mapstr.MapStr, len(object)+len(patch))
+	for key, value := range object {
+		patched[key] = value
+	}
+
+	for key, value := range patch {
+		if _, protected := protectedFields[key]; protected {
+			continue
+		}
+
+		if value == nil {
+			delete(patched, key)
+			continue
+		}
+		patched[key] = value
+	}
+
+	return patched
+}
@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestConfigValidate(t *testing.T) {
+	conf := &Config{Webhooks: []WebhookConfig{{
+		Name:  "host-check",
+		Type:  ValidatingWebhook,
+		URL:   "http://127.0.0.1:8080/admission",
+		Rules: []Rule{{Resources: []string{"host"}, Actions: []metadata.AdmissionAction{metadata.AdmissionTransfer}}},
+	}}}
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("validate config failed, err: %v", err)
+	}
+
+	if conf.Webhooks[0].FailurePolicy != FailurePolicyFail {
+		t.Errorf("default failure policy should be fail, but got %s", conf.Webhooks[0].FailurePolicy)
+	}
+
+	if !conf.Webhooks[0].match("host", metadata.AdmissionTransfer) || conf.Webhooks[0].match("set",
+		metadata.AdmissionTransfer) || conf.Webhooks[0].match("host", metadata.AdmissionUpdate) {
+		t.Errorf("webhook rule match result is invalid")
+	}
+
+	conf.Webhooks[0].Type = MutatingWebhook
+	if err := conf.Validate(); err == nil {
+		t.Errorf("mutating webhook with transfer action should be invalid")
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	object := mapstr.MapStr{"bk_host_id": 1, "bk_host_name": "a", "bk_comment": "b"}
+	patch := mapstr.MapStr{"bk_host_id": 2, "bk_host_name": "c", "bk_comment": nil, "operator": "admin"}
+
+	patched := applyPatch(object, patch)
+	expected := mapstr.MapStr{"bk_host_id": 1, "bk_host_name": "c", "operator": "admin"}
+	if len(patched) != len(expected) {
+		t.Fatalf("patched object %v does not match %v", patched, expected)
+	}
+	for key, value := range expected {
+		if patched[key] != value {
+			t.Errorf("patched object %v does not match %v", patched, expected)
+		}
+	}
+
+	if object["bk_host_name"] != "a" {
+		t.Errorf("the original object should not be changed")
+	}
+}
+
+func TestPost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(metadata.AdmissionRequest)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp := &metadata.AdmissionResponse{UID: req.UID, Allowed: req.Object["bk_host_name"] != "deny"}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := &controller{client: server.Client()}
+	webhook := &WebhookConfig{Name: "test", URL: server.URL}
+
+	for name, allowed := range map[string]bool{"allow": true, "deny": false} {
+		req := &metadata.AdmissionRequest{UID: name, Object: mapstr.MapStr{"bk_host_name": name}}
+		resp, err := c.post(context.Background(), webhook, req)
+		if err != nil {
+			t.Fatalf("post admission request failed, err: %v", err)
+		}
+
+		if resp.Allowed != allowed {
+			t.Errorf("admission response of %s should be %v", name, allowed)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admission
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// defaultTimeout is the default timeout of calling the admission webhook
+	defaultTimeout = 5 * time.Second
+	// maxTimeoutSeconds is the max timeout seconds of calling the admission webhook, the webhooks are called
+	// synchronously, so a slow webhook blocks the resource changes
+	maxTimeoutSeconds = 30
+	// allResources matches all resources in the webhook rules
+	allResources = "*"
+)
+
+// WebhookType is the type of the admission webhook
+type WebhookType string
+
+const (
+	// MutatingWebhook can patch the data of the create and update actions, it is called before validating webhooks
+	MutatingWebhook WebhookType = "mutating"
+	// ValidatingWebhook can only allow or deny the request, it is called after all mutating webhooks
+	ValidatingWebhook WebhookType = "validating"
+)
+
+// FailurePolicy defines how to handle the errors of calling the admission webhook
+type FailurePolicy string
+
+const (
+	// FailurePolicyFail denies the request if the webhook call failed, it is the default policy
+	FailurePolicyFail FailurePolicy = "fail"
+	// FailurePolicyIgnore ignores the webhook call error and allows the request
+	FailurePolicyIgnore FailurePolicy = "ignore"
+)
+
+// Config is the admission webhook config of core service
+type Config struct {
+	Webhooks []WebhookConfig
+}
+
+// WebhookConfig is the config of an admission webhook
+type WebhookConfig struct {
+	Name string
+	Type WebhookType
+	// URL is the address that the admission request is posted to
+	URL   string
+	Rules []Rule
+	// TimeoutSeconds is the timeout seconds of calling the webhook, default is 5 seconds
+	TimeoutSeconds int
+	FailurePolicy  FailurePolicy
+}
+
+// Rule defines the resources and actions that the webhook is called for
+type Rule struct {
+	// Resources are the object ids of the resource instances, * matches all resources
+	Resources []string
+	Actions   []metadata.AdmissionAction
+}
+
+// ParseConfig parse admission webhook config
+func ParseConfig() (*Config, error) {
+	conf := new(Config)
+
+	if !cc.IsExist("coreService.admission.webhooks") {
+		return conf, nil
+	}
+
+	if err := cc.UnmarshalKey("coreService.admission.webhooks", &conf.Webhooks); err != nil {
+		blog.Errorf("parse coreService.admission.webhooks failed, err: %v", err)
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("admission webhook config is invalid, err: %v", err)
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// Validate admission webhook config, and set the default values
+func (c *Config) Validate() error {
+	names := make(map[string]struct{})
+	for idx := range c.Webhooks {
+		webhook := &c.Webhooks[idx]
+
+		if len(webhook.Name) == 0 {
+			return fmt.Errorf("admission webhook name is not set, index: %d", idx)
+		}
+
+		if _, exists := names[webhook.Name]; exists {
+			return fmt.Errorf("admission webhook name %s is duplicated", webhook.Name)
+		}
+		names[webhook.Name] = struct{}{}
+
+		if err := webhook.validate(); err != nil {
+			return fmt.Errorf("admission webhook %s is invalid, %v", webhook.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *WebhookConfig) validate() error {
+	if w.Type != MutatingWebhook && w.Type != ValidatingWebhook {
+		return fmt.Errorf("type %s is invalid", w.Type)
+	}
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("url %s is invalid", w.URL)
+	}
+
+	if len(w.Rules) == 0 {
+		return fmt.Errorf("rules are not set")
+	}
+
+	for _, rule := range w.Rules {
+		if len(rule.Resources) == 0 || len(rule.Actions) == 0 {
+			return fmt.Errorf("rule resources or actions are not set")
+		}
+
+		for _, action := range rule.Actions {
+			if !action.Validate() {
+				return fmt.Errorf("rule action %s is invalid", action)
+			}
+
+			if w.Type == MutatingWebhook && !action.Mutable() {
+				return fmt.Errorf("mutating webhook does not support %s action", action)
+			}
+		}
+	}
+
+	if w.TimeoutSeconds < 0 || w.TimeoutSeconds > maxTimeoutSeconds {
+		return fmt.Errorf("timeout seconds %d exceeds the range [0, %d]", w.TimeoutSeconds, maxTimeoutSeconds)
+	}
+
+	switch w.FailurePolicy {
+	case "":
+		w.FailurePolicy = FailurePolicyFail
+	case FailurePolicyFail, FailurePolicyIgnore:
+	default:
+		return fmt.Errorf("failure policy %s is invalid", w.FailurePolicy)
+	}
+
+	return nil
+}
+
+func (w *WebhookConfig) timeout() time.Duration {
+	if w.TimeoutSeconds == 0 {
+		return defaultTimeout
+	}
+	return time.Duration(w.TimeoutSeconds) * time.Second
+}
+
+// match returns if the webhook is called for the resource action
+func (w *WebhookConfig) match(resource string, action metadata.AdmissionAction) bool {
+	for _, rule := range w.Rules {
+		resourceMatched := false
+		for _, res := range rule.Resources {
+			if res == allResources || res == resource {
+				resourceMatched = true
+				break
+			}
+		}
+
+		if !resourceMatched {
+			continue
+		}
+
+		for _, act := range rule.Actions {
+			if act == action {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/types"
+	"configcenter/src/source_controller/coreservice/admission"
 	"configcenter/src/source_controller/coreservice/app/options"
 	coresvr "configcenter/src/source_controller/coreservice/service"
 	"configcenter/src/storage/driver/mongodb"
@@ -91,6 +92,12 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return err
 	}
 
+	admissionConf, err := admission.ParseConfig()
+	if err != nil {
+		return fmt.Errorf("parse admission webhook config failed, err: %v", err)
+	}
+	admission.Init(admissionConf)
+
 	err = coreService.SetConfig(*coreSvr.Config, engine, engine.CCErr, engine.Language)
 	if err != nil {
 		return err
@@ -508,3 +508,47 @@ func (m *associationInstance) DeleteInstanceAssociation(kit *rest.Kit, objID str
 	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
+
+// UpdateInstanceAssociationAttrs set the attribute values of the instance association, the values are validated by
+// the caller, and they are updated in the association tables of both the source and target objects.
+func (m *associationInstance) UpdateInstanceAssociationAttrs(kit *rest.Kit,
+	input *metadata.UpdateInstAsstAttrsOption) error {
+
+	if len(input.ObjectID) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+
+	if input.ID <= 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: input.ID}, kit.SupplierAccount)
+	asst := new(metadata.InstAsst)
+	tableName := common.GetObjectInstAsstTableName(input.ObjectID, kit.SupplierAccount)
+	if err := mongodb.Client().Table(tableName).Find(cond).One(kit.Ctx, asst); err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("instance association %d is not exist, obj: %s, rid: %s", input.ID, input.ObjectID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+		}
+		blog.Errorf("get instance association %d failed, err: %v, rid: %s", input.ID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	objIDs := []string{asst.ObjectID}
+	// self related association is saved only once
+	if asst.AsstObjectID != asst.ObjectID {
+		objIDs = append(objIDs, asst.AsstObjectID)
+	}
+
+	data := mapstr.MapStr{metadata.InstAsstFieldAttributes: input.Attributes}
+	for _, objID := range objIDs {
+		asstTableName := common.GetObjectInstAsstTableName(objID, kit.SupplierAccount)
+		if err := mongodb.Client().Table(asstTableName).Update(kit.Ctx, cond, data); err != nil {
+			blog.Errorf("update instance association %d attributes failed, table: %s, err: %v, rid: %s", input.ID,
+				asstTableName, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+
+	return nil
+}
@@ -119,7 +119,7 @@ func (m *associationModel) UpdateModelAssociation(kit *rest.Kit, inputParam meta
 
 	// only field in white list could be update
 	// bk_asst_obj_id is allowed for add business model level
-	validFields := []string{"bk_obj_asst_name", "bk_asst_obj_id"}
+	validFields := []string{"bk_obj_asst_name", "bk_asst_obj_id", metadata.AssociationFieldAttributes}
 	validData := map[string]interface{}{}
 	filterOutFields := []string{}
 	for key, val := range inputParam.Data {
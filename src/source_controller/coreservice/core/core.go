@@ -126,6 +126,7 @@ type InstanceOperation interface {
 	DeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount,
 		error)
+	UpdateComputedFields(kit *rest.Kit, objID string, input *metadata.UpdateComputedFieldsOption) error
 }
 
 // KubeOperation crud operations on kube data.
@@ -177,6 +178,7 @@ type InstanceAssociation interface {
 	CountInstanceAssociations(kit *rest.Kit, objID string, input *metadata.Condition) (
 		*metadata.CommonCountResult, error)
 	DeleteInstanceAssociation(kit *rest.Kit, objID string, param metadata.DeleteOption) (*metadata.DeletedCount, error)
+	UpdateInstanceAssociationAttrs(kit *rest.Kit, input *metadata.UpdateInstAsstAttrsOption) error
 }
 
 // DataSynchronizeOperation manager data synchronize interface
@@ -213,6 +215,30 @@ type HostOperation interface {
 	LockHost(kit *rest.Kit, input *metadata.HostLockRequest) errors.CCError
 	UnlockHost(kit *rest.Kit, input *metadata.HostLockRequest) errors.CCError
 	QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLockRequest) ([]metadata.HostLockData, errors.CCError)
+	ListHostLock(kit *rest.Kit, opt *metadata.ListHostLockOption) (*metadata.ListHostLockResult,
+		errors.CCErrorCoder)
+
+	UpdateHostLifecycle(kit *rest.Kit, lifecycle *metadata.HostLifecycle) errors.CCErrorCoder
+	GetHostLifecycle(kit *rest.Kit) (*metadata.HostLifecycle, errors.CCErrorCoder)
+	CreateHostLifecycleHistory(kit *rest.Kit, opt *metadata.CreateHostLifecycleHistoryOption) errors.CCErrorCoder
+	SearchHostLifecycleHistory(kit *rest.Kit, opt *metadata.SearchHostLifecycleHistoryOption) (
+		*metadata.SearchHostLifecycleHistoryResult, errors.CCErrorCoder)
+	ListStuckHosts(kit *rest.Kit, opt *metadata.ListStuckHostOption) (*metadata.ListStuckHostResult,
+		errors.CCErrorCoder)
+	CreateHostSnapHistory(kit *rest.Kit, opt *metadata.CreateHostSnapHistoryOption) errors.CCErrorCoder
+	SearchHostSnapHistory(kit *rest.Kit, opt *metadata.SearchHostSnapHistoryOption) (
+		*metadata.SearchHostSnapHistoryResult, errors.CCErrorCoder)
+	ListHostSnapChangedHosts(kit *rest.Kit, opt *metadata.ListHostSnapChangedHostOption) (
+		*metadata.ListHostSnapChangedHostResult, errors.CCErrorCoder)
+
+	CreateHostLease(kit *rest.Kit, lease *metadata.HostLease) (*metadata.HostLease, errors.CCErrorCoder)
+	UpdateHostLease(kit *rest.Kit, id int64, opt *metadata.UpdateHostLeaseOption) (*metadata.HostLease,
+		errors.CCErrorCoder)
+	SearchHostLease(kit *rest.Kit, opt *metadata.SearchHostLeaseOption) (*metadata.SearchHostLeaseResult,
+		errors.CCErrorCoder)
+	CreateHostLeaseHistory(kit *rest.Kit, histories []metadata.HostLeaseHistory) errors.CCErrorCoder
+	SearchHostLeaseHistory(kit *rest.Kit, opt *metadata.SearchHostLeaseHistoryOption) (
+		*metadata.SearchHostLeaseHistoryResult, errors.CCErrorCoder)
 
 	// ListHosts TODO
 	// host search
@@ -405,6 +431,13 @@ type HostApplyRuleOperation interface {
 		metadata.MultipleHostApplyResult, errors.CCErrorCoder)
 	SearchRuleRelatedServiceTemplates(kit *rest.Kit, option metadata.RuleRelatedServiceTemplateOption) (
 		[]metadata.SrvTemplate, errors.CCErrorCoder)
+	CreateHostApplyScopeRule(kit *rest.Kit, bizID int64, option metadata.CreateHostApplyScopeRuleOption) (
+		metadata.HostApplyScopeRule, errors.CCErrorCoder)
+	UpdateHostApplyScopeRule(kit *rest.Kit, bizID int64, ruleID int64, option metadata.UpdateHostApplyScopeRuleOption) (
+		metadata.HostApplyScopeRule, errors.CCErrorCoder)
+	DeleteHostApplyScopeRule(kit *rest.Kit, bizID int64, option metadata.DeleteHostApplyScopeRuleOption) errors.CCErrorCoder
+	ListHostApplyScopeRule(kit *rest.Kit, bizID int64, option metadata.ListHostApplyScopeRuleOption) (
+		metadata.MultipleHostApplyScopeRuleResult, errors.CCErrorCoder)
 }
 
 // CloudOperation TODO
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core/host/hostlock"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/thirdparty/hooks"
 )
@@ -43,6 +44,10 @@ func (hm *hostManager) UpdateHostCloudAreaField(kit *rest.Kit,
 		return err
 	}
 
+	if err := hostlock.Check(kit, input.HostIDs, metadata.HostLockOpUpdate); err != nil {
+		return err
+	}
+
 	updateFilter := map[string]interface{}{
 		common.BKHostIDField: map[string]interface{}{
 			common.BKDBIN: input.HostIDs,
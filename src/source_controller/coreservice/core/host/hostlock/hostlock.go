@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package hostlock checks the host locks before the host operations in core service, so that the locked hosts can
+// not be changed by any of the scene servers
+package hostlock
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// ActiveCond returns the condition of the host locks that are not expired at the time, the expired locks are only
+// cleaned up by the ttl index periodically, so they must be filtered out by the expire time
+func ActiveCond(now time.Time) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{"expire_time": nil},
+			{"expire_time": mapstr.MapStr{common.BKDBGT: now}},
+		},
+	}
+}
+
+// GetActiveLocks get the active host locks of the hosts
+func GetActiveLocks(kit *rest.Kit, hostIDs []int64) ([]metadata.HostLockData, errors.CCErrorCoder) {
+	cond := mapstr.MapStr{
+		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs},
+	}
+	cond.Merge(ActiveCond(time.Now()))
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	locks := make([]metadata.HostLockData, 0)
+	if err := mongodb.Client().Table(common.BKTableNameHostLock).Find(cond).All(kit.Ctx, &locks); err != nil {
+		blog.Errorf("get host locks failed, err: %v, host ids: %v, rid: %s", err, hostIDs, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return locks, nil
+}
+
+// Check returns error if any of the hosts is locked by other users and the lock scope forbids the operation, the
+// lock owner is allowed to operate the locked hosts, and the expired locks are treated as unlocked
+func Check(kit *rest.Kit, hostIDs []int64, op metadata.HostLockOperation) errors.CCErrorCoder {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	locks, err := GetActiveLocks(kit, util.IntArrayUnique(hostIDs))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, lock := range locks {
+		if lock.IsExpired(now) || lock.User == kit.User || !lock.GetScope().Forbids(op) {
+			continue
+		}
+
+		blog.Errorf("host %d is locked by %s with scope %s, %s is not allowed, rid: %s", lock.ID, lock.User,
+			lock.GetScope(), op, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrHostLocked, lock.ID, lock.User, lock.Reason, op)
+	}
+
+	return nil
+}
+
+// CheckUpdate checks the host locks before updating the hosts, updating only the agent id of the hosts is checked
+// as binding agent to the hosts
+func CheckUpdate(kit *rest.Kit, hosts []mapstr.MapStr, data mapstr.MapStr) errors.CCErrorCoder {
+	hostIDs := make([]int64, 0, len(hosts))
+	for _, host := range hosts {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			blog.Errorf("parse host id failed, err: %v, host: %#v, rid: %s", err, host, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+
+	op := metadata.HostLockOpUpdate
+	if _, exists := data[common.BKAgentIDField]; exists && len(data) == 1 {
+		op = metadata.HostLockOpBindAgent
+	}
+
+	return Check(kit, hostIDs, op)
+}
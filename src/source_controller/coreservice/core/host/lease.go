@@ -0,0 +1,260 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CreateHostLease create an active host lease, the hosts can only be in one active lease at the same time
+func (hm *hostManager) CreateHostLease(kit *rest.Kit, lease *metadata.HostLease) (*metadata.HostLease,
+	errors.CCErrorCoder) {
+
+	if len(lease.HostIDs) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "bk_host_ids")
+	}
+
+	leasedCond := util.SetQueryOwner(mapstr.MapStr{
+		"bk_host_ids": mapstr.MapStr{common.BKDBIN: lease.HostIDs},
+		// the hosts of the lease that fails to be returned are still leased
+		common.BKStatusField: mapstr.MapStr{common.BKDBIN: []metadata.HostLeaseStatus{metadata.HostLeaseActive,
+			metadata.HostLeaseReturnFailed}},
+	}, kit.SupplierAccount)
+	leased := make([]metadata.HostLease, 0)
+	err := mongodb.Client().Table(common.BKTableNameHostLease).Find(leasedCond).
+		Fields(common.BKFieldID, "bk_host_ids").Limit(1).All(kit.Ctx, &leased)
+	if err != nil {
+		blog.Errorf("get leases of hosts %v failed, err: %v, rid: %s", lease.HostIDs, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(leased) > 0 {
+		for _, hostID := range leased[0].HostIDs {
+			if util.InArray(hostID, lease.HostIDs) {
+				return nil, kit.CCError.CCErrorf(common.CCErrHostLeaseHostAlreadyLeased, hostID, leased[0].ID)
+			}
+		}
+	}
+
+	id, err := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameHostLease)
+	if err != nil {
+		blog.Errorf("generate host lease id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	lease.ID = int64(id)
+	lease.Status = metadata.HostLeaseActive
+	lease.Extensions = 0
+	lease.OwnerID = kit.SupplierAccount
+	lease.Creator = kit.User
+	lease.Modifier = kit.User
+	lease.CreateTime = now
+	lease.LastTime = now
+	lease.CalcRemindTime()
+
+	if err := mongodb.Client().Table(common.BKTableNameHostLease).Insert(kit.Ctx, lease); err != nil {
+		blog.Errorf("create host lease failed, err: %v, lease: %#v, rid: %s", err, lease, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return lease, nil
+}
+
+// UpdateHostLease update the status, expire time or remind info of the active host lease
+func (hm *hostManager) UpdateHostLease(kit *rest.Kit, id int64, opt *metadata.UpdateHostLeaseOption) (
+	*metadata.HostLease, errors.CCErrorCoder) {
+
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: id}, kit.SupplierAccount)
+	lease := new(metadata.HostLease)
+	if err := mongodb.Client().Table(common.BKTableNameHostLease).Find(cond).One(kit.Ctx, lease); err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrHostLeaseNotExist, id)
+		}
+		blog.Errorf("get host lease %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	// the lease that fails to be returned automatically can only be returned by the user
+	prevStatus := lease.Status
+	if prevStatus != metadata.HostLeaseActive &&
+		!(prevStatus == metadata.HostLeaseReturnFailed && opt.Status == metadata.HostLeaseReturned) {
+		return nil, kit.CCError.CCErrorf(common.CCErrHostLeaseNotActive, id)
+	}
+
+	if len(opt.Status) > 0 {
+		lease.Status = opt.Status
+	}
+
+	if len(opt.ReturnError) > 0 {
+		lease.RecordReturnFailure(opt.ReturnError)
+	}
+
+	if opt.ExpireTime != nil {
+		lease.ExpireTime = *opt.ExpireTime
+		lease.CalcRemindTime()
+	}
+
+	if opt.Reminded != nil {
+		lease.Reminded = *opt.Reminded
+	}
+
+	if opt.Extend {
+		lease.Extensions++
+	}
+
+	lease.Modifier = kit.User
+	lease.LastTime = metadata.Now()
+
+	// only update the lease if its status is not changed, in case that it is returned concurrently
+	cond[common.BKStatusField] = prevStatus
+	if err := mongodb.Client().Table(common.BKTableNameHostLease).Update(kit.Ctx, cond, lease); err != nil {
+		blog.Errorf("update host lease %d failed, err: %v, opt: %#v, rid: %s", id, err, opt, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return lease, nil
+}
+
+// SearchHostLease search host leases
+func (hm *hostManager) SearchHostLease(kit *rest.Kit, opt *metadata.SearchHostLeaseOption) (
+	*metadata.SearchHostLeaseResult, errors.CCErrorCoder) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{}
+	if opt.BizID != 0 {
+		cond[common.BKAppIDField] = opt.BizID
+	}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if len(opt.HostIDs) > 0 {
+		cond["bk_host_ids"] = mapstr.MapStr{common.BKDBIN: opt.HostIDs}
+	}
+	if len(opt.Status) > 0 {
+		cond[common.BKStatusField] = mapstr.MapStr{common.BKDBIN: opt.Status}
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	table := mongodb.Client().Table(common.BKTableNameHostLease)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count host leases failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchHostLeaseResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-" + common.BKFieldID
+	}
+
+	leases := make([]metadata.HostLease, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &leases)
+	if err != nil {
+		blog.Errorf("search host leases failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchHostLeaseResult{Info: leases}, nil
+}
+
+// CreateHostLeaseHistory create host lease histories
+func (hm *hostManager) CreateHostLeaseHistory(kit *rest.Kit,
+	histories []metadata.HostLeaseHistory) errors.CCErrorCoder {
+
+	if len(histories) == 0 {
+		return nil
+	}
+
+	if len(histories) > common.BKMaxPageSize {
+		return kit.CCError.CCErrorf(common.CCErrExceedMaxOperationRecordsAtOnce, common.BKMaxPageSize)
+	}
+
+	ids, err := mongodb.Client().NextSequences(kit.Ctx, common.BKTableNameHostLeaseHistory, len(histories))
+	if err != nil {
+		blog.Errorf("generate host lease history ids failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	for idx := range histories {
+		histories[idx].ID = int64(ids[idx])
+		histories[idx].OwnerID = kit.SupplierAccount
+		histories[idx].CreateTime = now
+		if len(histories[idx].Operator) == 0 {
+			histories[idx].Operator = kit.User
+		}
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostLeaseHistory).Insert(kit.Ctx, histories); err != nil {
+		blog.Errorf("create host lease histories failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return nil
+}
+
+// SearchHostLeaseHistory search host lease histories, sorted by create time desc by default
+func (hm *hostManager) SearchHostLeaseHistory(kit *rest.Kit, opt *metadata.SearchHostLeaseHistoryOption) (
+	*metadata.SearchHostLeaseHistoryResult, errors.CCErrorCoder) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{}
+	if opt.BizID != 0 {
+		cond[common.BKAppIDField] = opt.BizID
+	}
+	if opt.LeaseID != 0 {
+		cond["lease_id"] = opt.LeaseID
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	table := mongodb.Client().Table(common.BKTableNameHostLeaseHistory)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count host lease histories failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchHostLeaseHistoryResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-" + common.CreateTimeField
+	}
+
+	histories := make([]metadata.HostLeaseHistory, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &histories)
+	if err != nil {
+		blog.Errorf("search host lease histories failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchHostLeaseHistoryResult{Info: histories}, nil
+}
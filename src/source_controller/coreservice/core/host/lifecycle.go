@@ -0,0 +1,270 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UpdateHostLifecycle create or update the host lifecycle definition of the supplier account
+func (hm *hostManager) UpdateHostLifecycle(kit *rest.Kit, lifecycle *metadata.HostLifecycle) errors.CCErrorCoder {
+	if rawErr := lifecycle.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	lifecycle.OwnerID = kit.SupplierAccount
+	lifecycle.Modifier = kit.User
+	lifecycle.LastTime = metadata.Now()
+
+	cond := util.SetModOwner(mapstr.MapStr{}, kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameHostLifecycle).Upsert(kit.Ctx, cond, lifecycle); err != nil {
+		blog.Errorf("update host lifecycle failed, err: %v, lifecycle: %#v, rid: %s", err, lifecycle, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
+
+// GetHostLifecycle get the host lifecycle definition of the supplier account, returns a disabled lifecycle if it is
+// not defined yet
+func (hm *hostManager) GetHostLifecycle(kit *rest.Kit) (*metadata.HostLifecycle, errors.CCErrorCoder) {
+	cond := util.SetQueryOwner(mapstr.MapStr{}, kit.SupplierAccount)
+	lifecycle := new(metadata.HostLifecycle)
+	if err := mongodb.Client().Table(common.BKTableNameHostLifecycle).Find(cond).One(kit.Ctx, lifecycle); err != nil {
+		if !mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("get host lifecycle failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		lifecycle = &metadata.HostLifecycle{OwnerID: kit.SupplierAccount}
+	}
+
+	if lifecycle.States == nil {
+		lifecycle.States = make([]metadata.HostLifecycleState, 0)
+	}
+	if lifecycle.Transitions == nil {
+		lifecycle.Transitions = make([]metadata.HostLifecycleTransition, 0)
+	}
+
+	return lifecycle, nil
+}
+
+// CreateHostLifecycleHistory create host lifecycle transition histories
+func (hm *hostManager) CreateHostLifecycleHistory(kit *rest.Kit,
+	opt *metadata.CreateHostLifecycleHistoryOption) errors.CCErrorCoder {
+
+	if len(opt.Histories) == 0 {
+		return nil
+	}
+
+	if len(opt.Histories) > common.BKMaxPageSize {
+		return kit.CCError.CCErrorf(common.CCErrExceedMaxOperationRecordsAtOnce, common.BKMaxPageSize)
+	}
+
+	ids, err := mongodb.Client().NextSequences(kit.Ctx, common.BKTableNameHostLifecycleHistory, len(opt.Histories))
+	if err != nil {
+		blog.Errorf("generate host lifecycle history ids failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	for idx := range opt.Histories {
+		opt.Histories[idx].ID = int64(ids[idx])
+		opt.Histories[idx].OwnerID = kit.SupplierAccount
+		if opt.Histories[idx].CreateTime.IsZero() {
+			opt.Histories[idx].CreateTime = now
+		}
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostLifecycleHistory).Insert(kit.Ctx,
+		opt.Histories); err != nil {
+		blog.Errorf("create host lifecycle histories failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return nil
+}
+
+// SearchHostLifecycleHistory search host lifecycle transition histories, sorted by create time desc by default
+func (hm *hostManager) SearchHostLifecycleHistory(kit *rest.Kit, opt *metadata.SearchHostLifecycleHistoryOption) (
+	*metadata.SearchHostLifecycleHistoryResult, errors.CCErrorCoder) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := mapstr.MapStr{}
+	if len(opt.HostIDs) > 0 {
+		cond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: opt.HostIDs}
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	table := mongodb.Client().Table(common.BKTableNameHostLifecycleHistory)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count host lifecycle history failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchHostLifecycleHistoryResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-" + common.CreateTimeField
+	}
+
+	histories := make([]metadata.HostLifecycleHistory, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &histories)
+	if err != nil {
+		blog.Errorf("search host lifecycle history failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchHostLifecycleHistoryResult{Info: histories}, nil
+}
+
+// ListStuckHosts list the hosts that stay in the state longer than the duration, the hosts are sorted by the time
+// they enter the state, so the hosts that stay in the state longest are returned first
+func (hm *hostManager) ListStuckHosts(kit *rest.Kit, opt *metadata.ListStuckHostOption) (
+	*metadata.ListStuckHostResult, errors.CCErrorCoder) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	hostCond := util.SetQueryOwner(mapstr.MapStr{common.BKHostState: opt.State}, kit.SupplierAccount)
+	hosts := make([]metadata.HostMapStr, 0)
+	err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(hostCond).
+		Fields(common.BKHostIDField, common.BKHostInnerIPField, common.CreateTimeField).All(kit.Ctx, &hosts)
+	if err != nil {
+		blog.Errorf("list hosts in state %s failed, err: %v, rid: %s", opt.State, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	deadline := time.Now().Add(-time.Duration(opt.Duration) * time.Second)
+	stuckHosts := make([]metadata.StuckHost, 0)
+	for start := 0; start < len(hosts); start += common.BKMaxPageSize {
+		end := start + common.BKMaxPageSize
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+
+		pageHosts, err := hm.getStuckHosts(kit, opt.State, hosts[start:end], deadline)
+		if err != nil {
+			return nil, err
+		}
+		stuckHosts = append(stuckHosts, pageHosts...)
+	}
+
+	if opt.Page.EnableCount {
+		return &metadata.ListStuckHostResult{Count: uint64(len(stuckHosts))}, nil
+	}
+
+	sort.SliceStable(stuckHosts, func(i, j int) bool {
+		return stuckHosts[i].EnterTime.Before(stuckHosts[j].EnterTime)
+	})
+
+	start := opt.Page.Start
+	if start > len(stuckHosts) {
+		start = len(stuckHosts)
+	}
+	end := start + opt.Page.Limit
+	if end > len(stuckHosts) {
+		end = len(stuckHosts)
+	}
+
+	return &metadata.ListStuckHostResult{Info: stuckHosts[start:end]}, nil
+}
+
+// getStuckHosts get the hosts that enter the state before the deadline, the enter time of the host is the time of
+// its last transition to the state, or its create time if it never transits to the state
+func (hm *hostManager) getStuckHosts(kit *rest.Kit, state string, hosts []metadata.HostMapStr,
+	deadline time.Time) ([]metadata.StuckHost, errors.CCErrorCoder) {
+
+	hostIDs := make([]int64, 0)
+	for _, host := range hosts {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			blog.Errorf("parse host id failed, err: %v, host: %#v, rid: %s", err, host, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: util.SetQueryOwner(mapstr.MapStr{
+			common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs},
+			"to":                 state,
+		}, kit.SupplierAccount)},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":                  "$" + common.BKHostIDField,
+			common.CreateTimeField: mapstr.MapStr{"$max": "$" + common.CreateTimeField},
+		}},
+	}
+	enterTimes := make([]struct {
+		HostID     int64     `bson:"_id"`
+		CreateTime time.Time `bson:"create_time"`
+	}, 0)
+	err := mongodb.Client().Table(common.BKTableNameHostLifecycleHistory).AggregateAll(kit.Ctx, pipeline,
+		&enterTimes)
+	if err != nil {
+		blog.Errorf("get host lifecycle enter time failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	enterTimeMap := make(map[int64]time.Time)
+	for _, enterTime := range enterTimes {
+		enterTimeMap[enterTime.HostID] = enterTime.CreateTime
+	}
+
+	stuckHosts := make([]metadata.StuckHost, 0)
+	for idx, host := range hosts {
+		enterTime, exists := enterTimeMap[hostIDs[idx]]
+		if !exists {
+			switch createTime := host[common.CreateTimeField].(type) {
+			case time.Time:
+				enterTime = createTime
+			case primitive.DateTime:
+				enterTime = createTime.Time()
+			default:
+				continue
+			}
+		}
+
+		if enterTime.After(deadline) {
+			continue
+		}
+
+		stuckHosts = append(stuckHosts, metadata.StuckHost{
+			HostID:    hostIDs[idx],
+			InnerIP:   util.GetStrByInterface(host[common.BKHostInnerIPField]),
+			State:     state,
+			EnterTime: enterTime,
+		})
+	}
+
+	return stuckHosts, nil
+}
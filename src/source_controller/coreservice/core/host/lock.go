@@ -19,11 +19,11 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
-	httpheader "configcenter/src/common/http/header"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core/host/hostlock"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -49,41 +49,80 @@ func (hm *hostManager) LockHost(kit *rest.Kit, input *metadata.HostLockRequest)
 		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, fmt.Sprintf(" id_list %v", diffID))
 	}
 
-	user := httpheader.GetUser(kit.Header)
-	var insertDataArr []interface{}
+	locks := make([]metadata.HostLockData, 0)
+	lockCond := util.SetQueryOwner(mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: input.IDS}},
+		kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameHostLock).Find(lockCond).All(kit.Ctx, &locks); err != nil {
+		blog.Errorf("lock host, query host lock from db failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommDBSelectFailed)
+	}
+
+	// the hosts locked by other users can not be locked again, the expired locks and the locks of the same user are
+	// replaced by the new locks
 	ts := time.Now().UTC()
-	for _, id := range input.IDS {
-		conds := mapstr.MapStr{
-			common.BKHostIDField: id,
-		}
-		conds = util.SetQueryOwner(conds, kit.SupplierAccount)
-		cnt, err := mongodb.Client().Table(common.BKTableNameHostLock).Find(conds).Count(kit.Ctx)
-		if nil != err {
-			blog.Errorf("lock host, query host lock from db failed, err:%+v, rid:%s", err, kit.Rid)
-			return kit.CCError.Errorf(common.CCErrCommDBSelectFailed)
-		}
-		if 0 == cnt {
-			insertDataArr = append(insertDataArr, metadata.HostLockData{
-				User:       user,
-				ID:         id,
-				CreateTime: ts,
-				OwnerID:    httpheader.GetSupplierAccount(kit.Header),
-			})
-		}
+	lockedIDs, lockUsers := metadata.GetLockedByOthers(locks, kit.User, ts)
+	if len(lockedIDs) > 0 {
+		blog.Errorf("lock host, hosts %v are already locked by %v, rid: %s", lockedIDs, lockUsers, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrHostLockedByOthers, lockedIDs, lockUsers)
 	}
 
-	if 0 < len(insertDataArr) {
-		err := mongodb.Client().Table(common.BKTableNameHostLock).Insert(kit.Ctx, insertDataArr)
-		if nil != err {
-			blog.Errorf("lock host, save host lock to db failed, err: %+v, rid:%s", err, kit.Rid)
-			return kit.CCError.Errorf(common.CCErrCommDBInsertFailed)
+	replacedHostIDs := make([]int64, 0)
+	for _, lock := range locks {
+		replacedHostIDs = append(replacedHostIDs, lock.ID)
+	}
+
+	if len(replacedHostIDs) > 0 {
+		replacedCond := util.SetQueryOwner(mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{
+			common.BKDBIN: replacedHostIDs}}, kit.SupplierAccount)
+		if err := mongodb.Client().Table(common.BKTableNameHostLock).Delete(kit.Ctx, replacedCond); err != nil {
+			blog.Errorf("lock host, delete replaced host lock failed, err: %v, rid: %s", err, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommDBDeleteFailed)
 		}
 	}
+
+	var expireTime *time.Time
+	if input.TTL > 0 {
+		expireAt := ts.Add(time.Duration(input.TTL) * time.Second)
+		expireTime = &expireAt
+	}
+
+	insertDataArr := make([]metadata.HostLockData, 0, len(input.IDS))
+	for _, id := range input.IDS {
+		insertDataArr = append(insertDataArr, metadata.HostLockData{
+			User:       kit.User,
+			ID:         id,
+			CreateTime: ts,
+			OwnerID:    kit.SupplierAccount,
+			Reason:     input.Reason,
+			Scope:      input.Scope,
+			ExpireTime: expireTime,
+		})
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostLock).Insert(kit.Ctx, insertDataArr); err != nil {
+		blog.Errorf("lock host, save host lock to db failed, err: %+v, rid:%s", err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommDBInsertFailed)
+	}
 	return nil
 }
 
 // UnlockHost TODO
 func (hm *hostManager) UnlockHost(kit *rest.Kit, input *metadata.HostLockRequest) errors.CCError {
+	// only the lock owner can release the host locks, the locks of other users are released by the administrators
+	// with the force unlock api
+	if !input.Force {
+		locks, err := hostlock.GetActiveLocks(kit, input.IDS)
+		if err != nil {
+			return err
+		}
+
+		lockedIDs, lockUsers := metadata.GetLockedByOthers(locks, kit.User, time.Now())
+		if len(lockedIDs) > 0 {
+			blog.Errorf("unlock host, hosts %v are locked by %v, rid: %s", lockedIDs, lockUsers, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrHostLockedByOthers, lockedIDs, lockUsers)
+		}
+	}
+
 	conds := mapstr.MapStr{
 		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: input.IDS},
 	}
@@ -104,6 +143,7 @@ func (hm *hostManager) QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLoc
 	conds := mapstr.MapStr{
 		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: input.IDS},
 	}
+	conds.Merge(hostlock.ActiveCond(time.Now()))
 	conds = util.SetModOwner(conds, kit.SupplierAccount)
 	limit := uint64(len(input.IDS))
 	err := mongodb.Client().Table(common.BKTableNameHostLock).Find(conds).Limit(limit).All(kit.Ctx, &hostLockInfoArr)
@@ -114,6 +154,49 @@ func (hm *hostManager) QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLoc
 	return hostLockInfoArr, nil
 }
 
+// ListHostLock list the active host locks
+func (hm *hostManager) ListHostLock(kit *rest.Kit, opt *metadata.ListHostLockOption) (*metadata.ListHostLockResult,
+	errors.CCErrorCoder) {
+
+	cond := hostlock.ActiveCond(time.Now())
+	if len(opt.HostIDs) > 0 {
+		cond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: opt.HostIDs}
+	}
+	if opt.User != "" {
+		cond["bk_user"] = opt.User
+	}
+	switch opt.Scope {
+	case "":
+	case metadata.HostLockScopeFull:
+		// the locks created before the scope is supported are full locks
+		cond["scope"] = mapstr.MapStr{common.BKDBIN: []interface{}{metadata.HostLockScopeFull, nil}}
+	default:
+		cond["scope"] = opt.Scope
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	count, err := mongodb.Client().Table(common.BKTableNameHostLock).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count host locks failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = common.BKHostIDField
+	}
+
+	locks := make([]metadata.HostLockData, 0)
+	err = mongodb.Client().Table(common.BKTableNameHostLock).Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(kit.Ctx, &locks)
+	if err != nil {
+		blog.Errorf("list host locks failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.ListHostLockResult{Count: int64(count), Info: locks}, nil
+}
+
 func diffHostLockID(ids []int64, hostInfos []metadata.HostMapStr, rid string) []int64 {
 	mapInnerID := make(map[int64]bool)
 	for _, hostInfo := range hostInfos {
@@ -0,0 +1,179 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CreateHostSnapHistory create host snapshot change or drift histories
+func (hm *hostManager) CreateHostSnapHistory(kit *rest.Kit,
+	opt *metadata.CreateHostSnapHistoryOption) errors.CCErrorCoder {
+
+	if len(opt.Histories) == 0 {
+		return nil
+	}
+
+	if len(opt.Histories) > common.BKMaxPageSize {
+		return kit.CCError.CCErrorf(common.CCErrExceedMaxOperationRecordsAtOnce, common.BKMaxPageSize)
+	}
+
+	for _, history := range opt.Histories {
+		if history.HostID <= 0 {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+		}
+
+		if !history.Type.Validate() {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "type")
+		}
+	}
+
+	ids, err := mongodb.Client().NextSequences(kit.Ctx, common.BKTableNameHostSnapHistory, len(opt.Histories))
+	if err != nil {
+		blog.Errorf("generate host snapshot history ids failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	for idx := range opt.Histories {
+		opt.Histories[idx].ID = int64(ids[idx])
+		opt.Histories[idx].OwnerID = kit.SupplierAccount
+		if opt.Histories[idx].CreateTime.IsZero() {
+			opt.Histories[idx].CreateTime = now
+		}
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostSnapHistory).Insert(kit.Ctx, opt.Histories); err != nil {
+		blog.Errorf("create host snapshot histories failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return nil
+}
+
+// hostSnapHistoryCond generate the condition of the host snapshot histories that matches the filter
+func hostSnapHistoryCond(kit *rest.Kit, filter *metadata.HostSnapHistoryFilter) mapstr.MapStr {
+	cond := mapstr.MapStr{"type": filter.Type}
+	if len(filter.Fields) > 0 {
+		cond["bk_field"] = mapstr.MapStr{common.BKDBIN: filter.Fields}
+	}
+
+	timeCond := mapstr.MapStr{}
+	if filter.StartTime != nil {
+		timeCond[common.BKDBGTE] = *filter.StartTime
+	}
+	if filter.EndTime != nil {
+		timeCond[common.BKDBLTE] = *filter.EndTime
+	}
+	if len(timeCond) > 0 {
+		cond[common.CreateTimeField] = timeCond
+	}
+
+	return util.SetQueryOwner(cond, kit.SupplierAccount)
+}
+
+// SearchHostSnapHistory search host snapshot histories, sorted by create time desc by default
+func (hm *hostManager) SearchHostSnapHistory(kit *rest.Kit, opt *metadata.SearchHostSnapHistoryOption) (
+	*metadata.SearchHostSnapHistoryResult, errors.CCErrorCoder) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	cond := hostSnapHistoryCond(kit, &opt.HostSnapHistoryFilter)
+	cond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: opt.HostIDs}
+
+	table := mongodb.Client().Table(common.BKTableNameHostSnapHistory)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count host snapshot history failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchHostSnapHistoryResult{Count: count}, nil
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-" + common.CreateTimeField
+	}
+
+	histories := make([]metadata.HostSnapHistory, 0)
+	err := table.Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).
+		All(kit.Ctx, &histories)
+	if err != nil {
+		blog.Errorf("search host snapshot history failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchHostSnapHistoryResult{Info: histories}, nil
+}
+
+// ListHostSnapChangedHosts list the hosts that have snapshot histories matching the filter, the hosts are sorted by
+// their last change time desc, so the most recently changed hosts are returned first
+func (hm *hostManager) ListHostSnapChangedHosts(kit *rest.Kit, opt *metadata.ListHostSnapChangedHostOption) (
+	*metadata.ListHostSnapChangedHostResult, errors.CCErrorCoder) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: hostSnapHistoryCond(kit, &opt.HostSnapHistoryFilter)},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":              "$" + common.BKHostIDField,
+			"bk_fields":        mapstr.MapStr{"$addToSet": "$bk_field"},
+			"count":            mapstr.MapStr{"$sum": 1},
+			"last_change_time": mapstr.MapStr{"$max": "$" + common.CreateTimeField},
+		}},
+	}
+
+	table := mongodb.Client().Table(common.BKTableNameHostSnapHistory)
+	if opt.Page.EnableCount {
+		pipeline = append(pipeline, mapstr.MapStr{"$count": "count"})
+		counts := make([]struct {
+			Count uint64 `bson:"count"`
+		}, 0)
+		if err := table.AggregateAll(kit.Ctx, pipeline, &counts); err != nil {
+			blog.Errorf("count host snapshot changed hosts failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if len(counts) == 0 {
+			return &metadata.ListHostSnapChangedHostResult{Count: 0}, nil
+		}
+		return &metadata.ListHostSnapChangedHostResult{Count: counts[0].Count}, nil
+	}
+
+	pipeline = append(pipeline,
+		mapstr.MapStr{common.BKDBSort: mapstr.MapStr{"last_change_time": -1}},
+		mapstr.MapStr{"$skip": opt.Page.Start},
+		mapstr.MapStr{"$limit": opt.Page.Limit},
+	)
+
+	hosts := make([]metadata.HostSnapChangedHost, 0)
+	if err := table.AggregateAll(kit.Ctx, pipeline, &hosts); err != nil {
+		blog.Errorf("list host snapshot changed hosts failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.ListHostSnapChangedHostResult{Info: hosts}, nil
+}
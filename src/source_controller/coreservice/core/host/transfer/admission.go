@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transfer
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/admission"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// admitTransfer calls the admission webhooks of host transfer action for each host, the old object contains the
+// current business and modules of the host, the object contains the target business and modules.
+func (t *genericTransfer) admitTransfer(kit *rest.Kit, hostIDs []int64) errors.CCErrorCoder {
+	if !admission.Enabled(common.BKInnerObjIDHost, metadata.AdmissionTransfer) {
+		return nil
+	}
+
+	cond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}}
+	relations := make([]metadata.ModuleHost, 0)
+	err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Find(cond).Fields(common.BKHostIDField,
+		common.BKAppIDField, common.BKModuleIDField).All(kit.Ctx, &relations)
+	if err != nil {
+		blog.Errorf("get host module relations failed, err: %v, host ids: %v, rid: %s", err, hostIDs, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	hostBizMap := make(map[int64]int64)
+	hostModuleMap := make(map[int64][]int64)
+	for _, relation := range relations {
+		hostBizMap[relation.HostID] = relation.AppID
+		hostModuleMap[relation.HostID] = append(hostModuleMap[relation.HostID], relation.ModuleID)
+	}
+
+	for _, hostID := range hostIDs {
+		targetModuleIDs := t.moduleIDArr
+		if t.isIncrement && hostBizMap[hostID] == t.bizID {
+			targetModuleIDs = util.IntArrayUnique(append(append([]int64{}, hostModuleMap[hostID]...),
+				t.moduleIDArr...))
+		}
+
+		oldObject := mapstr.MapStr{
+			common.BKHostIDField:   hostID,
+			common.BKAppIDField:    hostBizMap[hostID],
+			common.BKModuleIDField: hostModuleMap[hostID],
+		}
+		object := mapstr.MapStr{
+			common.BKHostIDField:   hostID,
+			common.BKAppIDField:    t.bizID,
+			common.BKModuleIDField: targetModuleIDs,
+		}
+
+		if _, err := admission.Admit(kit, common.BKInnerObjIDHost, metadata.AdmissionTransfer, oldObject,
+			object); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// admitDeleteHosts calls the admission webhooks of host delete action for each host
+func (t *genericTransfer) admitDeleteHosts(kit *rest.Kit, hostIDs []int64) errors.CCErrorCoder {
+	if !admission.Enabled(common.BKInnerObjIDHost, metadata.AdmissionDelete) {
+		return nil
+	}
+
+	cond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}}
+	hosts := make([]mapstr.MapStr, 0)
+	if err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(cond).All(kit.Ctx, &hosts); err != nil {
+		blog.Errorf("get hosts failed, err: %v, host ids: %v, rid: %s", err, hostIDs, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	for _, host := range hosts {
+		if _, err := admission.Admit(kit, common.BKInnerObjIDHost, metadata.AdmissionDelete, host, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core/host/hostlock"
 	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 )
@@ -146,6 +147,10 @@ func (manager *TransferManager) TransferToNormalModule(kit *rest.Kit, input *met
 // 如果主机属于故障机模块，操作失败
 // 如果主机不在参数指定的模块中，操作失败
 func (manager *TransferManager) RemoveFromModule(kit *rest.Kit, input *metadata.RemoveHostsFromModuleOption) error {
+	if err := hostlock.Check(kit, []int64{input.HostID}, metadata.HostLockOpTransfer); err != nil {
+		return err
+	}
+
 	hostConfigFilter := map[string]interface{}{
 		common.BKHostIDField: input.HostID,
 		common.BKAppIDField:  input.ApplicationID,
@@ -458,6 +463,10 @@ func (manager *TransferManager) TransferResourceDirectory(kit *rest.Kit,
 		return err
 	}
 
+	if err := hostlock.Check(kit, input.HostID, metadata.HostLockOpTransfer); err != nil {
+		return err
+	}
+
 	cond := map[string]interface{}{
 		common.BKHostIDField: map[string]interface{}{
 			common.BKDBIN: input.HostID,
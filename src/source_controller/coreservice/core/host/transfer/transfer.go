@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	kubetypes "configcenter/src/kube/types"
+	"configcenter/src/source_controller/coreservice/core/host/hostlock"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/thirdparty/hooks"
 )
@@ -104,6 +105,14 @@ func (t *genericTransfer) Transfer(kit *rest.Kit, hostIDs []int64, disableHostAp
 		return err
 	}
 
+	if err := hostlock.Check(kit, hostIDs, metadata.HostLockOpTransfer); err != nil {
+		return err
+	}
+
+	if err := t.admitTransfer(kit, hostIDs); err != nil {
+		return err
+	}
+
 	// remove service instance if necessary
 	if err := t.removeHostServiceInstance(kit, hostIDs); err != nil {
 		return err
@@ -146,6 +155,14 @@ func (t *genericTransfer) DeleteHosts(kit *rest.Kit, hostIDs []int64) error {
 		return err
 	}
 
+	if err := hostlock.Check(kit, hostIDs, metadata.HostLockOpDelete); err != nil {
+		return err
+	}
+
+	if err := t.admitDeleteHosts(kit, hostIDs); err != nil {
+		return err
+	}
+
 	// remove service instances
 	if err := t.removeHostServiceInstance(kit, hostIDs); err != nil {
 		return err
@@ -57,6 +57,15 @@ func (p *hostApplyRule) GenerateApplyPlan(kit *rest.Kit, bizID int64, option met
 	for _, item := range option.Rules {
 		attributeIDs = append(attributeIDs, item.AttributeID)
 	}
+
+	// host rules are the dynamic group rules matched by the hosts
+	hostRuleMap := make(map[int64][]metadata.HostApplyRule)
+	for _, item := range option.HostRules {
+		hostRuleMap[item.HostID] = append(hostRuleMap[item.HostID], item.Rules...)
+		for _, rule := range item.Rules {
+			attributeIDs = append(attributeIDs, rule.AttributeID)
+		}
+	}
 	attributes, ccErr := p.listHostAttributes(kit, bizID, attributeIDs...)
 	if ccErr != nil {
 		blog.Errorf("listHostAttributes failed, ccErr: %v, attributeIDs: %v, rid: %s", ccErr, attributeIDs, rid)
@@ -130,7 +139,7 @@ func (p *hostApplyRule) GenerateApplyPlan(kit *rest.Kit, bizID int64, option met
 			continue
 		}
 		hostApplyPlan, ccErr = p.generateOneHostApplyPlan(kit, hostModule.HostID, host, hostModule.ModuleIDs,
-			option.Rules, attributes, option.ConflictResolvers)
+			option.Rules, hostRuleMap[hostModule.HostID], attributes, option.ConflictResolvers)
 		if ccErr != nil {
 			blog.Errorf("generateOneHostApplyPlan failed, ccErr: %v, host: %v, moduleIDs: %v, rules: %v, rid: %s",
 				ccErr, host, hostModule.ModuleIDs, option.Rules, rid)
@@ -414,6 +423,7 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 	host map[string]interface{},
 	moduleIDs []int64,
 	rules []metadata.HostApplyRule,
+	hostRules []metadata.HostApplyRule,
 	attributes []metadata.Attribute,
 	resolvers []metadata.HostApplyConflictResolver,
 ) (metadata.OneHostApplyPlan, errors.CCErrorCoder) {
@@ -441,6 +451,14 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 		attributeRules[rule.AttributeID] = append(attributeRules[rule.AttributeID], rule)
 	}
 
+	// host rules have the lowest precedence, they are only used when the attribute has no module rules
+	for _, rule := range hostRules {
+		if _, exist := attributeRules[rule.AttributeID]; exist {
+			continue
+		}
+		attributeRules[rule.AttributeID] = []metadata.HostApplyRule{rule}
+	}
+
 	attributeMap := make(map[int64]metadata.Attribute)
 	for _, attribute := range attributes {
 		attributeMap[attribute.ID] = attribute
@@ -566,6 +584,30 @@ func (p *hostApplyRule) getFinalRules(kit *rest.Kit, bizID int64, haveHostApplyI
 	return finalRules, nil
 }
 
+// mergeScopeRules merge the business, set and set template scope rules into the module final rules by the scope
+// precedence, dynamic group rules are matched by hosts, they are not applied when hosts are transferred
+func (p *hostApplyRule) mergeScopeRules(kit *rest.Kit, bizID int64, modules []metadata.ModuleInst,
+	finalRules []metadata.HostApplyRule) ([]metadata.HostApplyRule, errors.CCErrorCoder) {
+
+	scopeRuleOpt := metadata.ListHostApplyScopeRuleOption{
+		Scopes: []metadata.HostApplyScope{metadata.HostApplyScopeBiz, metadata.HostApplyScopeSet,
+			metadata.HostApplyScopeSetTemplate},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	scopeRules, ccErr := p.ListHostApplyScopeRule(kit, bizID, scopeRuleOpt)
+	if ccErr != nil {
+		blog.Errorf("list host apply scope rules failed, bizID: %d, err: %v, rid: %s", bizID, ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
+	if len(scopeRules.Info) == 0 {
+		return finalRules, nil
+	}
+
+	mergedRules, _ := metadata.MergeHostApplyScopeRules(modules, finalRules, scopeRules.Info)
+	return mergedRules, nil
+}
+
 // RunHostApplyOnHosts run host apply rule on specified host
 func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, relations []metadata.ModuleHost) (
 	metadata.MultipleHostApplyResult, errors.CCErrorCoder) {
@@ -580,7 +622,8 @@ func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, relation
 	modules := make([]metadata.ModuleInst, 0)
 	moduleFilter := map[string]interface{}{common.BKModuleIDField: map[string]interface{}{common.BKDBIN: moduleIDs}}
 
-	fields := []string{common.BKModuleIDField, common.BKServiceTemplateIDField, common.HostApplyEnabledField}
+	fields := []string{common.BKAppIDField, common.BKSetIDField, common.BKModuleIDField,
+		common.BKServiceTemplateIDField, common.BKSetTemplateIDField, common.HostApplyEnabledField}
 	err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(moduleFilter).Fields(fields...).
 		All(kit.Ctx, &modules)
 	if err != nil {
@@ -593,6 +636,30 @@ func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, relation
 		return result, cErr
 	}
 
+	serviceTemplateIDs := make([]int64, 0)
+	for serviceTemplateID := range srvTemplateIDMap {
+		serviceTemplateIDs = append(serviceTemplateIDs, serviceTemplateID)
+	}
+
+	finalRules, cErr := p.getFinalRules(kit, bizID, haveHostApplyIDs, serviceTemplateIDs, srvTemplateIDMap)
+	if cErr != nil {
+		return result, cErr
+	}
+
+	finalRules, cErr = p.mergeScopeRules(kit, bizID, modules, finalRules)
+	if cErr != nil {
+		return result, cErr
+	}
+
+	if len(finalRules) == 0 {
+		return result, nil
+	}
+
+	// scope rules are applied regardless of the host apply enabled status of the modules
+	for _, rule := range finalRules {
+		enableModuleMap[rule.ModuleID] = struct{}{}
+	}
+
 	host2Modules := make(map[int64][]int64)
 	for _, relation := range relations {
 		if _, exist := enableModuleMap[relation.ModuleID]; !exist {
@@ -611,20 +678,6 @@ func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, relation
 			ModuleIDs: moduleIDs})
 	}
 
-	serviceTemplateIDs := make([]int64, 0)
-	for serviceTemplateID := range srvTemplateIDMap {
-		serviceTemplateIDs = append(serviceTemplateIDs, serviceTemplateID)
-	}
-
-	finalRules, cErr := p.getFinalRules(kit, bizID, haveHostApplyIDs, serviceTemplateIDs, srvTemplateIDMap)
-	if cErr != nil {
-		return result, cErr
-	}
-
-	if len(finalRules) == 0 {
-		return result, nil
-	}
-
 	planOption := metadata.HostApplyPlanOption{
 		Rules:       finalRules,
 		HostModules: hostModules,
@@ -0,0 +1,267 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/thirdparty/hooks"
+)
+
+const dynamicGroupIDField = "dynamic_group_id"
+
+// validateScopeID validate that the set, set template or host dynamic group of the scope rule exists in the business
+func (p *hostApplyRule) validateScopeID(kit *rest.Kit, bizID int64,
+	option *metadata.CreateHostApplyScopeRuleOption) errors.CCErrorCoder {
+
+	var table, field string
+	filter := map[string]interface{}{
+		common.BKAppIDField: bizID,
+	}
+
+	switch option.Scope {
+	case metadata.HostApplyScopeBiz:
+		table, field = common.BKTableNameBaseApp, common.BKAppIDField
+	case metadata.HostApplyScopeSet:
+		table, field = common.BKTableNameBaseSet, common.BKSetIDField
+		filter[common.BKSetIDField] = option.SetID
+	case metadata.HostApplyScopeSetTemplate:
+		table, field = common.BKTableNameSetTemplate, common.BKSetTemplateIDField
+		filter[common.BKFieldID] = option.SetTemplateID
+	case metadata.HostApplyScopeDynamicGroup:
+		// only host dynamic group can be used to apply host attributes
+		table, field = common.BKTableNameDynamicGroup, dynamicGroupIDField
+		filter[common.BKFieldID] = option.DynamicGroupID
+		filter[common.BKObjIDField] = common.BKInnerObjIDHost
+	default:
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "scope")
+	}
+
+	count, err := mongodb.Client().Table(table).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count scope instance failed, table: %s, filter: %v, err: %v, rid: %s", table, filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count == 0 {
+		blog.Errorf("scope instance not exists, table: %s, filter: %v, rid: %s", table, filter, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	return nil
+}
+
+// validateScopeRuleValue validate the property value of the scope rule by the host attribute
+func (p *hostApplyRule) validateScopeRuleValue(kit *rest.Kit, bizID, attributeID int64,
+	value interface{}) (interface{}, errors.CCErrorCoder) {
+
+	attribute, ccErr := p.getHostAttribute(kit, bizID, attributeID)
+	if ccErr != nil {
+		blog.Errorf("get host attribute failed, bizID: %d, attributeID: %d, err: %v, rid: %s", bizID, attributeID,
+			ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
+	if strValue, ok := value.(string); ok {
+		value = strings.TrimSpace(strValue)
+	}
+
+	rawError := attribute.Validate(kit.Ctx, value, common.BKPropertyValueField)
+	if rawError.ErrCode != 0 {
+		ccErr := rawError.ToCCError(kit.CCError)
+		blog.Errorf("validate host attribute value failed, attribute: %+v, value: %+v, err: %v, rid: %s", attribute,
+			value, ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
+	if err := hooks.ValidHostApplyStatusHook(kit, p.cs, attribute.PropertyID, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// CreateHostApplyScopeRule create host apply rule of business, set, set template or host dynamic group scope
+func (p *hostApplyRule) CreateHostApplyScopeRule(kit *rest.Kit, bizID int64,
+	option metadata.CreateHostApplyScopeRuleOption) (metadata.HostApplyScopeRule, errors.CCErrorCoder) {
+
+	now := time.Now()
+	rule := metadata.HostApplyScopeRule{
+		BizID:           bizID,
+		Scope:           option.Scope,
+		SetID:           option.SetID,
+		SetTemplateID:   option.SetTemplateID,
+		DynamicGroupID:  option.DynamicGroupID,
+		AttributeID:     option.AttributeID,
+		Creator:         kit.User,
+		Modifier:        kit.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: kit.SupplierAccount,
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("create host apply scope rule option is invalid, option: %+v, err: %v, rid: %s", option, rawErr,
+			kit.Rid)
+		return rule, rawErr.ToCCError(kit.CCError)
+	}
+
+	if err := p.validateScopeID(kit, bizID, &option); err != nil {
+		return rule, err
+	}
+
+	value, err := p.validateScopeRuleValue(kit, bizID, option.AttributeID, option.PropertyValue)
+	if err != nil {
+		return rule, err
+	}
+	rule.PropertyValue = value
+
+	id, dbErr := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameHostApplyScopeRule)
+	if dbErr != nil {
+		blog.Errorf("generate host apply scope rule id failed, err: %v, rid: %s", dbErr, kit.Rid)
+		return rule, kit.CCError.CCErrorf(common.CCErrCommGenerateRecordIDFailed)
+	}
+	rule.ID = int64(id)
+
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyScopeRule).Insert(kit.Ctx, rule); err != nil {
+		if mongodb.Client().IsDuplicatedError(err) {
+			blog.Errorf("host apply scope rule is duplicated, doc: %+v, err: %v, rid: %s", rule, err, kit.Rid)
+			return rule, kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, common.BKAttributeIDField)
+		}
+		blog.Errorf("insert host apply scope rule failed, doc: %+v, err: %v, rid: %s", rule, err, kit.Rid)
+		return rule, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return rule, nil
+}
+
+// UpdateHostApplyScopeRule update the property value of the host apply scope rule
+func (p *hostApplyRule) UpdateHostApplyScopeRule(kit *rest.Kit, bizID int64, ruleID int64,
+	option metadata.UpdateHostApplyScopeRuleOption) (metadata.HostApplyScopeRule, errors.CCErrorCoder) {
+
+	rule := metadata.HostApplyScopeRule{}
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField:      bizID,
+		common.BKFieldID:         ruleID,
+	}
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyScopeRule).Find(filter).One(kit.Ctx,
+		&rule); err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("host apply scope rule not found, filter: %v, rid: %s", filter, kit.Rid)
+			return rule, kit.CCError.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get host apply scope rule failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return rule, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	value, ccErr := p.validateScopeRuleValue(kit, bizID, rule.AttributeID, option.PropertyValue)
+	if ccErr != nil {
+		return rule, ccErr
+	}
+
+	rule.PropertyValue = value
+	rule.Modifier = kit.User
+	rule.LastTime = time.Now()
+
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyScopeRule).Update(kit.Ctx, filter, rule); err != nil {
+		blog.Errorf("update host apply scope rule failed, filter: %v, doc: %+v, err: %v, rid: %s", filter, rule, err,
+			kit.Rid)
+		return rule, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return rule, nil
+}
+
+// DeleteHostApplyScopeRule delete host apply scope rules by ids
+func (p *hostApplyRule) DeleteHostApplyScopeRule(kit *rest.Kit, bizID int64,
+	option metadata.DeleteHostApplyScopeRuleOption) errors.CCErrorCoder {
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField:      bizID,
+		common.BKFieldID: map[string]interface{}{
+			common.BKDBIN: option.RuleIDs,
+		},
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyScopeRule).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("delete host apply scope rules failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// ListHostApplyScopeRule list host apply scope rules by condition
+func (p *hostApplyRule) ListHostApplyScopeRule(kit *rest.Kit, bizID int64,
+	option metadata.ListHostApplyScopeRuleOption) (metadata.MultipleHostApplyScopeRuleResult, errors.CCErrorCoder) {
+
+	result := metadata.MultipleHostApplyScopeRuleResult{}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField:      bizID,
+	}
+	if len(option.Scopes) != 0 {
+		filter["scope"] = map[string]interface{}{common.BKDBIN: option.Scopes}
+	}
+	if len(option.SetIDs) != 0 {
+		filter[common.BKSetIDField] = map[string]interface{}{common.BKDBIN: option.SetIDs}
+	}
+	if len(option.SetTemplateIDs) != 0 {
+		filter[common.BKSetTemplateIDField] = map[string]interface{}{common.BKDBIN: option.SetTemplateIDs}
+	}
+	if len(option.DynamicGroupIDs) != 0 {
+		filter[dynamicGroupIDField] = map[string]interface{}{common.BKDBIN: option.DynamicGroupIDs}
+	}
+	if len(option.AttributeIDs) != 0 {
+		filter[common.BKAttributeIDField] = map[string]interface{}{common.BKDBIN: option.AttributeIDs}
+	}
+
+	query := mongodb.Client().Table(common.BKTableNameHostApplyScopeRule).Find(filter)
+	total, err := query.Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count host apply scope rules failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return result, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	result.Count = int64(total)
+
+	if len(option.Page.Sort) > 0 {
+		query = query.Sort(option.Page.Sort)
+	}
+	if option.Page.Limit > 0 {
+		query = query.Limit(uint64(option.Page.Limit))
+	}
+	if option.Page.Start > 0 {
+		query = query.Start(uint64(option.Page.Start))
+	}
+
+	rules := make([]metadata.HostApplyScopeRule, 0)
+	if err := query.All(kit.Ctx, &rules); err != nil {
+		blog.Errorf("list host apply scope rules failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return result, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result.Info = rules
+	return result, nil
+}
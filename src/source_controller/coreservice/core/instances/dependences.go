@@ -42,4 +42,7 @@ type OperationDependences interface {
 
 	// AttachQuotedInst attach quoted instances with source instance
 	AttachQuotedInst(kit *rest.Kit, objID string, instID uint64, data mapstr.MapStr) error
+
+	// CreateAuditLogDependence create audit logs
+	CreateAuditLogDependence(kit *rest.Kit, logs ...metadata.AuditLog) error
 }
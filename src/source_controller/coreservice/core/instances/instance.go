@@ -13,6 +13,7 @@
 package instances
 
 import (
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -25,7 +26,9 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/admission"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/host/hostlock"
 	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/mongodb/instancemapping"
@@ -64,6 +67,12 @@ func (m *instanceManager) CreateModelInstance(kit *rest.Kit, objID string,
 	rid := util.ExtractRequestIDFromContext(kit.Ctx)
 
 	inputParam.Data.Set(common.BKOwnerIDField, kit.SupplierAccount)
+	data, ccErr := admission.Admit(kit, objID, metadata.AdmissionCreate, nil, inputParam.Data)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+	inputParam.Data = data
+
 	bizID, err := m.getBizIDFromInstance(kit, objID, inputParam.Data, common.ValidCreate, 0)
 	if err != nil {
 		blog.Errorf("CreateModelInstance failed, getBizIDFromInstance err:%v, objID:%s, data:%#v, rid:%s", err, objID,
@@ -116,6 +125,27 @@ func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit,
 		return dataResult, nil
 	}
 
+	admitted := make(map[int]bool, len(inputParam.Datas))
+	for index, item := range inputParam.Datas {
+		if item == nil {
+			continue
+		}
+		item.Set(common.BKOwnerIDField, kit.SupplierAccount)
+
+		data, ccErr := admission.Admit(kit, objID, metadata.AdmissionCreate, nil, item)
+		if ccErr != nil {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     ccErr.Error(),
+				Code:        int64(ccErr.GetCode()),
+				Data:        item,
+				OriginIndex: int64(index),
+			})
+			continue
+		}
+		inputParam.Datas[index] = data
+		admitted[index] = true
+	}
+
 	instValidators, err := m.getValidatorsFromInstances(kit, objID, inputParam.Datas, common.ValidCreate)
 	if err != nil {
 		blog.Errorf("get inst(%#v) validators failed, err: %v, obj: %s, rid:%s", err, objID, inputParam.Datas, kit.Rid)
@@ -127,7 +157,11 @@ func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit,
 			blog.ErrorJSON("the model instance data can't be empty, input data: %s rid: %s", inputParam.Datas, kit.Rid)
 			return nil, kit.CCError.Errorf(common.CCErrCommInstDataNil, "modelInstance")
 		}
-		item.Set(common.BKOwnerIDField, kit.SupplierAccount)
+
+		// the instance denied by the admission webhooks is already recorded in the exceptions
+		if !admitted[index] {
+			continue
+		}
 
 		validator := instValidators[index]
 		if validator == nil {
@@ -214,6 +248,12 @@ func (m *instanceManager) BatchCreateModelInstance(kit *rest.Kit, objID string,
 		}
 		inputParam.Data[idx].Set(common.BKOwnerIDField, kit.SupplierAccount)
 
+		data, ccErr := admission.Admit(kit, objID, metadata.AdmissionCreate, nil, inputParam.Data[idx])
+		if ccErr != nil {
+			return nil, ccErr
+		}
+		inputParam.Data[idx] = data
+
 		validator := instValidators[idx]
 		if validator == nil {
 			blog.Errorf("get validator failed, objID: %s, inst: %#v, rid: %s", objID, inputParam.Data[idx], kit.Rid)
@@ -256,12 +296,23 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		return nil, kit.CCError.Error(common.CCErrCommNotFound)
 	}
 
+	if objID == common.BKInnerObjIDHost {
+		if err := hostlock.CheckUpdate(kit, origins, inputParam.Data); err != nil {
+			return nil, err
+		}
+	}
+
 	instValidators, err := m.getValidatorsFromInstances(kit, objID, origins, common.ValidUpdate)
 	if err != nil {
 		blog.Errorf("get inst validators failed, err: %v, objID: %s, data: %#v, rid:%s", err, objID, origins, kit.Rid)
 		return nil, err
 	}
 
+	updateData, isShared, ccErr := admitUpdateData(kit, objID, origins, inputParam.Data)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
 	isMainline, err := m.isMainlineObject(kit, objID)
 	if err != nil {
 		return nil, err
@@ -274,43 +325,107 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 			return nil, kit.CCError.CCErrorf(common.CCErrCommNotFound)
 		}
 
-		// it is not allowed to update multiple records if the updateData has a unique field
+		// it is not allowed to update multiple records if the updateData has a unique field, the unique fields
+		// patched differently for each instance by the mutating webhooks are allowed
 		if index == 0 && len(origins) > 1 {
-			if err := validator.validUpdateUniqFieldInMulti(kit, inputParam.Data, m); err != nil {
+			uniqueCheckData := inputParam.Data
+			if isShared {
+				uniqueCheckData = updateData[0]
+			}
+
+			if err := validator.validUpdateUniqFieldInMulti(kit, uniqueCheckData, m); err != nil {
 				blog.Errorf("update unique field in multiple records, err: %v, updateData: %#v, rid:%s",
-					err, inputParam.Data, kit.Rid)
+					err, uniqueCheckData, kit.Rid)
 				return nil, err
 			}
 		}
 
-		if err := hooks.UpdateProcessBindInfoHook(kit, objID, origin, inputParam.Data); err != nil {
+		if err := hooks.UpdateProcessBindInfoHook(kit, objID, origin, updateData[index]); err != nil {
 			return nil, err
 		}
 
-		if err = m.validUpdateInstanceData(kit, objID, inputParam.Data, origin, validator, inputParam.CanEditAll,
+		if err = m.validUpdateInstanceData(kit, objID, updateData[index], origin, validator, inputParam.CanEditAll,
 			isMainline); err != nil {
 			blog.Errorf("update instance validation failed, err: %v, objID: %s, update data: %#v, inst: %#v, rid: %s",
-				err, objID, inputParam.Data, origin, kit.Rid)
+				err, objID, updateData[index], origin, kit.Rid)
 			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, err.Error())
 		}
 	}
 
-	err = m.update(kit, objID, inputParam.Data, inputParam.Condition)
-	if err != nil {
-		blog.Errorf("update objID(%s) inst failed, err: %v, condition: %#v, data: %#v rid: %s", objID, err,
-			inputParam.Condition, inputParam.Data, kit.Rid)
-		return nil, err
+	if isShared {
+		err = m.update(kit, objID, updateData[0], inputParam.Condition)
+		if err != nil {
+			blog.Errorf("update objID(%s) inst failed, err: %v, condition: %#v, data: %#v rid: %s", objID, err,
+				inputParam.Condition, updateData[0], kit.Rid)
+			return nil, err
+		}
+
+		if objID == common.BKInnerObjIDHost {
+			if err := m.updateHostProcessBindIP(kit, updateData[0], origins); err != nil {
+				return nil, err
+			}
+		}
+
+		return &metadata.UpdatedCount{Count: uint64(len(origins))}, nil
 	}
 
-	if objID == common.BKInnerObjIDHost {
-		if err := m.updateHostProcessBindIP(kit, inputParam.Data, origins); err != nil {
+	// the mutating webhooks patched the instances differently, update each instance with its own admitted data
+	instIDField := common.GetInstIDField(objID)
+	for index, origin := range origins {
+		cond := mapstr.MapStr{instIDField: origin[instIDField]}
+		cond = util.SetModOwner(cond, kit.SupplierAccount)
+		if err = m.update(kit, objID, updateData[index], cond); err != nil {
+			blog.Errorf("update objID(%s) inst failed, err: %v, condition: %#v, data: %#v rid: %s", objID, err,
+				cond, updateData[index], kit.Rid)
 			return nil, err
 		}
+
+		if objID == common.BKInnerObjIDHost {
+			if err := m.updateHostProcessBindIP(kit, updateData[index], []mapstr.MapStr{origin}); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return &metadata.UpdatedCount{Count: uint64(len(origins))}, nil
 }
 
+// admitUpdateData admits the update data of each instance separately with the original update data, so that the
+// patch of one instance does not leak into the others. returns the admitted data of each instance, and whether the
+// admitted data is shared by all the instances so that they can be updated together
+func admitUpdateData(kit *rest.Kit, objID string, origins []mapstr.MapStr, data mapstr.MapStr) ([]mapstr.MapStr,
+	bool, errors.CCErrorCoder) {
+
+	updateData := make([]mapstr.MapStr, len(origins))
+	if !admission.Enabled(objID, metadata.AdmissionUpdate) {
+		for index := range origins {
+			updateData[index] = data
+		}
+		return updateData, true, nil
+	}
+
+	isShared := true
+	for index, origin := range origins {
+		admitted, err := admission.Admit(kit, objID, metadata.AdmissionUpdate, origin, data.Clone())
+		if err != nil {
+			return nil, false, err
+		}
+
+		updateData[index] = admitted
+		if index > 0 && !reflect.DeepEqual(admitted, updateData[0]) {
+			isShared = false
+		}
+	}
+
+	if isShared {
+		for index := range updateData {
+			updateData[index] = updateData[0]
+		}
+	}
+
+	return updateData, isShared, nil
+}
+
 // updateHostProcessBindIP if hosts' ips are updated, update processes which binds the changed ip
 func (m *instanceManager) updateHostProcessBindIP(kit *rest.Kit, data mapstr.MapStr, origins []mapstr.MapStr) error {
 	updatedHostFirstIPMap := make(map[string]string)
@@ -585,6 +700,10 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string,
 	}
 
 	for _, origin := range origins {
+		if _, ccErr := admission.Admit(kit, objID, metadata.AdmissionDelete, origin, nil); ccErr != nil {
+			return &metadata.DeletedCount{}, ccErr
+		}
+
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return nil, err
@@ -638,6 +757,12 @@ func (m *instanceManager) CascadeDeleteModelInstance(kit *rest.Kit, objID string
 		return &metadata.DeletedCount{}, err
 	}
 
+	for _, origin := range origins {
+		if _, ccErr := admission.Admit(kit, objID, metadata.AdmissionDelete, origin, nil); ccErr != nil {
+			return &metadata.DeletedCount{}, ccErr
+		}
+	}
+
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
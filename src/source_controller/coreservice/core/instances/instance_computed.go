@@ -0,0 +1,126 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// UpdateComputedFields updates the computed fields of the instances with the values evaluated by the event flow,
+// these fields are skipped by the normal instance update, and the changes are saved as system audit logs.
+func (m *instanceManager) UpdateComputedFields(kit *rest.Kit, objID string,
+	input *metadata.UpdateComputedFieldsOption) error {
+
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	// only the computed fields of the model can be updated
+	attrCond := mapstr.MapStr{common.BKObjIDField: objID, common.BKPropertyTypeField: common.FieldTypeComputed}
+	attrCond = util.SetQueryOwner(attrCond, kit.SupplierAccount)
+	computedFields, err := mongodb.Client().Table(common.BKTableNameObjAttDes).Distinct(kit.Ctx,
+		common.BKPropertyIDField, attrCond)
+	if err != nil {
+		blog.Errorf("get %s computed fields failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	computedFieldMap := make(map[string]struct{})
+	for _, field := range computedFields {
+		computedFieldMap[util.GetStrByInterface(field)] = struct{}{}
+	}
+
+	instIDs := make([]int64, len(input.Data))
+	for idx, data := range input.Data {
+		for field := range data.Data {
+			if _, exists := computedFieldMap[field]; !exists {
+				blog.Errorf("field %s is not a computed field of %s, rid: %s", field, objID, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+			}
+		}
+		instIDs[idx] = data.InstID
+	}
+
+	// get the instances before update to generate audit logs
+	idField := common.GetInstIDField(objID)
+	tableName := common.GetInstTableName(objID, kit.SupplierAccount)
+	instCond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: instIDs}}
+	instCond = util.SetQueryOwner(instCond, kit.SupplierAccount)
+	insts := make([]mapstr.MapStr, 0)
+	if err = mongodb.Client().Table(tableName).Find(instCond).All(kit.Ctx, &insts); err != nil {
+		blog.Errorf("get %s instances failed, cond: %+v, err: %v, rid: %s", objID, instCond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	instMap := make(map[int64]mapstr.MapStr)
+	for _, inst := range insts {
+		instID, err := util.GetInt64ByInterface(inst[idField])
+		if err != nil {
+			blog.Errorf("parse %s instance id failed, inst: %+v, err: %v, rid: %s", objID, inst, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, idField)
+		}
+		instMap[instID] = inst
+	}
+
+	audit := auditlog.NewInstanceAudit(m.clientSet.CoreService())
+	auditLogs := make([]metadata.AuditLog, 0)
+	var updateErr error
+	for _, data := range input.Data {
+		inst, exists := instMap[data.InstID]
+		if !exists {
+			// the instance is deleted after its computed fields are evaluated, skip it
+			continue
+		}
+
+		param := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).WithUpdateFields(data.Data).
+			WithOperateFrom(metadata.FromCCSystem)
+		logs, err := audit.GenerateAuditLog(param, objID, []mapstr.MapStr{inst})
+		if err != nil {
+			blog.Errorf("generate %s instance %d audit log failed, err: %v, rid: %s", objID, data.InstID, err,
+				kit.Rid)
+			updateErr = err
+			break
+		}
+
+		cond := util.SetModOwner(mapstr.MapStr{idField: data.InstID}, kit.SupplierAccount)
+		if err = mongodb.Client().Table(tableName).Update(kit.Ctx, cond, data.Data); err != nil {
+			blog.Errorf("update %s instance %d computed fields %+v failed, err: %v, rid: %s", objID, data.InstID,
+				data.Data, err, kit.Rid)
+			updateErr = kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+			break
+		}
+		auditLogs = append(auditLogs, logs...)
+	}
+
+	// save the audit logs of the instances that are already updated even if the others failed
+	if len(auditLogs) > 0 {
+		if err = m.dependent.CreateAuditLogDependence(kit, auditLogs...); err != nil {
+			blog.Errorf("save %s computed fields audit logs failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+		}
+	}
+
+	return updateErr
+}
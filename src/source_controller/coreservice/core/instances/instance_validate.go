@@ -207,6 +207,11 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
 	}
 
+	// validation rules validate the instance data after the time fields are converted, the same as update
+	if err := valid.validateRules(kit, instanceData); err != nil {
+		return err
+	}
+
 	switch objID {
 	case common.BKInnerObjIDModule:
 		// module instance's name must coincide with template
@@ -242,6 +247,11 @@ func (m *instanceManager) validateCreateInstValue(kit *rest.Kit, objID string, i
 			delete(instanceData, key)
 			continue
 		}
+		// computed field's value is evaluated by the event flow, the value set by user is ignored
+		if property.PropertyType == common.FieldTypeComputed {
+			instanceData[key] = nil
+			continue
+		}
 		if value, ok := val.(string); ok {
 			val = strings.TrimSpace(value)
 			instanceData[key] = val
@@ -382,7 +392,16 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, u
 		return nil
 	}
 
-	return nil
+	// validation rules validate the instance data after it is updated and the time fields are converted
+	newInstData := make(mapstr.MapStr)
+	for k, v := range instanceData {
+		newInstData[k] = v
+	}
+	for k, v := range updateData {
+		newInstData[k] = v
+	}
+
+	return valid.validateRules(kit, newInstData)
 }
 
 func (m *instanceManager) validOneUpdateInstKeyVal(kit *rest.Kit, valid *validator, updateData,
@@ -400,8 +419,9 @@ func (m *instanceManager) validOneUpdateInstKeyVal(kit *rest.Kit, valid *validat
 			continue
 		}
 
-		// right now inner table should be updated as quoted instance, cannot update in source instance
-		if property.PropertyType == common.FieldTypeInnerTable {
+		// right now inner table should be updated as quoted instance, cannot update in source instance,
+		// and computed field's value can only be updated by the event flow
+		if property.PropertyType == common.FieldTypeInnerTable || property.PropertyType == common.FieldTypeComputed {
 			delete(updateData, key)
 			continue
 		}
@@ -29,6 +29,7 @@ type validator struct {
 	require       map[string]bool
 	requireFields []string
 	uniqueAttrs   []metadata.ObjectUnique
+	rules         []*metadata.ParsedValidationRule
 	dependent     OperationDependences
 	objID         string
 	language      language.CCLanguageIf
@@ -75,6 +76,12 @@ func NewValidator(kit *rest.Kit, dependent OperationDependences, objID string, b
 	}
 	valid.uniqueAttrs = uniqueAttrs
 
+	rules, err := searchValidationRules(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+	valid.rules = rules
+
 	return valid, nil
 }
 
@@ -93,6 +100,11 @@ func NewValidators(kit *rest.Kit, dependent OperationDependences, objID string,
 		uniqueAttrs = make([]metadata.ObjectUnique, 0)
 	}
 
+	rules, err := searchValidationRules(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
 	attributes, err := dependent.SelectObjectAttributes(kit, objID, bizIDs)
 	if err != nil {
 		return nil, err
@@ -116,6 +128,7 @@ func NewValidators(kit *rest.Kit, dependent OperationDependences, objID string,
 			require:       make(map[string]bool),
 			requireFields: make([]string, 0),
 			uniqueAttrs:   uniqueAttrs,
+			rules:         rules,
 			objID:         objID,
 			errIf:         kit.CCError,
 			dependent:     dependent,
@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package instances
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// searchValidationRules search the validation rules of the model and parse them, so that the rules are parsed only
+// once for all the instances that are validated by them
+func searchValidationRules(kit *rest.Kit, objID string) ([]*metadata.ParsedValidationRule, error) {
+	cond := mapstr.MapStr{common.BKObjIDField: objID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	rules := make([]metadata.ValidationRule, 0)
+	err := mongodb.Client().Table(common.BKTableNameObjValidationRule).Find(cond).Sort(common.BKFieldID).
+		All(kit.Ctx, &rules)
+	if err != nil {
+		blog.Errorf("search validation rules failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	parsedRules := make([]*metadata.ParsedValidationRule, len(rules))
+	for idx := range rules {
+		parsedRules[idx], err = rules[idx].Parse()
+		if err != nil {
+			blog.Errorf("parse validation rule %d failed, err: %v, rid: %s", rules[idx].ID, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceValidationRuleFailed, rules[idx].Name,
+				rules[idx].Message)
+		}
+	}
+
+	return parsedRules, nil
+}
+
+// validateRules validate the whole instance data with the model validation rules, returns the error with the
+// names and messages of all the rules that the instance violates.
+func (valid *validator) validateRules(kit *rest.Kit, instanceData mapstr.MapStr) error {
+	violated, evalErrs := metadata.CheckValidationRules(valid.rules, instanceData)
+	if len(violated) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(violated))
+	messages := make([]string, len(violated))
+	for idx, rule := range violated {
+		if err, exists := evalErrs[rule.ID]; exists {
+			blog.Errorf("check validation rule %d failed, inst: %+v, err: %v, rid: %s", rule.ID, instanceData, err,
+				kit.Rid)
+		} else {
+			blog.Errorf("instance violates validation rule %d, inst: %+v, rid: %s", rule.ID, instanceData, kit.Rid)
+		}
+		names[idx] = rule.Name
+		messages[idx] = rule.Message
+	}
+
+	return kit.CCError.CCErrorf(common.CCErrCoreServiceValidationRuleFailed, strings.Join(names, ","),
+		strings.Join(messages, "; "))
+}
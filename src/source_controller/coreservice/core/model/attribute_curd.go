@@ -137,7 +137,7 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
 			common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeTimeZone,
-			common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIDRule:
+			common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIDRule, common.FieldTypeComputed:
 			isMultiple := false
 			attribute.IsMultiple = &isMultiple
 		case common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeEnumQuote, common.FieldTypeEnumMulti:
@@ -147,6 +147,13 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 			return 0, kit.CCError.Errorf(common.CCErrCommParamsInvalid, metadata.AttributeFieldPropertyType)
 		}
 	}
+	// 计算字段的值由公式计算得出，不能被用户编辑，也没有默认值
+	if attribute.PropertyType == common.FieldTypeComputed {
+		attribute.IsEditable = false
+		attribute.IsRequired = false
+		attribute.IsOnly = false
+		attribute.Default = nil
+	}
 	// 对于枚举，枚举多选，枚举引用字段, 默认值是放在option中的，需要将default置为nil
 	if attribute.Default != nil && (attribute.PropertyType == common.FieldTypeEnum ||
 		attribute.PropertyType == common.FieldTypeEnumMulti || attribute.PropertyType == common.FieldTypeEnumQuote) {
@@ -498,6 +505,7 @@ var validAttrPropertyTypes = map[string]struct{}{
 	common.FieldTypeList:         {},
 	common.FieldTypeEnumQuote:    {},
 	common.FieldTypeIDRule:       {},
+	common.FieldTypeComputed:     {},
 }
 
 func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadata.Attribute,
@@ -545,7 +553,8 @@ func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadat
 	}
 
 	if attribute.Default != nil && propertyType != common.FieldTypeEnum && propertyType != common.FieldTypeEnumMulti &&
-		propertyType != common.FieldTypeEnumQuote && propertyType != common.FieldTypeIDRule {
+		propertyType != common.FieldTypeEnumQuote && propertyType != common.FieldTypeIDRule &&
+		propertyType != common.FieldTypeComputed {
 
 		if err := m.checkAttributeDefaultValue(kit, attribute, propertyType); err != nil {
 			return err
@@ -1207,7 +1216,7 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attr metadata.Attribute) error
 		return err
 	}
 
-	if attr.PropertyType != common.FieldTypeIDRule {
+	if attr.PropertyType != common.FieldTypeIDRule && attr.PropertyType != common.FieldTypeComputed {
 		return nil
 	}
 
@@ -1230,6 +1239,10 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attr metadata.Attribute) error
 		return err
 	}
 
+	if attr.PropertyType == common.FieldTypeComputed {
+		return nil
+	}
+
 	if err = checkAddIDRule(kit, attr.ObjectID); err != nil {
 		blog.ErrorJSON("check add asset id, err: %s, data: %s, rid: %s", err, attr, kit.Ctx)
 		return err
@@ -1501,7 +1514,7 @@ func checkAttrOption(kit *rest.Kit, data mapstr.MapStr, dbAttributeArr []metadat
 	switch propertyType {
 	case common.FieldTypeEnum, common.FieldTypeEnumMulti:
 		extraOpt = isMultiple
-	case common.FieldTypeIDRule:
+	case common.FieldTypeIDRule, common.FieldTypeComputed:
 		dbAttrs := make([]metadata.Attribute, 0)
 		cond := mapstr.MapStr{common.BKObjIDField: dbAttributeArr[0].ObjectID}
 		util.SetQueryOwner(cond, kit.SupplierAccount)
@@ -1617,6 +1630,14 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 		data.Remove(metadata.AttributeFieldDefault)
 	}
 
+	// 计算字段始终不可编辑、非必填且没有默认值
+	if propertyType == common.FieldTypeComputed {
+		data.Remove(metadata.AttributeFieldIsEditable)
+		data.Remove(metadata.AttributeFieldIsRequired)
+		data.Remove(metadata.AttributeFieldIsOnly)
+		data.Remove(metadata.AttributeFieldDefault)
+	}
+
 	// 删除不可更新字段， 避免由于传入数据，修改字段
 	// TODO: 改成白名单方式
 	data.Remove(metadata.AttributeFieldPropertyID)
@@ -360,7 +360,7 @@ func (m *modelManager) updateSortNumWhenDelete(kit *rest.Kit, delCond map[string
 	return nil
 }
 
-// cascadeDelete 删除模型的字段，分组，唯一校验。模型等。
+// cascadeDelete 删除模型的字段，分组，唯一校验，校验规则，模型版本。模型等。
 func (m *modelManager) cascadeDelete(kit *rest.Kit, objIDs []string) (uint64, error) {
 	delCond := mongo.NewCondition()
 	delCond.Element(mongo.Field(common.BKObjIDField).In(objIDs))
@@ -384,6 +384,21 @@ func (m *modelManager) cascadeDelete(kit *rest.Kit, objIDs []string) (uint64, er
 		return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
 	}
 
+	// delete model validation rule
+	if err := mongodb.Client().Table(common.BKTableNameObjValidationRule).Delete(kit.Ctx, delCondMap); err != nil {
+		blog.Errorf("delete model validation rule error. err: %v, cond: %s, rid: %s", err, delCondMap, kit.Rid)
+		return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
+	}
+
+	// delete model schema draft and versions
+	for _, table := range []string{common.BKTableNameObjSchemaDraft, common.BKTableNameObjSchemaVersion} {
+		if err := mongodb.Client().Table(table).Delete(kit.Ctx, delCondMap); err != nil {
+			blog.Errorf("delete model schema error. table: %s, err: %v, cond: %s, rid: %s", table, err, delCondMap,
+				kit.Rid)
+			return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
+		}
+	}
+
 	if err := m.updateSortNumWhenDelete(kit, delCondMap); err != nil {
 		blog.Errorf("failed to update object sort number when delete object, err: %v, cond: %v, rid: %s", err,
 			delCondMap, kit.Rid)
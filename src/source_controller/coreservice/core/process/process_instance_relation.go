@@ -143,6 +143,12 @@ func (p *processOperation) ListProcessInstanceRelation(kit *rest.Kit, option met
 		filter[common.BKHostIDField] = option.HostID
 	}
 
+	if len(option.HostIDs) > 0 {
+		filter[common.BKHostIDField] = map[string]interface{}{
+			common.BKDBIN: option.HostIDs,
+		}
+	}
+
 	if option.ProcessIDs != nil && len(option.ProcessIDs) > 0 {
 		processIDFilter := map[string]interface{}{
 			common.BKDBIN: option.ProcessIDs,
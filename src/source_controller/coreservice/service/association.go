@@ -329,3 +329,18 @@ func (s *coreService) DeleteInstanceAssociation(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(result)
 }
+
+// UpdateInstanceAssociationAttrs set the attribute values of the instance association
+func (s *coreService) UpdateInstanceAssociationAttrs(ctx *rest.Contexts) {
+	input := new(metadata.UpdateInstAsstAttrsOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AssociationOperation().UpdateInstanceAssociationAttrs(ctx.Kit, input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// CreateHostApplyScopeRule create host apply rule of business, set, set template or host dynamic group scope
+func (s *coreService) CreateHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := metadata.CreateHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, ccErr := s.core.HostApplyRuleOperation().CreateHostApplyScopeRule(ctx.Kit, bizID, option)
+	if ccErr != nil {
+		blog.Errorf("create host apply scope rule failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID, option,
+			ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// UpdateHostApplyScopeRule update the property value of the host apply scope rule
+func (s *coreService) UpdateHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	ruleID, err := strconv.ParseInt(ctx.Request.PathParameter(common.HostApplyRuleIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.HostApplyRuleIDField))
+		return
+	}
+
+	option := metadata.UpdateHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, ccErr := s.core.HostApplyRuleOperation().UpdateHostApplyScopeRule(ctx.Kit, bizID, ruleID, option)
+	if ccErr != nil {
+		blog.Errorf("update host apply scope rule failed, ruleID: %d, option: %+v, err: %v, rid: %s", ruleID, option,
+			ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// DeleteHostApplyScopeRule delete host apply scope rules
+func (s *coreService) DeleteHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := metadata.DeleteHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ccErr := s.core.HostApplyRuleOperation().DeleteHostApplyScopeRule(ctx.Kit, bizID, option)
+	if ccErr != nil {
+		blog.Errorf("delete host apply scope rule failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID, option,
+			ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListHostApplyScopeRule list host apply scope rules
+func (s *coreService) ListHostApplyScopeRule(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := metadata.ListHostApplyScopeRuleOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, ccErr := s.core.HostApplyRuleOperation().ListHostApplyScopeRule(ctx.Kit, bizID, option)
+	if ccErr != nil {
+		blog.Errorf("list host apply scope rule failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID, option,
+			ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// CreateHostLease create an active host lease
+func (s *coreService) CreateHostLease(ctx *rest.Contexts) {
+	lease := new(metadata.HostLease)
+	if err := ctx.DecodeInto(lease); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostOperation().CreateHostLease(ctx.Kit, lease)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// UpdateHostLease update the active host lease
+func (s *coreService) UpdateHostLease(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	opt := new(metadata.UpdateHostLeaseOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, ccErr := s.core.HostOperation().UpdateHostLease(ctx.Kit, id, opt)
+	if ccErr != nil {
+		ctx.RespAutoError(ccErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// SearchHostLease search host leases
+func (s *coreService) SearchHostLease(ctx *rest.Contexts) {
+	opt := new(metadata.SearchHostLeaseOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostOperation().SearchHostLease(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// CreateHostLeaseHistory create host lease histories
+func (s *coreService) CreateHostLeaseHistory(ctx *rest.Contexts) {
+	histories := make([]metadata.HostLeaseHistory, 0)
+	if err := ctx.DecodeInto(&histories); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.HostOperation().CreateHostLeaseHistory(ctx.Kit, histories); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchHostLeaseHistory search host lease histories
+func (s *coreService) SearchHostLeaseHistory(ctx *rest.Contexts) {
+	opt := new(metadata.SearchHostLeaseHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostOperation().SearchHostLeaseHistory(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// UpdateHostLifecycle create or update the host lifecycle definition
+func (s *coreService) UpdateHostLifecycle(ctx *rest.Contexts) {
+	lifecycle := new(metadata.HostLifecycle)
+	if err := ctx.DecodeInto(lifecycle); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.HostOperation().UpdateHostLifecycle(ctx.Kit, lifecycle); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// GetHostLifecycle get the host lifecycle definition
+func (s *coreService) GetHostLifecycle(ctx *rest.Contexts) {
+	lifecycle, err := s.core.HostOperation().GetHostLifecycle(ctx.Kit)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(lifecycle)
+}
+
+// CreateHostLifecycleHistory create host lifecycle transition histories
+func (s *coreService) CreateHostLifecycleHistory(ctx *rest.Contexts) {
+	opt := new(metadata.CreateHostLifecycleHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.HostOperation().CreateHostLifecycleHistory(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// SearchHostLifecycleHistory search host lifecycle transition histories
+func (s *coreService) SearchHostLifecycleHistory(ctx *rest.Contexts) {
+	opt := new(metadata.SearchHostLifecycleHistoryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostOperation().SearchHostLifecycleHistory(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ListStuckHosts list the hosts that stay in a lifecycle state longer than the specified duration
+func (s *coreService) ListStuckHosts(ctx *rest.Contexts) {
+	opt := new(metadata.ListStuckHostOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostOperation().ListStuckHosts(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
@@ -71,3 +71,21 @@ func (s *coreService) QueryLockHost(ctx *rest.Contexts) {
 	result.Data.Count = int64(len(hostLockArr))
 	ctx.RespEntity(result.Data)
 }
+
+// ListHostLock list the active host locks
+func (s *coreService) ListHostLock(ctx *rest.Contexts) {
+	opt := new(metadata.ListHostLockOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostOperation().ListHostLock(ctx.Kit, opt)
+	if err != nil {
+		blog.Errorf("list host locks failed, err: %v, opt: %#v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
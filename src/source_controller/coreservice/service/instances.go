@@ -63,6 +63,23 @@ func (s *coreService) UpdateModelInstances(ctx *rest.Contexts) {
 	ctx.RespEntityWithError(s.core.InstanceOperation().UpdateModelInstance(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
 }
 
+// UpdateComputedFields update the computed fields of the instances with the values evaluated by the event flow
+func (s *coreService) UpdateComputedFields(ctx *rest.Contexts) {
+	input := new(metadata.UpdateComputedFieldsOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	err := s.core.InstanceOperation().UpdateComputedFields(ctx.Kit, ctx.Request.PathParameter(common.BKObjIDField),
+		input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
 // SearchModelInstances TODO
 func (s *coreService) SearchModelInstances(ctx *rest.Contexts) {
 	inputData := metadata.QueryCondition{}
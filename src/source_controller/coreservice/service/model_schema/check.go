@@ -0,0 +1,169 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CheckModelSchemaDraft check if the changes of model schema draft conflict with the existing instances,
+// such as attributes that become required but have no value, or new unique rules that have duplicated values.
+func (s *service) CheckModelSchemaDraft(cts *rest.Contexts) {
+	draft := new(metadata.ModelSchemaDraft)
+	if err := cts.DecodeInto(draft); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := draft.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	result := metadata.ModelSchemaCheckResult{Issues: make([]metadata.ModelSchemaIssue, 0)}
+	for _, change := range draft.Changes {
+		var issue *metadata.ModelSchemaIssue
+		var err error
+
+		switch change.Kind {
+		case metadata.SchemaAttribute:
+			issue, err = s.checkRequiredAttribute(cts.Kit, draft.ObjID, change)
+		case metadata.SchemaUnique:
+			if change.Op != metadata.SchemaChangeCreate {
+				continue
+			}
+			issue, err = s.checkUnique(cts.Kit, draft.ObjID, change.Key)
+		default:
+			continue
+		}
+
+		if err != nil {
+			cts.RespAutoError(err)
+			return
+		}
+
+		if issue != nil {
+			result.Issues = append(result.Issues, *issue)
+		}
+	}
+
+	cts.RespEntity(result)
+}
+
+// checkRequiredAttribute check if there are instances that have no value of the attribute that becomes required
+func (s *service) checkRequiredAttribute(kit *rest.Kit, objID string, change metadata.ModelSchemaChange) (
+	*metadata.ModelSchemaIssue, error) {
+
+	// attribute that is only is also required
+	required := change.Data[common.BKIsRequiredField] == true || change.Data[common.BKIsOnlyField] == true
+
+	switch change.Op {
+	case metadata.SchemaChangeCreate:
+		// the new attribute with default value is filled when the instance is updated, so it is not a conflict
+		if !required || change.Data[common.BKDefaultFiled] != nil {
+			return nil, nil
+		}
+	case metadata.SchemaChangeUpdate:
+		if !required {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	cond := instanceCondition(objID)
+	cond[common.BKDBOR] = []mapstr.MapStr{
+		{change.Key: mapstr.MapStr{common.BKDBExists: false}},
+		{change.Key: nil},
+		{change.Key: ""},
+	}
+
+	tableName := common.GetInstTableName(objID, kit.SupplierAccount)
+	count, err := mongodb.Client().Table(tableName).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count instances without value failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	return &metadata.ModelSchemaIssue{
+		Kind:   metadata.SchemaAttribute,
+		Key:    change.Key,
+		Reason: metadata.SchemaIssueRequiredValueMissing,
+		Count:  count,
+	}, nil
+}
+
+// checkUnique check if there are instances that have duplicated values of the new unique rule
+func (s *service) checkUnique(kit *rest.Kit, objID, key string) (*metadata.ModelSchemaIssue, error) {
+	propertyIDs := strings.Split(key, ",")
+
+	// the instances that have no values of the unique rule is ignored, refer to the unique check of instances.
+	cond := instanceCondition(objID)
+	group := mapstr.MapStr{}
+	for _, propertyID := range propertyIDs {
+		cond[propertyID] = mapstr.MapStr{common.BKDBExists: true, common.BKDBNIN: []interface{}{nil, ""}}
+		group[propertyID] = "$" + propertyID
+	}
+
+	pipeline := []interface{}{
+		mapstr.MapStr{common.BKDBMatch: cond},
+		mapstr.MapStr{common.BKDBGroup: mapstr.MapStr{"_id": group, "total": mapstr.MapStr{common.BKDBSum: 1}}},
+		mapstr.MapStr{common.BKDBMatch: mapstr.MapStr{"total": mapstr.MapStr{common.BKDBGT: 1}}},
+		mapstr.MapStr{common.BKDBCount: "duplicated_count"},
+	}
+
+	result := struct {
+		DuplicatedCount uint64 `bson:"duplicated_count"`
+	}{}
+	tableName := common.GetInstTableName(objID, kit.SupplierAccount)
+	err := mongodb.Client().Table(tableName).AggregateOne(kit.Ctx, pipeline, &result)
+	if err != nil && !mongodb.Client().IsNotFoundError(err) {
+		blog.Errorf("aggregate duplicated instances failed, pipeline: %+v, err: %v, rid: %s", pipeline, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if result.DuplicatedCount == 0 {
+		return nil, nil
+	}
+
+	return &metadata.ModelSchemaIssue{
+		Kind:   metadata.SchemaUnique,
+		Key:    key,
+		Reason: metadata.SchemaIssueUniqueValueDuplicated,
+		Count:  result.DuplicatedCount,
+	}, nil
+}
+
+func instanceCondition(objID string) mapstr.MapStr {
+	cond := mapstr.MapStr{}
+	if common.GetObjByType(objID) == common.BKInnerObjIDObject {
+		cond[common.BKObjIDField] = objID
+	}
+	return cond
+}
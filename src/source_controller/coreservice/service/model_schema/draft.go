@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// ListModelSchemaDraft list model schema drafts.
+func (s *service) ListModelSchemaDraft(cts *rest.Contexts) {
+	opt := new(metadata.CommonQueryOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	filter, err := opt.ToMgo()
+	if err != nil {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	filter = util.SetQueryOwner(filter, cts.Kit.SupplierAccount)
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameObjSchemaDraft).Find(filter).Count(cts.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count model schema drafts failed, err: %v, filter: %+v, rid: %v", err, filter, cts.Kit.Rid)
+			cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+
+		cts.RespEntity(metadata.ModelSchemaDraftInfo{Count: count})
+		return
+	}
+
+	drafts := make([]metadata.ModelSchemaDraft, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjSchemaDraft).Find(filter).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).Fields(opt.Fields...).All(cts.Kit.Ctx, &drafts)
+	if err != nil {
+		blog.Errorf("list model schema drafts failed, err: %v, filter: %+v, rid: %v", err, filter, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	cts.RespEntity(metadata.ModelSchemaDraftInfo{Info: drafts})
+}
+
+// SaveModelSchemaDraft save the changes of model schema draft, the draft is created if it does not exist.
+func (s *service) SaveModelSchemaDraft(cts *rest.Contexts) {
+	draft := new(metadata.ModelSchemaDraft)
+	if err := cts.DecodeInto(draft); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := draft.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: draft.ObjID}
+	cond = util.SetModOwner(cond, cts.Kit.SupplierAccount)
+
+	count, err := mongodb.Client().Table(common.BKTableNameObjSchemaDraft).Find(cond).Count(cts.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count model schema draft failed, err: %v, filter: %+v, rid: %s", err, cond, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	now := time.Now()
+	if count > 0 {
+		updateData := mapstr.MapStr{
+			"changes":            draft.Changes,
+			common.ModifierField: cts.Kit.User,
+			common.LastTimeField: now,
+		}
+
+		err = mongodb.Client().Table(common.BKTableNameObjSchemaDraft).Update(cts.Kit.Ctx, cond, updateData)
+		if err != nil {
+			blog.Errorf("update model schema draft failed, cond: %+v, err: %v, rid: %s", cond, err, cts.Kit.Rid)
+			cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
+			return
+		}
+
+		cts.RespEntity(nil)
+		return
+	}
+
+	id, err := mongodb.Client().NextSequence(cts.Kit.Ctx, common.BKTableNameObjSchemaDraft)
+	if err != nil {
+		blog.Errorf("get sequence id on the table (%s) failed, err: %v, rid: %s",
+			common.BKTableNameObjSchemaDraft, err, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.New(common.CCErrObjectDBOpErrno, err.Error()))
+		return
+	}
+
+	draft.ID = int64(id)
+	draft.OwnerID = cts.Kit.SupplierAccount
+	draft.Creator = cts.Kit.User
+	draft.Modifier = cts.Kit.User
+	draft.CreateTime = &metadata.Time{Time: now}
+	draft.LastTime = &metadata.Time{Time: now}
+
+	if err = mongodb.Client().Table(common.BKTableNameObjSchemaDraft).Insert(cts.Kit.Ctx, draft); err != nil {
+		blog.Errorf("save model schema draft failed, data: %+v, err: %v, rid: %s", draft, err, cts.Kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err)))
+			return
+		}
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	cts.RespEntity(nil)
+}
+
+// DeleteModelSchemaDraft delete model schema drafts.
+func (s *service) DeleteModelSchemaDraft(cts *rest.Contexts) {
+	opt := new(metadata.DeleteOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if len(opt.Condition) == 0 {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "condition"))
+		return
+	}
+
+	cond := util.SetModOwner(opt.Condition, cts.Kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameObjSchemaDraft).Delete(cts.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete model schema draft failed, cond: %+v, err: %v, rid: %s", cond, err, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	cts.RespEntity(nil)
+}
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package modelschema
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// ListModelSchemaVersion list model schema versions.
+func (s *service) ListModelSchemaVersion(cts *rest.Contexts) {
+	opt := new(metadata.CommonQueryOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	filter, err := opt.ToMgo()
+	if err != nil {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	filter = util.SetQueryOwner(filter, cts.Kit.SupplierAccount)
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(filter).Count(cts.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count model schema versions failed, err: %v, filter: %+v, rid: %v", err, filter, cts.Kit.Rid)
+			cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+
+		cts.RespEntity(metadata.ModelSchemaVersionInfo{Count: count})
+		return
+	}
+
+	versions := make([]metadata.ModelSchemaVersion, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(filter).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).Fields(opt.Fields...).All(cts.Kit.Ctx, &versions)
+	if err != nil {
+		blog.Errorf("list model schema versions failed, err: %v, filter: %+v, rid: %v", err, filter, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	cts.RespEntity(metadata.ModelSchemaVersionInfo{Info: versions})
+}
+
+// CreateModelSchemaVersion create model schema version, the version number is the latest version number plus one.
+func (s *service) CreateModelSchemaVersion(cts *rest.Contexts) {
+	version := new(metadata.ModelSchemaVersion)
+	if err := cts.DecodeInto(version); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if len(version.ObjID) == 0 {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKObjIDField))
+		return
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: version.ObjID}
+	cond = util.SetQueryOwner(cond, cts.Kit.SupplierAccount)
+
+	latest := make([]metadata.ModelSchemaVersion, 0)
+	err := mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(cond).Sort("-version").Limit(1).
+		Fields("version").All(cts.Kit.Ctx, &latest)
+	if err != nil {
+		blog.Errorf("get latest model schema version failed, cond: %+v, err: %v, rid: %s", cond, err, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	version.Version = 1
+	if len(latest) > 0 {
+		version.Version = latest[0].Version + 1
+	}
+
+	id, err := mongodb.Client().NextSequence(cts.Kit.Ctx, common.BKTableNameObjSchemaVersion)
+	if err != nil {
+		blog.Errorf("get sequence id on the table (%s) failed, err: %v, rid: %s",
+			common.BKTableNameObjSchemaVersion, err, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.New(common.CCErrObjectDBOpErrno, err.Error()))
+		return
+	}
+
+	version.ID = int64(id)
+	version.OwnerID = cts.Kit.SupplierAccount
+	version.Creator = cts.Kit.User
+	version.CreateTime = &metadata.Time{Time: time.Now()}
+
+	if err = mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Insert(cts.Kit.Ctx, version); err != nil {
+		blog.Errorf("save model schema version failed, data: %+v, err: %v, rid: %s", version, err, cts.Kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err)))
+			return
+		}
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	cts.RespEntity(version)
+}
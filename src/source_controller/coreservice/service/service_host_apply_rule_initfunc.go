@@ -46,5 +46,16 @@ func (s *coreService) initHostApplyRule(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/service_templates/host_apply_rule_related",
 		Handler: s.SearchRuleRelatedServiceTemplates})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/host_apply_scope_rule/bk_biz_id/{bk_biz_id}",
+		Handler: s.CreateHostApplyScopeRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path:    "/update/host_apply_scope_rule/{host_apply_rule_id}/bk_biz_id/{bk_biz_id}",
+		Handler: s.UpdateHostApplyScopeRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete,
+		Path:    "/deletemany/host_apply_scope_rule/bk_biz_id/{bk_biz_id}",
+		Handler: s.DeleteHostApplyScopeRule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_apply_scope_rule/bk_biz_id/{bk_biz_id}",
+		Handler: s.ListHostApplyScopeRule})
+
 	utility.AddToRestfulWebService(web)
 }
@@ -21,6 +21,8 @@ import (
 	"configcenter/src/source_controller/coreservice/service/id_rule"
 	"configcenter/src/source_controller/coreservice/service/kube"
 	modelquote "configcenter/src/source_controller/coreservice/service/model_quote"
+	modelschema "configcenter/src/source_controller/coreservice/service/model_schema"
+	validationrule "configcenter/src/source_controller/coreservice/service/validation_rule"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -158,6 +160,8 @@ func (s *coreService) initModelInstances(web *restful.WebService) {
 		Handler: s.BatchCreateModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/model/{bk_obj_id}/instance",
 		Handler: s.UpdateModelInstances})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/model/{bk_obj_id}/instance/computed_fields",
+		Handler: s.UpdateComputedFields})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/instances",
 		Handler: s.SearchModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/count/model/{bk_obj_id}/instances",
@@ -238,6 +242,8 @@ func (s *coreService) initInstanceAssociation(web *restful.WebService) {
 		Handler: s.CountInstanceAssociations})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/instanceassociation",
 		Handler: s.DeleteInstanceAssociation})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/instanceassociation/attrs",
+		Handler: s.UpdateInstanceAssociationAttrs})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -285,6 +291,33 @@ func (s *coreService) host(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host/lock", Handler: s.LockHost})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/host/lock", Handler: s.UnlockHost})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host/lock/search", Handler: s.QueryLockHost})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/list/host/lock", Handler: s.ListHostLock})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host_lifecycle/definition",
+		Handler: s.UpdateHostLifecycle})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host_lifecycle/definition",
+		Handler: s.GetHostLifecycle})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/host_lifecycle/history",
+		Handler: s.CreateHostLifecycleHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lifecycle/history",
+		Handler: s.SearchHostLifecycleHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lifecycle/stuck_host",
+		Handler: s.ListStuckHosts})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/host_snap/history",
+		Handler: s.CreateHostSnapHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_snap/history",
+		Handler: s.SearchHostSnapHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_snap/changed_host",
+		Handler: s.ListHostSnapChangedHosts})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/host_lease", Handler: s.CreateHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host_lease/{id}", Handler: s.UpdateHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lease", Handler: s.SearchHostLease})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/host_lease/history",
+		Handler: s.CreateHostLeaseHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_lease/history",
+		Handler: s.SearchHostLeaseHistory})
 
 	// dynamic grouping handlers.
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/dynamicgroup", Handler: s.CreateDynamicGroup})
@@ -587,6 +620,8 @@ func (s *coreService) initService(web *restful.WebService) {
 	s.initModelQuote(web)
 	fieldtmpl.InitFieldTemplate(c)
 	idrule.InitIDRule(c)
+	validationrule.InitValidationRule(c)
+	modelschema.InitModelSchema(c)
 
 	c.Utility.AddToRestfulWebService(web)
 }
@@ -87,5 +87,15 @@ func (s *coreService) initProcess(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/service_template/attribute",
 		Handler: s.ListServiceTemplateAttribute})
 
+	// service template and set template versions
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/template_version",
+		Handler: s.CreateTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/template_version",
+		Handler: s.ListTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/template_version/pin",
+		Handler: s.PinTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/template_version/pin",
+		Handler: s.ListTemplateVersionPin})
+
 	utility.AddToRestfulWebService(web)
 }
@@ -0,0 +1,245 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CreateTemplateVersion create the next version of the service template or the set template
+func (s *coreService) CreateTemplateVersion(ctx *rest.Contexts) {
+	version := new(metadata.TemplateVersionInfo)
+	if err := ctx.DecodeInto(version); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := version.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	kit := ctx.Kit
+	latestCond := util.SetQueryOwner(mapstr.MapStr{
+		"template_type": version.TemplateType,
+		"template_id":   version.TemplateID,
+	}, kit.SupplierAccount)
+	latest := make([]metadata.TemplateVersionInfo, 0)
+	err := mongodb.Client().Table(common.BKTableNameTemplateVersion).Find(latestCond).Fields("version").
+		Sort("-version").Limit(1).All(kit.Ctx, &latest)
+	if err != nil {
+		blog.Errorf("get latest template version failed, err: %v, cond: %#v, rid: %s", err, latestCond, kit.Rid)
+		ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	id, err := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameTemplateVersion)
+	if err != nil {
+		blog.Errorf("generate template version id failed, err: %v, rid: %s", err, kit.Rid)
+		ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed))
+		return
+	}
+
+	version.ID = int64(id)
+	version.Version = 1
+	if len(latest) > 0 {
+		version.Version = latest[0].Version + 1
+	}
+	version.OwnerID = kit.SupplierAccount
+	version.Creator = kit.User
+	version.CreateTime = metadata.Now()
+
+	// the unique index of the template version prevents the same version from being created concurrently
+	if err := mongodb.Client().Table(common.BKTableNameTemplateVersion).Insert(kit.Ctx, version); err != nil {
+		blog.Errorf("create template version failed, err: %v, version: %#v, rid: %s", err, version, kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			ctx.RespAutoError(kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "version"))
+			return
+		}
+		ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	ctx.RespEntity(version)
+}
+
+// ListTemplateVersion list the versions of the service template or the set template
+func (s *coreService) ListTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.ListTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	kit := ctx.Kit
+	cond := util.SetQueryOwner(mapstr.MapStr{
+		common.BKAppIDField: opt.BizID,
+		"template_type":     opt.TemplateType,
+		"template_id":       opt.TemplateID,
+	}, kit.SupplierAccount)
+	if len(opt.Versions) > 0 {
+		cond["version"] = mapstr.MapStr{common.BKDBIN: opt.Versions}
+	}
+
+	table := mongodb.Client().Table(common.BKTableNameTemplateVersion)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count template versions failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+		ctx.RespEntity(&metadata.ListTemplateVersionResult{Count: count})
+		return
+	}
+
+	if len(opt.Page.Sort) == 0 {
+		opt.Page.Sort = "-version"
+	}
+
+	versions := make([]metadata.TemplateVersionInfo, 0)
+	err := table.Find(cond).Fields(opt.Fields...).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).
+		Sort(opt.Page.Sort).All(kit.Ctx, &versions)
+	if err != nil {
+		blog.Errorf("list template versions failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(&metadata.ListTemplateVersionResult{Info: versions})
+}
+
+// PinTemplateVersion record the template version that the modules or the sets are synchronized to
+func (s *coreService) PinTemplateVersion(ctx *rest.Contexts) {
+	opt := new(metadata.PinTemplateVersionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	kit := ctx.Kit
+	instIDs := util.IntArrayUnique(opt.InstIDs)
+	cond := util.SetQueryOwner(mapstr.MapStr{
+		"template_type":      opt.TemplateType,
+		common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+	}, kit.SupplierAccount)
+
+	table := mongodb.Client().Table(common.BKTableNameTemplateVersionPin)
+	existPins := make([]metadata.TemplateVersionPin, 0)
+	if err := table.Find(cond).Fields(common.BKInstIDField).All(kit.Ctx, &existPins); err != nil {
+		blog.Errorf("get template version pins failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	now := metadata.Now()
+	if len(existPins) > 0 {
+		doc := mapstr.MapStr{
+			common.BKAppIDField:  opt.BizID,
+			"template_id":        opt.TemplateID,
+			"version":            opt.Version,
+			common.ModifierField: kit.User,
+			common.LastTimeField: now,
+		}
+		if _, err := table.UpdateMany(kit.Ctx, cond, doc); err != nil {
+			blog.Errorf("update template version pins failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
+			return
+		}
+	}
+
+	existMap := make(map[int64]struct{})
+	for _, pin := range existPins {
+		existMap[pin.InstID] = struct{}{}
+	}
+
+	pins := make([]metadata.TemplateVersionPin, 0)
+	for _, instID := range instIDs {
+		if _, exists := existMap[instID]; exists {
+			continue
+		}
+		pins = append(pins, metadata.TemplateVersionPin{
+			BizID:        opt.BizID,
+			TemplateType: opt.TemplateType,
+			TemplateID:   opt.TemplateID,
+			InstID:       instID,
+			Version:      opt.Version,
+			OwnerID:      kit.SupplierAccount,
+			Modifier:     kit.User,
+			LastTime:     now,
+		})
+	}
+
+	if len(pins) > 0 {
+		if err := table.Insert(kit.Ctx, pins); err != nil {
+			blog.Errorf("create template version pins failed, err: %v, pins: %#v, rid: %s", err, pins, kit.Rid)
+			ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+			return
+		}
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListTemplateVersionPin list the template versions that the modules or the sets are synchronized to
+func (s *coreService) ListTemplateVersionPin(ctx *rest.Contexts) {
+	opt := new(metadata.ListTemplateVersionPinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	kit := ctx.Kit
+	cond := util.SetQueryOwner(mapstr.MapStr{
+		common.BKAppIDField: opt.BizID,
+		"template_type":     opt.TemplateType,
+	}, kit.SupplierAccount)
+	if opt.TemplateID > 0 {
+		cond["template_id"] = opt.TemplateID
+	}
+	if len(opt.InstIDs) > 0 {
+		cond[common.BKInstIDField] = mapstr.MapStr{common.BKDBIN: opt.InstIDs}
+	}
+
+	pins := make([]metadata.TemplateVersionPin, 0)
+	err := mongodb.Client().Table(common.BKTableNameTemplateVersionPin).Find(cond).Sort(common.BKInstIDField).
+		All(kit.Ctx, &pins)
+	if err != nil {
+		blog.Errorf("list template version pins failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		ctx.RespAutoError(kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(pins)
+}
@@ -0,0 +1,284 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package validationrule
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// ListValidationRule list model validation rules.
+func (s *service) ListValidationRule(cts *rest.Contexts) {
+	opt := new(metadata.CommonQueryOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	filter, err := opt.ToMgo()
+	if err != nil {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	filter = util.SetQueryOwner(filter, cts.Kit.SupplierAccount)
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameObjValidationRule).Find(filter).Count(cts.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count validation rules failed, err: %v, filter: %+v, rid: %v", err, filter, cts.Kit.Rid)
+			cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+
+		cts.RespEntity(metadata.ValidationRuleInfo{Count: count})
+		return
+	}
+
+	rules := make([]metadata.ValidationRule, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjValidationRule).Find(filter).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).Fields(opt.Fields...).All(cts.Kit.Ctx, &rules)
+	if err != nil {
+		blog.Errorf("list validation rules failed, err: %v, filter: %+v, rid: %v", err, filter, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	cts.RespEntity(metadata.ValidationRuleInfo{Info: rules})
+}
+
+// CreateValidationRule create model validation rule.
+func (s *service) CreateValidationRule(cts *rest.Contexts) {
+	rule := new(metadata.ValidationRule)
+	if err := cts.DecodeInto(rule); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := rule.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	if err := s.validateRuleFields(cts.Kit, rule); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	countCond := mapstr.MapStr{common.BKObjIDField: rule.ObjID}
+	countCond = util.SetQueryOwner(countCond, cts.Kit.SupplierAccount)
+	count, err := mongodb.Client().Table(common.BKTableNameObjValidationRule).Find(countCond).Count(cts.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count validation rules failed, err: %v, filter: %+v, rid: %s", err, countCond, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	if count >= metadata.ValidationRuleMaxNum {
+		blog.Errorf("validation rule exceeds the maximum number limit, count: %d, rid: %s", count, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommXXExceedLimit, "validation rule",
+			metadata.ValidationRuleMaxNum))
+		return
+	}
+
+	id, err := mongodb.Client().NextSequence(cts.Kit.Ctx, common.BKTableNameObjValidationRule)
+	if err != nil {
+		blog.Errorf("get sequence id on the table (%s) failed, err: %v, rid: %s",
+			common.BKTableNameObjValidationRule, err, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.New(common.CCErrObjectDBOpErrno, err.Error()))
+		return
+	}
+
+	rule.ID = int64(id)
+	rule.OwnerID = cts.Kit.SupplierAccount
+	rule.Creator = cts.Kit.User
+	rule.Modifier = cts.Kit.User
+	now := time.Now()
+	rule.CreateTime = &metadata.Time{Time: now}
+	rule.LastTime = &metadata.Time{Time: now}
+
+	if err = mongodb.Client().Table(common.BKTableNameObjValidationRule).Insert(cts.Kit.Ctx, rule); err != nil {
+		blog.Errorf("save validation rule failed, data: %+v, err: %v, rid: %s", rule, err, cts.Kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err)))
+			return
+		}
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	cts.RespEntity(metadata.RspID{ID: rule.ID})
+}
+
+// UpdateValidationRule update model validation rule.
+func (s *service) UpdateValidationRule(cts *rest.Contexts) {
+	rule := new(metadata.ValidationRule)
+	if err := cts.DecodeInto(rule); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rule.ID == 0 {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKFieldID))
+		return
+	}
+
+	if rawErr := rule.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:    rule.ID,
+		common.BKObjIDField: rule.ObjID,
+	}
+	cond = util.SetModOwner(cond, cts.Kit.SupplierAccount)
+
+	count, err := mongodb.Client().Table(common.BKTableNameObjValidationRule).Find(cond).Count(cts.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count validation rule failed, err: %v, filter: %+v, rid: %s", err, cond, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	if count == 0 {
+		blog.Errorf("validation rule not found, cond: %+v, rid: %s", cond, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommNotFound, "validation rule"))
+		return
+	}
+
+	if err := s.validateRuleFields(cts.Kit, rule); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	updateData := mapstr.MapStr{
+		common.BKFieldName:                     rule.Name,
+		metadata.ValidationRuleConditionField:  rule.Condition,
+		metadata.ValidationRuleExpressionField: rule.Expression,
+		metadata.ValidationRuleMessageField:    rule.Message,
+		common.ModifierField:                   cts.Kit.User,
+		common.LastTimeField:                   time.Now(),
+	}
+
+	err = mongodb.Client().Table(common.BKTableNameObjValidationRule).Update(cts.Kit.Ctx, cond, updateData)
+	if err != nil {
+		blog.Errorf("update validation rule failed, cond: %+v, data: %+v, err: %v, rid: %s", cond, updateData, err,
+			cts.Kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err)))
+			return
+		}
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
+		return
+	}
+
+	cts.RespEntity(nil)
+}
+
+// DeleteValidationRule delete model validation rules.
+func (s *service) DeleteValidationRule(cts *rest.Contexts) {
+	opt := new(metadata.DeleteOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if len(opt.Condition) == 0 {
+		cts.RespAutoError(cts.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "condition"))
+		return
+	}
+
+	cond := util.SetModOwner(opt.Condition, cts.Kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameObjValidationRule).Delete(cts.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete validation rule failed, cond: %+v, err: %v, rid: %s", cond, err, cts.Kit.Rid)
+		cts.RespAutoError(cts.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	cts.RespEntity(nil)
+}
+
+// validateRuleFields validate that the fields referenced by the validation rule are the attributes of the model
+func (s *service) validateRuleFields(kit *rest.Kit, rule *metadata.ValidationRule) error {
+	fields, err := rule.Fields()
+	if err != nil {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: rule.ObjID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	objCnt, err := mongodb.Client().Table(common.BKTableNameObjDes).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count object failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if objCnt == 0 {
+		blog.Errorf("object %s not exists, rid: %s", rule.ObjID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+	}
+
+	fields = util.StrArrayUnique(fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	cond[common.BKPropertyIDField] = mapstr.MapStr{common.BKDBIN: fields}
+	attrs := make([]metadata.Attribute, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKPropertyIDField, common.BKPropertyTypeField).All(kit.Ctx, &attrs)
+	if err != nil {
+		blog.Errorf("find object attributes failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	attrTypeMap := make(map[string]string)
+	for _, attr := range attrs {
+		attrTypeMap[attr.PropertyID] = attr.PropertyType
+	}
+
+	for _, field := range fields {
+		propertyType, exists := attrTypeMap[field]
+		if !exists {
+			blog.Errorf("validation rule field %s is not the attribute of %s, rid: %s", field, rule.ObjID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, field)
+		}
+
+		// inner table value is not stored in the instance, and computed value is evaluated after the instance is
+		// saved, so they can not be validated.
+		if propertyType == common.FieldTypeInnerTable || propertyType == common.FieldTypeComputed {
+			blog.Errorf("validation rule can not reference %s field %s, rid: %s", propertyType, field, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, field)
+		}
+	}
+
+	return nil
+}
@@ -354,6 +354,9 @@ type UpdateDescription struct {
 type EventInfo struct {
 	UpdatedFields map[string]interface{} `json:"update_fields,omitempty"`
 	RemovedFields []string               `json:"deleted_fields,omitempty"`
+	// PreviousFields is the values of the updated and removed fields before the update, the field that did not exist
+	// before is not in it. it is nil if the previous values are unknown
+	PreviousFields map[string]interface{} `json:"previous_fields,omitempty"`
 }
 
 // EventDetail event document detail and changed fields
@@ -23,6 +23,7 @@ import (
 	"net/http"
 
 	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/thirdparty/apigw/apigwutil"
 )
 
 // GetCurAnn get current announcements
@@ -72,3 +73,28 @@ func (n *notice) RegApp(ctx context.Context, h http.Header) (*RegAppData, error)
 
 	return resp.Data, nil
 }
+
+// SendMsg send message to the users
+func (n *notice) SendMsg(ctx context.Context, h http.Header, msg *SendMsgReq) error {
+	resp := new(apigwutil.ApiGWBaseResponse)
+	subPath := "/apigw/v1/message/send_message"
+	msg.Platform = n.service.Config.AppCode
+
+	err := n.service.Client.Post().
+		WithContext(ctx).
+		Body(msg).
+		SubResourcef(subPath).
+		WithHeaders(httpheader.SetBkAuth(h, n.service.Auth)).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.Code != 0 {
+		return fmt.Errorf("code: %d, message: %s", resp.Code, resp.Message)
+	}
+
+	return nil
+}
@@ -54,3 +54,11 @@ type RegAppData struct {
 	Code string `json:"code"`
 	Name string `json:"name"`
 }
+
+// SendMsgReq the request of sending message to the users
+type SendMsgReq struct {
+	Platform  string   `json:"platform"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Receivers []string `json:"receivers"`
+}
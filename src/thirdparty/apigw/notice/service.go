@@ -28,6 +28,7 @@ import (
 type ClientI interface {
 	GetCurAnn(ctx context.Context, h http.Header, params map[string]string) ([]CurAnnData, error)
 	RegApp(ctx context.Context, h http.Header) (*RegAppData, error)
+	SendMsg(ctx context.Context, h http.Header, msg *SendMsgReq) error
 }
 
 type notice struct {
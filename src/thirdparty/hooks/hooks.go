@@ -1,2 +1,4 @@
-// Package hooks TODO
+// Package hooks defines the compile-time hooks of the scene servers and core service, the resource instance changes
+// in core service can also be validated or mutated by the admission webhooks configured in coreService.admission
+// without forking these hooks.
 package hooks
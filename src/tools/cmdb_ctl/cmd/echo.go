@@ -20,8 +20,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"time"
 
+	"configcenter/src/common/watch"
+
 	"github.com/spf13/cobra"
 )
 
@@ -32,6 +35,8 @@ func init() {
 type echo struct {
 	url        string
 	jsonPretty bool
+	// secret is the secret of the event subscription, used to verify the signature of the received data
+	secret string
 }
 
 // NewEchoCommand TODO
@@ -54,6 +59,8 @@ func NewEchoCommand() *cobra.Command {
 func (c *echo) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&c.url, "url", "", "the url of the echo server, eg: http://127.0.0.1:80/echo")
 	cmd.Flags().BoolVar(&c.jsonPretty, "pretty", false, "json indent the received data if it's json format.")
+	cmd.Flags().StringVar(&c.secret, "secret", "",
+		"the secret of the event subscription, if set, the signature of the received data is verified.")
 }
 
 func runEchoServer(c *echo) error {
@@ -76,6 +83,14 @@ func (c *echo) echoServer(w http.ResponseWriter, r *http.Request) {
 	}
 	fmt.Fprintf(os.Stdout, "%c[1;40;31m>> received new data, time: %s %c[0m\n", 0x1B, time.Now().Format(time.RFC3339),
 		0x1B)
+
+	if len(c.secret) > 0 && !c.verifySignature(r, s) {
+		fmt.Fprintf(os.Stderr, "verify signature of the received data failed, delivery id: %s\n",
+			r.Header.Get(watch.SubscriptionDeliveryHeader))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	if c.jsonPretty {
 		var prettyJSON bytes.Buffer
 		if err := json.Indent(&prettyJSON, s, "", "    "); err != nil {
@@ -88,3 +103,14 @@ func (c *echo) echoServer(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Fprintf(os.Stdout, "%s\n\n", s)
 }
+
+// verifySignature verify the signature of the data pushed by the event subscription
+func (c *echo) verifySignature(r *http.Request, body []byte) bool {
+	timestamp, err := strconv.ParseInt(r.Header.Get(watch.SubscriptionTimestampHeader), 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse timestamp header failed. error: %v\n", err)
+		return false
+	}
+
+	return watch.VerifySubscriptionPayload(c.secret, timestamp, body, r.Header.Get(watch.SubscriptionSignatureHeader))
+}
@@ -0,0 +1,197 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"configcenter/src/common"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+	"configcenter/src/common/util"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewModelCommand())
+}
+
+type modelConf struct {
+	file            string
+	prune           bool
+	user            string
+	supplierAccount string
+}
+
+func (c *modelConf) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&c.file, "file", "", "the path of the model definition yaml file")
+	cmd.PersistentFlags().BoolVar(&c.prune, "prune", false, "delete the attributes, groups, unique rules and "+
+		"associations of the defined models that are not in the definition, overrides the prune in the file")
+	cmd.PersistentFlags().StringVar(&c.user, "user", "cmdb_tool", "the user who applies the model definition")
+	cmd.PersistentFlags().StringVar(&c.supplierAccount, "supplier-account", common.BKDefaultOwnerID,
+		"the supplier account of the models")
+}
+
+// NewModelCommand new model definition plan and apply command
+func NewModelCommand() *cobra.Command {
+	conf := new(modelConf)
+
+	cmd := &cobra.Command{
+		Use:   "model",
+		Short: "converge the models to the model definition yaml file",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "plan",
+		Short: "show the changes that converge the models to the model definition",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelDefinition(conf, "plan", cmd.Flags().Changed("prune"))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "apply",
+		Short: "apply the changes that converge the models to the model definition",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelDefinition(conf, "apply", cmd.Flags().Changed("prune"))
+		},
+	})
+
+	conf.addFlags(cmd)
+	return cmd
+}
+
+func runModelDefinition(c *modelConf, action string, overridePrune bool) error {
+	if c.file == "" {
+		return fmt.Errorf("file must be set")
+	}
+
+	content, err := ioutil.ReadFile(c.file)
+	if err != nil {
+		return fmt.Errorf("read model definition file failed, err: %v", err)
+	}
+
+	def := new(metadata.ModelDefinition)
+	if err := yaml.Unmarshal(content, def); err != nil {
+		return fmt.Errorf("parse model definition file failed, err: %v", err)
+	}
+
+	if overridePrune {
+		def.Prune = c.prune
+	}
+
+	server, err := getTopoServer()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("http://%s/topo/v3/%s/model/definition", server, action)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	rid := util.GenerateRID()
+	req.Header = headerutil.GenCommonHeader(c.user, c.supplierAccount, rid)
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := new(metadata.ModelDefinitionPlanResp)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response failed, http status: %s, err: %v", resp.Status, err)
+	}
+
+	if !result.Result {
+		return fmt.Errorf("%s model definition failed, code: %d, err: %s, rid: %s", action, result.Code,
+			result.ErrMsg, rid)
+	}
+
+	printModelDefinitionChanges(result.Data.Changes)
+	return nil
+}
+
+// getTopoServer get the address of one topo server from zookeeper
+func getTopoServer() (string, error) {
+	zk, err := config.NewZkService(config.Conf.ZkAddr, &config.Conf.ZkTLS)
+	if err != nil {
+		return "", fmt.Errorf("new zk client failed, err: %v", err)
+	}
+
+	path := types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_TOPO
+	children, err := zk.ZkCli.GetChildren(path)
+	if err != nil {
+		return "", fmt.Errorf("get topo server failed, err: %v", err)
+	}
+
+	for _, child := range children {
+		node, err := zk.ZkCli.Get(path + "/" + child)
+		if err != nil {
+			return "", err
+		}
+		svr := new(types.TopoServInfo)
+		if err := json.Unmarshal([]byte(node), svr); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:%d", svr.RegisterIP, svr.Port), nil
+	}
+
+	return "", fmt.Errorf("no topo server")
+}
+
+func printModelDefinitionChanges(changes []metadata.ModelDefinitionChange) {
+	if len(changes) == 0 {
+		fmt.Println("no changes, the models are up to date with the definition")
+		return
+	}
+
+	symbols := map[metadata.SchemaChangeOp]string{
+		metadata.SchemaChangeCreate: "+",
+		metadata.SchemaChangeUpdate: "~",
+		metadata.SchemaChangeDelete: "-",
+	}
+	counts := make(map[metadata.SchemaChangeOp]int)
+	for _, change := range changes {
+		counts[change.Op]++
+		name := change.Key
+		if change.ObjID != "" {
+			name = change.ObjID + "." + change.Key
+		}
+		fmt.Printf("%s %s %s\n", symbols[change.Op], change.Kind, name)
+		if len(change.Data) > 0 {
+			js, _ := json.Marshal(change.Data)
+			fmt.Printf("    %s\n", js)
+		}
+	}
+
+	fmt.Printf("\n%d to create, %d to update, %d to delete\n", counts[metadata.SchemaChangeCreate],
+		counts[metadata.SchemaChangeUpdate], counts[metadata.SchemaChangeDelete])
+}
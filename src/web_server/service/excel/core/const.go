@@ -69,6 +69,9 @@ const (
 	// AsstDstInstColIdx excel关联关系sheet「目标实例」所在列位置
 	AsstDstInstColIdx = 4
 
+	// AsstAttrColIdx excel关联关系sheet「关联属性值」所在列位置
+	AsstAttrColIdx = 5
+
 	// AsstDataRowIdx excel关联关系sheet数据开始的位置
 	AsstDataRowIdx = 2
 )
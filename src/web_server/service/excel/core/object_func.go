@@ -53,6 +53,11 @@ func (d *Client) GetSortedColProp(kit *rest.Kit, cond mapstr.MapStr) ([]ColProp,
 		if util.InStrArr(filterPropID, colProps[idx].ID) {
 			colProps[idx].NotExport = true
 		}
+
+		// computed field's value is evaluated by cmdb, it is exported but can not be imported
+		if colProps[idx].PropertyType == common.FieldTypeComputed {
+			colProps[idx].NotEditable = true
+		}
 	}
 
 	bizID, err := cond.Int64(common.BKAppIDField)
@@ -18,6 +18,8 @@
 package exporter
 
 import (
+	"encoding/json"
+
 	"configcenter/pkg/excel"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -432,11 +434,22 @@ func (e *Exporter) getInstAsst(instIDs []int64) ([][]excel.Cell, error) {
 	// 4. 构造需要写到excel的关联关系数据
 	result := make([][]excel.Cell, len(asstData))
 	for idx, data := range asstData {
-		row := make([]excel.Cell, core.AsstDstInstColIdx+1)
+		row := make([]excel.Cell, core.AsstAttrColIdx+1)
 		row[core.AsstIDColIdx] = excel.Cell{Value: data.asstID}
 		row[core.AsstSrcInstColIdx] = excel.Cell{Value: data.srcInst}
 		row[core.AsstDstInstColIdx] = excel.Cell{Value: data.destInst}
 
+		// 关联属性值以json格式导出，导入时按照同样的格式解析
+		if len(data.attrs) != 0 {
+			attrs, err := json.Marshal(data.attrs)
+			if err != nil {
+				blog.Errorf("marshal association attributes failed, attrs: %v, err: %v, rid: %s", data.attrs, err,
+					e.GetKit().Rid)
+				return nil, err
+			}
+			row[core.AsstAttrColIdx] = excel.Cell{Value: string(attrs)}
+		}
+
 		result[idx] = row
 	}
 
@@ -447,6 +460,7 @@ type instAsstData struct {
 	asstID   string
 	srcInst  string
 	destInst string
+	attrs    mapstr.MapStr
 }
 
 func (e *Exporter) getInstAsstData(instAsstArr []*metadata.InstAsst) ([]instAsstData, error) {
@@ -511,7 +525,8 @@ func (e *Exporter) getInstAsstData(instAsstArr []*metadata.InstAsst) ([]instAsst
 				continue
 			}
 
-			result = append(result, instAsstData{asstID: instAsst.ObjectAsstID, srcInst: srcInst, destInst: dstInst})
+			result = append(result, instAsstData{asstID: instAsst.ObjectAsstID, srcInst: srcInst, destInst: dstInst,
+				attrs: instAsst.Attributes})
 			continue
 		}
 
@@ -535,7 +550,8 @@ func (e *Exporter) getInstAsstData(instAsstArr []*metadata.InstAsst) ([]instAsst
 			continue
 		}
 
-		result = append(result, instAsstData{asstID: instAsst.ObjectAsstID, srcInst: srcInst, destInst: dstInst})
+		result = append(result, instAsstData{asstID: instAsst.ObjectAsstID, srcInst: srcInst, destInst: dstInst,
+			attrs: instAsst.Attributes})
 	}
 
 	return result, nil
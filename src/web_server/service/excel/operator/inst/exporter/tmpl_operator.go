@@ -277,11 +277,13 @@ const (
 	asstThirdColWidth  = 12
 	asstFourthColWidth = 80
 	asstFifthColWidth  = 80
+	asstSixthColWidth  = 80
 )
 
 func (t *TmplOp) setAsstColWidth() error {
 	colWidths := []float64{
 		asstFirstColWidth, asstSecondColWidth, asstThirdColWidth, asstFourthColWidth, asstFifthColWidth,
+		asstSixthColWidth,
 	}
 	for idx, width := range colWidths {
 		if err := t.GetExcel().SetColWidth(core.AsstSheet, idx+1, idx+1, width); err != nil {
@@ -321,7 +323,8 @@ func (t *TmplOp) writeAsstHeader() error {
 		return err
 	}
 	firstRowFields := []string{lang.Language("excel_association_object_id"), lang.Language("excel_association_op"),
-		lang.Language("excel_association_src_inst"), lang.Language("excel_association_dst_inst")}
+		lang.Language("excel_association_src_inst"), lang.Language("excel_association_dst_inst"),
+		lang.Language("excel_association_attrs")}
 	for idx := range firstRowFields {
 		header[core.AsstStartRowIdx] = append(header[core.AsstStartRowIdx],
 			excel.Cell{StyleID: firstRowStyle, Value: firstRowFields[idx]})
@@ -333,7 +336,8 @@ func (t *TmplOp) writeAsstHeader() error {
 		return err
 	}
 	exampleFields := []string{lang.Language("excel_example_association"), lang.Language("excel_example_op"),
-		lang.Language("excel_example_association_src_inst"), lang.Language("excel_example_association_dst_inst")}
+		lang.Language("excel_example_association_src_inst"), lang.Language("excel_example_association_dst_inst"),
+		lang.Language("excel_example_association_attrs")}
 	for idx := range exampleFields {
 		header[core.AsstExampleRowIdx] = append(header[core.AsstExampleRowIdx],
 			excel.Cell{StyleID: exampleStyle, Value: exampleFields[idx]})
@@ -18,7 +18,9 @@
 package importer
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"configcenter/pkg/excel"
 	"configcenter/src/common"
@@ -219,6 +221,18 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 			continue
 		}
 
+		// 关联属性值为json格式，可以不填写
+		var attrs mapstr.MapStr
+		if len(row) > core.AsstAttrColIdx && strings.TrimSpace(row[core.AsstAttrColIdx]) != "" {
+			if err := json.Unmarshal([]byte(row[core.AsstAttrColIdx]), &attrs); err != nil {
+				blog.Errorf("unmarshal association attributes failed, val: %s, err: %v, rid: %s",
+					row[core.AsstAttrColIdx], err, i.GetKit().Rid)
+				msg := lang.Languagef("import_association_attrs_format_error", idx)
+				errMsg = append(errMsg, metadata.RowMsgData{Row: idx, Msg: msg})
+				continue
+			}
+		}
+
 		statisticalInfo, ok := statisticalMap[asstID]
 		if !ok {
 			asstIDs = append(asstIDs, asstID)
@@ -240,6 +254,7 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 			Operate:      operate,
 			SrcPrimary:   srcInst,
 			DstPrimary:   dstInst,
+			Attributes:   attrs,
 		}
 	}
 